@@ -40,11 +40,22 @@ var downloadMetric = prometheus.NewCounterVec(
 	[]string{"cluster", "way", "reason"},
 )
 
+var hedgedReadMetric = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "blobstore",
+		Subsystem: "access",
+		Name:      "hedged_read",
+		Help:      "hedged read of shards on access",
+	},
+	[]string{"cluster", "way", "result"},
+)
+
 var SteamReportDownload = reportDownload
 
 func init() {
 	prometheus.MustRegister(unhealthMetric)
 	prometheus.MustRegister(downloadMetric)
+	prometheus.MustRegister(hedgedReadMetric)
 }
 
 func reportUnhealth(cid proto.ClusterID, action, module, host, reason string) {
@@ -54,3 +65,9 @@ func reportUnhealth(cid proto.ClusterID, action, module, host, reason string) {
 func reportDownload(cid proto.ClusterID, way, reason string) {
 	downloadMetric.WithLabelValues(cid.ToString(), way, reason).Inc()
 }
+
+// reportHedgedRead result is win if the hedged shards are used to reconstruct data,
+// lose if they are not, fallback if direct read falls back to reconstruct read
+func reportHedgedRead(cid proto.ClusterID, way, result string) {
+	hedgedReadMetric.WithLabelValues(cid.ToString(), way, result).Inc()
+}
@@ -123,6 +123,8 @@ type StreamConfig struct {
 	MinReadShardsX             int    `json:"min_read_shards_x"`
 	ShardCrcDisabled           bool   `json:"shard_crc_disabled"`
 
+	HedgedRead HedgedReadConfig `json:"hedged_read"`
+
 	MemPoolSizeClasses map[int]int `json:"mem_pool_size_classes"`
 
 	// CodeModesPutQuorums
@@ -167,6 +169,9 @@ type Handler struct {
 	allCodeModes  CodeModePairs
 	maxObjectSize int64
 
+	// hedger is nil if hedged read is disabled
+	hedger *hedger
+
 	discardVidChan chan discardVid
 	stopCh         <-chan struct{}
 
@@ -249,6 +254,9 @@ func NewStreamHandler(cfg *StreamConfig, stopCh <-chan struct{}) (h StreamHandle
 		maxObjectSize: defaultMaxObjectSize,
 		StreamConfig:  *cfg,
 	}
+	if cfg.HedgedRead.Enable {
+		handler.hedger = newHedger(cfg.HedgedRead)
+	}
 
 	rawCodeModePolicies, err := handler.clusterController.GetConfig(context.Background(), proto.CodeModeConfigKey)
 	if err != nil {
@@ -42,6 +42,7 @@ var (
 	errNeedReconstructRead = errors.New("need to reconstruct read")
 	errCanceledReadShard   = errors.New("canceled read shard")
 	errPunishedDisk        = errors.New("punished disk")
+	errHedgedReadTimeout   = errors.New("hedged read timeout")
 )
 
 type blobGetArgs struct {
@@ -65,6 +66,7 @@ func (blob *blobGetArgs) ID() string {
 type shardData struct {
 	index  int
 	status bool
+	hedged bool
 	buffer []byte
 }
 
@@ -179,7 +181,7 @@ func (h *Handler) Get(ctx context.Context, w io.Writer, location access.Location
 
 				var blobVolume *controller.VolumePhy
 				var sortedVuids []sortedVuid
-				tactic := location.CodeMode.Tactic()
+				var tactic codemode.Tactic
 				for _, blob := range blobs {
 					var err error
 					if blobVolume == nil || blobVolume.Vid != blob.Vid {
@@ -190,6 +192,8 @@ func (h *Handler) Get(ctx context.Context, w io.Writer, location access.Location
 							return
 						}
 
+						// the volume may be converted into another code mode
+						tactic = blobVolume.CodeMode.Tactic()
 						// do not use local shards
 						sortedVuids = genSortedVuidByIDC(ctx, serviceController, h.IDC, blobVolume.Units[:tactic.N+tactic.M])
 						span.Debugf("to read %s with read-shard-x:%d active-shard-n:%d of data-n:%d party-n:%d",
@@ -202,6 +206,12 @@ func (h *Handler) Get(ctx context.Context, w io.Writer, location access.Location
 						}
 					}
 
+					if blob, err = h.convertBlob(blob, blobVolume.CodeMode); err != nil {
+						span.Error("convert blob", blob.ID(), err)
+						ch <- pipeBuffer{err: err}
+						return
+					}
+
 					st := time.Now()
 					shards := make([][]byte, tactic.N+tactic.M)
 					for ii := range shards {
@@ -318,8 +328,31 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 	}
 	shardSize, shardOffset, shardReadSize := blob.ShardSize, blob.ShardOffset, blob.ShardReadSize
 
+	// hedged reads are the next shards read before the previous failed
+	var (
+		hedgeDelay time.Duration
+		hedgeLimit int
+	)
+	if h.hedger != nil {
+		diskIDs := make([]proto.DiskID, 0, minShardsRead)
+		for _, vuid := range sortedVuids[:minShardsRead] {
+			if _, ok := empties[vuid.index]; !ok {
+				diskIDs = append(diskIDs, vuid.diskID)
+			}
+		}
+		for _, vuid := range sortedVuids[minShardsRead:] {
+			if _, ok := empties[vuid.index]; !ok {
+				hedgeLimit++
+			}
+		}
+		if hedgeLimit > h.hedger.MaxShards {
+			hedgeLimit = h.hedger.MaxShards
+		}
+		hedgeDelay = h.hedger.Delay(diskIDs, dataN-len(empties))
+	}
+
 	stopChan := make(chan struct{})
-	nextChan := make(chan struct{}, len(sortedVuids))
+	nextChan := make(chan bool, len(sortedVuids)+hedgeLimit)
 	shardPipe := func() <-chan shardData {
 		ch := make(chan shardData)
 		go func() {
@@ -344,17 +377,20 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 					continue
 				}
 
+				var hedged bool
 				select {
 				case <-stopChan:
 					return
-				case <-nextChan:
+				case hedged = <-nextChan:
 				}
 
 				wg.Add(1)
-				go func(vuid sortedVuid) {
-					ch <- h.readOneShard(ctx, serviceController, blob, vuid, stopChan)
+				go func(vuid sortedVuid, hedged bool) {
+					shard := h.readOneShard(ctx, serviceController, blob, vuid, stopChan)
+					shard.hedged = hedged
+					ch <- shard
 					wg.Done()
-				}(vuid)
+				}(vuid, hedged)
 			}
 		}()
 
@@ -367,14 +403,49 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 		h.memPool.Zero(shards[idx])
 	}
 
+	var (
+		hedgeTimer *time.Timer
+		hedgeC     <-chan time.Time
+		hedges     int
+		hedgesUsed int
+	)
+	if hedgeLimit > 0 {
+		hedgeTimer = time.NewTimer(hedgeDelay)
+		defer hedgeTimer.Stop()
+		hedgeC = hedgeTimer.C
+	}
+
 	startRead := time.Now()
 	reconstructed := false
-	for shard := range shardPipe {
+loop:
+	for {
+		var shard shardData
+		select {
+		case <-hedgeC:
+			hedges++
+			nextChan <- true
+			span.Debugf("%s hedged read the %dth shard after %s", blob.ID(), hedges, hedgeDelay)
+			if hedges < hedgeLimit {
+				hedgeTimer.Reset(hedgeDelay)
+			} else {
+				hedgeC = nil
+			}
+			continue
+		case s, ok := <-shardPipe:
+			if !ok {
+				break loop
+			}
+			shard = s
+		}
+
 		// swap shard buffer
 		if shard.status {
 			buf := shards[shard.index]
 			shards[shard.index] = shard.buffer
 			h.memPool.Put(buf)
+			if shard.hedged {
+				hedgesUsed++
+			}
 		}
 
 		received[shard.index] = shard.status
@@ -442,10 +513,18 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 			close(stopChan)
 			break
 		}
-		nextChan <- struct{}{}
+		nextChan <- false
 	}
 	getTime.IncR(time.Since(startRead))
 
+	if hedges > 0 {
+		if reconstructed && hedgesUsed > 0 {
+			reportHedgedRead(blob.Cid, "EC", "win")
+		} else {
+			reportHedgedRead(blob.Cid, "EC", "lose")
+		}
+	}
+
 	// release buffer of delayed shards
 	go func() {
 		for shard := range shardPipe {
@@ -485,6 +564,7 @@ func (h *Handler) readOneShard(ctx context.Context, serviceController controller
 		err  error
 		body io.ReadCloser
 	)
+	startRead := time.Now()
 	if hErr := hystrix.Do(rwCommand, func() error {
 		body, err = h.getOneShardFromHost(ctx, serviceController, vuid.host, vuid.diskID, args,
 			vuid.index, clusterID, vid, 3, stopChan)
@@ -520,6 +600,7 @@ func (h *Handler) readOneShard(ctx context.Context, serviceController controller
 		return shardResult
 	}
 
+	h.hedger.Record(vuid.diskID, time.Since(startRead))
 	shardResult.status = true
 	shardResult.buffer = buf
 	return shardResult
@@ -536,6 +617,9 @@ func (h *Handler) getDataShardOnly(ctx context.Context, getTime *timeReadWrite,
 	if err != nil {
 		return err
 	}
+	if blob, err = h.convertBlob(blob, blobVolume.CodeMode); err != nil {
+		return err
+	}
 	tactic := blobVolume.CodeMode.Tactic()
 
 	from, to := int(blob.Offset), int(blob.Offset+blob.ReadSize)
@@ -568,19 +652,36 @@ func (h *Handler) getDataShardOnly(ctx context.Context, getTime *timeReadWrite,
 			Size:   int64(toReadSize),
 		}
 
-		body, err := h.getOneShardFromHost(ctx, serviceController, shard.Host, shard.DiskID, args,
-			firstShardIdx+i, blob.Cid, blob.Vid, 1, nil)
-		if err != nil {
-			span.Warnf("read %s on blobnode(vuid:%d disk:%d host:%s) ecidx(%02d): %s", blob.ID(),
-				shard.Vuid, shard.DiskID, shard.Host, firstShardIdx+i, errors.Detail(err))
-			return errNeedReconstructRead
+		shard, idx := shard, firstShardIdx+i
+		readShard := func(buf []byte) error {
+			st := time.Now()
+			body, err := h.getOneShardFromHost(ctx, serviceController, shard.Host, shard.DiskID, args,
+				idx, blob.Cid, blob.Vid, 1, nil)
+			if err != nil {
+				return err
+			}
+			defer body.Close()
+
+			if _, err = io.ReadFull(body, buf); err != nil {
+				return err
+			}
+			h.hedger.Record(shard.DiskID, time.Since(st))
+			return nil
 		}
-		defer body.Close()
 
 		buf := buffer.DataBuf[bufOffset : bufOffset+int(toReadSize)]
-		_, err = io.ReadFull(body, buf)
+		if h.hedger != nil {
+			delay := h.hedger.Delay([]proto.DiskID{shard.DiskID}, 1)
+			err = h.readWithDeadline(buf, delay, readShard)
+		} else {
+			err = readShard(buf)
+		}
 		if err != nil {
-			span.Warn(err)
+			if err == errHedgedReadTimeout {
+				reportHedgedRead(blob.Cid, "Direct", "fallback")
+			}
+			span.Warnf("read %s on blobnode(vuid:%d disk:%d host:%s) ecidx(%02d): %s", blob.ID(),
+				shard.Vuid, shard.DiskID, shard.Host, idx, errors.Detail(err))
 			return errNeedReconstructRead
 		}
 
@@ -605,6 +706,37 @@ func (h *Handler) getDataShardOnly(ctx context.Context, getTime *timeReadWrite,
 	return nil
 }
 
+// readWithDeadline reads into a temporary buffer in background, and gives up if it has not
+// been done after delay, the temporary buffer is released after the background reading done.
+func (h *Handler) readWithDeadline(buf []byte, delay time.Duration, read func([]byte) error) error {
+	tmp, err := h.memPool.Alloc(len(buf))
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- read(tmp)
+	}()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case err = <-done:
+		if err == nil {
+			copy(buf, tmp)
+		}
+		h.memPool.Put(tmp)
+		return err
+	case <-timer.C:
+		go func() {
+			<-done
+			h.memPool.Put(tmp)
+		}()
+		return errHedgedReadTimeout
+	}
+}
+
 // getOneShardFromHost get body of one shard
 func (h *Handler) getOneShardFromHost(ctx context.Context, serviceController controller.ServiceController,
 	host string, diskID proto.DiskID, args blobnode.RangeGetShardArgs, // get shard param with host diskid
@@ -771,6 +903,34 @@ func genLocationBlobs(location *access.Location, readSize uint64, offset uint64)
 	return blobs, nil
 }
 
+// convertBlob returns the arguments to read the blob from a volume in the code mode,
+// which differs from the code mode of the location if the volume has been converted.
+// The converter re-encodes the padded data shards of the original code mode as a whole,
+// so the blob is the prefix of the converted data.
+func (h *Handler) convertBlob(blob blobGetArgs, mode codemode.CodeMode) (blobGetArgs, error) {
+	if blob.CodeMode == mode {
+		return blob, nil
+	}
+	if _, ok := h.encoder[mode]; !ok {
+		return blob, fmt.Errorf("no encoder of converted codemode %s for %s", mode.String(), blob.ID())
+	}
+
+	sizes, err := ec.GetBufferSizes(int(blob.BlobSize), blob.CodeMode.Tactic())
+	if err != nil {
+		return blob, err
+	}
+	converted, err := ec.GetBufferSizes(sizes.ECDataSize, mode.Tactic())
+	if err != nil {
+		return blob, err
+	}
+
+	blob.CodeMode = mode
+	blob.BlobSize = uint64(sizes.ECDataSize)
+	blob.ShardSize = converted.ShardSize
+	blob.ShardOffset, blob.ShardReadSize = shardSegment(converted.ShardSize, int(blob.Offset), int(blob.ReadSize))
+	return blob, nil
+}
+
 func genSortedVuidByIDC(ctx context.Context, serviceController controller.ServiceController, idc string,
 	vuidPhys []controller.Unit) []sortedVuid {
 	span := trace.SpanFromContextSafe(ctx)
@@ -26,6 +26,7 @@ import (
 
 	"github.com/cubefs/cubefs/blobstore/api/access"
 	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	"github.com/cubefs/cubefs/blobstore/common/ec"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
 
@@ -249,6 +250,57 @@ func TestAccessStreamGetShardTimeout(t *testing.T) {
 	}
 }
 
+func TestAccessStreamGetHedged(t *testing.T) {
+	ctx := ctxWithName("TestAccessStreamGetHedged")
+	dataShards.clean()
+	vuidController.Unbreak(1005)
+	streamer.MinReadShardsX = defaultMinReadShardsX
+	streamer.hedger = newHedger(HedgedReadConfig{Enable: true, MinDelayMS: 20, MaxDelayMS: 20})
+	defer func() {
+		vuidController.Break(1005)
+		streamer.MinReadShardsX = minReadShardsX
+		streamer.hedger = nil
+		dataShards.clean()
+	}()
+
+	size := 1 << 22
+	buff := make([]byte, size)
+	rand.Read(buff)
+	loc, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	require.NoError(t, err)
+
+	// no delay when blocking two shard, cos hedged read the next shards
+	vuidController.Block(1001)
+	vuidController.Block(1002)
+	defer func() {
+		vuidController.Unblock(1001)
+		vuidController.Unblock(1002)
+	}()
+	{
+		startTime := time.Now()
+		buffer := bytes.NewBuffer(nil)
+		transfer, _ := streamer.Get(ctx(), buffer, *loc, uint64(size), 0)
+		require.NoError(t, transfer())
+		require.True(t, dataEqual(buff, buffer.Bytes()))
+
+		duration := time.Since(startTime)
+		require.Greater(t, vuidController.duration, duration, "greater duration: ", duration)
+	}
+
+	// read data shard only falls back to reconstruct read
+	vuidController.Unblock(1002)
+	{
+		startTime := time.Now()
+		buffer := bytes.NewBuffer(nil)
+		transfer, _ := streamer.Get(ctx(), buffer, *loc, 1024, 0)
+		require.NoError(t, transfer())
+		require.True(t, dataEqual(buff[:1024], buffer.Bytes()))
+
+		duration := time.Since(startTime)
+		require.Greater(t, vuidController.duration, duration, "greater duration: ", duration)
+	}
+}
+
 func TestAccessStreamGetShardBroken(t *testing.T) {
 	ctx := ctxWithName("TestAccessStreamGetShardBroken")
 	dataShards.clean()
@@ -605,3 +657,45 @@ func BenchmarkAccessStreamGet(b *testing.B) {
 		})
 	}
 }
+
+func TestAccessStreamGetConvertBlob(t *testing.T) {
+	from, to := codemode.EC6P6, codemode.EC12P4
+	encoder, err := ec.NewEncoder(ec.Config{CodeMode: to.Tactic()})
+	require.NoError(t, err)
+	h := &Handler{encoder: map[codemode.CodeMode]ec.Encoder{to: encoder}}
+
+	for _, size := range []int{1, 1 << 10, 100001, 1 << 22} {
+		data := make([]byte, size)
+		rand.Read(data)
+
+		// re-encode the padded data shards like the converter
+		src, err := ec.GetBufferSizes(size, from.Tactic())
+		require.NoError(t, err)
+		dst, err := ec.GetBufferSizes(src.ECDataSize, to.Tactic())
+		require.NoError(t, err)
+		buf := make([]byte, dst.ECSize)
+		copy(buf, data)
+		shards, err := encoder.Split(buf[:dst.ECDataSize])
+		require.NoError(t, err)
+		require.NoError(t, encoder.Encode(shards))
+
+		for _, r := range [][2]int{{0, size}, {size / 3, size / 2}, {size - 1, 1}} {
+			blob := blobGetArgs{CodeMode: from, BlobSize: uint64(size), Offset: uint64(r[0]), ReadSize: uint64(r[1])}
+			converted, err := h.convertBlob(blob, to)
+			require.NoError(t, err)
+			require.Equal(t, to, converted.CodeMode)
+			require.Equal(t, len(shards[0]), converted.ShardSize)
+
+			idx := r[0] / converted.ShardSize
+			segment := shards[idx][converted.ShardOffset : converted.ShardOffset+converted.ShardReadSize]
+			if converted.ShardReadSize < converted.ShardSize {
+				require.Equal(t, data[r[0]:r[0]+r[1]], segment)
+			}
+			read := bytes.Join(shards[:to.Tactic().N], nil)
+			require.Equal(t, data[r[0]:r[0]+r[1]], read[r[0]:r[0]+r[1]])
+		}
+	}
+
+	_, err = h.convertBlob(blobGetArgs{CodeMode: from, BlobSize: 1, ReadSize: 1}, codemode.EC16P4)
+	require.Error(t, err)
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/util/defaulter"
+)
+
+const (
+	defaultHedgedPercentile = 95.0
+	defaultHedgedWindowSize = 128
+	defaultHedgedMinDelayMS = 10
+	defaultHedgedMaxDelayMS = 1000
+	defaultHedgedMaxShards  = 2
+
+	// the latency of disk is unknown before it has enough samples
+	hedgedMinSamples = 8
+)
+
+// HedgedReadConfig hedged read of blob shards on GET.
+// A shard read is regarded as slow if it has not been done after the percentile
+// latency of the recent reads on its disk, then read extra shards to reconstruct,
+// or fall back to reconstruct read if it is reading data shard only.
+type HedgedReadConfig struct {
+	Enable bool `json:"enable"`
+	// percentile of recent read latencies on one disk, in (0, 100]
+	Percentile float64 `json:"percentile"`
+	// number of recent read latencies kept for each disk
+	WindowSize int `json:"window_size"`
+	// hedged delay is limited in [min_delay_ms, max_delay_ms]
+	MinDelayMS int `json:"min_delay_ms"`
+	MaxDelayMS int `json:"max_delay_ms"`
+	// max extra shards to read of one blob
+	MaxShards int `json:"max_shards"`
+}
+
+func fixHedgedReadConfig(cfg *HedgedReadConfig) {
+	defaulter.LessOrEqual(&cfg.Percentile, defaultHedgedPercentile)
+	if cfg.Percentile > 100 {
+		cfg.Percentile = 100
+	}
+	defaulter.LessOrEqual(&cfg.WindowSize, defaultHedgedWindowSize)
+	if cfg.WindowSize < hedgedMinSamples {
+		cfg.WindowSize = hedgedMinSamples
+	}
+	defaulter.LessOrEqual(&cfg.MinDelayMS, defaultHedgedMinDelayMS)
+	defaulter.LessOrEqual(&cfg.MaxDelayMS, defaultHedgedMaxDelayMS)
+	if cfg.MaxDelayMS < cfg.MinDelayMS {
+		cfg.MaxDelayMS = cfg.MinDelayMS
+	}
+	defaulter.LessOrEqual(&cfg.MaxShards, defaultHedgedMaxShards)
+}
+
+// diskLatency is a ring of recent read latencies on one disk
+type diskLatency struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func (d *diskLatency) add(dur time.Duration) {
+	d.mu.Lock()
+	d.samples[d.next] = dur
+	d.next++
+	if d.next == len(d.samples) {
+		d.next = 0
+		d.full = true
+	}
+	d.mu.Unlock()
+}
+
+func (d *diskLatency) percentile(p float64) (time.Duration, bool) {
+	d.mu.Lock()
+	n := d.next
+	if d.full {
+		n = len(d.samples)
+	}
+	if n < hedgedMinSamples {
+		d.mu.Unlock()
+		return 0, false
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, d.samples[:n])
+	d.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(n)*p/100+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= n {
+		idx = n - 1
+	}
+	return sorted[idx], true
+}
+
+// hedger tracks read latency of disks and decides when to hedge
+type hedger struct {
+	HedgedReadConfig
+	disks sync.Map // proto.DiskID -> *diskLatency
+}
+
+func newHedger(cfg HedgedReadConfig) *hedger {
+	fixHedgedReadConfig(&cfg)
+	return &hedger{HedgedReadConfig: cfg}
+}
+
+// Record adds latency of a succeeded shard read on disk
+func (h *hedger) Record(diskID proto.DiskID, dur time.Duration) {
+	if h == nil {
+		return
+	}
+	val, ok := h.disks.Load(diskID)
+	if !ok {
+		val, _ = h.disks.LoadOrStore(diskID, &diskLatency{samples: make([]time.Duration, h.WindowSize)})
+	}
+	val.(*diskLatency).add(dur)
+}
+
+// diskDelay returns the hedged delay of one disk, max delay if the disk has no enough samples
+func (h *hedger) diskDelay(diskID proto.DiskID) time.Duration {
+	minDelay := time.Duration(h.MinDelayMS) * time.Millisecond
+	maxDelay := time.Duration(h.MaxDelayMS) * time.Millisecond
+	val, ok := h.disks.Load(diskID)
+	if !ok {
+		return maxDelay
+	}
+	delay, ok := val.(*diskLatency).percentile(h.Percentile)
+	if !ok {
+		return maxDelay
+	}
+	if delay < minDelay {
+		return minDelay
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// Delay returns the time to hedge when waiting n shards from disks,
+// it is the n-th smallest delay of disks, so that a few slow disks are excluded.
+func (h *hedger) Delay(diskIDs []proto.DiskID, n int) time.Duration {
+	if len(diskIDs) == 0 {
+		return time.Duration(h.MaxDelayMS) * time.Millisecond
+	}
+	delays := make([]time.Duration, len(diskIDs))
+	for idx, diskID := range diskIDs {
+		delays[idx] = h.diskDelay(diskID)
+	}
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	if n < 1 {
+		n = 1
+	} else if n > len(delays) {
+		n = len(delays)
+	}
+	return delays[n-1]
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func TestAccessStreamHedgerConfig(t *testing.T) {
+	h := newHedger(HedgedReadConfig{Enable: true, Percentile: 200, WindowSize: 1, MinDelayMS: 100, MaxDelayMS: 10})
+	require.Equal(t, float64(100), h.Percentile)
+	require.Equal(t, hedgedMinSamples, h.WindowSize)
+	require.Equal(t, 100, h.MaxDelayMS)
+	require.Equal(t, defaultHedgedMaxShards, h.MaxShards)
+}
+
+func TestAccessStreamHedgerDelay(t *testing.T) {
+	h := newHedger(HedgedReadConfig{Enable: true, Percentile: 90, WindowSize: 10, MinDelayMS: 5, MaxDelayMS: 500})
+	maxDelay := 500 * time.Millisecond
+
+	// unknown disk
+	require.Equal(t, maxDelay, h.Delay(nil, 1))
+	require.Equal(t, maxDelay, h.Delay([]proto.DiskID{1}, 1))
+	for ii := 1; ii < hedgedMinSamples; ii++ {
+		h.Record(1, time.Millisecond)
+	}
+	require.Equal(t, maxDelay, h.Delay([]proto.DiskID{1}, 1))
+
+	// percentile of the recent samples
+	for ii := 1; ii <= 10; ii++ {
+		h.Record(1, time.Duration(ii)*10*time.Millisecond)
+	}
+	require.Equal(t, 90*time.Millisecond, h.Delay([]proto.DiskID{1}, 1))
+	for ii := 0; ii < 10; ii++ {
+		h.Record(1, time.Millisecond)
+	}
+	require.Equal(t, 5*time.Millisecond, h.Delay([]proto.DiskID{1}, 1))
+	for ii := 0; ii < 10; ii++ {
+		h.Record(2, time.Second)
+		h.Record(3, 20*time.Millisecond)
+	}
+	require.Equal(t, maxDelay, h.Delay([]proto.DiskID{2}, 1))
+
+	// the slow disks are excluded
+	disks := []proto.DiskID{1, 2, 3, 4}
+	require.Equal(t, 5*time.Millisecond, h.Delay(disks, 0))
+	require.Equal(t, 20*time.Millisecond, h.Delay(disks, 2))
+	require.Equal(t, maxDelay, h.Delay(disks, 3))
+	require.Equal(t, maxDelay, h.Delay(disks, 10))
+}
@@ -71,6 +71,8 @@ type VolumeInfoBase struct {
 	Free           uint64             `json:"free"`
 	Used           uint64             `json:"used"`
 	CreateByNodeID uint64             `json:"create_by_node_id"`
+	// ConvertedFrom is the original code mode of a converted volume
+	ConvertedFrom codemode.CodeMode `json:"converted_from,omitempty"`
 }
 
 type AllocVolumeInfo struct {
@@ -198,6 +200,35 @@ func (c *Client) ReleaseVolumeUnit(ctx context.Context, args *ReleaseVolumeUnitA
 	return
 }
 
+type AllocConvertVolumeUnitsArgs struct {
+	Vid      proto.Vid         `json:"vid"`
+	CodeMode codemode.CodeMode `json:"code_mode"`
+}
+
+type ConvertVolumeUnits struct {
+	Units []Unit `json:"units"`
+}
+
+// AllocConvertVolumeUnits alloc chunks of all units of the volume in the code mode,
+// the volume is still in the old code mode until ConvertVolume commit the units.
+func (c *Client) AllocConvertVolumeUnits(ctx context.Context, args *AllocConvertVolumeUnitsArgs) (ret []Unit, err error) {
+	units := &ConvertVolumeUnits{}
+	err = c.PostWith(ctx, "/volume/convert/alloc", units, args)
+	return units.Units, err
+}
+
+type ConvertVolumeArgs struct {
+	Vid      proto.Vid         `json:"vid"`
+	CodeMode codemode.CodeMode `json:"code_mode"`
+	Units    []Unit            `json:"units"`
+}
+
+// ConvertVolume switch the volume into the code mode with the re-encoded units
+func (c *Client) ConvertVolume(ctx context.Context, args *ConvertVolumeArgs) (err error) {
+	err = c.PostWith(ctx, "/volume/convert", nil, args)
+	return
+}
+
 type ListVolumeUnitArgs struct {
 	DiskID proto.DiskID `json:"disk_id"`
 }
@@ -40,6 +40,11 @@ func New(cfg *Config) Client {
 	return &client{rpc.NewClient(&cfg.Config)}
 }
 
+// NewMQClient returns client of the queue embedded in proxy
+func NewMQClient(cfg *Config) MQClient {
+	return &client{rpc.NewClient(&cfg.Config)}
+}
+
 func (c *client) VolumeAlloc(ctx context.Context, host string, args *AllocVolsArgs) (ret []AllocRet, err error) {
 	ret = make([]AllocRet, 0)
 	err = c.PostWith(ctx, host+"/volume/alloc", &ret, args)
@@ -59,6 +64,28 @@ func (c *client) SendDeleteMsg(ctx context.Context, host string, args *DeleteArg
 	return c.PostWith(ctx, host+"/deletemsg", nil, args)
 }
 
+func (c *client) MQProduce(ctx context.Context, host string, args *MQProduceArgs) error {
+	return c.PostWith(ctx, host+"/mq/produce", nil, args)
+}
+
+func (c *client) MQFetch(ctx context.Context, host string, args *MQFetchArgs) (ret MQFetchRet, err error) {
+	err = c.PostWith(ctx, host+"/mq/fetch", &ret, args)
+	return
+}
+
+func (c *client) MQCommit(ctx context.Context, host string, args *MQCommitArgs) error {
+	return c.PostWith(ctx, host+"/mq/commit", nil, args)
+}
+
+func (c *client) MQNack(ctx context.Context, host string, args *MQCommitArgs) error {
+	return c.PostWith(ctx, host+"/mq/nack", nil, args)
+}
+
+func (c *client) MQStat(ctx context.Context, host string, args *MQStatArgs) (ret MQTopicStat, err error) {
+	err = c.GetWith(ctx, fmt.Sprintf("%s/mq/stat?topic=%s", host, args.Topic), &ret)
+	return
+}
+
 func (c *client) GetCacheVolume(ctx context.Context, host string, args *CacheVolumeArgs) (volume *VersionVolume, err error) {
 	volume = new(VersionVolume)
 	url := fmt.Sprintf("%s/cache/volume/%d?flush=%v&version=%d", host, args.Vid, args.Flush, args.Version)
@@ -47,3 +47,54 @@ type ShardRepairArgs struct {
 	BadIdxes  []uint8         `json:"bad_idxes"`
 	Reason    string          `json:"reason"`
 }
+
+// MQClient is client of the queue embedded in proxy
+type MQClient interface {
+	MQProduce(ctx context.Context, host string, args *MQProduceArgs) error
+	MQFetch(ctx context.Context, host string, args *MQFetchArgs) (ret MQFetchRet, err error)
+	MQCommit(ctx context.Context, host string, args *MQCommitArgs) error
+	MQNack(ctx context.Context, host string, args *MQCommitArgs) error
+	MQStat(ctx context.Context, host string, args *MQStatArgs) (ret MQTopicStat, err error)
+}
+
+type MQProduceArgs struct {
+	Topic string   `json:"topic"`
+	Msgs  [][]byte `json:"msgs"`
+}
+
+type MQFetchArgs struct {
+	Topic    string `json:"topic"`
+	Group    string `json:"group"`
+	Consumer string `json:"consumer"`
+	Count    int    `json:"count"`
+}
+
+type MQMessage struct {
+	Offset    int64  `json:"offset"`
+	Timestamp int64  `json:"timestamp"` // unix nano
+	Value     []byte `json:"value"`
+}
+
+type MQFetchRet struct {
+	Messages []MQMessage `json:"messages"`
+}
+
+// MQCommitArgs args of commit and nack, the committed offset is the next message to consume,
+// and the nack offset is the message consumed failed
+type MQCommitArgs struct {
+	Topic    string `json:"topic"`
+	Group    string `json:"group"`
+	Consumer string `json:"consumer"`
+	Offset   int64  `json:"offset"`
+}
+
+type MQStatArgs struct {
+	Topic string `json:"topic"`
+}
+
+type MQTopicStat struct {
+	Topic       string           `json:"topic"`
+	StartOffset int64            `json:"start_offset"`
+	EndOffset   int64            `json:"end_offset"`
+	Groups      map[string]int64 `json:"groups"`
+}
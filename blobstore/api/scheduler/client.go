@@ -39,6 +39,7 @@ const (
 	PathInspectComplete      = "/inspect/complete"
 	PathInspectAcquire       = "/inspect/acquire"
 	PathManualMigrateTaskAdd = "/manual/migrate/task/add"
+	PathVolumeConvertTaskAdd = "/volume/convert/task/add"
 
 	PathTaskDetail    = "/task/detail"
 	PathTaskDetailURI = PathTaskDetail + "/:type/:id" // "/task/detail/:type/:id"
@@ -78,6 +79,11 @@ type IManualMigrator interface {
 	AddManualMigrateTask(ctx context.Context, args *AddManualMigrateArgs) (err error)
 }
 
+// IVolumeConverter add volume convert task.
+type IVolumeConverter interface {
+	AddVolumeConvertTask(ctx context.Context, args *AddVolumeConvertArgs) (err error)
+}
+
 // IVolumeUpdater volume updater.
 type IVolumeUpdater interface {
 	UpdateVolume(ctx context.Context, host string, vid proto.Vid) (err error)
@@ -89,6 +95,7 @@ type IScheduler interface {
 	IInspector
 	ISchedulerStatus
 	IManualMigrator
+	IVolumeConverter
 	IVolumeUpdater
 }
 
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"net/url"
 
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
@@ -121,6 +122,22 @@ func (c *client) AddManualMigrateTask(ctx context.Context, args *AddManualMigrat
 	})
 }
 
+// AddVolumeConvertArgs convert the sealed volume into the code mode.
+type AddVolumeConvertArgs struct {
+	Vid      proto.Vid         `json:"vid"`
+	CodeMode codemode.CodeMode `json:"code_mode"`
+}
+
+func (args *AddVolumeConvertArgs) Valid() bool {
+	return args.Vid != proto.InvalidVid && args.CodeMode.IsValid()
+}
+
+func (c *client) AddVolumeConvertTask(ctx context.Context, args *AddVolumeConvertArgs) (err error) {
+	return c.request(func(host string) error {
+		return c.PostWith(ctx, host+PathVolumeConvertTaskAdd, nil, args)
+	})
+}
+
 // MigrateTaskDetailArgs migrate task detail args.
 type MigrateTaskDetailArgs struct {
 	Type proto.TaskType `json:"type"`
@@ -171,6 +188,10 @@ type ManualMigrateTasksStat struct {
 	MigrateTasksStat
 }
 
+type VolumeConvertTasksStat struct {
+	MigrateTasksStat
+}
+
 type VolumeInspectTasksStat struct {
 	Enable         bool   `json:"enable"`
 	FinishedPerMin string `json:"finished_per_min"`
@@ -191,6 +212,7 @@ type TasksStat struct {
 	DiskDrop      *DiskDropTasksStat      `json:"disk_drop,omitempty"`
 	Balance       *BalanceTasksStat       `json:"balance,omitempty"`
 	ManualMigrate *ManualMigrateTasksStat `json:"manual_migrate,omitempty"`
+	VolumeConvert *VolumeConvertTasksStat `json:"volume_convert,omitempty"`
 	VolumeInspect *VolumeInspectTasksStat `json:"volume_inspect,omitempty"`
 	ShardRepair   *RunnerStat             `json:"shard_repair"`
 	BlobDelete    *RunnerStat             `json:"blob_delete"`
@@ -0,0 +1,265 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/blobnode/base/workutils"
+	"github.com/cubefs/cubefs/blobstore/blobnode/client"
+	"github.com/cubefs/cubefs/blobstore/common/ec"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/retry"
+)
+
+// ConvertWorker used to re-encode all blobs of volume into the destination code mode
+type ConvertWorker struct {
+	t           *proto.MigrateTask
+	bolbNodeCli client.IBlobNode
+	encoder     ec.Encoder
+
+	benchmarkBids            []*ShardInfoSimple
+	downloadShardConcurrency int
+}
+
+// NewConvertWorker returns volume convert worker
+func NewConvertWorker(task MigrateTaskEx) ITaskWorker {
+	return &ConvertWorker{
+		t:                        task.taskInfo,
+		bolbNodeCli:              task.blobNodeCli,
+		downloadShardConcurrency: task.downloadShardConcurrency,
+	}
+}
+
+// convertShardSize returns the shard size in destination code mode of the shard in source code mode,
+// all data shards of source are joined as the data to be encoded, so that the blob is the prefix of it.
+func (w *ConvertWorker) convertShardSize(size int64) (int64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	sizes, err := ec.GetBufferSizes(int(size)*w.t.CodeMode.Tactic().N, w.t.DestCodeMode.Tactic())
+	if err != nil {
+		return 0, err
+	}
+	return int64(sizes.ShardSize), nil
+}
+
+// GenTasklets generates convert tasklets
+func (w *ConvertWorker) GenTasklets(ctx context.Context) ([]Tasklet, *WorkError) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	if workutils.TaskBufPool == nil {
+		return nil, OtherError(errors.New("TaskBufPool should init before"))
+	}
+	encoder, err := ec.NewEncoder(ec.Config{CodeMode: w.t.DestCodeMode.Tactic(), EnableVerify: true})
+	if err != nil {
+		return nil, OtherError(err)
+	}
+	w.encoder = encoder
+
+	// ensure the volume is read-only before convert
+	if err = retry.Timed(3, 1000).On(func() error {
+		if majorityLocked(ctx, w.bolbNodeCli, w.t.Sources, w.t.CodeMode) {
+			return nil
+		}
+		return ErrNotReadyForMigrate
+	}); err != nil {
+		return nil, OtherError(ErrNotReadyForMigrate)
+	}
+
+	benchmarkBids, err := GetBenchmarkBids(ctx, w.bolbNodeCli, w.t.Sources, w.t.CodeMode, nil)
+	if err != nil {
+		span.Errorf("get benchmark bids failed: err[%v]", err)
+		return nil, SrcError(err)
+	}
+	w.benchmarkBids = benchmarkBids
+
+	// skip the bids which have been converted into all destinations
+	converted := make(map[proto.BlobID]int, len(benchmarkBids))
+	for _, dest := range w.t.Destinations {
+		destBids, err := GetSingleVunitNormalBids(ctx, w.bolbNodeCli, dest)
+		if err != nil {
+			span.Errorf("get single vunit normal bids failed: dest[%+v], err[%+v]", dest, err)
+			return nil, DstError(err)
+		}
+		for _, bid := range destBids {
+			converted[bid.Bid]++
+		}
+	}
+	var convertBids []*ShardInfoSimple
+	for _, bid := range benchmarkBids {
+		if converted[bid.Bid] == len(w.t.Destinations) {
+			continue
+		}
+		convertBids = append(convertBids, bid)
+	}
+	span.Debugf("task info: taskType[%s], benchmarkBids size[%d], need convert bids size[%d]",
+		w.TaskType(), len(benchmarkBids), len(convertBids))
+
+	return BidsSplit(ctx, convertBids, workutils.TaskBufPool.GetMigrateBufSize())
+}
+
+// ExecTasklet execute convert tasklet
+func (w *ConvertWorker) ExecTasklet(ctx context.Context, tasklet Tasklet) *WorkError {
+	span := trace.SpanFromContextSafe(ctx)
+	dataN := w.t.CodeMode.Tactic().N
+	dataIdxs := make([]uint8, dataN)
+	for i := range dataIdxs {
+		dataIdxs[i] = uint8(i)
+	}
+
+	// step1 download data shards directly
+	shardRecover := NewShardRecover(w.t.Sources, w.t.CodeMode, tasklet.bids, w.bolbNodeCli,
+		w.downloadShardConcurrency, w.t.TaskType)
+	defer shardRecover.ReleaseBuf()
+	bids, err := shardRecover.directGetShard(ctx, GetBids(tasklet.bids), dataIdxs)
+	if err != nil {
+		return OtherError(err)
+	}
+
+	// step2 recover the missing data shards by ec
+	var repairRecover *ShardRecover
+	if len(bids) > 0 {
+		badIdxs := w.missingDataIdxs(shardRecover, bids, dataIdxs)
+		span.Infof("recover missing data shards: bids len[%d], badIdxs[%+v]", len(bids), badIdxs)
+
+		failBids := make(map[proto.BlobID]struct{}, len(bids))
+		for _, bid := range bids {
+			failBids[bid] = struct{}{}
+		}
+		repairBids := make([]*ShardInfoSimple, 0, len(bids))
+		for _, bid := range tasklet.bids {
+			if _, ok := failBids[bid.Bid]; ok {
+				repairBids = append(repairBids, bid)
+			}
+		}
+		repairRecover = NewShardRecover(w.t.Sources, w.t.CodeMode, repairBids, w.bolbNodeCli,
+			w.downloadShardConcurrency, w.t.TaskType)
+		defer repairRecover.ReleaseBuf()
+		if err = repairRecover.RecoverShards(ctx, badIdxs, false); err != nil {
+			return SrcError(err)
+		}
+	}
+
+	// step3 re-encode and put shards to destinations
+	for _, bid := range tasklet.bids {
+		shards, err := w.encode(shardRecover, repairRecover, bid, dataIdxs)
+		if err != nil {
+			span.Errorf("encode failed: bid[%d], err[%+v]", bid.Bid, err)
+			return OtherError(err)
+		}
+		for idx, dest := range w.t.Destinations {
+			data := shards[idx]
+			err = retry.Timed(3, 1000).On(func() error {
+				return w.bolbNodeCli.PutShard(ctx, dest, bid.Bid, int64(len(data)), bytes.NewReader(data), shardRecover.ioType)
+			})
+			if err != nil {
+				return DstError(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *ConvertWorker) missingDataIdxs(r *ShardRecover, bids []proto.BlobID, dataIdxs []uint8) (badIdxs []uint8) {
+	for _, idx := range dataIdxs {
+		for _, bid := range bids {
+			if !r.chunksShardsBuf[idx].shardIsOk(bid) {
+				badIdxs = append(badIdxs, idx)
+				break
+			}
+		}
+	}
+	return
+}
+
+// encode joins all data shards of source code mode and encodes into shards of destination code mode
+func (w *ConvertWorker) encode(direct, repair *ShardRecover, bid *ShardInfoSimple, dataIdxs []uint8) ([][]byte, error) {
+	shardSize, err := w.convertShardSize(bid.Size)
+	if err != nil {
+		return nil, err
+	}
+	tactic := w.t.DestCodeMode.Tactic()
+	shardN := tactic.N + tactic.M + tactic.L
+	buf := make([]byte, int(shardSize)*shardN)
+
+	offset := 0
+	for _, idx := range dataIdxs {
+		data, err := direct.GetShard(idx, bid.Bid)
+		if err != nil {
+			if repair == nil {
+				return nil, err
+			}
+			if data, err = repair.GetShard(idx, bid.Bid); err != nil {
+				return nil, err
+			}
+		}
+		offset += copy(buf[offset:], data)
+	}
+
+	shards := make([][]byte, shardN)
+	for i := range shards {
+		shards[i] = buf[i*int(shardSize) : (i+1)*int(shardSize)]
+	}
+	if shardSize == 0 {
+		return shards, nil
+	}
+	if err = w.encoder.Encode(shards); err != nil {
+		return nil, err
+	}
+	return shards, nil
+}
+
+// Check checks convert task execute result
+func (w *ConvertWorker) Check(ctx context.Context) *WorkError {
+	expectBids := make([]*ShardInfoSimple, 0, len(w.benchmarkBids))
+	for _, bid := range w.benchmarkBids {
+		size, err := w.convertShardSize(bid.Size)
+		if err != nil {
+			return OtherError(err)
+		}
+		expectBids = append(expectBids, &ShardInfoSimple{Bid: bid.Bid, Size: size})
+	}
+	for _, dest := range w.t.Destinations {
+		if err := CheckVunit(ctx, expectBids, dest, w.bolbNodeCli); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBenchmarkBids returns benchmark bids
+func (w *ConvertWorker) GetBenchmarkBids() []*ShardInfoSimple {
+	return w.benchmarkBids
+}
+
+// OperateArgs args for cancel, complete, reclaim.
+func (w *ConvertWorker) OperateArgs() scheduler.OperateTaskArgs {
+	return scheduler.OperateTaskArgs{
+		TaskID:   w.t.TaskID,
+		TaskType: w.t.TaskType,
+		Src:      w.t.Sources,
+		Dest:     w.t.Destination,
+	}
+}
+
+// TaskType returns task type
+func (w *ConvertWorker) TaskType() (taskType proto.TaskType) {
+	return w.t.TaskType
+}
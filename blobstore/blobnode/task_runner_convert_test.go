@@ -0,0 +1,146 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobnode
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	api "github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/blobnode/base/workutils"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	"github.com/cubefs/cubefs/blobstore/common/ec"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func readMockShard(t *testing.T, getter *MockGetter, location proto.VunitLocation, bid proto.BlobID) []byte {
+	body, _, err := getter.GetShard(context.Background(), location, bid, api.BackgroundIO)
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return data
+}
+
+func TestConvertExecTasklet(t *testing.T) {
+	ctx := context.Background()
+	mode := codemode.EC6P6
+	destMode := codemode.EC6P10L2
+	replicas := genMockVol(100, mode)
+	destTactic := destMode.Tactic()
+	dests := make([]proto.VunitLocation, destTactic.N+destTactic.M+destTactic.L)
+	for i := range dests {
+		vuid, _ := proto.NewVuid(100, uint8(i), 2)
+		dests[i] = proto.VunitLocation{Vuid: vuid, Host: "127.0.0.1:xxxx", DiskID: 2}
+	}
+	convertTask := &proto.MigrateTask{
+		TaskID:       "mock_volume_convert_task_id",
+		TaskType:     proto.TaskTypeVolumeConvert,
+		CodeMode:     mode,
+		DestCodeMode: destMode,
+		Sources:      replicas,
+		Destination:  dests[0],
+		Destinations: dests,
+		SourceVuid:   replicas[0].Vuid,
+	}
+	bids := []proto.BlobID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	sizes := []int64{1024, 2048, 0, 512, 23, 65, 12, 50, 100, 2047}
+
+	workutils.TaskBufPool = workutils.NewBufPool(&workutils.BufConfig{
+		MigrateBufSize:     2 * 1024,
+		MigrateBufCapacity: 100,
+		RepairBufSize:      1,
+		RepairBufCapacity:  1,
+	})
+	defer func() { workutils.TaskBufPool = nil }()
+	getter := NewMockGetterWithBids(replicas, mode, bids, sizes)
+	for _, dest := range dests {
+		getter.vunits[dest.Vuid] = newMockVunit(dest.Vuid, api.ChunkStatusNormal)
+	}
+
+	// the missing data shards need to be recovered by ec
+	getter.Delete(ctx, replicas[1].Vuid, bids[0])
+	getter.Delete(ctx, replicas[4].Vuid, bids[1])
+
+	w := NewMigrateWorker(MigrateTaskEx{taskInfo: convertTask, blobNodeCli: getter, downloadShardConcurrency: 1})
+	require.IsType(t, &ConvertWorker{}, w)
+	tasklets, werr := w.GenTasklets(ctx)
+	require.Nil(t, werr)
+	require.Equal(t, 4, len(tasklets))
+	for _, tasklet := range tasklets {
+		require.Nil(t, w.ExecTasklet(ctx, tasklet))
+	}
+	require.Nil(t, w.Check(ctx))
+
+	// the joined data shards of source is the prefix of the joined data shards of destination
+	encoder, err := ec.NewEncoder(ec.Config{CodeMode: destTactic, EnableVerify: true})
+	require.NoError(t, err)
+	for i, bid := range bids {
+		var srcData, destData []byte
+		for _, replica := range replicas[:mode.Tactic().N] {
+			// the data of deleted shards are still kept in mock vunit
+			srcData = append(srcData, getter.vunits[replica.Vuid].shards[bid]...)
+		}
+		shards := make([][]byte, len(dests))
+		for idx, dest := range dests {
+			shards[idx] = readMockShard(t, getter, dest, bid)
+			destData = append(destData, shards[idx]...)
+		}
+		require.Equal(t, int(sizes[i])*mode.Tactic().N, len(srcData))
+		if sizes[i] == 0 {
+			require.Equal(t, 0, len(destData))
+			continue
+		}
+		require.True(t, bytes.HasPrefix(destData, srcData))
+		ok, err := encoder.Verify(shards)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// all bids have been converted
+	tasklets, werr = w.GenTasklets(ctx)
+	require.Nil(t, werr)
+	require.Equal(t, 0, len(tasklets))
+
+	// check failed when destination missing bid
+	getter.Delete(ctx, dests[3].Vuid, bids[0])
+	werr = w.Check(ctx)
+	require.NotNil(t, werr)
+	require.ErrorIs(t, werr.err, ErrBidMissing)
+}
+
+func TestConvertArgs(t *testing.T) {
+	mode := codemode.EC6P6
+	replicas := genMockVol(100, mode)
+	convertTask := &proto.MigrateTask{
+		TaskID:       "mock_volume_convert_task_id",
+		TaskType:     proto.TaskTypeVolumeConvert,
+		CodeMode:     mode,
+		DestCodeMode: codemode.EC6P10L2,
+		Sources:      replicas,
+		Destination:  replicas[0],
+		SourceVuid:   replicas[0].Vuid,
+	}
+	w := NewConvertWorker(MigrateTaskEx{taskInfo: convertTask})
+	args := w.OperateArgs()
+	require.Equal(t, convertTask.TaskID, args.TaskID)
+	require.Equal(t, proto.TaskTypeVolumeConvert, args.TaskType)
+	require.Equal(t, replicas, args.Src)
+	require.Equal(t, replicas[0], args.Dest)
+	require.Equal(t, proto.TaskTypeVolumeConvert, w.TaskType())
+}
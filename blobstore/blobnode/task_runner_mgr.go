@@ -61,6 +61,7 @@ func NewTaskRunnerMgr(idc string, meter WorkerConfigMeter, genWorker WorkerGener
 			proto.TaskTypeDiskDrop:      make(mapTaskRunner),
 			proto.TaskTypeDiskRepair:    make(mapTaskRunner),
 			proto.TaskTypeManualMigrate: make(mapTaskRunner),
+			proto.TaskTypeVolumeConvert: make(mapTaskRunner),
 		},
 
 		idc:          idc,
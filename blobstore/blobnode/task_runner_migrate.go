@@ -56,6 +56,9 @@ type MigrateTaskEx struct {
 
 // NewMigrateWorker returns migrate worker
 func NewMigrateWorker(task MigrateTaskEx) ITaskWorker {
+	if task.taskInfo.TaskType == proto.TaskTypeVolumeConvert {
+		return NewConvertWorker(task)
+	}
 	return &MigrateWorker{
 		t:                        task.taskInfo,
 		bolbNodeCli:              task.blobNodeCli,
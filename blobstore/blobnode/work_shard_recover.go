@@ -840,7 +840,8 @@ func (r *ShardRecover) allocBuf(ctx context.Context, vunitIdxs []uint8) error {
 			switch r.taskType {
 			case proto.TaskTypeShardRepair:
 				buf, err = workutils.TaskBufPool.GetRepairBuf()
-			case proto.TaskTypeDiskRepair, proto.TaskTypeBalance, proto.TaskTypeManualMigrate, proto.TaskTypeDiskDrop:
+			case proto.TaskTypeDiskRepair, proto.TaskTypeBalance, proto.TaskTypeManualMigrate, proto.TaskTypeDiskDrop,
+				proto.TaskTypeVolumeConvert:
 				buf, err = workutils.TaskBufPool.GetMigrateBuf()
 			default:
 				err = errors.New("unknown type")
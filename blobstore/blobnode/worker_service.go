@@ -46,6 +46,8 @@ type WorkerConfigMeter struct {
 	DiskDropConcurrency int `json:"disk_drop_concurrency"`
 	// tasklet concurrency of single manual migrate task
 	ManualMigrateConcurrency int `json:"manual_migrate_concurrency"`
+	// tasklet concurrency of single volume convert task
+	VolumeConvertConcurrency int `json:"volume_convert_concurrency"`
 	// shard repair concurrency
 	ShardRepairConcurrency int `json:"shard_repair_concurrency"`
 	// volume inspect concurrency
@@ -65,6 +67,8 @@ func (meter *WorkerConfigMeter) concurrencyByType(taskType proto.TaskType) int {
 		return meter.DiskDropConcurrency
 	case proto.TaskTypeManualMigrate:
 		return meter.ManualMigrateConcurrency
+	case proto.TaskTypeVolumeConvert:
+		return meter.VolumeConvertConcurrency
 	default:
 		return 0
 	}
@@ -110,6 +114,7 @@ func (cfg *WorkerConfig) checkAndFix() {
 	fixConfigItemInt(&cfg.BalanceConcurrency, 1)
 	fixConfigItemInt(&cfg.DiskDropConcurrency, 1)
 	fixConfigItemInt(&cfg.ManualMigrateConcurrency, 10)
+	fixConfigItemInt(&cfg.VolumeConvertConcurrency, 1)
 	fixConfigItemInt(&cfg.ShardRepairConcurrency, 1)
 	fixConfigItemInt(&cfg.InspectConcurrency, 1)
 	fixConfigItemInt(&cfg.DownloadShardConcurrency, 10)
@@ -21,6 +21,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/cli/common"
 	"github.com/cubefs/cubefs/blobstore/cli/common/fmt"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/scheduler/client"
@@ -59,6 +60,16 @@ func addCmdMigrateTask(cmd *grumble.Command) {
 			f.Bool("", _directDownload, true, "whether download directly")
 		},
 	})
+	migrateCommand.AddCommand(&grumble.Command{
+		Name: "convert",
+		Help: "add volume convert task",
+		Run:  cmdAddConvertTask,
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+			f.Uint64L("vid", 0, "set the vid")
+			f.StringL("codemode", "", "set the destination codemode, such as EC6P6")
+		},
+	})
 	migrateCommand.AddCommand(&grumble.Command{
 		Name: "list",
 		Help: "list migrate tasks",
@@ -91,7 +102,7 @@ func addCmdMigrateTask(cmd *grumble.Command) {
 
 func migrateFlags(f *grumble.Flags) {
 	clusterFlags(f)
-	f.StringL(_taskType, "", "task_type, such as disk_repair, disk_drop, balance and manual_migrate")
+	f.StringL(_taskType, "", "task_type, such as disk_repair, disk_drop, balance, manual_migrate and volume_convert")
 }
 
 func cmdGetTask(c *grumble.Context) error {
@@ -136,6 +147,30 @@ func cmdAddTask(c *grumble.Context) error {
 	return nil
 }
 
+func cmdAddConvertTask(c *grumble.Context) error {
+	ctx := common.CmdContext()
+	clusterID := getClusterID(c.Flags)
+	vid := proto.Vid(c.Flags.Uint64("vid"))
+	mode := codemode.CodeModeName(c.Flags.String("codemode"))
+	if !mode.IsValid() {
+		return fmt.Errorf("invalid codemode: %s", mode)
+	}
+	if !common.Confirm(fmt.Sprintf("add volume convert task: vid[%d], codemode[%s] ?", vid, mode)) {
+		return nil
+	}
+	clusterMgrCli := newClusterMgrClient(clusterID)
+	cli := scheduler.New(&scheduler.Config{}, clusterMgrCli, clusterID)
+	err := cli.AddVolumeConvertTask(ctx, &scheduler.AddVolumeConvertArgs{
+		Vid:      vid,
+		CodeMode: mode.GetCodeMode(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("add volume convert task successfully")
+	return nil
+}
+
 func cmdListTask(c *grumble.Context) error {
 	ctx := common.CmdContext()
 	taskType := proto.TaskType(c.Flags.String(_taskType))
@@ -98,6 +98,10 @@ func NewHandler(service *Service) *rpc.Router {
 
 	rpc.GET("/volume/unit/list", service.VolumeUnitList, rpc.OptArgsQuery())
 
+	rpc.POST("/volume/convert/alloc", service.VolumeConvertAlloc, rpc.OptArgsBody())
+
+	rpc.POST("/volume/convert", service.VolumeConvert, rpc.OptArgsBody())
+
 	rpc.GET("/volume/allocated/list", service.VolumeAllocatedList, rpc.OptArgsQuery())
 
 	rpc.POST("/admin/update/volume/unit", service.AdminUpdateVolumeUnit, rpc.OptArgsBody())
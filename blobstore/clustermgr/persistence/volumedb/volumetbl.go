@@ -52,6 +52,7 @@ type VolumeRecord struct {
 	Free           uint64
 	Used           uint64
 	CreateByNodeID uint64
+	ConvertedFrom  codemode.CodeMode
 }
 
 type VolumeTaskRecord struct {
@@ -270,6 +271,35 @@ func (v *VolumeTable) PutVolumeAndVolumeUnit(volumeRecs []*VolumeRecord, volumeU
 	return v.volTbl.DoBatch(batch)
 }
 
+// ConvertVolume replace all units of the converted volume, old units not in the new units will be deleted
+func (v *VolumeTable) ConvertVolume(volRec *VolumeRecord, units, oldUnits []*VolumeUnitRecord) (err error) {
+	batch := v.volTbl.NewWriteBatch()
+	defer batch.Destroy()
+
+	indexName := v.indexes[volumeUintDiskIDIndex].indexName
+	indexCf := v.indexes[volumeUintDiskIDIndex].indexTbl.GetCf()
+	for _, unit := range oldUnits {
+		batch.DeleteCF(indexCf, []byte(fmtIndexKey(indexName, unit.DiskID, unit.VuidPrefix)))
+		batch.DeleteCF(v.unitTbl.GetCf(), encodeVuidPrefix(unit.VuidPrefix))
+	}
+	for _, unit := range units {
+		unitKey := encodeVuidPrefix(unit.VuidPrefix)
+		uRec, err := encodeVolumeUnitRecord(unit)
+		if err != nil {
+			return err
+		}
+		batch.PutCF(indexCf, []byte(fmtIndexKey(indexName, unit.DiskID, unit.VuidPrefix)), unitKey)
+		batch.PutCF(v.unitTbl.GetCf(), unitKey, uRec)
+	}
+	valueVol, err := encodeVolumeRecord(volRec)
+	if err != nil {
+		return err
+	}
+	batch.PutCF(v.volTbl.GetCf(), EncodeVid(volRec.Vid), valueVol)
+
+	return v.volTbl.DoBatch(batch)
+}
+
 func (v *VolumeTable) RangeVolumeRecord(f func(Record *VolumeRecord) error) (err error) {
 	snap := v.volTbl.NewSnapshot()
 	defer v.volTbl.ReleaseSnapshot(snap)
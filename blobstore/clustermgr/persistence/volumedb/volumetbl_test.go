@@ -386,6 +386,50 @@ func TestVolumeTable_UpdateVolumeUnit(t *testing.T) {
 	require.Equal(t, 1, len(ret))
 }
 
+func TestVolumeTable_ConvertVolume(t *testing.T) {
+	initVolumeDB()
+	defer closeVolumeDB()
+
+	vid := proto.Vid(10)
+	oldUnits := make([]*VolumeUnitRecord, 3)
+	vol := &VolumeRecord{Vid: vid, CodeMode: 1, Status: proto.VolumeStatusLock}
+	for i := range oldUnits {
+		oldUnits[i] = &VolumeUnitRecord{VuidPrefix: proto.EncodeVuidPrefix(vid, uint8(i)), Epoch: 1, NextEpoch: 4, DiskID: 100}
+		vol.VuidPrefixs = append(vol.VuidPrefixs, oldUnits[i].VuidPrefix)
+	}
+	err := volumeTable.PutVolumeAndVolumeUnit([]*VolumeRecord{vol}, [][]*VolumeUnitRecord{oldUnits})
+	require.NoError(t, err)
+
+	newVol := *vol
+	newVol.VuidPrefixs = nil
+	newVol.CodeMode = 2
+	newVol.ConvertedFrom = 1
+	newUnits := make([]*VolumeUnitRecord, 2)
+	for i := range newUnits {
+		newUnits[i] = &VolumeUnitRecord{VuidPrefix: proto.EncodeVuidPrefix(vid, uint8(i)), Epoch: 2, NextEpoch: 4, DiskID: 200}
+		newVol.VuidPrefixs = append(newVol.VuidPrefixs, newUnits[i].VuidPrefix)
+	}
+	err = volumeTable.ConvertVolume(&newVol, newUnits, oldUnits)
+	require.NoError(t, err)
+
+	rec, err := volumeTable.GetVolume(vid)
+	require.NoError(t, err)
+	require.Equal(t, newVol, *rec)
+
+	ret, err := volumeTable.ListVolumeUnit(100)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(ret))
+	ret, err = volumeTable.ListVolumeUnit(200)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ret))
+
+	unit, err := volumeTable.GetVolumeUnit(newUnits[0].VuidPrefix)
+	require.NoError(t, err)
+	require.Equal(t, newUnits[0], unit)
+	_, err = volumeTable.GetVolumeUnit(oldUnits[2].VuidPrefix)
+	require.Error(t, err)
+}
+
 func TestVolumeUnitTable_PutBatch(t *testing.T) {
 	initVolumeDB()
 	defer closeVolumeDB()
@@ -314,6 +314,60 @@ func (s *Service) VolumeUnitRelease(c *rpc.Context) {
 	c.RespondError(s.VolumeMgr.ReleaseVolumeUnit(ctx, args.Vuid, args.DiskID, false))
 }
 
+func (s *Service) VolumeConvertAlloc(c *rpc.Context) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	args := new(clustermgr.AllocConvertVolumeUnitsArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	span.Debugf("accept VolumeConvertAlloc request, args: %v", args)
+
+	units, err := s.VolumeMgr.AllocConvertVolumeUnits(ctx, args.Vid, args.CodeMode)
+	if err != nil {
+		span.Error("alloc convert volume units failed, err: ", errors.Detail(err))
+		c.RespondError(err)
+		return
+	}
+	c.RespondJSON(&clustermgr.ConvertVolumeUnits{Units: units})
+}
+
+func (s *Service) VolumeConvert(c *rpc.Context) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	args := new(clustermgr.ConvertVolumeArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	span.Debugf("accept VolumeConvert request, args: %v", args)
+
+	err := s.VolumeMgr.PreConvertVolume(ctx, args)
+	if err != nil {
+		if err == volumemgr.ErrRepeatConvertVolume {
+			span.Info("repeat convert volume, ignore and return success")
+			return
+		}
+		span.Errorf("convert volume error:%v", err)
+		c.RespondError(err)
+		return
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		span.Errorf("convert json marshal failed, args: %v, error: %v", args, err)
+		c.RespondError(apierrors.ErrCMUnexpect)
+		return
+	}
+	proposeInfo := base.EncodeProposeInfo(s.VolumeMgr.GetModuleName(), volumemgr.OperTypeConvertVolume, data, base.ProposeContext{ReqID: span.TraceID()})
+	err = s.raftNode.Propose(ctx, proposeInfo)
+	if err != nil {
+		span.Error(err)
+		c.RespondError(apierrors.ErrRaftPropose)
+		return
+	}
+}
+
 func (s *Service) ChunkReport(c *rpc.Context) {
 	ctx := c.Request.Context()
 	span := trace.SpanFromContextSafe(ctx)
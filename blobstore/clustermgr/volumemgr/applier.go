@@ -47,6 +47,8 @@ const (
 	OperTypeAdminUpdateVolumeUnit
 	OperTypeInitCreateVolume
 	OperTypeIncreaseVolumeUnitsEpoch
+	OperTypeAllocConvertVolumeUnits
+	OperTypeConvertVolume
 )
 
 type CreateVolumeCtx struct {
@@ -397,6 +399,36 @@ func (v *VolumeMgr) Apply(ctx context.Context, operTypes []int32, datas [][]byte
 				wg.Done()
 			})
 
+		case OperTypeAllocConvertVolumeUnits:
+			args := &allocConvertVolumeUnitsCtx{}
+			err := json.Unmarshal(datas[idx], args)
+			if err != nil {
+				errs[idx] = errors.Info(err, t, datas[idx]).Detail(err)
+				wg.Done()
+				continue
+			}
+			v.applyTaskPool.Run(v.getTaskIdx(args.Vid), func() {
+				if err = v.applyAllocConvertVolumeUnits(taskCtx, args); err != nil {
+					errs[idx] = errors.Info(err, "apply alloc convert volume units failed, args: ", args).Detail(err)
+				}
+				wg.Done()
+			})
+
+		case OperTypeConvertVolume:
+			args := &clustermgr.ConvertVolumeArgs{}
+			err := json.Unmarshal(datas[idx], args)
+			if err != nil {
+				errs[idx] = errors.Info(err, t, datas[idx]).Detail(err)
+				wg.Done()
+				continue
+			}
+			v.applyTaskPool.Run(v.getTaskIdx(args.Vid), func() {
+				if err = v.applyConvertVolume(taskCtx, args); err != nil {
+					errs[idx] = errors.Info(err, "apply convert volume failed, args: ", args).Detail(err)
+				}
+				wg.Done()
+			})
+
 		default:
 			errs[idx] = errors.New("unsupported operation")
 			wg.Done()
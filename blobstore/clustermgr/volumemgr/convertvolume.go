@@ -0,0 +1,265 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package volumemgr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	cm "github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/base"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	apierrors "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/errors"
+)
+
+type allocConvertVolumeUnitsCtx struct {
+	Vid       proto.Vid `json:"vid"`
+	NextEpoch uint32    `json:"next_epoch"`
+}
+
+// checkConvertVolume check the volume can be converted into the code mode, must be called with volume's lock
+func (v *VolumeMgr) checkConvertVolume(ctx context.Context, vol *volume, mode codemode.CodeMode) error {
+	span := trace.SpanFromContextSafe(ctx)
+	if _, ok := v.codeMode[mode]; !ok {
+		span.Warnf("convert volume %d into unknown code mode %s", vol.vid, mode.String())
+		return ErrInvalidCodeMode
+	}
+	if vol.volInfoBase.ConvertedFrom != 0 || vol.volInfoBase.CodeMode == mode {
+		span.Warnf("can't convert volume %d from %s into %s, converted from %s", vol.vid,
+			vol.volInfoBase.CodeMode.String(), mode.String(), vol.volInfoBase.ConvertedFrom.String())
+		return apierrors.ErrConvertVolumeNotAllow
+	}
+	if status := vol.getStatus(); status != proto.VolumeStatusLock {
+		span.Warnf("can't convert volume %d, current status(%d)", vol.vid, status)
+		return apierrors.ErrConvertVolumeNotAllow
+	}
+	return nil
+}
+
+// AllocConvertVolumeUnits alloc chunks of all units in the target code mode for volume convert,
+// the epoch of new units are reserved by increasing all volume units' nextEpoch, so that a new
+// vuid will never conflict with the old chunks of the volume or the chunks allocated by last retry
+func (v *VolumeMgr) AllocConvertVolumeUnits(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) ([]cm.Unit, error) {
+	span := trace.SpanFromContextSafe(ctx)
+	vol := v.all.getVol(vid)
+	if vol == nil {
+		return nil, ErrVolumeNotExist
+	}
+
+	vol.lock.RLock()
+	if err := v.checkConvertVolume(ctx, vol, mode); err != nil {
+		vol.lock.RUnlock()
+		return nil, err
+	}
+	nextEpoch := uint32(0)
+	for _, unit := range vol.vUnits {
+		if unit.nextEpoch > nextEpoch {
+			nextEpoch = unit.nextEpoch
+		}
+	}
+	vol.lock.RUnlock()
+	nextEpoch += IncreaseEpochInterval
+
+	data, err := json.Marshal(&allocConvertVolumeUnitsCtx{Vid: vid, NextEpoch: nextEpoch})
+	if err != nil {
+		return nil, errors.Info(err, "json marshal failed").Detail(err)
+	}
+	err = v.raftServer.Propose(ctx, base.EncodeProposeInfo(v.GetModuleName(), OperTypeAllocConvertVolumeUnits, data, base.ProposeContext{ReqID: span.TraceID()}))
+	if err != nil {
+		return nil, errors.Info(err, "propose failed").Detail(err)
+	}
+
+	unitCount := v.getModeUnitCount(mode)
+	vuInfos := make([]*cm.VolumeUnitInfo, unitCount)
+	for index := 0; index < unitCount; index++ {
+		vuInfos[index] = &cm.VolumeUnitInfo{
+			DiskID: proto.InvalidDiskID,
+			Free:   v.ChunkSize,
+			Total:  v.ChunkSize,
+			Vuid:   proto.EncodeVuid(proto.EncodeVuidPrefix(vid, uint8(index)), nextEpoch-IncreaseEpochInterval+1),
+		}
+	}
+	createVolCtx := &CreateVolumeCtx{
+		Vid:     vid,
+		VuInfos: vuInfos,
+		VolInfo: cm.VolumeInfoBase{Vid: vid, CodeMode: mode},
+	}
+	if err = v.allocChunkForAllUnits(ctx, createVolCtx); err != nil {
+		return nil, errors.Info(err, fmt.Sprintf("alloc chunk for convert volume[%d] unit failed", vid)).Detail(err)
+	}
+
+	units := make([]cm.Unit, unitCount)
+	for i, vuInfo := range vuInfos {
+		units[i] = cm.Unit{Vuid: vuInfo.Vuid, DiskID: vuInfo.DiskID, Host: vuInfo.Host}
+	}
+	span.Debugf("alloc convert volume units success, vid: %d, units: %+v", vid, units)
+	return units, nil
+}
+
+// PreConvertVolume check the convert volume arguments before raft propose
+func (v *VolumeMgr) PreConvertVolume(ctx context.Context, args *cm.ConvertVolumeArgs) error {
+	span := trace.SpanFromContextSafe(ctx)
+	vol := v.all.getVol(args.Vid)
+	if vol == nil {
+		return ErrVolumeNotExist
+	}
+
+	vol.lock.RLock()
+	defer vol.lock.RUnlock()
+
+	// idempotent retry convert volume, return success
+	if vol.volInfoBase.ConvertedFrom != 0 && vol.volInfoBase.CodeMode == args.CodeMode && len(vol.vUnits) == len(args.Units) {
+		repeat := true
+		for i, unit := range vol.vUnits {
+			if unit.vuInfo.Vuid != args.Units[i].Vuid {
+				repeat = false
+				break
+			}
+		}
+		if repeat {
+			return ErrRepeatConvertVolume
+		}
+	}
+	if err := v.checkConvertVolume(ctx, vol, args.CodeMode); err != nil {
+		return err
+	}
+	if len(args.Units) != v.getModeUnitCount(args.CodeMode) {
+		span.Errorf("convert volume %d units count %d not match code mode %s", args.Vid, len(args.Units), args.CodeMode.String())
+		return apierrors.ErrIllegalArguments
+	}
+	for i, unit := range args.Units {
+		if unit.Vuid.Vid() != args.Vid || int(unit.Vuid.Index()) != i || !unit.Vuid.IsValid() {
+			span.Errorf("convert volume %d with invalid unit %d at index %d", args.Vid, unit.Vuid, i)
+			return apierrors.ErrIllegalArguments
+		}
+		if i < len(vol.vUnits) && (unit.Vuid.Epoch() <= vol.vUnits[i].epoch || unit.Vuid.Epoch() > vol.vUnits[i].nextEpoch) {
+			span.Errorf("convert volume %d unit %d epoch not match, current epoch: %d, next epoch: %d",
+				args.Vid, unit.Vuid, vol.vUnits[i].epoch, vol.vUnits[i].nextEpoch)
+			return ErrNewVuidNotMatch
+		}
+		if _, err := v.diskMgr.GetDiskInfo(ctx, unit.DiskID); err != nil {
+			span.Errorf("convert volume %d unit %d disk %d not exist", args.Vid, unit.Vuid, unit.DiskID)
+			return apierrors.ErrCMDiskNotFound
+		}
+	}
+	return nil
+}
+
+func (v *VolumeMgr) applyAllocConvertVolumeUnits(ctx context.Context, args *allocConvertVolumeUnitsCtx) error {
+	span := trace.SpanFromContextSafe(ctx)
+	span.Debugf("start apply alloc convert volume units, args is %+v", args)
+
+	vol := v.all.getVol(args.Vid)
+	if vol == nil {
+		span.Errorf("alloc convert vid:%d get volume is nil ", args.Vid)
+		return ErrVolumeNotExist
+	}
+
+	vol.lock.Lock()
+	defer vol.lock.Unlock()
+	units := make([]*volumeUnit, 0, len(vol.vUnits))
+	for _, unit := range vol.vUnits {
+		// concurrent alloc or wal log replay, do nothing
+		if unit.nextEpoch >= args.NextEpoch {
+			continue
+		}
+		unit.nextEpoch = args.NextEpoch
+		units = append(units, unit)
+	}
+	if len(units) == 0 {
+		return nil
+	}
+	return v.volumeTbl.PutVolumeUnits(volumeUnitsToVolumeUnitRecords(units))
+}
+
+// applyConvertVolume replace all volume units with the converted units and switch the volume's code mode
+func (v *VolumeMgr) applyConvertVolume(ctx context.Context, args *cm.ConvertVolumeArgs) error {
+	span := trace.SpanFromContextSafe(ctx)
+	span.Debugf("start apply convert volume, args is %+v", args)
+
+	vol := v.all.getVol(args.Vid)
+	if vol == nil {
+		span.Errorf("convert vid:%d get volume is nil ", args.Vid)
+		return ErrVolumeNotExist
+	}
+	modeConf, ok := v.codeMode[args.CodeMode]
+	if !ok {
+		return ErrInvalidCodeMode
+	}
+
+	vol.lock.Lock()
+	// volume already converted, wal log replay or repeat apply
+	if vol.volInfoBase.ConvertedFrom != 0 {
+		vol.lock.Unlock()
+		return nil
+	}
+
+	vUnits := make([]*volumeUnit, len(args.Units))
+	for i, unit := range args.Units {
+		diskInfo, err := v.diskMgr.GetDiskInfo(ctx, unit.DiskID)
+		if err != nil {
+			vol.lock.Unlock()
+			span.Errorf("get diskInfo failed, diskID is %d", unit.DiskID)
+			return err
+		}
+		nextEpoch := unit.Vuid.Epoch()
+		if i < len(vol.vUnits) && vol.vUnits[i].nextEpoch > nextEpoch {
+			nextEpoch = vol.vUnits[i].nextEpoch
+		}
+		vUnits[i] = &volumeUnit{
+			vuidPrefix: unit.Vuid.VuidPrefix(),
+			epoch:      unit.Vuid.Epoch(),
+			nextEpoch:  nextEpoch,
+			vuInfo: &cm.VolumeUnitInfo{
+				Vuid:   unit.Vuid,
+				DiskID: unit.DiskID,
+				Host:   diskInfo.Host,
+				Free:   v.ChunkSize,
+				Total:  v.ChunkSize,
+			},
+		}
+	}
+
+	oldUnitRecords := volumeUnitsToVolumeUnitRecords(vol.vUnits)
+	vol.vUnits = vUnits
+	vol.smallestVUIdx = 0
+	vol.volInfoBase.ConvertedFrom = vol.volInfoBase.CodeMode
+	vol.volInfoBase.CodeMode = args.CodeMode
+	vol.volInfoBase.Total = v.ChunkSize * uint64(modeConf.tactic.N)
+	free := uint64(0)
+	if vol.volInfoBase.Total > vol.volInfoBase.Used {
+		free = vol.volInfoBase.Total - vol.volInfoBase.Used
+	}
+	vol.setFree(ctx, free)
+
+	err := v.volumeTbl.ConvertVolume(vol.ToRecord(), volumeUnitsToVolumeUnitRecords(vUnits), oldUnitRecords)
+	vol.lock.Unlock()
+	if err != nil {
+		return errors.Info(err, "convert volume in volume table failed").Detail(err)
+	}
+
+	// refresh health
+	if err = v.refreshHealth(ctx, args.Vid); err != nil {
+		span.Errorf("refresh health failed, vid is %d, error is %v", args.Vid, err)
+		return err
+	}
+
+	span.Debugf("finish apply convert volume")
+	return nil
+}
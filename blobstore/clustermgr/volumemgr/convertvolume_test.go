@@ -0,0 +1,131 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package volumemgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/diskmgr"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	apierrors "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/testing/mocks"
+)
+
+func TestVolumeMgr_ConvertVolume(t *testing.T) {
+	mockVolumeMgr, clean := initMockVolumeMgr(t)
+	defer clean()
+
+	ctr := gomock.NewController(t)
+	mockRaftServer := mocks.NewMockRaftServer(ctr)
+	mockDiskMgr := NewMockDiskMgrAPI(ctr)
+	mockDiskMgr.EXPECT().AllocChunks(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, policy *diskmgr.AllocPolicy) ([]proto.DiskID, error) {
+		var diskids []proto.DiskID
+		for _, vuid := range policy.Vuids {
+			diskids = append(diskids, proto.DiskID(100+int(vuid.Index())))
+		}
+		return diskids, nil
+	})
+	mockDiskMgr.EXPECT().IsDiskWritable(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(mockIsDiskWritable)
+	mockDiskMgr.EXPECT().GetDiskInfo(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(mockGetDiskInfo)
+	mockVolumeMgr.diskMgr = mockDiskMgr
+	mockVolumeMgr.raftServer = mockRaftServer
+
+	mode := codemode.EC6P6
+	mockVolumeMgr.codeMode[mode] = codeModeConf{mode: mode, tactic: mode.Tactic(), enable: false}
+	_, ctx := trace.StartSpanFromContext(context.Background(), "")
+	vid := proto.Vid(2)
+	vol := mockVolumeMgr.all.getVol(vid)
+
+	// failed case, volume not locked
+	_, err := mockVolumeMgr.AllocConvertVolumeUnits(ctx, vid, mode)
+	require.ErrorIs(t, err, apierrors.ErrConvertVolumeNotAllow)
+	vol.lock.Lock()
+	vol.setStatus(ctx, proto.VolumeStatusLock)
+	vol.lock.Unlock()
+
+	// failed case, unknown code mode or same code mode
+	_, err = mockVolumeMgr.AllocConvertVolumeUnits(ctx, vid, codemode.EC16P20L2)
+	require.ErrorIs(t, err, ErrInvalidCodeMode)
+	_, err = mockVolumeMgr.AllocConvertVolumeUnits(ctx, vid, codemode.EC15P12)
+	require.ErrorIs(t, err, apierrors.ErrConvertVolumeNotAllow)
+	_, err = mockVolumeMgr.AllocConvertVolumeUnits(ctx, proto.Vid(44), mode)
+	require.ErrorIs(t, err, ErrVolumeNotExist)
+
+	mockRaftServer.EXPECT().Propose(gomock.Any(), gomock.Any()).Return(nil)
+	units, err := mockVolumeMgr.AllocConvertVolumeUnits(ctx, vid, mode)
+	require.NoError(t, err)
+	require.Equal(t, mode.GetShardNum(), len(units))
+	for i, unit := range units {
+		require.Equal(t, proto.EncodeVuid(proto.EncodeVuidPrefix(vid, uint8(i)), 2), unit.Vuid)
+		require.Equal(t, proto.DiskID(100+i), unit.DiskID)
+		require.Equal(t, "127.0.0.1", unit.Host)
+	}
+
+	// apply alloc convert units reserve epoch of all units, and it's idempotent
+	for i := 0; i < 2; i++ {
+		err = mockVolumeMgr.applyAllocConvertVolumeUnits(ctx, &allocConvertVolumeUnitsCtx{Vid: vid, NextEpoch: 1 + IncreaseEpochInterval})
+		require.NoError(t, err)
+		for _, unit := range vol.vUnits {
+			require.Equal(t, uint32(1+IncreaseEpochInterval), unit.nextEpoch)
+		}
+	}
+	unitRec, err := mockVolumeMgr.volumeTbl.GetVolumeUnit(proto.EncodeVuidPrefix(vid, 20))
+	require.NoError(t, err)
+	require.Equal(t, uint32(1+IncreaseEpochInterval), unitRec.NextEpoch)
+
+	// failed case, invalid units
+	args := &clustermgr.ConvertVolumeArgs{Vid: vid, CodeMode: mode, Units: units[:len(units)-1]}
+	require.ErrorIs(t, mockVolumeMgr.PreConvertVolume(ctx, args), apierrors.ErrIllegalArguments)
+	badUnits := append([]clustermgr.Unit{}, units...)
+	badUnits[0].Vuid = proto.EncodeVuid(badUnits[0].Vuid.VuidPrefix(), 1)
+	args.Units = badUnits
+	require.ErrorIs(t, mockVolumeMgr.PreConvertVolume(ctx, args), ErrNewVuidNotMatch)
+
+	args.Units = units
+	require.NoError(t, mockVolumeMgr.PreConvertVolume(ctx, args))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, mockVolumeMgr.applyConvertVolume(ctx, args))
+		require.Equal(t, mode, vol.volInfoBase.CodeMode)
+		require.Equal(t, codemode.EC15P12, vol.volInfoBase.ConvertedFrom)
+		require.Equal(t, mode.GetShardNum(), len(vol.vUnits))
+		require.Equal(t, units[3].Vuid, vol.vUnits[3].vuInfo.Vuid)
+		require.Equal(t, uint32(1+IncreaseEpochInterval), vol.vUnits[3].nextEpoch)
+		require.Equal(t, mockVolumeMgr.ChunkSize*uint64(mode.Tactic().N), vol.volInfoBase.Total)
+	}
+
+	volRec, err := mockVolumeMgr.volumeTbl.GetVolume(vid)
+	require.NoError(t, err)
+	require.Equal(t, mode, volRec.CodeMode)
+	require.Equal(t, codemode.EC15P12, volRec.ConvertedFrom)
+	require.Equal(t, mode.GetShardNum(), len(volRec.VuidPrefixs))
+	_, err = mockVolumeMgr.volumeTbl.GetVolumeUnit(proto.EncodeVuidPrefix(vid, 20))
+	require.Error(t, err)
+	unitRec, err = mockVolumeMgr.volumeTbl.GetVolumeUnit(proto.EncodeVuidPrefix(vid, 3))
+	require.NoError(t, err)
+	require.Equal(t, proto.DiskID(103), unitRec.DiskID)
+	require.Equal(t, uint32(2), unitRec.Epoch)
+
+	// repeat convert return success, convert again is not allowed
+	require.ErrorIs(t, mockVolumeMgr.PreConvertVolume(ctx, args), ErrRepeatConvertVolume)
+	_, err = mockVolumeMgr.AllocConvertVolumeUnits(ctx, vid, codemode.EC15P12)
+	require.ErrorIs(t, err, apierrors.ErrConvertVolumeNotAllow)
+}
@@ -54,6 +54,7 @@ func (vol *volume) ToRecord() *volumedb.VolumeRecord {
 		Free:           vol.volInfoBase.Free,
 		Used:           vol.volInfoBase.Used,
 		CreateByNodeID: vol.volInfoBase.CreateByNodeID,
+		ConvertedFrom:  vol.volInfoBase.ConvertedFrom,
 	}
 }
 
@@ -299,6 +300,7 @@ func volumeRecordToVolumeInfoBase(volRecord *volumedb.VolumeRecord) cm.VolumeInf
 		Total:          volRecord.Total,
 		Free:           volRecord.Free,
 		CreateByNodeID: volRecord.CreateByNodeID,
+		ConvertedFrom:  volRecord.ConvertedFrom,
 	}
 }
 
@@ -75,6 +75,7 @@ var (
 	ErrInvalidVolume            = errors.New(" volume is invalid ")
 	ErrInvalidToken             = errors.New("retain token is invalid")
 	ErrRepeatUpdateUnit         = errors.New("repeat update volume unit")
+	ErrRepeatConvertVolume      = errors.New("repeat convert volume")
 )
 
 // VolumeMgr defines volume manager interface
@@ -114,6 +115,12 @@ type VolumeMgrAPI interface {
 	LockVolume(ctx context.Context, vid proto.Vid) error
 	UnlockVolume(ctx context.Context, vid proto.Vid) error
 
+	// AllocConvertVolumeUnits alloc chunks of all units in the target code mode for the locked volume
+	AllocConvertVolumeUnits(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) ([]cm.Unit, error)
+
+	// PreConvertVolume check the volume can be converted with the allocated units
+	PreConvertVolume(ctx context.Context, args *cm.ConvertVolumeArgs) error
+
 	// Stat return volume statistic info
 	Stat(ctx context.Context) (stat cm.VolumeStatInfo)
 }
@@ -84,6 +84,7 @@ var errCodeMap = map[int]string{
 	CodeNotSupportIdle:               "list volume v2 not support idle status",
 	CodeDiskIsDropping:               "dropping disk not allow change state or set readonly",
 	CodeRejectDeleteSystemConfig:     "reject delete system config",
+	CodeConvertVolumeNotAllow:        "convert volume not allow",
 	CodeRegisterServiceInvalidParams: "register service params is invalid",
 
 	// scheduler
@@ -93,6 +94,10 @@ var errCodeMap = map[int]string{
 	CodeNoAvaliableVolume: "this codemode has no avaliable volume",
 	CodeAllocBidFromCm:    "alloc bid from clustermgr error",
 	CodeClusterIDNotMatch: "clusterId not match",
+	CodeMQNotEnabled:      "embedded mq not enabled",
+	CodeMQIllegalTopic:    "embedded mq illegal topic",
+	CodeMQConsumeConflict: "embedded mq group is consumed by other consumer",
+	CodeMQOffsetOutRange:  "embedded mq offset out of range",
 
 	// blobnode
 	CodeInvalidParam:   "blobnode: invalid params",
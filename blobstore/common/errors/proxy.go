@@ -18,10 +18,18 @@ const (
 	CodeNoAvaliableVolume = 801
 	CodeAllocBidFromCm    = 802
 	CodeClusterIDNotMatch = 803
+	CodeMQNotEnabled      = 804
+	CodeMQIllegalTopic    = 805
+	CodeMQConsumeConflict = 806
+	CodeMQOffsetOutRange  = 807
 )
 
 var (
 	ErrNoAvaliableVolume = Error(CodeNoAvaliableVolume)
 	ErrAllocBidFromCm    = Error(CodeAllocBidFromCm)
 	ErrClusterIDNotMatch = Error(CodeClusterIDNotMatch)
+	ErrMQNotEnabled      = Error(CodeMQNotEnabled)
+	ErrMQIllegalTopic    = Error(CodeMQIllegalTopic)
+	ErrMQConsumeConflict = Error(CodeMQConsumeConflict)
+	ErrMQOffsetOutRange  = Error(CodeMQOffsetOutRange)
 )
@@ -43,12 +43,13 @@ const (
 	TaskTypeVolumeInspect TaskType = "volume_inspect"
 	TaskTypeShardRepair   TaskType = "shard_repair"
 	TaskTypeBlobDelete    TaskType = "blob_delete"
+	TaskTypeVolumeConvert TaskType = "volume_convert"
 )
 
 func (t TaskType) Valid() bool {
 	switch t {
 	case TaskTypeDiskRepair, TaskTypeBalance, TaskTypeDiskDrop, TaskTypeManualMigrate,
-		TaskTypeVolumeInspect, TaskTypeShardRepair, TaskTypeBlobDelete:
+		TaskTypeVolumeInspect, TaskTypeShardRepair, TaskTypeBlobDelete, TaskTypeVolumeConvert:
 		return true
 	default:
 		return false
@@ -111,6 +112,10 @@ type MigrateTask struct {
 	ForbiddenDirectDownload bool `json:"forbidden_direct_download"`
 
 	WorkerRedoCnt uint8 `json:"worker_redo_cnt"` // worker redo task count
+
+	// volume convert task re-encodes all blobs of Sources into Destinations
+	DestCodeMode codemode.CodeMode `json:"dest_code_mode,omitempty"` // destination codemode
+	Destinations []VunitLocation   `json:"destinations,omitempty"`   // destination volume units location
 }
 
 func (t *MigrateTask) Vid() Vid {
@@ -147,10 +152,19 @@ func (t *MigrateTask) Copy() *MigrateTask {
 	dst := make([]VunitLocation, len(t.Sources))
 	copy(dst, t.Sources)
 	task.Sources = dst
+	if t.Destinations != nil {
+		task.Destinations = make([]VunitLocation, len(t.Destinations))
+		copy(task.Destinations, t.Destinations)
+	}
 	return task
 }
 
 func (t *MigrateTask) IsValid() bool {
+	if t.TaskType == TaskTypeVolumeConvert {
+		return t.CodeMode.IsValid() && t.DestCodeMode.IsValid() && t.DestCodeMode != t.CodeMode &&
+			CheckVunitLocations(t.Sources) &&
+			CheckVunitLocations(t.Destinations) && len(t.Destinations) == t.DestCodeMode.GetShardNum()
+	}
 	return t.TaskType.Valid() && t.CodeMode.IsValid() &&
 		CheckVunitLocations(t.Sources) &&
 		CheckVunitLocations([]VunitLocation{t.Destination})
@@ -83,6 +83,33 @@ func TestSchedulerMigrateTask(t *testing.T) {
 	require.Equal(t, proto.DiskID(33), mt.DestinationDiskID())
 }
 
+func TestSchedulerVolumeConvertTask(t *testing.T) {
+	require.True(t, proto.TaskTypeVolumeConvert.Valid())
+
+	sVuid, _ := proto.NewVuid(111, 0, 1)
+	mt := proto.MigrateTask{
+		TaskID:       "task_id",
+		TaskType:     proto.TaskTypeVolumeConvert,
+		CodeMode:     codemode.EC6P6,
+		Sources:      []proto.VunitLocation{{Vuid: sVuid, Host: "src_host", DiskID: 11}},
+		DestCodeMode: codemode.EC3P3,
+	}
+	require.False(t, mt.IsValid())
+	for idx := 0; idx < codemode.EC3P3.GetShardNum(); idx++ {
+		dVuid, _ := proto.NewVuid(111, uint8(idx), 2)
+		mt.Destinations = append(mt.Destinations, proto.VunitLocation{Vuid: dVuid, Host: "dest_host", DiskID: 22})
+	}
+	require.True(t, mt.IsValid())
+
+	copied := mt.Copy()
+	require.Equal(t, mt, *copied)
+	copied.Destinations[0].DiskID = 33
+	require.Equal(t, proto.DiskID(22), mt.Destinations[0].DiskID)
+
+	mt.DestCodeMode = codemode.EC6P6
+	require.False(t, mt.IsValid())
+}
+
 func TestSchedulerTaskProgress(t *testing.T) {
 	{
 		tp := proto.NewTaskProgress()
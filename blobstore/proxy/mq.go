@@ -19,6 +19,7 @@ import (
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/rpc"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
 )
 
 // SendRepairMessage send repair message to kafka
@@ -79,3 +80,136 @@ func (s *Service) SendDeleteMessage(c *rpc.Context) {
 
 	c.Respond()
 }
+
+// MQProduce send messages to the embedded queue
+func (s *Service) MQProduce(c *rpc.Context) {
+	args := new(api.MQProduceArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if s.queue == nil {
+		c.RespondError(errcode.ErrMQNotEnabled)
+		return
+	}
+
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	span.Debugf("accept MQProduce request, topic: %s, msgs len: %d", args.Topic, len(args.Msgs))
+	if err := s.queue.Produce(args.Topic, args.Msgs); err != nil {
+		span.Errorf("produce messages failed: topic[%s], err[%+v]", args.Topic, err)
+		c.RespondError(mqError(err))
+		return
+	}
+	c.Respond()
+}
+
+// MQFetch fetch messages from the committed offset of group in the embedded queue
+func (s *Service) MQFetch(c *rpc.Context) {
+	args := new(api.MQFetchArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if s.queue == nil {
+		c.RespondError(errcode.ErrMQNotEnabled)
+		return
+	}
+
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	msgs, err := s.queue.Fetch(args.Topic, args.Group, args.Consumer, args.Count)
+	if err != nil {
+		span.Warnf("fetch messages failed: args[%+v], err[%+v]", args, err)
+		c.RespondError(mqError(err))
+		return
+	}
+
+	ret := api.MQFetchRet{Messages: make([]api.MQMessage, len(msgs))}
+	for i, msg := range msgs {
+		ret.Messages[i] = api.MQMessage{Offset: msg.Offset, Timestamp: msg.Timestamp, Value: msg.Value}
+	}
+	c.RespondJSON(ret)
+}
+
+// MQCommit commit the offset of group in the embedded queue
+func (s *Service) MQCommit(c *rpc.Context) {
+	args := new(api.MQCommitArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if s.queue == nil {
+		c.RespondError(errcode.ErrMQNotEnabled)
+		return
+	}
+
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	if err := s.queue.Commit(args.Topic, args.Group, args.Consumer, args.Offset); err != nil {
+		span.Errorf("commit offset failed: args[%+v], err[%+v]", args, err)
+		c.RespondError(mqError(err))
+		return
+	}
+	c.Respond()
+}
+
+// MQNack report the message consumed failed in the embedded queue,
+// the message will be moved to dead letter topic after max retries
+func (s *Service) MQNack(c *rpc.Context) {
+	args := new(api.MQCommitArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if s.queue == nil {
+		c.RespondError(errcode.ErrMQNotEnabled)
+		return
+	}
+
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	span.Warnf("accept MQNack request, args: %+v", args)
+	if err := s.queue.Nack(args.Topic, args.Group, args.Consumer, args.Offset); err != nil {
+		span.Errorf("nack message failed: args[%+v], err[%+v]", args, err)
+		c.RespondError(mqError(err))
+		return
+	}
+	c.Respond()
+}
+
+// MQStat returns offsets of topic in the embedded queue
+func (s *Service) MQStat(c *rpc.Context) {
+	args := new(api.MQStatArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+	if s.queue == nil {
+		c.RespondError(errcode.ErrMQNotEnabled)
+		return
+	}
+
+	st, err := s.queue.Stat(args.Topic)
+	if err != nil {
+		c.RespondError(mqError(err))
+		return
+	}
+	c.RespondJSON(api.MQTopicStat{
+		Topic:       st.Topic,
+		StartOffset: st.StartOffset,
+		EndOffset:   st.EndOffset,
+		Groups:      st.Groups,
+	})
+}
+
+func mqError(err error) error {
+	switch err {
+	case diskqueue.ErrIllegalTopic:
+		return errcode.ErrMQIllegalTopic
+	case diskqueue.ErrIllegalMessage:
+		return errcode.ErrIllegalArguments
+	case diskqueue.ErrConsumerConflict:
+		return errcode.ErrMQConsumeConflict
+	case diskqueue.ErrOffsetOutOfRange:
+		return errcode.ErrMQOffsetOutRange
+	default:
+		return err
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/kafka"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
 )
 
 // BlobDeleteHandler stream http handler
@@ -40,6 +41,8 @@ type Producer interface {
 type BlobDeleteConfig struct {
 	Topic        string            `json:"topic"`
 	MsgSenderCfg kafka.ProducerCfg `json:"msg_sender_cfg"`
+	// Queue is the embedded queue, messages are sent to it instead of kafka if it is not nil
+	Queue *diskqueue.Queue `json:"-"`
 }
 
 // blobDeleteMgr is blob delete manager
@@ -50,7 +53,7 @@ type blobDeleteMgr struct {
 
 // NewBlobDeleteMgr returns blob delete manager to handle delete message
 func NewBlobDeleteMgr(cfg BlobDeleteConfig) (*blobDeleteMgr, error) {
-	delMsgSender, err := kafka.NewProducer(&cfg.MsgSenderCfg)
+	delMsgSender, err := newMsgProducer(&cfg.MsgSenderCfg, cfg.Queue)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,200 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package diskqueue is a durable message queue on local disk, it is embedded in proxy
+// to replace kafka in the deployments without kafka.
+//
+// Every topic is a directory of append only segment files, and the committed offsets
+// of consumer groups are saved in the same directory. A group is consumed by one
+// consumer at the same time, the ownership is kept by lease. The message which has
+// failed more than max retries is moved into the dead letter topic of the topic.
+package diskqueue
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/cubefs/cubefs/blobstore/util/defaulter"
+)
+
+const (
+	// DeadLetterSuffix is suffix of the dead letter topic name
+	DeadLetterSuffix = "_dead_letter"
+
+	maxMessageSize = 16 << 20
+	maxFetchCount  = 10000
+	maxFetchBytes  = 16 << 20
+
+	defaultSegmentSize    = int64(64 << 20)
+	defaultMaxRetries     = 10
+	defaultConsumerLeaseS = 30
+)
+
+var (
+	ErrIllegalTopic       = errors.New("illegal topic")
+	ErrIllegalMessage     = errors.New("illegal message")
+	ErrConsumerConflict   = errors.New("group is consumed by other consumer")
+	ErrOffsetOutOfRange   = errors.New("offset out of range")
+	ErrCorrupted          = errors.New("corrupted segment")
+	ErrClosed             = errors.New("queue closed")
+	ErrIllegalQueueConfig = errors.New("illegal queue config")
+
+	topicNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,128}$`)
+)
+
+// Config is config of disk queue
+type Config struct {
+	Dir            string `json:"dir"`
+	SegmentSize    int64  `json:"segment_size"`
+	MaxRetries     int    `json:"max_retries"`
+	ConsumerLeaseS int    `json:"consumer_lease_s"`
+	DisableSync    bool   `json:"disable_sync"`
+}
+
+// Message is message in queue
+type Message struct {
+	Offset    int64
+	Timestamp int64
+	Value     []byte
+}
+
+// TopicStat is offsets of topic and all groups
+type TopicStat struct {
+	Topic       string           `json:"topic"`
+	StartOffset int64            `json:"start_offset"`
+	EndOffset   int64            `json:"end_offset"`
+	Groups      map[string]int64 `json:"groups"`
+}
+
+// Queue is disk queue with multiple topics
+type Queue struct {
+	mu     sync.Mutex
+	cfg    Config
+	topics map[string]*topic
+	closed bool
+}
+
+// Open opens the disk queue in config dir, topics are loaded when they are used
+func Open(cfg Config) (*Queue, error) {
+	if cfg.Dir == "" {
+		return nil, ErrIllegalQueueConfig
+	}
+	defaulter.LessOrEqual(&cfg.SegmentSize, defaultSegmentSize)
+	defaulter.LessOrEqual(&cfg.MaxRetries, defaultMaxRetries)
+	defaulter.LessOrEqual(&cfg.ConsumerLeaseS, defaultConsumerLeaseS)
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Queue{cfg: cfg, topics: make(map[string]*topic)}, nil
+}
+
+func (q *Queue) topic(name string) (*topic, error) {
+	if !topicNameRegexp.MatchString(name) {
+		return nil, ErrIllegalTopic
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrClosed
+	}
+	if t, ok := q.topics[name]; ok {
+		return t, nil
+	}
+	t, err := openTopic(q.cfg.Dir, name, &q.cfg)
+	if err != nil {
+		return nil, err
+	}
+	q.topics[name] = t
+	return t, nil
+}
+
+// Produce appends messages into topic
+func (q *Queue) Produce(topic string, msgs [][]byte) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	for _, msg := range msgs {
+		if len(msg) > maxMessageSize {
+			return ErrIllegalMessage
+		}
+	}
+	t, err := q.topic(topic)
+	if err != nil {
+		return err
+	}
+	return t.produce(msgs)
+}
+
+// Fetch returns at most count messages from the committed offset of group
+func (q *Queue) Fetch(topic, group, consumer string, count int) ([]Message, error) {
+	t, err := q.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+	return t.fetch(group, consumer, count)
+}
+
+// Commit sets committed offset of group, the offset is the next message to consume
+func (q *Queue) Commit(topic, group, consumer string, offset int64) error {
+	t, err := q.topic(topic)
+	if err != nil {
+		return err
+	}
+	return t.commit(group, consumer, offset)
+}
+
+// Nack reports the message at committed offset of group consumed failed,
+// the message will be moved to dead letter topic and skipped when it has failed more than max retries
+func (q *Queue) Nack(topic, group, consumer string, offset int64) error {
+	t, err := q.topic(topic)
+	if err != nil {
+		return err
+	}
+	msg, err := t.nack(group, consumer, offset)
+	if err != nil || msg == nil {
+		return err
+	}
+
+	if err = q.Produce(topic+DeadLetterSuffix, [][]byte{msg.Value}); err != nil {
+		return err
+	}
+	return t.commit(group, consumer, offset+1)
+}
+
+// Stat returns offsets of topic
+func (q *Queue) Stat(topic string) (TopicStat, error) {
+	t, err := q.topic(topic)
+	if err != nil {
+		return TopicStat{}, err
+	}
+	return t.stat(), nil
+}
+
+// Close closes all topics
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for _, t := range q.topics {
+		t.Lock()
+		t.close()
+		t.Unlock()
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diskqueue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTopic    = "blob_delete"
+	testGroup    = "scheduler-blob_delete"
+	testConsumer = "scheduler-1"
+)
+
+func genMsgs(start, count int) [][]byte {
+	msgs := make([][]byte, count)
+	for i := range msgs {
+		msgs[i] = []byte(fmt.Sprintf("msg-%d", start+i))
+	}
+	return msgs
+}
+
+func TestQueueProduceAndConsume(t *testing.T) {
+	q, err := Open(Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer q.Close()
+
+	require.NoError(t, q.Produce(testTopic, genMsgs(0, 10)))
+	require.NoError(t, q.Produce(testTopic, nil))
+
+	msgs, err := q.Fetch(testTopic, testGroup, testConsumer, 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(msgs))
+	for i, msg := range msgs {
+		require.Equal(t, int64(i), msg.Offset)
+		require.Equal(t, fmt.Sprintf("msg-%d", i), string(msg.Value))
+		require.NotZero(t, msg.Timestamp)
+	}
+
+	// fetch again without commit returns the same messages
+	msgs, err = q.Fetch(testTopic, testGroup, testConsumer, 4)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), msgs[0].Offset)
+
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 4))
+	require.ErrorIs(t, q.Commit(testTopic, testGroup, testConsumer, 3), ErrOffsetOutOfRange)
+	require.ErrorIs(t, q.Commit(testTopic, testGroup, testConsumer, 11), ErrOffsetOutOfRange)
+	msgs, err = q.Fetch(testTopic, testGroup, testConsumer, 100)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(msgs))
+	require.Equal(t, int64(4), msgs[0].Offset)
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 10))
+
+	msgs, err = q.Fetch(testTopic, testGroup, testConsumer, 100)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(msgs))
+
+	// other group consumes from the oldest message
+	msgs, err = q.Fetch(testTopic, "other", testConsumer, 100)
+	require.NoError(t, err)
+	require.Equal(t, 10, len(msgs))
+
+	st, err := q.Stat(testTopic)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), st.StartOffset)
+	require.Equal(t, int64(10), st.EndOffset)
+	require.Equal(t, int64(10), st.Groups[testGroup])
+	require.Equal(t, int64(0), st.Groups["other"])
+
+	_, err = q.Fetch("../topic", testGroup, testConsumer, 1)
+	require.ErrorIs(t, err, ErrIllegalTopic)
+	require.ErrorIs(t, q.Produce("", genMsgs(0, 1)), ErrIllegalTopic)
+	require.ErrorIs(t, q.Produce(testTopic, [][]byte{make([]byte, maxMessageSize+1)}), ErrIllegalMessage)
+
+	_, err = Open(Config{})
+	require.ErrorIs(t, err, ErrIllegalQueueConfig)
+}
+
+func TestQueueReopen(t *testing.T) {
+	dir := t.TempDir()
+	cfg := Config{Dir: dir, SegmentSize: 100}
+	q, err := Open(cfg)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.Produce(testTopic, genMsgs(i*10, 10)))
+	}
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 35))
+	q.Close()
+	require.ErrorIs(t, q.Produce(testTopic, genMsgs(0, 1)), ErrClosed)
+
+	// append a torn record into the last segment
+	bases, err := listSegments(filepath.Join(dir, testTopic))
+	require.NoError(t, err)
+	require.Less(t, 1, len(bases))
+	last := filepath.Join(dir, testTopic, segmentName(bases[len(bases)-1]))
+	f, err := os.OpenFile(last, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 10, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, err = Open(cfg)
+	require.NoError(t, err)
+	defer q.Close()
+
+	// the committed offset is kept and the torn record is dropped
+	msgs, err := q.Fetch(testTopic, testGroup, "scheduler-2", maxFetchCount+1)
+	require.NoError(t, err)
+	require.Equal(t, 65, len(msgs))
+	for i, msg := range msgs {
+		require.Equal(t, int64(35+i), msg.Offset)
+		require.Equal(t, fmt.Sprintf("msg-%d", 35+i), string(msg.Value))
+	}
+	require.NoError(t, q.Produce(testTopic, genMsgs(100, 1)))
+	st, err := q.Stat(testTopic)
+	require.NoError(t, err)
+	require.Equal(t, int64(101), st.EndOffset)
+}
+
+func TestQueueCleanup(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(Config{Dir: dir, SegmentSize: 1, DisableSync: true})
+	require.NoError(t, err)
+	defer q.Close()
+
+	// every produce rolls a new segment
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Produce(testTopic, genMsgs(i*2, 2)))
+	}
+	bases, err := listSegments(filepath.Join(dir, testTopic))
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 2, 4, 6, 8}, bases)
+
+	msgs, err := q.Fetch(testTopic, testGroup, testConsumer, 7)
+	require.NoError(t, err)
+	require.Equal(t, 7, len(msgs))
+	require.Equal(t, int64(6), msgs[6].Offset)
+
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 5))
+	bases, err = listSegments(filepath.Join(dir, testTopic))
+	require.NoError(t, err)
+	require.Equal(t, []int64{4, 6, 8}, bases)
+
+	// the active segment is always kept
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 10))
+	bases, err = listSegments(filepath.Join(dir, testTopic))
+	require.NoError(t, err)
+	require.Equal(t, []int64{8}, bases)
+
+	// new group starts from the oldest kept message
+	msgs, err = q.Fetch(testTopic, "other", testConsumer, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(msgs))
+	require.Equal(t, int64(8), msgs[0].Offset)
+}
+
+func TestQueueConsumerLease(t *testing.T) {
+	q, err := Open(Config{Dir: t.TempDir(), ConsumerLeaseS: 1})
+	require.NoError(t, err)
+	defer q.Close()
+	require.NoError(t, q.Produce(testTopic, genMsgs(0, 2)))
+
+	_, err = q.Fetch(testTopic, testGroup, testConsumer, 1)
+	require.NoError(t, err)
+	_, err = q.Fetch(testTopic, testGroup, "scheduler-2", 1)
+	require.ErrorIs(t, err, ErrConsumerConflict)
+	require.ErrorIs(t, q.Commit(testTopic, testGroup, "scheduler-2", 1), ErrConsumerConflict)
+	require.ErrorIs(t, q.Nack(testTopic, testGroup, "scheduler-2", 0), ErrConsumerConflict)
+
+	// the other consumer takes over the group after lease expired
+	time.Sleep(1100 * time.Millisecond)
+	msgs, err := q.Fetch(testTopic, testGroup, "scheduler-2", 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), msgs[0].Offset)
+	_, err = q.Fetch(testTopic, testGroup, testConsumer, 1)
+	require.ErrorIs(t, err, ErrConsumerConflict)
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	q, err := Open(Config{Dir: t.TempDir(), MaxRetries: 3})
+	require.NoError(t, err)
+	defer q.Close()
+	require.NoError(t, q.Produce(testTopic, genMsgs(0, 3)))
+
+	_, err = q.Fetch(testTopic, testGroup, testConsumer, 3)
+	require.NoError(t, err)
+	require.ErrorIs(t, q.Nack(testTopic, testGroup, testConsumer, 1), ErrOffsetOutOfRange)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, q.Nack(testTopic, testGroup, testConsumer, 0))
+		msgs, err := q.Fetch(testTopic, testGroup, testConsumer, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(0), msgs[0].Offset)
+	}
+
+	// the message is moved into dead letter topic after max retries
+	require.NoError(t, q.Nack(testTopic, testGroup, testConsumer, 0))
+	msgs, err := q.Fetch(testTopic, testGroup, testConsumer, 3)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(msgs))
+	require.Equal(t, int64(1), msgs[0].Offset)
+
+	msgs, err = q.Fetch(testTopic+DeadLetterSuffix, testGroup, testConsumer, 3)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(msgs))
+	require.Equal(t, "msg-0", string(msgs[0].Value))
+
+	// the retry count is reset after commit
+	require.NoError(t, q.Nack(testTopic, testGroup, testConsumer, 1))
+	require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, 2))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, q.Nack(testTopic, testGroup, testConsumer, 2))
+	}
+	msgs, err = q.Fetch(testTopic, testGroup, testConsumer, 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), msgs[0].Offset)
+}
+
+func TestQueueFetchWithIndex(t *testing.T) {
+	q, err := Open(Config{Dir: t.TempDir(), DisableSync: true})
+	require.NoError(t, err)
+	defer q.Close()
+	require.NoError(t, q.Produce(testTopic, genMsgs(0, indexInterval*3+10)))
+
+	for _, offset := range []int64{indexInterval - 1, indexInterval, indexInterval*2 + 7, indexInterval*3 + 9} {
+		require.NoError(t, q.Commit(testTopic, testGroup, testConsumer, offset))
+		msgs, err := q.Fetch(testTopic, testGroup, testConsumer, 2)
+		require.NoError(t, err)
+		require.Equal(t, offset, msgs[0].Offset)
+		require.Equal(t, fmt.Sprintf("msg-%d", offset), string(msgs[0].Value))
+	}
+}
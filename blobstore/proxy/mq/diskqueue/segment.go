@@ -0,0 +1,250 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diskqueue
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentSuffix = ".log"
+
+	// record layout: | length(4) | crc32(4) | timestamp(8) | value(length) |
+	// crc32 is checksum of timestamp and value
+	recordHeaderSize = 16
+	// a sparse index entry is kept every indexInterval records
+	indexInterval = 256
+)
+
+type indexEntry struct {
+	offset int64
+	pos    int64
+}
+
+// segment is an append only file of records, the first record's offset is base
+type segment struct {
+	base  int64
+	next  int64
+	size  int64
+	index []indexEntry
+	file  *os.File
+	sync  bool
+}
+
+func segmentName(base int64) string {
+	return fmt.Sprintf("%020d%s", base, segmentSuffix)
+}
+
+// listSegments returns base offsets of all segments in dir by ascending order
+func listSegments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var bases []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		base, err := strconv.ParseInt(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+func createSegment(dir string, base int64, sync bool) (*segment, error) {
+	file, err := os.OpenFile(filepath.Join(dir, segmentName(base)), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &segment{base: base, next: base, file: file, sync: sync}, nil
+}
+
+// openSegment opens an exist segment and rebuilds its index,
+// the torn tail of last segment is truncated, other corruption returns ErrCorrupted
+func openSegment(dir string, base int64, last, sync bool) (*segment, error) {
+	file, err := os.OpenFile(filepath.Join(dir, segmentName(base)), os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &segment{base: base, next: base, file: file, sync: sync}
+
+	var (
+		pos    int64
+		header [recordHeaderSize]byte
+	)
+	reader := bufio.NewReader(file)
+	for {
+		n, err := s.readRecord(reader, header[:], nil)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !last {
+				file.Close()
+				return nil, fmt.Errorf("segment %s at pos %d: %w", segmentName(base), pos, ErrCorrupted)
+			}
+			if err = file.Truncate(pos); err != nil {
+				file.Close()
+				return nil, err
+			}
+			break
+		}
+		s.addIndex(s.next, pos)
+		s.next++
+		pos += n
+	}
+	s.size = pos
+	return s, nil
+}
+
+// readRecord reads one record from reader, the message is filled if msg is not nil
+func (s *segment) readRecord(reader *bufio.Reader, header []byte, msg *Message) (int64, error) {
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF {
+			return 0, io.EOF
+		}
+		return 0, ErrCorrupted
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxMessageSize {
+		return 0, ErrCorrupted
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return 0, ErrCorrupted
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(header[8:16])
+	crc.Write(value)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:8]) {
+		return 0, ErrCorrupted
+	}
+	if msg != nil {
+		msg.Timestamp = int64(binary.BigEndian.Uint64(header[8:16]))
+		msg.Value = value
+	}
+	return int64(recordHeaderSize) + int64(length), nil
+}
+
+func (s *segment) addIndex(offset, pos int64) {
+	if (offset-s.base)%indexInterval == 0 {
+		s.index = append(s.index, indexEntry{offset: offset, pos: pos})
+	}
+}
+
+// append writes all messages as one write and returns offset of the first message
+func (s *segment) append(values [][]byte, timestamp int64) (int64, error) {
+	size := 0
+	for _, value := range values {
+		size += recordHeaderSize + len(value)
+	}
+	buf := make([]byte, 0, size)
+	for _, value := range values {
+		var header [recordHeaderSize]byte
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(value)))
+		binary.BigEndian.PutUint64(header[8:16], uint64(timestamp))
+		crc := crc32.NewIEEE()
+		crc.Write(header[8:16])
+		crc.Write(value)
+		binary.BigEndian.PutUint32(header[4:8], crc.Sum32())
+		buf = append(buf, header[:]...)
+		buf = append(buf, value...)
+	}
+
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		// drop the partial written records
+		_ = s.file.Truncate(s.size)
+		return 0, err
+	}
+	if s.sync {
+		if err := s.file.Sync(); err != nil {
+			_ = s.file.Truncate(s.size)
+			return 0, err
+		}
+	}
+
+	first := s.next
+	pos := s.size
+	for _, value := range values {
+		s.addIndex(s.next, pos)
+		s.next++
+		pos += int64(recordHeaderSize + len(value))
+	}
+	s.size = pos
+	return first, nil
+}
+
+// read returns at most count messages from offset, and the total bytes of messages not exceed maxBytes
+// except the first message
+func (s *segment) read(offset int64, count int, maxBytes int64) ([]Message, error) {
+	if offset < s.base || offset >= s.next || count <= 0 {
+		return nil, nil
+	}
+	idx := sort.Search(len(s.index), func(i int) bool { return s.index[i].offset > offset }) - 1
+	entry := s.index[idx]
+
+	var (
+		header [recordHeaderSize]byte
+		bytes  int64
+		msgs   []Message
+	)
+	reader := bufio.NewReader(io.NewSectionReader(s.file, entry.pos, s.size-entry.pos))
+	for cur := entry.offset; cur < s.next && len(msgs) < count; cur++ {
+		if cur < offset {
+			if _, err := s.readRecord(reader, header[:], nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		msg := Message{Offset: cur}
+		n, err := s.readRecord(reader, header[:], &msg)
+		if err != nil {
+			return nil, err
+		}
+		if len(msgs) > 0 && bytes+n > maxBytes {
+			break
+		}
+		bytes += n
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+func (s *segment) close() error {
+	return s.file.Close()
+}
+
+func (s *segment) remove() error {
+	name := s.file.Name()
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
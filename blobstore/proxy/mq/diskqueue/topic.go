@@ -0,0 +1,305 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package diskqueue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	offsetsFile    = "offsets.json"
+	offsetsFileTmp = "offsets.json.tmp"
+)
+
+type group struct {
+	offset int64
+	// nacks is the failed times of the message at offset
+	nacks      int
+	owner      string
+	leaseUntil time.Time
+}
+
+type topic struct {
+	sync.Mutex
+	name     string
+	dir      string
+	cfg      *Config
+	segments []*segment
+	groups   map[string]*group
+}
+
+func openTopic(dir, name string, cfg *Config) (*topic, error) {
+	t := &topic{
+		name:   name,
+		dir:    filepath.Join(dir, name),
+		cfg:    cfg,
+		groups: make(map[string]*group),
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	bases, err := listSegments(t.dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, base := range bases {
+		s, err := openSegment(t.dir, base, i == len(bases)-1, !cfg.DisableSync)
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		t.segments = append(t.segments, s)
+	}
+	if len(t.segments) == 0 {
+		s, err := createSegment(t.dir, 0, !cfg.DisableSync)
+		if err != nil {
+			return nil, err
+		}
+		t.segments = append(t.segments, s)
+	}
+
+	offsets, err := t.loadOffsets()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	for name, offset := range offsets {
+		t.groups[name] = &group{offset: offset}
+	}
+	return t, nil
+}
+
+func (t *topic) loadOffsets() (map[string]int64, error) {
+	data, err := os.ReadFile(filepath.Join(t.dir, offsetsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	offsets := make(map[string]int64)
+	if err = json.Unmarshal(data, &offsets); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+// saveOffsets persists committed offsets of all groups by write and rename
+func (t *topic) saveOffsets() error {
+	offsets := make(map[string]int64, len(t.groups))
+	for name, g := range t.groups {
+		offsets[name] = g.offset
+	}
+	data, err := json.Marshal(offsets)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(t.dir, offsetsFileTmp)
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil && !t.cfg.DisableSync {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.dir, offsetsFile))
+}
+
+func (t *topic) startOffset() int64 {
+	return t.segments[0].base
+}
+
+func (t *topic) endOffset() int64 {
+	return t.segments[len(t.segments)-1].next
+}
+
+func (t *topic) produce(values [][]byte) error {
+	t.Lock()
+	defer t.Unlock()
+
+	active := t.segments[len(t.segments)-1]
+	if active.size >= t.cfg.SegmentSize && active.next > active.base {
+		s, err := createSegment(t.dir, active.next, !t.cfg.DisableSync)
+		if err != nil {
+			return err
+		}
+		t.segments = append(t.segments, s)
+		active = s
+	}
+	_, err := active.append(values, time.Now().UnixNano())
+	return err
+}
+
+// acquire returns the group which is owned by consumer, a new group consumes from the oldest message
+func (t *topic) acquire(name, consumer string) (*group, error) {
+	g, ok := t.groups[name]
+	if !ok {
+		g = &group{offset: t.startOffset()}
+		t.groups[name] = g
+	}
+	now := time.Now()
+	if g.owner != "" && g.owner != consumer && now.Before(g.leaseUntil) {
+		return nil, ErrConsumerConflict
+	}
+	if g.owner != consumer {
+		g.owner = consumer
+		g.nacks = 0
+	}
+	g.leaseUntil = now.Add(time.Duration(t.cfg.ConsumerLeaseS) * time.Second)
+	// the messages before start offset have been removed
+	if g.offset < t.startOffset() {
+		g.offset = t.startOffset()
+	}
+	return g, nil
+}
+
+func (t *topic) fetch(name, consumer string, count int) ([]Message, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	g, err := t.acquire(name, consumer)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxFetchCount {
+		count = maxFetchCount
+	}
+
+	var msgs []Message
+	offset, bytes := g.offset, int64(0)
+	for _, s := range t.segments {
+		if offset >= s.next {
+			continue
+		}
+		ret, err := s.read(offset, count-len(msgs), maxFetchBytes-bytes)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range ret {
+			bytes += int64(recordHeaderSize + len(msg.Value))
+		}
+		msgs = append(msgs, ret...)
+		if len(ret) == 0 || len(msgs) >= count || bytes >= maxFetchBytes {
+			break
+		}
+		offset = msgs[len(msgs)-1].Offset + 1
+	}
+	return msgs, nil
+}
+
+// commit sets the next offset to consume of group
+func (t *topic) commit(name, consumer string, offset int64) error {
+	t.Lock()
+	defer t.Unlock()
+
+	g, err := t.acquire(name, consumer)
+	if err != nil {
+		return err
+	}
+	if offset < g.offset || offset > t.endOffset() {
+		return ErrOffsetOutOfRange
+	}
+	if offset == g.offset {
+		return nil
+	}
+	g.offset = offset
+	g.nacks = 0
+	if err = t.saveOffsets(); err != nil {
+		return err
+	}
+	t.cleanup()
+	return nil
+}
+
+// nack records a failed consumption of the message at offset,
+// returns the message when it has failed more than max retries and should be dead lettered
+func (t *topic) nack(name, consumer string, offset int64) (*Message, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	g, err := t.acquire(name, consumer)
+	if err != nil {
+		return nil, err
+	}
+	if offset != g.offset {
+		return nil, ErrOffsetOutOfRange
+	}
+	g.nacks++
+	if g.nacks < t.cfg.MaxRetries {
+		return nil, nil
+	}
+	for _, s := range t.segments {
+		if offset >= s.base && offset < s.next {
+			msgs, err := s.read(offset, 1, maxFetchBytes)
+			if err != nil {
+				return nil, err
+			}
+			return &msgs[0], nil
+		}
+	}
+	return nil, ErrOffsetOutOfRange
+}
+
+// cleanup removes the segments which have been consumed by all groups, the active segment is always kept
+func (t *topic) cleanup() {
+	if len(t.groups) == 0 {
+		return
+	}
+	minOffset := t.endOffset()
+	for _, g := range t.groups {
+		if g.offset < minOffset {
+			minOffset = g.offset
+		}
+	}
+	for len(t.segments) > 1 && t.segments[0].next <= minOffset {
+		// ignore the remove error, the segment will be removed again after restart
+		_ = t.segments[0].remove()
+		t.segments = t.segments[1:]
+	}
+}
+
+func (t *topic) stat() TopicStat {
+	t.Lock()
+	defer t.Unlock()
+
+	st := TopicStat{
+		Topic:       t.name,
+		StartOffset: t.startOffset(),
+		EndOffset:   t.endOffset(),
+		Groups:      make(map[string]int64, len(t.groups)),
+	}
+	for name, g := range t.groups {
+		st.Groups[name] = g.offset
+	}
+	return st
+}
+
+func (t *topic) close() {
+	for _, s := range t.segments {
+		_ = s.close()
+	}
+}
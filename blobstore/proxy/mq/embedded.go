@@ -0,0 +1,56 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mq
+
+import (
+	"github.com/cubefs/cubefs/blobstore/common/kafka"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
+)
+
+// EmbeddedConfig is config of the queue embedded in proxy, messages are sent to it instead of kafka if enabled
+type EmbeddedConfig struct {
+	Enable bool `json:"enable"`
+	diskqueue.Config
+}
+
+// queueProducer sends messages to the embedded queue
+type queueProducer struct {
+	queue *diskqueue.Queue
+}
+
+// NewQueueProducer returns producer of the embedded queue
+func NewQueueProducer(queue *diskqueue.Queue) Producer {
+	return &queueProducer{queue: queue}
+}
+
+func (p *queueProducer) SendMessage(topic string, msg []byte) error {
+	return p.queue.Produce(topic, [][]byte{msg})
+}
+
+func (p *queueProducer) SendMessages(topic string, msgs [][]byte) error {
+	return p.queue.Produce(topic, msgs)
+}
+
+// newMsgProducer returns producer of the embedded queue if queue is not nil, otherwise returns kafka producer
+func newMsgProducer(cfg *kafka.ProducerCfg, queue *diskqueue.Queue) (Producer, error) {
+	if queue != nil {
+		return NewQueueProducer(queue), nil
+	}
+	producer, err := kafka.NewProducer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return producer, nil
+}
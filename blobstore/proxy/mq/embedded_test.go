@@ -0,0 +1,60 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package mq
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/api/proxy"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
+)
+
+func TestEmbeddedQueueProducer(t *testing.T) {
+	queue, err := diskqueue.Open(diskqueue.Config{Dir: t.TempDir()})
+	require.NoError(t, err)
+	defer queue.Close()
+
+	deleteMgr, err := NewBlobDeleteMgr(BlobDeleteConfig{Topic: "blob_delete", Queue: queue})
+	require.NoError(t, err)
+	err = deleteMgr.SendDeleteMsg(context.Background(), &proxy.DeleteArgs{
+		ClusterID: 1,
+		Blobs:     []proxy.BlobDelete{{Vid: 1, Bid: 1000}, {Vid: 2, Bid: 2000}},
+	})
+	require.NoError(t, err)
+
+	msgs, err := queue.Fetch("blob_delete", "test", "test", 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(msgs))
+	var delMsg proto.DeleteMsg
+	require.NoError(t, json.Unmarshal(msgs[1].Value, &delMsg))
+	require.Equal(t, proto.Vid(2), delMsg.Vid)
+	require.Equal(t, proto.BlobID(2000), delMsg.Bid)
+
+	repairMgr, err := NewShardRepairMgr(ShardRepairConfig{Topic: "shard_repair", PriorityTopic: "shard_repair_prior", Queue: queue})
+	require.NoError(t, err)
+	err = repairMgr.SendShardRepairMsg(context.Background(), &proxy.ShardRepairArgs{ClusterID: 1, Vid: 1, Bid: 1, BadIdxes: []uint8{1, 2}})
+	require.NoError(t, err)
+	msgs, err = queue.Fetch("shard_repair_prior", "test", "test", 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(msgs))
+	var repairMsg proto.ShardRepairMsg
+	require.NoError(t, json.Unmarshal(msgs[0].Value, &repairMsg))
+	require.Equal(t, []uint8{1, 2}, repairMsg.BadIdx)
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/kafka"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
 )
 
 // ShardRepairHandler stream http handler
@@ -36,11 +37,13 @@ type ShardRepairConfig struct {
 	Topic         string            `json:"topic"`
 	PriorityTopic string            `json:"priority_topic"`
 	MsgSenderCfg  kafka.ProducerCfg `json:"msg_sender_cfg"`
+	// Queue is the embedded queue, messages are sent to it instead of kafka if it is not nil
+	Queue *diskqueue.Queue `json:"-"`
 }
 
 // NewShardRepairMgr returns shard repair manager
 func NewShardRepairMgr(cfg ShardRepairConfig) (*shardRepairMgr, error) {
-	shardRepairMsgSender, err := kafka.NewProducer(&cfg.MsgSenderCfg)
+	shardRepairMsgSender, err := newMsgProducer(&cfg.MsgSenderCfg, cfg.Queue)
 	if err != nil {
 		return nil, err
 	}
@@ -30,6 +30,7 @@ import (
 	alloc "github.com/cubefs/cubefs/blobstore/proxy/allocator"
 	"github.com/cubefs/cubefs/blobstore/proxy/cacher"
 	"github.com/cubefs/cubefs/blobstore/proxy/mq"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
 	"github.com/cubefs/cubefs/blobstore/util/defaulter"
 	"github.com/cubefs/cubefs/blobstore/util/errors"
 	"github.com/cubefs/cubefs/blobstore/util/log"
@@ -59,6 +60,8 @@ type MQConfig struct {
 	ShardRepairPriorityTopic string            `json:"shard_repair_priority_topic"`
 	MsgSender                kafka.ProducerCfg `json:"msg_sender"`
 	Version                  string            `json:"version"`
+	// Embedded is the queue embedded in proxy, which is used instead of kafka if enabled
+	Embedded mq.EmbeddedConfig `json:"embedded"`
 }
 
 type Config struct {
@@ -75,18 +78,20 @@ type Config struct {
 	MQ                 MQConfig          `json:"mq"`
 }
 
-func (c *Config) blobDeleteCfg() mq.BlobDeleteConfig {
+func (c *Config) blobDeleteCfg(queue *diskqueue.Queue) mq.BlobDeleteConfig {
 	return mq.BlobDeleteConfig{
 		Topic:        c.MQ.BlobDeleteTopic,
 		MsgSenderCfg: c.MQ.MsgSender,
+		Queue:        queue,
 	}
 }
 
-func (c *Config) shardRepairCfg() mq.ShardRepairConfig {
+func (c *Config) shardRepairCfg(queue *diskqueue.Queue) mq.ShardRepairConfig {
 	return mq.ShardRepairConfig{
 		Topic:         c.MQ.ShardRepairTopic,
 		PriorityTopic: c.MQ.ShardRepairPriorityTopic,
 		MsgSenderCfg:  c.MQ.MsgSender,
+		Queue:         queue,
 	}
 }
 
@@ -96,6 +101,7 @@ type Service struct {
 	// mq
 	shardRepairMgr mq.ShardRepairHandler
 	blobDeleteMgr  mq.BlobDeleteHandler
+	queue          *diskqueue.Queue
 	// allocator
 	volumeMgr alloc.VolumeMgr
 	// cacher
@@ -128,6 +134,9 @@ func setUp() (*rpc.Router, []rpc.ProgressHandler) {
 
 func tearDown() {
 	service.volumeMgr.Close()
+	if service.queue != nil {
+		service.queue.Close()
+	}
 }
 
 func New(cfg Config, cmcli clustermgr.APIProxy) *Service {
@@ -136,11 +145,19 @@ func New(cfg Config, cmcli clustermgr.APIProxy) *Service {
 	}
 
 	// mq
-	blobDeleteMgr, err := mq.NewBlobDeleteMgr(cfg.blobDeleteCfg())
+	var queue *diskqueue.Queue
+	if cfg.MQ.Embedded.Enable {
+		q, err := diskqueue.Open(cfg.MQ.Embedded.Config)
+		if err != nil {
+			log.Fatalf("fail to open embedded queue, error: %s", err.Error())
+		}
+		queue = q
+	}
+	blobDeleteMgr, err := mq.NewBlobDeleteMgr(cfg.blobDeleteCfg(queue))
 	if err != nil {
 		log.Fatalf("fail to new blobDeleteMgr, error: %s", err.Error())
 	}
-	shardRepairMgr, err := mq.NewShardRepairMgr(cfg.shardRepairCfg())
+	shardRepairMgr, err := mq.NewShardRepairMgr(cfg.shardRepairCfg(queue))
 	if err != nil {
 		log.Fatalf("fail to new shardRepairMgr, error: %s", err.Error())
 	}
@@ -174,6 +191,7 @@ func New(cfg Config, cmcli clustermgr.APIProxy) *Service {
 		cacher:         cacher,
 		shardRepairMgr: shardRepairMgr,
 		blobDeleteMgr:  blobDeleteMgr,
+		queue:          queue,
 	}
 }
 
@@ -183,6 +201,7 @@ func NewHandler(service *Service) *rpc.Router {
 	rpc.RegisterArgsParser(&proxy.CacheVolumeArgs{}, "json")
 	rpc.RegisterArgsParser(&proxy.CacheDiskArgs{}, "json")
 	rpc.RegisterArgsParser(&proxy.DiscardVolsArgs{}, "json")
+	rpc.RegisterArgsParser(&proxy.MQStatArgs{}, "json")
 
 	// POST /volume/alloc
 	// request  body:  json
@@ -204,6 +223,24 @@ func NewHandler(service *Service) *rpc.Router {
 	// request body: json
 	router.Handle(http.MethodPost, "/deletemsg", service.SendDeleteMessage, rpc.OptArgsBody())
 
+	// the embedded queue
+	// POST /mq/produce
+	// request body: json
+	router.Handle(http.MethodPost, "/mq/produce", service.MQProduce, rpc.OptArgsBody())
+	// POST /mq/fetch
+	// request body: json
+	// response body: json
+	router.Handle(http.MethodPost, "/mq/fetch", service.MQFetch, rpc.OptArgsBody())
+	// POST /mq/commit
+	// request body: json
+	router.Handle(http.MethodPost, "/mq/commit", service.MQCommit, rpc.OptArgsBody())
+	// POST /mq/nack
+	// request body: json
+	router.Handle(http.MethodPost, "/mq/nack", service.MQNack, rpc.OptArgsBody())
+	// GET /mq/stat?topic={topic}
+	// response body: json
+	router.Handle(http.MethodGet, "/mq/stat", service.MQStat, rpc.OptArgsQuery())
+
 	// GET /cache/volume/{vid}?flush={flush}&version={version}
 	// response body: json
 	router.Handle(http.MethodGet, "/cache/volume/:vid", service.GetCacheVolume, rpc.OptArgsURI(), rpc.OptArgsQuery())
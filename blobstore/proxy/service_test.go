@@ -34,6 +34,8 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/rpc"
 	"github.com/cubefs/cubefs/blobstore/proxy/allocator"
 	"github.com/cubefs/cubefs/blobstore/proxy/mock"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
 	_ "github.com/cubefs/cubefs/blobstore/testing/nolog"
 	"github.com/cubefs/cubefs/blobstore/util/errors"
 )
@@ -182,6 +184,70 @@ func TestService_New(t *testing.T) {
 	for _, tc := range testCases {
 		New(tc.cfg, cmcli)
 	}
+
+	// the embedded queue is used without kafka
+	svr := New(Config{
+		MQ: MQConfig{
+			BlobDeleteTopic:          "test1",
+			ShardRepairTopic:         "test2",
+			ShardRepairPriorityTopic: "test3",
+			Embedded: mq.EmbeddedConfig{
+				Enable: true,
+				Config: diskqueue.Config{Dir: t.TempDir()},
+			},
+		},
+	}, cmcli)
+	defer svr.queue.Close()
+	require.NotNil(t, svr.queue)
+	require.NoError(t, svr.blobDeleteMgr.SendDeleteMsg(ctx, &proxy.DeleteArgs{Blobs: []proxy.BlobDelete{{Bid: 1, Vid: 1}}}))
+	st, err := svr.queue.Stat("test1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), st.EndOffset)
+}
+
+func TestService_EmbeddedMQ(t *testing.T) {
+	queue, err := diskqueue.Open(diskqueue.Config{Dir: t.TempDir(), MaxRetries: 1})
+	require.NoError(t, err)
+	defer queue.Close()
+	svr := newMockService(t)
+	svr.queue = queue
+	server := httptest.NewServer(NewHandler(svr))
+	defer server.Close()
+
+	cli := proxy.NewMQClient(&proxy.Config{})
+	topic, group, consumer := "blob_delete", "scheduler-blob_delete", "scheduler-1"
+	err = cli.MQProduce(ctx, server.URL, &proxy.MQProduceArgs{Topic: topic, Msgs: [][]byte{[]byte("msg-0"), []byte("msg-1")}})
+	require.NoError(t, err)
+	err = cli.MQProduce(ctx, server.URL, &proxy.MQProduceArgs{Topic: "../illegal", Msgs: [][]byte{[]byte("msg")}})
+	require.Equal(t, errcode.CodeMQIllegalTopic, rpc.DetectStatusCode(err))
+
+	ret, err := cli.MQFetch(ctx, server.URL, &proxy.MQFetchArgs{Topic: topic, Group: group, Consumer: consumer, Count: 10})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ret.Messages))
+	require.Equal(t, "msg-1", string(ret.Messages[1].Value))
+	_, err = cli.MQFetch(ctx, server.URL, &proxy.MQFetchArgs{Topic: topic, Group: group, Consumer: "scheduler-2", Count: 10})
+	require.Equal(t, errcode.CodeMQConsumeConflict, rpc.DetectStatusCode(err))
+
+	err = cli.MQCommit(ctx, server.URL, &proxy.MQCommitArgs{Topic: topic, Group: group, Consumer: consumer, Offset: 1})
+	require.NoError(t, err)
+	err = cli.MQCommit(ctx, server.URL, &proxy.MQCommitArgs{Topic: topic, Group: group, Consumer: consumer, Offset: 3})
+	require.Equal(t, errcode.CodeMQOffsetOutRange, rpc.DetectStatusCode(err))
+
+	// the message is moved into dead letter topic
+	err = cli.MQNack(ctx, server.URL, &proxy.MQCommitArgs{Topic: topic, Group: group, Consumer: consumer, Offset: 1})
+	require.NoError(t, err)
+	st, err := cli.MQStat(ctx, server.URL, &proxy.MQStatArgs{Topic: topic})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), st.EndOffset)
+	require.Equal(t, int64(2), st.Groups[group])
+	st, err = cli.MQStat(ctx, server.URL, &proxy.MQStatArgs{Topic: topic + diskqueue.DeadLetterSuffix})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), st.EndOffset)
+
+	// the embedded queue is not enabled
+	runMockService(newMockService(t))
+	_, err = cli.MQStat(ctx, proxyServer.URL, &proxy.MQStatArgs{Topic: topic})
+	require.Equal(t, errcode.CodeMQNotEnabled, rpc.DetectStatusCode(err))
 }
 
 func TestService_MQ(t *testing.T) {
@@ -423,8 +489,8 @@ func TestConfigFix(t *testing.T) {
 	for _, tc := range testCases {
 		err := tc.cfg.checkAndFix()
 		require.Equal(t, true, errors.Is(err, tc.err))
-		tc.cfg.shardRepairCfg()
-		tc.cfg.blobDeleteCfg()
+		tc.cfg.shardRepairCfg(nil)
+		tc.cfg.blobDeleteCfg(nil)
 	}
 }
 
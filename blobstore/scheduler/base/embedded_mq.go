@@ -0,0 +1,290 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/cubefs/cubefs/blobstore/api/proxy"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/closer"
+)
+
+const (
+	embeddedMQHostSyncInterval = time.Minute
+	embeddedMQRetryInterval    = time.Second
+)
+
+var errNoProxyAvailable = errors.New("no proxy available")
+
+// EmbeddedMQ is the queue embedded in all proxies of cluster, it's used instead of kafka.
+// Every proxy is consumed as a partition, and the failed messages are sent to any proxy.
+type EmbeddedMQ struct {
+	cli        proxy.MQClient
+	hostGetter func(ctx context.Context) ([]string, error)
+	consumer   string
+}
+
+// NewEmbeddedMQ returns embedded mq, hostGetter returns hosts of all proxies,
+// and consumer is the unique id of this scheduler
+func NewEmbeddedMQ(cli proxy.MQClient, hostGetter func(ctx context.Context) ([]string, error), consumer string) *EmbeddedMQ {
+	return &EmbeddedMQ{
+		cli:        cli,
+		hostGetter: hostGetter,
+		consumer:   consumer,
+	}
+}
+
+// StartKafkaConsumer starts consuming the topic in all proxies
+func (mq *EmbeddedMQ) StartKafkaConsumer(cfg KafkaConsumerCfg, fn func(msg []*sarama.ConsumerMessage,
+	consumerPause ConsumerPause) bool) (GroupConsumer, error) {
+	group := fmt.Sprintf("%s-%s", proto.ServiceNameScheduler, cfg.Topic)
+	span, ctx := trace.StartSpanFromContext(context.Background(), group)
+
+	hosts, err := mq.hostGetter(ctx)
+	if err != nil {
+		span.Errorf("get proxy hosts failed: err[%+v]", err)
+		return nil, err
+	}
+
+	consumer := &embeddedGroupConsumer{
+		Closer:     closer.New(),
+		mq:         mq,
+		cfg:        cfg,
+		group:      group,
+		fn:         fn,
+		span:       span,
+		partitions: make(map[string]int32),
+	}
+	consumer.addHosts(ctx, hosts)
+	go consumer.syncHosts(ctx)
+
+	span.Infof("start embedded mq consumer: group[%s], hosts[%v]", group, hosts)
+	return consumer, nil
+}
+
+// NewMsgSender returns message sender of topic
+func (mq *EmbeddedMQ) NewMsgSender(topic string) IProducer {
+	return &embeddedMsgSender{mq: mq, topic: topic}
+}
+
+type embeddedGroupConsumer struct {
+	closer.Closer
+	mq    *EmbeddedMQ
+	cfg   KafkaConsumerCfg
+	group string
+	fn    func(msg []*sarama.ConsumerMessage, consumerPause ConsumerPause) bool
+	span  trace.Span
+
+	mu         sync.Mutex
+	partitions map[string]int32
+	wg         sync.WaitGroup
+}
+
+func (c *embeddedGroupConsumer) Stop() {
+	c.Close()
+	// no more proxy will be added after closed
+	c.mu.Lock()
+	c.mu.Unlock() // nolint: staticcheck
+	c.wg.Wait()
+	c.span.Infof("stop embedded mq consumer: group[%s]", c.group)
+}
+
+// addHosts starts consuming the new proxies, the proxy is never removed
+// because the messages in it must be consumed even if it's offline for a while
+func (c *embeddedGroupConsumer) addHosts(ctx context.Context, hosts []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.Done():
+		return
+	default:
+	}
+	for _, host := range hosts {
+		if _, ok := c.partitions[host]; ok {
+			continue
+		}
+		partition := int32(len(c.partitions))
+		c.partitions[host] = partition
+		c.wg.Add(1)
+		go func(host string) {
+			defer c.wg.Done()
+			c.consume(ctx, host, partition)
+		}(host)
+	}
+}
+
+func (c *embeddedGroupConsumer) syncHosts(ctx context.Context) {
+	tk := time.NewTicker(embeddedMQHostSyncInterval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-tk.C:
+			hosts, err := c.mq.hostGetter(ctx)
+			if err != nil {
+				c.span.Warnf("sync proxy hosts failed: err[%+v]", err)
+				continue
+			}
+			c.addHosts(ctx, hosts)
+		case <-c.Done():
+			return
+		}
+	}
+}
+
+func (c *embeddedGroupConsumer) wait(d time.Duration) bool {
+	select {
+	case <-c.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// consume fetches messages from the committed offset of proxy and commits offset after consumed success.
+// The messages are retried one by one after a batch consumed failed, and the single message consumed
+// failed is nacked, so that only the message always failed is dead lettered after max retries.
+func (c *embeddedGroupConsumer) consume(ctx context.Context, host string, partition int32) {
+	span := c.span
+	waitTime := time.Second * time.Duration(c.cfg.MaxWaitTimeS)
+	if waitTime <= 0 {
+		waitTime = embeddedMQRetryInterval
+	}
+	batchSize := c.cfg.MaxBatchSize
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		default:
+		}
+
+		ret, err := c.mq.cli.MQFetch(ctx, host, &proxy.MQFetchArgs{
+			Topic:    c.cfg.Topic,
+			Group:    c.group,
+			Consumer: c.mq.consumer,
+			Count:    batchSize,
+		})
+		if err != nil {
+			if rpc.DetectStatusCode(err) == errcode.CodeMQConsumeConflict {
+				// the proxy is consumed by other scheduler
+				span.Debugf("proxy is consumed by other: host[%s], topic[%s]", host, c.cfg.Topic)
+			} else {
+				span.Warnf("fetch messages failed: host[%s], topic[%s], err[%+v]", host, c.cfg.Topic, err)
+			}
+			if !c.wait(waitTime) {
+				return
+			}
+			continue
+		}
+		if len(ret.Messages) == 0 {
+			if !c.wait(waitTime) {
+				return
+			}
+			continue
+		}
+
+		msgs := make([]*sarama.ConsumerMessage, len(ret.Messages))
+		for i, m := range ret.Messages {
+			msgs[i] = &sarama.ConsumerMessage{
+				Topic:     c.cfg.Topic,
+				Partition: partition,
+				Offset:    m.Offset,
+				Value:     m.Value,
+				Timestamp: time.Unix(0, m.Timestamp),
+			}
+		}
+		first, last := msgs[0], msgs[len(msgs)-1]
+		span.Debugf("messages claimed: host[%s], topic[%s], offset[%d-%d]", host, c.cfg.Topic, first.Offset, last.Offset)
+
+		if success := c.fn(msgs, c); !success {
+			select {
+			case <-c.Done():
+				return
+			default:
+			}
+			span.Warnf("message not consume: host[%s], topic[%s], offset[%d-%d]", host, c.cfg.Topic, first.Offset, last.Offset)
+			if len(msgs) == 1 {
+				if err = c.mq.cli.MQNack(ctx, host, &proxy.MQCommitArgs{
+					Topic:    c.cfg.Topic,
+					Group:    c.group,
+					Consumer: c.mq.consumer,
+					Offset:   first.Offset,
+				}); err != nil {
+					span.Errorf("nack message failed: host[%s], topic[%s], offset[%d], err[%+v]", host, c.cfg.Topic, first.Offset, err)
+				}
+			}
+			batchSize = 1
+			if !c.wait(embeddedMQRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		// the messages will be consumed again if commit failed
+		if err = c.mq.cli.MQCommit(ctx, host, &proxy.MQCommitArgs{
+			Topic:    c.cfg.Topic,
+			Group:    c.group,
+			Consumer: c.mq.consumer,
+			Offset:   last.Offset + 1,
+		}); err != nil {
+			span.Errorf("commit offset failed: host[%s], topic[%s], offset[%d], err[%+v]", host, c.cfg.Topic, last.Offset+1, err)
+		}
+		batchSize = c.cfg.MaxBatchSize
+	}
+}
+
+type embeddedMsgSender struct {
+	mq    *EmbeddedMQ
+	topic string
+}
+
+// SendMessage send message to mq
+func (sender *embeddedMsgSender) SendMessage(msg []byte) error {
+	return sender.SendMessages([][]byte{msg})
+}
+
+// SendMessages send message batch to a random proxy, and try other proxies if failed
+func (sender *embeddedMsgSender) SendMessages(msgs [][]byte) (err error) {
+	span, ctx := trace.StartSpanFromContext(context.Background(), "EmbeddedMQSend")
+	hosts, err := sender.mq.hostGetter(ctx)
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		return errNoProxyAvailable
+	}
+
+	start := rand.Intn(len(hosts))
+	for i := range hosts {
+		host := hosts[(start+i)%len(hosts)]
+		err = sender.mq.cli.MQProduce(ctx, host, &proxy.MQProduceArgs{Topic: sender.topic, Msgs: msgs})
+		if err == nil {
+			return nil
+		}
+		span.Warnf("send messages failed: host[%s], topic[%s], err[%+v]", host, sender.topic, err)
+	}
+	return err
+}
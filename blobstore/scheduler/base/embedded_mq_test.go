@@ -0,0 +1,182 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/api/proxy"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/proxy/mq/diskqueue"
+)
+
+// mockMQClient is client of the queues in multiple proxies
+type mockMQClient struct {
+	queues map[string]*diskqueue.Queue
+}
+
+func newMockMQClient(t *testing.T, hosts ...string) *mockMQClient {
+	cli := &mockMQClient{queues: make(map[string]*diskqueue.Queue)}
+	for _, host := range hosts {
+		q, err := diskqueue.Open(diskqueue.Config{Dir: t.TempDir(), MaxRetries: 2, DisableSync: true})
+		require.NoError(t, err)
+		t.Cleanup(q.Close)
+		cli.queues[host] = q
+	}
+	return cli
+}
+
+func (c *mockMQClient) hosts(ctx context.Context) ([]string, error) {
+	var hosts []string
+	for host := range c.queues {
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+func (c *mockMQClient) convertErr(err error) error {
+	if err == diskqueue.ErrConsumerConflict {
+		return errcode.ErrMQConsumeConflict
+	}
+	return err
+}
+
+func (c *mockMQClient) MQProduce(ctx context.Context, host string, args *proxy.MQProduceArgs) error {
+	q, ok := c.queues[host]
+	if !ok {
+		return errNoProxyAvailable
+	}
+	return q.Produce(args.Topic, args.Msgs)
+}
+
+func (c *mockMQClient) MQFetch(ctx context.Context, host string, args *proxy.MQFetchArgs) (ret proxy.MQFetchRet, err error) {
+	msgs, err := c.queues[host].Fetch(args.Topic, args.Group, args.Consumer, args.Count)
+	if err != nil {
+		return ret, c.convertErr(err)
+	}
+	for _, msg := range msgs {
+		ret.Messages = append(ret.Messages, proxy.MQMessage{Offset: msg.Offset, Timestamp: msg.Timestamp, Value: msg.Value})
+	}
+	return ret, nil
+}
+
+func (c *mockMQClient) MQCommit(ctx context.Context, host string, args *proxy.MQCommitArgs) error {
+	return c.convertErr(c.queues[host].Commit(args.Topic, args.Group, args.Consumer, args.Offset))
+}
+
+func (c *mockMQClient) MQNack(ctx context.Context, host string, args *proxy.MQCommitArgs) error {
+	return c.convertErr(c.queues[host].Nack(args.Topic, args.Group, args.Consumer, args.Offset))
+}
+
+func (c *mockMQClient) MQStat(ctx context.Context, host string, args *proxy.MQStatArgs) (ret proxy.MQTopicStat, err error) {
+	st, err := c.queues[host].Stat(args.Topic)
+	if err != nil {
+		return ret, err
+	}
+	return proxy.MQTopicStat{Topic: st.Topic, StartOffset: st.StartOffset, EndOffset: st.EndOffset, Groups: st.Groups}, nil
+}
+
+func TestEmbeddedMQConsumer(t *testing.T) {
+	cli := newMockMQClient(t, "proxy1", "proxy2")
+	group := fmt.Sprintf("%s-%s", proto.ServiceNameScheduler, testTopic)
+	for host, q := range cli.queues {
+		for i := 0; i < 5; i++ {
+			require.NoError(t, q.Produce(testTopic, [][]byte{[]byte(fmt.Sprintf("%s-%d", host, i))}))
+		}
+	}
+	require.NoError(t, cli.queues["proxy1"].Produce(testTopic, [][]byte{[]byte("bad")}))
+	require.NoError(t, cli.queues["proxy1"].Produce(testTopic, [][]byte{[]byte("proxy1-5")}))
+
+	var (
+		mu         sync.Mutex
+		consumed   = make(map[string]struct{})
+		partitions = make(map[int32]struct{})
+	)
+	mq := NewEmbeddedMQ(cli, cli.hosts, "scheduler-1")
+	consumer, err := mq.StartKafkaConsumer(KafkaConsumerCfg{
+		TaskType:     proto.TaskTypeBlobDelete,
+		Topic:        testTopic,
+		MaxBatchSize: 2,
+		MaxWaitTimeS: 1,
+	}, func(msgs []*sarama.ConsumerMessage, consumerPause ConsumerPause) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		require.LessOrEqual(t, len(msgs), 2)
+		// the batch with bad message is always consumed failed
+		for _, msg := range msgs {
+			if string(msg.Value) == "bad" {
+				return false
+			}
+		}
+		for _, msg := range msgs {
+			consumed[string(msg.Value)] = struct{}{}
+			partitions[msg.Partition] = struct{}{}
+		}
+		return true
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(consumed) == 11
+	}, 20*time.Second, 100*time.Millisecond)
+
+	// the other scheduler can't consume the proxies at the same time
+	other := NewEmbeddedMQ(cli, cli.hosts, "scheduler-2")
+	_, err = other.cli.MQFetch(context.Background(), "proxy1", &proxy.MQFetchArgs{Topic: testTopic, Group: group, Consumer: "scheduler-2"})
+	require.ErrorIs(t, err, errcode.ErrMQConsumeConflict)
+	consumer.Stop()
+
+	mu.Lock()
+	require.Equal(t, 2, len(partitions))
+	mu.Unlock()
+	for host, q := range cli.queues {
+		st, err := q.Stat(testTopic)
+		require.NoError(t, err)
+		require.Equal(t, st.EndOffset, st.Groups[group], host)
+	}
+	// the bad message is moved into dead letter topic
+	msgs, err := cli.queues["proxy1"].Fetch(testTopic+diskqueue.DeadLetterSuffix, group, "scheduler-1", 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(msgs))
+	require.Equal(t, "bad", string(msgs[0].Value))
+}
+
+func TestEmbeddedMQMsgSender(t *testing.T) {
+	cli := newMockMQClient(t, "proxy1")
+	hosts := []string{"proxy1", "offline"}
+	mq := NewEmbeddedMQ(cli, func(ctx context.Context) ([]string, error) { return hosts, nil }, "scheduler-1")
+
+	sender := mq.NewMsgSender(testTopic)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, sender.SendMessage([]byte("msg")))
+	}
+	require.NoError(t, sender.SendMessages([][]byte{[]byte("msg1"), []byte("msg2")}))
+	st, err := cli.queues["proxy1"].Stat(testTopic)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), st.EndOffset)
+
+	hosts = nil
+	require.ErrorIs(t, sender.SendMessage([]byte("msg")), errNoProxyAvailable)
+}
@@ -194,6 +194,13 @@ func (cfg *BlobDeleteConfig) failedProducerConfig() *kafka.ProducerCfg {
 	}
 }
 
+func (cfg *BlobDeleteConfig) newFailMsgSender() (base.IProducer, error) {
+	if cfg.Kafka.Embedded != nil {
+		return cfg.Kafka.Embedded.NewMsgSender(cfg.Kafka.TopicFailed), nil
+	}
+	return base.NewMsgSender(cfg.failedProducerConfig())
+}
+
 // BlobDeleteMgr is blob delete manager
 type BlobDeleteMgr struct {
 	closer.Closer
@@ -229,7 +236,7 @@ func NewBlobDeleteMgr(
 	blobnodeCli client.BlobnodeAPI,
 	kafkaClient base.KafkaConsumer,
 ) (*BlobDeleteMgr, error) {
-	failMsgSender, err := base.NewMsgSender(cfg.failedProducerConfig())
+	failMsgSender, err := cfg.newFailMsgSender()
 	if err != nil {
 		return nil, err
 	}
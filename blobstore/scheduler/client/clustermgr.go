@@ -44,6 +44,8 @@ type ClusterMgrVolumeAPI interface {
 	UpdateVolume(ctx context.Context, newVuid, oldVuid proto.Vuid, newDiskID proto.DiskID) (err error)
 	AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (ret *AllocVunitInfo, err error)
 	ReleaseVolumeUnit(ctx context.Context, vuid proto.Vuid, diskID proto.DiskID) (err error)
+	AllocConvertVolumeUnits(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) (ret []proto.VunitLocation, err error)
+	ConvertVolume(ctx context.Context, vid proto.Vid, mode codemode.CodeMode, units []proto.VunitLocation) (err error)
 	ListDiskVolumeUnits(ctx context.Context, diskID proto.DiskID) (ret []*VunitInfoSimple, err error)
 	ListVolume(ctx context.Context, marker proto.Vid, count int) (volInfo []*VolumeInfoSimple, retVid proto.Vid, err error)
 }
@@ -363,6 +365,8 @@ type IClusterManager interface {
 	UpdateVolume(ctx context.Context, args *cmapi.UpdateVolumeArgs) (err error)
 	AllocVolumeUnit(ctx context.Context, args *cmapi.AllocVolumeUnitArgs) (ret *cmapi.AllocVolumeUnit, err error)
 	ReleaseVolumeUnit(ctx context.Context, args *cmapi.ReleaseVolumeUnitArgs) (err error)
+	AllocConvertVolumeUnits(ctx context.Context, args *cmapi.AllocConvertVolumeUnitsArgs) (ret []cmapi.Unit, err error)
+	ConvertVolume(ctx context.Context, args *cmapi.ConvertVolumeArgs) (err error)
 	ListVolumeUnit(ctx context.Context, args *cmapi.ListVolumeUnitArgs) ([]*cmapi.VolumeUnitInfo, error)
 	ListVolume(ctx context.Context, args *cmapi.ListVolumeArgs) (ret cmapi.ListVolumes, err error)
 	ListDisk(ctx context.Context, args *cmapi.ListOptionArgs) (ret cmapi.ListDiskRet, err error)
@@ -500,6 +504,45 @@ func (c *clustermgrClient) ReleaseVolumeUnit(ctx context.Context, vuid proto.Vui
 	return
 }
 
+// AllocConvertVolumeUnits alloc all volume units in the code mode for volume convert
+func (c *clustermgrClient) AllocConvertVolumeUnits(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) ([]proto.VunitLocation, error) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+
+	span := trace.SpanFromContextSafe(ctx)
+
+	span.Debugf("alloc convert volume units: args vid[%d], code_mode[%s]", vid, mode.String())
+	units, err := c.client.AllocConvertVolumeUnits(ctx, &cmapi.AllocConvertVolumeUnitsArgs{Vid: vid, CodeMode: mode})
+	if err != nil {
+		span.Errorf("alloc convert volume units failed: err[%+v]", err)
+		return nil, err
+	}
+	span.Debugf("alloc convert volume units ret: units[%+v]", units)
+
+	ret := make([]proto.VunitLocation, len(units))
+	for i, unit := range units {
+		ret[i] = proto.VunitLocation{Vuid: unit.Vuid, Host: unit.Host, DiskID: unit.DiskID}
+	}
+	return ret, nil
+}
+
+// ConvertVolume switch volume into the code mode with converted units
+func (c *clustermgrClient) ConvertVolume(ctx context.Context, vid proto.Vid, mode codemode.CodeMode, units []proto.VunitLocation) (err error) {
+	c.rwLock.Lock()
+	defer c.rwLock.Unlock()
+
+	span := trace.SpanFromContextSafe(ctx)
+
+	args := &cmapi.ConvertVolumeArgs{Vid: vid, CodeMode: mode, Units: make([]cmapi.Unit, len(units))}
+	for i, unit := range units {
+		args.Units[i] = cmapi.Unit{Vuid: unit.Vuid, Host: unit.Host, DiskID: unit.DiskID}
+	}
+	span.Infof("convert volume: args vid[%d], code_mode[%s], units[%+v]", vid, mode.String(), units)
+	err = c.client.ConvertVolume(ctx, args)
+	span.Infof("convert volume ret: err %+v", err)
+	return
+}
+
 // ListDiskVolumeUnits list disk volume units
 func (c *clustermgrClient) ListDiskVolumeUnits(ctx context.Context, diskID proto.DiskID) (rets []*VunitInfoSimple, err error) {
 	c.rwLock.RLock()
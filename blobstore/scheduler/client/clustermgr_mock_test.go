@@ -37,6 +37,21 @@ func (m *MockClusterManager) EXPECT() *MockClusterManagerMockRecorder {
 	return m.recorder
 }
 
+// AllocConvertVolumeUnits mocks base method.
+func (m *MockClusterManager) AllocConvertVolumeUnits(arg0 context.Context, arg1 *clustermgr.AllocConvertVolumeUnitsArgs) ([]clustermgr.Unit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocConvertVolumeUnits", arg0, arg1)
+	ret0, _ := ret[0].([]clustermgr.Unit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocConvertVolumeUnits indicates an expected call of AllocConvertVolumeUnits.
+func (mr *MockClusterManagerMockRecorder) AllocConvertVolumeUnits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocConvertVolumeUnits", reflect.TypeOf((*MockClusterManager)(nil).AllocConvertVolumeUnits), arg0, arg1)
+}
+
 // AllocVolumeUnit mocks base method.
 func (m *MockClusterManager) AllocVolumeUnit(arg0 context.Context, arg1 *clustermgr.AllocVolumeUnitArgs) (*clustermgr.AllocVolumeUnit, error) {
 	m.ctrl.T.Helper()
@@ -52,6 +67,20 @@ func (mr *MockClusterManagerMockRecorder) AllocVolumeUnit(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocVolumeUnit", reflect.TypeOf((*MockClusterManager)(nil).AllocVolumeUnit), arg0, arg1)
 }
 
+// ConvertVolume mocks base method.
+func (m *MockClusterManager) ConvertVolume(arg0 context.Context, arg1 *clustermgr.ConvertVolumeArgs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertVolume", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConvertVolume indicates an expected call of ConvertVolume.
+func (mr *MockClusterManagerMockRecorder) ConvertVolume(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertVolume", reflect.TypeOf((*MockClusterManager)(nil).ConvertVolume), arg0, arg1)
+}
+
 // DeleteKV mocks base method.
 func (m *MockClusterManager) DeleteKV(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -154,6 +154,29 @@ func TestClustermgrClient(t *testing.T) {
 		err := cli.ReleaseVolumeUnit(ctx, proto.Vuid(2), proto.DiskID(1))
 		require.NoError(t, err)
 	}
+	{
+		// alloc convert volume units
+		cli.client.(*MockClusterManager).EXPECT().AllocConvertVolumeUnits(any, any).Return(nil, errMock)
+		_, err := cli.AllocConvertVolumeUnits(ctx, proto.Vid(1), codemode.EC6P6)
+		require.True(t, errors.Is(err, errMock))
+
+		unit := cmapi.Unit{Vuid: proto.Vuid(3), DiskID: proto.DiskID(2), Host: "127.0.0.1:8889"}
+		cli.client.(*MockClusterManager).EXPECT().AllocConvertVolumeUnits(any, any).Return([]cmapi.Unit{unit}, nil)
+		units, err := cli.AllocConvertVolumeUnits(ctx, proto.Vid(1), codemode.EC6P6)
+		require.NoError(t, err)
+		require.Equal(t, []proto.VunitLocation{{Vuid: unit.Vuid, Host: unit.Host, DiskID: unit.DiskID}}, units)
+	}
+	{
+		// convert volume
+		cli.client.(*MockClusterManager).EXPECT().ConvertVolume(any, any).DoAndReturn(
+			func(_ context.Context, args *cmapi.ConvertVolumeArgs) error {
+				require.Equal(t, codemode.EC6P6, args.CodeMode)
+				require.Equal(t, proto.Vuid(3), args.Units[0].Vuid)
+				return nil
+			})
+		err := cli.ConvertVolume(ctx, proto.Vid(1), codemode.EC6P6, []proto.VunitLocation{{Vuid: proto.Vuid(3), DiskID: proto.DiskID(2)}})
+		require.NoError(t, err)
+	}
 	{
 		// list disk volume units
 		cli.client.(*MockClusterManager).EXPECT().ListVolumeUnit(any, any).Return(nil, errMock)
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	clustermgr "github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	codemode "github.com/cubefs/cubefs/blobstore/common/codemode"
 	proto "github.com/cubefs/cubefs/blobstore/common/proto"
 	client "github.com/cubefs/cubefs/blobstore/scheduler/client"
 	gomock "github.com/golang/mock/gomock"
@@ -65,6 +66,21 @@ func (mr *MockClusterMgrAPIMockRecorder) AddMigratingDisk(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMigratingDisk", reflect.TypeOf((*MockClusterMgrAPI)(nil).AddMigratingDisk), arg0, arg1)
 }
 
+// AllocConvertVolumeUnits mocks base method.
+func (m *MockClusterMgrAPI) AllocConvertVolumeUnits(arg0 context.Context, arg1 proto.Vid, arg2 codemode.CodeMode) ([]proto.VunitLocation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllocConvertVolumeUnits", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]proto.VunitLocation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllocConvertVolumeUnits indicates an expected call of AllocConvertVolumeUnits.
+func (mr *MockClusterMgrAPIMockRecorder) AllocConvertVolumeUnits(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocConvertVolumeUnits", reflect.TypeOf((*MockClusterMgrAPI)(nil).AllocConvertVolumeUnits), arg0, arg1, arg2)
+}
+
 // AllocVolumeUnit mocks base method.
 func (m *MockClusterMgrAPI) AllocVolumeUnit(arg0 context.Context, arg1 proto.Vuid) (*client.AllocVunitInfo, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +96,20 @@ func (mr *MockClusterMgrAPIMockRecorder) AllocVolumeUnit(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllocVolumeUnit", reflect.TypeOf((*MockClusterMgrAPI)(nil).AllocVolumeUnit), arg0, arg1)
 }
 
+// ConvertVolume mocks base method.
+func (m *MockClusterMgrAPI) ConvertVolume(arg0 context.Context, arg1 proto.Vid, arg2 codemode.CodeMode, arg3 []proto.VunitLocation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertVolume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConvertVolume indicates an expected call of ConvertVolume.
+func (mr *MockClusterMgrAPIMockRecorder) ConvertVolume(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertVolume", reflect.TypeOf((*MockClusterMgrAPI)(nil).ConvertVolume), arg0, arg1, arg2, arg3)
+}
+
 // DeleteMigrateTask mocks base method.
 func (m *MockClusterMgrAPI) DeleteMigrateTask(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/kafka"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/recordlog"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
 	"github.com/cubefs/cubefs/blobstore/util/defaulter"
 )
 
@@ -89,10 +90,12 @@ type Config struct {
 	DiskDrop      DropMgrConfig       `json:"disk_drop"`
 	DiskRepair    MigrateConfig       `json:"disk_repair"`
 	ManualMigrate MigrateConfig       `json:"manual_migrate"`
+	VolumeConvert MigrateConfig       `json:"volume_convert"`
 	VolumeInspect VolumeInspectMgrCfg `json:"volume_inspect"`
 	TaskLog       recordlog.Config    `json:"task_log"`
 
 	Kafka       KafkaConfig       `json:"kafka"`
+	EmbeddedMQ  EmbeddedMQConfig  `json:"embedded_mq"`
 	ShardRepair ShardRepairConfig `json:"shard_repair"`
 	BlobDelete  BlobDeleteConfig  `json:"blob_delete"`
 
@@ -114,6 +117,8 @@ type ShardRepairKafkaConfig struct {
 	TopicNormals           []string
 	TopicFailed            string
 	FailMsgSenderTimeoutMs int64
+	// Embedded sends failed messages to the queue embedded in proxy instead of kafka if it is not nil
+	Embedded *base.EmbeddedMQ
 }
 
 // BlobDeleteKafkaConfig is kafka config of blob delete
@@ -122,6 +127,8 @@ type BlobDeleteKafkaConfig struct {
 	FailMsgSenderTimeoutMs int64
 	TopicNormal            string
 	TopicFailed            string
+	// Embedded sends failed messages to the queue embedded in proxy instead of kafka if it is not nil
+	Embedded *base.EmbeddedMQ
 }
 
 type Topics struct {
@@ -139,6 +146,12 @@ type KafkaConfig struct {
 	Version                string   `json:"version"`
 }
 
+// EmbeddedMQConfig is config of the queue embedded in proxy, it's used instead of kafka if enabled,
+// and the topics are same as kafka
+type EmbeddedMQConfig struct {
+	Enable bool `json:"enable"`
+}
+
 type Services struct {
 	Leader  uint64            `json:"leader"`
 	NodeID  uint64            `json:"node_id"`
@@ -194,6 +207,7 @@ func (c *Config) fixConfig() (err error) {
 	c.fixDiskDropConfig()
 	c.fixDiskRepairConfig()
 	c.fixManualMigrateConfig()
+	c.fixVolumeConvertConfig()
 	c.fixInspectConfig()
 	c.fixShardRepairConfig()
 	if err := c.fixBlobDeleteConfig(); err != nil {
@@ -254,6 +268,11 @@ func (c *Config) fixManualMigrateConfig() {
 	c.ManualMigrate.CheckAndFix()
 }
 
+func (c *Config) fixVolumeConvertConfig() {
+	c.VolumeConvert.ClusterID = c.ClusterID
+	c.VolumeConvert.CheckAndFix()
+}
+
 func (c *Config) fixInspectConfig() {
 	defaulter.LessOrEqual(&c.VolumeInspect.TimeoutMs, defaultInspectTimeoutMs)
 	defaulter.LessOrEqual(&c.VolumeInspect.ListVolStep, defaultListVolStep)
@@ -47,6 +47,7 @@ type MMigrator interface {
 	IMigrator
 	IDisKMigrator
 	IManualMigrator
+	IVolumeConverter
 }
 
 // Migrator base interface of migrate, balancer, disk_droper, manual_migrater.
@@ -544,6 +545,10 @@ func (mgr *MigrateMgr) prepareTask() (err error) {
 		return err
 	}
 
+	if migTask.TaskType == proto.TaskTypeVolumeConvert {
+		return mgr.prepareConvertTask(ctx, migTask, volInfo)
+	}
+
 	// check necessity of generating current task
 	if migTask.SourceVuid != volInfo.VunitLocations[migTask.SourceVuid.Index()].Vuid {
 		span.Infof("the source unit has been moved and finish task immediately: task_id[%s], task source vuid[%v], current vuid[%v]",
@@ -639,6 +644,10 @@ func (mgr *MigrateMgr) finishTask() (err error) {
 		return mgr.clusterMgrCli.UpdateMigrateTask(ctx, migrateTask)
 	})
 
+	if migrateTask.TaskType == proto.TaskTypeVolumeConvert {
+		return mgr.finishConvertTask(ctx, migrateTask)
+	}
+
 	// update volume mapping relationship
 	err = mgr.clusterMgrCli.UpdateVolume(ctx, migrateTask.Destination.Vuid, migrateTask.SourceVuid, migrateTask.DestinationDiskID())
 	if err != nil {
@@ -668,6 +677,12 @@ func (mgr *MigrateMgr) finishTask() (err error) {
 		err = nil
 	}
 
+	return mgr.unlockAndFinishTask(ctx, migrateTask)
+}
+
+func (mgr *MigrateMgr) unlockAndFinishTask(ctx context.Context, migrateTask *proto.MigrateTask) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
 	err = mgr.clusterMgrCli.UnlockVolume(ctx, migrateTask.SourceVuid.Vid())
 	if err != nil {
 		span.Errorf("unlock volume failed: err[%+v]", err)
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	scheduler "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	codemode "github.com/cubefs/cubefs/blobstore/common/codemode"
 	proto "github.com/cubefs/cubefs/blobstore/common/proto"
 	client "github.com/cubefs/cubefs/blobstore/scheduler/client"
 	gomock "github.com/golang/mock/gomock"
@@ -210,6 +211,20 @@ func (mr *MockMigraterMockRecorder) AcquireTask(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireTask", reflect.TypeOf((*MockMigrater)(nil).AcquireTask), arg0, arg1)
 }
 
+// AddConvertTask mocks base method.
+func (m *MockMigrater) AddConvertTask(arg0 context.Context, arg1 proto.Vid, arg2 codemode.CodeMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddConvertTask", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddConvertTask indicates an expected call of AddConvertTask.
+func (mr *MockMigraterMockRecorder) AddConvertTask(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddConvertTask", reflect.TypeOf((*MockMigrater)(nil).AddConvertTask), arg0, arg1, arg2)
+}
+
 // AddManualTask mocks base method.
 func (m *MockMigrater) AddManualTask(arg0 context.Context, arg1 proto.Vuid, arg2 bool) error {
 	m.ctrl.T.Helper()
@@ -42,6 +42,7 @@ type Service struct {
 	diskDropMgr   IDisKMigrator
 	diskRepairMgr IDisKMigrator
 	manualMigMgr  IManualMigrator
+	convertMgr    IVolumeConverter
 	inspectMgr    IVolumeInspector
 
 	shardRepairMgr  ITaskRunner
@@ -63,6 +64,8 @@ func (svr *Service) mgrByType(typ proto.TaskType) (Migrator, error) {
 		return svr.diskDropMgr, nil
 	case proto.TaskTypeManualMigrate:
 		return svr.manualMigMgr, nil
+	case proto.TaskTypeVolumeConvert:
+		return svr.convertMgr, nil
 	default:
 		return nil, errIllegalTaskType
 	}
@@ -89,7 +92,7 @@ func (svr *Service) HTTPTaskAcquire(c *rpc.Context) {
 
 	// acquire task ordered: returns disk repair task first and other random
 	ctx := c.Request.Context()
-	migrators := []Migrator{svr.diskRepairMgr, svr.manualMigMgr, svr.diskDropMgr, svr.balanceMgr}
+	migrators := []Migrator{svr.diskRepairMgr, svr.manualMigMgr, svr.diskDropMgr, svr.balanceMgr, svr.convertMgr}
 	shuffledMigrators := migrators[1:]
 	rand.Shuffle(len(shuffledMigrators), func(i, j int) {
 		shuffledMigrators[i], shuffledMigrators[j] = shuffledMigrators[j], shuffledMigrators[i]
@@ -121,6 +124,13 @@ func (svr *Service) HTTPTaskReclaim(c *rpc.Context) {
 		return
 	}
 
+	// the units of volume convert task are reserved and allocated together, redo with the same destination
+	if args.TaskType == proto.TaskTypeVolumeConvert {
+		c.RespondError(reclaimer.ReclaimTask(ctx, args.IDC, args.TaskID, args.Src, args.Dest,
+			&client.AllocVunitInfo{VunitLocation: args.Dest}))
+		return
+	}
+
 	newDst, err := base.AllocVunitSafe(ctx, svr.clusterMgrCli, args.Dest.Vuid, args.Src)
 	if err != nil {
 		c.RespondError(err)
@@ -357,6 +367,11 @@ func (svr *Service) HTTPStats(c *rpc.Context) {
 		MigrateTasksStat: svr.manualMigMgr.Stats(),
 	}
 
+	// stats volume convert tasks
+	taskStats.VolumeConvert = &api.VolumeConvertTasksStat{
+		MigrateTasksStat: svr.convertMgr.Stats(),
+	}
+
 	// stats inspect tasks
 	finished, timeout := svr.inspectMgr.GetTaskStats()
 	taskStats.VolumeInspect = &api.VolumeInspectTasksStat{
@@ -387,6 +402,25 @@ func (svr *Service) HTTPManualMigrateTaskAdd(c *rpc.Context) {
 	c.RespondError(rpc.Error2HTTPError(err))
 }
 
+// HTTPVolumeConvertTaskAdd adds volume convert task
+func (svr *Service) HTTPVolumeConvertTaskAdd(c *rpc.Context) {
+	ctx := c.Request.Context()
+
+	args := new(api.AddVolumeConvertArgs)
+	if err := c.ParseArgs(args); err != nil {
+		c.RespondError(err)
+		return
+	}
+
+	if !args.Valid() {
+		c.RespondError(errcode.ErrIllegalArguments)
+		return
+	}
+
+	err := svr.convertMgr.AddConvertTask(ctx, args.Vid, args.CodeMode)
+	c.RespondError(rpc.Error2HTTPError(err))
+}
+
 // HTTPUpdateVolume updates volume cache
 func (svr *Service) HTTPUpdateVolume(c *rpc.Context) {
 	args := new(api.UpdateVolumeArgs)
@@ -25,6 +25,7 @@ import (
 
 	cmapi "github.com/cubefs/cubefs/blobstore/api/clustermgr"
 	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	"github.com/cubefs/cubefs/blobstore/common/counter"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/rpc"
@@ -53,6 +54,7 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr := NewMockMigrater(ctr)
 	diskRepairMgr := NewMockMigrater(ctr)
 	manualMgr := NewMockMigrater(ctr)
+	convertMgr := NewMockMigrater(ctr)
 	balanceMgr := NewMockMigrater(ctr)
 	inspectorMgr := NewMockVolumeInspector(ctr)
 	clusterTopology := NewMockClusterTopology(ctr)
@@ -72,6 +74,8 @@ func newMockService(t *testing.T) *Service {
 	// reclaim manual migrate task
 	manualMgr.EXPECT().ReclaimTask(any, any, any, any, any, any).Return(nil)
 	clusterMgrCli.EXPECT().AllocVolumeUnit(any, any).Return(&client.AllocVunitInfo{}, nil)
+	// reclaim volume convert task
+	convertMgr.EXPECT().ReclaimTask(any, any, any, any, any, any).Return(nil)
 
 	// cancel repair task
 	diskRepairMgr.EXPECT().CancelTask(any, any).Return(nil)
@@ -81,6 +85,8 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().CancelTask(any, any).Return(nil)
 	// cancel manual migrate task
 	manualMgr.EXPECT().CancelTask(any, any).Return(nil)
+	// cancel volume convert task
+	convertMgr.EXPECT().CancelTask(any, any).Return(nil)
 
 	// complete repair task
 	diskRepairMgr.EXPECT().CompleteTask(any, any).Return(nil)
@@ -90,6 +96,8 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().CompleteTask(any, any).Return(nil)
 	// complete manual migrate task
 	manualMgr.EXPECT().CompleteTask(any, any).Return(nil)
+	// complete volume convert task
+	convertMgr.EXPECT().CompleteTask(any, any).Return(nil)
 
 	// renewal repair task
 	diskRepairMgr.EXPECT().RenewalTask(any, any, any).Times(3).Return(nil)
@@ -99,6 +107,8 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().RenewalTask(any, any, any).Times(3).Return(nil)
 	// renewal manual migrate task
 	manualMgr.EXPECT().RenewalTask(any, any, any).Times(3).Return(nil)
+	// renewal volume convert task
+	convertMgr.EXPECT().RenewalTask(any, any, any).Times(3).Return(nil)
 
 	// report repair task
 	diskRepairMgr.EXPECT().ReportWorkerTaskStats(any).Return()
@@ -108,10 +118,15 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().ReportWorkerTaskStats(any).Return()
 	// report manual migrate task
 	manualMgr.EXPECT().ReportWorkerTaskStats(any).Return()
+	// report volume convert task
+	convertMgr.EXPECT().ReportWorkerTaskStats(any).Return()
 
 	// add manual migrate task
 	manualMgr.EXPECT().AddManualTask(any, any, any).Return(nil)
 
+	// add volume convert task
+	convertMgr.EXPECT().AddConvertTask(any, any, any).Return(nil)
+
 	// acquire inspect task
 	inspectorMgr.EXPECT().AcquireInspect(any).Return(&proto.VolumeInspectTask{}, nil)
 
@@ -138,6 +153,7 @@ func newMockService(t *testing.T) *Service {
 	balanceMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
 	balanceMgr.EXPECT().Enabled().Return(true)
 	manualMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
+	convertMgr.EXPECT().Stats().Return(api.MigrateTasksStat{})
 	inspectorMgr.EXPECT().GetTaskStats().Return([counter.SLOT]int{}, [counter.SLOT]int{})
 	inspectorMgr.EXPECT().Enabled().Return(true)
 
@@ -146,10 +162,12 @@ func newMockService(t *testing.T) *Service {
 	diskDropMgr.EXPECT().QueryTask(any, any).Return(nil, nil)
 	diskRepairMgr.EXPECT().QueryTask(any, any).Return(nil, nil)
 	manualMgr.EXPECT().QueryTask(any, any).Return(nil, nil)
+	convertMgr.EXPECT().QueryTask(any, any).Return(nil, nil)
 	balanceMgr.EXPECT().QueryTask(any, any).Return(nil, errMock)
 	diskDropMgr.EXPECT().QueryTask(any, any).Return(nil, errMock)
 	diskRepairMgr.EXPECT().QueryTask(any, any).Return(nil, errMock)
 	manualMgr.EXPECT().QueryTask(any, any).Return(nil, errMock)
+	convertMgr.EXPECT().QueryTask(any, any).Return(nil, errMock)
 
 	// disk stats
 	diskRepairMgr.EXPECT().DiskProgress(any, any).Return(nil, errMock)
@@ -164,6 +182,7 @@ func newMockService(t *testing.T) *Service {
 		balanceMgr:    balanceMgr,
 		diskDropMgr:   diskDropMgr,
 		manualMigMgr:  manualMgr,
+		convertMgr:    convertMgr,
 		diskRepairMgr: diskRepairMgr,
 		inspectMgr:    inspectorMgr,
 
@@ -191,6 +210,7 @@ func TestServiceAPI(t *testing.T) {
 	taskTypes := []proto.TaskType{
 		proto.TaskTypeBalance, proto.TaskTypeDiskDrop,
 		proto.TaskTypeDiskRepair, proto.TaskTypeManualMigrate,
+		proto.TaskTypeVolumeConvert,
 	}
 	// acquire task
 	task, err := cli.AcquireTask(ctx, &api.AcquireArgs{IDC: idc})
@@ -232,6 +252,11 @@ func TestServiceAPI(t *testing.T) {
 				client.GenMigrateTaskPrefix(proto.TaskTypeManualMigrate) + "2",
 				client.GenMigrateTaskPrefix(proto.TaskTypeManualMigrate) + "3",
 			},
+			proto.TaskTypeVolumeConvert: {
+				client.GenMigrateTaskPrefix(proto.TaskTypeVolumeConvert) + "1",
+				client.GenMigrateTaskPrefix(proto.TaskTypeVolumeConvert) + "2",
+				client.GenMigrateTaskPrefix(proto.TaskTypeVolumeConvert) + "3",
+			},
 		},
 	})
 	require.NoError(t, err)
@@ -242,6 +267,12 @@ func TestServiceAPI(t *testing.T) {
 	err = cli.AddManualMigrateTask(ctx, &api.AddManualMigrateArgs{Vuid: proto.Vuid(24726512599042)})
 	require.NoError(t, err)
 
+	// add volume convert task
+	err = cli.AddVolumeConvertTask(ctx, &api.AddVolumeConvertArgs{Vid: volumeID})
+	require.Equal(t, 400, rpc.DetectStatusCode(err))
+	err = cli.AddVolumeConvertTask(ctx, &api.AddVolumeConvertArgs{Vid: volumeID, CodeMode: codemode.EC6P6})
+	require.NoError(t, err)
+
 	// acquire inspect task
 	_, err = cli.AcquireInspectTask(ctx)
 	require.NoError(t, err)
@@ -85,6 +85,13 @@ func (cfg *ShardRepairConfig) failedProducerConfig() *kafka.ProducerCfg {
 	}
 }
 
+func (cfg *ShardRepairConfig) newFailMsgSender() (base.IProducer, error) {
+	if cfg.Kafka.Embedded != nil {
+		return cfg.Kafka.Embedded.NewMsgSender(cfg.Kafka.TopicFailed), nil
+	}
+	return base.NewMsgSender(cfg.failedProducerConfig())
+}
+
 // OrphanShard orphan shard identification.
 type OrphanShard struct {
 	ClusterID proto.ClusterID `json:"cluster_id"`
@@ -136,7 +143,7 @@ func NewShardRepairMgr(
 	workerSelector := selector.MakeSelector(60*1000, func() (hosts []string, err error) {
 		return clusterMgrCli.GetService(context.Background(), proto.ServiceNameBlobNode, cfg.ClusterID)
 	})
-	failMsgSender, err := base.NewMsgSender(cfg.failedProducerConfig())
+	failMsgSender, err := cfg.newFailMsgSender()
 	if err != nil {
 		return nil, err
 	}
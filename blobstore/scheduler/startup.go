@@ -21,9 +21,11 @@ import (
 	"net/http"
 	httpproxy "net/http/httputil"
 	"net/url"
+	"os"
 	"time"
 
 	cmapi "github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/api/proxy"
 	api "github.com/cubefs/cubefs/blobstore/api/scheduler"
 	"github.com/cubefs/cubefs/blobstore/cmd"
 	"github.com/cubefs/cubefs/blobstore/common/config"
@@ -119,7 +121,15 @@ func NewService(conf *Config) (svr *Service, err error) {
 	}
 	topologyMgr := NewClusterTopologyMgr(clusterMgrCli, topoConf)
 
-	kafkaClient := base.NewKafkaConsumer(conf.Kafka.BrokerList)
+	var kafkaClient base.KafkaConsumer
+	if conf.EmbeddedMQ.Enable {
+		embeddedMQ := newEmbeddedMQ(conf, clusterMgrCli)
+		conf.ShardRepair.Kafka.Embedded = embeddedMQ
+		conf.BlobDelete.Kafka.Embedded = embeddedMQ
+		kafkaClient = embeddedMQ
+	} else {
+		kafkaClient = base.NewKafkaConsumer(conf.Kafka.BrokerList)
+	}
 	shardRepairMgr, err := NewShardRepairMgr(&conf.ShardRepair, topologyMgr, switchMgr, blobnodeCli, clusterMgrCli, kafkaClient)
 	if err != nil {
 		log.Errorf("new shard repair mgr: cfg[%+v], err[%w]", conf.ShardRepair, err)
@@ -150,10 +160,12 @@ func NewService(conf *Config) (svr *Service, err error) {
 		return
 	}
 
-	err = svr.NewKafkaMonitor(conf.ClusterID)
-	if err != nil {
-		log.Errorf("run kafka monitor failed: err[%w]", err)
-		return nil, err
+	if !conf.EmbeddedMQ.Enable {
+		err = svr.NewKafkaMonitor(conf.ClusterID)
+		if err != nil {
+			log.Errorf("run kafka monitor failed: err[%w]", err)
+			return nil, err
+		}
 	}
 
 	// all migrate manager
@@ -184,6 +196,8 @@ func NewService(conf *Config) (svr *Service, err error) {
 
 	manualMigMgr := NewManualMigrateMgr(clusterMgrCli, volumeUpdater, taskLogger, &conf.ManualMigrate)
 
+	convertMgr := NewVolumeConvertMgr(clusterMgrCli, volumeUpdater, taskLogger, &conf.VolumeConvert)
+
 	mqProxy := client.NewProxyClient(&conf.Proxy, cmapi.New(&conf.ClusterMgr), conf.ClusterID)
 	inspectorTaskSwitch, err := switchMgr.AddSwitch(proto.TaskTypeVolumeInspect.String())
 	if err != nil {
@@ -194,6 +208,7 @@ func NewService(conf *Config) (svr *Service, err error) {
 	svr.balanceMgr = balanceMgr
 	svr.diskDropMgr = diskDropMgr
 	svr.manualMigMgr = manualMigMgr
+	svr.convertMgr = convertMgr
 	svr.diskRepairMgr = diskRepairMgr
 	svr.inspectMgr = inspectMgr
 
@@ -228,6 +243,9 @@ func (svr *Service) load() (err error) {
 	if err = svr.manualMigMgr.Load(); err != nil {
 		return
 	}
+	if err = svr.convertMgr.Load(); err != nil {
+		return
+	}
 
 	return
 }
@@ -251,9 +269,23 @@ func (svr *Service) Run() {
 	svr.balanceMgr.Run()
 	svr.diskDropMgr.Run()
 	svr.manualMigMgr.Run()
+	svr.convertMgr.Run()
 	svr.inspectMgr.Run()
 }
 
+// newEmbeddedMQ returns the queue embedded in all proxies of cluster,
+// the registered host of scheduler is used as consumer id
+func newEmbeddedMQ(conf *Config, clusterMgrCli client.ClusterMgrAPI) *base.EmbeddedMQ {
+	consumer := conf.ServiceRegister.Host
+	if consumer == "" {
+		hostname, _ := os.Hostname()
+		consumer = hostname + conf.BindAddr
+	}
+	return base.NewEmbeddedMQ(proxy.NewMQClient(&conf.Proxy.Config), func(ctx context.Context) ([]string, error) {
+		return clusterMgrCli.GetService(ctx, proto.ServiceNameProxy, conf.ClusterID)
+	}, consumer)
+}
+
 // RunTask run shard repair and blob delete tasks
 func (svr *Service) RunTask() error {
 	if err := svr.LoadVolInfo(); err != nil {
@@ -350,6 +382,7 @@ func (svr *Service) Close() {
 	svr.diskRepairMgr.Close()
 	svr.diskDropMgr.Close()
 	svr.manualMigMgr.Close()
+	svr.convertMgr.Close()
 	svr.inspectMgr.Close()
 }
 
@@ -365,6 +398,7 @@ func NewHandler(service *Service) *rpc.Router {
 	rpc.POST(api.PathTaskCancel, service.HTTPTaskCancel, rpc.OptArgsBody())
 	rpc.POST(api.PathTaskComplete, service.HTTPTaskComplete, rpc.OptArgsBody())
 	rpc.POST(api.PathManualMigrateTaskAdd, service.HTTPManualMigrateTaskAdd, rpc.OptArgsBody())
+	rpc.POST(api.PathVolumeConvertTaskAdd, service.HTTPVolumeConvertTaskAdd, rpc.OptArgsBody())
 
 	rpc.GET(api.PathInspectAcquire, service.HTTPInspectAcquire)
 	rpc.POST(api.PathInspectComplete, service.HTTPInspectComplete, rpc.OptArgsBody())
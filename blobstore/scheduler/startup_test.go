@@ -99,6 +99,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskDropMgr := NewMockMigrater(ctr)
 	diskRepairMgr := NewMockMigrater(ctr)
 	manualMgr := NewMockMigrater(ctr)
+	convertMgr := NewMockMigrater(ctr)
 	balanceMgr := NewMockMigrater(ctr)
 	inspecterMgr := NewMockVolumeInspector(ctr)
 	clusterTopology := NewMockClusterTopology(ctr)
@@ -108,6 +109,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr.EXPECT().Close().AnyTimes().Return()
 	diskDropMgr.EXPECT().Close().AnyTimes().Return()
 	manualMgr.EXPECT().Close().AnyTimes().Return()
+	convertMgr.EXPECT().Close().AnyTimes().Return()
 	inspecterMgr.EXPECT().Close().AnyTimes().Return()
 
 	balanceMgr.EXPECT().Run().AnyTimes().Return()
@@ -115,6 +117,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr.EXPECT().Run().AnyTimes().Return()
 	inspecterMgr.EXPECT().Run().AnyTimes().Return()
 	manualMgr.EXPECT().Run().AnyTimes().Return()
+	convertMgr.EXPECT().Run().AnyTimes().Return()
 
 	clusterTopology.EXPECT().LoadVolumes().AnyTimes().Return(nil)
 	shardRepairMgr.EXPECT().Run().AnyTimes().Return()
@@ -126,6 +129,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr.EXPECT().Load().AnyTimes().Return(nil)
 	diskDropMgr.EXPECT().Load().AnyTimes().Return(nil)
 	manualMgr.EXPECT().Load().AnyTimes().Return(nil)
+	convertMgr.EXPECT().Load().AnyTimes().Return(nil)
 
 	blobDeleteMgr.EXPECT().GetErrorStats().AnyTimes().Return([]string{}, uint64(0))
 	blobDeleteMgr.EXPECT().GetTaskStats().AnyTimes().Return([counter.SLOT]int{}, [counter.SLOT]int{})
@@ -142,6 +146,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	balanceMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
 	balanceMgr.EXPECT().Enabled().AnyTimes().Return(true)
 	manualMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
+	convertMgr.EXPECT().Stats().AnyTimes().Return(api.MigrateTasksStat{})
 	inspecterMgr.EXPECT().GetTaskStats().AnyTimes().Return([counter.SLOT]int{}, [counter.SLOT]int{})
 	inspecterMgr.EXPECT().Enabled().AnyTimes().Return(true)
 
@@ -152,6 +157,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 	diskRepairMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 	diskDropMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 	balanceMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
+	convertMgr.EXPECT().AcquireTask(any, any).AnyTimes().Return(proto.MigrateTask{}, errMock)
 
 	clusterTopology.EXPECT().UpdateVolume(any).AnyTimes().Return(&client.VolumeInfoSimple{}, nil)
 	clusterMgrCli.EXPECT().GetConfig(any, any).AnyTimes().Return("", errMock)
@@ -162,6 +168,7 @@ func newMockServiceWithOpts(ctr *gomock.Controller, isLeader bool) *Service {
 		balanceMgr:      balanceMgr,
 		diskDropMgr:     diskDropMgr,
 		manualMigMgr:    manualMgr,
+		convertMgr:      convertMgr,
 		diskRepairMgr:   diskRepairMgr,
 		inspectMgr:      inspecterMgr,
 		shardRepairMgr:  shardRepairMgr,
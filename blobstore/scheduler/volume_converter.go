@@ -0,0 +1,200 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/recordlog"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/taskswitch"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/scheduler/base"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+)
+
+// IVolumeConverter interface of volume converter
+type IVolumeConverter interface {
+	Migrator
+	AddConvertTask(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) (err error)
+}
+
+// VolumeConvertMgr volume convert manager, re-encode all blobs of a sealed volume into another code mode
+type VolumeConvertMgr struct {
+	IMigrator
+
+	clusterMgrCli client.ClusterMgrAPI
+}
+
+// NewVolumeConvertMgr returns volume convert manager
+func NewVolumeConvertMgr(clusterMgrCli client.ClusterMgrAPI, volumeUpdater client.IVolumeUpdater,
+	taskLogger recordlog.Encoder, conf *MigrateConfig) *VolumeConvertMgr {
+	mgr := &VolumeConvertMgr{
+		clusterMgrCli: clusterMgrCli,
+	}
+	mgr.IMigrator = NewMigrateMgr(clusterMgrCli, volumeUpdater, taskswitch.NewEnabledTaskSwitch(), taskLogger,
+		conf, proto.TaskTypeVolumeConvert)
+	return mgr
+}
+
+// AddConvertTask add volume convert task
+func (mgr *VolumeConvertMgr) AddConvertTask(ctx context.Context, vid proto.Vid, mode codemode.CodeMode) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	volume, err := mgr.clusterMgrCli.GetVolumeInfo(ctx, vid)
+	if err != nil {
+		span.Errorf("get volume failed: vid[%d], err[%+v]", vid, err)
+		return err
+	}
+	if volume.CodeMode == mode {
+		span.Warnf("volume is already in code mode: vid[%d], code_mode[%s]", vid, mode.String())
+		return errcode.ErrIllegalArguments
+	}
+	diskID := volume.VunitLocations[0].DiskID
+	disk, err := mgr.clusterMgrCli.GetDiskInfo(ctx, diskID)
+	if err != nil {
+		span.Errorf("get disk info failed: disk_id[%d], err[%+v]", diskID, err)
+		return err
+	}
+
+	task := &proto.MigrateTask{
+		TaskID:       client.GenMigrateTaskID(proto.TaskTypeVolumeConvert, disk.DiskID, vid),
+		TaskType:     proto.TaskTypeVolumeConvert,
+		State:        proto.MigrateStateInited,
+		SourceIDC:    disk.Idc,
+		SourceDiskID: disk.DiskID,
+		SourceVuid:   volume.VunitLocations[0].Vuid,
+		CodeMode:     volume.CodeMode,
+		DestCodeMode: mode,
+	}
+	mgr.IMigrator.AddTask(ctx, task)
+
+	span.Debugf("add volume convert task success: task_info[%+v]", task)
+	return nil
+}
+
+// prepareConvertTask alloc all units of the destination code mode for the locked volume
+func (mgr *MigrateMgr) prepareConvertTask(ctx context.Context, task *proto.MigrateTask, volInfo *client.VolumeInfoSimple) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	// check necessity of generating current task
+	if volInfo.CodeMode != task.CodeMode {
+		span.Infof("the volume code mode has been changed and finish task immediately: task_id[%s], task code_mode[%s], current code_mode[%s]",
+			task.TaskID, task.CodeMode.String(), volInfo.CodeMode.String())
+
+		err = mgr.clusterMgrCli.UnlockVolume(ctx, task.Vid())
+		if err != nil {
+			span.Errorf("before finish in advance try unlock volume failed: vid[%d], err[%+v]", task.Vid(), err)
+			return err
+		}
+
+		mgr.finishTaskInAdvance(ctx, task, "volume has converted")
+		return nil
+	}
+
+	// lock volume
+	err = mgr.clusterMgrCli.LockVolume(ctx, task.Vid())
+	if err != nil {
+		if rpc.DetectStatusCode(err) == errcode.CodeLockNotAllow {
+			return mgr.lockVolFailHandleFunc(ctx, task)
+		}
+		span.Errorf("lock volume failed: volume_id[%v], err[%+v]", task.Vid(), err)
+		return err
+	}
+
+	units, err := mgr.clusterMgrCli.AllocConvertVolumeUnits(ctx, task.Vid(), task.DestCodeMode)
+	if err != nil {
+		span.Errorf("alloc convert volume units failed: err[%+v]", err)
+		return err
+	}
+
+	task.Sources = volInfo.VunitLocations
+	task.Destinations = units
+	task.SetDestination(units[0])
+	task.State = proto.MigrateStatePrepared
+
+	// update db
+	base.InsistOn(ctx, "convert prepare task update task tbl", func() error {
+		return mgr.clusterMgrCli.UpdateMigrateTask(ctx, task)
+	})
+
+	// send task to worker queue and remove task in prepareQueue
+	mgr.workQueue.AddPreparedTask(task.SourceIDC, task.TaskID, task)
+	_ = mgr.prepareQueue.RemoveTask(task.TaskID)
+
+	span.Infof("prepare convert task success: task_id[%s], state[%v]", task.TaskID, task.State)
+	return nil
+}
+
+// finishConvertTask switch the volume into destination code mode and release all old units
+func (mgr *MigrateMgr) finishConvertTask(ctx context.Context, task *proto.MigrateTask) (err error) {
+	span := trace.SpanFromContextSafe(ctx)
+
+	err = mgr.clusterMgrCli.ConvertVolume(ctx, task.Vid(), task.DestCodeMode, task.Destinations)
+	if err != nil {
+		span.Errorf("convert volume failed: vid[%d], code_mode[%s], err[%+v]", task.Vid(), task.DestCodeMode.String(), err)
+		if base.ShouldAllocAndRedo(rpc.DetectStatusCode(err)) {
+			return mgr.reallocConvertTask(ctx, task)
+		}
+		return err
+	}
+
+	// the code mode and all units of volume have been changed, all scheduler need to update the volume cache
+	if err = mgr.updateVolumeCache(ctx, task); err != nil {
+		return base.ErrUpdateVolumeCache
+	}
+
+	for _, src := range task.Sources {
+		err = mgr.clusterMgrCli.ReleaseVolumeUnit(ctx, src.Vuid, src.DiskID)
+		if err != nil {
+			// the released unit or broken disk need ignore it, other error need to retry
+			httpCode := rpc.DetectStatusCode(err)
+			if httpCode != errcode.CodeVuidNotFound && httpCode != errcode.CodeDiskBroken {
+				span.Errorf("release volume unit failed: vuid[%d], err[%+v]", src.Vuid, err)
+				return err
+			}
+			span.Warnf("release volume unit ignored: vuid[%d], err[%+v]", src.Vuid, err)
+		}
+	}
+
+	return mgr.unlockAndFinishTask(ctx, task)
+}
+
+func (mgr *MigrateMgr) reallocConvertTask(ctx context.Context, task *proto.MigrateTask) error {
+	span := trace.SpanFromContextSafe(ctx)
+	span.Infof("realloc convert units and redo: task_id[%s]", task.TaskID)
+
+	units, err := mgr.clusterMgrCli.AllocConvertVolumeUnits(ctx, task.Vid(), task.DestCodeMode)
+	if err != nil {
+		span.Errorf("realloc convert volume units failed: vid[%d], err[%+v]", task.Vid(), err)
+		return err
+	}
+	task.Destinations = units
+	task.SetDestination(units[0])
+	task.State = proto.MigrateStatePrepared
+	task.WorkerRedoCnt++
+
+	base.InsistOn(ctx, "convert redo task update task tbl", func() error {
+		return mgr.clusterMgrCli.UpdateMigrateTask(ctx, task)
+	})
+
+	_ = mgr.finishQueue.RemoveTask(task.TaskID)
+	mgr.workQueue.AddPreparedTask(task.SourceIDC, task.TaskID, task)
+	span.Infof("task %+v redo again", task)
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
+	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/scheduler/client"
+	"github.com/cubefs/cubefs/blobstore/testing/mocks"
+)
+
+func newVolumeConverter(t *testing.T) *VolumeConvertMgr {
+	ctr := gomock.NewController(t)
+	clusterMgr := NewMockClusterMgrAPI(ctr)
+	volumeUpdater := NewMockVolumeUpdater(ctr)
+	taskLogger := mocks.NewMockRecordLogEncoder(ctr)
+	migrater := NewMockMigrater(ctr)
+	mgr := NewVolumeConvertMgr(clusterMgr, volumeUpdater, taskLogger, &MigrateConfig{ClusterID: 1})
+	mgr.IMigrator = migrater
+	return mgr
+}
+
+func mockConvertUnits(vid proto.Vid, mode codemode.CodeMode) []proto.VunitLocation {
+	return MockGenVolInfo(vid, mode, proto.VolumeStatusLock).VunitLocations
+}
+
+func mockGenConvertTask(vid proto.Vid, state proto.MigrateState, mode codemode.CodeMode) *proto.MigrateTask {
+	task := mockGenMigrateTask(proto.TaskTypeVolumeConvert, "z0", 4, vid, state, MockMigrateVolInfoMap)
+	task.DestCodeMode = mode
+	task.Destinations = mockConvertUnits(vid, mode)
+	return task
+}
+
+func TestVolumeConvertAddTask(t *testing.T) {
+	ctx := context.Background()
+	{
+		mgr := newVolumeConverter(t)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(nil, errMock)
+		err := mgr.AddConvertTask(ctx, proto.Vid(1), codemode.EC6P10L2)
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		mgr := newVolumeConverter(t)
+		volume := MockGenVolInfo(10001, codemode.EC6P6, proto.VolumeStatusIdle)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		err := mgr.AddConvertTask(ctx, proto.Vid(10001), codemode.EC6P6)
+		require.True(t, errors.Is(err, errcode.ErrIllegalArguments))
+	}
+	{
+		mgr := newVolumeConverter(t)
+		volume := MockGenVolInfo(10001, codemode.EC6P6, proto.VolumeStatusIdle)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetDiskInfo(any, any).Return(nil, errMock)
+		err := mgr.AddConvertTask(ctx, proto.Vid(10001), codemode.EC6P10L2)
+		require.True(t, errors.Is(err, errMock))
+	}
+	{
+		mgr := newVolumeConverter(t)
+		volume := MockGenVolInfo(10001, codemode.EC6P6, proto.VolumeStatusIdle)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetDiskInfo(any, any).Return(&client.DiskInfoSimple{Idc: "z0"}, nil)
+		mgr.IMigrator.(*MockMigrater).EXPECT().AddTask(any, any).DoAndReturn(func(_ context.Context, task *proto.MigrateTask) {
+			require.Equal(t, proto.TaskTypeVolumeConvert, task.TaskType)
+			require.Equal(t, codemode.EC6P6, task.CodeMode)
+			require.Equal(t, codemode.EC6P10L2, task.DestCodeMode)
+			require.Equal(t, volume.VunitLocations[0].Vuid, task.SourceVuid)
+		})
+		err := mgr.AddConvertTask(ctx, proto.Vid(10001), codemode.EC6P10L2)
+		require.NoError(t, err)
+	}
+}
+
+func TestPrepareConvertTask(t *testing.T) {
+	ctx := context.Background()
+	{
+		// volume has been converted and finish in advance
+		mgr := newMigrateMgr(t)
+		t1 := mockGenConvertTask(100, proto.MigrateStateInited, codemode.EC6P10L2)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddMigrateTask(any, any).Return(nil)
+		mgr.AddTask(ctx, t1)
+
+		volume := MockGenVolInfo(100, codemode.EC6P10L2, proto.VolumeStatusLock)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UnlockVolume(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+		mgr.taskLogger.(*mocks.MockRecordLogEncoder).EXPECT().Encode(any).Return(nil)
+		err := mgr.prepareTask()
+		require.NoError(t, err)
+		_, exist := mgr.prepareQueue.Query(t1.TaskID)
+		require.False(t, exist)
+	}
+	{
+		mgr := newMigrateMgr(t)
+		t1 := mockGenConvertTask(100, proto.MigrateStateInited, codemode.EC6P10L2)
+		t1.Destinations = nil
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AddMigrateTask(any, any).Return(nil)
+		mgr.AddTask(ctx, t1)
+
+		volume := MockMigrateVolInfoMap[100]
+		// lock volume not allow
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().LockVolume(any, any).Return(errMock)
+		err := mgr.prepareTask()
+		require.True(t, errors.Is(err, errMock))
+
+		// alloc convert units failed
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().LockVolume(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocConvertVolumeUnits(any, any, any).Return(nil, errMock)
+		err = mgr.prepareTask()
+		require.True(t, errors.Is(err, errMock))
+
+		// prepare success
+		units := mockConvertUnits(100, codemode.EC6P10L2)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().GetVolumeInfo(any, any).Return(volume, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().LockVolume(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocConvertVolumeUnits(any, any, codemode.EC6P10L2).Return(units, nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		err = mgr.prepareTask()
+		require.NoError(t, err)
+
+		task, err := mgr.workQueue.Query(t1.SourceIDC, t1.TaskID)
+		require.NoError(t, err)
+		convertTask := task.(*proto.MigrateTask)
+		require.Equal(t, proto.MigrateStatePrepared, convertTask.State)
+		require.Equal(t, units, convertTask.Destinations)
+		require.Equal(t, volume.VunitLocations, convertTask.Sources)
+	}
+}
+
+func TestFinishConvertTask(t *testing.T) {
+	{
+		// convert failed and realloc units to redo
+		mgr := newMigrateMgr(t)
+		t1 := mockGenConvertTask(100, proto.MigrateStateWorkCompleted, codemode.EC6P10L2)
+		mgr.finishQueue.PushTask(t1.TaskID, t1)
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(errMock)
+		err := mgr.finishTask()
+		require.True(t, errors.Is(err, errMock))
+
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(errcode.ErrNewVuidNotMatch)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocConvertVolumeUnits(any, any, any).Return(nil, errMock)
+		err = mgr.finishTask()
+		require.True(t, errors.Is(err, errMock))
+
+		units := mockConvertUnits(100, codemode.EC6P10L2)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Times(2).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(errcode.ErrNewVuidNotMatch)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().AllocConvertVolumeUnits(any, any, any).Return(units, nil)
+		err = mgr.finishTask()
+		require.NoError(t, err)
+
+		task, err := mgr.workQueue.Query(t1.SourceIDC, t1.TaskID)
+		require.NoError(t, err)
+		require.Equal(t, proto.MigrateStatePrepared, task.(*proto.MigrateTask).State)
+		require.Equal(t, uint8(1), task.(*proto.MigrateTask).WorkerRedoCnt)
+	}
+	{
+		// convert success
+		mgr := newMigrateMgr(t)
+		t1 := mockGenConvertTask(100, proto.MigrateStateWorkCompleted, codemode.EC6P10L2)
+		mgr.finishQueue.PushTask(t1.TaskID, t1)
+
+		// update volume cache failed
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(nil)
+		mgr.volumeUpdater.(*MockVolumeUpdater).EXPECT().UpdateLeaderVolumeCache(any, any).Return(errMock)
+		err := mgr.finishTask()
+		require.Error(t, err)
+
+		// release unit failed
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(nil)
+		mgr.volumeUpdater.(*MockVolumeUpdater).EXPECT().UpdateLeaderVolumeCache(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ReleaseVolumeUnit(any, any, any).Return(errMock)
+		err = mgr.finishTask()
+		require.True(t, errors.Is(err, errMock))
+
+		// released units are ignored
+		sourceCnt := len(t1.Sources)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UpdateMigrateTask(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ConvertVolume(any, any, any, any).Return(nil)
+		mgr.volumeUpdater.(*MockVolumeUpdater).EXPECT().UpdateLeaderVolumeCache(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ReleaseVolumeUnit(any, any, any).Return(errcode.ErrNoSuchVuid)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().ReleaseVolumeUnit(any, any, any).Times(sourceCnt - 1).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().UnlockVolume(any, any).Return(nil)
+		mgr.clusterMgrCli.(*MockClusterMgrAPI).EXPECT().DeleteMigrateTask(any, any).Return(nil)
+		mgr.taskLogger.(*mocks.MockRecordLogEncoder).EXPECT().Encode(any).Return(nil)
+		err = mgr.finishTask()
+		require.NoError(t, err)
+	}
+}
@@ -80,6 +80,20 @@ func (mr *MockISchedulerMockRecorder) AddManualMigrateTask(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddManualMigrateTask", reflect.TypeOf((*MockIScheduler)(nil).AddManualMigrateTask), arg0, arg1)
 }
 
+// AddVolumeConvertTask mocks base method.
+func (m *MockIScheduler) AddVolumeConvertTask(arg0 context.Context, arg1 *scheduler.AddVolumeConvertArgs) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVolumeConvertTask", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddVolumeConvertTask indicates an expected call of AddVolumeConvertTask.
+func (mr *MockISchedulerMockRecorder) AddVolumeConvertTask(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVolumeConvertTask", reflect.TypeOf((*MockIScheduler)(nil).AddVolumeConvertTask), arg0, arg1)
+}
+
 // CancelTask mocks base method.
 func (m *MockIScheduler) CancelTask(arg0 context.Context, arg1 *scheduler.OperateTaskArgs) error {
 	m.ctrl.T.Helper()
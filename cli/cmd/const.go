@@ -43,6 +43,10 @@ const (
 	CliOpShrink                  = "shrink"
 	CliOpGetDiscard              = "get-discard"
 	CliOpSetDiscard              = "set-discard"
+	CliOpSetRack                 = "set-rack"
+	CliOpBalance                 = "balance"
+	CliOpPlan                    = "plan"
+	CliOpScrub                   = "scrub"
 	CliOpForbidMpDecommission    = "forbid-mp-decommission"
 
 	// Shorthand format of operation name
@@ -87,6 +91,7 @@ const (
 	CliFlagCacheLRUInterval    = "cache-lru-interval"
 	CliFlagCacheRule           = "cache-rule"
 	CliFlagThreshold           = "threshold"
+	CliFlagMaxMigrations       = "max-migrations"
 	CliFlagAddress             = "addr"
 	CliFlagDiskPath            = "path"
 	CliFlagAuthKey             = "authkey"
@@ -118,7 +123,15 @@ const (
 	CliFlagMaxBytes            = "maxBytes"
 	CliFlagMaxConcurrencyInode = "maxConcurrencyInode"
 	CliFlagForceInode          = "forceInode"
+	CliFlagHardFiles           = "hardFiles"
+	CliFlagHardBytes           = "hardBytes"
+	CliFlagSoftFiles           = "softFiles"
+	CliFlagSoftBytes           = "softBytes"
+	CliFlagGracePeriod         = "gracePeriod"
+	CliFlagOwnerType           = "type"
 	CliFlagEnableQuota         = "enableQuota"
+	CliFlagMetaStoreMode       = "metaStoreMode"
+	CliFlagCompressCodec       = "compressCodec"
 	CliFlagDeleteLockTime      = "delete-lock-time"
 	CliFlagClientIDKey         = "clientIDKey"
 
@@ -133,10 +146,12 @@ const (
 	// Usages
 	CliUsageClientIDKey = "needed if cluster authentication is on"
 	// version op
-	CliFlagVersionCreate      = "verCreate"
-	CliFlagVersionList        = "verList"
-	CliFlagVersionDel         = "verDel"
-	CliFlagVersionSetStrategy = "verSetStrategy"
+	CliFlagVersionCreate       = "verCreate"
+	CliFlagVersionList         = "verList"
+	CliFlagVersionDel          = "verDel"
+	CliFlagVersionSetStrategy  = "verSetStrategy"
+	CliFlagVersionSetRetention = "verSetRetention"
+	CliFlagVersionGetRetention = "verGetRetention"
 )
 
 type MasterOp int
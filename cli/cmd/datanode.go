@@ -15,6 +15,7 @@
 package cmd
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -38,6 +39,9 @@ func newDataNodeCmd(client *master.MasterClient) *cobra.Command {
 		newDataNodeInfoCmd(client),
 		newDataNodeDecommissionCmd(client),
 		newDataNodeMigrateCmd(client),
+		newDataNodeSetRackCmd(client),
+		newDataNodeBalanceCmd(client),
+		newDataNodeScrubCmd(client),
 	)
 	return cmd
 }
@@ -46,6 +50,11 @@ const (
 	cmdDataNodeListShort             = "List information of data nodes"
 	cmdDataNodeInfoShort             = "Show information of a data node"
 	cmdDataNodeDecommissionInfoShort = "decommission partitions in a data node to others"
+	cmdDataNodeSetRackShort          = "Set the rack of a data node, the rack is cleared if RACK is omitted"
+	cmdDataNodeBalanceShort          = "Manage the data partition balancer which moves data partitions from the most used data nodes to the least used ones"
+	cmdDataNodeBalanceSetShort       = "Set the config of the data partition balancer"
+	cmdDataNodeBalancePlanShort      = "Show the migrations planned by the data partition balancer without starting them"
+	cmdDataNodeScrubShort            = "Show the progress of the data scrubber and the corrupt extents found on a data node"
 )
 
 func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -171,3 +180,139 @@ func newDataNodeMigrateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newDataNodeSetRackCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpSetRack + " [{HOST}:{PORT}] [RACK]",
+		Short: cmdDataNodeSetRackShort,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var rackName string
+			if len(args) > 1 {
+				rackName = args[1]
+			}
+			if err := client.NodeAPI().SetNodeRack(args[0], rackName); err != nil {
+				return err
+			}
+			stdoutf("Set rack of data node %v to [%v] successfully\n", args[0], rackName)
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newDataNodeBalanceCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpBalance,
+		Short: cmdDataNodeBalanceShort,
+	}
+	cmd.AddCommand(
+		newDataNodeBalanceSetCmd(client),
+		newDataNodeBalancePlanCmd(client),
+	)
+	return cmd
+}
+
+func newDataNodeBalanceSetCmd(client *master.MasterClient) *cobra.Command {
+	var optEnable, optThreshold, optMaxMigrations string
+	cmd := &cobra.Command{
+		Use:   CliOpSet,
+		Short: cmdDataNodeBalanceSetShort,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if optEnable == "" && optThreshold == "" && optMaxMigrations == "" {
+				return fmt.Errorf("at least one of --%v, --%v and --%v should be set",
+					CliFlagEnable, CliFlagThreshold, CliFlagMaxMigrations)
+			}
+			if err := client.AdminAPI().SetDpBalance(optEnable, optThreshold, optMaxMigrations); err != nil {
+				return err
+			}
+			stdoutln("Set data partition balancer successfully")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&optEnable, CliFlagEnable, "", "Enable the data partition balancer [true|false]")
+	cmd.Flags().StringVar(&optThreshold, CliFlagThreshold, "",
+		"The data nodes whose usage ratio exceeds the average of the node set by the threshold are balanced, in (0, 1)")
+	cmd.Flags().StringVar(&optMaxMigrations, CliFlagMaxMigrations, "",
+		"The max count of the data partitions migrated by the balancer at the same time")
+	return cmd
+}
+
+func newDataNodeBalancePlanCmd(client *master.MasterClient) *cobra.Command {
+	var optCount int
+	cmd := &cobra.Command{
+		Use:   CliOpPlan,
+		Short: cmdDataNodeBalancePlanShort,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if optCount < 0 {
+				return fmt.Errorf("--%v should >= 0", CliFlagCount)
+			}
+			plan, err := client.AdminAPI().GetDpBalancePlan(optCount)
+			if err != nil {
+				return err
+			}
+			stdoutln("[Data partition balancer]")
+			stdoutln(formatDpBalanceConfig(&plan.Config))
+			stdoutln()
+			stdoutln("[Running migrations]")
+			stdoutln(formatDpBalanceMigrationTableHeader())
+			for _, m := range plan.Running {
+				stdoutln(formatDpBalanceMigrationTableRow(m))
+			}
+			stdoutln()
+			stdoutln("[Planned migrations]")
+			stdoutln(formatDpBalanceMigrationTableHeader())
+			for _, m := range plan.Migrations {
+				stdoutln(formatDpBalanceMigrationTableRow(m))
+			}
+			stdoutln()
+			stdoutln("[Data nodes]")
+			stdoutln(formatDpBalanceNodeTableHeader())
+			for _, n := range plan.Nodes {
+				stdoutln(formatDpBalanceNodeTableRow(n))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&optCount, CliFlagCount, 0, "The max count of the planned migrations, the max migrations of the balancer by default")
+	return cmd
+}
+
+func newDataNodeScrubCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpScrub + " [{HOST}:{PORT}]",
+		Short: cmdDataNodeScrubShort,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			datanodeInfo, err := client.NodeAPI().GetDataNode(args[0])
+			if err != nil {
+				return err
+			}
+			stdoutln("[Disks]")
+			stdoutln(formatDiskScrubStatTableHeader())
+			for i := range datanodeInfo.ScrubStats {
+				stdoutln(formatDiskScrubStatTableRow(&datanodeInfo.ScrubStats[i]))
+			}
+			stdoutln()
+			stdoutln("[Corrupt extents]")
+			stdoutln(formatScrubCorruptExtentTableHeader())
+			for _, ce := range datanodeInfo.CorruptExtents {
+				stdoutln(formatScrubCorruptExtentTableRow(ce))
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
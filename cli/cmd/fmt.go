@@ -167,7 +167,10 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Tx limit interval(s)            : %v\n", svv.TxOpLimit))
 	sb.WriteString(fmt.Sprintf("  Forbidden                       : %v\n", svv.Forbidden))
 	sb.WriteString(fmt.Sprintf("  EnableAuditLog                  : %v\n", svv.EnableAuditLog))
+	sb.WriteString(fmt.Sprintf("  EnableReplication               : %v\n", svv.EnableReplication))
 	sb.WriteString(fmt.Sprintf("  Quota                           : %v\n", formatEnabledDisabled(svv.EnableQuota)))
+	sb.WriteString(fmt.Sprintf("  MetaStoreMode                   : %v\n", svv.MetaStoreMode))
+	sb.WriteString(fmt.Sprintf("  CompressCodec                   : %v\n", formatCompressCodec(svv.CompressCodec)))
 	if svv.Forbidden && svv.Status == 1 {
 		sb.WriteString(fmt.Sprintf("  DeleteDelayTime                 : %v\n", time.Until(svv.DeleteExecTime)))
 	}
@@ -613,6 +616,13 @@ func formatEnabledDisabled(b bool) string {
 	return "Disabled"
 }
 
+func formatCompressCodec(codec string) string {
+	if codec == "" {
+		return "none"
+	}
+	return codec
+}
+
 func formatNodeStatus(status bool) string {
 	if status {
 		return "Active"
@@ -753,6 +763,7 @@ func formatDataNodeDetail(dn *proto.DataNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Available           : %v\n", formatSize(dn.AvailableSpace)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(dn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", dn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", dn.RackName))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(dn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(dn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", dn.DataPartitionCount))
@@ -785,6 +796,7 @@ func formatMetaNodeDetail(mn *proto.MetaNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Allocated           : %v\n", formatSize(mn.Used)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(mn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", mn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", mn.RackName))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(mn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(mn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", mn.MetaPartitionCount))
@@ -883,6 +895,48 @@ func formatQuotaInfo(info *proto.QuotaInfo) string {
 	return ret
 }
 
+// the report of user and group quota is in the layout of repquota,
+// the status shows whether bytes and files are over the soft limits
+var ownerQuotaReportRowPattern = "%-6v %-10v %-3v    %-12v %-12v %-12v %-10v    %-10v %-10v %-10v %-10v"
+
+func formatOwnerQuotaReportHeader() string {
+	return fmt.Sprintf(ownerQuotaReportRowPattern, "TYPE", "ID", "", "BYTESUSED", "BYTESSOFT", "BYTESHARD", "BYTESGRACE",
+		"FILESUSED", "FILESSOFT", "FILESHARD", "FILESGRACE")
+}
+
+func formatOwnerQuotaReportRow(info *proto.OwnerQuotaInfo, now int64) string {
+	status := []byte("--")
+	if info.BytesSoftTime != 0 {
+		status[0] = '+'
+	}
+	if info.FilesSoftTime != 0 {
+		status[1] = '+'
+	}
+	formatLimit := func(limit uint64, size bool) string {
+		if limit == 0 {
+			return "0"
+		}
+		if size {
+			return formatSize(limit)
+		}
+		return strconv.FormatUint(limit, 10)
+	}
+	formatGrace := func(softTime int64) string {
+		left, exceeded := info.GraceLeft(softTime, now)
+		if !exceeded {
+			return ""
+		}
+		if left <= 0 {
+			return "none"
+		}
+		return (time.Duration(left) * time.Second).String()
+	}
+	return fmt.Sprintf(ownerQuotaReportRowPattern, proto.OwnerQuotaTypeString(info.Type), info.Id, string(status),
+		formatSize(uint64(info.UsedInfo.UsedBytes)), formatLimit(info.SoftBytes, true), formatLimit(info.HardBytes, true),
+		formatGrace(info.BytesSoftTime), info.UsedInfo.UsedFiles, formatLimit(info.SoftFiles, false),
+		formatLimit(info.HardFiles, false), formatGrace(info.FilesSoftTime))
+}
+
 func formatBadDisks(disks []proto.DiskInfo) string {
 	if len(disks) == 0 {
 		return ""
@@ -963,3 +1017,65 @@ func formatDataPartitionDecommissionProgress(info *proto.DecommissionDataPartiti
 	sb.WriteString(fmt.Sprintf("ErrorMessage:      %v\n", info.ErrorMessage))
 	return sb.String()
 }
+
+func formatDpBalanceConfig(config *proto.DpBalanceConfig) string {
+	return alignColumn(
+		arow("Status", formatEnabledDisabled(config.Enable)),
+		arow("Threshold", config.Threshold),
+		arow("Max migrations", config.MaxMigrations),
+	)
+}
+
+var dpBalanceMigrationTablePattern = "%-12v    %-20v    %-12v    %-10v    %-22v    %-20v    %-22v"
+
+func formatDpBalanceMigrationTableHeader() string {
+	return fmt.Sprintf(dpBalanceMigrationTablePattern, "PARTITION ID", "VOLUME", "SIZE", "NODESET", "SOURCE", "SOURCE DISK", "TARGET")
+}
+
+func formatDpBalanceMigrationTableRow(m *proto.DpBalanceMigration) string {
+	return fmt.Sprintf(dpBalanceMigrationTablePattern, m.PartitionID, m.VolName, formatSize(m.Size),
+		m.NodeSetID, m.SrcAddr, m.SrcDisk, m.DstAddr)
+}
+
+var dpBalanceNodeTablePattern = "%-22v    %-10v    %-10v    %-12v    %-12v    %-8v    %-8v"
+
+func formatDpBalanceNodeTableHeader() string {
+	return fmt.Sprintf(dpBalanceNodeTablePattern, "ADDRESS", "ZONE", "NODESET", "TOTAL", "USED", "USAGE", "PLANNED")
+}
+
+func formatDpBalanceNodeTableRow(n *proto.DpBalanceNodeStat) string {
+	return fmt.Sprintf(dpBalanceNodeTablePattern, n.Addr, n.ZoneName, n.NodeSetID, formatSize(n.Total), formatSize(n.Used),
+		fmt.Sprintf("%.2f%%", n.UsageRatio*100), fmt.Sprintf("%.2f%%", n.PlannedUsageRatio*100))
+}
+
+var diskScrubStatTablePattern = "%-20v    %-8v    %-6v    %-12v    %-10v    %-12v    %-8v    %-8v    %-8v    %-20v    %-20v"
+
+func formatDiskScrubStatTableHeader() string {
+	return fmt.Sprintf(diskScrubStatTablePattern, "DISK", "RUNNING", "ROUND", "PARTITIONS", "EXTENTS", "SCANNED",
+		"CORRUPT", "REPAIRED", "MISMATCH", "ROUND START", "LAST FINISH")
+}
+
+func formatDiskScrubStatTableRow(stat *proto.DiskScrubStat) string {
+	lastFinish := "-"
+	if stat.LastFinishTime > 0 {
+		lastFinish = formatTime(stat.LastFinishTime)
+	}
+	roundStart := "-"
+	if stat.RoundStartTime > 0 {
+		roundStart = formatTime(stat.RoundStartTime)
+	}
+	return fmt.Sprintf(diskScrubStatTablePattern, stat.DiskPath, stat.Running, stat.Round,
+		fmt.Sprintf("%v/%v", stat.ScannedPartitions, stat.TotalPartitions), stat.ScannedExtents, formatSize(stat.ScannedBytes),
+		stat.CorruptBlocks, stat.RepairedBlocks, stat.MismatchBlocks, roundStart, lastFinish)
+}
+
+var scrubCorruptExtentTablePattern = "%-12v    %-10v    %-20v    %-20v    %-8v    %-20v    %v"
+
+func formatScrubCorruptExtentTableHeader() string {
+	return fmt.Sprintf(scrubCorruptExtentTablePattern, "PARTITION ID", "EXTENT ID", "DISK", "BLOCKS", "REPAIRED", "FOUND TIME", "ERROR")
+}
+
+func formatScrubCorruptExtentTableRow(ce *proto.ScrubCorruptExtent) string {
+	return fmt.Sprintf(scrubCorruptExtentTablePattern, ce.PartitionID, ce.ExtentID, ce.DiskPath, ce.CorruptBlocks,
+		ce.Repaired, formatTime(ce.FoundTime), ce.Err)
+}
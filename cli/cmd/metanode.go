@@ -39,6 +39,7 @@ func newMetaNodeCmd(client *master.MasterClient) *cobra.Command {
 		newMetaNodeInfoCmd(client),
 		newMetaNodeDecommissionCmd(client),
 		newMetaNodeMigrateCmd(client),
+		newMetaNodeSetRackCmd(client),
 	)
 	return cmd
 }
@@ -48,6 +49,7 @@ const (
 	cmdMetaNodeInfoShort             = "Show information of meta nodes"
 	cmdMetaNodeDecommissionInfoShort = "Decommission partitions in a meta node to other nodes"
 	cmdMetaNodeMigrateInfoShort      = "Migrate partitions from a meta node to the other node"
+	cmdMetaNodeSetRackShort          = "Set the rack of a meta node, the rack is cleared if RACK is omitted"
 )
 
 func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -192,3 +194,29 @@ func newMetaNodeMigrateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 	return cmd
 }
+
+func newMetaNodeSetRackCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliOpSetRack + " [{HOST}:{PORT}] [RACK]",
+		Short: cmdMetaNodeSetRackShort,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var rackName string
+			if len(args) > 1 {
+				rackName = args[1]
+			}
+			if err := client.NodeAPI().SetNodeRack(args[0], rackName); err != nil {
+				return err
+			}
+			stdoutf("Set rack of meta node %v to [%v] successfully\n", args[0], rackName)
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
@@ -20,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
@@ -46,6 +47,15 @@ const (
 	cmdQuotaApplyShort    = "apply quota"
 	cmdQuotaRevokeUse     = "revoke [volname] [quotaId]"
 	cmdQuotaRevokeShort   = "revoke quota"
+
+	cmdQuotaSetOwnerUse      = "setOwner [volname] [user|group] [id]"
+	cmdQuotaSetOwnerShort    = "set user or group quota of the volume"
+	cmdQuotaGetOwnerUse      = "getOwner [volname] [user|group] [id]"
+	cmdQuotaGetOwnerShort    = "get user or group quota of the volume"
+	cmdQuotaDeleteOwnerUse   = "deleteOwner [volname] [user|group] [id]"
+	cmdQuotaDeleteOwnerShort = "delete user or group quota of the volume"
+	cmdQuotaReportUse        = "report [volname]"
+	cmdQuotaReportShort      = "report usage and quota of users and groups of the volume"
 )
 
 const (
@@ -70,6 +80,10 @@ func newQuotaCmd(client *master.MasterClient) *cobra.Command {
 		newQuotaListAllCmd(client),
 		newQuotaApplyCmd(client),
 		newQuotaRevokeCmd(client),
+		newQuotaSetOwnerCmd(client),
+		newQuotaGetOwnerCmd(client),
+		newQuotaDeleteOwnerCmd(client),
+		newQuotaReportCmd(client),
 	)
 	return cmd
 }
@@ -416,6 +430,147 @@ func newQuotaRevokeCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+func parseQuotaOwner(args []string) (typ uint8, id uint32, err error) {
+	if typ, err = proto.ParseOwnerQuotaType(args[0]); err != nil {
+		return
+	}
+	tmp, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		err = fmt.Errorf("id %v is illegal", args[1])
+		return
+	}
+	id = uint32(tmp)
+	return
+}
+
+func newQuotaSetOwnerCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		hardFiles   uint64
+		hardBytes   uint64
+		softFiles   uint64
+		softBytes   uint64
+		gracePeriod time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   cmdQuotaSetOwnerUse,
+		Short: cmdQuotaSetOwnerShort,
+		Long: "Set the quota of a uid or gid over the whole volume, zero means no limit.\n" +
+			"The hard limits are enforced at once, the soft limits are enforced after being exceeded for the grace period.",
+		Args: cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			typ, id, err := parseQuotaOwner(args[1:])
+			if err != nil {
+				stdout("%v\n", err)
+				return
+			}
+			req := &proto.SetOwnerQuotaRequest{
+				VolName:     volName,
+				Type:        typ,
+				Id:          id,
+				HardFiles:   hardFiles,
+				HardBytes:   hardBytes,
+				SoftFiles:   softFiles,
+				SoftBytes:   softBytes,
+				GracePeriod: int64(gracePeriod / time.Second),
+			}
+			if err = client.AdminAPI().SetOwnerQuota(req); err != nil {
+				stdout("volName %v %v %v set quota failed(%v)\n", volName, args[1], id, err)
+				return
+			}
+			stdout("setOwnerQuota: volName %v %v %v hardFiles %v hardBytes %v softFiles %v softBytes %v gracePeriod %v success.\n",
+				volName, proto.OwnerQuotaTypeString(typ), id, hardFiles, hardBytes, softFiles, softBytes, gracePeriod)
+		},
+	}
+	cmd.Flags().Uint64Var(&hardFiles, CliFlagHardFiles, 0, "Specify hard limit of files")
+	cmd.Flags().Uint64Var(&hardBytes, CliFlagHardBytes, 0, "Specify hard limit of bytes")
+	cmd.Flags().Uint64Var(&softFiles, CliFlagSoftFiles, 0, "Specify soft limit of files")
+	cmd.Flags().Uint64Var(&softBytes, CliFlagSoftBytes, 0, "Specify soft limit of bytes")
+	cmd.Flags().DurationVar(&gracePeriod, CliFlagGracePeriod, 0, "Specify grace period of soft limits, 7 days if not set")
+	return cmd
+}
+
+func newQuotaGetOwnerCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdQuotaGetOwnerUse,
+		Short: cmdQuotaGetOwnerShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			typ, id, err := parseQuotaOwner(args[1:])
+			if err != nil {
+				stdout("%v\n", err)
+				return
+			}
+			quotaInfo, err := client.AdminAPI().GetOwnerQuota(volName, typ, id)
+			if err != nil {
+				stdout("volName %v %v %v get quota failed(%v)\n", volName, args[1], id, err)
+				return
+			}
+			stdout("%v\n", formatOwnerQuotaReportHeader())
+			stdout("%v\n", formatOwnerQuotaReportRow(quotaInfo, time.Now().Unix()))
+		},
+	}
+	return cmd
+}
+
+func newQuotaDeleteOwnerCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   cmdQuotaDeleteOwnerUse,
+		Short: cmdQuotaDeleteOwnerShort,
+		Args:  cobra.MinimumNArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			volName := args[0]
+			typ, id, err := parseQuotaOwner(args[1:])
+			if err != nil {
+				stdout("%v\n", err)
+				return
+			}
+			if err = client.AdminAPI().DeleteOwnerQuota(volName, typ, id); err != nil {
+				stdout("volName %v %v %v delete quota failed(%v)\n", volName, args[1], id, err)
+				return
+			}
+			stdout("deleteOwnerQuota: volName %v %v %v success.\n", volName, proto.OwnerQuotaTypeString(typ), id)
+		},
+	}
+	return cmd
+}
+
+func newQuotaReportCmd(client *master.MasterClient) *cobra.Command {
+	var ownerType string
+	cmd := &cobra.Command{
+		Use:   cmdQuotaReportUse,
+		Short: cmdQuotaReportShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				typ uint8
+				err error
+			)
+			volName := args[0]
+			if ownerType != "" {
+				if typ, err = proto.ParseOwnerQuotaType(ownerType); err != nil {
+					stdout("%v\n", err)
+					return
+				}
+			}
+			quotaInfos, err := client.AdminAPI().ReportOwnerQuota(volName, typ)
+			if err != nil {
+				stdout("volName %v report quota failed(%v)\n", volName, err)
+				return
+			}
+			now := time.Now().Unix()
+			stdout("[quota report of volume %v]\n", volName)
+			stdout("%v\n", formatOwnerQuotaReportHeader())
+			for _, quotaInfo := range quotaInfos {
+				stdout("%v\n", formatOwnerQuotaReportRow(quotaInfo, now))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&ownerType, CliFlagOwnerType, "", "Specify owner type to report, user or group")
+	return cmd
+}
+
 func checkNestedDirectories(paths []string) error {
 	for i, path := range paths {
 		for j := i + 1; j < len(paths); j++ {
@@ -1,18 +1,23 @@
 package cmd
 
 import (
+	"encoding/json"
+	"os"
+
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/spf13/cobra"
 )
 
 const (
-	cmdVersionUse              = "version [COMMAND]"
-	cmdVersionShort            = "Manage cluster volumes versions"
-	cmdVersionCreateShort      = "create volume version"
-	cmdVersionDelShort         = "del volume version"
-	cmdVersionListShort        = "list volume version"
-	cmdVersionSetStrategyShort = "set volume version strategy"
+	cmdVersionUse               = "version [COMMAND]"
+	cmdVersionShort             = "Manage cluster volumes versions"
+	cmdVersionCreateShort       = "create volume version"
+	cmdVersionDelShort          = "del volume version"
+	cmdVersionListShort         = "list volume version"
+	cmdVersionSetStrategyShort  = "set volume version strategy"
+	cmdVersionSetRetentionShort = "set volume version retention rules"
+	cmdVersionGetRetentionShort = "get volume version retention rules"
 )
 
 func newVersionCmd(client *master.MasterClient) *cobra.Command {
@@ -27,6 +32,8 @@ func newVersionCmd(client *master.MasterClient) *cobra.Command {
 		newVersionDelCmd(client),
 		newVersionListCmd(client),
 		newVersionStrategyCmd(client),
+		newVersionSetRetentionCmd(client),
+		newVersionGetRetentionCmd(client),
 	)
 	return cmd
 }
@@ -152,3 +159,64 @@ func newVersionStrategyCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optKeyword, "keyword", "", "Specify keyword of volume name to filter")
 	return cmd
 }
+
+func newVersionSetRetentionCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliFlagVersionSetRetention + " [VOLUME] [RULES FILE]",
+		Short: cmdVersionSetRetentionShort,
+		Long: `Set the retention rules of the volume versions from a json file of rules, such as
+[{"ID":"all","Prefix":"","Tiers":[{"Interval":3600,"Duration":172800},{"Interval":86400,"Duration":2592000}]}]
+An empty list removes the rules.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				data []byte
+				err  error
+			)
+			defer func() {
+				errout(err)
+			}()
+			req := &proto.SnapshotRetentionConfig{VolName: args[0]}
+			if data, err = os.ReadFile(args[1]); err != nil {
+				return
+			}
+			if err = json.Unmarshal(data, &req.Rules); err != nil {
+				return
+			}
+			if err = req.Validate(); err != nil {
+				return
+			}
+			if err = client.AdminAPI().SetSnapshotRetention(req); err != nil {
+				return
+			}
+			stdout("set retention rules of volume %v successfully\n", args[0])
+		},
+	}
+	return cmd
+}
+
+func newVersionGetRetentionCmd(client *master.MasterClient) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   CliFlagVersionGetRetention + " [VOLUME]",
+		Short: cmdVersionGetRetentionShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				conf *proto.SnapshotRetentionConfig
+				data []byte
+				err  error
+			)
+			defer func() {
+				errout(err)
+			}()
+			if conf, err = client.AdminAPI().GetSnapshotRetention(args[0]); err != nil {
+				return
+			}
+			if data, err = json.MarshalIndent(conf.Rules, "", "  "); err != nil {
+				return
+			}
+			stdout("%s\n", data)
+		},
+	}
+	return cmd
+}
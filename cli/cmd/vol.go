@@ -130,6 +130,8 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	var optCacheLRUInterval int
 	var optDpReadOnlyWhenVolFull string
 	var optEnableQuota string
+	var optMetaStoreMode string
+	var optCompressCodec string
 	var optTxMask string
 	var optTxTimeout uint32
 	var optTxConflictRetryNum int64
@@ -199,6 +201,8 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 				stdout("  TransactionTimeout       : %v min\n", optTxTimeout)
 				stdout("  TxConflictRetryNum       : %v\n", optTxConflictRetryNum)
 				stdout("  TxConflictRetryInterval  : %v ms\n", optTxConflictRetryInterval)
+				stdout("  metaStoreMode            : %v\n", optMetaStoreMode)
+				stdout("  compressCodec            : %v\n", formatCompressCodec(optCompressCodec))
 				stdout("\nConfirm (yes/no)[yes]: ")
 				var userConfirm string
 				_, _ = fmt.Scanln(&userConfirm)
@@ -214,7 +218,7 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 				optZoneName, optCacheRuleKey, optEbsBlkSize, optCacheCap,
 				optCacheAction, optCacheThreshold, optCacheTTL, optCacheHighWater,
 				optCacheLowWater, optCacheLRUInterval, dpReadOnlyWhenVolFull,
-				optTxMask, optTxTimeout, optTxConflictRetryNum, optTxConflictRetryInterval, optEnableQuota, clientIDKey)
+				optTxMask, optTxTimeout, optTxConflictRetryNum, optTxConflictRetryInterval, optEnableQuota, optMetaStoreMode, optCompressCodec, clientIDKey)
 			if err != nil {
 				err = fmt.Errorf("Create volume failed case:\n%v\n", err)
 				return
@@ -251,6 +255,8 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().Int64Var(&optTxConflictRetryNum, CliTxConflictRetryNum, 0, "Specify retry times for transaction conflict [1-100]")
 	cmd.Flags().Int64Var(&optTxConflictRetryInterval, CliTxConflictRetryInterval, 0, "Specify retry interval[Unit: ms] for transaction conflict [10-1000]")
 	cmd.Flags().StringVar(&optEnableQuota, CliFlagEnableQuota, "false", "Enable quota (default false)")
+	cmd.Flags().StringVar(&optMetaStoreMode, CliFlagMetaStoreMode, "mem", "Specify where the meta partitions keep the metadata [mem|rocksdb]")
+	cmd.Flags().StringVar(&optCompressCodec, CliFlagCompressCodec, "", "Specify the codec to compress file data with [none|gzip|lz4|zstd]")
 	cmd.Flags().Int64Var(&optDeleteLockTime, CliFlagDeleteLockTime, 0, "Specify delete lock time[Unit: hour] for volume")
 
 	return cmd
@@ -287,6 +293,7 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	var optReplicaNum string
 	var optDeleteLockTime int64
 	var optEnableQuota string
+	var optCompressCodec string
 	confirmString := strings.Builder{}
 	var vv *proto.SimpleVolView
 	cmd := &cobra.Command{
@@ -405,6 +412,22 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 				confirmString.WriteString(fmt.Sprintf("  DeleteLockTime            : %v h\n", vv.DeleteLockTime))
 			}
 
+			if optCompressCodec != "" {
+				newCodec := optCompressCodec
+				if newCodec == "none" {
+					newCodec = ""
+				}
+				if newCodec != vv.CompressCodec {
+					isChange = true
+					confirmString.WriteString(fmt.Sprintf("  CompressCodec       : %v -> %v\n", formatCompressCodec(vv.CompressCodec), optCompressCodec))
+					vv.CompressCodec = newCodec
+				} else {
+					confirmString.WriteString(fmt.Sprintf("  CompressCodec       : %v\n", formatCompressCodec(vv.CompressCodec)))
+				}
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  CompressCodec       : %v\n", formatCompressCodec(vv.CompressCodec)))
+			}
+
 			// var maskStr string
 			if optTxMask != "" {
 				var oldMask, newMask proto.TxOpMask
@@ -627,6 +650,7 @@ func newVolUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optReplicaNum, CliFlagReplicaNum, "", "Specify data partition replicas number(default 3 for normal volume,1 for low volume)")
 	cmd.Flags().StringVar(&optEnableQuota, CliFlagEnableQuota, "", "Enable quota")
 	cmd.Flags().Int64Var(&optDeleteLockTime, CliFlagDeleteLockTime, -1, "Specify delete lock time[Unit: hour] for volume")
+	cmd.Flags().StringVar(&optCompressCodec, CliFlagCompressCodec, "", "Specify the codec to compress file data with [none|gzip|lz4|zstd]")
 	cmd.Flags().StringVar(&clientIDKey, CliFlagClientIDKey, client.ClientIDKey(), CliUsageClientIDKey)
 
 	return cmd
@@ -29,6 +29,7 @@ import (
 	"github.com/cubefs/cubefs/depends/bazil.org/fuse/fs"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
@@ -44,25 +45,37 @@ type File struct {
 	sync.RWMutex
 	fReader *blobstore.Reader
 	fWriter *blobstore.Writer
+	locked  int32 // set once a file lock is acquired through the file
 }
 
 // Functions that File needs to implement
 var (
-	_ fs.Node              = (*File)(nil)
-	_ fs.Handle            = (*File)(nil)
-	_ fs.NodeForgetter     = (*File)(nil)
-	_ fs.NodeOpener        = (*File)(nil)
-	_ fs.HandleReleaser    = (*File)(nil)
-	_ fs.HandleReader      = (*File)(nil)
-	_ fs.HandleWriter      = (*File)(nil)
-	_ fs.HandleFlusher     = (*File)(nil)
-	_ fs.NodeFsyncer       = (*File)(nil)
-	_ fs.NodeSetattrer     = (*File)(nil)
-	_ fs.NodeReadlinker    = (*File)(nil)
-	_ fs.NodeGetxattrer    = (*File)(nil)
-	_ fs.NodeListxattrer   = (*File)(nil)
-	_ fs.NodeSetxattrer    = (*File)(nil)
-	_ fs.NodeRemovexattrer = (*File)(nil)
+	_ fs.Node                 = (*File)(nil)
+	_ fs.Handle               = (*File)(nil)
+	_ fs.NodeForgetter        = (*File)(nil)
+	_ fs.NodeOpener           = (*File)(nil)
+	_ fs.HandleReleaser       = (*File)(nil)
+	_ fs.HandleReader         = (*File)(nil)
+	_ fs.HandleWriter         = (*File)(nil)
+	_ fs.HandleFlusher        = (*File)(nil)
+	_ fs.NodeFsyncer          = (*File)(nil)
+	_ fs.NodeSetattrer        = (*File)(nil)
+	_ fs.NodeReadlinker       = (*File)(nil)
+	_ fs.NodeGetxattrer       = (*File)(nil)
+	_ fs.NodeListxattrer      = (*File)(nil)
+	_ fs.NodeSetxattrer       = (*File)(nil)
+	_ fs.NodeRemovexattrer    = (*File)(nil)
+	_ fs.HandleLocker         = (*File)(nil)
+	_ fs.HandleFallocater     = (*File)(nil)
+	_ fs.HandleCopyFileRanger = (*File)(nil)
+)
+
+const (
+	lockWaitMinInterval = 10 * time.Millisecond
+	lockWaitMaxInterval = time.Second
+
+	// the size in the reply of copy_file_range is 32 bits
+	copyFileRangeMaxSize = uint64(1) << 30
 )
 
 // NewFile returns a new file.
@@ -296,6 +309,10 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 
 	log.LogDebugf("TRACE Release enter: ino(%v) req(%v)", ino, req)
 
+	if req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		f.unlockOwner(req.LockOwner, true)
+	}
+
 	start := time.Now()
 
 	//log.LogErrorf("TRACE Release close stream: ino(%v) req(%v)", ino, req)
@@ -486,7 +503,15 @@ func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
 		stat.EndStat("Flush", err, bgTime, 1)
 	}()
 
+	if f.super.enableLock {
+		// posix locks of the owner are released once any of its descriptors is closed, see fcntl(2)
+		f.unlockOwner(req.LockOwner, false)
+	}
 	if !f.super.fsyncOnClose {
+		if f.super.enableLock {
+			// ENOSYS stops the kernel from sending flush requests, which are needed to release the locks
+			return nil
+		}
 		return fuse.ENOSYS
 	}
 	log.LogDebugf("TRACE Flush enter: ino(%v)", f.info.Inode)
@@ -606,6 +631,192 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 	return nil
 }
 
+// Fallocate handles the fallocate request. Punching a hole or zeroing a range removes the
+// extent keys within the range through the meta partition, and zeroing a range extends the
+// file size unless FALLOC_FL_KEEP_SIZE is set.
+// The extents are allocated by the data nodes on write, so the space can not be reserved:
+// the default mode and FALLOC_FL_KEEP_SIZE alone fail with EOPNOTSUPP, which makes
+// posix_fallocate(3) fall back to writing zeros.
+func (f *File) Fallocate(ctx context.Context, req *fuse.FallocateRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Fallocate", err, bgTime, 1)
+	}()
+
+	ino := f.info.Inode
+	if !proto.IsHot(f.super.volType) {
+		return fuse.ENOTSUP
+	}
+	if req.Mode&(fuse.FallocCollapseRange|fuse.FallocInsertRange) != 0 {
+		return fuse.ENOTSUP
+	}
+	// preallocation, the space could not be reserved
+	if req.Mode&(fuse.FallocPunchHole|fuse.FallocZeroRange) == 0 {
+		return fuse.ENOTSUP
+	}
+	// see fallocate(2), FALLOC_FL_PUNCH_HOLE must be ORed with FALLOC_FL_KEEP_SIZE
+	if req.Mode&fuse.FallocPunchHole != 0 && (req.Mode&fuse.FallocKeepSize == 0 || req.Mode&fuse.FallocZeroRange != 0) {
+		return fuse.Errno(syscall.EINVAL)
+	}
+	if req.Length == 0 {
+		return fuse.Errno(syscall.EINVAL)
+	}
+
+	log.LogDebugf("TRACE Fallocate enter: ino(%v) req(%v)", ino, req)
+	start := time.Now()
+	if err = f.super.ec.Flush(ino); err != nil {
+		log.LogErrorf("Fallocate: wait for flush ino(%v) req(%v) err(%v)", ino, req, err)
+		return ParseError(err)
+	}
+	defer func() {
+		f.super.ic.Delete(ino)
+		f.super.ec.ForceRefreshExtentsCache(ino)
+	}()
+
+	if err = f.super.mw.PunchHole(ino, req.Offset, req.Length); err != nil {
+		log.LogErrorf("Fallocate: punch hole ino(%v) req(%v) err(%v)", ino, req, err)
+		return ParseError(err)
+	}
+
+	end := req.Offset + req.Length
+	if req.Mode&fuse.FallocKeepSize == 0 {
+		if filesize, _ := f.fileSize(ino); end > uint64(filesize) {
+			fullPath := path.Join(f.getParentPath(), f.name)
+			if err = f.super.ec.Truncate(f.super.mw, f.parentIno, ino, int(end), fullPath); err != nil {
+				log.LogErrorf("Fallocate: extend ino(%v) req(%v) err(%v)", ino, req, err)
+				return ParseError(err)
+			}
+		}
+	}
+
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE Fallocate: ino(%v) req(%v) (%v)ns", ino, req, elapsed.Nanoseconds())
+	return nil
+}
+
+// CopyFileRange handles the copy_file_range request. The extent keys of the source range
+// are cloned to the destination by the meta partition, so that no data goes through the
+// client. If the files are not in the same meta partition, or the range could not be
+// cloned, the data is copied by the client instead.
+// FICLONE and FICLONERANGE are served by the kernel and never reach the fuse daemon,
+// tools like cp --reflink=auto fall back to copy_file_range, so the files are cloned here.
+func (f *File) CopyFileRange(ctx context.Context, req *fuse.CopyFileRangeRequest, out fs.Handle, resp *fuse.CopyFileRangeResponse) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("CopyFileRange", err, bgTime, 1)
+	}()
+
+	dst, ok := out.(*File)
+	if !ok || dst.super != f.super {
+		return fuse.Errno(syscall.EXDEV)
+	}
+	if !proto.IsHot(f.super.volType) {
+		return fuse.ENOTSUP
+	}
+	if req.Flags != 0 {
+		return fuse.Errno(syscall.EINVAL)
+	}
+
+	srcIno, dstIno := f.info.Inode, dst.info.Inode
+	log.LogDebugf("TRACE CopyFileRange enter: src(%v) dst(%v) req(%v)", srcIno, dstIno, req)
+	start := time.Now()
+
+	if err = f.super.ec.Flush(srcIno); err != nil {
+		log.LogErrorf("CopyFileRange: wait for flush ino(%v) err(%v)", srcIno, err)
+		return ParseError(err)
+	}
+	if err = f.super.ec.Flush(dstIno); err != nil {
+		log.LogErrorf("CopyFileRange: wait for flush ino(%v) err(%v)", dstIno, err)
+		return ParseError(err)
+	}
+
+	size := req.Len
+	if size > copyFileRangeMaxSize {
+		size = copyFileRangeMaxSize
+	}
+	filesize, _ := f.fileSize(srcIno)
+	if req.Offset >= uint64(filesize) {
+		return nil
+	}
+	if req.Offset+size > uint64(filesize) {
+		size = uint64(filesize) - req.Offset
+	}
+
+	defer func() {
+		f.super.ic.Delete(dstIno)
+		f.super.ec.ForceRefreshExtentsCache(dstIno)
+	}()
+
+	copied, err := f.super.mw.CloneExtents(srcIno, dstIno, req.Offset, req.OffsetOut, size)
+	if err == nil {
+		// the cloned extents of the source are shared, refresh them so that the following writes do not overwrite them in place
+		f.super.ic.Delete(srcIno)
+		f.super.ec.ForceRefreshExtentsCache(srcIno)
+	} else if err == syscall.EXDEV || err == syscall.EINVAL {
+		log.LogDebugf("CopyFileRange: clone src(%v) dst(%v) req(%v) err(%v), copy the data instead", srcIno, dstIno, req, err)
+		f.super.ec.GetStreamer(dstIno).SetParentInode(dst.parentIno)
+		copied, err = f.copyData(dst, req.Uid, req.Offset, req.OffsetOut, size)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("CopyFileRange: src(%v) dst(%v) req(%v) copied(%v) err(%v)", srcIno, dstIno, req, copied, err)
+		f.super.handleError("CopyFileRange", msg)
+		if copied == 0 {
+			return ParseError(err)
+		}
+	}
+	resp.Size = int(copied)
+
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE CopyFileRange: src(%v) dst(%v) req(%v) copied(%v) (%v)ns", srcIno, dstIno, req, copied, elapsed.Nanoseconds())
+	return nil
+}
+
+// copyData copies the data of the file to another file through the client.
+func (f *File) copyData(dst *File, uid uint32, srcOff, dstOff, size uint64) (copied uint64, err error) {
+	dstIno := dst.info.Inode
+	checkFunc := func() error {
+		if !f.super.mw.EnableQuota {
+			return nil
+		}
+		if ok := f.super.ec.UidIsLimited(uid); ok {
+			return ParseError(syscall.ENOSPC)
+		}
+		var quotaIds []uint32
+		for quotaId := range dst.info.QuotaInfos {
+			quotaIds = append(quotaIds, quotaId)
+		}
+		if limited := f.super.mw.IsQuotaLimited(quotaIds); limited {
+			return ParseError(syscall.ENOSPC)
+		}
+		return nil
+	}
+	buf := make([]byte, util.MB)
+	for copied < size {
+		n := uint64(len(buf))
+		if size-copied < n {
+			n = size - copied
+		}
+		var read, write int
+		read, err = f.super.ec.Read(f.info.Inode, buf[:n], int(srcOff+copied), int(n))
+		if err != nil && err != io.EOF {
+			return
+		}
+		err = nil
+		if read <= 0 {
+			return
+		}
+		if write, err = f.super.ec.Write(dstIno, int(dstOff+copied), buf[:read], 0, checkFunc); err != nil {
+			return
+		}
+		copied += uint64(write)
+		if write < read {
+			return
+		}
+	}
+	err = f.super.ec.Flush(dstIno)
+	return
+}
+
 // Readlink handles the readlink request.
 func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string, error) {
 	var err error
@@ -727,6 +938,128 @@ func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) er
 	return nil
 }
 
+// Setlk acquires or releases a posix or flock lock through the meta partition, so that
+// the lock is shared by all the clients. If the lock is held by others, it fails with
+// EAGAIN, or polls until the lock is released if req.Wait is set.
+func (f *File) Setlk(ctx context.Context, req *fuse.SetlkRequest) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Setlk", err, bgTime, 1)
+	}()
+
+	if !f.super.enableLock {
+		return fuse.ENOSYS
+	}
+	ino := f.info.Inode
+	lock := fuseToFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	if lock.Type != proto.FileLockUnlock {
+		atomic.StoreInt32(&f.locked, 1)
+	}
+	interval := lockWaitMinInterval
+	for {
+		var conflict *proto.FileLock
+		if conflict, err = f.super.mw.SetLock_ll(ino, lock); err != nil {
+			log.LogErrorf("Setlk: ino(%v) lock(%v) err(%v)", ino, lock, err)
+			return ParseError(err)
+		}
+		if conflict == nil {
+			log.LogDebugf("TRACE Setlk: ino(%v) lock(%v)", ino, lock)
+			return nil
+		}
+		if !req.Wait {
+			log.LogDebugf("TRACE Setlk: ino(%v) lock(%v) conflict(%v)", ino, lock, conflict)
+			return fuse.Errno(syscall.EAGAIN)
+		}
+		select {
+		case <-ctx.Done():
+			return fuse.EINTR
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > lockWaitMaxInterval {
+			interval = lockWaitMaxInterval
+		}
+	}
+}
+
+// Getlk returns the lock which prevents the requested lock from being acquired.
+func (f *File) Getlk(ctx context.Context, req *fuse.GetlkRequest, resp *fuse.GetlkResponse) (err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("Getlk", err, bgTime, 1)
+	}()
+
+	if !f.super.enableLock {
+		return fuse.ENOSYS
+	}
+	ino := f.info.Inode
+	lock := fuseToFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	conflict, err := f.super.mw.GetLock_ll(ino, lock)
+	if err != nil {
+		log.LogErrorf("Getlk: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		return ParseError(err)
+	}
+	if conflict != nil {
+		resp.Lock = fuse.FileLock{
+			Start: conflict.Start,
+			End:   conflict.End,
+			Type:  fuse.LockWrite,
+		}
+		if conflict.Type == proto.FileLockRead {
+			resp.Lock.Type = fuse.LockRead
+		}
+		// the pid of a remote process means nothing to the local kernel
+		if conflict.ClientID == f.super.mw.LockClientID() {
+			resp.Lock.Pid = conflict.Pid
+		}
+	}
+	log.LogDebugf("TRACE Getlk: ino(%v) lock(%v) conflict(%v)", ino, lock, conflict)
+	return nil
+}
+
+// unlockOwner releases all the posix or flock locks held by the owner on the file.
+func (f *File) unlockOwner(owner uint64, flock bool) {
+	if atomic.LoadInt32(&f.locked) == 0 {
+		return
+	}
+	lock := &proto.FileLock{
+		Owner: owner,
+		Start: 0,
+		End:   proto.FileLockMaxOffset,
+		Type:  proto.FileLockUnlock,
+		Flock: flock,
+	}
+	if _, err := f.super.mw.SetLock_ll(f.info.Inode, lock); err != nil {
+		log.LogWarnf("unlockOwner: ino(%v) lock(%v) err(%v)", f.info.Inode, lock, err)
+	}
+}
+
+func fuseToFileLock(owner uint64, fl fuse.FileLock, flags fuse.LockFlags) *proto.FileLock {
+	lock := &proto.FileLock{
+		Owner: owner,
+		Pid:   fl.Pid,
+		Start: fl.Start,
+		End:   fl.End,
+		Flock: flags&fuse.LockFlock != 0,
+	}
+	switch fl.Type {
+	case fuse.LockRead:
+		lock.Type = proto.FileLockRead
+	case fuse.LockWrite:
+		lock.Type = proto.FileLockWrite
+	default:
+		lock.Type = proto.FileLockUnlock
+	}
+	// flock locks the whole file
+	if lock.Flock {
+		lock.Start = 0
+		lock.End = proto.FileLockMaxOffset
+	}
+	if lock.End > proto.FileLockMaxOffset {
+		lock.End = proto.FileLockMaxOffset
+	}
+	return lock
+}
+
 func (f *File) fileSize(ino uint64) (size int, gen uint64) {
 	size, gen, valid := f.super.ec.FileSize(ino)
 	if !valid {
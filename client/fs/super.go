@@ -64,6 +64,7 @@ type Super struct {
 	disableDcache bool
 	fsyncOnClose  bool
 	enableXattr   bool
+	enableLock    bool
 	rootIno       uint64
 
 	state     fs.FSStatType
@@ -158,6 +159,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
 	s.enableXattr = opt.EnableXattr
+	s.enableLock = opt.EnableFileLock
 	s.bcacheCheckInterval = opt.BcacheCheckIntervalS
 	s.bcacheFilterFiles = opt.BcacheFilterFiles
 	s.bcacheBatchCnt = opt.BcacheBatchCnt
@@ -228,12 +230,15 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		OnGetExtents:      s.mw.GetExtents,
 		OnTruncate:        s.mw.Truncate,
 		OnEvictIcache:     s.ic.Delete,
+		OnCheckReleased:   s.mw.IsTransitionReleased,
 		OnLoadBcache:      s.bc.Get,
 		OnCacheBcache:     s.bc.Put,
 		OnEvictBcache:     s.bc.Evict,
 
 		DisableMetaCache:             DisableMetaCache,
 		MinWriteAbleDataPartitionCnt: opt.MinWriteAbleDataPartitionCnt,
+		ReadAheadMemMB:               opt.ReadAheadMemMB,
+		ReadAheadWindowMB:            opt.ReadAheadWindowMB,
 	}
 
 	s.ec, err = stream.NewExtentClient(extentConfig)
@@ -49,6 +49,7 @@ const (
 	ActionCreateExtent                  = "ActionCreateExtent:"
 	ActionMarkDelete                    = "ActionMarkDelete:"
 	ActionGetAllExtentWatermarks        = "ActionGetAllExtentWatermarks:"
+	ActionGetExtentBlockCrc             = "ActionGetExtentBlockCrc:"
 	ActionWrite                         = "ActionWrite:"
 	ActionRepair                        = "ActionRepair:"
 	ActionDecommissionPartition         = "ActionDecommissionPartition"
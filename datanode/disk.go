@@ -89,6 +89,7 @@ type Disk struct {
 	extentRepairReadLimit       chan struct{}
 	enableExtentRepairReadLimit bool
 	extentRepairReadDp          uint64
+	scrub                       *diskScrubber
 }
 
 const (
@@ -147,6 +148,7 @@ func NewDisk(path string, reservedSpace, diskRdonlySpace uint64, maxErrCnt int,
 	d.extentRepairReadLimit = make(chan struct{}, MaxExtentRepairReadLimit)
 	d.extentRepairReadLimit <- struct{}{}
 	d.enableExtentRepairReadLimit = diskEnableReadRepairExtentLimit
+	d.scrub = newDiskScrubber(d)
 	return
 }
 
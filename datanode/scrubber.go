@@ -0,0 +1,499 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ScrubStatusFile = ".scrubStatus"
+
+	DefaultScrubBandwidth    = 10     // MB/s of each disk
+	DefaultScrubIntervalHour = 24 * 7 // hours from the end of a round to the start of the next one
+	scrubCheckInterval       = time.Minute
+	scrubSkipModifiedSec     = storage.UpdateCrcInterval // extents being appended are skipped
+	scrubReadTimeout         = 60                        // seconds
+	maxScrubCorruptExtents   = 1024
+)
+
+var errScrubNoHealthyReplica = errors.New("no healthy replica")
+
+type scrubConfig struct {
+	Enable       bool `json:"enable"`
+	Bandwidth    int  `json:"bandwidth"`    // MB/s of each disk
+	IntervalHour int  `json:"intervalHour"` // hours from the end of a round to the start of the next one
+}
+
+// dataScrubber keeps the config shared by the scrubbers of the disks and the corrupt extents they found.
+// The scrubber of a disk reads every block of the normal extents periodically, compares the data with the
+// crc in the header and with the crc of the other replicas, and repairs the corrupt blocks from a healthy replica.
+type dataScrubber struct {
+	sync.RWMutex
+	config  scrubConfig
+	corrupt []*proto.ScrubCorruptExtent
+}
+
+func newDataScrubber(config scrubConfig) *dataScrubber {
+	s := &dataScrubber{corrupt: make([]*proto.ScrubCorruptExtent, 0)}
+	s.setConfig(config)
+	return s
+}
+
+func (s *dataScrubber) setConfig(config scrubConfig) {
+	if config.Bandwidth <= 0 {
+		config.Bandwidth = DefaultScrubBandwidth
+	}
+	if config.IntervalHour <= 0 {
+		config.IntervalHour = DefaultScrubIntervalHour
+	}
+	s.Lock()
+	s.config = config
+	s.Unlock()
+	log.LogInfof("action[setScrubConfig] enable(%v) bandwidth(%vMB/s) interval(%vh)",
+		config.Enable, config.Bandwidth, config.IntervalHour)
+}
+
+func (s *dataScrubber) getConfig() scrubConfig {
+	s.RLock()
+	defer s.RUnlock()
+	return s.config
+}
+
+// reportCorrupt records a corrupt extent, the record of the same extent found before is replaced.
+func (s *dataScrubber) reportCorrupt(ce *proto.ScrubCorruptExtent) {
+	s.Lock()
+	defer s.Unlock()
+	for i, old := range s.corrupt {
+		if old.PartitionID == ce.PartitionID && old.ExtentID == ce.ExtentID {
+			s.corrupt = append(s.corrupt[:i], s.corrupt[i+1:]...)
+			break
+		}
+	}
+	s.corrupt = append(s.corrupt, ce)
+	if len(s.corrupt) > maxScrubCorruptExtents {
+		s.corrupt = s.corrupt[len(s.corrupt)-maxScrubCorruptExtents:]
+	}
+}
+
+func (s *dataScrubber) getCorruptExtents() []*proto.ScrubCorruptExtent {
+	s.RLock()
+	defer s.RUnlock()
+	corrupt := make([]*proto.ScrubCorruptExtent, len(s.corrupt))
+	copy(corrupt, s.corrupt)
+	return corrupt
+}
+
+// diskScrubber scrubs the data partitions on a disk, the reads are throttled by the bandwidth of the config.
+type diskScrubber struct {
+	disk     *Disk
+	limiter  *rate.Limiter
+	buf      []byte
+	statLock sync.RWMutex
+	stat     proto.DiskScrubStat
+}
+
+func newDiskScrubber(d *Disk) *diskScrubber {
+	ds := &diskScrubber{
+		disk:    d,
+		limiter: rate.NewLimiter(rate.Limit(DefaultScrubBandwidth*util.MB), util.BlockSize),
+	}
+	ds.stat.DiskPath = d.Path
+	ds.loadStat()
+	return ds
+}
+
+func (ds *diskScrubber) statPath() string {
+	return path.Join(ds.disk.Path, ScrubStatusFile)
+}
+
+func (ds *diskScrubber) loadStat() {
+	data, err := os.ReadFile(ds.statPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.LogWarnf("action[loadScrubStat] disk(%v) err(%v)", ds.disk.Path, err)
+		}
+		return
+	}
+	stat := proto.DiskScrubStat{}
+	if err = json.Unmarshal(data, &stat); err != nil {
+		log.LogWarnf("action[loadScrubStat] disk(%v) err(%v)", ds.disk.Path, err)
+		return
+	}
+	stat.DiskPath = ds.disk.Path
+	stat.Running = false
+	ds.stat = stat
+}
+
+func (ds *diskScrubber) persistStat() {
+	stat := ds.getStat()
+	data, err := json.Marshal(stat)
+	if err == nil {
+		err = os.WriteFile(ds.statPath(), data, 0o644)
+	}
+	if err != nil {
+		log.LogWarnf("action[persistScrubStat] disk(%v) err(%v)", ds.disk.Path, err)
+	}
+}
+
+func (ds *diskScrubber) getStat() proto.DiskScrubStat {
+	ds.statLock.RLock()
+	defer ds.statLock.RUnlock()
+	return ds.stat
+}
+
+func (ds *diskScrubber) updateStat(update func(stat *proto.DiskScrubStat)) {
+	ds.statLock.Lock()
+	update(&ds.stat)
+	ds.statLock.Unlock()
+}
+
+func (ds *diskScrubber) config() (config scrubConfig, ok bool) {
+	if ds.disk.dataNode == nil || ds.disk.dataNode.scrubber == nil {
+		return
+	}
+	config = ds.disk.dataNode.scrubber.getConfig()
+	return config, config.Enable && ds.disk.Status != proto.Unavailable
+}
+
+func (ds *diskScrubber) run() {
+	ticker := time.NewTicker(scrubCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ds.disk.space.stopC:
+			return
+		case <-ticker.C:
+			config, ok := ds.config()
+			stat := ds.getStat()
+			if ok && time.Since(time.Unix(stat.LastFinishTime, 0)) >= time.Duration(config.IntervalHour)*time.Hour {
+				ds.scrubRound()
+			}
+		}
+	}
+}
+
+func (ds *diskScrubber) scrubRound() {
+	partitionIDs := ds.disk.DataPartitionList()
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+	ds.updateStat(func(stat *proto.DiskScrubStat) {
+		stat.Running = true
+		stat.RoundStartTime = time.Now().Unix()
+		stat.TotalPartitions = len(partitionIDs)
+		stat.ScannedPartitions = 0
+		stat.ScannedExtents = 0
+		stat.ScannedBytes = 0
+	})
+	log.LogInfof("action[scrubRound] disk(%v) start, partitions(%v)", ds.disk.Path, len(partitionIDs))
+	if ds.buf == nil {
+		ds.buf = make([]byte, util.BlockSize)
+	}
+	finished := true
+	for _, id := range partitionIDs {
+		dp := ds.disk.GetDataPartition(id)
+		if dp != nil && dp.isNormalType() {
+			if finished = ds.scrubPartition(dp); !finished {
+				break
+			}
+		}
+		ds.updateStat(func(stat *proto.DiskScrubStat) { stat.ScannedPartitions++ })
+	}
+	ds.updateStat(func(stat *proto.DiskScrubStat) {
+		stat.Running = false
+		if finished {
+			stat.Round++
+			stat.LastFinishTime = time.Now().Unix()
+		}
+	})
+	ds.persistStat()
+	stat := ds.getStat()
+	log.LogInfof("action[scrubRound] disk(%v) finished(%v) stat(%+v)", ds.disk.Path, finished, stat)
+}
+
+// scrubPartition returns false if the scrubber is disabled or stopped during the scrub.
+func (ds *diskScrubber) scrubPartition(dp *DataPartition) bool {
+	extents, _, err := dp.ExtentStore().GetAllWatermarks(storage.NormalExtentFilter())
+	if err != nil {
+		log.LogWarnf("action[scrubPartition] dp(%v) err(%v)", dp.partitionID, err)
+		return true
+	}
+	sort.Sort(storage.ExtentInfoArr(extents))
+	for _, ei := range extents {
+		select {
+		case <-ds.disk.space.stopC:
+			return false
+		default:
+		}
+		config, ok := ds.config()
+		if !ok {
+			return false
+		}
+		if ds.disk.GetDataPartition(dp.partitionID) == nil {
+			return true
+		}
+		if time.Now().Unix()-ei.ModifyTime <= scrubSkipModifiedSec {
+			continue
+		}
+		ds.limiter.SetLimit(rate.Limit(config.Bandwidth * util.MB))
+		ds.scrubExtent(dp, ei.FileID)
+	}
+	return true
+}
+
+func (ds *diskScrubber) scrubExtent(dp *DataPartition, extentID uint64) {
+	var (
+		store   = dp.ExtentStore()
+		remotes map[string][]uint32
+		ce      *proto.ScrubCorruptExtent
+		err     error
+	)
+	for blockNo := 0; ; blockNo++ {
+		ds.limiter.WaitN(context.Background(), util.BlockSize)
+		var (
+			size         int
+			dataCrc, crc uint32
+		)
+		size, dataCrc, crc, err = store.ScrubBlock(extentID, blockNo, ds.buf)
+		if err != nil {
+			if err == storage.ExtentNotFoundError {
+				return
+			}
+			dp.checkIsDiskError(err, ReadFlag)
+			log.LogErrorf("action[scrubExtent] dp(%v) extent(%v) block(%v) err(%v)", dp.partitionID, extentID, blockNo, err)
+			break
+		}
+		if size == 0 {
+			break
+		}
+		ds.updateStat(func(stat *proto.DiskScrubStat) { stat.ScannedBytes += uint64(size) })
+
+		expectCrc := crc
+		// the crc of the partial blocks differ while the replicas are being appended, only full blocks are compared
+		if size == util.BlockSize {
+			if remotes == nil {
+				remotes = ds.getRemoteBlockCrcs(dp, extentID)
+			}
+			if remoteCrc, agreed := agreedRemoteBlockCrc(remotes, blockNo); agreed {
+				if crc == 0 {
+					expectCrc = remoteCrc
+				} else if crc == dataCrc && remoteCrc != crc {
+					log.LogWarnf("action[scrubExtent] dp(%v) extent(%v) block(%v) crc(%v) differs from replicas(%v)",
+						dp.partitionID, extentID, blockNo, crc, remoteCrc)
+					ds.updateStat(func(stat *proto.DiskScrubStat) { stat.MismatchBlocks++ })
+				}
+			}
+		}
+		if expectCrc == 0 || expectCrc == dataCrc {
+			continue
+		}
+
+		log.LogErrorf("action[scrubExtent] dp(%v) extent(%v) block(%v) corrupt, crc(%v) dataCrc(%v) expectCrc(%v)",
+			dp.partitionID, extentID, blockNo, crc, dataCrc, expectCrc)
+		ds.updateStat(func(stat *proto.DiskScrubStat) { stat.CorruptBlocks++ })
+		if ce == nil {
+			ce = &proto.ScrubCorruptExtent{
+				PartitionID: dp.partitionID,
+				ExtentID:    extentID,
+				DiskPath:    ds.disk.Path,
+				Repaired:    true,
+				FoundTime:   time.Now().Unix(),
+			}
+		}
+		ce.CorruptBlocks = append(ce.CorruptBlocks, blockNo)
+		if rErr := ds.repairBlock(dp, extentID, blockNo, size, dataCrc, expectCrc, remotes); rErr != nil {
+			log.LogErrorf("action[scrubExtent] dp(%v) extent(%v) block(%v) repair err(%v)", dp.partitionID, extentID, blockNo, rErr)
+			ce.Repaired = false
+			ce.Err = rErr.Error()
+			continue
+		}
+		ds.updateStat(func(stat *proto.DiskScrubStat) { stat.RepairedBlocks++ })
+	}
+	if err != nil {
+		if ce == nil {
+			ce = &proto.ScrubCorruptExtent{PartitionID: dp.partitionID, ExtentID: extentID, DiskPath: ds.disk.Path, FoundTime: time.Now().Unix()}
+		}
+		ce.Repaired = false
+		ce.Err = err.Error()
+	}
+	ds.updateStat(func(stat *proto.DiskScrubStat) { stat.ScannedExtents++ })
+	if ce != nil && ds.disk.dataNode.scrubber != nil {
+		ds.disk.dataNode.scrubber.reportCorrupt(ce)
+	}
+}
+
+// agreedRemoteBlockCrc returns the crc of a block if all the replicas which know the crc agree on it.
+func agreedRemoteBlockCrc(remotes map[string][]uint32, blockNo int) (crc uint32, agreed bool) {
+	for _, crcs := range remotes {
+		if blockNo >= len(crcs) || crcs[blockNo] == 0 {
+			continue
+		}
+		if agreed && crcs[blockNo] != crc {
+			return 0, false
+		}
+		crc, agreed = crcs[blockNo], true
+	}
+	return
+}
+
+// repairBlock reads the block from the other replicas and overwrites the local one with the copy matching expectCrc.
+// If no copy matches, the local crc may be the corrupt one, the copies which match the crc of their own replica are
+// used if they agree.
+func (ds *diskScrubber) repairBlock(dp *DataPartition, extentID uint64, blockNo, size int, corruptCrc, expectCrc uint32,
+	remotes map[string][]uint32,
+) (err error) {
+	if !AutoRepairStatus {
+		return fmt.Errorf("auto repair is disabled")
+	}
+	consistent := make(map[uint32][]byte)
+	for _, host := range dp.getReplicaCopy() {
+		if host == ds.disk.dataNode.localServerAddr {
+			continue
+		}
+		var data []byte
+		if data, err = dp.readRemoteBlock(host, extentID, blockNo, size); err != nil {
+			log.LogWarnf("action[repairBlock] dp(%v) extent(%v) block(%v) read from(%v) err(%v)",
+				dp.partitionID, extentID, blockNo, host, err)
+			continue
+		}
+		dataCrc := crc32.ChecksumIEEE(data)
+		if dataCrc == expectCrc {
+			return dp.ExtentStore().RepairBlock(extentID, blockNo, data, corruptCrc)
+		}
+		if crcs := remotes[host]; blockNo < len(crcs) && crcs[blockNo] == dataCrc {
+			consistent[dataCrc] = data
+		}
+	}
+	if len(consistent) == 1 {
+		for _, data := range consistent {
+			return dp.ExtentStore().RepairBlock(extentID, blockNo, data, corruptCrc)
+		}
+	}
+	return errScrubNoHealthyReplica
+}
+
+// getRemoteBlockCrcs returns the crc of the blocks of the extent on the other replicas.
+func (ds *diskScrubber) getRemoteBlockCrcs(dp *DataPartition, extentID uint64) (remotes map[string][]uint32) {
+	remotes = make(map[string][]uint32)
+	for _, host := range dp.getReplicaCopy() {
+		if host == ds.disk.dataNode.localServerAddr {
+			continue
+		}
+		blocks, err := dp.getRemoteBlockCrc(host, extentID)
+		if err != nil {
+			log.LogWarnf("action[getRemoteBlockCrcs] dp(%v) extent(%v) host(%v) err(%v)", dp.partitionID, extentID, host, err)
+			continue
+		}
+		crcs := make([]uint32, len(blocks))
+		for _, b := range blocks {
+			if b.BlockNo >= 0 && b.BlockNo < len(crcs) {
+				crcs[b.BlockNo] = b.Crc
+			}
+		}
+		remotes[host] = crcs
+	}
+	return
+}
+
+func (dp *DataPartition) getRemoteBlockCrc(target string, extentID uint64) (blocks []*storage.BlockCrc, err error) {
+	p := repl.NewPacketToGetExtentBlockCrc(dp.partitionID, extentID)
+	var conn *net.TCPConn
+	if conn, err = gConnPool.GetConnect(target); err != nil {
+		return
+	}
+	defer func() {
+		gConnPool.PutConnect(conn, err != nil)
+	}()
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	reply := new(repl.Packet)
+	if err = reply.ReadFromConnWithVer(conn, scrubReadTimeout); err != nil {
+		return
+	}
+	if reply.ResultCode != proto.OpOk {
+		err = fmt.Errorf("result code(%v) msg(%v)", reply.ResultCode, string(reply.Data[:reply.Size]))
+		return
+	}
+	err = json.Unmarshal(reply.Data[:reply.Size], &blocks)
+	return
+}
+
+// readRemoteBlock reads a block of the extent from another replica through OpExtentRepairRead.
+func (dp *DataPartition) readRemoteBlock(target string, extentID uint64, blockNo, size int) (data []byte, err error) {
+	offset := blockNo * util.BlockSize
+	request := repl.NewExtentRepairReadPacket(dp.partitionID, extentID, offset, size)
+	var conn net.Conn
+	if conn, err = dp.getRepairConn(target); err != nil {
+		return
+	}
+	defer dp.putRepairConn(conn, true)
+	if err = request.WriteToConn(conn); err != nil {
+		return
+	}
+	data = make([]byte, 0, size)
+	for len(data) < size {
+		reply := repl.NewPacket()
+		if err = reply.ReadFromConnWithVer(conn, scrubReadTimeout); err != nil {
+			return
+		}
+		if reply.ResultCode != proto.OpOk {
+			err = fmt.Errorf("result code(%v) msg(%v)", reply.ResultCode, string(reply.Data[:util.Min(len(reply.Data), int(reply.Size))]))
+			return
+		}
+		if reply.ReqID != request.GetReqID() || reply.ExtentID != extentID || reply.Size == 0 ||
+			reply.ExtentOffset != int64(offset+len(data)) {
+			err = fmt.Errorf("invalid reply(%v) of request(%v)", reply.GetUniqueLogId(), request.GetUniqueLogId())
+			return
+		}
+		if crc32.ChecksumIEEE(reply.Data[:reply.Size]) != reply.CRC {
+			err = storage.CrcMismatchError
+			return
+		}
+		data = append(data, reply.Data[:reply.Size]...)
+	}
+	if len(data) != size {
+		err = fmt.Errorf("read %v bytes, expect %v", len(data), size)
+	}
+	return
+}
+
+func (s *DataNode) getScrubReport() (stats []proto.DiskScrubStat, corrupt []*proto.ScrubCorruptExtent) {
+	stats = make([]proto.DiskScrubStat, 0)
+	for _, d := range s.space.GetDisks() {
+		stats = append(stats, d.scrub.getStat())
+	}
+	if s.scrubber != nil {
+		corrupt = s.scrubber.getCorruptExtents()
+	}
+	return
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestAgreedRemoteBlockCrc(t *testing.T) {
+	remotes := map[string][]uint32{
+		"a": {1, 0, 3, 4},
+		"b": {1, 2, 5},
+	}
+	crc, agreed := agreedRemoteBlockCrc(remotes, 0)
+	require.True(t, agreed)
+	require.EqualValues(t, 1, crc)
+	// the replicas without the crc are ignored
+	crc, agreed = agreedRemoteBlockCrc(remotes, 1)
+	require.True(t, agreed)
+	require.EqualValues(t, 2, crc)
+	_, agreed = agreedRemoteBlockCrc(remotes, 2)
+	require.False(t, agreed)
+	crc, agreed = agreedRemoteBlockCrc(remotes, 3)
+	require.True(t, agreed)
+	require.EqualValues(t, 4, crc)
+	_, agreed = agreedRemoteBlockCrc(remotes, 4)
+	require.False(t, agreed)
+}
+
+func TestScrubberReportCorrupt(t *testing.T) {
+	s := newDataScrubber(scrubConfig{})
+	config := s.getConfig()
+	require.False(t, config.Enable)
+	require.Equal(t, DefaultScrubBandwidth, config.Bandwidth)
+	require.Equal(t, DefaultScrubIntervalHour, config.IntervalHour)
+
+	for i := 0; i < maxScrubCorruptExtents+10; i++ {
+		s.reportCorrupt(&proto.ScrubCorruptExtent{PartitionID: 1, ExtentID: uint64(i)})
+	}
+	s.reportCorrupt(&proto.ScrubCorruptExtent{PartitionID: 1, ExtentID: 20, Repaired: true})
+	corrupt := s.getCorruptExtents()
+	require.Len(t, corrupt, maxScrubCorruptExtents)
+	require.EqualValues(t, 10, corrupt[0].ExtentID)
+	last := corrupt[len(corrupt)-1]
+	require.EqualValues(t, 20, last.ExtentID)
+	require.True(t, last.Repaired)
+}
+
+func TestScrubExtent(t *testing.T) {
+	path, clean, err := getSrcPathExtentStore("scrub")
+	require.NoError(t, err)
+	defer clean()
+	store, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer store.Close()
+	dp := mockMakeDp(path)
+	dp.extentStore = store
+	disk := &Disk{Path: filepath.Dir(path), dataNode: &DataNode{scrubber: newDataScrubber(scrubConfig{Enable: true})}}
+	ds := &diskScrubber{disk: disk, limiter: rate.NewLimiter(rate.Inf, util.BlockSize), buf: make([]byte, util.BlockSize)}
+	ds.stat.DiskPath = disk.Path
+
+	id, err := store.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, store.Create(id))
+	block := bytes.Repeat([]byte("a"), util.BlockSize)
+	for i := 0; i < 2; i++ {
+		_, err = store.Write(id, int64(i*util.BlockSize), util.BlockSize, block, crc32.ChecksumIEEE(block), storage.AppendWriteType, true, false)
+		require.NoError(t, err)
+	}
+
+	// an intact extent
+	ds.scrubExtent(dp, id)
+	stat := ds.getStat()
+	require.EqualValues(t, 1, stat.ScannedExtents)
+	require.EqualValues(t, 2*util.BlockSize, stat.ScannedBytes)
+	require.EqualValues(t, 0, stat.CorruptBlocks)
+	require.Empty(t, disk.dataNode.scrubber.getCorruptExtents())
+
+	// the corrupt block is found, but can not be repaired without other replicas
+	f, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%v", id)), os.O_RDWR, 0o666)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("b"), util.BlockSize+1)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	ds.scrubExtent(dp, id)
+	stat = ds.getStat()
+	require.EqualValues(t, 1, stat.CorruptBlocks)
+	require.EqualValues(t, 0, stat.RepairedBlocks)
+	corrupt := disk.dataNode.scrubber.getCorruptExtents()
+	require.Len(t, corrupt, 1)
+	require.Equal(t, id, corrupt[0].ExtentID)
+	require.Equal(t, []int{1}, corrupt[0].CorruptBlocks)
+	require.False(t, corrupt[0].Repaired)
+	require.Equal(t, errScrubNoHealthyReplica.Error(), corrupt[0].Err)
+}
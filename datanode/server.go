@@ -79,6 +79,7 @@ const (
 	ConfigKeyPort          = "port"            // int
 	ConfigKeyMasterAddr    = "masterAddr"      // array
 	ConfigKeyZone          = "zoneName"        // string
+	ConfigKeyRack          = "rackName"        // string
 	ConfigKeyDisks         = "disks"           // array
 	ConfigKeyRaftDir       = "raftDir"         // string
 	ConfigKeyRaftHeartbeat = "raftHeartbeat"   // string
@@ -123,6 +124,11 @@ const (
 	ConfigKeyDiskUnavailablePartitionErrorCount = "diskUnavailablePartitionErrorCount"
 	// disk read extent limit
 	ConfigEnableDiskReadExtentLimit = "enableDiskReadRepairExtentLimit" // bool
+
+	// data scrubber
+	ConfigKeyScrubEnable       = "scrubEnable"       // bool
+	ConfigKeyScrubBandwidth    = "scrubBandwidth"    // int, MB/s of each disk
+	ConfigKeyScrubIntervalHour = "scrubIntervalHour" // int
 )
 
 const cpuSampleDuration = 1 * time.Second
@@ -132,6 +138,7 @@ type DataNode struct {
 	space           *SpaceManager
 	port            string
 	zoneName        string
+	rackName        string
 	clusterID       string
 	bindIp          bool
 	localServerAddr string
@@ -182,6 +189,7 @@ type DataNode struct {
 	// dpRepairTimeOut         uint64
 
 	diskUnavailablePartitionErrorCount uint64 // disk status becomes unavailable when disk error partition count reaches this value
+	scrubber                           *dataScrubber
 }
 
 type verOp2Phase struct {
@@ -345,6 +353,7 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	s.rackName = cfg.GetString(ConfigKeyRack)
 	s.metricsDegrade = cfg.GetInt64(CfgMetricsDegrade)
 
 	s.serviceIDKey = cfg.GetString(ConfigServiceIDKey)
@@ -358,9 +367,16 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	s.diskUnavailablePartitionErrorCount = uint64(diskUnavailablePartitionErrorCount)
 	log.LogDebugf("action[parseConfig] load diskUnavailablePartitionErrorCount(%v)", s.diskUnavailablePartitionErrorCount)
 
+	s.scrubber = newDataScrubber(scrubConfig{
+		Enable:       cfg.GetBoolWithDefault(ConfigKeyScrubEnable, false),
+		Bandwidth:    int(cfg.GetInt64(ConfigKeyScrubBandwidth)),
+		IntervalHour: int(cfg.GetInt64(ConfigKeyScrubIntervalHour)),
+	})
+
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
+	log.LogDebugf("action[parseConfig] load rackName(%v).", s.rackName)
 	return
 }
 
@@ -545,7 +561,7 @@ func (s *DataNode) register(cfg *config.Config) {
 			// register this data node on the master
 			var nodeID uint64
 			if nodeID, err = MasterClient.NodeAPI().AddDataNodeWithAuthNode(fmt.Sprintf("%s:%v", LocalIP, s.port),
-				s.zoneName, s.serviceIDKey); err != nil {
+				s.zoneName, s.rackName, s.serviceIDKey); err != nil {
 				log.LogErrorf("action[registerToMaster] cannot register this node to master[%v] err(%v).",
 					masterAddr, err)
 				timer.Reset(2 * time.Second)
@@ -655,6 +671,8 @@ func (s *DataNode) registerHandler() {
 	// http.HandleFunc("/detachDataPartition", s.detachDataPartition)
 	// http.HandleFunc("/loadDataPartition", s.loadDataPartition)
 	http.HandleFunc("/releaseDiskExtentReadLimitToken", s.releaseDiskExtentReadLimitToken)
+	http.HandleFunc("/scrubStatus", s.getScrubStatus)
+	http.HandleFunc("/setScrub", s.setScrub)
 }
 
 func (s *DataNode) startTCPService() (err error) {
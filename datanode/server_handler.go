@@ -656,3 +656,43 @@ func (s *DataNode) loadDataPartition(w http.ResponseWriter, r *http.Request) {
 		s.buildSuccessResp(w, "success")
 	}
 }
+
+type ScrubStatusResponse struct {
+	Config         scrubConfig                 `json:"config"`
+	Disks          []proto.DiskScrubStat       `json:"disks"`
+	CorruptExtents []*proto.ScrubCorruptExtent `json:"corruptExtents"`
+}
+
+func (s *DataNode) getScrubStatus(w http.ResponseWriter, r *http.Request) {
+	resp := &ScrubStatusResponse{}
+	if s.scrubber != nil {
+		resp.Config = s.scrubber.getConfig()
+	}
+	resp.Disks, resp.CorruptExtents = s.getScrubReport()
+	s.buildSuccessResp(w, resp)
+}
+
+func (s *DataNode) setScrub(w http.ResponseWriter, r *http.Request) {
+	if s.scrubber == nil {
+		s.buildFailureResp(w, http.StatusBadRequest, "scrubber is not initialized")
+		return
+	}
+	config := s.scrubber.getConfig()
+	var (
+		enable       = common.Bool{V: config.Enable}
+		bandwidth    = common.Int{V: int64(config.Bandwidth)}
+		intervalHour = common.Int{V: int64(config.IntervalHour)}
+	)
+	if err := parseArgs(r, enable.Enable().OmitEmpty(), bandwidth.Key("bandwidth").OmitEmpty(),
+		intervalHour.Key("intervalHour").OmitEmpty()); err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if bandwidth.V <= 0 || intervalHour.V <= 0 {
+		s.buildFailureResp(w, http.StatusBadRequest, "bandwidth and intervalHour should be positive")
+		return
+	}
+	config = scrubConfig{Enable: enable.V, Bandwidth: int(bandwidth.V), IntervalHour: int(intervalHour.V)}
+	s.scrubber.setConfig(config)
+	s.buildSuccessResp(w, config)
+}
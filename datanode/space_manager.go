@@ -260,6 +260,7 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace, diskRdonlySpac
 		manager.putDisk(disk)
 		err = nil
 		go disk.doBackendTask()
+		go disk.scrub.run()
 	}
 	return
 }
@@ -501,4 +502,5 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 		}
 		response.DiskStats = append(response.DiskStats, bds)
 	}
+	response.ScrubStats, response.CorruptExtents = s.getScrubReport()
 }
@@ -154,6 +154,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		s.handlePacketToNotifyExtentRepair(p)
 	case proto.OpGetAllWatermarks:
 		s.handlePacketToGetAllWatermarks(p)
+	case proto.OpGetExtentBlockCrc:
+		s.handlePacketToGetExtentBlockCrc(p)
 	case proto.OpCreateDataPartition:
 		s.handlePacketToCreateDataPartition(p)
 	case proto.OpLoadDataPartition:
@@ -1029,6 +1031,24 @@ func (s *DataNode) handlePacketToGetAllWatermarks(p *repl.Packet) {
 	}
 }
 
+// Handle OpGetExtentBlockCrc packet, the data scrubber of other replicas compares the crc of the blocks.
+func (s *DataNode) handlePacketToGetExtentBlockCrc(p *repl.Packet) {
+	var (
+		buf    []byte
+		blocks []*storage.BlockCrc
+		err    error
+	)
+	partition := p.Object.(*DataPartition)
+	if blocks, err = partition.ExtentStore().ScanBlocks(p.ExtentID); err == nil {
+		buf, err = json.Marshal(blocks)
+	}
+	if err != nil {
+		p.PackErrorBody(ActionGetExtentBlockCrc, err.Error())
+		return
+	}
+	p.PacketOkWithByte(buf)
+}
+
 func writeEmptyPacketOnExtentRepairRead(reply repl.PacketInterface, newOffset, currentOffset int64, connect net.Conn) (replySize int64, err error) {
 	replySize = newOffset - currentOffset
 	reply.SetData(make([]byte, 0))
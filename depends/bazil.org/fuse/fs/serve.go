@@ -306,6 +306,36 @@ type HandleFlusher interface {
 	Flush(ctx context.Context, req *fuse.FlushRequest) error
 }
 
+// HandleLocker is implemented by the handles which support POSIX byte-range
+// and flock(2) locks, the kernel only sends the lock requests if the file
+// system is mounted with fuse.FileLocks. The flock(2) locks are marked
+// with fuse.LockFlock in the LockFlags of the requests.
+type HandleLocker interface {
+	// Setlk acquires or releases a lock. If req.Wait is false, it returns
+	// EAGAIN if the lock is held by others, otherwise it waits until the
+	// lock is released or the request is interrupted.
+	Setlk(ctx context.Context, req *fuse.SetlkRequest) error
+
+	// Getlk sets resp.Lock to the lock which conflicts with the requested
+	// one, resp.Lock.Type is left as fuse.LockUnlock if there is none.
+	Getlk(ctx context.Context, req *fuse.GetlkRequest, resp *fuse.GetlkResponse) error
+}
+
+// HandleFallocater is implemented by the handles which support fallocate(2).
+type HandleFallocater interface {
+	// Fallocate preallocates, punches or zeroes the range of the file
+	// according to req.Mode.
+	Fallocate(ctx context.Context, req *fuse.FallocateRequest) error
+}
+
+// HandleCopyFileRanger is implemented by the handles which support
+// copy_file_range(2), out is the handle of the destination file.
+type HandleCopyFileRanger interface {
+	// CopyFileRange copies the range of the file to out, and sets resp.Size
+	// to the number of bytes copied.
+	CopyFileRange(ctx context.Context, req *fuse.CopyFileRangeRequest, out Handle, resp *fuse.CopyFileRangeResponse) error
+}
+
 type HandleReadAller interface {
 	ReadAll(ctx context.Context) ([]byte, error)
 }
@@ -2013,6 +2043,70 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		r.Respond()
 		return nil
 
+	case *fuse.SetlkRequest:
+		h, err := c.lockHandle(r.Handle)
+		if err != nil {
+			return err
+		}
+		if err := h.Setlk(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.GetlkRequest:
+		h, err := c.lockHandle(r.Handle)
+		if err != nil {
+			return err
+		}
+		s := &fuse.GetlkResponse{
+			Lock: fuse.FileLock{Type: fuse.LockUnlock},
+		}
+		if err := h.Getlk(ctx, r, s); err != nil {
+			return err
+		}
+		done(s)
+		r.Respond(s)
+		return nil
+
+	case *fuse.FallocateRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleFallocater)
+		if !ok {
+			return fuse.ENOSYS
+		}
+		if err := h.Fallocate(ctx, r); err != nil {
+			return err
+		}
+		done(nil)
+		r.Respond()
+		return nil
+
+	case *fuse.CopyFileRangeRequest:
+		shandle := c.getHandle(r.Handle)
+		if shandle == nil {
+			return fuse.ESTALE
+		}
+		h, ok := shandle.handle.(HandleCopyFileRanger)
+		if !ok {
+			return fuse.ENOSYS
+		}
+		outHandle := c.getHandle(r.HandleOut)
+		if outHandle == nil {
+			return fuse.ESTALE
+		}
+		s := &fuse.CopyFileRangeResponse{}
+		if err := h.CopyFileRange(ctx, r, outHandle.handle, s); err != nil {
+			return err
+		}
+		done(s)
+		r.Respond(s)
+		return nil
+
 	case *fuse.InterruptRequest:
 		c.meta.Lock()
 		ireq := c.req[r.IntrID]
@@ -2028,9 +2122,6 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 		/*	case *FsyncdirRequest:
 				return ENOSYS
 
-			case *GetlkRequest, *SetlkRequest, *SetlkwRequest:
-				return ENOSYS
-
 			case *BmapRequest:
 				return ENOSYS
 
@@ -2042,6 +2133,18 @@ func (c *Server) handleRequest(ctx context.Context, node Node, snode *serveNode,
 	panic("not reached")
 }
 
+func (c *Server) lockHandle(id fuse.HandleID) (HandleLocker, error) {
+	shandle := c.getHandle(id)
+	if shandle == nil {
+		return nil, fuse.ESTALE
+	}
+	h, ok := shandle.handle.(HandleLocker)
+	if !ok {
+		return nil, fuse.ENOSYS
+	}
+	return h, nil
+}
+
 func (c *Server) saveLookup(ctx context.Context, s *fuse.LookupResponse, snode *serveNode, elem string, n2 Node) error {
 	if err := nodeAttr(ctx, n2, &s.Attr); err != nil {
 		return err
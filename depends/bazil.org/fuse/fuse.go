@@ -980,12 +980,69 @@ loop:
 			Flags:        InitFlags(in.Flags),
 		}
 
-	case opGetlk:
-		panic("opGetlk")
-	case opSetlk:
-		panic("opSetlk")
-	case opSetlkw:
-		panic("opSetlkw")
+	case opGetlk, opSetlk, opSetlkw:
+		size := lkInSize(c.proto)
+		if m.len() < size {
+			goto corrupt
+		}
+		in := (*lkIn)(m.data())
+		var flags LockFlags
+		if size == unsafe.Sizeof(*in) {
+			flags = LockFlags(in.LkFlags)
+		}
+		lock := FileLock{
+			Start: in.Lk.Start,
+			End:   in.Lk.End,
+			Type:  LockType(in.Lk.Type),
+			Pid:   in.Lk.Pid,
+		}
+		if m.hdr.Opcode == opGetlk {
+			req = &GetlkRequest{
+				Header:    m.Header(),
+				Handle:    HandleID(in.Fh),
+				LockOwner: in.Owner,
+				Lock:      lock,
+				LockFlags: flags,
+			}
+		} else {
+			req = &SetlkRequest{
+				Header:    m.Header(),
+				Handle:    HandleID(in.Fh),
+				LockOwner: in.Owner,
+				Lock:      lock,
+				LockFlags: flags,
+				Wait:      m.hdr.Opcode == opSetlkw,
+			}
+		}
+
+	case opFallocate:
+		in := (*fallocateIn)(m.data())
+		if m.len() < unsafe.Sizeof(*in) {
+			goto corrupt
+		}
+		req = &FallocateRequest{
+			Header: m.Header(),
+			Handle: HandleID(in.Fh),
+			Offset: in.Offset,
+			Length: in.Length,
+			Mode:   FallocateFlags(in.Mode),
+		}
+
+	case opCopyFileRange:
+		in := (*copyFileRangeIn)(m.data())
+		if m.len() < unsafe.Sizeof(*in) {
+			goto corrupt
+		}
+		req = &CopyFileRangeRequest{
+			Header:    m.Header(),
+			Handle:    HandleID(in.FhIn),
+			Offset:    in.OffIn,
+			NodeOut:   NodeID(in.NodeIDOut),
+			HandleOut: HandleID(in.FhOut),
+			OffsetOut: in.OffOut,
+			Len:       in.Len,
+			Flags:     in.Flags,
+		}
 
 	case opAccess:
 		in := (*accessIn)(m.data())
@@ -1825,7 +1882,7 @@ type ReleaseRequest struct {
 	Handle       HandleID
 	Flags        OpenFlags // flags from OpenRequest
 	ReleaseFlags ReleaseFlags
-	LockOwner    uint32
+	LockOwner    uint64
 }
 
 var _ = Request(&ReleaseRequest{})
@@ -2125,6 +2182,138 @@ func (r *FlushRequest) Respond() {
 	r.respond(buf)
 }
 
+// A FileLock describes a POSIX byte-range lock, the End is inclusive.
+type FileLock struct {
+	Start uint64
+	End   uint64
+	Type  LockType
+	Pid   uint32
+}
+
+func (l FileLock) String() string {
+	return fmt.Sprintf("%v[%d,%d] pid=%d", l.Type, l.Start, l.End, l.Pid)
+}
+
+// A SetlkRequest asks to acquire or release a lock on an open file. If Wait
+// is false, the request fails with EAGAIN if the lock is held by others,
+// otherwise it waits until the lock is released or the request is interrupted.
+type SetlkRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner uint64
+	Lock      FileLock
+	LockFlags LockFlags
+	Wait      bool // is this Setlkw?
+}
+
+var _ = Request(&SetlkRequest{})
+
+func (r *SetlkRequest) String() string {
+	return fmt.Sprintf("Setlk [%s] %v owner=%#x lock=%v fl=%v wait=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags, r.Wait)
+}
+
+// Respond replies to the request, indicating that the lock has been acquired or released.
+func (r *SetlkRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// A GetlkRequest asks for the lock which prevents the given lock from being acquired.
+type GetlkRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	LockOwner uint64
+	Lock      FileLock
+	LockFlags LockFlags
+}
+
+var _ = Request(&GetlkRequest{})
+
+func (r *GetlkRequest) String() string {
+	return fmt.Sprintf("Getlk [%s] %v owner=%#x lock=%v fl=%v", &r.Header, r.Handle, r.LockOwner, r.Lock, r.LockFlags)
+}
+
+// A GetlkResponse is the response to a GetlkRequest, the Lock.Type
+// is LockUnlock if the lock could be acquired.
+type GetlkResponse struct {
+	Lock FileLock
+}
+
+func (r *GetlkResponse) String() string {
+	return fmt.Sprintf("Getlk %v", r.Lock)
+}
+
+// Respond replies to the request with the conflicting lock.
+func (r *GetlkRequest) Respond(resp *GetlkResponse) {
+	buf := newBuffer(unsafe.Sizeof(lkOut{}))
+	out := (*lkOut)(buf.alloc(unsafe.Sizeof(lkOut{})))
+	out.Lk = fileLock{
+		Start: resp.Lock.Start,
+		End:   resp.Lock.End,
+		Type:  uint32(resp.Lock.Type),
+		Pid:   resp.Lock.Pid,
+	}
+	r.respond(buf)
+}
+
+// A FallocateRequest asks to manipulate the allocated space of an open file.
+type FallocateRequest struct {
+	Header `json:"-"`
+	Handle HandleID
+	Offset uint64
+	Length uint64
+	Mode   FallocateFlags
+}
+
+var _ = Request(&FallocateRequest{})
+
+func (r *FallocateRequest) String() string {
+	return fmt.Sprintf("Fallocate [%s] %v %d @%d mode=%v", &r.Header, r.Handle, r.Length, r.Offset, r.Mode)
+}
+
+// Respond replies to the request, indicating that the space has been manipulated.
+func (r *FallocateRequest) Respond() {
+	buf := newBuffer(0)
+	r.respond(buf)
+}
+
+// A CopyFileRangeRequest asks to copy a range of an open file to another
+// open file, which is identified by NodeOut and HandleOut.
+type CopyFileRangeRequest struct {
+	Header    `json:"-"`
+	Handle    HandleID
+	Offset    uint64
+	NodeOut   NodeID
+	HandleOut HandleID
+	OffsetOut uint64
+	Len       uint64
+	Flags     uint64
+}
+
+var _ = Request(&CopyFileRangeRequest{})
+
+func (r *CopyFileRangeRequest) String() string {
+	return fmt.Sprintf("CopyFileRange [%s] %v %d @%d -> %v %v @%d fl=%#x", &r.Header, r.Handle, r.Len, r.Offset,
+		r.NodeOut, r.HandleOut, r.OffsetOut, r.Flags)
+}
+
+// Respond replies to the request with the number of bytes copied.
+func (r *CopyFileRangeRequest) Respond(resp *CopyFileRangeResponse) {
+	buf := newBuffer(unsafe.Sizeof(writeOut{}))
+	out := (*writeOut)(buf.alloc(unsafe.Sizeof(writeOut{})))
+	out.Size = uint32(resp.Size)
+	r.respond(buf)
+}
+
+// A CopyFileRangeResponse replies to a copy indicating how many bytes were copied.
+type CopyFileRangeResponse struct {
+	Size int
+}
+
+func (r *CopyFileRangeResponse) String() string {
+	return fmt.Sprintf("CopyFileRange %d", r.Size)
+}
+
 // A RemoveRequest asks to remove a file or directory from the
 // directory r.Node.
 type RemoveRequest struct {
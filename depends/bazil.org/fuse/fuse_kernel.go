@@ -339,7 +339,8 @@ func flagString(f uint32, names []flagName) string {
 type ReleaseFlags uint32
 
 const (
-	ReleaseFlush ReleaseFlags = 1 << 0
+	ReleaseFlush       ReleaseFlags = 1 << 0
+	ReleaseFlockUnlock ReleaseFlags = 1 << 1
 )
 
 func (fl ReleaseFlags) String() string {
@@ -348,6 +349,7 @@ func (fl ReleaseFlags) String() string {
 
 var releaseFlagNames = []flagName{
 	{uint32(ReleaseFlush), "ReleaseFlush"},
+	{uint32(ReleaseFlockUnlock), "ReleaseFlockUnlock"},
 }
 
 // Opcodes
@@ -391,6 +393,9 @@ const (
 	opIoctl       = 39 // Linux?
 	opPoll        = 40 // Linux?
 
+	opFallocate     = 43
+	opCopyFileRange = 47
+
 	// OS X
 	opSetvolname = 61
 	opGetxtimes  = 62
@@ -549,7 +554,7 @@ type releaseIn struct {
 	Fh           uint64
 	Flags        uint32
 	ReleaseFlags uint32
-	LockOwner    uint32
+	LockOwner    uint64
 }
 
 type flushIn struct {
@@ -692,6 +697,84 @@ type lkOut struct {
 	Lk fileLock
 }
 
+// LockType is the type of a file lock.
+type LockType uint32
+
+const (
+	LockRead   LockType = syscall.F_RDLCK
+	LockWrite  LockType = syscall.F_WRLCK
+	LockUnlock LockType = syscall.F_UNLCK
+)
+
+func (t LockType) String() string {
+	switch t {
+	case LockRead:
+		return "LockRead"
+	case LockWrite:
+		return "LockWrite"
+	case LockUnlock:
+		return "LockUnlock"
+	}
+	return fmt.Sprintf("LockType(%d)", uint32(t))
+}
+
+// The LockFlags are used in the lock exchanges.
+type LockFlags uint32
+
+const (
+	// LockFlock marks the lock as a flock(2) lock rather than a POSIX byte-range lock.
+	LockFlock LockFlags = 1 << 0
+)
+
+func (fl LockFlags) String() string {
+	return flagString(uint32(fl), lockFlagNames)
+}
+
+var lockFlagNames = []flagName{
+	{uint32(LockFlock), "LockFlock"},
+}
+
+type fallocateIn struct {
+	Fh     uint64
+	Offset uint64
+	Length uint64
+	Mode   uint32
+	_      uint32
+}
+
+// The FallocateFlags are the mode of fallocate(2).
+type FallocateFlags uint32
+
+const (
+	FallocKeepSize      FallocateFlags = 0x01
+	FallocPunchHole     FallocateFlags = 0x02
+	FallocCollapseRange FallocateFlags = 0x08
+	FallocZeroRange     FallocateFlags = 0x10
+	FallocInsertRange   FallocateFlags = 0x20
+)
+
+func (fl FallocateFlags) String() string {
+	return flagString(uint32(fl), fallocateFlagNames)
+}
+
+var fallocateFlagNames = []flagName{
+	{uint32(FallocKeepSize), "FallocKeepSize"},
+	{uint32(FallocPunchHole), "FallocPunchHole"},
+	{uint32(FallocCollapseRange), "FallocCollapseRange"},
+	{uint32(FallocZeroRange), "FallocZeroRange"},
+	{uint32(FallocInsertRange), "FallocInsertRange"},
+}
+
+type copyFileRangeIn struct {
+	FhIn      uint64
+	OffIn     uint64
+	NodeIDOut uint64
+	FhOut     uint64
+	OffOut    uint64
+	Len       uint64
+	Flags     uint64
+}
+
 type accessIn struct {
 	Mask uint32
 	_    uint32
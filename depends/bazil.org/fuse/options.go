@@ -322,6 +322,16 @@ func AllowNonEmptyMount() MountOption {
 	}
 }
 
+// FileLocks enables POSIX byte-range and flock(2) locks to be handled by
+// the file system, so that the locks are visible to other mounts. Without
+// this, the locks are only kept by the local kernel.
+func FileLocks() MountOption {
+	return func(conf *mountConfig) error {
+		conf.initFlags |= InitPosixLocks | InitFlockLocks
+		return nil
+	}
+}
+
 // PosixACL enable posix ACL supported.
 func PosixACL() MountOption {
 	return func(conf *mountConfig) error {
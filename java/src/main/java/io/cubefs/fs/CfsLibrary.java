@@ -52,6 +52,26 @@ public interface CfsLibrary extends Library {
         }
     }
 
+    public class StatfsInfo extends Structure implements Structure.ByReference {
+        // note that the field layout should be aligned with cfs_statfs_info
+        public long blocks;
+        public long bfree;
+        public long bavail;
+        public long files;
+        public long ffree;
+        public int bsize;
+        public int namelen;
+
+        public StatfsInfo() {
+            super();
+        };
+
+        @Override
+        protected List<String> getFieldOrder() {
+            return Arrays.asList(new String[] { "blocks", "bfree", "bavail", "files", "ffree", "bsize", "namelen" });
+        }
+    }
+
     public class Dirent extends Structure {
         // note that the field layout should be aligned with cfs_dirent
         public long ino;
@@ -127,4 +147,28 @@ public interface CfsLibrary extends Library {
 
     int cfs_getsummary(long cid, String path, SummaryInfo.ByReference summaryInfo, String useCache, int goroutineNum);
     int cfs_refreshsummary(long cid, String path, int goroutineNum);
+
+    int cfs_symlink(long id, String target, String linkpath);
+
+    long cfs_readlink(long id, String path, byte[] buf, long size);
+
+    int cfs_link(long id, String oldpath, String newpath);
+
+    int cfs_setxattr(long id, String path, String name, byte[] value, long size, int flags);
+
+    long cfs_getxattr(long id, String path, String name, byte[] value, long size);
+
+    long cfs_listxattr(long id, String path, byte[] list, long size);
+
+    int cfs_removexattr(long id, String path, String name);
+
+    int cfs_truncate(long id, String path, long length);
+
+    int cfs_ftruncate(long id, int fd, long length);
+
+    int cfs_fsync(long id, int fd);
+
+    int cfs_statfs(long id, StatfsInfo stat);
+
+    int cfs_access(long id, String path, int mode);
 }
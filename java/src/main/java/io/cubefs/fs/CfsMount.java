@@ -5,6 +5,10 @@ import com.sun.jna.Pointer;
 
 import java.io.FileNotFoundException;
 import java.io.IOException;
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
 
 public class CfsMount {
     // Open flags
@@ -39,6 +43,16 @@ public class CfsMount {
     //success single
     public static final int SUCCESS = 0;
 
+    // Flags of setXattr
+    public static final int XATTR_CREATE = 1;
+    public static final int XATTR_REPLACE = 2;
+
+    // Modes of access
+    public static final int F_OK = 0;
+    public static final int X_OK = 1;
+    public static final int W_OK = 2;
+    public static final int R_OK = 4;
+
     private CfsLibrary libcfs;
     private long cid; // client id allocated by libcfs library
 
@@ -176,4 +190,115 @@ public class CfsMount {
         return r;
     }
 
+    public int symlink(String target, String linkpath) throws IOException {
+        int r = libcfs.cfs_symlink(this.cid, target, linkpath);
+        if (r < 0) {
+            throw new IOException("symlink failed : " + linkpath + " code : " + r);
+        }
+        return r;
+    }
+
+    public String readlink(String path) throws IOException {
+        byte[] buf = new byte[4096];
+        long r = libcfs.cfs_readlink(this.cid, path, buf, buf.length);
+        if (r < 0) {
+            throw new IOException("readlink failed : " + path + " code : " + r);
+        }
+        return new String(buf, 0, (int) r, StandardCharsets.UTF_8);
+    }
+
+    public int link(String oldpath, String newpath) throws IOException {
+        int r = libcfs.cfs_link(this.cid, oldpath, newpath);
+        if (r < 0) {
+            throw new IOException("link failed: from: " + oldpath + " to: " + newpath + " code : " + r);
+        }
+        return r;
+    }
+
+    public int setXattr(String path, String name, byte[] value, int flags) throws IOException {
+        int r = libcfs.cfs_setxattr(this.cid, path, name, value, value.length, flags);
+        if (r < 0) {
+            throw new IOException("setXattr failed : " + path + " name : " + name + " code : " + r);
+        }
+        return r;
+    }
+
+    public byte[] getXattr(String path, String name) throws IOException {
+        long size = libcfs.cfs_getxattr(this.cid, path, name, null, 0);
+        if (size < 0) {
+            throw new IOException("getXattr failed : " + path + " name : " + name + " code : " + size);
+        }
+        byte[] value = new byte[(int) size];
+        long r = libcfs.cfs_getxattr(this.cid, path, name, value, size);
+        if (r < 0) {
+            throw new IOException("getXattr failed : " + path + " name : " + name + " code : " + r);
+        }
+        return Arrays.copyOf(value, (int) r);
+    }
+
+    public List<String> listXattr(String path) throws IOException {
+        long size = libcfs.cfs_listxattr(this.cid, path, null, 0);
+        if (size < 0) {
+            throw new IOException("listXattr failed : " + path + " code : " + size);
+        }
+        byte[] list = new byte[(int) size];
+        long r = libcfs.cfs_listxattr(this.cid, path, list, size);
+        if (r < 0) {
+            throw new IOException("listXattr failed : " + path + " code : " + r);
+        }
+        List<String> names = new ArrayList<String>();
+        int start = 0;
+        for (int i = 0; i < r; i++) {
+            if (list[i] == 0) {
+                names.add(new String(list, start, i - start, StandardCharsets.UTF_8));
+                start = i + 1;
+            }
+        }
+        return names;
+    }
+
+    public int removeXattr(String path, String name) throws IOException {
+        int r = libcfs.cfs_removexattr(this.cid, path, name);
+        if (r < 0) {
+            throw new IOException("removeXattr failed : " + path + " name : " + name + " code : " + r);
+        }
+        return r;
+    }
+
+    public int truncate(String path, long length) throws IOException {
+        int r = libcfs.cfs_truncate(this.cid, path, length);
+        if (r < 0) {
+            throw new IOException("truncate failed : " + path + " code : " + r);
+        }
+        return r;
+    }
+
+    public int ftruncate(int fd, long length) throws IOException {
+        int r = libcfs.cfs_ftruncate(this.cid, fd, length);
+        if (r < 0) {
+            throw new IOException("ftruncate failed : " + fd + " code : " + r);
+        }
+        return r;
+    }
+
+    public int fsync(int fd) throws IOException {
+        int r = libcfs.cfs_fsync(this.cid, fd);
+        if (r < 0) {
+            throw new IOException("fsync failed : " + fd + " code : " + r);
+        }
+        return r;
+    }
+
+    public int statfs(CfsLibrary.StatfsInfo stat) throws IOException {
+        int r = libcfs.cfs_statfs(this.cid, stat);
+        if (r < 0) {
+            throw new IOException("statfs failed, code : " + r);
+        }
+        return r;
+    }
+
+    public int access(String path, int mode) {
+        return libcfs.cfs_access(this.cid, path, mode);
+    }
+
 }
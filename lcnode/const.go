@@ -24,6 +24,7 @@ import (
 const (
 	configListen                     = proto.ListenPort
 	configMasterAddr                 = proto.MasterAddr
+	configLogDir                     = "logDir"
 	configBatchExpirationGetNumStr   = "batchExpirationGetNum"
 	configScanCheckIntervalStr       = "scanCheckInterval"
 	configLcScanRoutineNumPerTaskStr = "lcScanRoutineNumPerTask"
@@ -31,6 +32,10 @@ const (
 
 	configSnapshotRoutineNumPerTaskStr = "snapshotRoutineNumPerTask"
 	configLcNodeTaskCountLimit         = "lcNodeTaskCountLimit"
+
+	configReplicationTargets     = "replicationTargets"
+	configReplicationIntervalStr = "replicationInterval"
+	configSSEMasterKeyFile       = "sseMasterKeyFile"
 )
 
 // Default of configuration value
@@ -51,6 +56,8 @@ const (
 	defaultUnboundedChanInitCapacity = 10000
 	defaultLcNodeTaskCountLimit      = 1
 	maxLcNodeTaskCountLimit          = 20
+
+	defaultReplicationInterval = 60
 )
 
 var (
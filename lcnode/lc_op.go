@@ -67,6 +67,10 @@ func (l *LcNode) opMasterHeartbeat(conn net.Conn, p *proto.Packet, remoteAddr st
 					FileScannedNum:       atomic.LoadInt64(&scanner.currentStat.FileScannedNum),
 					DirScannedNum:        atomic.LoadInt64(&scanner.currentStat.DirScannedNum),
 					ExpiredNum:           atomic.LoadInt64(&scanner.currentStat.ExpiredNum),
+					TransitionedNum:      atomic.LoadInt64(&scanner.currentStat.TransitionedNum),
+					AbortedMultipartNum:  atomic.LoadInt64(&scanner.currentStat.AbortedMultipartNum),
+					NoncurrentExpiredNum: atomic.LoadInt64(&scanner.currentStat.NoncurrentExpiredNum),
+					LockedSkippedNum:     atomic.LoadInt64(&scanner.currentStat.LockedSkippedNum),
 					ErrorSkippedNum:      atomic.LoadInt64(&scanner.currentStat.ErrorSkippedNum),
 				},
 			}
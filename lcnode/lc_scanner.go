@@ -16,7 +16,9 @@ package lcnode
 
 import (
 	"context"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"syscall"
@@ -32,6 +34,15 @@ import (
 
 const (
 	pathSep = "/"
+	// XAttrKeyOSSTagging keeps the tags of objects written by objectnode
+	XAttrKeyOSSTagging = "oss:tagging"
+	// the retain until date in unix nanoseconds and legal hold of locked objects written by objectnode
+	XAttrKeyOSSLock      = "oss:lock"
+	XAttrKeyOSSLegalHold = "oss:legal-hold"
+	ossLegalHoldOn       = "ON"
+	// directories under the volume root which are kept by objectnode for internal use
+	ossVersionsDirName    = ".oss_versions"
+	ossReplicationDirName = ".oss_replication"
 )
 
 type LcScanner struct {
@@ -50,6 +61,8 @@ type LcScanner struct {
 	limiter       *rate.Limiter
 	now           time.Time
 	stopC         chan bool
+	transitioner  *transitioner
+	versioning    string // versioning status of the bucket, empty if it has never been enabled
 }
 
 func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
@@ -87,6 +100,16 @@ func NewS3Scanner(adminTask *proto.AdminTask, l *LcNode) (*LcScanner, error) {
 		stopC:         make(chan bool),
 	}
 
+	if len(scanTask.Rule.Transitions) > 0 {
+		coldVolume := scanTask.Rule.Transitions[0].StorageClass
+		if scanner.transitioner, err = newTransitioner(l, scanTask.VolName, metaWrapper, coldVolume); err != nil {
+			log.LogErrorf("NewS3Scanner: new transitioner fail: volume(%v) coldVolume(%v) err(%v)",
+				scanTask.VolName, coldVolume, err)
+			metaWrapper.Close()
+			return nil, err
+		}
+	}
+
 	return scanner, nil
 }
 
@@ -124,7 +147,16 @@ func (l *LcNode) startLcScan(adminTask *proto.AdminTask) (err error) {
 
 func (s *LcScanner) Start() (err error) {
 	response := s.adminTask.Response.(*proto.LcNodeRuleTaskResponse)
-	parentId, prefixDirs, err := s.FindPrefixInode()
+	var (
+		parentId   uint64
+		prefixDirs []string
+	)
+	if s.rule.HasDentryAction() {
+		parentId, prefixDirs, err = s.FindPrefixInode()
+	}
+	if err == nil && s.rule.Expire != nil {
+		s.versioning, err = s.loadVersioning()
+	}
 	if err != nil {
 		log.LogErrorf("startScan err(%v): volume(%v), rule id(%v), scanning done!",
 			err, s.Volume, s.rule.ID)
@@ -141,20 +173,39 @@ func (s *LcScanner) Start() (err error) {
 
 	go s.scan()
 
-	var currentPath string
-	if len(prefixDirs) > 0 {
-		currentPath = strings.Join(prefixDirs, pathSep)
+	t := time.Now()
+	response.StartTime = &t
+
+	if s.rule.AbortIncompleteMultipartUpload != nil {
+		if _, err = s.fileRPoll.Submit(s.abortMultiparts); err != nil {
+			log.LogErrorf("startScan: submit abort multiparts fail: volume(%v) rule id(%v) err(%v)",
+				s.Volume, s.rule.ID, err)
+			err = nil
+		}
 	}
 
-	firstDentry := &proto.ScanDentry{
-		Inode: parentId,
-		Path:  strings.TrimPrefix(currentPath, pathSep),
-		Type:  uint32(os.ModeDir),
+	if s.rule.NoncurrentVersionExpiration != nil {
+		if _, err = s.fileRPoll.Submit(s.expireNoncurrentVersions); err != nil {
+			log.LogErrorf("startScan: submit expire noncurrent versions fail: volume(%v) rule id(%v) err(%v)",
+				s.Volume, s.rule.ID, err)
+			err = nil
+		}
 	}
-	t := time.Now()
-	response.StartTime = &t
 
-	s.firstIn(firstDentry)
+	// rules which only abort incomplete multipart uploads need not scan the dentries
+	if s.rule.HasDentryAction() {
+		var currentPath string
+		if len(prefixDirs) > 0 {
+			currentPath = strings.Join(prefixDirs, pathSep)
+		}
+
+		firstDentry := &proto.ScanDentry{
+			Inode: parentId,
+			Path:  strings.TrimPrefix(currentPath, pathSep),
+			Type:  uint32(os.ModeDir),
+		}
+		s.firstIn(firstDentry)
+	}
 
 	go s.checkScanning()
 
@@ -318,14 +369,33 @@ func (s *LcScanner) handleFile(dentry *proto.ScanDentry) {
 func (s *LcScanner) batchHandleFile() {
 	dentries, inodes := s.batchDentries.BatchGetAndClear()
 
-	var expiredDentries []*proto.ScanDentry
+	var (
+		expiredDentries []*proto.ScanDentry
+		expiredInodes   []*proto.InodeInfo
+		transitInodes   []*proto.InodeInfo
+	)
 	inodesInfo := s.mw.BatchInodeGet(inodes)
+	xattrs, err := s.batchGetXAttr(inodes)
+	if err != nil {
+		log.LogErrorf("batchHandleFile: batch get xattr fail: volume(%v) err(%v), skip the batch", s.Volume, err)
+		atomic.AddInt64(&s.currentStat.ErrorSkippedNum, int64(len(inodes)))
+		return
+	}
 	for _, info := range inodesInfo {
+		d := dentries[info.Inode]
+		if d == nil || !s.inodeMatched(info, xattrs[info.Inode]) {
+			continue
+		}
 		if s.inodeExpired(info, s.rule.Expire) {
-			d := dentries[info.Inode]
-			if d != nil {
-				expiredDentries = append(expiredDentries, d)
+			if s.objectLocked(xattrs[info.Inode]) {
+				log.LogDebugf("batchHandleFile: skip locked object: volume(%v) path(%v)", s.Volume, d.Path)
+				atomic.AddInt64(&s.currentStat.LockedSkippedNum, 1)
+				continue
 			}
+			expiredDentries = append(expiredDentries, d)
+			expiredInodes = append(expiredInodes, info)
+		} else if s.inodeTransited(info) {
+			transitInodes = append(transitInodes, info)
 		}
 	}
 
@@ -340,6 +410,13 @@ func (s *LcScanner) batchHandleFile() {
 
 	for i, dentry := range expiredDentries {
 		s.limiter.Wait(context.Background())
+		// the current objects of versioned buckets are kept as noncurrent versions
+		if s.versioning != "" {
+			if err = s.expireVersionedObject(dentry, expiredInodes[i], xattrs[dentry.Inode]); err != nil {
+				log.LogWarnf("batchHandleFile expireVersionedObject err: %v, dentry: %+v, skip it", err, dentry)
+			}
+			continue
+		}
 		_, err := s.mw.DeleteWithCond_ll(dentry.ParentId, dentry.Inode, dentry.Name, os.FileMode(dentry.Type).IsDir(), paths[i])
 		if err != nil {
 			log.LogWarnf("batchHandleFile DeleteWithCond_ll err: %v, dentry: %+v, skip it", err, dentry)
@@ -350,22 +427,125 @@ func (s *LcScanner) batchHandleFile() {
 		}
 	}
 	atomic.AddInt64(&s.currentStat.ExpiredNum, int64(len(expiredDentries)))
+
+	for _, info := range transitInodes {
+		s.limiter.Wait(context.Background())
+		dentry := dentries[info.Inode]
+		copied, err := s.transitioner.transit(dentry, info, xattrs[info.Inode])
+		if err != nil {
+			log.LogWarnf("batchHandleFile transit err: %v, dentry: %+v, skip it", err, dentry)
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+			continue
+		}
+		if copied {
+			atomic.AddInt64(&s.currentStat.TransitionedNum, 1)
+		}
+	}
+}
+
+// batchGetXAttr gets the xattrs needed by the filter, expiration and transition of the rule.
+func (s *LcScanner) batchGetXAttr(inodes []uint64) (map[uint64]*proto.XAttrInfo, error) {
+	var keys []string
+	if s.rule.Filter != nil && len(s.rule.Filter.Tags) > 0 {
+		keys = append(keys, XAttrKeyOSSTagging)
+	}
+	if s.transitioner != nil {
+		keys = append(keys, XAttrKeyTransitionState)
+	}
+	if s.rule.Expire != nil || s.rule.NoncurrentVersionExpiration != nil {
+		keys = append(keys, XAttrKeyOSSLock, XAttrKeyOSSLegalHold)
+	}
+	if s.versioning != "" {
+		keys = append(keys, XAttrKeyOSSVersionId)
+	}
+	if len(keys) == 0 || len(inodes) == 0 {
+		return nil, nil
+	}
+	infos, err := s.mw.BatchGetXAttr(inodes, keys)
+	if err != nil {
+		return nil, err
+	}
+	xattrs := make(map[uint64]*proto.XAttrInfo, len(infos))
+	for _, info := range infos {
+		xattrs[info.Inode] = info
+	}
+	return xattrs, nil
+}
+
+// inodeMatched checks the tag and size conditions of the rule filter.
+func (s *LcScanner) inodeMatched(inode *proto.InodeInfo, xattr *proto.XAttrInfo) bool {
+	filter := s.rule.Filter
+	if filter == nil {
+		return true
+	}
+	if !filter.MatchSize(inode.Size) {
+		return false
+	}
+	if len(filter.Tags) == 0 {
+		return true
+	}
+	var tags map[string]string
+	if xattr != nil {
+		tags = parseTagging(string(xattr.Get(XAttrKeyOSSTagging)))
+	}
+	return filter.MatchTags(tags)
+}
+
+// parseTagging decodes the object tags which are stored by objectnode as a url query.
+func parseTagging(value string) map[string]string {
+	tags := make(map[string]string)
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return tags
+	}
+	for key := range values {
+		tags[key] = values.Get(key)
+	}
+	return tags
+}
+
+func (s *LcScanner) inodeTransited(inode *proto.InodeInfo) bool {
+	if s.transitioner == nil || inode == nil {
+		return false
+	}
+	// nothing to move for empty objects
+	if inode.Size == 0 {
+		return false
+	}
+	cond := s.rule.Transitions[0]
+	return s.timeReached(inode, cond.Days, cond.Date)
+}
+
+// objectLocked checks whether the object is protected by object lock, either retention or legal hold.
+// The governance retention can't be bypassed by lifecycle expiration.
+func (s *LcScanner) objectLocked(xattr *proto.XAttrInfo) bool {
+	if xattr == nil {
+		return false
+	}
+	if string(xattr.Get(XAttrKeyOSSLegalHold)) == ossLegalHoldOn {
+		return true
+	}
+	retainUntilDate, err := strconv.ParseInt(string(xattr.Get(XAttrKeyOSSLock)), 10, 64)
+	return err == nil && retainUntilDate > s.now.UnixNano()
 }
 
 func (s *LcScanner) inodeExpired(inode *proto.InodeInfo, cond *proto.ExpirationConfig) bool {
 	if inode == nil || cond == nil {
 		return false
 	}
+	return s.timeReached(inode, cond.Days, cond.Date)
+}
 
+func (s *LcScanner) timeReached(inode *proto.InodeInfo, days int, date *time.Time) bool {
 	now := s.now.Unix()
-	if cond.Days > 0 {
-		if now-inode.CreateTime.Unix() < int64(cond.Days*24*60*60) {
+	if days > 0 {
+		if now-inode.CreateTime.Unix() < int64(days*24*60*60) {
 			return false
 		}
 	}
 
-	if cond.Date != nil {
-		if now < cond.Date.Unix() {
+	if date != nil {
+		if now < date.Unix() {
 			return false
 		}
 	}
@@ -373,6 +553,45 @@ func (s *LcScanner) inodeExpired(inode *proto.InodeInfo, cond *proto.ExpirationC
 	return true
 }
 
+func (s *LcScanner) abortMultiparts() {
+	var prefix string
+	if s.rule.Filter != nil {
+		prefix = s.rule.Filter.Prefix
+	}
+	days := s.rule.AbortIncompleteMultipartUpload.DaysAfterInitiation
+	expired, err := s.mw.BatchGetExpiredMultipart(prefix, days)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogErrorf("abortMultiparts: get expired multipart fail: volume(%v) prefix(%v) days(%v) err(%v)",
+				s.Volume, prefix, days, err)
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+		}
+		return
+	}
+
+	for _, info := range expired {
+		s.limiter.Wait(context.Background())
+		for _, inode := range info.Inodes {
+			if _, err = s.mw.InodeUnlink_ll(inode, info.Path); err != nil {
+				log.LogWarnf("abortMultiparts: unlink part inode fail: volume(%v) path(%v) multipartID(%v) inode(%v) err(%v)",
+					s.Volume, info.Path, info.MultipartId, inode, err)
+				continue
+			}
+			if err = s.mw.Evict(inode, info.Path); err != nil {
+				log.LogWarnf("abortMultiparts: evict part inode fail: volume(%v) path(%v) multipartID(%v) inode(%v) err(%v)",
+					s.Volume, info.Path, info.MultipartId, inode, err)
+			}
+		}
+		if err = s.mw.RemoveMultipart_ll(info.Path, info.MultipartId); err != nil {
+			log.LogWarnf("abortMultiparts: remove multipart fail: volume(%v) path(%v) multipartID(%v) err(%v)",
+				s.Volume, info.Path, info.MultipartId, err)
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+			continue
+		}
+		atomic.AddInt64(&s.currentStat.AbortedMultipartNum, 1)
+	}
+}
+
 // scan dir tree in depth when size of dirChan.In grow too much.
 // consider 40 Bytes is the ave size of dentry, 100 million ScanDentries may take up to around 4GB of Memory
 func (s *LcScanner) handleDirLimitDepthFirst(dentry *proto.ScanDentry) {
@@ -410,6 +629,9 @@ func (s *LcScanner) handleDirLimitDepthFirst(dentry *proto.ScanDentry) {
 		files := make([]*proto.ScanDentry, 0)
 		dirs := make([]*proto.ScanDentry, 0)
 		for _, child := range children {
+			if dentry.Inode == proto.RootIno && isReservedDir(child.Name) {
+				continue
+			}
 			childDentry := &proto.ScanDentry{
 				ParentId: dentry.Inode,
 				Name:     child.Name,
@@ -475,6 +697,9 @@ func (s *LcScanner) handleDirLimitBreadthFirst(dentry *proto.ScanDentry) {
 		}
 
 		for _, child := range children {
+			if dentry.Inode == proto.RootIno && isReservedDir(child.Name) {
+				continue
+			}
 			childDentry := &proto.ScanDentry{
 				ParentId: dentry.Inode,
 				Name:     child.Name,
@@ -499,6 +724,10 @@ func (s *LcScanner) handleDirLimitBreadthFirst(dentry *proto.ScanDentry) {
 	}
 }
 
+func isReservedDir(name string) bool {
+	return name == ossVersionsDirName || name == ossReplicationDirName
+}
+
 func (s *LcScanner) checkScanning() {
 	dur := time.Second * time.Duration(scanCheckInterval)
 	taskCheckTimer := time.NewTimer(dur)
@@ -513,6 +742,9 @@ func (s *LcScanner) checkScanning() {
 					log.LogInfof("checkScanning last batchDentries")
 					s.batchHandleFile()
 				} else {
+					if s.transitioner != nil {
+						s.transitioner.cleanOrphans()
+					}
 					log.LogInfof("checkScanning completed for task(%v)", s.adminTask)
 					taskCheckTimer.Stop()
 					t := time.Now()
@@ -525,6 +757,10 @@ func (s *LcScanner) checkScanning() {
 					response.Volume = s.Volume
 					response.RuleId = s.rule.ID
 					response.ExpiredNum = s.currentStat.ExpiredNum
+					response.TransitionedNum = s.currentStat.TransitionedNum
+					response.AbortedMultipartNum = s.currentStat.AbortedMultipartNum
+					response.NoncurrentExpiredNum = s.currentStat.NoncurrentExpiredNum
+					response.LockedSkippedNum = s.currentStat.LockedSkippedNum
 					response.FileScannedNum = s.currentStat.FileScannedNum
 					response.DirScannedNum = s.currentStat.DirScannedNum
 					response.TotalInodeScannedNum = s.currentStat.TotalInodeScannedNum
@@ -556,6 +792,9 @@ func (s *LcScanner) Stop() {
 	s.dirRPoll.WaitAndClose()
 	close(s.dirChan.In)
 	close(s.fileChan.In)
+	if s.transitioner != nil {
+		s.transitioner.Close()
+	}
 	s.mw.Close()
 	log.LogInfof("scanner(%v) stopped", s.ID)
 }
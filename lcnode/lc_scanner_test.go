@@ -15,6 +15,7 @@
 package lcnode
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/cubefs/cubefs/util/routinepool"
 	"github.com/cubefs/cubefs/util/unboundedchan"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func TestLcScanner(t *testing.T) {
@@ -52,3 +54,80 @@ func TestLcScanner(t *testing.T) {
 	time.Sleep(time.Second * 5)
 	require.Equal(t, true, scanner.DoneScanning())
 }
+
+func TestLcScannerFilter(t *testing.T) {
+	scanner := &LcScanner{
+		rule: &proto.Rule{
+			Filter: &proto.FilterConfig{
+				Prefix:                "logs/",
+				Tags:                  []*proto.TagConfig{{Key: "type", Value: "log"}},
+				ObjectSizeGreaterThan: 1024,
+			},
+		},
+	}
+	xattr := &proto.XAttrInfo{
+		Inode:  1,
+		XAttrs: map[string]string{XAttrKeyOSSTagging: "type=log&owner=cubefs"},
+	}
+	require.True(t, scanner.inodeMatched(&proto.InodeInfo{Inode: 1, Size: 2048}, xattr))
+	require.False(t, scanner.inodeMatched(&proto.InodeInfo{Inode: 1, Size: 1024}, xattr))
+	require.False(t, scanner.inodeMatched(&proto.InodeInfo{Inode: 1, Size: 2048}, nil))
+
+	xattr.XAttrs[XAttrKeyOSSTagging] = "type=txt"
+	require.False(t, scanner.inodeMatched(&proto.InodeInfo{Inode: 1, Size: 2048}, xattr))
+
+	scanner.rule.Filter = nil
+	require.True(t, scanner.inodeMatched(&proto.InodeInfo{Inode: 1}, nil))
+}
+
+func TestLcScannerObjectLocked(t *testing.T) {
+	now := time.Now()
+	scanner := &LcScanner{now: now}
+	require.False(t, scanner.objectLocked(nil))
+
+	retainUntil := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(d).UnixNano(), 10)
+	}
+	xattr := &proto.XAttrInfo{Inode: 1, XAttrs: map[string]string{XAttrKeyOSSLock: retainUntil(time.Hour)}}
+	require.True(t, scanner.objectLocked(xattr))
+
+	xattr.XAttrs[XAttrKeyOSSLock] = retainUntil(-time.Hour)
+	require.False(t, scanner.objectLocked(xattr))
+
+	xattr.XAttrs[XAttrKeyOSSLegalHold] = ossLegalHoldOn
+	require.True(t, scanner.objectLocked(xattr))
+
+	xattr.XAttrs[XAttrKeyOSSLegalHold] = "OFF"
+	require.False(t, scanner.objectLocked(xattr))
+}
+
+func TestLcScannerAbortMultipart(t *testing.T) {
+	lcScanRoutineNumPerTask = 1
+	scanCheckInterval = 1
+	scanner := &LcScanner{
+		ID:     "test_abort_id",
+		Volume: "test_vol",
+		mw:     NewMockMetaWrapper(),
+		lcnode: &LcNode{lcScanners: make(map[string]*LcScanner)},
+		adminTask: &proto.AdminTask{
+			Response: &proto.LcNodeRuleTaskResponse{},
+		},
+		rule: &proto.Rule{
+			AbortIncompleteMultipartUpload: &proto.AbortIncompleteMultipartUploadConfig{DaysAfterInitiation: 1},
+		},
+		dirChan:       unboundedchan.NewUnboundedChan(10),
+		fileChan:      unboundedchan.NewUnboundedChan(10),
+		dirRPoll:      routinepool.NewRoutinePool(lcScanRoutineNumPerTask),
+		fileRPoll:     routinepool.NewRoutinePool(lcScanRoutineNumPerTask),
+		batchDentries: proto.NewBatchDentries(),
+		currentStat:   &proto.LcNodeRuleTaskStatistics{},
+		limiter:       rate.NewLimiter(rate.Inf, defaultLcScanLimitBurst),
+		now:           time.Now(),
+		stopC:         make(chan bool),
+	}
+	err := scanner.Start()
+	require.NoError(t, err)
+	time.Sleep(time.Second * 2)
+	require.Equal(t, true, scanner.DoneScanning())
+	require.Equal(t, int64(0), scanner.currentStat.DirScannedNum)
+}
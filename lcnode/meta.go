@@ -24,5 +24,15 @@ type MetaWrapper interface {
 	DeleteWithCond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (inode *proto.InodeInfo, err error)
 	Evict(inode uint64, fullPath string) error
 	ReadDirLimit_ll(parentID uint64, from string, limit uint64) ([]proto.Dentry, error)
+	BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.XAttrInfo, error)
+	BatchGetExpiredMultipart(prefix string, days int) ([]*proto.ExpiredMultipartInfo, error)
+	InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error)
+	RemoveMultipart_ll(path, multipartID string) error
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	BatchSetXAttr_ll(inode uint64, attrs map[string]string) error
+	Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error)
+	InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error)
+	DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32, fullPath string) error
+	Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error)
 	Close() error
 }
@@ -50,6 +50,46 @@ func (*MockMetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint
 	return nil, nil
 }
 
+func (*MockMetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) ([]*proto.XAttrInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) BatchGetExpiredMultipart(prefix string, days int) ([]*proto.ExpiredMultipartInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) InodeUnlink_ll(inode uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) RemoveMultipart_ll(path, multipartID string) error {
+	return nil
+}
+
+func (*MockMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	return &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}, nil
+}
+
+func (*MockMetaWrapper) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
+	return nil
+}
+
+func (*MockMetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
+func (*MockMetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32, fullPath string) error {
+	return nil
+}
+
+func (*MockMetaWrapper) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
+	return nil, nil
+}
+
 func (*MockMetaWrapper) Close() error {
 	return nil
 }
@@ -0,0 +1,746 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/sse"
+)
+
+// Objectnode marks the objects matching the replication configuration of the bucket as PENDING,
+// and queues them under ossReplicationDirName with the destination bucket.
+// The replicator consumes the queue of the volumes with replication enabled on master, copies the
+// objects with their metadata, tags and ACLs to the destination endpoint, and updates the replication
+// status of the objects. A volume is replicated by the lcnode holding the lease of its queue, the
+// other lcnodes configured with replication targets take it over once the lease expires.
+// SSE-S3 objects are decrypted with the master keys shared with objectnode and encrypted again by
+// the destination, the objects which could not be decrypted are kept in the queue as FAILED.
+const (
+	XAttrKeyOSSReplicationStatus = "oss:replication-status"
+	XAttrKeyOSSReplicationPath   = "oss:replication-path"
+	XAttrKeyOSSReplicationBucket = "oss:replication-bucket"
+	XAttrKeyOSSReplicationClass  = "oss:replication-class"
+	XAttrKeyOSSACL               = "oss:acl"
+	XAttrKeyOSSMIME              = "oss:mime"
+	XAttrKeyOSSDisposition       = "oss:disposition"
+	XAttrKeyOSSCacheControl      = "oss:cache"
+	XAttrKeyOSSExpires           = "oss:expires"
+	XAttrKeyOSSSSEType           = "oss:sse-type"
+	XAttrKeyOSSSSEKey            = "oss:sse-key"
+	XAttrKeyOSSSSEIV             = "oss:sse-iv"
+	XAttrKeyOSSSSEParts          = "oss:sse-parts"
+	xattrKeyOSSPrefix            = "oss:"
+
+	replicationStatusCompleted = "COMPLETED"
+	replicationStatusFailed    = "FAILED"
+
+	// queue entries without destination are left by interrupted writes of objectnode
+	replicationEntryTimeout  = 10 * time.Minute
+	replicationPartSize      = int64(64) << 20
+	replicationDefaultRegion = "default"
+
+	sseTypeS3          = "SSE-S3"
+	sseAlgorithmAES256 = "AES256"
+)
+
+// ReplicationTarget is the destination endpoint of a bucket replicated to.
+type ReplicationTarget struct {
+	Bucket    string `json:"bucket"`
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+func parseReplicationTargets(cfg *config.Config) (targets map[string]*ReplicationTarget, err error) {
+	value := cfg.GetValue(configReplicationTargets)
+	if value == nil {
+		return
+	}
+	var data []byte
+	if data, err = json.Marshal(value); err != nil {
+		return
+	}
+	var list []*ReplicationTarget
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+	}
+	targets = make(map[string]*ReplicationTarget, len(list))
+	for _, t := range list {
+		if t.Bucket == "" || t.Endpoint == "" {
+			return nil, fmt.Errorf("%v,err:replication target without bucket or endpoint", proto.ErrInvalidCfg)
+		}
+		if _, ok := targets[t.Bucket]; ok {
+			return nil, fmt.Errorf("%v,err:duplicate replication target(%v)", proto.ErrInvalidCfg, t.Bucket)
+		}
+		if t.Region == "" {
+			t.Region = replicationDefaultRegion
+		}
+		targets[t.Bucket] = t
+	}
+	return
+}
+
+type Replicator struct {
+	l        *LcNode
+	targets  map[string]*ReplicationTarget
+	clients  map[string]*s3.S3
+	volumes  map[string]*replicationVolume
+	interval time.Duration
+	stopC    chan bool
+}
+
+func newReplicator(l *LcNode, targets map[string]*ReplicationTarget, interval time.Duration) (r *Replicator, err error) {
+	r = &Replicator{
+		l:        l,
+		targets:  targets,
+		clients:  make(map[string]*s3.S3, len(targets)),
+		volumes:  make(map[string]*replicationVolume),
+		interval: interval,
+		stopC:    l.stopC,
+	}
+	var sess *session.Session
+	if sess, err = session.NewSession(); err != nil {
+		return nil, err
+	}
+	for bucket, t := range targets {
+		r.clients[bucket] = s3.New(sess, aws.NewConfig().
+			WithEndpoint(t.Endpoint).
+			WithRegion(t.Region).
+			WithCredentials(credentials.NewStaticCredentials(t.AccessKey, t.SecretKey, "")).
+			WithS3ForcePathStyle(true))
+	}
+	return r, nil
+}
+
+func (r *Replicator) run() {
+	log.LogInfof("replicator: start with targets(%v) interval(%v)", len(r.targets), r.interval)
+	timer := time.NewTimer(0)
+	defer func() {
+		timer.Stop()
+		for name, v := range r.volumes {
+			v.Close()
+			delete(r.volumes, name)
+		}
+	}()
+	for {
+		select {
+		case <-timer.C:
+			r.replicate()
+			timer.Reset(r.interval)
+		case <-r.stopC:
+			log.LogInfof("replicator: stop")
+			return
+		}
+	}
+}
+
+func (r *Replicator) stopped() bool {
+	select {
+	case <-r.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Replicator) replicate() {
+	vols, err := r.l.mc.AdminAPI().ListVols("")
+	if err != nil {
+		log.LogErrorf("replicate: list volumes fail: err(%v)", err)
+		return
+	}
+	for _, vol := range vols {
+		if r.stopped() {
+			return
+		}
+		// the meta wrapper is not opened for the volumes without replication configuration
+		if vol.Status != proto.VolStatusNormal || !vol.EnableReplication {
+			continue
+		}
+		v, err := r.getVolume(vol.Name)
+		if err != nil {
+			log.LogErrorf("replicate: load volume fail: volume(%v) err(%v)", vol.Name, err)
+			continue
+		}
+		r.replicateVolume(v)
+	}
+	// close the volumes not used in this round, which releases their leases
+	for name, v := range r.volumes {
+		if !v.used {
+			v.Close()
+			delete(r.volumes, name)
+			continue
+		}
+		v.used = false
+	}
+}
+
+func (r *Replicator) replicateVolume(v *replicationVolume) {
+	dirIno, _, err := v.mw.Lookup_ll(proto.RootIno, ossReplicationDirName)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogErrorf("replicateVolume: lookup queue fail: volume(%v) err(%v)", v.name, err)
+		}
+		return
+	}
+	if !v.holdLease(dirIno) {
+		return
+	}
+	marker := ""
+	for {
+		children, err := v.mw.ReadDirLimit_ll(dirIno, marker, uint64(defaultReadDirLimit))
+		if err != nil {
+			if err != syscall.ENOENT {
+				log.LogErrorf("replicateVolume: read queue fail: volume(%v) marker(%v) err(%v)", v.name, marker, err)
+			}
+			return
+		}
+		if marker != "" && len(children) > 0 && children[0].Name == marker {
+			children = children[1:]
+		}
+		if len(children) == 0 {
+			return
+		}
+		for _, child := range children {
+			if r.stopped() {
+				return
+			}
+			r.replicateEntry(v, dirIno, child)
+		}
+		if len(children) < defaultReadDirLimit-1 {
+			return
+		}
+		marker = children[len(children)-1].Name
+	}
+}
+
+// replicateEntry replicates the object of a queue entry, the entry is kept for retrying if it fails.
+func (r *Replicator) replicateEntry(v *replicationVolume, dirIno uint64, entry proto.Dentry) {
+	ino, err := strconv.ParseUint(entry.Name, 10, 64)
+	if err != nil {
+		v.removeEntry(dirIno, entry.Name)
+		return
+	}
+	var attrs *proto.XAttrInfo
+	if attrs, err = v.mw.XAttrGetAll_ll(entry.Inode); err != nil {
+		log.LogErrorf("replicateEntry: get entry fail: volume(%v) entry(%v) err(%v)", v.name, entry.Name, err)
+		return
+	}
+	key, bucket := string(attrs.Get(XAttrKeyOSSReplicationPath)), string(attrs.Get(XAttrKeyOSSReplicationBucket))
+	if key == "" || bucket == "" {
+		if info, err := v.mw.InodeGet_ll(entry.Inode); err == nil && time.Since(info.ModifyTime) > replicationEntryTimeout {
+			v.removeEntry(dirIno, entry.Name)
+		}
+		return
+	}
+
+	// the object has been deleted or overwritten, the new object is queued by itself
+	var current uint64
+	if current, err = v.lookupPath(key); err != nil || current != ino {
+		if err != nil && err != syscall.ENOENT {
+			log.LogErrorf("replicateEntry: lookup object fail: volume(%v) key(%v) err(%v)", v.name, key, err)
+			return
+		}
+		v.removeEntry(dirIno, entry.Name)
+		return
+	}
+
+	var info *proto.InodeInfo
+	if info, err = v.mw.InodeGet_ll(ino); err != nil {
+		log.LogErrorf("replicateEntry: get inode fail: volume(%v) key(%v) inode(%v) err(%v)", v.name, key, ino, err)
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGetAll_ll(ino); err != nil {
+		log.LogErrorf("replicateEntry: get xattr fail: volume(%v) key(%v) inode(%v) err(%v)", v.name, key, ino, err)
+		return
+	}
+	var objCipher *sse.Cipher
+	if sseType := string(xattr.Get(XAttrKeyOSSSSEType)); sseType != "" {
+		// the entry is retried once the master keys are configured
+		if objCipher, err = r.objectCipher(sseType, xattr); err != nil {
+			log.LogErrorf("replicateEntry: decrypt object fail: volume(%v) key(%v) inode(%v) type(%v) err(%v)",
+				v.name, key, ino, sseType, err)
+			v.setStatus(ino, replicationStatusFailed)
+			return
+		}
+	}
+	target, client := r.targets[bucket], r.clients[bucket]
+	if target == nil {
+		log.LogWarnf("replicateEntry: replication target not configured: volume(%v) key(%v) bucket(%v)", v.name, key, bucket)
+		v.setStatus(ino, replicationStatusFailed)
+		return
+	}
+
+	start := time.Now()
+	if err = r.upload(client, v, target.Bucket, key, info, xattr, objCipher, string(attrs.Get(XAttrKeyOSSReplicationClass))); err != nil {
+		log.LogErrorf("replicateEntry: replicate object fail: volume(%v) key(%v) inode(%v) target(%v) err(%v)",
+			v.name, key, ino, bucket, err)
+		v.setStatus(ino, replicationStatusFailed)
+		return
+	}
+	v.setStatus(ino, replicationStatusCompleted)
+	v.removeEntry(dirIno, entry.Name)
+	log.LogInfof("replicateEntry: replicate object: volume(%v) key(%v) inode(%v) size(%v) target(%v) cost(%v)",
+		v.name, key, ino, info.Size, bucket, time.Since(start))
+}
+
+// objectCipher loads the data key of an encrypted object, only SSE-S3 objects could be decrypted
+// without the client, the keys of SSE-C objects are never kept by the cluster.
+func (r *Replicator) objectCipher(sseType string, xattr *proto.XAttrInfo) (c *sse.Cipher, err error) {
+	if sseType != sseTypeS3 {
+		return nil, fmt.Errorf("encryption type %v could not be replicated", sseType)
+	}
+	if r.l.sseKeyStore == nil {
+		return nil, fmt.Errorf("%v is not configured", configSSEMasterKeyFile)
+	}
+	c = &sse.Cipher{}
+	if c.Key, err = r.l.sseKeyStore.Unseal(string(xattr.Get(XAttrKeyOSSSSEKey))); err != nil {
+		return nil, err
+	}
+	if c.IV, err = base64.StdEncoding.DecodeString(string(xattr.Get(XAttrKeyOSSSSEIV))); err != nil || len(c.IV) != aes.BlockSize {
+		return nil, sse.ErrInvalidSealedKey
+	}
+	if c.Parts, err = sse.ParseParts(string(xattr.Get(XAttrKeyOSSSSEParts))); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (r *Replicator) upload(client *s3.S3, v *replicationVolume, bucket, key string, info *proto.InodeInfo,
+	xattr *proto.XAttrInfo, objCipher *sse.Cipher, storageClass string,
+) (err error) {
+	dataVol, dataIno := v, info.Inode
+	// the data of a transitioned object lives in the cold volume
+	if coldVolume := string(xattr.Get(XAttrKeyTransition)); coldVolume != "" {
+		if dataVol, err = r.getVolume(coldVolume); err != nil {
+			return
+		}
+		if dataIno, err = dataVol.lookupPath(path.Join(v.name, strconv.FormatUint(info.Inode, 10))); err != nil {
+			return
+		}
+	}
+	var reader *inodeReader
+	if reader, err = r.newInodeReader(dataVol, dataIno); err != nil {
+		return
+	}
+	defer reader.Close()
+
+	var data io.ReaderAt = reader
+	objMeta := newReplicationMeta(xattr)
+	if objCipher != nil {
+		data = objCipher.DecryptReaderAt(reader)
+		objMeta.serverSideEncryption = aws.String(sseAlgorithmAES256)
+	}
+	size := int64(info.Size)
+	if size <= replicationPartSize {
+		input := &s3.PutObjectInput{
+			Bucket:               aws.String(bucket),
+			Key:                  aws.String(key),
+			Body:                 io.NewSectionReader(data, 0, size),
+			ContentLength:        aws.Int64(size),
+			ContentType:          objMeta.contentType,
+			ContentDisposition:   objMeta.contentDisposition,
+			CacheControl:         objMeta.cacheControl,
+			Expires:              objMeta.expires,
+			Metadata:             objMeta.metadata,
+			Tagging:              objMeta.tagging,
+			ServerSideEncryption: objMeta.serverSideEncryption,
+		}
+		if storageClass != "" {
+			input.StorageClass = aws.String(storageClass)
+		}
+		if _, err = client.PutObject(input); err != nil {
+			return
+		}
+	} else if err = r.uploadMultipart(client, data, bucket, key, size, objMeta, storageClass); err != nil {
+		return
+	}
+	if acl := xattr.Get(XAttrKeyOSSACL); len(acl) > 0 {
+		return r.putACL(client, bucket, key, acl)
+	}
+	return
+}
+
+func (r *Replicator) uploadMultipart(client *s3.S3, reader io.ReaderAt, bucket, key string, size int64,
+	objMeta *replicationMeta, storageClass string,
+) (err error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		ContentType:          objMeta.contentType,
+		ContentDisposition:   objMeta.contentDisposition,
+		CacheControl:         objMeta.cacheControl,
+		Expires:              objMeta.expires,
+		Metadata:             objMeta.metadata,
+		Tagging:              objMeta.tagging,
+		ServerSideEncryption: objMeta.serverSideEncryption,
+	}
+	if storageClass != "" {
+		input.StorageClass = aws.String(storageClass)
+	}
+	var output *s3.CreateMultipartUploadOutput
+	if output, err = client.CreateMultipartUpload(input); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if _, abortErr := client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucket),
+				Key:      aws.String(key),
+				UploadId: output.UploadId,
+			}); abortErr != nil {
+				log.LogWarnf("uploadMultipart: abort upload fail: bucket(%v) key(%v) uploadId(%v) err(%v)",
+					bucket, key, aws.StringValue(output.UploadId), abortErr)
+			}
+		}
+	}()
+
+	parts := make([]*s3.CompletedPart, 0, size/replicationPartSize+1)
+	for offset, number := int64(0), int64(1); offset < size; offset, number = offset+replicationPartSize, number+1 {
+		partSize := replicationPartSize
+		if rest := size - offset; rest < partSize {
+			partSize = rest
+		}
+		var part *s3.UploadPartOutput
+		if part, err = client.UploadPart(&s3.UploadPartInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			UploadId:      output.UploadId,
+			PartNumber:    aws.Int64(number),
+			Body:          io.NewSectionReader(reader, offset, partSize),
+			ContentLength: aws.Int64(partSize),
+		}); err != nil {
+			return
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: part.ETag, PartNumber: aws.Int64(number)})
+	}
+	_, err = client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        output.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	return
+}
+
+// putACL applies the grants of the source object to the replica, the grants to the source owner
+// are given to the owner of the replica as users are not shared across clusters.
+func (r *Replicator) putACL(client *s3.S3, bucket, key string, raw []byte) (err error) {
+	acl := &objectACL{}
+	if err = json.Unmarshal(raw, acl); err != nil {
+		return
+	}
+	var current *s3.GetObjectAclOutput
+	if current, err = client.GetObjectAcl(&s3.GetObjectAclInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return
+	}
+	policy := acl.toPolicy(current.Owner)
+	if len(policy.Grants) == 0 {
+		return
+	}
+	_, err = client.PutObjectAcl(&s3.PutObjectAclInput{
+		Bucket:              aws.String(bucket),
+		Key:                 aws.String(key),
+		AccessControlPolicy: policy,
+	})
+	return
+}
+
+func (r *Replicator) getVolume(name string) (v *replicationVolume, err error) {
+	if v = r.volumes[name]; v != nil {
+		v.used = true
+		return
+	}
+	v = &replicationVolume{name: name, masters: r.l.masters}
+	if v.view, err = r.l.mc.AdminAPI().GetVolumeSimpleInfo(name); err != nil {
+		return nil, err
+	}
+	if v.mw, err = meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        name,
+		Masters:       r.l.masters,
+		Authenticate:  false,
+		ValidateOwner: false,
+	}); err != nil {
+		return nil, err
+	}
+	v.used = true
+	r.volumes[name] = v
+	return
+}
+
+func (r *Replicator) newInodeReader(v *replicationVolume, ino uint64) (reader *inodeReader, err error) {
+	var ec *stream.ExtentClient
+	if ec, err = v.extentClient(); err != nil {
+		return
+	}
+	if err = ec.OpenStream(ino); err != nil {
+		return
+	}
+	reader = &inodeReader{ec: ec, ino: ino}
+	if proto.IsHot(v.view.VolType) {
+		return
+	}
+	var ebsc *blobstore.BlobStoreClient
+	if ebsc, err = r.l.getEbsClient(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+	reader.ebs = blobstore.NewReader(blobstore.ClientConfig{
+		VolName:         v.name,
+		VolType:         v.view.VolType,
+		Ino:             ino,
+		BlockSize:       v.view.ObjBlockSize,
+		Mw:              v.mw,
+		Ec:              ec,
+		Ebsc:            ebsc,
+		ReadConcurrency: transitionReadThreads,
+		CacheAction:     v.view.CacheAction,
+		CacheThreshold:  v.view.CacheThreshold,
+	})
+	return
+}
+
+type replicationVolume struct {
+	name     string
+	masters  []string
+	view     *proto.SimpleVolView
+	mw       *meta.MetaWrapper
+	ec       *stream.ExtentClient
+	used     bool   // used in the current round
+	leaseIno uint64 // the queue directory locked as the lease
+}
+
+// extentClient is created on demand, most volumes have nothing to replicate.
+func (v *replicationVolume) extentClient() (ec *stream.ExtentClient, err error) {
+	if v.ec == nil {
+		if v.ec, err = newExtentClient(v.name, v.masters, v.mw); err != nil {
+			return
+		}
+	}
+	return v.ec, nil
+}
+
+func (v *replicationVolume) lookupPath(key string) (ino uint64, err error) {
+	ino = proto.RootIno
+	for _, name := range strings.Split(strings.Trim(key, pathSep), pathSep) {
+		if name == "" {
+			continue
+		}
+		if ino, _, err = v.mw.Lookup_ll(ino, name); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (v *replicationVolume) setStatus(ino uint64, status string) {
+	if err := v.mw.XAttrSet_ll(ino, []byte(XAttrKeyOSSReplicationStatus), []byte(status)); err != nil {
+		log.LogWarnf("setStatus: set replication status fail: volume(%v) inode(%v) status(%v) err(%v)",
+			v.name, ino, status, err)
+	}
+}
+
+func (v *replicationVolume) removeEntry(dirIno uint64, name string) {
+	fullPath := path.Join(pathSep, ossReplicationDirName, name)
+	info, err := v.mw.Delete_ll(dirIno, name, false, fullPath)
+	if err == nil && info != nil {
+		err = v.mw.Evict(info.Inode, fullPath)
+	}
+	if err != nil && err != syscall.ENOENT {
+		log.LogWarnf("removeEntry: remove queue entry fail: volume(%v) entry(%v) err(%v)", v.name, name, err)
+	}
+}
+
+// holdLease acquires or renews the lease of replicating the volume, so that the volume is replicated
+// by a single lcnode. The lease is a flock on the queue directory which is renewed by the meta wrapper
+// and dropped by the meta partition if the lcnode stops renewing it.
+func (v *replicationVolume) holdLease(dirIno uint64) bool {
+	conflict, err := v.mw.SetLock_ll(dirIno, newReplicationLease(proto.FileLockWrite))
+	if err != nil {
+		log.LogWarnf("holdLease: lock queue fail: volume(%v) inode(%v) err(%v)", v.name, dirIno, err)
+		return false
+	}
+	if conflict != nil {
+		log.LogDebugf("holdLease: replicated by others: volume(%v) lease(%v)", v.name, conflict)
+		return false
+	}
+	v.leaseIno = dirIno
+	return true
+}
+
+func newReplicationLease(typ uint32) *proto.FileLock {
+	return &proto.FileLock{
+		Pid:   uint32(os.Getpid()),
+		Start: 0,
+		End:   proto.FileLockMaxOffset,
+		Type:  typ,
+		Flock: true,
+	}
+}
+
+func (v *replicationVolume) Close() {
+	if v.leaseIno != 0 {
+		if _, err := v.mw.SetLock_ll(v.leaseIno, newReplicationLease(proto.FileLockUnlock)); err != nil {
+			log.LogWarnf("Close: release lease fail: volume(%v) inode(%v) err(%v)", v.name, v.leaseIno, err)
+		}
+	}
+	if v.ec != nil {
+		v.ec.Close()
+	}
+	if v.mw != nil {
+		v.mw.Close()
+	}
+}
+
+// inodeReader reads the data of an inode at random offsets.
+type inodeReader struct {
+	ec  *stream.ExtentClient
+	ebs *blobstore.Reader
+	ino uint64
+}
+
+func (r *inodeReader) ReadAt(p []byte, off int64) (n int, err error) {
+	ctx := context.Background()
+	for n < len(p) {
+		var m int
+		if r.ebs != nil {
+			m, err = r.ebs.Read(ctx, p[n:], int(off)+n, len(p)-n)
+		} else {
+			m, err = r.ec.Read(r.ino, p[n:], int(off)+n, len(p)-n)
+		}
+		n += m
+		if err != nil && err != io.EOF {
+			return
+		}
+		if m == 0 || err == io.EOF {
+			break
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *inodeReader) Close() {
+	if r.ebs != nil {
+		r.ebs.Close(context.Background())
+	}
+	if err := r.ec.CloseStream(r.ino); err != nil {
+		log.LogWarnf("inodeReader: close stream fail: inode(%v) err(%v)", r.ino, err)
+	}
+}
+
+// replicationMeta is the system and user-defined metadata of an object sent to the destination.
+type replicationMeta struct {
+	contentType        *string
+	contentDisposition *string
+	cacheControl       *string
+	expires            *time.Time
+	metadata           map[string]*string
+	tagging            *string
+	// the destination encrypts the replica with its own keys
+	serverSideEncryption *string
+}
+
+func newReplicationMeta(xattr *proto.XAttrInfo) *replicationMeta {
+	m := &replicationMeta{metadata: make(map[string]*string)}
+	for key, value := range xattr.XAttrs {
+		if value == "" {
+			continue
+		}
+		switch key {
+		case XAttrKeyOSSMIME:
+			m.contentType = aws.String(value)
+		case XAttrKeyOSSDisposition:
+			m.contentDisposition = aws.String(value)
+		case XAttrKeyOSSCacheControl:
+			m.cacheControl = aws.String(value)
+		case XAttrKeyOSSExpires:
+			if expires, err := http.ParseTime(value); err == nil {
+				m.expires = aws.Time(expires)
+			}
+		case XAttrKeyOSSTagging:
+			m.tagging = aws.String(value)
+		default:
+			if !strings.HasPrefix(key, xattrKeyOSSPrefix) {
+				m.metadata[key] = aws.String(value)
+			}
+		}
+	}
+	return m
+}
+
+// objectACL is the ACL stored by objectnode in XAttrKeyOSSACL.
+type objectACL struct {
+	Owner struct {
+		Id string `json:"i"`
+	} `json:"o"`
+	Acl struct {
+		Grants []struct {
+			Grantee struct {
+				Type string `json:"t"`
+				Id   string `json:"i"`
+				URI  string `json:"u"`
+			} `json:"g"`
+			Permission string `json:"p"`
+		} `json:"gs"`
+	} `json:"a"`
+}
+
+func (acl *objectACL) toPolicy(owner *s3.Owner) *s3.AccessControlPolicy {
+	policy := &s3.AccessControlPolicy{Owner: owner}
+	for _, g := range acl.Acl.Grants {
+		grantee := &s3.Grantee{Type: aws.String(g.Grantee.Type)}
+		switch {
+		case g.Grantee.URI != "":
+			grantee.URI = aws.String(g.Grantee.URI)
+		case g.Grantee.Id == acl.Owner.Id && owner != nil:
+			grantee.ID = owner.ID
+		default:
+			grantee.ID = aws.String(g.Grantee.Id)
+		}
+		policy.Grants = append(policy.Grants, &s3.Grant{Grantee: grantee, Permission: aws.String(g.Permission)})
+	}
+	return policy
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/sse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReplicationTargets(t *testing.T) {
+	targets, err := parseReplicationTargets(config.LoadConfigString(`{}`))
+	require.NoError(t, err)
+	require.Empty(t, targets)
+
+	targets, err = parseReplicationTargets(config.LoadConfigString(`{"replicationTargets": [
+		{"bucket": "backup", "endpoint": "http://127.0.0.1:17410", "accessKey": "ak", "secretKey": "sk"},
+		{"bucket": "archive", "endpoint": "http://127.0.0.1:17411", "region": "dc2"}]}`))
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, replicationDefaultRegion, targets["backup"].Region)
+	require.Equal(t, "ak", targets["backup"].AccessKey)
+	require.Equal(t, "dc2", targets["archive"].Region)
+
+	_, err = parseReplicationTargets(config.LoadConfigString(`{"replicationTargets": [{"bucket": "backup"}]}`))
+	require.Error(t, err)
+	_, err = parseReplicationTargets(config.LoadConfigString(`{"replicationTargets": [
+		{"bucket": "backup", "endpoint": "a"}, {"bucket": "backup", "endpoint": "b"}]}`))
+	require.Error(t, err)
+	_, err = parseReplicationTargets(config.LoadConfigString(`{"replicationTargets": "backup"}`))
+	require.Error(t, err)
+}
+
+func TestReplicationMeta(t *testing.T) {
+	meta := newReplicationMeta(&proto.XAttrInfo{XAttrs: map[string]string{
+		XAttrKeyOSSMIME:              "text/plain",
+		XAttrKeyOSSExpires:           "Wed, 21 Oct 2015 07:28:00 GMT",
+		XAttrKeyOSSTagging:           "k1=v1&k2=v2",
+		XAttrKeyOSSReplicationStatus: "PENDING",
+		"oss:etag":                   "d41d8cd98f00b204e9800998ecf8427e",
+		"owner":                      "alice",
+	}})
+	require.Equal(t, "text/plain", aws.StringValue(meta.contentType))
+	require.Nil(t, meta.contentDisposition)
+	require.Equal(t, int64(1445412480), meta.expires.Unix())
+	require.Equal(t, "k1=v1&k2=v2", aws.StringValue(meta.tagging))
+	require.Len(t, meta.metadata, 1)
+	require.Equal(t, "alice", aws.StringValue(meta.metadata["owner"]))
+}
+
+func TestReplicationACL(t *testing.T) {
+	raw := `{"o":{"i":"src"},"a":{"gs":[
+		{"g":{"t":"CanonicalUser","i":"src"},"p":"FULL_CONTROL"},
+		{"g":{"t":"CanonicalUser","i":"bob"},"p":"READ"},
+		{"g":{"t":"Group","u":"http://acs.amazonaws.com/groups/global/AllUsers"},"p":"READ"}]}}`
+	acl := &objectACL{}
+	require.NoError(t, json.Unmarshal([]byte(raw), acl))
+
+	policy := acl.toPolicy(&s3.Owner{ID: aws.String("dst")})
+	require.Equal(t, "dst", aws.StringValue(policy.Owner.ID))
+	require.Len(t, policy.Grants, 3)
+	require.Equal(t, "dst", aws.StringValue(policy.Grants[0].Grantee.ID))
+	require.Equal(t, "bob", aws.StringValue(policy.Grants[1].Grantee.ID))
+	require.Nil(t, policy.Grants[2].Grantee.ID)
+	require.Equal(t, "READ", aws.StringValue(policy.Grants[2].Permission))
+}
+
+func TestReplicationLease(t *testing.T) {
+	held, other := newReplicationLease(proto.FileLockWrite), newReplicationLease(proto.FileLockWrite)
+	held.ClientID, other.ClientID = 1, 2
+	// the whole queue is locked by a single lcnode
+	require.True(t, other.Conflict(held))
+	other.ClientID = held.ClientID
+	require.False(t, other.Conflict(held))
+	require.Equal(t, proto.FileLockUnlock, newReplicationLease(proto.FileLockUnlock).Type)
+}
+
+func TestReplicationObjectCipher(t *testing.T) {
+	masterKey := make([]byte, sse.KeySize)
+	_, err := io.ReadFull(rand.Reader, masterKey)
+	require.NoError(t, err)
+	ks, err := sse.NewLocalKeyStore(&sse.LocalKeyStoreConfig{
+		Current: "k1",
+		Keys:    map[string]string{"k1": base64.StdEncoding.EncodeToString(masterKey)},
+	})
+	require.NoError(t, err)
+
+	src := &sse.Cipher{Key: make([]byte, sse.KeySize), IV: make([]byte, aes.BlockSize)}
+	_, err = io.ReadFull(rand.Reader, src.Key)
+	require.NoError(t, err)
+	sealed, err := ks.Seal(src.Key)
+	require.NoError(t, err)
+	plaintext := []byte("replicated object data")
+	ciphertext, err := io.ReadAll(src.EncryptReader(bytes.NewReader(plaintext), sse.Part{}))
+	require.NoError(t, err)
+	xattr := &proto.XAttrInfo{XAttrs: map[string]string{
+		XAttrKeyOSSSSEType: sseTypeS3,
+		XAttrKeyOSSSSEKey:  sealed,
+		XAttrKeyOSSSSEIV:   base64.StdEncoding.EncodeToString(src.IV),
+	}}
+
+	// the objects stay queued until the master keys are configured
+	r := &Replicator{l: &LcNode{}}
+	_, err = r.objectCipher(sseTypeS3, xattr)
+	require.Error(t, err)
+
+	r.l.sseKeyStore = ks
+	_, err = r.objectCipher("SSE-C", xattr)
+	require.Error(t, err)
+	c, err := r.objectCipher(sseTypeS3, xattr)
+	require.NoError(t, err)
+	decrypted := make([]byte, len(plaintext))
+	_, err = c.DecryptReaderAt(bytes.NewReader(ciphertext)).ReadAt(decrypted, 0)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+}
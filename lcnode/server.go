@@ -26,12 +26,14 @@ import (
 
 	"github.com/cubefs/cubefs/cmd/common"
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/sse"
 	"golang.org/x/time/rate"
 )
 
@@ -48,6 +50,13 @@ type LcNode struct {
 	control          common.Control
 	lcScanners       map[string]*LcScanner
 	snapshotScanners map[string]*SnapshotScanner
+	logDir           string
+	ebsMutex         sync.Mutex
+	ebsClient        *blobstore.BlobStoreClient
+
+	replicationTargets  map[string]*ReplicationTarget
+	replicationInterval int64
+	sseKeyStore         sse.MasterKeyStore
 }
 
 func NewServer() *LcNode {
@@ -87,6 +96,13 @@ func doStart(s common.Server, cfg *config.Config) (err error) {
 	if err = l.startServer(); err != nil {
 		return
 	}
+	if len(l.replicationTargets) > 0 {
+		var replicator *Replicator
+		if replicator, err = newReplicator(l, l.replicationTargets, time.Duration(l.replicationInterval)*time.Second); err != nil {
+			return
+		}
+		go replicator.run()
+	}
 
 	exporter.Init(ModuleName, cfg)
 	exporter.RegistConsul(l.clusterID, ModuleName, cfg)
@@ -124,6 +140,7 @@ func (l *LcNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogInfof("loadConfig: setup config: %v(%v)", configMasterAddr, strings.Join(masters, ","))
 	l.masters = masters
 	l.mc = master.NewMasterClient(masters, false)
+	l.logDir = cfg.GetString(configLogDir)
 
 	// parse batchExpirationGetNum
 	begns := cfg.GetString(configBatchExpirationGetNumStr)
@@ -210,6 +227,32 @@ func (l *LcNode) parseConfig(cfg *config.Config) (err error) {
 	}
 	log.LogInfof("loadConfig: setup config: %v(%v)", configLcNodeTaskCountLimit, lcNodeTaskCountLimit)
 
+	// parse replication targets
+	if l.replicationTargets, err = parseReplicationTargets(cfg); err != nil {
+		return
+	}
+	log.LogInfof("loadConfig: setup config: %v(%v)", configReplicationTargets, len(l.replicationTargets))
+
+	// parse replicationInterval
+	ris := cfg.GetString(configReplicationIntervalStr)
+	if ris != "" {
+		if l.replicationInterval, err = strconv.ParseInt(ris, 10, 64); err != nil {
+			return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+		}
+	}
+	if l.replicationInterval <= 0 {
+		l.replicationInterval = defaultReplicationInterval
+	}
+	log.LogInfof("loadConfig: setup config: %v(%v)", configReplicationIntervalStr, l.replicationInterval)
+
+	// parse the master key file shared with objectnode, it is required to replicate SSE-S3 objects
+	if keyFile := cfg.GetString(configSSEMasterKeyFile); keyFile != "" {
+		if l.sseKeyStore, err = sse.LoadLocalKeyStore(keyFile); err != nil {
+			return fmt.Errorf("%v,err:load sse master key file(%v) fail: %v", proto.ErrInvalidCfg, keyFile, err)
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSSEMasterKeyFile, keyFile)
+	}
+
 	return
 }
 
@@ -16,6 +16,7 @@ package lcnode
 
 import (
 	"os"
+	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -127,24 +128,64 @@ func (s *SnapshotScanner) Start() {
 	t := time.Now()
 	response.StartTime = &t
 
+	firstDentry, err := s.getFirstDentry()
+	if err != nil {
+		log.LogErrorf("snapshot startScan(%v): prefix(%v) lookup err(%v)", s.ID, s.verDelReq.Task.Prefix, err)
+		atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+	}
+
 	// 1. delete all files
 	log.LogInfof("snapshot startScan(%v): first round files start!", s.ID)
 	s.scanType = SnapScanTypeOnlyFile
 	go s.scan()
-	firstDentry := &proto.ScanDentry{
-		Inode: proto.RootIno,
-		Type:  proto.Mode(os.ModeDir),
+	if firstDentry != nil {
+		s.firstIn(firstDentry)
 	}
-	s.firstIn(firstDentry)
 	s.checkScanning(false)
 
 	// 2. delete all dirs
 	log.LogInfof("snapshot startScan(%v): second round dirs start!", s.ID)
 	s.scanType = SnapScanTypeOnlyDirAndDepth
-	s.firstIn(firstDentry)
+	if firstDentry != nil {
+		s.firstIn(firstDentry)
+	}
 	s.checkScanning(true)
 }
 
+// getFirstDentry returns the dentry to start scanning, the root or the directory of the prefix of the task.
+// It returns nil if the directory does not exist, so there is nothing to delete.
+func (s *SnapshotScanner) getFirstDentry() (*proto.ScanDentry, error) {
+	dentry := &proto.ScanDentry{
+		Inode: proto.RootIno,
+		Type:  proto.Mode(os.ModeDir),
+	}
+	prefix := strings.Trim(s.verDelReq.Task.Prefix, "/")
+	if prefix == "" {
+		return dentry, nil
+	}
+
+	for _, name := range strings.Split(prefix, "/") {
+		ino, mode, err := s.mw.Lookup_ll(dentry.Inode, name)
+		if err == syscall.ENOENT {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !proto.IsDir(mode) {
+			return nil, nil
+		}
+		dentry = &proto.ScanDentry{
+			ParentId: dentry.Inode,
+			Name:     name,
+			Inode:    ino,
+			Type:     mode,
+		}
+	}
+	dentry.Path = prefix
+	return dentry, nil
+}
+
 func (s *SnapshotScanner) firstIn(d *proto.ScanDentry) {
 	select {
 	case <-s.stopC:
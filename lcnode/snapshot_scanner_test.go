@@ -15,6 +15,8 @@
 package lcnode
 
 import (
+	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -51,3 +53,49 @@ func TestSnapshotScanner(t *testing.T) {
 	time.Sleep(time.Second * 5)
 	require.Equal(t, true, scanner.DoneScanning())
 }
+
+type lookupMetaWrapper struct {
+	MockMetaWrapper
+	dentries map[uint64]map[string]proto.Dentry
+}
+
+func (mw *lookupMetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	d, ok := mw.dentries[parentID][name]
+	if !ok {
+		return 0, 0, syscall.ENOENT
+	}
+	return d.Inode, d.Type, nil
+}
+
+func TestSnapshotScannerPrefix(t *testing.T) {
+	mw := &lookupMetaWrapper{dentries: map[uint64]map[string]proto.Dentry{
+		proto.RootIno: {"a": {Inode: 10, Type: proto.Mode(os.ModeDir)}},
+		10:            {"b": {Inode: 11, Type: proto.Mode(os.ModeDir)}, "f": {Inode: 12}},
+	}}
+	scanner := &SnapshotScanner{
+		mw: mw,
+		verDelReq: &proto.SnapshotVerDelTaskRequest{
+			Task: &proto.SnapshotVerDelTask{},
+		},
+	}
+
+	dentry, err := scanner.getFirstDentry()
+	require.NoError(t, err)
+	require.Equal(t, proto.RootIno, dentry.Inode)
+
+	scanner.verDelReq.Task.Prefix = "/a/b"
+	dentry, err = scanner.getFirstDentry()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), dentry.ParentId)
+	require.Equal(t, uint64(11), dentry.Inode)
+	require.Equal(t, "b", dentry.Name)
+	require.Equal(t, "a/b", dentry.Path)
+
+	// nothing to delete if the directory does not exist
+	for _, prefix := range []string{"a/c", "a/f", "a/f/g"} {
+		scanner.verDelReq.Task.Prefix = prefix
+		dentry, err = scanner.getFirstDentry()
+		require.NoError(t, err)
+		require.Nil(t, dentry)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The data of a transitioned object is kept at "/<hot volume>/<hot inode>" of the cold volume,
+// objectnode reads it from there once XAttrKeyTransition is set on the hot inode.
+// The hot data is released in a later round of scanning: objectnode caches xattrs for a while,
+// and it must see XAttrKeyTransition before the hot data disappears.
+const (
+	XAttrKeyTransition       = proto.XAttrKeyTransition
+	XAttrKeyTransitionState  = proto.XAttrKeyTransitionState
+	transitionStateReleasing = proto.TransitionStateReleasing
+	transitionStateReleased  = proto.TransitionStateReleased
+	transitionDirMode        = 0o755 | os.ModeDir
+	transitionFileMode       = 0o644
+	transitionWriteThreads   = 4
+	transitionReadThreads    = 4
+	ebsMaxSizePutOnce        = int64(1) << 23
+)
+
+type transitioner struct {
+	volume     string
+	coldVolume string
+	coldView   *proto.SimpleVolView
+	mw         *meta.MetaWrapper
+	ec         *stream.ExtentClient
+	coldMw     *meta.MetaWrapper
+	coldEc     *stream.ExtentClient
+	ebsc       *blobstore.BlobStoreClient
+	dirIno     uint64
+}
+
+func newTransitioner(l *LcNode, volume string, mw *meta.MetaWrapper, coldVolume string) (t *transitioner, err error) {
+	t = &transitioner{
+		volume:     volume,
+		coldVolume: coldVolume,
+		mw:         mw,
+	}
+	defer func() {
+		if err != nil {
+			t.Close()
+		}
+	}()
+
+	if t.coldView, err = l.mc.AdminAPI().GetVolumeSimpleInfo(coldVolume); err != nil {
+		return
+	}
+	if !proto.IsCold(t.coldView.VolType) {
+		err = fmt.Errorf("transition target volume(%v) is not a cold volume", coldVolume)
+		return
+	}
+	if t.ebsc, err = l.getEbsClient(); err != nil {
+		return
+	}
+	if t.ec, err = newExtentClient(volume, l.masters, mw); err != nil {
+		return
+	}
+	if t.coldMw, err = meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        coldVolume,
+		Masters:       l.masters,
+		Authenticate:  false,
+		ValidateOwner: false,
+	}); err != nil {
+		return
+	}
+	if t.coldEc, err = newExtentClient(coldVolume, l.masters, t.coldMw); err != nil {
+		return
+	}
+	t.dirIno, err = t.makeDir()
+	return
+}
+
+func newExtentClient(volume string, masters []string, mw *meta.MetaWrapper) (*stream.ExtentClient, error) {
+	return stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            volume,
+		Masters:           masters,
+		FollowerRead:      true,
+		OnAppendExtentKey: mw.AppendExtentKey,
+		OnSplitExtentKey:  mw.SplitExtentKey,
+		OnGetExtents:      mw.GetExtents,
+		OnTruncate:        mw.Truncate,
+	})
+}
+
+func (l *LcNode) getEbsClient() (*blobstore.BlobStoreClient, error) {
+	l.ebsMutex.Lock()
+	defer l.ebsMutex.Unlock()
+	if l.ebsClient != nil {
+		return l.ebsClient, nil
+	}
+	ci, err := l.mc.AdminAPI().GetClusterInfo()
+	if err != nil {
+		return nil, err
+	}
+	if l.ebsClient, err = blobstore.NewEbsClient(access.Config{
+		ConnMode: access.NoLimitConnMode,
+		Consul: access.ConsulConfig{
+			Address: ci.EbsAddr,
+		},
+		MaxSizePutOnce: ebsMaxSizePutOnce,
+		Logger: &access.Logger{
+			Filename: path.Join(l.logDir, "ebs.log"),
+		},
+	}); err != nil {
+		return nil, err
+	}
+	return l.ebsClient, nil
+}
+
+// makeDir returns the directory in the cold volume which keeps the data of the hot volume.
+func (t *transitioner) makeDir() (uint64, error) {
+	ino, _, err := t.coldMw.Lookup_ll(proto.RootIno, t.volume)
+	if err == nil {
+		return ino, nil
+	}
+	if err != syscall.ENOENT {
+		return 0, err
+	}
+	info, err := t.coldMw.Create_ll(proto.RootIno, t.volume, uint32(transitionDirMode), 0, 0, nil, pathSep+t.volume)
+	if err == syscall.EEXIST {
+		ino, _, err = t.coldMw.Lookup_ll(proto.RootIno, t.volume)
+		return ino, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Inode, nil
+}
+
+// transit moves the data of the inode into the cold volume in two rounds of scanning,
+// it returns true when the data is copied.
+func (t *transitioner) transit(dentry *proto.ScanDentry, info *proto.InodeInfo, xattr *proto.XAttrInfo) (bool, error) {
+	var state string
+	if xattr != nil {
+		state = string(xattr.Get(XAttrKeyTransitionState))
+	}
+	switch state {
+	case "":
+		return true, t.copy(dentry, info)
+	case transitionStateReleasing:
+		// interrupted in the last round, the data in cold volume is complete
+		return false, t.release(dentry, info, true)
+	case transitionStateReleased:
+		return false, nil
+	}
+	if gen, err := strconv.ParseUint(state, 10, 64); err != nil || gen != info.Generation {
+		// the object is modified after copied, it is copied again in the next round
+		log.LogWarnf("transit: inode modified after copied: volume(%v) path(%v) inode(%v) state(%v) gen(%v)",
+			t.volume, dentry.Path, info.Inode, state, info.Generation)
+		return false, t.clearTransition(info.Inode)
+	}
+	return false, t.release(dentry, info, false)
+}
+
+func (t *transitioner) clearTransition(ino uint64) error {
+	if err := t.mw.XAttrDel_ll(ino, XAttrKeyTransition); err != nil {
+		return err
+	}
+	return t.mw.XAttrDel_ll(ino, XAttrKeyTransitionState)
+}
+
+func (t *transitioner) copy(dentry *proto.ScanDentry, info *proto.InodeInfo) (err error) {
+	name := strconv.FormatUint(info.Inode, 10)
+	fullPath := path.Join(pathSep, t.volume, name)
+
+	var coldInfo *proto.InodeInfo
+	coldInfo, err = t.coldMw.Create_ll(t.dirIno, name, transitionFileMode, 0, 0, nil, fullPath)
+	if err == syscall.EEXIST {
+		// left by an interrupted transition
+		if err = t.removeCold(name); err != nil {
+			return
+		}
+		coldInfo, err = t.coldMw.Create_ll(t.dirIno, name, transitionFileMode, 0, 0, nil, fullPath)
+	}
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			if removeErr := t.removeCold(name); removeErr != nil {
+				log.LogWarnf("copy: remove cold file fail: volume(%v) coldVolume(%v) path(%v) err(%v)",
+					t.volume, t.coldVolume, fullPath, removeErr)
+			}
+		}
+	}()
+
+	if err = t.copyData(info, coldInfo.Inode); err != nil {
+		return
+	}
+
+	// make sure the object is not modified during copying
+	var current *proto.InodeInfo
+	if current, err = t.mw.InodeGet_ll(info.Inode); err != nil {
+		return
+	}
+	if current.Generation != info.Generation || current.Size != info.Size {
+		return fmt.Errorf("inode(%v) modified during transition", info.Inode)
+	}
+	return t.mw.BatchSetXAttr_ll(info.Inode, map[string]string{
+		XAttrKeyTransition:      t.coldVolume,
+		XAttrKeyTransitionState: strconv.FormatUint(info.Generation, 10),
+	})
+}
+
+func (t *transitioner) copyData(info *proto.InodeInfo, coldIno uint64) (err error) {
+	if err = t.ec.OpenStream(info.Inode); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := t.ec.CloseStream(info.Inode); closeErr != nil {
+			log.LogWarnf("copyData: close stream fail: volume(%v) inode(%v) err(%v)", t.volume, info.Inode, closeErr)
+		}
+	}()
+	if err = t.coldEc.OpenStream(coldIno); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := t.coldEc.CloseStream(coldIno); closeErr != nil {
+			log.LogWarnf("copyData: close stream fail: volume(%v) inode(%v) err(%v)", t.coldVolume, coldIno, closeErr)
+		}
+	}()
+
+	writer := blobstore.NewWriter(blobstore.ClientConfig{
+		VolName:         t.coldVolume,
+		VolType:         t.coldView.VolType,
+		Ino:             coldIno,
+		BlockSize:       t.coldView.ObjBlockSize,
+		Mw:              t.coldMw,
+		Ec:              t.coldEc,
+		Ebsc:            t.ebsc,
+		WConcurrency:    transitionWriteThreads,
+		ReadConcurrency: transitionReadThreads,
+		CacheAction:     t.coldView.CacheAction,
+		CacheThreshold:  t.coldView.CacheThreshold,
+	})
+	defer writer.FreeCache()
+
+	ctx := context.Background()
+	buf := make([]byte, 2*util.BlockSize)
+	var offset int
+	for offset < int(info.Size) {
+		size := len(buf)
+		if rest := int(info.Size) - offset; rest < size {
+			size = rest
+		}
+		n, readErr := t.ec.Read(info.Inode, buf, offset, size)
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if n > 0 {
+			if _, err = writer.WriteWithoutPool(ctx, offset, buf[:n]); err != nil {
+				return
+			}
+			offset += n
+		}
+		if n == 0 || readErr == io.EOF {
+			break
+		}
+	}
+	if offset != int(info.Size) {
+		return fmt.Errorf("inode(%v) copied size(%v) mismatch size(%v)", info.Inode, offset, info.Size)
+	}
+	return writer.FlushWithoutPool(coldIno, ctx)
+}
+
+// release frees the extents of the hot inode and keeps its size and modify time.
+// Clients read the hot inode through the extent client which fails with ENODATA once the
+// state is releasing, the inode is checked again after that to catch the writes since copied.
+// The extents are freed by a hole punched on the checked generation, so the writes landing
+// after the check fail the release instead of being wiped.
+func (t *transitioner) release(dentry *proto.ScanDentry, info *proto.InodeInfo, resumed bool) (err error) {
+	var coldIno uint64
+	if coldIno, _, err = t.coldMw.Lookup_ll(t.dirIno, strconv.FormatUint(info.Inode, 10)); err != nil {
+		return
+	}
+	var coldInfo *proto.InodeInfo
+	if coldInfo, err = t.coldMw.InodeGet_ll(coldIno); err != nil {
+		return
+	}
+
+	if err = t.mw.XAttrSet_ll(info.Inode, []byte(XAttrKeyTransitionState), []byte(transitionStateReleasing)); err != nil {
+		return
+	}
+	var current *proto.InodeInfo
+	if current, err = t.mw.InodeGet_ll(info.Inode); err != nil {
+		return
+	}
+	if !releasable(info, current, coldInfo, resumed) {
+		log.LogWarnf("release: inode modified after copied: volume(%v) path(%v) inode(%v) gen(%v) size(%v) current gen(%v) size(%v) cold size(%v)",
+			t.volume, dentry.Path, info.Inode, info.Generation, info.Size, current.Generation, current.Size, coldInfo.Size)
+		if err = t.clearTransition(info.Inode); err != nil {
+			return
+		}
+		return fmt.Errorf("inode(%v) modified after transition copied", info.Inode)
+	}
+	if err = t.mw.PunchHoleWithCond(info.Inode, 0, current.Size, current.Generation); err != nil {
+		if err != syscall.EINVAL {
+			return
+		}
+		log.LogWarnf("release: inode modified while releasing: volume(%v) path(%v) inode(%v) gen(%v)",
+			t.volume, dentry.Path, info.Inode, current.Generation)
+		if err = t.clearTransition(info.Inode); err != nil {
+			return
+		}
+		return fmt.Errorf("inode(%v) modified while releasing", info.Inode)
+	}
+	if err = t.mw.Setattr(info.Inode, proto.AttrModifyTime, 0, 0, 0, 0, info.ModifyTime.Unix()); err != nil {
+		log.LogWarnf("release: restore modify time fail: volume(%v) path(%v) inode(%v) err(%v)",
+			t.volume, dentry.Path, info.Inode, err)
+	}
+	return t.mw.XAttrSet_ll(info.Inode, []byte(XAttrKeyTransitionState), []byte(transitionStateReleased))
+}
+
+// releasable returns true if the hot inode is not modified since its data is copied to cold volume.
+// The generation of an interrupted release is bumped by the punched hole, which keeps the size.
+func releasable(info, current, coldInfo *proto.InodeInfo, resumed bool) bool {
+	if resumed {
+		return current.Size == coldInfo.Size
+	}
+	return current.Generation == info.Generation && current.Size == info.Size && current.Size == coldInfo.Size
+}
+
+func (t *transitioner) removeCold(name string) error {
+	fullPath := path.Join(pathSep, t.volume, name)
+	info, err := t.coldMw.Delete_ll(t.dirIno, name, false, fullPath)
+	if err != nil {
+		return err
+	}
+	if info != nil {
+		return t.coldMw.Evict(info.Inode, fullPath)
+	}
+	return nil
+}
+
+// cleanOrphans removes the cold data whose hot inode has been deleted,
+// inode numbers are never reused so a missing inode will not come back.
+func (t *transitioner) cleanOrphans() {
+	marker := ""
+	for {
+		children, err := t.coldMw.ReadDirLimit_ll(t.dirIno, marker, uint64(defaultReadDirLimit))
+		if err != nil {
+			if err != syscall.ENOENT {
+				log.LogErrorf("cleanOrphans: read dir fail: coldVolume(%v) dir(%v) err(%v)", t.coldVolume, t.volume, err)
+			}
+			return
+		}
+		if marker != "" && len(children) > 0 && children[0].Name == marker {
+			children = children[1:]
+		}
+		if len(children) == 0 {
+			return
+		}
+
+		names := make(map[uint64]string, len(children))
+		inodes := make([]uint64, 0, len(children))
+		for _, child := range children {
+			ino, err := strconv.ParseUint(child.Name, 10, 64)
+			if err != nil {
+				continue
+			}
+			names[ino] = child.Name
+			inodes = append(inodes, ino)
+		}
+		for _, info := range t.mw.BatchInodeGet(inodes) {
+			delete(names, info.Inode)
+		}
+		for ino, name := range names {
+			// batch get skips the failed partitions, confirm before removing the data
+			if _, err = t.mw.InodeGet_ll(ino); err != syscall.ENOENT {
+				continue
+			}
+			if err = t.removeCold(name); err != nil {
+				log.LogWarnf("cleanOrphans: remove orphan fail: volume(%v) coldVolume(%v) inode(%v) err(%v)",
+					t.volume, t.coldVolume, ino, err)
+				continue
+			}
+			log.LogInfof("cleanOrphans: remove orphan: volume(%v) coldVolume(%v) inode(%v)", t.volume, t.coldVolume, ino)
+		}
+
+		if len(children) < defaultReadDirLimit-1 {
+			return
+		}
+		marker = children[len(children)-1].Name
+	}
+}
+
+func (t *transitioner) Close() {
+	if t.ec != nil {
+		t.ec.Close()
+	}
+	if t.coldEc != nil {
+		t.coldEc.Close()
+	}
+	if t.coldMw != nil {
+		t.coldMw.Close()
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionReleasable(t *testing.T) {
+	info := &proto.InodeInfo{Inode: 1, Generation: 3, Size: 4096}
+	cold := &proto.InodeInfo{Inode: 2, Size: 4096}
+
+	require.True(t, releasable(info, &proto.InodeInfo{Generation: 3, Size: 4096}, cold, false))
+	// written or truncated after copied
+	require.False(t, releasable(info, &proto.InodeInfo{Generation: 4, Size: 4096}, cold, false))
+	require.False(t, releasable(info, &proto.InodeInfo{Generation: 3, Size: 8192}, cold, false))
+	// the cold data is incomplete
+	require.False(t, releasable(info, &proto.InodeInfo{Generation: 3, Size: 4096}, &proto.InodeInfo{Size: 100}, false))
+
+	// an interrupted release keeps the cold size
+	require.True(t, releasable(info, &proto.InodeInfo{Generation: 4, Size: 4096}, cold, true))
+	require.False(t, releasable(info, &proto.InodeInfo{Generation: 4, Size: 0}, cold, true))
+	require.False(t, releasable(info, &proto.InodeInfo{Generation: 4, Size: 100}, cold, true))
+}
@@ -0,0 +1,445 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Objectnode keeps the noncurrent versions and delete markers of objects in the version directory
+// of their keys under ossVersionsDirName, the layout is the same as objectnode/fs_volume_version.go:
+//
+//	/.oss_versions/<hex chunk 1>/~<hex chunk 2>/<version entry>
+//
+// The expiration of a current object in a versioned bucket keeps it as a noncurrent version and puts
+// a delete marker, like objectnode deletes an object without a version ID. The noncurrent versions are
+// only removed by the NoncurrentVersionExpiration action.
+const (
+	XAttrKeyOSSVersioning   = "oss:versioning"
+	XAttrKeyOSSVersionId    = "oss:version-id"
+	XAttrKeyOSSDeleteMarker = "oss:delete-marker"
+
+	ossVersioningEnabled   = "Enabled"
+	ossVersioningSuspended = "Suspended"
+	ossNullVersionId       = "null"
+	ossDefaultFileMode     = 0o644
+	ossDefaultDirMode      = ossDefaultFileMode | os.ModeDir
+
+	versionSortKeyWidth   = 16
+	versionKeyChunkLen    = 254
+	versionKeyChunkPrefix = "~"
+)
+
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// loadVersioning returns the versioning status of the bucket, it is empty if versioning has never been enabled.
+func (s *LcScanner) loadVersioning() (status string, err error) {
+	var info *proto.XAttrInfo
+	if info, err = s.mw.XAttrGet_ll(proto.RootIno, XAttrKeyOSSVersioning); err != nil || info == nil {
+		return
+	}
+	raw := info.Get(XAttrKeyOSSVersioning)
+	if len(raw) == 0 {
+		return
+	}
+	config := &versioningConfiguration{}
+	if err = xml.Unmarshal(raw, config); err != nil {
+		return
+	}
+	if config.Status == ossVersioningEnabled || config.Status == ossVersioningSuspended {
+		status = config.Status
+	}
+	return
+}
+
+func newVersionId(ts time.Time, inode uint64) string {
+	return versionSortKey(ts) + fmt.Sprintf("%016x", inode)
+}
+
+func versionSortKey(ts time.Time) string {
+	return fmt.Sprintf("%016x", uint64(math.MaxInt64-ts.UnixNano()))
+}
+
+func versionEntryName(versionId string, modifyTime time.Time) string {
+	if versionId == "" || versionId == ossNullVersionId {
+		return versionSortKey(modifyTime) + ossNullVersionId
+	}
+	return versionId
+}
+
+// versionEntryTime returns the creation time of the version kept in the entry, it is zero if the name is invalid.
+func versionEntryTime(name string) time.Time {
+	if len(name) < versionSortKeyWidth {
+		return time.Time{}
+	}
+	key, err := strconv.ParseUint(name[:versionSortKeyWidth], 16, 64)
+	if err != nil || key > math.MaxInt64 {
+		return time.Time{}
+	}
+	return time.Unix(0, math.MaxInt64-int64(key))
+}
+
+func versionKeyDirNames(key string) []string {
+	encoded := hex.EncodeToString([]byte(key))
+	names := make([]string, 0, len(encoded)/versionKeyChunkLen+1)
+	for start := 0; start < len(encoded); start += versionKeyChunkLen {
+		end := start + versionKeyChunkLen
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		name := encoded[start:end]
+		if start > 0 {
+			name = versionKeyChunkPrefix + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func versionStorePath(key, entryName string) string {
+	dirs := append([]string{ossVersionsDirName}, versionKeyDirNames(key)...)
+	return strings.Join(append(dirs, entryName), pathSep)
+}
+
+// versionKeyDir looks up the version directory of the key, the missing directories are created if autoCreate is set.
+func (s *LcScanner) versionKeyDir(key string, autoCreate bool) (dir uint64, err error) {
+	dirs := append([]string{ossVersionsDirName}, versionKeyDirNames(key)...)
+	dir = proto.RootIno
+	for i, name := range dirs {
+		ino, mode, lookupErr := s.mw.Lookup_ll(dir, name)
+		if lookupErr == syscall.ENOENT && autoCreate {
+			var info *proto.InodeInfo
+			dirPath := strings.Join(dirs[:i+1], pathSep)
+			info, lookupErr = s.mw.Create_ll(dir, name, uint32(ossDefaultDirMode), 0, 0, nil, dirPath)
+			if lookupErr == nil {
+				dir = info.Inode
+				continue
+			}
+			if lookupErr == syscall.EEXIST {
+				ino, mode, lookupErr = s.mw.Lookup_ll(dir, name)
+			}
+		}
+		if lookupErr != nil {
+			return 0, lookupErr
+		}
+		if !os.FileMode(mode).IsDir() {
+			return 0, syscall.ENOTDIR
+		}
+		dir = ino
+	}
+	return
+}
+
+// readDirAll lists all the children of the directory in name order.
+func (s *LcScanner) readDirAll(dir uint64) (children []proto.Dentry, err error) {
+	var from string
+	for {
+		var batch []proto.Dentry
+		if batch, err = s.mw.ReadDirLimit_ll(dir, from, uint64(defaultReadDirLimit)); err != nil {
+			return
+		}
+		n := len(batch)
+		if from != "" && n > 0 && batch[0].Name == from {
+			batch = batch[1:]
+		}
+		children = append(children, batch...)
+		if n < defaultReadDirLimit || len(children) == 0 {
+			return
+		}
+		from = children[len(children)-1].Name
+	}
+}
+
+// expireVersionedObject expires the current object of a versioned bucket. The object is linked into the
+// version directory before its dentry is removed, and a delete marker becomes the latest version. The null
+// version is replaced instead of kept while versioning is suspended.
+func (s *LcScanner) expireVersionedObject(dentry *proto.ScanDentry, info *proto.InodeInfo, xattr *proto.XAttrInfo) (err error) {
+	versionId := ossNullVersionId
+	if xattr != nil {
+		if id := string(xattr.Get(XAttrKeyOSSVersionId)); id != "" {
+			versionId = id
+		}
+	}
+	var keyDir uint64
+	if keyDir, err = s.versionKeyDir(dentry.Path, true); err != nil {
+		return
+	}
+	if versionId == ossNullVersionId || s.versioning == ossVersioningSuspended {
+		s.removeNullVersion(dentry.Path, keyDir)
+	}
+
+	if versionId == ossNullVersionId && s.versioning == ossVersioningSuspended {
+		if _, err = s.mw.DeleteWithCond_ll(dentry.ParentId, dentry.Inode, dentry.Name, false, dentry.Path); err != nil {
+			return
+		}
+		if err = s.mw.Evict(dentry.Inode, dentry.Path); err != nil {
+			log.LogWarnf("expireVersionedObject: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+				s.Volume, dentry.Path, dentry.Inode, err)
+		}
+	} else {
+		entryName := versionEntryName(versionId, info.ModifyTime)
+		storePath := versionStorePath(dentry.Path, entryName)
+		if _, err = s.mw.Link(keyDir, entryName, dentry.Inode, storePath); err != nil {
+			return
+		}
+		if _, err = s.mw.DeleteWithCond_ll(dentry.ParentId, dentry.Inode, dentry.Name, false, dentry.Path); err != nil {
+			// the object has been overwritten or deleted, which archives the version by objectnode itself
+			if _, unlinkErr := s.mw.DeleteWithCond_ll(keyDir, dentry.Inode, entryName, false, storePath); unlinkErr != nil {
+				log.LogWarnf("expireVersionedObject: unlink version fail: volume(%v) path(%v) inode(%v) err(%v)",
+					s.Volume, storePath, dentry.Inode, unlinkErr)
+			}
+			return
+		}
+	}
+	return s.putDeleteMarker(dentry.Path, keyDir)
+}
+
+// removeNullVersion deletes the noncurrent null version of the key if there is one.
+func (s *LcScanner) removeNullVersion(key string, keyDir uint64) {
+	children, err := s.readDirAll(keyDir)
+	if err != nil {
+		return
+	}
+	for _, child := range children {
+		if strings.HasPrefix(child.Name, versionKeyChunkPrefix) || !strings.HasSuffix(child.Name, ossNullVersionId) {
+			continue
+		}
+		storePath := versionStorePath(key, child.Name)
+		if _, err = s.mw.DeleteWithCond_ll(keyDir, child.Inode, child.Name, false, storePath); err != nil {
+			log.LogWarnf("removeNullVersion: delete null version fail: volume(%v) path(%v) inode(%v) err(%v)",
+				s.Volume, storePath, child.Inode, err)
+			return
+		}
+		if err = s.mw.Evict(child.Inode, storePath); err != nil {
+			log.LogWarnf("removeNullVersion: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+				s.Volume, storePath, child.Inode, err)
+		}
+		return
+	}
+}
+
+func (s *LcScanner) putDeleteMarker(key string, keyDir uint64) (err error) {
+	var info *proto.InodeInfo
+	if info, err = s.mw.InodeCreate_ll(keyDir, ossDefaultFileMode, 0, 0, nil, make([]uint64, 0), key); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = s.mw.InodeUnlink_ll(info.Inode, key)
+			_ = s.mw.Evict(info.Inode, key)
+		}
+	}()
+	versionId := ossNullVersionId
+	if s.versioning == ossVersioningEnabled {
+		versionId = newVersionId(info.ModifyTime, info.Inode)
+	}
+	attrs := map[string]string{
+		XAttrKeyOSSVersionId:    versionId,
+		XAttrKeyOSSDeleteMarker: "true",
+	}
+	if err = s.mw.BatchSetXAttr_ll(info.Inode, attrs); err != nil {
+		return
+	}
+	entryName := versionEntryName(versionId, info.ModifyTime)
+	return s.mw.DentryCreate_ll(keyDir, entryName, info.Inode, ossDefaultFileMode, versionStorePath(key, entryName))
+}
+
+// expireNoncurrentVersions removes the versions of the keys matching the rule which have been noncurrent
+// for NoncurrentDays. A version becomes noncurrent when the next version of the key is written, so the time
+// comes from the entry name of the newer version, or the creation time of the current object for the latest
+// noncurrent version.
+func (s *LcScanner) expireNoncurrentVersions() {
+	root, _, err := s.mw.Lookup_ll(proto.RootIno, ossVersionsDirName)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogErrorf("expireNoncurrentVersions: lookup versions dir fail: volume(%v) err(%v)", s.Volume, err)
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+		}
+		return
+	}
+	s.walkVersionDir(root, ossVersionsDirName, "")
+}
+
+// walkVersionDir expires the noncurrent versions in the directory and the nested ones, the key of the
+// directory is hex encoded in encoded. It returns true if the directory has nothing left.
+func (s *LcScanner) walkVersionDir(dir uint64, dirPath, encoded string) (empty bool) {
+	children, err := s.readDirAll(dir)
+	if err != nil {
+		if err != syscall.ENOENT {
+			log.LogErrorf("walkVersionDir: read dir fail: volume(%v) path(%v) err(%v)", s.Volume, dirPath, err)
+			atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+		}
+		return false
+	}
+	var hexPrefix string
+	if s.rule.Filter != nil {
+		hexPrefix = hex.EncodeToString([]byte(s.rule.Filter.Prefix))
+	}
+
+	var versions []proto.Dentry
+	remaining := 0
+	for _, child := range children {
+		if !os.FileMode(child.Type).IsDir() {
+			versions = append(versions, child)
+			continue
+		}
+		childEncoded := encoded + strings.TrimPrefix(child.Name, versionKeyChunkPrefix)
+		if !strings.HasPrefix(childEncoded, hexPrefix) && !strings.HasPrefix(hexPrefix, childEncoded) {
+			remaining++
+			continue
+		}
+		childPath := dirPath + pathSep + child.Name
+		if s.walkVersionDir(child.Inode, childPath, childEncoded) {
+			if _, err = s.mw.DeleteWithCond_ll(dir, child.Inode, child.Name, true, childPath); err == nil {
+				continue
+			}
+			log.LogDebugf("walkVersionDir: delete empty dir fail: volume(%v) path(%v) err(%v)", s.Volume, childPath, err)
+		}
+		remaining++
+	}
+	if len(versions) > 0 && encoded != "" {
+		remaining += s.expireKeyVersions(encoded, dir, dirPath, versions)
+	}
+	return remaining == 0 && encoded != ""
+}
+
+// expireKeyVersions expires the noncurrent versions of a key, which are sorted from the newest to the
+// oldest. It returns the number of the versions left.
+func (s *LcScanner) expireKeyVersions(encoded string, keyDir uint64, dirPath string, versions []proto.Dentry) (remaining int) {
+	remaining = len(versions)
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		log.LogWarnf("expireKeyVersions: invalid version dir: volume(%v) path(%v) err(%v)", s.Volume, dirPath, err)
+		return
+	}
+	key := string(raw)
+	if s.rule.Filter != nil && !strings.HasPrefix(key, s.rule.Filter.Prefix) {
+		return
+	}
+	current, err := s.currentObject(key)
+	if err != nil && err != syscall.ENOENT {
+		log.LogWarnf("expireKeyVersions: lookup current object fail: volume(%v) key(%v) err(%v)", s.Volume, key, err)
+		atomic.AddInt64(&s.currentStat.ErrorSkippedNum, 1)
+		return
+	}
+
+	days := s.rule.NoncurrentVersionExpiration.NoncurrentDays
+	var (
+		expired []proto.Dentry
+		inodes  []uint64
+	)
+	for i, since := range noncurrentTimes(versions, current) {
+		if since.IsZero() || s.now.Sub(since) < time.Duration(days)*24*time.Hour {
+			continue
+		}
+		expired = append(expired, versions[i])
+		inodes = append(inodes, versions[i].Inode)
+	}
+	if len(expired) == 0 {
+		return
+	}
+	infos := make(map[uint64]*proto.InodeInfo, len(inodes))
+	for _, info := range s.mw.BatchInodeGet(inodes) {
+		infos[info.Inode] = info
+	}
+	xattrs, err := s.batchGetXAttr(inodes)
+	if err != nil {
+		log.LogWarnf("expireKeyVersions: batch get xattr fail: volume(%v) key(%v) err(%v)", s.Volume, key, err)
+		atomic.AddInt64(&s.currentStat.ErrorSkippedNum, int64(len(inodes)))
+		return
+	}
+
+	for _, version := range expired {
+		info := infos[version.Inode]
+		if info == nil || !s.inodeMatched(info, xattrs[version.Inode]) {
+			continue
+		}
+		storePath := dirPath + pathSep + version.Name
+		if s.objectLocked(xattrs[version.Inode]) {
+			log.LogDebugf("expireKeyVersions: skip locked version: volume(%v) path(%v)", s.Volume, storePath)
+			atomic.AddInt64(&s.currentStat.LockedSkippedNum, 1)
+			continue
+		}
+		s.limiter.Wait(context.Background())
+		if _, err = s.mw.DeleteWithCond_ll(keyDir, version.Inode, version.Name, false, storePath); err != nil {
+			log.LogWarnf("expireKeyVersions: delete version fail: volume(%v) path(%v) err(%v), skip it", s.Volume, storePath, err)
+			continue
+		}
+		if err = s.mw.Evict(version.Inode, storePath); err != nil {
+			log.LogWarnf("expireKeyVersions: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+				s.Volume, storePath, version.Inode, err)
+		}
+		atomic.AddInt64(&s.currentStat.NoncurrentExpiredNum, 1)
+		remaining--
+	}
+	return
+}
+
+// currentObject returns the inode of the current object of the key, or syscall.ENOENT if there is none.
+func (s *LcScanner) currentObject(key string) (*proto.InodeInfo, error) {
+	var (
+		mode uint32
+		err  error
+	)
+	ino := proto.RootIno
+	names := strings.Split(key, pathSep)
+	for i, name := range names {
+		if ino, mode, err = s.mw.Lookup_ll(ino, name); err != nil {
+			return nil, err
+		}
+		if i < len(names)-1 && !os.FileMode(mode).IsDir() {
+			return nil, syscall.ENOENT
+		}
+	}
+	if os.FileMode(mode).IsDir() {
+		return nil, syscall.ENOENT
+	}
+	infos := s.mw.BatchInodeGet([]uint64{ino})
+	if len(infos) == 0 {
+		return nil, syscall.ENOENT
+	}
+	return infos[0], nil
+}
+
+// noncurrentTimes returns the time since when each version has been noncurrent, the versions are sorted from
+// the newest to the oldest. The latest version is still current if there is no current object, which is
+// returned as the zero time.
+func noncurrentTimes(versions []proto.Dentry, current *proto.InodeInfo) []time.Time {
+	times := make([]time.Time, len(versions))
+	for i := range versions {
+		if i > 0 {
+			times[i] = versionEntryTime(versions[i-1].Name)
+		} else if current != nil {
+			times[i] = current.CreateTime
+		}
+	}
+	return times
+}
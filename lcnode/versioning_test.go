@@ -0,0 +1,346 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package lcnode
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// versionsMetaWrapper keeps a tiny namespace in memory for the versioning tests.
+type versionsMetaWrapper struct {
+	MockMetaWrapper
+	nextIno  uint64
+	dentries map[uint64][]proto.Dentry
+	inodes   map[uint64]*proto.InodeInfo
+	xattrs   map[uint64]map[string]string
+}
+
+func newVersionsMetaWrapper() *versionsMetaWrapper {
+	mw := &versionsMetaWrapper{
+		nextIno:  proto.RootIno,
+		dentries: make(map[uint64][]proto.Dentry),
+		inodes:   make(map[uint64]*proto.InodeInfo),
+		xattrs:   make(map[uint64]map[string]string),
+	}
+	mw.inodes[proto.RootIno] = &proto.InodeInfo{Inode: proto.RootIno, Mode: uint32(os.ModeDir)}
+	return mw
+}
+
+func (mw *versionsMetaWrapper) newInode(mode uint32, ctime time.Time) *proto.InodeInfo {
+	mw.nextIno++
+	info := &proto.InodeInfo{Inode: mw.nextIno, Mode: mode, CreateTime: ctime, ModifyTime: ctime}
+	mw.inodes[info.Inode] = info
+	mw.xattrs[info.Inode] = make(map[string]string)
+	return info
+}
+
+func (mw *versionsMetaWrapper) add(parent uint64, name string, ino uint64) {
+	children := append(mw.dentries[parent], proto.Dentry{Name: name, Inode: ino, Type: mw.inodes[ino].Mode})
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	mw.dentries[parent] = children
+	mw.inodes[ino].Nlink++
+}
+
+// mkdirs creates the directories of the path and returns the inode of the last one.
+func (mw *versionsMetaWrapper) mkdirs(names ...string) uint64 {
+	parent := proto.RootIno
+	for _, name := range names {
+		ino, _, err := mw.Lookup_ll(parent, name)
+		if err != nil {
+			ino = mw.newInode(uint32(os.ModeDir), time.Now()).Inode
+			mw.add(parent, name, ino)
+		}
+		parent = ino
+	}
+	return parent
+}
+
+func (mw *versionsMetaWrapper) names(parent uint64) (names []string) {
+	for _, d := range mw.dentries[parent] {
+		names = append(names, d.Name)
+	}
+	return
+}
+
+func (mw *versionsMetaWrapper) Lookup_ll(parentID uint64, name string) (uint64, uint32, error) {
+	for _, d := range mw.dentries[parentID] {
+		if d.Name == name {
+			return d.Inode, d.Type, nil
+		}
+	}
+	return 0, 0, syscall.ENOENT
+}
+
+func (mw *versionsMetaWrapper) ReadDirLimit_ll(parentID uint64, from string, limit uint64) (children []proto.Dentry, err error) {
+	for _, d := range mw.dentries[parentID] {
+		if d.Name >= from && uint64(len(children)) < limit {
+			children = append(children, d)
+		}
+	}
+	return
+}
+
+func (mw *versionsMetaWrapper) BatchInodeGet(inodes []uint64) (infos []*proto.InodeInfo) {
+	for _, ino := range inodes {
+		if info, ok := mw.inodes[ino]; ok {
+			infos = append(infos, info)
+		}
+	}
+	return
+}
+
+func (mw *versionsMetaWrapper) DeleteWithCond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
+	children := mw.dentries[parentID]
+	for i, d := range children {
+		if d.Name != name {
+			continue
+		}
+		if d.Inode != cond {
+			return nil, syscall.ENOENT
+		}
+		if isDir && len(mw.dentries[d.Inode]) > 0 {
+			return nil, syscall.ENOTEMPTY
+		}
+		mw.dentries[parentID] = append(children[:i:i], children[i+1:]...)
+		mw.inodes[d.Inode].Nlink--
+		return mw.inodes[d.Inode], nil
+	}
+	return nil, syscall.ENOENT
+}
+
+func (mw *versionsMetaWrapper) BatchGetXAttr(inodes []uint64, keys []string) (infos []*proto.XAttrInfo, err error) {
+	for _, ino := range inodes {
+		info := &proto.XAttrInfo{Inode: ino, XAttrs: make(map[string]string)}
+		for _, key := range keys {
+			if value, ok := mw.xattrs[ino][key]; ok {
+				info.XAttrs[key] = value
+			}
+		}
+		infos = append(infos, info)
+	}
+	return
+}
+
+func (mw *versionsMetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	info := &proto.XAttrInfo{Inode: inode, XAttrs: make(map[string]string)}
+	if value, ok := mw.xattrs[inode][name]; ok {
+		info.XAttrs[name] = value
+	}
+	return info, nil
+}
+
+func (mw *versionsMetaWrapper) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
+	for key, value := range attrs {
+		mw.xattrs[inode][key] = value
+	}
+	return nil
+}
+
+func (mw *versionsMetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	if _, _, err := mw.Lookup_ll(parentID, name); err == nil {
+		return nil, syscall.EEXIST
+	}
+	info := mw.newInode(mode, time.Now())
+	mw.add(parentID, name, info.Inode)
+	return info, nil
+}
+
+func (mw *versionsMetaWrapper) InodeCreate_ll(parentID uint64, mode, uid, gid uint32, target []byte, quotaIds []uint64, fullPath string) (*proto.InodeInfo, error) {
+	return mw.newInode(mode, time.Now()), nil
+}
+
+func (mw *versionsMetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32, fullPath string) error {
+	if _, _, err := mw.Lookup_ll(parentID, name); err == nil {
+		return syscall.EEXIST
+	}
+	mw.add(parentID, name, inode)
+	return nil
+}
+
+func (mw *versionsMetaWrapper) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
+	if err := mw.DentryCreate_ll(parentID, name, ino, 0, fullPath); err != nil {
+		return nil, err
+	}
+	return mw.inodes[ino], nil
+}
+
+func newVersionsScanner(mw MetaWrapper, rule *proto.Rule) *LcScanner {
+	return &LcScanner{
+		Volume:      "test_vol",
+		mw:          mw,
+		rule:        rule,
+		currentStat: &proto.LcNodeRuleTaskStatistics{},
+		limiter:     rate.NewLimiter(rate.Inf, defaultLcScanLimitBurst),
+		now:         time.Now(),
+	}
+}
+
+func TestVersionEntryName(t *testing.T) {
+	ts := time.Unix(1700000000, 123456789)
+	versionId := newVersionId(ts, 0x1234)
+	require.Len(t, versionId, 32)
+	require.True(t, strings.HasSuffix(versionId, "0000000000001234"))
+	require.Equal(t, versionId, versionEntryName(versionId, time.Now()))
+	require.True(t, ts.Equal(versionEntryTime(versionId)))
+
+	nullEntry := versionEntryName(ossNullVersionId, ts)
+	require.True(t, strings.HasSuffix(nullEntry, ossNullVersionId))
+	require.True(t, ts.Equal(versionEntryTime(nullEntry)))
+	require.True(t, versionEntryTime("null").IsZero())
+
+	// the newer versions sort first
+	require.Less(t, newVersionId(ts.Add(time.Second), 1), versionId)
+
+	key := strings.Repeat("k", versionKeyChunkLen/2+1)
+	names := versionKeyDirNames(key)
+	require.Len(t, names, 2)
+	require.Len(t, names[0], versionKeyChunkLen)
+	require.Equal(t, versionKeyChunkPrefix+"6b", names[1])
+	require.Equal(t, []string{"612f62"}, versionKeyDirNames("a/b"))
+}
+
+func TestNoncurrentTimes(t *testing.T) {
+	now := time.Now()
+	versions := []proto.Dentry{
+		{Name: newVersionId(now.Add(-time.Hour), 3)},
+		{Name: newVersionId(now.Add(-2*time.Hour), 2)},
+		{Name: versionEntryName(ossNullVersionId, now.Add(-3*time.Hour))},
+	}
+	times := noncurrentTimes(versions, nil)
+	require.True(t, times[0].IsZero())
+	require.True(t, now.Add(-time.Hour).Equal(times[1]))
+	require.True(t, now.Add(-2*time.Hour).Equal(times[2]))
+
+	current := &proto.InodeInfo{CreateTime: now}
+	require.True(t, now.Equal(noncurrentTimes(versions, current)[0]))
+}
+
+func TestLcScannerExpireVersionedObject(t *testing.T) {
+	mw := newVersionsMetaWrapper()
+	scanner := newVersionsScanner(mw, &proto.Rule{Expire: &proto.ExpirationConfig{Days: 1}})
+	mw.xattrs[proto.RootIno] = map[string]string{
+		XAttrKeyOSSVersioning: "<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>",
+	}
+	status, err := scanner.loadVersioning()
+	require.NoError(t, err)
+	require.Equal(t, ossVersioningEnabled, status)
+	scanner.versioning = status
+
+	dir := mw.mkdirs("a")
+	obj := mw.newInode(ossDefaultFileMode, time.Now().Add(-48*time.Hour))
+	versionId := newVersionId(obj.CreateTime, obj.Inode)
+	mw.xattrs[obj.Inode][XAttrKeyOSSVersionId] = versionId
+	mw.add(dir, "b", obj.Inode)
+
+	dentry := &proto.ScanDentry{ParentId: dir, Inode: obj.Inode, Name: "b", Path: "a/b"}
+	xattrs, err := scanner.batchGetXAttr([]uint64{obj.Inode})
+	require.NoError(t, err)
+	require.NoError(t, scanner.expireVersionedObject(dentry, obj, xattrs[obj.Inode]))
+
+	// the object is kept as a noncurrent version behind a delete marker
+	require.Empty(t, mw.names(dir))
+	keyDir, err := scanner.versionKeyDir("a/b", false)
+	require.NoError(t, err)
+	entries := mw.dentries[keyDir]
+	require.Len(t, entries, 2)
+	marker := entries[0]
+	require.Equal(t, "true", mw.xattrs[marker.Inode][XAttrKeyOSSDeleteMarker])
+	require.Equal(t, marker.Name, mw.xattrs[marker.Inode][XAttrKeyOSSVersionId])
+	require.Equal(t, versionId, entries[1].Name)
+	require.Equal(t, obj.Inode, entries[1].Inode)
+
+	// the null version is replaced while versioning is suspended
+	scanner.versioning = ossVersioningSuspended
+	obj = mw.newInode(ossDefaultFileMode, time.Now().Add(-48*time.Hour))
+	mw.add(dir, "b", obj.Inode)
+	dentry.Inode = obj.Inode
+	require.NoError(t, scanner.expireVersionedObject(dentry, obj, nil))
+	require.Empty(t, mw.names(dir))
+	entries = mw.dentries[keyDir]
+	require.Len(t, entries, 3)
+	require.Equal(t, ossNullVersionId, mw.xattrs[entries[0].Inode][XAttrKeyOSSVersionId])
+	require.Equal(t, uint32(0), obj.Nlink)
+}
+
+func TestLcScannerExpireNoncurrentVersions(t *testing.T) {
+	mw := newVersionsMetaWrapper()
+	scanner := newVersionsScanner(mw, &proto.Rule{
+		NoncurrentVersionExpiration: &proto.NoncurrentVersionExpirationConfig{NoncurrentDays: 30},
+		Filter:                      &proto.FilterConfig{Prefix: "logs/"},
+	})
+	day := 24 * time.Hour
+	now := scanner.now
+
+	putVersion := func(key string, ctime time.Time, deleteMarker bool) uint64 {
+		keyDir := mw.mkdirs(append([]string{ossVersionsDirName}, versionKeyDirNames(key)...)...)
+		info := mw.newInode(ossDefaultFileMode, ctime)
+		versionId := newVersionId(ctime, info.Inode)
+		mw.xattrs[info.Inode][XAttrKeyOSSVersionId] = versionId
+		if deleteMarker {
+			mw.xattrs[info.Inode][XAttrKeyOSSDeleteMarker] = "true"
+		}
+		mw.add(keyDir, versionId, info.Inode)
+		return keyDir
+	}
+	putCurrent := func(dirs []string, name string, ctime time.Time) {
+		mw.add(mw.mkdirs(dirs...), name, mw.newInode(ossDefaultFileMode, ctime).Inode)
+	}
+
+	// both versions have been noncurrent for more than 30 days
+	putCurrent([]string{"logs"}, "a", now.Add(-35*day))
+	aDir := putVersion("logs/a", now.Add(-40*day), false)
+	putVersion("logs/a", now.Add(-60*day), false)
+	// the latest version became noncurrent 10 days ago
+	putCurrent([]string{"logs"}, "b", now.Add(-10*day))
+	bDir := putVersion("logs/b", now.Add(-40*day), false)
+	putVersion("logs/b", now.Add(-60*day), false)
+	// the delete marker is the current version
+	cDir := putVersion("logs/c", now.Add(-100*day), false)
+	putVersion("logs/c", now.Add(-50*day), true)
+	// locked version
+	dDir := putVersion("logs/d", now.Add(-100*day), true)
+	putVersion("logs/d", now.Add(-200*day), false)
+	locked := mw.dentries[dDir][1].Inode
+	mw.xattrs[locked][XAttrKeyOSSLegalHold] = ossLegalHoldOn
+	// out of the prefix
+	tmpDir := putVersion("tmp/e", now.Add(-100*day), true)
+	putVersion("tmp/e", now.Add(-200*day), false)
+
+	scanner.expireNoncurrentVersions()
+
+	root, _, err := mw.Lookup_ll(proto.RootIno, ossVersionsDirName)
+	require.NoError(t, err)
+	_, _, err = mw.Lookup_ll(root, versionKeyDirNames("logs/a")[0])
+	require.Equal(t, syscall.ENOENT, err, "empty version directory is removed")
+	require.Empty(t, mw.dentries[aDir])
+	require.Len(t, mw.dentries[bDir], 1)
+	require.Len(t, mw.dentries[cDir], 1)
+	require.Equal(t, "true", mw.xattrs[mw.dentries[cDir][0].Inode][XAttrKeyOSSDeleteMarker])
+	require.Len(t, mw.dentries[dDir], 2)
+	require.Equal(t, locked, mw.dentries[dDir][1].Inode)
+	require.Len(t, mw.dentries[tmpDir], 2)
+
+	require.Equal(t, int64(4), scanner.currentStat.NoncurrentExpiredNum)
+	require.Equal(t, int64(1), scanner.currentStat.LockedSkippedNum)
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+/*
+#include <stdint.h>
+#include <sys/types.h>
+#include <sys/uio.h>
+*/
+import "C"
+
+import "unsafe"
+
+// The tests can not use cgo, so the C arguments of the exported functions are built here.
+
+// cString returns a null-terminated copy of s in the Go memory, which needs no free.
+func cString(s string) *C.char {
+	b := append([]byte(s), 0)
+	return (*C.char)(unsafe.Pointer(&b[0]))
+}
+
+// cBuf returns the buffer as a char array, the buffer must not be empty.
+func cBuf(buf []byte) *C.char {
+	return (*C.char)(unsafe.Pointer(&buf[0]))
+}
+
+func cInt(n int) C.int {
+	return C.int(n)
+}
+
+func cSize(n int) C.size_t {
+	return C.size_t(n)
+}
+
+func newStatInfo() *C.struct_cfs_stat_info {
+	return new(C.struct_cfs_stat_info)
+}
+
+func newStatfsInfo() *C.struct_cfs_statfs_info {
+	return new(C.struct_cfs_statfs_info)
+}
+
+// newIovecs returns the iovecs of the buffers.
+func newIovecs(bufs ...[]byte) (*C.struct_iovec, C.int) {
+	iovs := make([]C.struct_iovec, len(bufs))
+	for i, b := range bufs {
+		if len(b) > 0 {
+			iovs[i].iov_base = unsafe.Pointer(&b[0])
+		}
+		iovs[i].iov_len = C.size_t(len(b))
+	}
+	return &iovs[0], C.int(len(iovs))
+}
+
+func newIoReq(userData uint64, fd C.int, write bool, buf []byte, offset int64) C.struct_cfs_io_req {
+	req := C.struct_cfs_io_req{
+		user_data: C.uint64_t(userData),
+		fd:        fd,
+		opcode:    ioOpRead,
+		buf:       unsafe.Pointer(&buf[0]),
+		size:      C.size_t(len(buf)),
+		offset:    C.off_t(offset),
+	}
+	if write {
+		req.opcode = ioOpWrite
+	}
+	return req
+}
+
+func newIoReqs(reqs ...C.struct_cfs_io_req) (*C.struct_cfs_io_req, C.int) {
+	return &reqs[0], C.int(len(reqs))
+}
+
+func newIoEvents(n int) []C.struct_cfs_io_event {
+	return make([]C.struct_cfs_io_event, n)
+}
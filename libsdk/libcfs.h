@@ -29,6 +29,7 @@ typedef struct { const char *p; ptrdiff_t n; } _GoString_;
 #include <sys/stat.h>
 #include <dirent.h>
 #include <fcntl.h>
+#include <sys/uio.h>
 
 struct cfs_stat_info {
     uint64_t ino;
@@ -76,6 +77,33 @@ struct cfs_dirent_info {
     uint32_t     nameLen;
 };
 
+struct cfs_statfs_info {
+    uint64_t blocks;
+    uint64_t bfree;
+    uint64_t bavail;
+    uint64_t files;
+    uint64_t ffree;
+    uint32_t bsize;
+    uint32_t namelen;
+};
+
+#define CFS_IO_READ  0
+#define CFS_IO_WRITE 1
+
+struct cfs_io_req {
+    uint64_t user_data;
+    int      fd;
+    int      opcode;
+    void     *buf;
+    size_t   size;
+    off_t    offset;
+};
+
+struct cfs_io_event {
+    uint64_t user_data;
+    int64_t  res;
+};
+
 
 #line 1 "cgo-generated-wrapper"
 
@@ -140,6 +168,12 @@ extern int cfs_flush(int64_t id, int fd);
 extern void cfs_close(int64_t id, int fd);
 extern ssize_t cfs_write(int64_t id, int fd, void* buf, size_t size, off_t off);
 extern ssize_t cfs_read(int64_t id, int fd, void* buf, size_t size, off_t off);
+extern ssize_t cfs_pwritev(int64_t id, int fd, struct iovec* iov, int iovcnt, off_t off);
+extern ssize_t cfs_preadv(int64_t id, int fd, struct iovec* iov, int iovcnt, off_t off);
+extern int64_t cfs_io_setup(int64_t id, int depth);
+extern int cfs_io_submit(int64_t id, int64_t ctxID, struct cfs_io_req* reqs, int nr);
+extern int cfs_io_getevents(int64_t id, int64_t ctxID, int minNr, int nr, struct cfs_io_event* events, int64_t timeoutMs);
+extern int cfs_io_destroy(int64_t id, int64_t ctxID);
 extern int cfs_batch_get_inodes(int64_t id, int fd, void* iids, GoSlice stats, int count);
 extern int cfs_refreshsummary(int64_t id, char* path, int goroutine_num);
 extern int cfs_readdir(int64_t id, int fd, GoSlice dirents, int count);
@@ -148,8 +182,21 @@ extern int cfs_mkdirs(int64_t id, char* path, mode_t mode);
 extern int cfs_rmdir(int64_t id, char* path);
 extern int cfs_unlink(int64_t id, char* path);
 extern int cfs_rename(int64_t id, char* from, char* to);
+extern int cfs_clone_file(int64_t id, char* from, char* to);
 extern int cfs_fchmod(int64_t id, int fd, mode_t mode);
 extern int cfs_getsummary(int64_t id, char* path, struct cfs_summary_info* summary, char* useCache, int goroutine_num);
+extern int cfs_symlink(int64_t id, char* target, char* linkpath);
+extern ssize_t cfs_readlink(int64_t id, char* path, char* buf, size_t size);
+extern int cfs_link(int64_t id, char* oldpath, char* newpath);
+extern int cfs_setxattr(int64_t id, char* path, char* name, void* value, size_t size, int flags);
+extern ssize_t cfs_getxattr(int64_t id, char* path, char* name, void* value, size_t size);
+extern ssize_t cfs_listxattr(int64_t id, char* path, char* list, size_t size);
+extern int cfs_removexattr(int64_t id, char* path, char* name);
+extern int cfs_truncate(int64_t id, char* path, off_t length);
+extern int cfs_ftruncate(int64_t id, int fd, off_t length);
+extern int cfs_fsync(int64_t id, int fd);
+extern int cfs_statfs(int64_t id, struct cfs_statfs_info* stat);
+extern int cfs_access(int64_t id, char* path, int mode);
 
 #ifdef __cplusplus
 }
@@ -23,6 +23,7 @@ package main
 #include <sys/stat.h>
 #include <dirent.h>
 #include <fcntl.h>
+#include <sys/uio.h>
 
 struct cfs_stat_info {
     uint64_t ino;
@@ -70,6 +71,33 @@ struct cfs_dirent_info {
     uint32_t     nameLen;
 };
 
+struct cfs_statfs_info {
+    uint64_t blocks;
+    uint64_t bfree;
+    uint64_t bavail;
+    uint64_t files;
+    uint64_t ffree;
+    uint32_t bsize;
+    uint32_t namelen;
+};
+
+#define CFS_IO_READ  0
+#define CFS_IO_WRITE 1
+
+struct cfs_io_req {
+    uint64_t user_data;
+    int      fd;
+    int      opcode;
+    void     *buf;
+    size_t   size;
+    off_t    offset;
+};
+
+struct cfs_io_event {
+    uint64_t user_data;
+    int64_t  res;
+};
+
 */
 import "C"
 
@@ -104,19 +132,47 @@ import (
 	"github.com/cubefs/cubefs/util/buf"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
 	"github.com/cubefs/cubefs/util/stat"
 )
 
 const (
 	defaultBlkSize = uint32(1) << 12
 
+	defaultMaxMetaPartitionInodeID uint64 = 1<<63 - 1
+
+	// flags of cfs_setxattr, the same as setxattr(2)
+	xattrCreate  = 0x1
+	xattrReplace = 0x2
+
+	// modes of cfs_access, the same as access(2)
+	accessExec  = 0x1
+	accessWrite = 0x2
+	accessRead  = 0x4
+
 	maxFdNum uint = 10240000
 
+	// opcodes of cfs_io_req
+	ioOpRead  = C.CFS_IO_READ
+	ioOpWrite = C.CFS_IO_WRITE
+
+	// the same as IOV_MAX of linux
+	maxIovCnt = 1024
+	// the max number of requests of an io context, submitted and not reaped
+	maxIoDepth = 65536
+
 	MaxSizePutOnce = int64(1) << 23
 )
 
 var gClientManager *clientManager
 
+// the identity of the process which the permissions are checked for
+var (
+	getuid    = os.Getuid
+	getgid    = os.Getgid
+	getgroups = os.Getgroups
+)
+
 var (
 	statusOK = C.int(0)
 	// error status must be minus value
@@ -129,6 +185,10 @@ var (
 	statusENOTDIR = errorToStatus(syscall.ENOTDIR)
 	statusEISDIR  = errorToStatus(syscall.EISDIR)
 	statusENOSPC  = errorToStatus(syscall.ENOSPC)
+	statusEPERM   = errorToStatus(syscall.EPERM)
+	statusENODATA = errorToStatus(syscall.ENODATA)
+	statusERANGE  = errorToStatus(syscall.ERANGE)
+	statusEAGAIN  = errorToStatus(syscall.EAGAIN)
 )
 
 func init() {
@@ -159,6 +219,7 @@ func newClient() *client {
 		id:                  id,
 		fdmap:               make(map[uint]*file),
 		fdset:               bitset.New(maxFdNum),
+		ioctxs:              make(map[int64]*ioContext),
 		dirChildrenNumLimit: proto.DefaultDirChildrenNumLimit,
 		cwd:                 "/",
 		sc:                  fs.NewSummaryCache(fs.DefaultSummaryExpiration, fs.MaxSummaryCache),
@@ -208,6 +269,50 @@ type dirStream struct {
 	dirents []proto.Dentry
 }
 
+// metaWrapper is the part of meta.MetaWrapper used by the client.
+type metaWrapper interface {
+	LookupPath(subdir string) (uint64, error)
+	Statfs() (total, used, inodeCount uint64)
+	Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error)
+	Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error)
+	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
+	BatchInodeGet(inodes []uint64) []*proto.InodeInfo
+	Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error)
+	Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string, srcFullPath string, dstFullPath string, overwritten bool) error
+	ReadDir_ll(parentID uint64) ([]proto.Dentry, error)
+	CloneFile_ll(srcIno, parentID uint64, name string, mode, uid, gid uint32, fullPath string) (*proto.InodeInfo, error)
+	Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error)
+	Evict(inode uint64, fullPath string) error
+	Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error
+	XAttrSet_ll(inode uint64, name, value []byte) error
+	BatchSetXAttr_ll(inode uint64, attrs map[string]string) error
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	XAttrDel_ll(inode uint64, name string) error
+	XAttrsList_ll(inode uint64) ([]string, error)
+	GetSummary_ll(parentIno uint64, goroutineNum int32) (meta.SummaryInfo, error)
+	RefreshSummary_ll(parentIno uint64, goroutineNum int32) error
+	IsQuotaLimitedById(inodeId uint64, size bool, files bool) bool
+	QuotaEnabled() bool
+	Close() error
+}
+
+// extentClient is the part of stream.ExtentClient used by the client.
+type extentClient interface {
+	OpenStream(inode uint64) error
+	CloseStream(inode uint64) error
+	EvictStream(inode uint64) error
+	FileSize(inode uint64) (size int, gen uint64, valid bool)
+	Flush(inode uint64) error
+	ForceRefreshExtentsCache(inode uint64) error
+	GetEnablePosixAcl() bool
+	GetStreamer(inode uint64) *stream.Streamer
+	Read(inode uint64, data []byte, offset int, size int) (read int, err error)
+	Write(inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error)
+	Truncate(mw *meta.MetaWrapper, parentIno uint64, inode uint64, size int, fullPath string) error
+	UidIsLimited(uid uint32) bool
+	Close() error
+}
+
 type client struct {
 	// client id allocated by libsdk
 	id int64
@@ -236,6 +341,8 @@ type client struct {
 	cluster             string
 	dirChildrenNumLimit uint32
 	enableAudit         bool
+	readAheadMemMB      int64
+	readAheadWindowMB   int64
 
 	// runtime context
 	cwd    string // current working directory
@@ -243,9 +350,14 @@ type client struct {
 	fdset  *bitset.BitSet
 	fdlock sync.RWMutex
 
+	// async io contexts
+	ioctxs    map[int64]*ioContext
+	nextIoCtx int64
+	iolock    sync.Mutex
+
 	// server info
-	mw   *meta.MetaWrapper
-	ec   *stream.ExtentClient
+	mw   metaWrapper
+	ec   extentClient
 	ic   *fs.InodeCache
 	dc   *fs.DentryCache
 	bc   *bcache.BcacheClient
@@ -319,6 +431,16 @@ func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
 		} else {
 			c.enableAudit = false
 		}
+	case "readAheadMemMB":
+		mem, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			c.readAheadMemMB = mem
+		}
+	case "readAheadWindowMB":
+		window, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			c.readAheadWindowMB = window
+		}
 	default:
 		return statusEINVAL
 	}
@@ -343,6 +465,7 @@ func cfs_start_client(id C.int64_t) C.int {
 //export cfs_close_client
 func cfs_close_client(id C.int64_t) {
 	if c, exist := getClient(int64(id)); exist {
+		c.destroyIoContexts()
 		if c.ec != nil {
 			_ = c.ec.Close()
 		}
@@ -493,7 +616,13 @@ func cfs_open(id C.int64_t, path *C.char, flags C.int, mode C.mode_t) C.int {
 				auditlog.LogClientOp("Create", dirpath, "nil", err, time.Since(start).Microseconds(), 0, 0)
 			}
 		}()
-		newInfo, err := c.create(dirInfo.Inode, name, fuseMode, absPath)
+		var newInfo *proto.InodeInfo
+		if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err == nil {
+			newInfo, err = c.create(dirInfo.Inode, name, fuseMode, absPath)
+		} else if _, lookupErr := c.lookupPath(absPath); lookupErr == nil {
+			// an existing file is opened without the permission of the directory
+			err = syscall.EEXIST
+		}
 		if err != nil {
 			if err != syscall.EEXIST {
 				return errorToStatus(err)
@@ -502,6 +631,9 @@ func cfs_open(id C.int64_t, path *C.char, flags C.int, mode C.mode_t) C.int {
 			if err != nil {
 				return errorToStatus(err)
 			}
+			if err = c.checkPosixAcl(newInfo.Inode, openPerm(accFlags)); err != nil {
+				return errorToStatus(err)
+			}
 		}
 		info = newInfo
 	} else {
@@ -515,6 +647,9 @@ func cfs_open(id C.int64_t, path *C.char, flags C.int, mode C.mode_t) C.int {
 		if err != nil {
 			return errorToStatus(err)
 		}
+		if err = c.checkPosixAcl(newInfo.Inode, openPerm(accFlags)); err != nil {
+			return errorToStatus(err)
+		}
 		info = newInfo
 	}
 	var fileCache bool
@@ -605,78 +740,200 @@ func cfs_write(id C.int64_t, fd C.int, buf unsafe.Pointer, size C.size_t, off C.
 		return C.ssize_t(statusEBADFD)
 	}
 
-	accFlags := f.flags & uint32(C.O_ACCMODE)
-	if accFlags != uint32(C.O_WRONLY) && accFlags != uint32(C.O_RDWR) {
-		return C.ssize_t(statusEACCES)
-	}
+	return c.pwrite(f, cBytes(buf, size), int(off))
+}
 
-	var buffer []byte
+//export cfs_read
+func cfs_read(id C.int64_t, fd C.int, buf unsafe.Pointer, size C.size_t, off C.off_t) C.ssize_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.ssize_t(statusEINVAL)
+	}
 
-	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&buffer))
-	hdr.Data = uintptr(buf)
-	hdr.Len = int(size)
-	hdr.Cap = int(size)
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return C.ssize_t(statusEBADFD)
+	}
 
-	var flags int
-	var wait bool
+	return c.pread(f, cBytes(buf, size), int(off))
+}
 
-	if f.flags&uint32(C.O_DIRECT) != 0 || f.flags&uint32(C.O_SYNC) != 0 || f.flags&uint32(C.O_DSYNC) != 0 {
-		if proto.IsHot(c.volType) {
-			wait = true
-		}
+//export cfs_pwritev
+func cfs_pwritev(id C.int64_t, fd C.int, iov *C.struct_iovec, iovcnt C.int, off C.off_t) C.ssize_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.ssize_t(statusEINVAL)
 	}
-	if f.flags&uint32(C.O_APPEND) != 0 || proto.IsCold(c.volType) {
-		flags |= proto.FlagsAppend
-		flags |= proto.FlagsSyncWrite
+	if iovcnt < 0 || iovcnt > maxIovCnt {
+		return C.ssize_t(statusEINVAL)
 	}
 
-	n, err := c.write(f, int(off), buffer, flags)
-	if err != nil {
-		if err == syscall.ENOSPC {
-			return C.ssize_t(statusENOSPC)
-		}
-		return C.ssize_t(statusEIO)
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return C.ssize_t(statusEBADFD)
 	}
 
-	if wait {
-		if err = c.flush(f); err != nil {
-			return C.ssize_t(statusEIO)
+	var total C.ssize_t
+	for _, v := range iovecs(iov, iovcnt) {
+		if v.iov_len == 0 {
+			continue
+		}
+		n := c.pwrite(f, cBytes(v.iov_base, v.iov_len), int(off)+int(total))
+		if n < 0 {
+			if total > 0 {
+				break
+			}
+			return n
+		}
+		total += n
+		if n < C.ssize_t(v.iov_len) {
+			break
 		}
 	}
-
-	return C.ssize_t(n)
+	return total
 }
 
-//export cfs_read
-func cfs_read(id C.int64_t, fd C.int, buf unsafe.Pointer, size C.size_t, off C.off_t) C.ssize_t {
+//export cfs_preadv
+func cfs_preadv(id C.int64_t, fd C.int, iov *C.struct_iovec, iovcnt C.int, off C.off_t) C.ssize_t {
 	c, exist := getClient(int64(id))
 	if !exist {
 		return C.ssize_t(statusEINVAL)
 	}
+	if iovcnt < 0 || iovcnt > maxIovCnt {
+		return C.ssize_t(statusEINVAL)
+	}
 
 	f := c.getFile(uint(fd))
 	if f == nil {
 		return C.ssize_t(statusEBADFD)
 	}
 
-	accFlags := f.flags & uint32(C.O_ACCMODE)
-	if accFlags == uint32(C.O_WRONLY) {
-		return C.ssize_t(statusEACCES)
+	var total C.ssize_t
+	for _, v := range iovecs(iov, iovcnt) {
+		if v.iov_len == 0 {
+			continue
+		}
+		n := c.pread(f, cBytes(v.iov_base, v.iov_len), int(off)+int(total))
+		if n < 0 {
+			if total > 0 {
+				break
+			}
+			return n
+		}
+		total += n
+		// a short read means the end of the file
+		if n < C.ssize_t(v.iov_len) {
+			break
+		}
+	}
+	return total
+}
+
+// cfs_io_setup creates an async io context which holds at most depth requests submitted and not reaped,
+// it returns the id of the context.
+//
+//export cfs_io_setup
+func cfs_io_setup(id C.int64_t, depth C.int) C.int64_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.int64_t(statusEINVAL)
+	}
+	if depth <= 0 || depth > maxIoDepth {
+		return C.int64_t(statusEINVAL)
 	}
 
-	var buffer []byte
+	ctx := newIoContext(c, int(depth))
+	c.iolock.Lock()
+	c.nextIoCtx++
+	ctx.id = c.nextIoCtx
+	c.ioctxs[ctx.id] = ctx
+	c.iolock.Unlock()
+	return C.int64_t(ctx.id)
+}
 
-	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&buffer))
-	hdr.Data = uintptr(buf)
-	hdr.Len = int(size)
-	hdr.Cap = int(size)
+// cfs_io_submit submits nr requests to the io context, it returns the number of requests submitted,
+// which is less than nr if the context is full. The buffers must be kept valid until the requests complete.
+//
+//export cfs_io_submit
+func cfs_io_submit(id C.int64_t, ctxID C.int64_t, reqs *C.struct_cfs_io_req, nr C.int) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+	ctx := c.getIoContext(int64(ctxID))
+	if ctx == nil || nr < 0 {
+		return statusEINVAL
+	}
 
-	n, err := c.read(f, int(off), buffer)
-	if err != nil {
-		return C.ssize_t(statusEIO)
+	var requests []C.struct_cfs_io_req
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&requests))
+	hdr.Data = uintptr(unsafe.Pointer(reqs))
+	hdr.Len = int(nr)
+	hdr.Cap = int(nr)
+
+	for i := range requests {
+		if requests[i].opcode != ioOpRead && requests[i].opcode != ioOpWrite {
+			if i == 0 {
+				return statusEINVAL
+			}
+			return C.int(i)
+		}
+		if !ctx.submit(requests[i]) {
+			if i == 0 {
+				return statusEAGAIN
+			}
+			return C.int(i)
+		}
 	}
+	return nr
+}
 
-	return C.ssize_t(n)
+// cfs_io_getevents reaps at least minNr and at most nr completed requests of the io context,
+// it waits timeoutMs milliseconds at most, or forever if timeoutMs is negative.
+// The res of an event is the bytes read or written, or a negative errno.
+//
+//export cfs_io_getevents
+func cfs_io_getevents(id C.int64_t, ctxID C.int64_t, minNr C.int, nr C.int, events *C.struct_cfs_io_event, timeoutMs C.int64_t) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+	ctx := c.getIoContext(int64(ctxID))
+	if ctx == nil || nr < 0 || minNr < 0 || minNr > nr {
+		return statusEINVAL
+	}
+
+	var evs []C.struct_cfs_io_event
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&evs))
+	hdr.Data = uintptr(unsafe.Pointer(events))
+	hdr.Len = int(nr)
+	hdr.Cap = int(nr)
+
+	var timeout time.Duration = -1
+	if timeoutMs >= 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	return C.int(ctx.getEvents(evs, int(minNr), timeout))
+}
+
+// cfs_io_destroy waits for the submitted requests of the io context and destroys it.
+//
+//export cfs_io_destroy
+func cfs_io_destroy(id C.int64_t, ctxID C.int64_t) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	c.iolock.Lock()
+	ctx, ok := c.ioctxs[int64(ctxID)]
+	delete(c.ioctxs, int64(ctxID))
+	c.iolock.Unlock()
+	if !ok {
+		return statusEINVAL
+	}
+	ctx.wg.Wait()
+	return statusOK
 }
 
 //export cfs_batch_get_inodes
@@ -943,6 +1200,10 @@ func cfs_mkdirs(id C.int64_t, path *C.char, mode C.mode_t) C.int {
 		child, _, err := c.mw.Lookup_ll(pino, dir)
 		if err != nil {
 			if err == syscall.ENOENT {
+				if err = c.checkPosixAcl(pino, posixacl.PermWrite|posixacl.PermExec); err != nil {
+					gerr = err
+					return errorToStatus(err)
+				}
 				info, err := c.mkdir(pino, dir, uint32(mode), dirpath)
 
 				if err != nil {
@@ -988,6 +1249,9 @@ func cfs_rmdir(id C.int64_t, path *C.char) C.int {
 	if err != nil {
 		return errorToStatus(err)
 	}
+	if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
 
 	info, err = c.mw.Delete_ll(dirInfo.Inode, name, true, absPath)
 	c.ic.Delete(dirInfo.Inode)
@@ -1028,6 +1292,9 @@ func cfs_unlink(id C.int64_t, path *C.char) C.int {
 	if proto.IsDir(mode) {
 		return statusEISDIR
 	}
+	if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
 
 	info, err = c.mw.Delete_ll(dirInfo.Inode, name, false, absPath)
 	if err != nil {
@@ -1069,6 +1336,12 @@ func cfs_rename(id C.int64_t, from *C.char, to *C.char) C.int {
 	if err != nil {
 		return errorToStatus(err)
 	}
+	if err = c.checkPosixAcl(srcDirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
+	if err = c.checkPosixAcl(dstDirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
 
 	err = c.mw.Rename_ll(srcDirInfo.Inode, srcName, dstDirInfo.Inode, dstName, absFrom, absTo, false)
 	c.ic.Delete(srcDirInfo.Inode)
@@ -1077,6 +1350,68 @@ func cfs_rename(id C.int64_t, from *C.char, to *C.char) C.int {
 	return errorToStatus(err)
 }
 
+//export cfs_clone_file
+func cfs_clone_file(id C.int64_t, from *C.char, to *C.char) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	start := time.Now()
+	var err error
+	var info *proto.InodeInfo
+
+	absFrom := c.absPath(C.GoString(from))
+	absTo := c.absPath(C.GoString(to))
+
+	defer func() {
+		if info == nil {
+			auditlog.LogClientOp("CloneFile", absFrom, absTo, err, time.Since(start).Microseconds(), 0, 0)
+		} else {
+			auditlog.LogClientOp("CloneFile", absFrom, absTo, err, time.Since(start).Microseconds(), info.Inode, 0)
+		}
+	}()
+
+	if !proto.IsHot(c.volType) {
+		return statusEINVAL
+	}
+
+	srcInfo, err := c.lookupPath(absFrom)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if proto.IsDir(srcInfo.Mode) {
+		return statusEISDIR
+	}
+	dirpath, name := gopath.Split(absTo)
+	dirInfo, err := c.lookupPath(dirpath)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if err = c.checkPosixAcl(srcInfo.Inode, posixacl.PermRead); err != nil {
+		return errorToStatus(err)
+	}
+	if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
+
+	// the data written through the opened file must reach the meta partition before cloning
+	if c.ec.GetStreamer(srcInfo.Inode) != nil {
+		if err = c.ec.Flush(srcInfo.Inode); err != nil {
+			return errorToStatus(err)
+		}
+	}
+	info, err = c.mw.CloneFile_ll(srcInfo.Inode, dirInfo.Inode, name, srcInfo.Mode&0o777, srcInfo.Uid, srcInfo.Gid, absTo)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	// the extents of the source are shared now, the following writes must not overwrite them in place
+	_ = c.ec.ForceRefreshExtentsCache(srcInfo.Inode)
+	c.ic.Delete(srcInfo.Inode)
+	c.ic.Delete(dirInfo.Inode)
+	return statusOK
+}
+
 //export cfs_fchmod
 func cfs_fchmod(id C.int64_t, fd C.int, mode C.mode_t) C.int {
 	c, exist := getClient(int64(id))
@@ -1141,43 +1476,410 @@ func cfs_getsummary(id C.int64_t, path *C.char, summary *C.struct_cfs_summary_in
 	return statusOK
 }
 
-// internals
+//export cfs_symlink
+func cfs_symlink(id C.int64_t, target *C.char, linkpath *C.char) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
 
-func (c *client) absPath(path string) string {
-	p := gopath.Clean(path)
-	if !gopath.IsAbs(p) {
-		p = gopath.Join(c.cwd, p)
+	start := time.Now()
+	var err error
+	var info *proto.InodeInfo
+
+	absPath := c.absPath(C.GoString(linkpath))
+	defer func() {
+		if info == nil {
+			auditlog.LogClientOp("Symlink", absPath, "nil", err, time.Since(start).Microseconds(), 0, 0)
+		} else {
+			auditlog.LogClientOp("Symlink", absPath, "nil", err, time.Since(start).Microseconds(), info.Inode, 0)
+		}
+	}()
+
+	dirpath, name := gopath.Split(absPath)
+	dirInfo, err := c.lookupPath(dirpath)
+	if err != nil {
+		return errorToStatus(err)
 	}
-	return gopath.Clean(p)
+	if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
+	info, err = c.mw.Create_ll(dirInfo.Inode, name, proto.Mode(os.ModeSymlink|os.ModePerm), 0, 0, []byte(C.GoString(target)), absPath)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	c.ic.Delete(dirInfo.Inode)
+	return statusOK
 }
 
-func (c *client) start() (err error) {
-	masters := strings.Split(c.masterAddr, ",")
-	if c.logDir != "" {
-		if c.logLevel == "" {
-			c.logLevel = "WARN"
-		}
-		level := parseLogLevel(c.logLevel)
-		log.InitLog(c.logDir, "libcfs", level, nil, log.DefaultLogLeftSpaceLimit)
-		stat.NewStatistic(c.logDir, "libcfs", int64(stat.DefaultStatLogSize), stat.DefaultTimeOutUs, true)
+//export cfs_readlink
+func cfs_readlink(id C.int64_t, path *C.char, buf *C.char, size C.size_t) C.ssize_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.ssize_t(statusEINVAL)
 	}
-	proto.InitBufferPool(int64(32768))
-	if c.readBlockThread == 0 {
-		c.readBlockThread = 10
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return C.ssize_t(errorToStatus(err))
 	}
-	if c.writeBlockThread == 0 {
-		c.writeBlockThread = 10
+	if !proto.IsSymlink(info.Mode) {
+		return C.ssize_t(statusEINVAL)
 	}
-	if err = c.loadConfFromMaster(masters); err != nil {
-		return
+
+	// like readlink(2), the target is truncated to the buffer and not null-terminated
+	n := len(info.Target)
+	if n > int(size) {
+		n = int(size)
 	}
-	if err = c.checkPermission(); err != nil {
-		err = errors.NewErrorf("check permission failed: %v", err)
-		syslog.Println(err)
-		return
+	if n > 0 {
+		C.memcpy(unsafe.Pointer(buf), unsafe.Pointer(&info.Target[0]), C.size_t(n))
 	}
+	return C.ssize_t(n)
+}
 
-	if c.enableAudit {
+//export cfs_link
+func cfs_link(id C.int64_t, oldpath *C.char, newpath *C.char) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	start := time.Now()
+	var err error
+	var info *proto.InodeInfo
+
+	absOld := c.absPath(C.GoString(oldpath))
+	absNew := c.absPath(C.GoString(newpath))
+	defer func() {
+		if info == nil {
+			auditlog.LogClientOp("Link", absOld, absNew, err, time.Since(start).Microseconds(), 0, 0)
+		} else {
+			auditlog.LogClientOp("Link", absOld, absNew, err, time.Since(start).Microseconds(), info.Inode, 0)
+		}
+	}()
+
+	oldInfo, err := c.lookupPath(absOld)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if !proto.IsRegular(oldInfo.Mode) {
+		return statusEPERM
+	}
+	dirpath, name := gopath.Split(absNew)
+	dirInfo, err := c.lookupPath(dirpath)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if err = c.checkPosixAcl(dirInfo.Inode, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return errorToStatus(err)
+	}
+
+	info, err = c.mw.Link(dirInfo.Inode, name, oldInfo.Inode, absNew)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	c.ic.Delete(oldInfo.Inode)
+	c.ic.Delete(dirInfo.Inode)
+	return statusOK
+}
+
+//export cfs_setxattr
+func cfs_setxattr(id C.int64_t, path *C.char, name *C.char, value unsafe.Pointer, size C.size_t, flags C.int) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return errorToStatus(err)
+	}
+
+	key := C.GoString(name)
+	val := C.GoBytes(value, C.int(size))
+	if err = c.checkSetXattr(info, key, val); err != nil {
+		return errorToStatus(err)
+	}
+	if flags&(xattrCreate|xattrReplace) != 0 {
+		_, found, err := c.getxattr(info.Inode, key)
+		if err != nil {
+			return errorToStatus(err)
+		}
+		if found && flags&xattrCreate != 0 {
+			return statusEEXIST
+		}
+		if !found && flags&xattrReplace != 0 {
+			return statusENODATA
+		}
+	}
+
+	err = c.mw.XAttrSet_ll(info.Inode, []byte(key), val)
+	return errorToStatus(err)
+}
+
+//export cfs_getxattr
+func cfs_getxattr(id C.int64_t, path *C.char, name *C.char, value unsafe.Pointer, size C.size_t) C.ssize_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.ssize_t(statusEINVAL)
+	}
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return C.ssize_t(errorToStatus(err))
+	}
+
+	val, found, err := c.getxattr(info.Inode, C.GoString(name))
+	if err != nil {
+		return C.ssize_t(errorToStatus(err))
+	}
+	if !found {
+		return C.ssize_t(statusENODATA)
+	}
+
+	// like getxattr(2), a zero size asks for the size of the value
+	if size == 0 {
+		return C.ssize_t(len(val))
+	}
+	if int(size) < len(val) {
+		return C.ssize_t(statusERANGE)
+	}
+	if len(val) > 0 {
+		C.memcpy(value, unsafe.Pointer(&val[0]), C.size_t(len(val)))
+	}
+	return C.ssize_t(len(val))
+}
+
+//export cfs_listxattr
+func cfs_listxattr(id C.int64_t, path *C.char, list *C.char, size C.size_t) C.ssize_t {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return C.ssize_t(statusEINVAL)
+	}
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return C.ssize_t(errorToStatus(err))
+	}
+
+	keys, err := c.mw.XAttrsList_ll(info.Inode)
+	if err != nil {
+		return C.ssize_t(errorToStatus(err))
+	}
+
+	// like listxattr(2), the names are null-terminated one after another
+	var names []byte
+	for _, key := range keys {
+		names = append(names, key...)
+		names = append(names, 0)
+	}
+	if size == 0 {
+		return C.ssize_t(len(names))
+	}
+	if int(size) < len(names) {
+		return C.ssize_t(statusERANGE)
+	}
+	if len(names) > 0 {
+		C.memcpy(unsafe.Pointer(list), unsafe.Pointer(&names[0]), C.size_t(len(names)))
+	}
+	return C.ssize_t(len(names))
+}
+
+//export cfs_removexattr
+func cfs_removexattr(id C.int64_t, path *C.char, name *C.char) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return errorToStatus(err)
+	}
+
+	key := C.GoString(name)
+	if err = c.checkSetXattr(info, key, nil); err != nil {
+		return errorToStatus(err)
+	}
+	_, found, err := c.getxattr(info.Inode, key)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if !found {
+		return statusENODATA
+	}
+	err = c.mw.XAttrDel_ll(info.Inode, key)
+	return errorToStatus(err)
+}
+
+//export cfs_truncate
+func cfs_truncate(id C.int64_t, path *C.char, length C.off_t) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+	if length < 0 {
+		return statusEINVAL
+	}
+
+	absPath := c.absPath(C.GoString(path))
+	info, err := c.lookupPath(absPath)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if proto.IsDir(info.Mode) {
+		return statusEISDIR
+	}
+	if !proto.IsRegular(info.Mode) {
+		return statusEINVAL
+	}
+	if err = c.checkPosixAcl(info.Inode, posixacl.PermWrite); err != nil {
+		return errorToStatus(err)
+	}
+	dirInfo, err := c.lookupPath(gopath.Dir(absPath))
+	if err != nil {
+		return errorToStatus(err)
+	}
+
+	f := &file{ino: info.Inode, pino: dirInfo.Inode, path: absPath}
+	c.openStream(f)
+	err = c.truncate(f, int(length))
+	c.closeStream(f)
+	c.ic.Delete(info.Inode)
+	return errorToStatus(err)
+}
+
+//export cfs_ftruncate
+func cfs_ftruncate(id C.int64_t, fd C.int, length C.off_t) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+	if length < 0 {
+		return statusEINVAL
+	}
+
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return statusEBADFD
+	}
+	accFlags := f.flags & uint32(C.O_ACCMODE)
+	if accFlags != uint32(C.O_WRONLY) && accFlags != uint32(C.O_RDWR) {
+		return statusEINVAL
+	}
+
+	err := c.truncate(f, int(length))
+	c.ic.Delete(f.ino)
+	return errorToStatus(err)
+}
+
+//export cfs_fsync
+func cfs_fsync(id C.int64_t, fd C.int) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	f := c.getFile(uint(fd))
+	if f == nil {
+		return statusEBADFD
+	}
+
+	if err := c.flush(f); err != nil {
+		return errorToStatus(err)
+	}
+	c.ic.Delete(f.ino)
+	return statusOK
+}
+
+//export cfs_statfs
+func cfs_statfs(id C.int64_t, stat *C.struct_cfs_statfs_info) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	total, used, inodeCount := c.mw.Statfs()
+	// the used space may exceed the total one, when the capacity of the volume is reduced
+	var free uint64
+	if total > used {
+		free = total - used
+	}
+	stat.blocks = C.uint64_t(total / uint64(defaultBlkSize))
+	stat.bfree = C.uint64_t(free / uint64(defaultBlkSize))
+	stat.bavail = stat.bfree
+	stat.files = C.uint64_t(inodeCount)
+	stat.ffree = C.uint64_t(defaultMaxMetaPartitionInodeID - inodeCount)
+	stat.bsize = C.uint32_t(defaultBlkSize)
+	stat.namelen = C.uint32_t(fs.DefaultMaxNameLen)
+	return statusOK
+}
+
+//export cfs_access
+func cfs_access(id C.int64_t, path *C.char, mode C.int) C.int {
+	c, exist := getClient(int64(id))
+	if !exist {
+		return statusEINVAL
+	}
+
+	info, err := c.lookupPath(c.absPath(C.GoString(path)))
+	if err != nil {
+		return errorToStatus(err)
+	}
+	acl, err := c.getPosixAcl(info.Inode, posixacl.XattrAccess)
+	if err != nil {
+		return errorToStatus(err)
+	}
+	if acl != nil {
+		if !acl.Check(info.Uid, info.Gid, uint32(getuid()), callerGids(), uint16(mode)) {
+			return statusEACCES
+		}
+		return statusOK
+	}
+	if !accessAllowed(info, uint32(mode), uint32(getuid()), uint32(getgid())) {
+		return statusEACCES
+	}
+	return statusOK
+}
+
+// internals
+
+func (c *client) absPath(path string) string {
+	p := gopath.Clean(path)
+	if !gopath.IsAbs(p) {
+		p = gopath.Join(c.cwd, p)
+	}
+	return gopath.Clean(p)
+}
+
+func (c *client) start() (err error) {
+	masters := strings.Split(c.masterAddr, ",")
+	if c.logDir != "" {
+		if c.logLevel == "" {
+			c.logLevel = "WARN"
+		}
+		level := parseLogLevel(c.logLevel)
+		log.InitLog(c.logDir, "libcfs", level, nil, log.DefaultLogLeftSpaceLimit)
+		stat.NewStatistic(c.logDir, "libcfs", int64(stat.DefaultStatLogSize), stat.DefaultTimeOutUs, true)
+	}
+	proto.InitBufferPool(int64(32768))
+	if c.readBlockThread == 0 {
+		c.readBlockThread = 10
+	}
+	if c.writeBlockThread == 0 {
+		c.writeBlockThread = 10
+	}
+	if err = c.loadConfFromMaster(masters); err != nil {
+		return
+	}
+	if err = c.checkPermission(); err != nil {
+		err = errors.NewErrorf("check permission failed: %v", err)
+		syslog.Println(err)
+		return
+	}
+
+	if c.enableAudit {
 		_, err = auditlog.InitAudit(c.logDir, "clientSdk", int64(auditlog.DefaultAuditLogSize))
 		if err != nil {
 			log.LogWarnf("Init audit log fail: %v", err)
@@ -1225,11 +1927,14 @@ func (c *client) start() (err error) {
 		OnSplitExtentKey:  mw.SplitExtentKey,
 		OnGetExtents:      mw.GetExtents,
 		OnTruncate:        mw.Truncate,
+		OnCheckReleased:   mw.IsTransitionReleased,
 		BcacheEnable:      c.enableBcache,
 		OnLoadBcache:      c.bc.Get,
 		OnCacheBcache:     c.bc.Put,
 		OnEvictBcache:     c.bc.Evict,
 		DisableMetaCache:  true,
+		ReadAheadMemMB:    c.readAheadMemMB,
+		ReadAheadWindowMB: c.readAheadWindowMB,
 	}); err != nil {
 		log.LogErrorf("newClient NewExtentClient failed(%v)", err)
 		return
@@ -1291,8 +1996,8 @@ func (c *client) allocFD(ino uint64, flags, mode uint32, fileCache bool, fileSiz
 			BlockSize:       c.ebsBlockSize,
 			Ino:             ino,
 			Bc:              c.bc,
-			Mw:              c.mw,
-			Ec:              c.ec,
+			Mw:              c.metaWrapper(),
+			Ec:              c.extentClient(),
 			Ebsc:            c.ebsc,
 			EnableBcache:    c.enableBcache,
 			WConcurrency:    c.writeBlockThread,
@@ -1376,14 +2081,45 @@ func (c *client) setattr(info *proto.InodeInfo, valid uint32, mode, uid, gid uin
 }
 
 func (c *client) create(pino uint64, name string, mode uint32, fullPath string) (info *proto.InodeInfo, err error) {
-	fuseMode := mode & 0o777
-	return c.mw.Create_ll(pino, name, fuseMode, 0, 0, nil, fullPath)
+	return c.createWithPosixAcl(pino, name, mode&0o777, false, fullPath)
 }
 
 func (c *client) mkdir(pino uint64, name string, mode uint32, fullPath string) (info *proto.InodeInfo, err error) {
-	fuseMode := mode & 0o777
-	fuseMode |= uint32(os.ModeDir)
-	return c.mw.Create_ll(pino, name, fuseMode, 0, 0, nil, fullPath)
+	return c.createWithPosixAcl(pino, name, mode&0o777, true, fullPath)
+}
+
+// createWithPosixAcl creates the inode with the mode and the ACLs inherited from the
+// default ACL of the parent directory.
+func (c *client) createWithPosixAcl(pino uint64, name string, fuseMode uint32, isDir bool, fullPath string) (info *proto.InodeInfo, err error) {
+	fuseMode, xattrs, err := c.inheritPosixAcl(pino, fuseMode, isDir)
+	if err != nil {
+		return nil, err
+	}
+	if isDir {
+		fuseMode |= uint32(os.ModeDir)
+	}
+	if info, err = c.mw.Create_ll(pino, name, fuseMode, 0, 0, nil, fullPath); err != nil {
+		return nil, err
+	}
+	if len(xattrs) > 0 {
+		if err = c.mw.BatchSetXAttr_ll(info.Inode, xattrs); err != nil {
+			log.LogErrorf("createWithPosixAcl: set acl failed, ino(%v) path(%v) err(%v)", info.Inode, fullPath, err)
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// metaWrapper returns the meta wrapper required by the data clients, nil if it is a fake.
+func (c *client) metaWrapper() *meta.MetaWrapper {
+	mw, _ := c.mw.(*meta.MetaWrapper)
+	return mw
+}
+
+// extentClient returns the extent client required by the data clients, nil if it is a fake.
+func (c *client) extentClient() *stream.ExtentClient {
+	ec, _ := c.ec.(*stream.ExtentClient)
+	return ec
 }
 
 func (c *client) openStream(f *file) {
@@ -1410,18 +2146,72 @@ func (c *client) flush(f *file) error {
 }
 
 func (c *client) truncate(f *file, size int) error {
-	err := c.ec.Truncate(c.mw, f.pino, f.ino, size, f.path)
+	err := c.ec.Truncate(c.metaWrapper(), f.pino, f.ino, size, f.path)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// pwrite writes the data to the file like cfs_write, it returns the bytes written or a negative errno.
+func (c *client) pwrite(f *file, data []byte, offset int) C.ssize_t {
+	accFlags := f.flags & uint32(C.O_ACCMODE)
+	if accFlags != uint32(C.O_WRONLY) && accFlags != uint32(C.O_RDWR) {
+		return C.ssize_t(statusEACCES)
+	}
+
+	var flags int
+	var wait bool
+
+	if f.flags&uint32(C.O_DIRECT) != 0 || f.flags&uint32(C.O_SYNC) != 0 || f.flags&uint32(C.O_DSYNC) != 0 {
+		if proto.IsHot(c.volType) {
+			wait = true
+		}
+	}
+	if f.flags&uint32(C.O_APPEND) != 0 || proto.IsCold(c.volType) {
+		flags |= proto.FlagsAppend
+		flags |= proto.FlagsSyncWrite
+	}
+
+	n, err := c.write(f, offset, data, flags)
+	if err != nil {
+		if err == syscall.ENOSPC {
+			return C.ssize_t(statusENOSPC)
+		}
+		return C.ssize_t(statusEIO)
+	}
+
+	if wait {
+		if err = c.flush(f); err != nil {
+			return C.ssize_t(statusEIO)
+		}
+	}
+
+	return C.ssize_t(n)
+}
+
+// pread reads the data from the file like cfs_read, it returns the bytes read or a negative errno.
+func (c *client) pread(f *file, data []byte, offset int) C.ssize_t {
+	accFlags := f.flags & uint32(C.O_ACCMODE)
+	if accFlags == uint32(C.O_WRONLY) {
+		return C.ssize_t(statusEACCES)
+	}
+
+	n, err := c.read(f, offset, data)
+	if err != nil {
+		return C.ssize_t(statusEIO)
+	}
+
+	return C.ssize_t(n)
+}
+
 func (c *client) write(f *file, offset int, data []byte, flags int) (n int, err error) {
 	if proto.IsHot(c.volType) {
-		c.ec.GetStreamer(f.ino).SetParentInode(f.pino) // set the parent inode
+		if s := c.ec.GetStreamer(f.ino); s != nil {
+			s.SetParentInode(f.pino) // set the parent inode
+		}
 		checkFunc := func() error {
-			if !c.mw.EnableQuota {
+			if !c.mw.QuotaEnabled() {
 				return nil
 			}
 
@@ -1456,6 +2246,272 @@ func (c *client) read(f *file, offset int, data []byte) (n int, err error) {
 	return n, nil
 }
 
+func (c *client) getIoContext(id int64) *ioContext {
+	c.iolock.Lock()
+	defer c.iolock.Unlock()
+	return c.ioctxs[id]
+}
+
+func (c *client) destroyIoContexts() {
+	c.iolock.Lock()
+	ctxs := c.ioctxs
+	c.ioctxs = make(map[int64]*ioContext)
+	c.iolock.Unlock()
+	for _, ctx := range ctxs {
+		ctx.wg.Wait()
+	}
+}
+
+// ioContext runs the async requests of cfs_io_submit in goroutines,
+// and queues the completions until they are reaped by cfs_io_getevents.
+type ioContext struct {
+	id     int64
+	c      *client
+	depth  int32
+	queued int32 // submitted and not reaped
+	events chan ioEvent
+	wg     sync.WaitGroup
+}
+
+type ioEvent struct {
+	userData uint64
+	res      int64
+}
+
+func newIoContext(c *client, depth int) *ioContext {
+	return &ioContext{
+		c:      c,
+		depth:  int32(depth),
+		events: make(chan ioEvent, depth),
+	}
+}
+
+// submit runs the request in background, it returns false if the context is full.
+func (ctx *ioContext) submit(req C.struct_cfs_io_req) bool {
+	if atomic.AddInt32(&ctx.queued, 1) > ctx.depth {
+		atomic.AddInt32(&ctx.queued, -1)
+		return false
+	}
+
+	ctx.wg.Add(1)
+	go func() {
+		defer ctx.wg.Done()
+		// the channel holds depth events, so the send never blocks
+		ctx.events <- ioEvent{userData: uint64(req.user_data), res: ctx.do(req)}
+	}()
+	return true
+}
+
+func (ctx *ioContext) do(req C.struct_cfs_io_req) int64 {
+	f := ctx.c.getFile(uint(req.fd))
+	if f == nil {
+		return int64(statusEBADFD)
+	}
+	data := cBytes(req.buf, req.size)
+	if req.opcode == ioOpWrite {
+		return int64(ctx.c.pwrite(f, data, int(req.offset)))
+	}
+	return int64(ctx.c.pread(f, data, int(req.offset)))
+}
+
+// getEvents fills the events with the completions, it waits until minNr events are reaped or timeout,
+// a negative timeout means to wait forever.
+func (ctx *ioContext) getEvents(events []C.struct_cfs_io_event, minNr int, timeout time.Duration) (n int) {
+	var expire <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expire = timer.C
+	}
+
+	reap := func(ev ioEvent) {
+		events[n].user_data = C.uint64_t(ev.userData)
+		events[n].res = C.int64_t(ev.res)
+		atomic.AddInt32(&ctx.queued, -1)
+		n++
+	}
+	for n < len(events) {
+		select {
+		case ev := <-ctx.events:
+			reap(ev)
+			continue
+		default:
+		}
+		if n >= minNr {
+			return
+		}
+		select {
+		case ev := <-ctx.events:
+			reap(ev)
+		case <-expire:
+			return
+		}
+	}
+	return
+}
+
+func cBytes(buf unsafe.Pointer, size C.size_t) (data []byte) {
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&data))
+	hdr.Data = uintptr(buf)
+	hdr.Len = int(size)
+	hdr.Cap = int(size)
+	return
+}
+
+func iovecs(iov *C.struct_iovec, iovcnt C.int) (iovs []C.struct_iovec) {
+	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&iovs))
+	hdr.Data = uintptr(unsafe.Pointer(iov))
+	hdr.Len = int(iovcnt)
+	hdr.Cap = int(iovcnt)
+	return
+}
+
+// getxattr returns the value of the xattr and whether it is set, since an unset xattr reads as an empty value.
+func (c *client) getxattr(ino uint64, key string) (value []byte, found bool, err error) {
+	info, err := c.mw.XAttrGet_ll(ino, key)
+	if err != nil {
+		return
+	}
+	if value = info.Get(key); len(value) > 0 {
+		return value, true, nil
+	}
+	keys, err := c.mw.XAttrsList_ll(ino)
+	if err != nil {
+		return
+	}
+	for _, k := range keys {
+		if k == key {
+			return value, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// accessAllowed checks the mode of access(2) against the permission bits of the inode for the user.
+func accessAllowed(info *proto.InodeInfo, mode, uid, gid uint32) bool {
+	mode &= accessRead | accessWrite | accessExec
+	if mode == 0 {
+		return true
+	}
+	perm := info.Mode & uint32(os.ModePerm)
+	if uid == 0 {
+		// root may read and write anything, and execute if anyone may
+		return mode&accessExec == 0 || perm&0o111 != 0
+	}
+
+	var bits uint32
+	switch {
+	case uid == info.Uid:
+		bits = perm >> 6
+	case gid == info.Gid:
+		bits = perm >> 3
+	default:
+		bits = perm
+	}
+	return bits&mode == mode
+}
+
+// openPerm returns the permission of the ACL required by the access mode of open(2).
+func openPerm(accFlags uint32) uint16 {
+	switch accFlags {
+	case uint32(C.O_WRONLY):
+		return posixacl.PermWrite
+	case uint32(C.O_RDWR):
+		return posixacl.PermRead | posixacl.PermWrite
+	default:
+		return posixacl.PermRead
+	}
+}
+
+func callerGids() []uint32 {
+	gids := []uint32{uint32(getgid())}
+	groups, _ := getgroups()
+	for _, g := range groups {
+		gids = append(gids, uint32(g))
+	}
+	return gids
+}
+
+// getPosixAcl returns the ACL of the inode stored in the xattr key, nil if posix ACL is
+// not enabled on the volume or the inode has no such ACL.
+func (c *client) getPosixAcl(ino uint64, key string) (acl posixacl.ACL, err error) {
+	if !c.ec.GetEnablePosixAcl() {
+		return nil, nil
+	}
+	info, err := c.mw.XAttrGet_ll(ino, key)
+	if err != nil {
+		return nil, err
+	}
+	value := info.Get(key)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if acl, err = posixacl.Parse(value); err != nil {
+		log.LogWarnf("getPosixAcl: ino(%v) key(%v) err(%v)", ino, key, err)
+		return nil, syscall.EIO
+	}
+	return acl, nil
+}
+
+// checkPosixAcl checks the access ACL of the inode for the user of the process, so that
+// the ACLs set through the fuse client are not bypassed by libsdk. The inodes without
+// an access ACL are not checked, as the rwx mode is ignored by libsdk.
+func (c *client) checkPosixAcl(ino uint64, want uint16) error {
+	acl, err := c.getPosixAcl(ino, posixacl.XattrAccess)
+	if err != nil || acl == nil {
+		return err
+	}
+	info := c.ic.Get(ino)
+	if info == nil {
+		if info, err = c.mw.InodeGet_ll(ino); err != nil {
+			return err
+		}
+		c.ic.Put(info)
+	}
+	if !acl.Check(info.Uid, info.Gid, uint32(getuid()), callerGids(), want) {
+		log.LogWarnf("checkPosixAcl: denied, ino(%v) uid(%v) want(%v)", ino, getuid(), want)
+		return syscall.EACCES
+	}
+	return nil
+}
+
+// checkSetXattr checks whether the user of the process may set or remove the xattr key of
+// the inode, a nil value means to remove. Like the kernel, the ACLs may only be changed by
+// the owner and must be valid, and the other xattrs require the write permission.
+func (c *client) checkSetXattr(info *proto.InodeInfo, key string, value []byte) error {
+	if key != posixacl.XattrAccess && key != posixacl.XattrDefault {
+		return c.checkPosixAcl(info.Inode, posixacl.PermWrite)
+	}
+	if uid := uint32(getuid()); uid != 0 && uid != info.Uid {
+		log.LogWarnf("checkSetXattr: not the owner, ino(%v) uid(%v) owner(%v) key(%v)", info.Inode, uid, info.Uid, key)
+		return syscall.EPERM
+	}
+	if value != nil {
+		if _, err := posixacl.Parse(value); err != nil {
+			return syscall.EINVAL
+		}
+	}
+	return nil
+}
+
+// inheritPosixAcl derives the mode and the xattrs of the ACLs of an inode to be created
+// in the directory pino from the default ACL of the directory.
+func (c *client) inheritPosixAcl(pino uint64, mode uint32, isDir bool) (newMode uint32, xattrs map[string]string, err error) {
+	def, err := c.getPosixAcl(pino, posixacl.XattrDefault)
+	if err != nil || def == nil {
+		return mode, nil, err
+	}
+	access, newMode := def.Inherit(mode)
+	xattrs = make(map[string]string)
+	if access != nil {
+		xattrs[posixacl.XattrAccess] = string(access.Marshal())
+	}
+	if isDir {
+		xattrs[posixacl.XattrDefault] = string(def.Marshal())
+	}
+	return newMode, xattrs, nil
+}
+
 func (c *client) ctx(cid int64, ino uint64) context.Context {
 	_, ctx := trace.StartSpanFromContextWithTraceID(context.Background(), "", fmt.Sprintf("cid=%v,ino=%v", cid, ino))
 	return ctx
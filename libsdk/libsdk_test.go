@@ -0,0 +1,358 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/mocktest/fsmock"
+	"github.com/cubefs/cubefs/util/posixacl"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestClient replaces the meta wrapper and the extent client of the client id with fakes.
+func setupTestClient(t *testing.T, id int64) (*fsmock.MetaWrapper, *fsmock.ExtentClient) {
+	c, exist := getClient(id)
+	require.True(t, exist)
+	mw := fsmock.NewMetaWrapper()
+	ec := fsmock.NewExtentClient(mw)
+	c.mw, c.ec = mw, ec
+	t.Cleanup(func() { removeClient(id) })
+	return mw, ec
+}
+
+func TestLibsdk_Link(t *testing.T) {
+	id := cfs_new_client()
+	setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	cfs_close(id, fd)
+	require.Equal(t, statusOK, cfs_mkdirs(id, cString("/dir"), 0o755))
+
+	require.Equal(t, statusOK, cfs_symlink(id, cString("/file"), cString("/sym")))
+	require.Equal(t, statusEEXIST, cfs_symlink(id, cString("/file"), cString("/sym")))
+	require.Equal(t, errorToStatus(syscall.ENOENT), cfs_symlink(id, cString("/file"), cString("/none/sym")))
+
+	buf := make([]byte, 16)
+	n := cfs_readlink(id, cString("/sym"), cBuf(buf), cSize(len(buf)))
+	require.EqualValues(t, len("/file"), n)
+	require.Equal(t, "/file", string(buf[:n]))
+	// truncated to the buffer like readlink(2)
+	n = cfs_readlink(id, cString("/sym"), cBuf(buf), 3)
+	require.EqualValues(t, 3, n)
+	require.EqualValues(t, statusEINVAL, cfs_readlink(id, cString("/file"), cBuf(buf), cSize(len(buf))))
+	require.EqualValues(t, errorToStatus(syscall.ENOENT), cfs_readlink(id, cString("/none"), cBuf(buf), cSize(len(buf))))
+
+	require.Equal(t, statusOK, cfs_link(id, cString("/file"), cString("/dir/link")))
+	st := newStatInfo()
+	require.Equal(t, statusOK, cfs_getattr(id, cString("/dir/link"), st))
+	require.EqualValues(t, 2, st.nlink)
+	require.Equal(t, statusEEXIST, cfs_link(id, cString("/file"), cString("/dir/link")))
+	require.Equal(t, errorToStatus(syscall.ENOENT), cfs_link(id, cString("/none"), cString("/dir/link2")))
+	require.Equal(t, statusEPERM, cfs_link(id, cString("/dir"), cString("/dir2")))
+}
+
+func TestLibsdk_Xattr(t *testing.T) {
+	id := cfs_new_client()
+	setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	cfs_close(id, fd)
+
+	path, name := cString("/file"), cString("user.k")
+	value := []byte("value")
+	setxattr := func(v []byte, flags int) int {
+		return int(cfs_setxattr(id, path, name, unsafe.Pointer(&v[0]), cSize(len(v)), cInt(flags)))
+	}
+	require.EqualValues(t, statusENODATA, setxattr(value, xattrReplace))
+	require.EqualValues(t, statusOK, setxattr(value, xattrCreate))
+	require.EqualValues(t, statusEEXIST, setxattr(value, xattrCreate))
+	require.EqualValues(t, statusOK, setxattr([]byte("value2"), xattrReplace))
+	require.EqualValues(t, errorToStatus(syscall.ENOENT),
+		cfs_setxattr(id, cString("/none"), name, unsafe.Pointer(&value[0]), cSize(len(value)), 0))
+
+	buf := make([]byte, 16)
+	require.EqualValues(t, 6, cfs_getxattr(id, path, name, nil, 0))
+	require.EqualValues(t, statusERANGE, cfs_getxattr(id, path, name, unsafe.Pointer(&buf[0]), 2))
+	n := cfs_getxattr(id, path, name, unsafe.Pointer(&buf[0]), cSize(len(buf)))
+	require.Equal(t, "value2", string(buf[:n]))
+	require.EqualValues(t, statusENODATA, cfs_getxattr(id, path, cString("user.none"), unsafe.Pointer(&buf[0]), cSize(len(buf))))
+	require.EqualValues(t, errorToStatus(syscall.ENOENT), cfs_getxattr(id, cString("/none"), name, unsafe.Pointer(&buf[0]), cSize(len(buf))))
+
+	require.EqualValues(t, len("user.k\x00"), cfs_listxattr(id, path, nil, 0))
+	n = cfs_listxattr(id, path, cBuf(buf), cSize(len(buf)))
+	require.Equal(t, "user.k\x00", string(buf[:n]))
+	require.EqualValues(t, statusERANGE, cfs_listxattr(id, path, cBuf(buf), 2))
+
+	require.Equal(t, statusOK, cfs_removexattr(id, path, name))
+	require.Equal(t, statusENODATA, cfs_removexattr(id, path, name))
+	require.Equal(t, errorToStatus(syscall.ENOENT), cfs_removexattr(id, cString("/none"), name))
+}
+
+func TestLibsdk_Truncate(t *testing.T) {
+	id := cfs_new_client()
+	_, ec := setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	data := []byte("0123456789")
+	require.EqualValues(t, len(data), cfs_write(id, fd, unsafe.Pointer(&data[0]), cSize(len(data)), 0))
+	require.Equal(t, statusOK, cfs_mkdirs(id, cString("/dir"), 0o755))
+
+	st := newStatInfo()
+	require.Equal(t, statusOK, cfs_truncate(id, cString("/file"), 4))
+	require.Equal(t, statusOK, cfs_getattr(id, cString("/file"), st))
+	require.EqualValues(t, 4, st.size)
+	require.Equal(t, statusEISDIR, cfs_truncate(id, cString("/dir"), 0))
+	require.Equal(t, statusEINVAL, cfs_truncate(id, cString("/file"), -1))
+	require.Equal(t, errorToStatus(syscall.ENOENT), cfs_truncate(id, cString("/none"), 0))
+
+	require.Equal(t, statusOK, cfs_ftruncate(id, fd, 2))
+	require.Equal(t, statusOK, cfs_getattr(id, cString("/file"), st))
+	require.EqualValues(t, 2, st.size)
+	require.Equal(t, statusEBADFD, cfs_ftruncate(id, fd+1, 0))
+	rfd := cfs_open(id, cString("/file"), syscall.O_RDONLY, 0)
+	require.True(t, rfd > 0)
+	require.Equal(t, statusEINVAL, cfs_ftruncate(id, rfd, 0))
+
+	require.Equal(t, statusOK, cfs_fsync(id, fd))
+	require.Equal(t, statusEBADFD, cfs_fsync(id, fd+2))
+	// the errno of the flush is returned as is
+	ec.FlushErr = syscall.ENOSPC
+	require.Equal(t, statusENOSPC, cfs_fsync(id, fd))
+	ec.FlushErr = nil
+	cfs_close(id, rfd)
+	cfs_close(id, fd)
+	require.Equal(t, statusEBADFD, cfs_fsync(id, fd))
+	require.Zero(t, ec.OpenedStreams())
+}
+
+func TestLibsdk_StatfsAccess(t *testing.T) {
+	id := cfs_new_client()
+	mw, _ := setupTestClient(t, int64(id))
+
+	st := newStatfsInfo()
+	mw.TotalSpace, mw.UsedSpace = 1<<20, 1<<18
+	require.Equal(t, statusOK, cfs_statfs(id, st))
+	require.EqualValues(t, 256, st.blocks)
+	require.EqualValues(t, 192, st.bfree)
+	require.Equal(t, st.bfree, st.bavail)
+
+	// the used space exceeds the total one after the capacity is reduced
+	mw.UsedSpace = 1 << 21
+	require.Equal(t, statusOK, cfs_statfs(id, st))
+	require.EqualValues(t, 0, st.bfree)
+	require.EqualValues(t, 0, st.bavail)
+
+	require.Equal(t, errorToStatus(syscall.ENOENT), cfs_access(id, cString("/none"), accessRead))
+	require.Equal(t, statusOK, cfs_access(id, cString("/"), accessRead|accessExec))
+}
+
+func TestLibsdk_PosixAcl(t *testing.T) {
+	id := cfs_new_client()
+	mw, ec := setupTestClient(t, int64(id))
+	ec.EnablePosixAcl = true
+	defer func() { getuid, getgid, getgroups = os.Getuid, os.Getgid, os.Getgroups }()
+	setIdentity := func(uid int) {
+		getuid = func() int { return uid }
+		getgid = func() int { return uid }
+		getgroups = func() ([]int, error) { return nil, nil }
+	}
+	setIdentity(0)
+
+	// user::rwx user:1001:rwx group::r-x mask::rwx other::r-x
+	acl := posixacl.ACL{
+		{Tag: posixacl.TagUserObj, Perm: posixacl.PermRWX, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagUser, Perm: posixacl.PermRWX, Id: 1001},
+		{Tag: posixacl.TagGroupObj, Perm: posixacl.PermRead | posixacl.PermExec, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagMask, Perm: posixacl.PermRWX, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagOther, Perm: posixacl.PermRead | posixacl.PermExec, Id: posixacl.UndefinedId},
+	}
+	value := acl.Marshal()
+	setAcl := func(path string) int {
+		return int(cfs_setxattr(id, cString(path), cString(posixacl.XattrAccess), unsafe.Pointer(&value[0]), cSize(len(value)), 0))
+	}
+	for _, path := range []string{"/dir", "/dir/file", "/file"} {
+		if path == "/dir" {
+			require.Equal(t, statusOK, cfs_mkdirs(id, cString(path), 0o755))
+		} else {
+			fd := cfs_open(id, cString(path), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+			require.True(t, fd > 0)
+			cfs_close(id, fd)
+		}
+		require.EqualValues(t, statusOK, setAcl(path))
+	}
+
+	// the user 1000 falls into other, which may not write the files or the directory
+	setIdentity(1000)
+	eacces := errorToStatus(syscall.EACCES)
+	require.Equal(t, eacces, cfs_rename(id, cString("/dir/file"), cString("/file2")))
+	require.Equal(t, eacces, cfs_rename(id, cString("/file"), cString("/dir/file2")))
+	require.Equal(t, eacces, cfs_link(id, cString("/file"), cString("/dir/link")))
+	require.Equal(t, eacces, cfs_symlink(id, cString("/file"), cString("/dir/sym")))
+	require.Equal(t, eacces, cfs_clone_file(id, cString("/file"), cString("/dir/clone")))
+	require.Equal(t, eacces, cfs_truncate(id, cString("/file"), 0))
+	data := []byte("v")
+	require.Equal(t, eacces, cfs_setxattr(id, cString("/file"), cString("user.k"), unsafe.Pointer(&data[0]), 1, 0))
+	require.Equal(t, eacces, cfs_removexattr(id, cString("/file"), cString("user.k")))
+	// only the owner may change the ACLs
+	require.EqualValues(t, statusEPERM, setAcl("/file"))
+	require.Equal(t, statusEPERM, cfs_removexattr(id, cString("/file"), cString(posixacl.XattrAccess)))
+
+	// the named user 1001 is allowed
+	setIdentity(1001)
+	require.Equal(t, statusOK, cfs_rename(id, cString("/dir/file"), cString("/dir/file2")))
+	require.Equal(t, statusOK, cfs_link(id, cString("/file"), cString("/dir/link")))
+	require.Equal(t, statusOK, cfs_symlink(id, cString("/file"), cString("/dir/sym")))
+	require.Equal(t, statusOK, cfs_truncate(id, cString("/file"), 0))
+	require.Equal(t, statusOK, cfs_setxattr(id, cString("/file"), cString("user.k"), unsafe.Pointer(&data[0]), 1, 0))
+	require.Equal(t, statusOK, cfs_removexattr(id, cString("/file"), cString("user.k")))
+	require.EqualValues(t, statusEPERM, setAcl("/file"))
+
+	// the owner may change the ACL, which must be valid
+	ino, err := mw.LookupPath("/file")
+	require.NoError(t, err)
+	require.NoError(t, mw.Setattr(ino, proto.AttrUid, 0, 1001, 0, 0, 0))
+	c, _ := getClient(int64(id))
+	c.ic.Delete(ino)
+	require.EqualValues(t, statusOK, setAcl("/file"))
+	require.Equal(t, statusEINVAL, cfs_setxattr(id, cString("/file"), cString(posixacl.XattrAccess), unsafe.Pointer(&data[0]), 1, 0))
+	require.Equal(t, statusOK, cfs_removexattr(id, cString("/file"), cString(posixacl.XattrAccess)))
+}
+
+func TestLibsdk_Vectored(t *testing.T) {
+	id := cfs_new_client()
+	setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	defer cfs_close(id, fd)
+
+	iov, cnt := newIovecs([]byte("0123"), nil, []byte("456"))
+	require.EqualValues(t, 7, cfs_pwritev(id, fd, iov, cnt, 2))
+
+	// the file is 9 bytes, the read stops in the second iovec at the end of the file
+	b1, b2, b3 := make([]byte, 4), make([]byte, 4), make([]byte, 4)
+	iov, cnt = newIovecs(b1, b2, b3)
+	require.EqualValues(t, 6, cfs_preadv(id, fd, iov, cnt, 3))
+	require.Equal(t, "1234", string(b1))
+	require.Equal(t, "56", string(b2[:2]))
+	require.Equal(t, make([]byte, 4), b3)
+	// nothing is read beyond the end of the file
+	require.EqualValues(t, 0, cfs_preadv(id, fd, iov, cnt, 9))
+
+	require.EqualValues(t, statusEBADFD, cfs_preadv(id, fd+1, iov, cnt, 0))
+	require.EqualValues(t, statusEINVAL, cfs_pwritev(id, fd, iov, -1, 0))
+	require.EqualValues(t, statusEINVAL, cfs_pwritev(id, fd, iov, maxIovCnt+1, 0))
+}
+
+func TestLibsdk_AsyncIO(t *testing.T) {
+	id := cfs_new_client()
+	_, ec := setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	defer cfs_close(id, fd)
+	data := []byte("0123456789")
+	require.EqualValues(t, len(data), cfs_write(id, fd, unsafe.Pointer(&data[0]), cSize(len(data)), 0))
+
+	// the reads at the offset 0 are blocked until released
+	release := make(chan struct{})
+	ec.ReadHook = func(inode uint64, offset int) {
+		if offset == 0 {
+			<-release
+		}
+	}
+
+	require.EqualValues(t, statusEINVAL, cfs_io_setup(id, 0))
+	ctxID := cfs_io_setup(id, 2)
+	require.True(t, ctxID > 0)
+	evs := newIoEvents(4)
+
+	// the events are reaped in the order of completion
+	b1, b2 := make([]byte, 4), make([]byte, 4)
+	reqs, nr := newIoReqs(newIoReq(1, fd, false, b1, 0), newIoReq(2, fd, false, b2, 4))
+	require.EqualValues(t, 2, cfs_io_submit(id, ctxID, reqs, nr))
+	// the context is full
+	require.EqualValues(t, statusEAGAIN, cfs_io_submit(id, ctxID, reqs, 1))
+
+	require.EqualValues(t, 1, cfs_io_getevents(id, ctxID, 1, 2, &evs[0], 1000))
+	require.EqualValues(t, 2, evs[0].user_data)
+	require.EqualValues(t, 4, evs[0].res)
+	require.Equal(t, "4567", string(b2))
+	// times out without enough completions
+	start := time.Now()
+	require.EqualValues(t, 0, cfs_io_getevents(id, ctxID, 1, 2, &evs[0], 50))
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+	close(release)
+	require.EqualValues(t, 1, cfs_io_getevents(id, ctxID, 1, 2, &evs[0], -1))
+	require.EqualValues(t, 1, evs[0].user_data)
+	require.Equal(t, "0123", string(b1))
+	require.EqualValues(t, 0, cfs_io_getevents(id, ctxID, 0, 2, &evs[0], 0))
+
+	// a bad fd fails the request but not the submit
+	reqs, nr = newIoReqs(newIoReq(3, fd+1, false, b1, 0))
+	require.EqualValues(t, 1, cfs_io_submit(id, ctxID, reqs, nr))
+	require.EqualValues(t, 1, cfs_io_getevents(id, ctxID, 1, 1, &evs[0], -1))
+	require.EqualValues(t, 3, evs[0].user_data)
+	require.EqualValues(t, statusEBADFD, evs[0].res)
+	require.EqualValues(t, statusEINVAL, cfs_io_getevents(id, ctxID, 2, 1, &evs[0], 0))
+	require.EqualValues(t, statusEINVAL, cfs_io_submit(id, ctxID+1, reqs, nr))
+	require.EqualValues(t, statusOK, cfs_io_destroy(id, ctxID))
+	require.EqualValues(t, statusEINVAL, cfs_io_destroy(id, ctxID))
+}
+
+func TestLibsdk_AsyncIODestroy(t *testing.T) {
+	id := cfs_new_client()
+	_, ec := setupTestClient(t, int64(id))
+
+	fd := cfs_open(id, cString("/file"), syscall.O_RDWR|syscall.O_CREAT, 0o644)
+	require.True(t, fd > 0)
+	defer cfs_close(id, fd)
+	data := []byte("0123")
+	require.EqualValues(t, len(data), cfs_write(id, fd, unsafe.Pointer(&data[0]), cSize(len(data)), 0))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	ec.ReadHook = func(inode uint64, offset int) {
+		close(started)
+		<-release
+	}
+	ctxID := cfs_io_setup(id, 1)
+	buf := make([]byte, 4)
+	reqs, nr := newIoReqs(newIoReq(1, fd, false, buf, 0))
+	require.EqualValues(t, 1, cfs_io_submit(id, ctxID, reqs, nr))
+	<-started
+
+	// destroying waits for the request in flight, so the buffer may be freed after it returns
+	done := make(chan int)
+	go func() { done <- int(cfs_io_destroy(id, ctxID)) }()
+	select {
+	case <-done:
+		t.Fatal("destroyed with a request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	require.EqualValues(t, statusOK, <-done)
+	require.Equal(t, "0123", string(buf))
+	evs := newIoEvents(1)
+	require.EqualValues(t, statusEINVAL, cfs_io_getevents(id, ctxID, 0, 1, &evs[0], 0))
+}
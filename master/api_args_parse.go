@@ -165,7 +165,7 @@ func parseRequestForUpdateMetaNode(r *http.Request) (nodeAddr string, id uint64,
 	return
 }
 
-func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err error) {
+func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName, rackName string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -175,6 +175,7 @@ func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err err
 	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
 		zoneName = DefaultZoneName
 	}
+	rackName = r.FormValue(rackNameKey)
 	return
 }
 
@@ -375,6 +376,28 @@ func extractBoolWithDefault(r *http.Request, key string, def bool) (val bool, er
 	return val, nil
 }
 
+// extractCompressCodec parses the data compression codec of a volume, "none" turns compression off.
+func extractCompressCodec(r *http.Request, volType int, def string) (codec string, err error) {
+	var str string
+	if str = r.FormValue(compressCodecKey); str == "" {
+		return def, nil
+	}
+
+	if str == "none" {
+		return "", nil
+	}
+
+	if _, ok := compressor.Codec(str); !ok {
+		return "", fmt.Errorf("parse [%s] unsupported codec [%s], should be none, gzip, lz4 or zstd", compressCodecKey, str)
+	}
+
+	if !proto.IsHot(volType) {
+		return "", fmt.Errorf("data compression is only supported by hot volumes")
+	}
+
+	return str, nil
+}
+
 type updateVolReq struct {
 	name                    string
 	authKey                 string
@@ -396,6 +419,7 @@ type updateVolReq struct {
 	coldArgs                *coldVolArgs
 	dpReadOnlyWhenVolFull   bool
 	enableQuota             bool
+	compressCodec           string
 }
 
 func parseColdVolUpdateArgs(r *http.Request, vol *Vol) (args *coldVolArgs, err error) {
@@ -528,6 +552,10 @@ func parseVolUpdateReq(r *http.Request, vol *Vol, req *updateVolReq) (err error)
 		return
 	}
 
+	if req.compressCodec, err = extractCompressCodec(r, vol.VolType, vol.compressCodec); err != nil {
+		return
+	}
+
 	req.dpSelectorName = r.FormValue(dpSelectorNameKey)
 	req.dpSelectorParm = r.FormValue(dpSelectorParmKey)
 
@@ -661,6 +689,8 @@ type createVolReq struct {
 	DpReadOnlyWhenVolFull                bool
 	enableTransaction                    proto.TxOpMask
 	enableQuota                          bool
+	metaStoreMode                        proto.MetaStoreMode
+	compressCodec                        string
 	txTimeout                            int64
 	txConflictRetryNum                   int64
 	txConflictRetryInterval              int64
@@ -804,6 +834,14 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 
 	req.enablePosixAcl, _ = extractPosixAcl(r)
 
+	if req.metaStoreMode, err = proto.ParseMetaStoreMode(extractStr(r, metaStoreModeKey)); err != nil {
+		return
+	}
+
+	if req.compressCodec, err = extractCompressCodec(r, req.volType, ""); err != nil {
+		return
+	}
+
 	if req.DpReadOnlyWhenVolFull, err = extractBoolWithDefault(r, dpReadOnlyWhenVolFull, false); err != nil {
 		return
 	}
@@ -1259,6 +1297,15 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		params[clusterCreateTimeKey] = value
 	}
 
+	// the rack of a single node, an empty rack name clears the rack of the node
+	if _, ok := r.Form[rackNameKey]; ok {
+		noParams = false
+		if params[addrKey], err = extractNodeAddr(r); err != nil {
+			return
+		}
+		params[rackNameKey] = r.FormValue(rackNameKey)
+	}
+
 	if value = extractDataNodesetSelector(r); value != "" {
 		noParams = false
 		params[dataNodesetSelectorKey] = value
@@ -1765,6 +1812,76 @@ func parseGetQuotaParam(r *http.Request) (volName string, quotaId uint32, err er
 	return
 }
 
+func parseSetOwnerQuotaParam(r *http.Request, req *proto.SetOwnerQuotaRequest) (err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if req.VolName, err = extractName(r); err != nil {
+		return
+	}
+	if req.Type, req.Id, err = extractQuotaOwner(r); err != nil {
+		return
+	}
+	if req.HardFiles, err = extractUint64WithDefault(r, hardFilesKey, 0); err != nil {
+		return
+	}
+	if req.HardBytes, err = extractUint64WithDefault(r, hardBytesKey, 0); err != nil {
+		return
+	}
+	if req.SoftFiles, err = extractUint64WithDefault(r, softFilesKey, 0); err != nil {
+		return
+	}
+	if req.SoftBytes, err = extractUint64WithDefault(r, softBytesKey, 0); err != nil {
+		return
+	}
+	if req.GracePeriod, err = extractInt64WithDefault(r, gracePeriodKey, 0); err != nil {
+		return
+	}
+	return
+}
+
+func parseOwnerQuotaParam(r *http.Request) (volName string, typ uint8, id uint32, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	typ, id, err = extractQuotaOwner(r)
+	return
+}
+
+func parseOwnerQuotaReportParam(r *http.Request) (volName string, typ uint8, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	if value := r.FormValue(ownerTypeKey); value != "" {
+		typ, err = proto.ParseOwnerQuotaType(value)
+	}
+	return
+}
+
+func extractQuotaOwner(r *http.Request) (typ uint8, id uint32, err error) {
+	var value string
+	if value = r.FormValue(ownerTypeKey); value == "" {
+		err = keyNotFound(ownerTypeKey)
+		return
+	}
+	if typ, err = proto.ParseOwnerQuotaType(value); err != nil {
+		return
+	}
+	if value = r.FormValue(ownerIdKey); value == "" {
+		err = keyNotFound(ownerIdKey)
+		return
+	}
+	tmp, err := strconv.ParseUint(value, 10, 32)
+	id = uint32(tmp)
+	return
+}
+
 func extractQuotaId(r *http.Request) (quotaId uint32, err error) {
 	var value string
 	if value = r.FormValue(quotaKey); value == "" {
@@ -1802,3 +1919,39 @@ func parseS3QosReq(r *http.Request, req *proto.S3QosRequest) (err error) {
 	log.LogInfo("parseS3QosReq success.")
 	return
 }
+
+// parseRequestToSetDpBalance updates the config of the data partition balancer by the params in the request
+func parseRequestToSetDpBalance(r *http.Request, config proto.DpBalanceConfig) (proto.DpBalanceConfig, error) {
+	if err := r.ParseForm(); err != nil {
+		return config, err
+	}
+	noParams := true
+	if value := r.FormValue(enableKey); value != "" {
+		noParams = false
+		enable, err := strconv.ParseBool(value)
+		if err != nil {
+			return config, unmatchedKey(enableKey)
+		}
+		config.Enable = enable
+	}
+	if value := r.FormValue(thresholdKey); value != "" {
+		noParams = false
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 || threshold >= 1 {
+			return config, fmt.Errorf("args [%s] should be in (0, 1), val %s", thresholdKey, value)
+		}
+		config.Threshold = threshold
+	}
+	if value := r.FormValue(maxMigrationsKey); value != "" {
+		noParams = false
+		maxMigrations, err := extractPositiveUint(r, maxMigrationsKey)
+		if err != nil {
+			return config, err
+		}
+		config.MaxMigrations = maxMigrations
+	}
+	if noParams {
+		return config, keyNotFound(enableKey)
+	}
+	return config, nil
+}
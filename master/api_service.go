@@ -316,6 +316,50 @@ func (m *Server) setEnableAuditLogForVolume(w http.ResponseWriter, r *http.Reque
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set volume audit log to (%v) success", status)))
 }
 
+// setEnableReplicationForVolume is called by objectnode when the replication configuration of
+// the bucket is put or deleted, lcnode only replicates the volumes with replication enabled.
+func (m *Server) setEnableReplicationForVolume(w http.ResponseWriter, r *http.Request) {
+	var (
+		status bool
+		name   string
+		err    error
+	)
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminVolEnableReplication))
+	defer func() {
+		doStatAndMetric(proto.AdminVolEnableReplication, metric, err, nil)
+		if err != nil {
+			log.LogErrorf("set volume replication failed, error: %v", err)
+		} else {
+			log.LogInfof("set volume(%v) replication to (%v) success", name, status)
+		}
+	}()
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if status, err = parseAndExtractStatus(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	vol, err := m.cluster.getVol(name)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	oldEnable := vol.EnableReplication
+	vol.EnableReplication = status
+	defer func() {
+		if err != nil {
+			vol.EnableReplication = oldEnable
+		}
+	}()
+	if err = m.cluster.syncUpdateVol(vol); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set volume replication to (%v) success", status)))
+}
+
 func (m *Server) setupForbidMetaPartitionDecommission(w http.ResponseWriter, r *http.Request) {
 	var (
 		status bool
@@ -2294,6 +2338,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.txConflictRetryInterval = req.txConflictRetryInterval
 	newArgs.txOpLimit = req.txOpLimit
 	newArgs.enableQuota = req.enableQuota
+	newArgs.compressCodec = req.compressCodec
 	if req.coldArgs != nil {
 		newArgs.coldArgs = req.coldArgs
 	}
@@ -2649,6 +2694,8 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		TxConflictRetryNum:      vol.txConflictRetryNum,
 		TxConflictRetryInterval: vol.txConflictRetryInterval,
 		TxOpLimit:               vol.txOpLimit,
+		MetaStoreMode:           vol.metaStoreMode.String(),
+		CompressCodec:           vol.compressCodec,
 		NeedToLowerReplica:      vol.NeedToLowerReplica,
 		Authenticate:            vol.authenticate,
 		CrossZone:               vol.crossZone,
@@ -2678,6 +2725,7 @@ func newSimpleView(vol *Vol) (view *proto.SimpleVolView) {
 		Forbidden:               vol.Forbidden,
 		EnableAuditLog:          vol.EnableAuditLog,
 		DeleteExecTime:          vol.DeleteExecTime,
+		EnableReplication:       vol.EnableReplication,
 	}
 
 	vol.uidSpaceManager.RLock()
@@ -2720,6 +2768,7 @@ func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr  string
 		zoneName  string
+		rackName  string
 		id        uint64
 		err       error
 		nodesetId uint64
@@ -2729,7 +2778,7 @@ func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
 		doStatAndMetric(proto.AddDataNode, metric, err, nil)
 	}()
 
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if nodeAddr, zoneName, rackName, err = parseRequestForAddNode(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -2746,7 +2795,7 @@ func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	if id, err = m.cluster.addDataNode(nodeAddr, zoneName, nodesetId); err != nil {
+	if id, err = m.cluster.addDataNode(nodeAddr, zoneName, rackName, nodesetId); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -2783,6 +2832,7 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		AvailableSpace:            dataNode.AvailableSpace,
 		ID:                        dataNode.ID,
 		ZoneName:                  dataNode.ZoneName,
+		RackName:                  dataNode.RackName,
 		Addr:                      dataNode.Addr,
 		DomainAddr:                dataNode.DomainAddr,
 		ReportTime:                dataNode.ReportTime,
@@ -2799,6 +2849,8 @@ func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
 		MaxDpCntLimit:             dataNode.GetDpCntLimit(),
 		CpuUtil:                   dataNode.CpuUtil.Load(),
 		IoUtils:                   dataNode.GetIoUtils(),
+		ScrubStats:                dataNode.ScrubStats,
+		CorruptExtents:            dataNode.CorruptExtents,
 	}
 
 	sendOkReply(w, r, newSuccessHTTPReply(dataNodeInfo))
@@ -3070,6 +3122,13 @@ func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if val, ok := params[rackNameKey]; ok {
+		if err = m.setNodeRack(params[addrKey].(string), val.(string)); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
+
 	dataNodesetSelector := extractDataNodesetSelector(r)
 	metaNodesetSelector := extractMetaNodesetSelector(r)
 	dataNodeSelector := extractDataNodeSelector(r)
@@ -3325,6 +3384,28 @@ func (m *Server) setNodeRdOnly(addr string, nodeType uint32, rdOnly bool) (err e
 	return
 }
 
+func (m *Server) setNodeRack(addr string, rackName string) (err error) {
+	if value, ok := m.cluster.dataNodes.Load(addr); ok {
+		m.cluster.dnMutex.Lock()
+		defer m.cluster.dnMutex.Unlock()
+		if err = m.cluster.setDataNodeRack(value.(*DataNode), rackName); err != nil {
+			return fmt.Errorf("[setNodeRack] syncUpdateDataNode err(%s)", err.Error())
+		}
+		return
+	}
+
+	value, ok := m.cluster.metaNodes.Load(addr)
+	if !ok {
+		return fmt.Errorf("[setNodeRack] node %s is not exist", addr)
+	}
+	m.cluster.mnMutex.Lock()
+	defer m.cluster.mnMutex.Unlock()
+	if err = m.cluster.setMetaNodeRack(value.(*MetaNode), rackName); err != nil {
+		return fmt.Errorf("[setNodeRack] syncUpdateMetaNode err(%s)", err.Error())
+	}
+	return
+}
+
 func (m *Server) updateNodesetCapcity(zoneName string, nodesetId uint64, capcity uint64) (err error) {
 	var ns *nodeSet
 	var ok bool
@@ -3396,6 +3477,7 @@ func (m *Server) buildNodeSetGrpInfo(nsg *nodeSetGroup) *proto.SimpleNodeSetGrpI
 				AvailableSpace:     node.AvailableSpace,
 				ID:                 node.ID,
 				ZoneName:           node.ZoneName,
+				RackName:           node.RackName,
 				Addr:               node.Addr,
 				ReportTime:         node.ReportTime,
 				IsActive:           node.isActive,
@@ -3424,6 +3506,7 @@ func (m *Server) buildNodeSetGrpInfo(nsg *nodeSetGroup) *proto.SimpleNodeSetGrpI
 				IsActive:           node.IsActive,
 				IsWriteAble:        node.isWritable(),
 				ZoneName:           node.ZoneName,
+				RackName:           node.RackName,
 				MaxMemAvailWeight:  node.MaxMemAvailWeight,
 				Total:              node.Total,
 				Used:               node.Used,
@@ -4252,6 +4335,7 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr  string
 		zoneName  string
+		rackName  string
 		id        uint64
 		err       error
 		nodesetId uint64
@@ -4261,7 +4345,7 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 		doStatAndMetric(proto.AddMetaNode, metric, err, nil)
 	}()
 
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if nodeAddr, zoneName, rackName, err = parseRequestForAddNode(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -4278,7 +4362,7 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName, nodesetId); err != nil {
+	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName, rackName, nodesetId); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -4368,6 +4452,7 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		IsActive:                  metaNode.IsActive,
 		IsWriteAble:               metaNode.isWritable(),
 		ZoneName:                  metaNode.ZoneName,
+		RackName:                  metaNode.RackName,
 		MaxMemAvailWeight:         metaNode.MaxMemAvailWeight,
 		Total:                     metaNode.Total,
 		Used:                      metaNode.Used,
@@ -5141,6 +5226,7 @@ func (m *Server) listVols(w http.ResponseWriter, r *http.Request) {
 			stat := volStat(vol, false)
 			volInfo := proto.NewVolInfo(vol.Name, vol.Owner, vol.createTime, vol.status(), stat.TotalSize,
 				stat.UsedSize, stat.DpReadOnlyWhenVolFull)
+			volInfo.EnableReplication = vol.EnableReplication
 			volsInfo = append(volsInfo, volInfo)
 		}
 	}
@@ -5349,6 +5435,54 @@ func (m *Server) SetVerStrategy(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply("success"))
 }
 
+func (m *Server) SetSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+	if bytes, err = io.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	req := proto.SnapshotRetentionConfig{}
+	if err = json.Unmarshal(bytes, &req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = req.Validate(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if err = m.cluster.SetSnapshotRetention(req.VolName, req.Rules); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply("success"))
+}
+
+func (m *Server) GetSnapshotRetention(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		name string
+		vol  *Vol
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(&proto.SnapshotRetentionConfig{
+		VolName: name,
+		Rules:   vol.VersionMgr.getRetention(),
+	}))
+}
+
 func (m *Server) getVolVer(w http.ResponseWriter, r *http.Request) {
 	var (
 		err  error
@@ -5719,6 +5853,52 @@ func (m *Server) setCheckDataReplicasEnable(w http.ResponseWriter, r *http.Reque
 		"set checkDataReplicasEnable to [%v] successfully", enable)))
 }
 
+func (m *Server) setDpBalance(w http.ResponseWriter, r *http.Request) {
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminSetDpBalance))
+	defer func() {
+		doStatAndMetric(proto.AdminSetDpBalance, metric, nil, nil)
+	}()
+
+	oldConfig := m.cluster.dpBalancer.getConfig()
+	config, err := parseRequestToSetDpBalance(r, oldConfig)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	m.cluster.dpBalancer.setConfig(config)
+	if err = m.cluster.syncPutCluster(); err != nil {
+		m.cluster.dpBalancer.setConfig(oldConfig)
+		log.LogErrorf("action[setDpBalance] syncPutCluster failed %v", err)
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrPersistenceByRaft))
+		return
+	}
+
+	log.LogInfof("action[setDpBalance] config be set [%+v]", config)
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set dp balance config to [%+v] successfully", config)))
+}
+
+// getDpBalancePlan returns the migrations that the data partition balancer would start now, nothing is migrated.
+func (m *Server) getDpBalancePlan(w http.ResponseWriter, r *http.Request) {
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.AdminGetDpBalancePlan))
+	defer func() {
+		doStatAndMetric(proto.AdminGetDpBalancePlan, metric, nil, nil)
+	}()
+
+	config := m.cluster.dpBalancer.getConfig()
+	if err := r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	count, err := extractUintWithDefault(r, countKey, config.MaxMigrations)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.dpBalancer.plan(config, count)))
+}
+
 func (m *Server) setFileStats(w http.ResponseWriter, r *http.Request) {
 	var (
 		err    error
@@ -6065,6 +6245,137 @@ func (m *Server) GetQuota(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(quotaInfo))
 }
 
+func (m *Server) SetOwnerQuota(w http.ResponseWriter, r *http.Request) {
+	req := &proto.SetOwnerQuotaRequest{}
+	var (
+		err error
+		vol *Vol
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.QuotaOwnerSet))
+	defer func() {
+		doStatAndMetric(proto.QuotaOwnerSet, metric, err, map[string]string{exporter.Vol: req.VolName})
+	}()
+
+	if err = parseSetOwnerQuotaParam(r, req); err != nil {
+		log.LogErrorf("[SetOwnerQuota] set owner quota fail err [%v]", err)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(req.VolName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if !vol.enableQuota {
+		err = errors.NewErrorf("vol %v disableQuota.", vol.Name)
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	if err = vol.ownerQuotaManager.setQuota(req); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	msg := fmt.Sprintf("set owner quota successfully, req %v", req)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) DeleteOwnerQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		name string
+		typ  uint8
+		id   uint32
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.QuotaOwnerDelete))
+	defer func() {
+		doStatAndMetric(proto.QuotaOwnerDelete, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, typ, id, err = parseOwnerQuotaParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if err = vol.ownerQuotaManager.deleteQuota(typ, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	msg := fmt.Sprintf("delete owner quota successfully, vol [%v] %v [%v]", name, proto.OwnerQuotaTypeString(typ), id)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) GetOwnerQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		vol       *Vol
+		name      string
+		typ       uint8
+		id        uint32
+		quotaInfo *proto.OwnerQuotaInfo
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.QuotaOwnerGet))
+	defer func() {
+		doStatAndMetric(proto.QuotaOwnerGet, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, typ, id, err = parseOwnerQuotaParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	if quotaInfo, err = vol.ownerQuotaManager.getQuota(typ, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(quotaInfo))
+}
+
+func (m *Server) ReportOwnerQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		vol  *Vol
+		name string
+		typ  uint8
+	)
+
+	metric := exporter.NewTPCnt(apiToMetricsName(proto.QuotaOwnerReport))
+	defer func() {
+		doStatAndMetric(proto.QuotaOwnerReport, metric, err, map[string]string{exporter.Vol: name})
+	}()
+
+	if name, typ, err = parseOwnerQuotaReportParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+
+	resp := &proto.OwnerQuotaReportResponse{Quotas: vol.ownerQuotaManager.report(typ)}
+	sendOkReply(w, r, newSuccessHTTPReply(resp))
+}
+
 // func (m *Server) BatchModifyQuotaFullPath(w http.ResponseWriter, r *http.Request) {
 // 	var (
 // 		name              string
@@ -579,6 +579,31 @@ func TestUpdateVol(t *testing.T) {
 	checkParam(cacheLRUIntervalKey, proto.AdminUpdateVol, req, lru, lru, t)
 }
 
+func TestUpdateVolCompressCodec(t *testing.T) {
+	volName := "compressVol"
+	req := map[string]interface{}{}
+	req[nameKey] = volName
+	req[compressCodecKey] = "lz4"
+
+	createVol(req, t)
+
+	view := getSimpleVol(volName, true, t)
+	assert.Equal(t, "lz4", view.CompressCodec)
+
+	req[volAuthKey] = buildAuthKey(testOwner)
+	checkParam(compressCodecKey, proto.AdminUpdateVol, req, "snappy", "zstd", t)
+	setParam(compressCodecKey, proto.AdminUpdateVol, req, "zstd", t)
+	assert.Equal(t, "zstd", getSimpleVol(volName, true, t).CompressCodec)
+
+	// keep the codec if not set
+	delete(req, compressCodecKey)
+	setParam(descriptionKey, proto.AdminUpdateVol, req, "compressed", t)
+	assert.Equal(t, "zstd", getSimpleVol(volName, true, t).CompressCodec)
+
+	setParam(compressCodecKey, proto.AdminUpdateVol, req, "none", t)
+	assert.Equal(t, "", getSimpleVol(volName, true, t).CompressCodec)
+}
+
 func setUpdateVolParm(key string, req map[string]interface{}, val interface{}, t *testing.T) {
 	setParam(key, proto.AdminUpdateVol, req, val, t)
 }
@@ -1475,3 +1500,24 @@ func TestVolumeEnableAuditLog(t *testing.T) {
 	require.True(t, vol.EnableAuditLog)
 	require.True(t, checkVolAuditLog(name, true))
 }
+
+func TestVolumeEnableReplication(t *testing.T) {
+	name := "replicationVol"
+	createVol(map[string]interface{}{nameKey: name}, t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Errorf("failed to get vol %v, err %v", name, err)
+		return
+	}
+	defer func() {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, name, buildAuthKey(testOwner))
+		process(reqURL, t)
+	}()
+	require.False(t, vol.EnableReplication)
+	reqUrl := fmt.Sprintf("%v%v", hostAddr, proto.AdminVolEnableReplication)
+	process(fmt.Sprintf("%v?name=%v&%v=true", reqUrl, vol.Name, enableKey), t)
+	require.True(t, vol.EnableReplication)
+	require.True(t, newSimpleView(vol).EnableReplication)
+	process(fmt.Sprintf("%v?name=%v&%v=false", reqUrl, vol.Name, enableKey), t)
+	require.False(t, vol.EnableReplication)
+}
@@ -100,6 +100,7 @@ type Cluster struct {
 	lcNodes                      sync.Map
 	lcMgr                        *lifecycleManager
 	snapshotMgr                  *snapshotDelManager
+	dpBalancer                   *dpBalancer
 	DecommissionDiskFactor       float64
 	S3ApiQosQuota                *sync.Map // (api,uid,limtType) -> limitQuota
 }
@@ -360,6 +361,8 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.lcMgr.cluster = c
 	c.snapshotMgr = newSnapshotManager()
 	c.snapshotMgr.cluster = c
+	c.dpBalancer = newDpBalancer()
+	c.dpBalancer.cluster = c
 	c.S3ApiQosQuota = new(sync.Map)
 	return
 }
@@ -387,6 +390,7 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToLcScan()
 	c.scheduleToSnapshotDelVerScan()
 	c.scheduleToBadDisk()
+	c.scheduleToBalanceDataPartitions()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -757,6 +761,10 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 					hbReq.QuotaHbInfos = append(hbReq.QuotaHbInfos, quotaHbInfos...)
 				}
 			}
+			if vol.ownerQuotaManager != nil && vol.enableQuota {
+				hbReq.OwnerQuotaEnableVols = append(hbReq.OwnerQuotaEnableVols, vol.Name)
+				hbReq.OwnerQuotaHbInfos = append(hbReq.OwnerQuotaHbInfos, vol.ownerQuotaManager.getQuotaHbInfos()...)
+			}
 
 			hbReq.TxInfo = append(hbReq.TxInfo, &proto.TxInfo{
 				Volume:     vol.Name,
@@ -1009,7 +1017,7 @@ func (c *Cluster) updateMetaNodeBaseInfo(nodeAddr string, id uint64) (err error)
 	return
 }
 
-func (c *Cluster) addMetaNode(nodeAddr, zoneName string, nodesetId uint64) (id uint64, err error) {
+func (c *Cluster) addMetaNode(nodeAddr, zoneName, rackName string, nodesetId uint64) (id uint64, err error) {
 	c.mnMutex.Lock()
 	defer c.mnMutex.Unlock()
 
@@ -1019,10 +1027,17 @@ func (c *Cluster) addMetaNode(nodeAddr, zoneName string, nodesetId uint64) (id u
 		if nodesetId > 0 && nodesetId != metaNode.ID {
 			return metaNode.ID, fmt.Errorf("addr already in nodeset [%v]", nodeAddr)
 		}
+		// the rack set by the admin is kept when the node registers again
+		if metaNode.RackName == "" && rackName != "" {
+			if err = c.setMetaNodeRack(metaNode, rackName); err != nil {
+				return metaNode.ID, err
+			}
+		}
 		return metaNode.ID, nil
 	}
 
 	metaNode = newMetaNode(nodeAddr, zoneName, c.Name)
+	metaNode.RackName = rackName
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -1073,7 +1088,7 @@ errHandler:
 	return
 }
 
-func (c *Cluster) addDataNode(nodeAddr, zoneName string, nodesetId uint64) (id uint64, err error) {
+func (c *Cluster) addDataNode(nodeAddr, zoneName, rackName string, nodesetId uint64) (id uint64, err error) {
 	c.dnMutex.Lock()
 	defer c.dnMutex.Unlock()
 	var dataNode *DataNode
@@ -1082,10 +1097,17 @@ func (c *Cluster) addDataNode(nodeAddr, zoneName string, nodesetId uint64) (id u
 		if nodesetId > 0 && nodesetId != dataNode.NodeSetID {
 			return dataNode.ID, fmt.Errorf("addr already in nodeset [%v]", nodeAddr)
 		}
+		// the rack set by the admin is kept when the node registers again
+		if dataNode.RackName == "" && rackName != "" {
+			if err = c.setDataNodeRack(dataNode, rackName); err != nil {
+				return dataNode.ID, err
+			}
+		}
 		return dataNode.ID, nil
 	}
 
 	dataNode = newDataNode(nodeAddr, zoneName, c.Name)
+	dataNode.RackName = rackName
 	dataNode.DpCntLimit = newDpCountLimiter(&c.cfg.MaxDpCntLimit)
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
@@ -1136,6 +1158,34 @@ errHandler:
 	return
 }
 
+// setDataNodeRack sets the rack of the data node, the replicas of a data partition
+// are not placed on the nodes of the same rack within a node set.
+func (c *Cluster) setDataNodeRack(dataNode *DataNode, rackName string) (err error) {
+	oldRackName := dataNode.RackName
+	dataNode.RackName = rackName
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.RackName = oldRackName
+		return
+	}
+	log.LogInfof("action[setDataNodeRack] clusterID[%v] dataNode[%v] rack[%v] -> [%v]",
+		c.Name, dataNode.Addr, oldRackName, rackName)
+	return
+}
+
+// setMetaNodeRack sets the rack of the meta node, the replicas of a meta partition
+// are not placed on the nodes of the same rack within a node set.
+func (c *Cluster) setMetaNodeRack(metaNode *MetaNode, rackName string) (err error) {
+	oldRackName := metaNode.RackName
+	metaNode.RackName = rackName
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.RackName = oldRackName
+		return
+	}
+	log.LogInfof("action[setMetaNodeRack] clusterID[%v] metaNode[%v] rack[%v] -> [%v]",
+		c.Name, metaNode.Addr, oldRackName, rackName)
+	return
+}
+
 func (c *Cluster) checkInactiveDataNodes() (inactiveDataNodes []string, err error) {
 	inactiveDataNodes = make([]string, 0)
 
@@ -1320,6 +1370,18 @@ func (c *Cluster) SetVerStrategy(volName string, strategy proto.VolumeVerStrateg
 	return vol.VersionMgr.SetVerStrategy(strategy, isForce)
 }
 
+func (c *Cluster) SetSnapshotRetention(volName string, rules []*proto.SnapshotRetentionRule) (err error) {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return
+	}
+	if !proto.IsHot(vol.VolType) {
+		err = fmt.Errorf("vol need be hot one")
+		return
+	}
+	return vol.VersionMgr.SetRetention(rules)
+}
+
 func (c *Cluster) getVolVer(volName string) (info *proto.VolumeVerInfo, err error) {
 	c.volMutex.RLock()
 	defer c.volMutex.RUnlock()
@@ -1672,7 +1734,11 @@ func (c *Cluster) syncCreateDataPartitionToDataNode(host string, size uint64, dp
 func (c *Cluster) syncCreateMetaPartitionToMetaNode(host string, mp *MetaPartition) (err error) {
 	hosts := make([]string, 0)
 	hosts = append(hosts, host)
-	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName)
+	vol, err := c.getVol(mp.volName)
+	if err != nil {
+		return
+	}
+	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName, vol.metaStoreMode)
 	metaNode, err := c.metaNode(host)
 	if err != nil {
 		return
@@ -2376,7 +2442,7 @@ func (c *Cluster) migrateDataPartition(srcAddr, targetAddr string, dp *DataParti
 
 	if targetAddr != "" {
 		targetHosts = []string{targetAddr}
-	} else if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
+	} else if targetHosts, _, err = ns.getReplaceDataNodeHosts(dp.Hosts, srcAddr); err != nil {
 		if _, ok := c.vols[dp.VolName]; !ok {
 			log.LogWarnf("clusterID[%v] partitionID:%v  on node:%v offline failed,PersistenceHosts:[%v]",
 				c.Name, dp.PartitionID, srcAddr, dp.Hosts)
@@ -3256,6 +3322,7 @@ func (c *Cluster) createVol(req *createVolReq) (vol *Vol, err error) {
 	vol.aclMgr.init(c, vol)
 	vol.initUidSpaceManager(c)
 	vol.initQuotaManager(c)
+	vol.initOwnerQuotaManager(c)
 	if err = vol.VersionMgr.init(c); err != nil {
 		log.LogError("init dataPartition error in verMgr init", err.Error())
 	}
@@ -3354,6 +3421,8 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		TxTimeout:               req.txTimeout,
 		TxConflictRetryNum:      req.txConflictRetryNum,
 		TxConflictRetryInterval: req.txConflictRetryInterval,
+		MetaStoreMode:           req.metaStoreMode,
+		CompressCodec:           req.compressCodec,
 
 		VolType:          req.volType,
 		EbsBlkSize:       req.coldArgs.objBlockSize,
@@ -4646,6 +4715,10 @@ func (c *Cluster) getSnapshotDelVer() {
 				c.snapshotMgr.lcSnapshotTaskStatus.AddVerInfo(task)
 			}
 		}
+		// delete the versions expired by the retention rules under the prefixes
+		for _, task := range vol.VersionMgr.getRetentionDelTasks(time.Now()) {
+			c.snapshotMgr.lcSnapshotTaskStatus.AddVerInfo(task)
+		}
 	}
 	log.LogDebug("getSnapshotDelVer AddVerInfo finish")
 	c.snapshotMgr.lcSnapshotTaskStatus.DeleteOldResult()
@@ -139,7 +139,7 @@ func (c *Cluster) migrateMetaPartition(srcAddr, targetAddr string, mp *MetaParti
 		newPeers = []proto.Peer{{
 			Addr: targetAddr,
 		}}
-	} else if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1); err != nil {
+	} else if _, newPeers, err = ns.getReplaceMetaNodeHosts(oldHosts, srcAddr); err != nil {
 		if _, ok := c.vols[mp.volName]; !ok {
 			log.LogWarnf("[migrateMetaPartition] clusterID[%v] partitionID:%v  on node:[%v]",
 				c.Name, mp.PartitionID, mp.Hosts)
@@ -517,7 +517,11 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 }
 
 func (c *Cluster) createMetaReplica(partition *MetaPartition, addPeer proto.Peer) (err error) {
-	task, err := partition.createTaskToCreateReplica(addPeer.Addr)
+	vol, err := c.getVol(partition.volName)
+	if err != nil {
+		return
+	}
+	task, err := partition.createTaskToCreateReplica(addPeer.Addr, vol.metaStoreMode)
 	if err != nil {
 		return
 	}
@@ -1134,6 +1138,9 @@ func (c *Cluster) updateMetaNode(metaNode *MetaNode, metaPartitions []*proto.Met
 		mp.updateMetaPartition(mr, metaNode)
 		vol.uidSpaceManager.volUidUpdate(mr)
 		vol.quotaManager.quotaUpdate(mr)
+		if vol.ownerQuotaManager != nil {
+			vol.ownerQuotaManager.quotaUpdate(mr)
+		}
 		c.updateInodeIDUpperBound(mp, mr, threshold, metaNode)
 	}
 }
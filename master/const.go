@@ -69,6 +69,8 @@ const (
 	akKey                      = "ak"
 	keywordsKey                = "keywords"
 	zoneNameKey                = "zoneName"
+	rackNameKey                = "rackName"
+	maxMigrationsKey           = "maxMigrations"
 	nodesetIdKey               = "nodesetId"
 	crossZoneKey               = "crossZone"
 	normalZonesFirstKey        = "normalZonesFirst"
@@ -94,6 +96,8 @@ const (
 	forceKey                   = "force"
 	raftForceDelKey            = "raftForceDel"
 	enablePosixAclKey          = "enablePosixAcl"
+	metaStoreModeKey           = "metaStoreMode"
+	compressCodecKey           = "compressCodec"
 	enableTxMaskKey            = "enableTxMask"
 	txTimeoutKey               = "txTimeout"
 	txConflictRetryNumKey      = "txConflictRetryNum"
@@ -126,6 +130,13 @@ const (
 	fullPathKey                = "fullPath"
 	inodeKey                   = "inode"
 	quotaKey                   = "quotaId"
+	ownerTypeKey               = "ownerType"
+	ownerIdKey                 = "ownerId"
+	hardFilesKey               = "hardFiles"
+	hardBytesKey               = "hardBytes"
+	softFilesKey               = "softFiles"
+	softBytesKey               = "softBytes"
+	gracePeriodKey             = "gracePeriod"
 	enableQuota                = "enableQuota"
 	dpDiscardKey               = "dpDiscard"
 	ignoreDiscardKey           = "ignoreDiscard"
@@ -284,6 +295,10 @@ const (
 	opSyncAllocQuotaID uint32 = 0x40
 	opSyncSetQuota     uint32 = 0x41
 	opSyncDeleteQuota  uint32 = 0x42
+
+	opSyncSetOwnerQuota    uint32 = 0x43
+	opSyncDeleteOwnerQuota uint32 = 0x44
+
 	opSyncMulitVersion uint32 = 0x53
 
 	opSyncS3QosSet    uint32 = 0x60
@@ -338,6 +353,7 @@ const (
 	volWarnUsedRatio = 0.9
 	volCachePrefix   = keySeparator + volNameAcronym + keySeparator
 	quotaPrefix      = keySeparator + "quota" + keySeparator
+	ownerQuotaPrefix = keySeparator + "ownerquota" + keySeparator
 	lcNodePrefix     = keySeparator + lcNodeAcronym + keySeparator
 	lcConfPrefix     = keySeparator + lcConfigurationAcronym + keySeparator
 	S3QoSPrefix      = keySeparator + S3QoS + keySeparator
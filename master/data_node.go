@@ -33,6 +33,7 @@ type DataNode struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	Addr                      string
 	DomainAddr                string
 	ReportTime                time.Time
@@ -69,6 +70,8 @@ type DataNode struct {
 	ioUtils                   atomic.Value       `json:"-"`
 	DecommissionDiskList      []string
 	DecommissionDpTotal       int
+	ScrubStats                []proto.DiskScrubStat
+	CorruptExtents            []*proto.ScrubCorruptExtent
 }
 
 func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
@@ -153,6 +156,8 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 
 	dataNode.BadDisks = resp.BadDisks
 	dataNode.DiskStats = resp.DiskStats
+	dataNode.ScrubStats = resp.ScrubStats
+	dataNode.CorruptExtents = resp.CorruptExtents
 
 	dataNode.StartTime = resp.StartTime
 	if dataNode.Total == 0 {
@@ -166,6 +171,18 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 		dataNode.Total, dataNode.Used, dataNode.AvailableSpace)
 }
 
+// unrepairedCorruptExtents returns the count of the corrupt extents reported by the data scrubber which are not repaired.
+func (dataNode *DataNode) unrepairedCorruptExtents() (count int) {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	for _, ce := range dataNode.CorruptExtents {
+		if !ce.Repaired {
+			count++
+		}
+	}
+	return
+}
+
 func (dataNode *DataNode) canAlloc() bool {
 	dataNode.RLock()
 	defer dataNode.RUnlock()
@@ -0,0 +1,364 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultDpBalanceThreshold     = 0.1
+	defaultDpBalanceMaxMigrations = 5
+	defaultDpBalanceInterval      = time.Minute
+	// the space reserved on the target data node after the migration
+	dpBalanceReservedSpace = 10 * util.GB
+)
+
+// dpBalancer moves the data partitions from the most used data nodes to the least used ones within a node set,
+// the migrations go through migrateDataPartition, and the count of the running migrations is limited.
+type dpBalancer struct {
+	sync.RWMutex
+	cluster *Cluster
+	config  proto.DpBalanceConfig
+	running map[uint64]*proto.DpBalanceMigration // key: partition id
+}
+
+func newDpBalancer() *dpBalancer {
+	return &dpBalancer{
+		config: proto.DpBalanceConfig{
+			Threshold:     defaultDpBalanceThreshold,
+			MaxMigrations: defaultDpBalanceMaxMigrations,
+		},
+		running: make(map[uint64]*proto.DpBalanceMigration),
+	}
+}
+
+func (b *dpBalancer) getConfig() proto.DpBalanceConfig {
+	b.RLock()
+	defer b.RUnlock()
+	return b.config
+}
+
+func (b *dpBalancer) setConfig(config proto.DpBalanceConfig) {
+	b.Lock()
+	defer b.Unlock()
+	b.config = config
+}
+
+func (b *dpBalancer) getRunning() (migrations []*proto.DpBalanceMigration) {
+	b.RLock()
+	defer b.RUnlock()
+	migrations = make([]*proto.DpBalanceMigration, 0, len(b.running))
+	for _, m := range b.running {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].PartitionID < migrations[j].PartitionID
+	})
+	return
+}
+
+func (b *dpBalancer) isRunning(partitionID uint64) bool {
+	b.RLock()
+	defer b.RUnlock()
+	_, ok := b.running[partitionID]
+	return ok
+}
+
+// updateRunning removes the migrations that have been recovered and returns the count of the left ones
+func (b *dpBalancer) updateRunning() int {
+	b.Lock()
+	defer b.Unlock()
+	for id, m := range b.running {
+		dp, err := b.cluster.getDataPartitionByID(id)
+		if err != nil {
+			delete(b.running, id)
+			continue
+		}
+		dp.RLock()
+		finished := !dp.isRecover || !dp.hasHost(m.DstAddr)
+		dp.RUnlock()
+		if finished {
+			log.LogInfof("action[dpBalancer] partition[%v] migration from [%v] to [%v] finished",
+				id, m.SrcAddr, m.DstAddr)
+			delete(b.running, id)
+		}
+	}
+	return len(b.running)
+}
+
+func (b *dpBalancer) balance() {
+	config := b.getConfig()
+	if !config.Enable {
+		return
+	}
+	limit := config.MaxMigrations - b.updateRunning()
+	if limit <= 0 {
+		return
+	}
+	plan := b.plan(config, limit)
+	for _, m := range plan.Migrations {
+		dp, err := b.cluster.getDataPartitionByID(m.PartitionID)
+		if err != nil {
+			continue
+		}
+		if err = b.cluster.migrateDataPartition(m.SrcAddr, m.DstAddr, dp, false, "dpBalancer"); err != nil {
+			log.LogWarnf("action[dpBalancer] migrate partition[%v] from [%v] to [%v] failed, err[%v]",
+				m.PartitionID, m.SrcAddr, m.DstAddr, err)
+			continue
+		}
+		if !dp.hasHost(m.DstAddr) {
+			continue
+		}
+		log.LogInfof("action[dpBalancer] migrate partition[%v] size[%v] from [%v:%v] to [%v]",
+			m.PartitionID, m.Size, m.SrcAddr, m.SrcDisk, m.DstAddr)
+		b.Lock()
+		b.running[m.PartitionID] = m
+		b.Unlock()
+	}
+}
+
+type dpBalanceNode struct {
+	*proto.DpBalanceNodeStat
+	node      *DataNode
+	available uint64
+}
+
+func (n *dpBalanceNode) ratio(used uint64) float64 {
+	return float64(used) / float64(n.Total)
+}
+
+// plan makes at most limit migrations for every node set, the usage of the nodes is recalculated
+// after each migration so that a node is not overloaded by the planned migrations.
+func (b *dpBalancer) plan(config proto.DpBalanceConfig, limit int) (plan *proto.DpBalancePlan) {
+	plan = &proto.DpBalancePlan{
+		Config:     config,
+		Running:    b.getRunning(),
+		Migrations: make([]*proto.DpBalanceMigration, 0),
+		Nodes:      make([]*proto.DpBalanceNodeStat, 0),
+	}
+	planned := make(map[uint64]bool)
+	for _, zone := range b.cluster.t.getAllZones() {
+		for _, ns := range zone.getAllNodeSet() {
+			nodes := b.getNodes(ns)
+			if len(nodes) > 1 && limit > len(plan.Migrations) {
+				migrations := b.planNodeSet(ns, nodes, config.Threshold, limit-len(plan.Migrations), planned)
+				plan.Migrations = append(plan.Migrations, migrations...)
+			}
+			for _, n := range nodes {
+				plan.Nodes = append(plan.Nodes, n.DpBalanceNodeStat)
+			}
+		}
+	}
+	return
+}
+
+func (b *dpBalancer) getNodes(ns *nodeSet) (nodes []*dpBalanceNode) {
+	nodes = make([]*dpBalanceNode, 0)
+	ns.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		ok := dataNode.isActive && dataNode.Total > 0 && !dataNode.ToBeOffline &&
+			dataNode.GetDecommissionStatus() == DecommissionInitial
+		n := &dpBalanceNode{
+			DpBalanceNodeStat: &proto.DpBalanceNodeStat{
+				Addr:      dataNode.Addr,
+				ZoneName:  dataNode.ZoneName,
+				NodeSetID: ns.ID,
+				Total:     dataNode.Total,
+				Used:      dataNode.Used,
+			},
+			node:      dataNode,
+			available: dataNode.AvailableSpace,
+		}
+		dataNode.RUnlock()
+		if ok {
+			n.UsageRatio = n.ratio(n.Used)
+			n.PlannedUsageRatio = n.UsageRatio
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Addr < nodes[j].Addr
+	})
+	return
+}
+
+func (b *dpBalancer) planNodeSet(ns *nodeSet, nodes []*dpBalanceNode, threshold float64, limit int,
+	planned map[uint64]bool) (migrations []*proto.DpBalanceMigration) {
+	var total, used uint64
+	for _, n := range nodes {
+		total += n.Total
+		used += n.Used
+	}
+	avg := float64(used) / float64(total)
+	planUsed := make(map[string]uint64, len(nodes))
+	for _, n := range nodes {
+		planUsed[n.Addr] = n.Used
+	}
+
+	for len(migrations) < limit {
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].ratio(planUsed[nodes[i].Addr]) > nodes[j].ratio(planUsed[nodes[j].Addr])
+		})
+		src, dst := nodes[0], nodes[len(nodes)-1]
+		if src.ratio(planUsed[src.Addr]) <= avg+threshold && dst.ratio(planUsed[dst.Addr]) >= avg-threshold {
+			break
+		}
+		// try the next node if no data partition could be moved from the most used one
+		var m *proto.DpBalanceMigration
+		for i := 0; m == nil && i < len(nodes) && nodes[i].ratio(planUsed[nodes[i].Addr]) > avg; i++ {
+			m = b.pickMigration(ns, nodes[i], nodes, planUsed, planned)
+		}
+		if m == nil {
+			break
+		}
+		planned[m.PartitionID] = true
+		planUsed[m.SrcAddr] -= m.Size
+		planUsed[m.DstAddr] += m.Size
+		migrations = append(migrations, m)
+	}
+	for _, n := range nodes {
+		n.PlannedUsageRatio = n.ratio(planUsed[n.Addr])
+	}
+	return
+}
+
+// pickMigration picks a data partition on the most used disk of src, and the least used node which the
+// data partition could be moved to. The usage gap between the nodes must be narrowed by the migration.
+func (b *dpBalancer) pickMigration(ns *nodeSet, src *dpBalanceNode, nodes []*dpBalanceNode,
+	planUsed map[string]uint64, planned map[uint64]bool) *proto.DpBalanceMigration {
+	srcUsed := planUsed[src.Addr]
+	for _, disk := range b.getSortedDisks(src.node) {
+		partitions := b.getCandidatePartitions(src.node, disk, planned)
+		for i := len(nodes) - 1; i >= 0; i-- {
+			dst := nodes[i]
+			if dst == src || !dst.node.canAllocDp() {
+				continue
+			}
+			dstUsed := planUsed[dst.Addr]
+			if dst.ratio(dstUsed) >= src.ratio(srcUsed) {
+				break
+			}
+			available := int64(dst.available) - (int64(dstUsed) - int64(dst.Used))
+			for _, report := range partitions {
+				size := report.Used
+				if size == 0 || size > srcUsed || available < int64(size+dpBalanceReservedSpace) {
+					continue
+				}
+				if dst.ratio(dstUsed+size) >= src.ratio(srcUsed) || src.ratio(srcUsed-size) <= dst.ratio(dstUsed) {
+					continue
+				}
+				if !b.canMigrate(ns, report.PartitionID, src.Addr, dst.Addr) {
+					continue
+				}
+				return &proto.DpBalanceMigration{
+					PartitionID: report.PartitionID,
+					VolName:     report.VolName,
+					Size:        size,
+					ZoneName:    src.ZoneName,
+					NodeSetID:   ns.ID,
+					SrcAddr:     src.Addr,
+					SrcDisk:     disk,
+					DstAddr:     dst.Addr,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getSortedDisks returns the disks of the data node sorted by the usage ratio in descending order
+func (b *dpBalancer) getSortedDisks(dataNode *DataNode) (disks []string) {
+	dataNode.RLock()
+	stats := make([]proto.DiskStat, 0, len(dataNode.DiskStats))
+	for _, stat := range dataNode.DiskStats {
+		if stat.Total > 0 && stat.Status == proto.ReadWrite {
+			stats = append(stats, stat)
+		}
+	}
+	dataNode.RUnlock()
+	sort.Slice(stats, func(i, j int) bool {
+		return float64(stats[i].Used)/float64(stats[i].Total) > float64(stats[j].Used)/float64(stats[j].Total)
+	})
+	disks = make([]string, 0, len(stats))
+	for _, stat := range stats {
+		disks = append(disks, stat.DiskPath)
+	}
+	return
+}
+
+// getCandidatePartitions returns the data partitions on the disk sorted by the used size in descending order
+func (b *dpBalancer) getCandidatePartitions(dataNode *DataNode, disk string, planned map[uint64]bool) (reports []*proto.DataPartitionReport) {
+	dataNode.RLock()
+	reports = make([]*proto.DataPartitionReport, 0)
+	for _, report := range dataNode.DataPartitionReports {
+		if report.DiskPath == disk && !planned[report.PartitionID] && !b.isRunning(report.PartitionID) {
+			reports = append(reports, report)
+		}
+	}
+	dataNode.RUnlock()
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Used > reports[j].Used
+	})
+	return
+}
+
+func (b *dpBalancer) canMigrate(ns *nodeSet, partitionID uint64, srcAddr, dstAddr string) bool {
+	dp, err := b.cluster.getDataPartitionByID(partitionID)
+	if err != nil {
+		return false
+	}
+	vol, err := b.cluster.getVol(dp.VolName)
+	if err != nil || vol.Status == proto.VolStatusMarkDelete {
+		return false
+	}
+	dp.RLock()
+	defer dp.RUnlock()
+	if !proto.IsNormalDp(dp.PartitionType) || dp.isSpecialReplicaCnt() || dp.isRecover || dp.IsDiscard ||
+		dp.Status == proto.Unavailable || !dp.IsDecommissionInitial() ||
+		len(dp.Hosts) != int(dp.ReplicaNum) || !dp.hasHost(srcAddr) || dp.hasHost(dstAddr) {
+		return false
+	}
+	// keep the replicas on different racks
+	dstRack := ns.getNodeRack(DataNodeType, dstAddr)
+	if dstRack == "" {
+		return true
+	}
+	for _, host := range dp.Hosts {
+		if host != srcAddr && ns.getNodeRack(DataNodeType, host) == dstRack {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cluster) scheduleToBalanceDataPartitions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() && c.metaReady {
+				c.dpBalancer.balance()
+			}
+			time.Sleep(defaultDpBalanceInterval)
+		}
+	}()
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+// prepareClusterForDpBalance makes a node set of full nodes with the data partitions of size dpSize
+// on them, and empty nodes without data partitions.
+func prepareClusterForDpBalance(fullNodes, emptyNodes, dpCount int, dpSize uint64) (c *Cluster, ns *nodeSet) {
+	const volName = "balance"
+	c = &Cluster{
+		vols: make(map[string]*Vol),
+		t:    newTopology(),
+	}
+	c.dpBalancer = newDpBalancer()
+	c.dpBalancer.cluster = c
+	vol := &Vol{
+		Name:           volName,
+		dataPartitions: newDataPartitionMap(volName),
+	}
+	c.vols[volName] = vol
+	ns = &nodeSet{
+		ID:        1,
+		zoneName:  testZone1,
+		metaNodes: new(sync.Map),
+		dataNodes: new(sync.Map),
+	}
+
+	total := uint64(dpCount)*dpSize + 100*util.GB
+	nodes := make([]*DataNode, 0)
+	for i := 0; i < fullNodes+emptyNodes; i++ {
+		node := newDataNode(fmt.Sprintf("192.168.0.%v:17310", i+1), testZone1, "")
+		node.isActive = true
+		node.Total = total
+		node.AvailableSpace = total
+		node.DiskStats = []proto.DiskStat{{DiskPath: "/disk1", Status: proto.ReadWrite, Total: total}}
+		nodes = append(nodes, node)
+		ns.putDataNode(node)
+	}
+	for i := 0; i < dpCount; i++ {
+		dp := newDataPartition(uint64(i+1), uint8(fullNodes), volName, 1, proto.PartitionTypeNormal, 0)
+		for _, node := range nodes[:fullNodes] {
+			dp.Hosts = append(dp.Hosts, node.Addr)
+			node.DataPartitionReports = append(node.DataPartitionReports, &proto.DataPartitionReport{
+				VolName:     volName,
+				PartitionID: dp.PartitionID,
+				Used:        dpSize,
+				DiskPath:    "/disk1",
+			})
+			node.Used += dpSize
+			node.AvailableSpace -= dpSize
+			node.DiskStats[0].Used += dpSize
+		}
+		vol.dataPartitions.put(dp)
+	}
+	return
+}
+
+func TestDpBalancePlan(t *testing.T) {
+	c, ns := prepareClusterForDpBalance(3, 1, 30, 10*util.GB)
+	b := c.dpBalancer
+	config := b.getConfig()
+
+	nodes := b.getNodes(ns)
+	avg := nodes[0].UsageRatio * 3 / 4
+	migrations := b.planNodeSet(ns, nodes, config.Threshold, 100, make(map[uint64]bool))
+	require.NotEmpty(t, migrations)
+	emptyAddr := "192.168.0.4:17310"
+	partitions := make(map[uint64]bool)
+	moved := make(map[string]int)
+	for _, m := range migrations {
+		require.Equal(t, emptyAddr, m.DstAddr)
+		require.Equal(t, "/disk1", m.SrcDisk)
+		require.False(t, partitions[m.PartitionID], "partition %v is planned twice", m.PartitionID)
+		partitions[m.PartitionID] = true
+		moved[m.SrcAddr]++
+	}
+	// the full nodes are evened out, and the usage of all the nodes gets close to the average
+	require.Len(t, moved, 3)
+	for _, n := range nodes {
+		require.InDelta(t, avg, n.PlannedUsageRatio, config.Threshold, n.Addr)
+	}
+
+	// the plan is limited
+	migrations = b.planNodeSet(ns, b.getNodes(ns), config.Threshold, 2, make(map[uint64]bool))
+	require.Len(t, migrations, 2)
+
+	// nothing to do if the usage gap is within the threshold
+	migrations = b.planNodeSet(ns, b.getNodes(ns), 0.9, 100, make(map[uint64]bool))
+	require.Empty(t, migrations)
+}
+
+func TestDpBalancePlanRack(t *testing.T) {
+	c, ns := prepareClusterForDpBalance(3, 1, 30, 10*util.GB)
+	b := c.dpBalancer
+	ns.dataNodes.Range(func(key, value interface{}) bool {
+		node := value.(*DataNode)
+		node.RackName = "rack" + node.Addr[len("192.168.0."):len("192.168.0.")+1]
+		return true
+	})
+	// the empty node shares the rack of the first full node, only the replicas on that node could be moved
+	emptyNode, _ := ns.dataNodes.Load("192.168.0.4:17310")
+	emptyNode.(*DataNode).RackName = "rack1"
+
+	migrations := b.planNodeSet(ns, b.getNodes(ns), b.getConfig().Threshold, 100, make(map[uint64]bool))
+	require.NotEmpty(t, migrations)
+	for _, m := range migrations {
+		require.Equal(t, "192.168.0.1:17310", m.SrcAddr)
+	}
+}
+
+func TestDpBalanceSkipRunning(t *testing.T) {
+	c, ns := prepareClusterForDpBalance(3, 1, 4, 100*util.GB)
+	b := c.dpBalancer
+	for id := uint64(1); id <= 4; id++ {
+		dp, err := c.getDataPartitionByID(id)
+		require.NoError(t, err)
+		if id != 4 {
+			dp.isRecover = true
+		}
+	}
+	migrations := b.planNodeSet(ns, b.getNodes(ns), b.getConfig().Threshold, 100, make(map[uint64]bool))
+	require.NotEmpty(t, migrations)
+	for _, m := range migrations {
+		require.EqualValues(t, 4, m.PartitionID)
+	}
+}
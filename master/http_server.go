@@ -293,6 +293,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolEnableAuditLog).
 		HandlerFunc(m.setEnableAuditLogForVolume)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminVolEnableReplication).
+		HandlerFunc(m.setEnableReplicationForVolume)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminClusterForbidMpDecommission).
 		HandlerFunc(m.setupForbidMetaPartitionDecommission)
@@ -309,6 +312,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetCheckDataReplicasEnable).
 		HandlerFunc(m.setCheckDataReplicasEnable)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetDpBalance).
+		HandlerFunc(m.setDpBalance)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetDpBalancePlan).
+		HandlerFunc(m.getDpBalancePlan)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetConfig).
 		HandlerFunc(m.setConfigHandler)
@@ -413,6 +422,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetVerStrategy).
 		HandlerFunc(m.SetVerStrategy)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.AdminSetSnapshotRetention).
+		HandlerFunc(m.SetSnapshotRetention)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetSnapshotRetention).
+		HandlerFunc(m.GetSnapshotRetention)
 
 	// S3 lifecycle configuration APIS
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -752,6 +767,18 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.QuotaListAll).
 		HandlerFunc(m.ListQuotaAll)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaOwnerSet).
+		HandlerFunc(m.SetOwnerQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaOwnerDelete).
+		HandlerFunc(m.DeleteOwnerQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.QuotaOwnerGet).
+		HandlerFunc(m.GetOwnerQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.QuotaOwnerReport).
+		HandlerFunc(m.ReportOwnerQuota)
 
 	// S3 API QoS Manager
 	router.NewRoute().Methods(http.MethodPut, http.MethodPost).
@@ -177,6 +177,9 @@ func (c *Cluster) handleLcNodeSnapshotScanResp(nodeAddr string, resp *proto.Snap
 		if err != nil {
 			log.LogErrorf("action[handleLcNodeSnapshotScanResp] snapshot task(%v) scanning completed by %v, results(%v), volume(%v) is not found",
 				resp.ID, nodeAddr, resp, resp.VolName)
+		} else if task := resp.SnapshotVerDelTask; task != nil && task.RuleID != "" {
+			// only the data under the prefix of the retention rule are deleted
+			_ = vol.VersionMgr.addRetentionExpiredVer(task.RuleID, resp.VerSeq)
 		} else {
 			_ = vol.VersionMgr.DelVer(resp.VerSeq)
 		}
@@ -0,0 +1,264 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// MasterOwnerQuotaManager manages the user and group quotas of a volume,
+// the used info of every uid and gid is summed up from the reports of meta partitions.
+type MasterOwnerQuotaManager struct {
+	MpUsedInfoMap map[uint64][]*proto.OwnerQuotaReportInfo
+	UsedInfoMap   map[proto.OwnerQuotaKey]proto.QuotaUsedInfo
+	QuotaInfoMap  map[proto.OwnerQuotaKey]*proto.OwnerQuotaInfo
+	vol           *Vol
+	c             *Cluster
+
+	sync.RWMutex
+	// syncMutex serializes the raft submits of the quota infos,
+	// it is taken before the RWMutex.
+	syncMutex sync.Mutex
+}
+
+func newMasterOwnerQuotaManager(c *Cluster, vol *Vol) *MasterOwnerQuotaManager {
+	return &MasterOwnerQuotaManager{
+		MpUsedInfoMap: make(map[uint64][]*proto.OwnerQuotaReportInfo),
+		UsedInfoMap:   make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo),
+		QuotaInfoMap:  make(map[proto.OwnerQuotaKey]*proto.OwnerQuotaInfo),
+		c:             c,
+		vol:           vol,
+	}
+}
+
+func (oqMgr *MasterOwnerQuotaManager) quotaKey(key proto.OwnerQuotaKey) string {
+	return ownerQuotaPrefix + strconv.FormatUint(oqMgr.vol.ID, 10) + keySeparator +
+		strconv.FormatUint(uint64(key.Type), 10) + keySeparator + strconv.FormatUint(uint64(key.Id), 10)
+}
+
+func (oqMgr *MasterOwnerQuotaManager) syncQuota(op uint32, quotaInfo *proto.OwnerQuotaInfo) (err error) {
+	var value []byte
+	if value, err = json.Marshal(quotaInfo); err != nil {
+		return
+	}
+	metadata := new(RaftCmd)
+	metadata.Op = op
+	metadata.K = oqMgr.quotaKey(quotaInfo.Key())
+	metadata.V = value
+	return oqMgr.c.submit(metadata)
+}
+
+func (oqMgr *MasterOwnerQuotaManager) setQuota(req *proto.SetOwnerQuotaRequest) (err error) {
+	if req.Type != proto.OwnerQuotaTypeUser && req.Type != proto.OwnerQuotaTypeGroup {
+		return fmt.Errorf("invalid owner quota type %v", req.Type)
+	}
+	if req.SoftFiles > 0 && req.HardFiles > 0 && req.SoftFiles > req.HardFiles {
+		return fmt.Errorf("soft files %v is larger than hard files %v", req.SoftFiles, req.HardFiles)
+	}
+	if req.SoftBytes > 0 && req.HardBytes > 0 && req.SoftBytes > req.HardBytes {
+		return fmt.Errorf("soft bytes %v is larger than hard bytes %v", req.SoftBytes, req.HardBytes)
+	}
+	if req.GracePeriod < 0 {
+		return fmt.Errorf("invalid grace period %v", req.GracePeriod)
+	}
+
+	oqMgr.syncMutex.Lock()
+	defer oqMgr.syncMutex.Unlock()
+	oqMgr.Lock()
+	defer oqMgr.Unlock()
+
+	key := proto.OwnerQuotaKey{Type: req.Type, Id: req.Id}
+	quotaInfo := &proto.OwnerQuotaInfo{
+		VolName: oqMgr.vol.Name,
+		Type:    req.Type,
+		Id:      req.Id,
+		CTime:   time.Now().Unix(),
+	}
+	if old, isFind := oqMgr.QuotaInfoMap[key]; isFind {
+		*quotaInfo = *old
+	}
+	quotaInfo.HardFiles = req.HardFiles
+	quotaInfo.HardBytes = req.HardBytes
+	quotaInfo.SoftFiles = req.SoftFiles
+	quotaInfo.SoftBytes = req.SoftBytes
+	quotaInfo.GracePeriod = req.GracePeriod
+	if quotaInfo.GracePeriod == 0 {
+		quotaInfo.GracePeriod = proto.DefaultOwnerQuotaGracePeriod
+	}
+	now := time.Now().Unix()
+	quotaInfo.UsedInfo = oqMgr.UsedInfoMap[key]
+	quotaInfo.UpdateSoftTime(now)
+	quotaInfo.UpdateLimited(now)
+
+	if err = oqMgr.syncQuota(opSyncSetOwnerQuota, quotaInfo); err != nil {
+		log.LogErrorf("set owner quota [%v] submit fail [%v].", quotaInfo, err)
+		return
+	}
+	oqMgr.QuotaInfoMap[key] = quotaInfo
+	log.LogInfof("set owner quota [%v] success.", quotaInfo)
+	return
+}
+
+func (oqMgr *MasterOwnerQuotaManager) deleteQuota(typ uint8, id uint32) (err error) {
+	oqMgr.syncMutex.Lock()
+	defer oqMgr.syncMutex.Unlock()
+	oqMgr.Lock()
+	defer oqMgr.Unlock()
+
+	key := proto.OwnerQuotaKey{Type: typ, Id: id}
+	quotaInfo, isFind := oqMgr.QuotaInfoMap[key]
+	if !isFind {
+		log.LogErrorf("vol [%v] owner quota [%v] is not exist.", oqMgr.vol.Name, key)
+		return errors.New("quota is not exist.")
+	}
+	if err = oqMgr.syncQuota(opSyncDeleteOwnerQuota, quotaInfo); err != nil {
+		log.LogErrorf("delete owner quota [%v] submit fail [%v].", quotaInfo, err)
+		return
+	}
+	delete(oqMgr.QuotaInfoMap, key)
+	log.LogInfof("delete owner quota [%v] success.", quotaInfo)
+	return
+}
+
+func (oqMgr *MasterOwnerQuotaManager) getQuota(typ uint8, id uint32) (quotaInfo *proto.OwnerQuotaInfo, err error) {
+	oqMgr.RLock()
+	defer oqMgr.RUnlock()
+	key := proto.OwnerQuotaKey{Type: typ, Id: id}
+	if info, isFind := oqMgr.QuotaInfoMap[key]; isFind {
+		copied := *info
+		return &copied, nil
+	}
+	return nil, errors.New("quota is not exist.")
+}
+
+// report returns the quota and used info of every uid or gid which has a quota or uses the volume,
+// typ zero means both users and groups.
+func (oqMgr *MasterOwnerQuotaManager) report(typ uint8) (infos []*proto.OwnerQuotaInfo) {
+	oqMgr.RLock()
+	defer oqMgr.RUnlock()
+	infos = make([]*proto.OwnerQuotaInfo, 0)
+	for key, info := range oqMgr.QuotaInfoMap {
+		if typ != 0 && key.Type != typ {
+			continue
+		}
+		copied := *info
+		infos = append(infos, &copied)
+	}
+	for key, usedInfo := range oqMgr.UsedInfoMap {
+		if typ != 0 && key.Type != typ {
+			continue
+		}
+		if _, isFind := oqMgr.QuotaInfoMap[key]; isFind {
+			continue
+		}
+		infos = append(infos, &proto.OwnerQuotaInfo{
+			VolName:  oqMgr.vol.Name,
+			Type:     key.Type,
+			Id:       key.Id,
+			UsedInfo: usedInfo,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Type != infos[j].Type {
+			return infos[i].Type < infos[j].Type
+		}
+		return infos[i].Id < infos[j].Id
+	})
+	return
+}
+
+func (oqMgr *MasterOwnerQuotaManager) quotaUpdate(report *proto.MetaPartitionReport) {
+	if !report.IsLeader {
+		return
+	}
+
+	// the changed quota infos are submitted to raft after the lock is released,
+	// so a slow commit does not block the quota requests and the other reports
+	changed := oqMgr.updateUsedInfo(report)
+	if len(changed) == 0 {
+		return
+	}
+	oqMgr.syncMutex.Lock()
+	defer oqMgr.syncMutex.Unlock()
+	for _, quotaInfo := range changed {
+		// skip the quota deleted or set again in the meantime, it must not be brought back
+		current, err := oqMgr.getQuota(quotaInfo.Type, quotaInfo.Id)
+		if err != nil {
+			continue
+		}
+		current.UsedInfo, current.LimitedInfo = quotaInfo.UsedInfo, quotaInfo.LimitedInfo
+		if *current != *quotaInfo {
+			continue
+		}
+		if err := oqMgr.syncQuota(opSyncSetOwnerQuota, quotaInfo); err != nil {
+			log.LogWarnf("[ownerQuotaUpdate] sync owner quota [%v] fail [%v]", quotaInfo, err)
+		}
+	}
+}
+
+// updateUsedInfo sums up the used info and returns the copies of the quota infos
+// whose soft time is changed.
+func (oqMgr *MasterOwnerQuotaManager) updateUsedInfo(report *proto.MetaPartitionReport) (changed []*proto.OwnerQuotaInfo) {
+	oqMgr.Lock()
+	defer oqMgr.Unlock()
+
+	oqMgr.MpUsedInfoMap[report.PartitionID] = report.OwnerQuotaReportInfos
+	usedInfoMap := make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo)
+	for _, reportInfos := range oqMgr.MpUsedInfoMap {
+		for _, info := range reportInfos {
+			key := proto.OwnerQuotaKey{Type: info.Type, Id: info.Id}
+			usedInfo := usedInfoMap[key]
+			usedInfo.Add(&info.UsedInfo)
+			usedInfoMap[key] = usedInfo
+		}
+	}
+	oqMgr.UsedInfoMap = usedInfoMap
+
+	now := time.Now().Unix()
+	for key, quotaInfo := range oqMgr.QuotaInfoMap {
+		quotaInfo.UsedInfo = usedInfoMap[key]
+		// persist the time soft limits are exceeded, so the grace period survives the change of leader
+		if quotaInfo.UpdateSoftTime(now) {
+			copied := *quotaInfo
+			changed = append(changed, &copied)
+		}
+		quotaInfo.UpdateLimited(now)
+		log.LogDebugf("[ownerQuotaUpdate] vol [%v] key [%v] quotaInfo [%v]", oqMgr.vol.Name, key, quotaInfo)
+	}
+	return
+}
+
+func (oqMgr *MasterOwnerQuotaManager) getQuotaHbInfos() (infos []*proto.OwnerQuotaHeartBeatInfo) {
+	oqMgr.RLock()
+	defer oqMgr.RUnlock()
+	for key, quotaInfo := range oqMgr.QuotaInfoMap {
+		infos = append(infos, &proto.OwnerQuotaHeartBeatInfo{
+			VolName:     oqMgr.vol.Name,
+			Type:        key.Type,
+			Id:          key.Id,
+			LimitedInfo: quotaInfo.LimitedInfo,
+		})
+	}
+	return
+}
@@ -32,6 +32,7 @@ type MetaNode struct {
 	IsActive                  bool
 	Sender                    *AdminTaskManager `graphql:"-"`
 	ZoneName                  string            `json:"Zone"`
+	RackName                  string            `json:"Rack"`
 	MaxMemAvailWeight         uint64            `json:"MaxMemAvailWeight"`
 	Total                     uint64            `json:"TotalWeight"`
 	Used                      uint64            `json:"UsedWeight"`
@@ -591,7 +591,9 @@ func (mp *MetaPartition) replicaCreationTasks(clusterID, volName string) (tasks
 	return
 }
 
-func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName string) (tasks []*proto.AdminTask) {
+func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName string,
+	storeMode proto.MetaStoreMode,
+) (tasks []*proto.AdminTask) {
 	tasks = make([]*proto.AdminTask, 0)
 	var hosts []string
 
@@ -602,6 +604,7 @@ func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers
 		Members:     peers,
 		VolName:     volName,
 		VerSeq:      mp.VerSeq,
+		StoreMode:   storeMode,
 	}
 	if specifyAddrs == nil {
 		hosts = mp.Hosts
@@ -655,7 +658,7 @@ func (mp *MetaPartition) createTaskToTryToChangeLeader(addr string) (task *proto
 	return
 }
 
-func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminTask, err error) {
+func (mp *MetaPartition) createTaskToCreateReplica(host string, storeMode proto.MetaStoreMode) (t *proto.AdminTask, err error) {
 	req := &proto.CreateMetaPartitionRequest{
 		Start:       mp.Start,
 		End:         mp.End,
@@ -663,6 +666,7 @@ func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminT
 		Members:     mp.Peers,
 		VolName:     mp.volName,
 		VerSeq:      mp.VerSeq,
+		StoreMode:   storeMode,
 	}
 	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
 	resetMetaPartitionTaskID(t, mp.PartitionID)
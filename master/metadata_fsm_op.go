@@ -62,6 +62,9 @@ type clusterValue struct {
 	EnableAutoDecommissionDisk  bool
 	DecommissionDiskFactor      float64
 	VolDeletionDelayTimeHour    int64
+	DpBalanceEnable             bool
+	DpBalanceThreshold          float64
+	DpBalanceMaxMigrations      int
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -94,6 +97,10 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		DecommissionDiskFactor:      c.DecommissionDiskFactor,
 		VolDeletionDelayTimeHour:    c.cfg.volDelayDeleteTimeHour,
 	}
+	dpBalanceConfig := c.dpBalancer.getConfig()
+	cv.DpBalanceEnable = dpBalanceConfig.Enable
+	cv.DpBalanceThreshold = dpBalanceConfig.Threshold
+	cv.DpBalanceMaxMigrations = dpBalanceConfig.MaxMigrations
 	return cv
 }
 
@@ -288,6 +295,8 @@ type volValue struct {
 	TxConflictRetryNum      int64
 	TxConflictRetryInterval int64
 	TxOpLimit               int
+	MetaStoreMode           bsProto.MetaStoreMode
+	CompressCodec           string
 
 	VolQosEnable                                           bool
 	DiskQosEnable                                          bool
@@ -296,6 +305,7 @@ type volValue struct {
 	ClientReqPeriod, ClientHitTriggerCnt                   uint32
 	Forbidden                                              bool
 	EnableAuditLog                                         bool
+	EnableReplication                                      bool
 }
 
 func (v *volValue) Bytes() (raw []byte, err error) {
@@ -333,6 +343,8 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		TxConflictRetryNum:      vol.txConflictRetryNum,
 		TxConflictRetryInterval: vol.txConflictRetryInterval,
 		TxOpLimit:               vol.txOpLimit,
+		MetaStoreMode:           vol.metaStoreMode,
+		CompressCodec:           vol.compressCodec,
 
 		VolType:             vol.VolType,
 		EbsBlkSize:          vol.EbsBlkSize,
@@ -359,6 +371,7 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		DpReadOnlyWhenVolFull: vol.DpReadOnlyWhenVolFull,
 		Forbidden:             vol.Forbidden,
 		EnableAuditLog:        vol.EnableAuditLog,
+		EnableReplication:     vol.EnableReplication,
 		AuthKey:               vol.authKey,
 		DeleteExecTime:        vol.DeleteExecTime,
 		User:                  vol.user,
@@ -380,6 +393,7 @@ type dataNodeValue struct {
 	NodeSetID                uint64
 	Addr                     string
 	ZoneName                 string
+	RackName                 string
 	RdOnly                   bool
 	DecommissionedDisks      []string
 	DecommissionStatus       uint32
@@ -399,6 +413,7 @@ func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 		NodeSetID:                dataNode.NodeSetID,
 		Addr:                     dataNode.Addr,
 		ZoneName:                 dataNode.ZoneName,
+		RackName:                 dataNode.RackName,
 		RdOnly:                   dataNode.RdOnly,
 		DecommissionedDisks:      dataNode.getDecommissionedDisks(),
 		DecommissionStatus:       atomic.LoadUint32(&dataNode.DecommissionStatus),
@@ -418,6 +433,7 @@ type metaNodeValue struct {
 	NodeSetID uint64
 	Addr      string
 	ZoneName  string
+	RackName  string
 	RdOnly    bool
 }
 
@@ -427,6 +443,7 @@ func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
 		NodeSetID: metaNode.NodeSetID,
 		Addr:      metaNode.Addr,
 		ZoneName:  metaNode.ZoneName,
+		RackName:  metaNode.RackName,
 		RdOnly:    metaNode.RdOnly,
 	}
 }
@@ -1149,6 +1166,19 @@ func (c *Cluster) loadClusterValue() (err error) {
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 
 		c.checkDataReplicasEnable = cv.CheckDataReplicasEnable
+
+		dpBalanceConfig := bsProto.DpBalanceConfig{
+			Enable:        cv.DpBalanceEnable,
+			Threshold:     cv.DpBalanceThreshold,
+			MaxMigrations: cv.DpBalanceMaxMigrations,
+		}
+		if dpBalanceConfig.Threshold <= 0 {
+			dpBalanceConfig.Threshold = defaultDpBalanceThreshold
+		}
+		if dpBalanceConfig.MaxMigrations <= 0 {
+			dpBalanceConfig.MaxMigrations = defaultDpBalanceMaxMigrations
+		}
+		c.dpBalancer.setConfig(dpBalanceConfig)
 	}
 	return
 }
@@ -1362,6 +1392,7 @@ func (c *Cluster) loadDataNodes() (err error) {
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
 		dataNode.RdOnly = dnv.RdOnly
+		dataNode.RackName = dnv.RackName
 		for _, disk := range dnv.DecommissionedDisks {
 			dataNode.addDecommissionedDisk(disk)
 		}
@@ -1412,6 +1443,7 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
 		metaNode.RdOnly = mnv.RdOnly
+		metaNode.RackName = mnv.RackName
 
 		oldmn, ok := c.metaNodes.Load(metaNode.Addr)
 		if ok {
@@ -1573,6 +1605,10 @@ func (c *Cluster) loadQuota() (err error) {
 			log.LogErrorf("loadQuota loadQuotaManager vol [%v] fail err [%v]", name, err.Error())
 			return err
 		}
+		if err = vol.loadOwnerQuotaManager(c); err != nil {
+			log.LogErrorf("loadQuota loadOwnerQuotaManager vol [%v] fail err [%v]", name, err.Error())
+			return err
+		}
 	}
 	return
 }
@@ -88,6 +88,9 @@ const (
 	MetricLcTotalFileScanned         = "lc_total_file_scanned"
 	MetricLcTotalDirScanned          = "lc_total_dirs_scanned"
 	MetricLcTotalExpired             = "lc_total_expired"
+	MetricLcTotalTransitioned        = "lc_total_transitioned"
+	MetricLcTotalAbortedMultipart    = "lc_total_aborted_multipart"
+	MetricLcTotalLockedSkipped       = "lc_total_locked_skipped"
 )
 
 var WarnMetrics *warningMetrics
@@ -149,6 +152,9 @@ type monitorMetrics struct {
 	lcTotalFileScanned *exporter.GaugeVec
 	lcTotalDirScanned  *exporter.GaugeVec
 	lcTotalExpired     *exporter.GaugeVec
+	lcTotalTransited   *exporter.GaugeVec
+	lcTotalAborted     *exporter.GaugeVec
+	lcTotalLocked      *exporter.GaugeVec
 }
 
 func newMonitorMetrics(c *Cluster) *monitorMetrics {
@@ -477,6 +483,9 @@ func (mm *monitorMetrics) start() {
 	mm.lcTotalFileScanned = exporter.NewGaugeVec(MetricLcTotalFileScanned, "", []string{"volName", "type"})
 	mm.lcTotalDirScanned = exporter.NewGaugeVec(MetricLcTotalDirScanned, "", []string{"volName", "type"})
 	mm.lcTotalExpired = exporter.NewGaugeVec(MetricLcTotalExpired, "", []string{"volName", "type"})
+	mm.lcTotalTransited = exporter.NewGaugeVec(MetricLcTotalTransitioned, "", []string{"volName", "type"})
+	mm.lcTotalAborted = exporter.NewGaugeVec(MetricLcTotalAbortedMultipart, "", []string{"volName", "type"})
+	mm.lcTotalLocked = exporter.NewGaugeVec(MetricLcTotalLockedSkipped, "", []string{"volName", "type"})
 	go mm.statMetrics()
 }
 
@@ -836,6 +845,7 @@ func (mm *monitorMetrics) updateDataNodesStat() {
 		mm.nodeStat.SetWithLabelValues(float64(dataNode.AvailableSpace), MetricRoleDataNode, dataNode.Addr, "diskAvail")
 		mm.nodeStat.SetWithLabelValues(dataNode.UsageRatio, MetricRoleDataNode, dataNode.Addr, "usageRatio")
 		mm.nodeStat.SetWithLabelValues(float64(len(dataNode.BadDisks)), MetricRoleDataNode, dataNode.Addr, "badDiskCount")
+		mm.nodeStat.SetWithLabelValues(float64(dataNode.unrepairedCorruptExtents()), MetricRoleDataNode, dataNode.Addr, "corruptExtentCount")
 		mm.nodeStat.SetBoolWithLabelValues(dataNode.isActive, MetricRoleDataNode, dataNode.Addr, "active")
 		mm.nodeStat.SetBoolWithLabelValues(dataNode.isWriteAble(), MetricRoleDataNode, dataNode.Addr, "writable")
 		return true
@@ -897,6 +907,10 @@ func (mm *monitorMetrics) deleteS3LcVolMetric(volName string) {
 	mm.lcTotalFileScanned.DeleteLabelValues(volName, "file")
 	mm.lcTotalDirScanned.DeleteLabelValues(volName, "dir")
 	mm.lcTotalExpired.DeleteLabelValues(volName, "expired")
+	mm.lcTotalExpired.DeleteLabelValues(volName, "noncurrent")
+	mm.lcTotalTransited.DeleteLabelValues(volName, "transitioned")
+	mm.lcTotalAborted.DeleteLabelValues(volName, "aborted")
+	mm.lcTotalLocked.DeleteLabelValues(volName, "locked")
 }
 
 func (mm *monitorMetrics) setLcMetrics() {
@@ -918,6 +932,10 @@ func (mm *monitorMetrics) setLcMetrics() {
 		mm.lcTotalFileScanned.SetWithLabelValues(float64(stat.FileScannedNum), key, "file")
 		mm.lcTotalDirScanned.SetWithLabelValues(float64(stat.DirScannedNum), key, "dir")
 		mm.lcTotalExpired.SetWithLabelValues(float64(stat.ExpiredNum), key, "expired")
+		mm.lcTotalExpired.SetWithLabelValues(float64(stat.NoncurrentExpiredNum), key, "noncurrent")
+		mm.lcTotalTransited.SetWithLabelValues(float64(stat.TransitionedNum), key, "transitioned")
+		mm.lcTotalAborted.SetWithLabelValues(float64(stat.AbortedMultipartNum), key, "aborted")
+		mm.lcTotalLocked.SetWithLabelValues(float64(stat.LockedSkippedNum), key, "locked")
 	}
 }
 
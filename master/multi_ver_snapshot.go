@@ -39,6 +39,7 @@ type VolVersionPersist struct {
 	MultiVersionList []*proto.VolVersionInfo
 	Strategy         proto.VolumeVerStrategy
 	VerSeq           uint64
+	Retention        []*proto.SnapshotRetentionRule `json:",omitempty"`
 }
 
 type VolVersionManager struct {
@@ -52,6 +53,7 @@ type VolVersionManager struct {
 	verSeq           uint64
 	enabled          bool
 	strategy         proto.VolumeVerStrategy
+	retention        []*proto.SnapshotRetentionRule
 	checkStrategy    int32
 	checkStatus      int32
 	c                *Cluster
@@ -82,6 +84,7 @@ func (verMgr *VolVersionManager) Persist() (err error) {
 		MultiVersionList: verMgr.multiVersionList,
 		Strategy:         verMgr.strategy,
 		VerSeq:           verMgr.verSeq,
+		Retention:        verMgr.retention,
 	}
 	var val []byte
 	if val, err = json.Marshal(persistInfo); err != nil {
@@ -105,6 +108,7 @@ func (verMgr *VolVersionManager) loadMultiVersion(c *Cluster, val []byte) (err e
 	verMgr.multiVersionList = persistInfo.MultiVersionList
 	verMgr.verSeq = persistInfo.VerSeq
 	verMgr.strategy = persistInfo.Strategy
+	verMgr.retention = persistInfo.Retention
 	return nil
 }
 
@@ -146,8 +150,12 @@ func (verMgr *VolVersionManager) GenerateVer(verSeq uint64, op uint8) (err error
 	defer verMgr.Unlock()
 	tm := time.Now()
 	verMgr.enabled = true
-	if len(verMgr.multiVersionList) > MaxSnapshotCount {
-		err = fmt.Errorf("too much version exceed %v in list", MaxSnapshotCount)
+	maxCount := MaxSnapshotCount
+	if len(verMgr.retention) > 0 {
+		maxCount = MaxRetainedSnapshotCount
+	}
+	if len(verMgr.multiVersionList) > maxCount {
+		err = fmt.Errorf("too much version exceed %v in list", maxCount)
 		log.LogWarnf("action[GenerateVer] vol %v err %v", verMgr.vol.Name, err)
 		return
 	}
@@ -183,6 +191,15 @@ func (verMgr *VolVersionManager) DelVer(verSeq uint64) (err error) {
 			break
 		}
 	}
+	// the version is gone for the whole volume, so the rules need not remember it
+	for _, rule := range verMgr.retention {
+		for i, ver := range rule.ExpiredVers {
+			if ver == verSeq {
+				rule.ExpiredVers = append(rule.ExpiredVers[:i], rule.ExpiredVers[i+1:]...)
+				break
+			}
+		}
+	}
 	if err = verMgr.Persist(); err != nil {
 		log.LogErrorf("[DelVer] vol %v call persist error %v", verMgr.vol.Name, err)
 	}
@@ -225,7 +242,8 @@ func (verMgr *VolVersionManager) SetVerStrategy(strategy proto.VolumeVerStrategy
 func (verMgr *VolVersionManager) checkCreateStrategy(c *Cluster) {
 	verMgr.RLock()
 	log.LogDebugf("checkSnapshotStrategy enter")
-	if len(verMgr.multiVersionList)-1 > verMgr.strategy.KeepVerCnt {
+	// the retention rules decide which versions to keep instead of the count
+	if len(verMgr.retention) == 0 && len(verMgr.multiVersionList)-1 > verMgr.strategy.KeepVerCnt {
 		verMgr.RUnlock()
 		return
 	}
@@ -278,6 +296,164 @@ func (verMgr *VolVersionManager) checkDeleteStrategy(c *Cluster) {
 	verMgr.RUnlock()
 }
 
+func (verMgr *VolVersionManager) SetRetention(rules []*proto.SnapshotRetentionRule) (err error) {
+	verMgr.Lock()
+	defer verMgr.Unlock()
+
+	// the data of the expired versions are deleted already if the rule is not changed
+	for _, rule := range rules {
+		for _, old := range verMgr.retention {
+			if old.ID == rule.ID && old.Prefix == rule.Prefix {
+				rule.ExpiredVers = old.ExpiredVers
+			}
+		}
+	}
+	verMgr.retention = rules
+	log.LogWarnf("action[SetRetention] vol %v rules %v", verMgr.vol.Name, len(rules))
+	if err = verMgr.Persist(); err != nil {
+		log.LogErrorf("action[SetRetention] vol %v err %v", verMgr.vol.Name, err)
+	}
+	return
+}
+
+func (verMgr *VolVersionManager) getRetention() []*proto.SnapshotRetentionRule {
+	verMgr.RLock()
+	defer verMgr.RUnlock()
+
+	rules := make([]*proto.SnapshotRetentionRule, 0, len(verMgr.retention))
+	for _, rule := range verMgr.retention {
+		r := *rule
+		r.ExpiredVers = append([]uint64(nil), rule.ExpiredVers...)
+		rules = append(rules, &r)
+	}
+	return rules
+}
+
+// retentionCandidates returns the committed versions in normal status, which may be expired by the retention rules.
+func (verMgr *VolVersionManager) retentionCandidates() (vers []uint64) {
+	for i, ver := range verMgr.multiVersionList {
+		// the last one is the version being written
+		if i == len(verMgr.multiVersionList)-1 {
+			break
+		}
+		if ver.Status == proto.VersionNormal {
+			vers = append(vers, ver.Ver)
+		}
+	}
+	return
+}
+
+// evalSnapshotRetention returns the versions to delete from the whole volume, and the versions to delete
+// under the prefix of each rule. A rule never expires a version which is kept by a rule within its directory,
+// so a version is deleted from the whole volume only if all the rules agree.
+func evalSnapshotRetention(rules []*proto.SnapshotRetentionRule, vers []uint64, now time.Time) (volExpired []uint64, prefixExpired map[string][]uint64) {
+	retains := make(map[string]map[uint64]bool, len(rules))
+	for _, rule := range rules {
+		retains[rule.ID] = rule.Retains(vers, now)
+	}
+	expires := func(rule *proto.SnapshotRetentionRule, ver uint64) bool {
+		for _, r := range rules {
+			if rule.Covers(r) && retains[r.ID][ver] {
+				return false
+			}
+		}
+		return true
+	}
+
+	volExpiredSet := make(map[uint64]bool)
+	for _, rule := range rules {
+		if rule.Prefix != "" {
+			continue
+		}
+		for _, ver := range vers {
+			if expires(rule, ver) {
+				volExpired = append(volExpired, ver)
+				volExpiredSet[ver] = true
+			}
+		}
+	}
+
+	prefixExpired = make(map[string][]uint64)
+	for _, rule := range rules {
+		if rule.Prefix == "" {
+			continue
+		}
+	nextVer:
+		for _, ver := range vers {
+			if volExpiredSet[ver] || rule.IsExpired(ver) || !expires(rule, ver) {
+				continue
+			}
+			// the version is deleted by the rule of a parent directory
+			for _, r := range rules {
+				if r.ID != rule.ID && r.Prefix != "" && r.Prefix != rule.Prefix && r.Covers(rule) && expires(r, ver) {
+					continue nextVer
+				}
+			}
+			prefixExpired[rule.ID] = append(prefixExpired[rule.ID], ver)
+		}
+	}
+	return
+}
+
+// checkRetentionStrategy deletes the oldest version expired by the retention rules from the whole volume.
+func (verMgr *VolVersionManager) checkRetentionStrategy(c *Cluster) {
+	verMgr.RLock()
+	volExpired, _ := evalSnapshotRetention(verMgr.retention, verMgr.retentionCandidates(), time.Now())
+	force := verMgr.strategy.ForceUpdate
+	verMgr.RUnlock()
+
+	if len(volExpired) == 0 {
+		return
+	}
+	log.LogInfof("action[checkRetentionStrategy] vol %v delete expired version %v, expired cnt %v", verMgr.vol.Name, volExpired[0], len(volExpired))
+	if _, err := verMgr.createVer2PhaseTask(c, volExpired[0], proto.DeleteVersion, force); err != nil {
+		log.LogWarnf("action[checkRetentionStrategy] vol %v delete version %v err %v", verMgr.vol.Name, volExpired[0], err)
+	}
+}
+
+// getRetentionDelTasks returns the tasks to delete the versions expired by the retention rules under their prefixes.
+func (verMgr *VolVersionManager) getRetentionDelTasks(now time.Time) (tasks []*proto.SnapshotVerDelTask) {
+	verMgr.RLock()
+	defer verMgr.RUnlock()
+
+	if len(verMgr.retention) == 0 {
+		return
+	}
+	_, prefixExpired := evalSnapshotRetention(verMgr.retention, verMgr.retentionCandidates(), now)
+	for _, rule := range verMgr.retention {
+		for _, ver := range prefixExpired[rule.ID] {
+			tasks = append(tasks, &proto.SnapshotVerDelTask{
+				Id:      fmt.Sprintf("%s:%d:%s", verMgr.vol.Name, ver, rule.ID),
+				VolName: verMgr.vol.Name,
+				VolVersionInfo: &proto.VolVersionInfo{
+					Ver:    ver,
+					Status: proto.VersionNormal,
+				},
+				Prefix: rule.Prefix,
+				RuleID: rule.ID,
+			})
+		}
+	}
+	return
+}
+
+// addRetentionExpiredVer records that the data of the version under the prefix of the rule are deleted.
+func (verMgr *VolVersionManager) addRetentionExpiredVer(ruleID string, verSeq uint64) (err error) {
+	verMgr.Lock()
+	defer verMgr.Unlock()
+
+	for _, rule := range verMgr.retention {
+		if rule.ID == ruleID && !rule.IsExpired(verSeq) {
+			rule.ExpiredVers = append(rule.ExpiredVers, verSeq)
+			if err = verMgr.Persist(); err != nil {
+				log.LogErrorf("action[addRetentionExpiredVer] vol %v err %v", verMgr.vol.Name, err)
+			}
+			return
+		}
+	}
+	return
+}
+
 func (verMgr *VolVersionManager) UpdateVerStatus(verSeq uint64, status uint8) (err error) {
 	verMgr.Lock()
 	defer verMgr.Unlock()
@@ -297,6 +473,8 @@ const (
 	TypeNoReply      = 0
 	TypeReply        = 1
 	MaxSnapshotCount = 30
+	// the max number of versions of a volume with retention rules
+	MaxRetainedSnapshotCount = 1024
 )
 
 func (verMgr *VolVersionManager) handleTaskRsp(resp *proto.MultiVersionOpResponse, partitionType uint32) {
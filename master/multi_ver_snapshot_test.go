@@ -0,0 +1,71 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalSnapshotRetention(t *testing.T) {
+	now := time.Now()
+	ver := func(hours int) uint64 {
+		return uint64(now.Add(-time.Duration(hours)*time.Hour + 30*time.Minute).UnixMicro())
+	}
+	// hourly versions of the last 4 days
+	var vers []uint64
+	for h := 96; h > 0; h-- {
+		vers = append(vers, ver(h))
+	}
+
+	hourly := func(hours int64) []proto.SnapshotRetentionTier {
+		return []proto.SnapshotRetentionTier{{Interval: 3600, Duration: hours * 3600}}
+	}
+	vol := &proto.SnapshotRetentionRule{ID: "vol", Tiers: hourly(24)}
+	dirA := &proto.SnapshotRetentionRule{ID: "a", Prefix: "a", Tiers: hourly(48)}
+	dirAB := &proto.SnapshotRetentionRule{ID: "ab", Prefix: "a/b", Tiers: hourly(12)}
+	dirC := &proto.SnapshotRetentionRule{ID: "c", Prefix: "c", Tiers: hourly(6)}
+
+	// only the volume rule
+	volExpired, prefixExpired := evalSnapshotRetention([]*proto.SnapshotRetentionRule{vol}, vers, now)
+	require.Len(t, volExpired, 72)
+	require.Equal(t, ver(96), volExpired[0])
+	require.Len(t, prefixExpired, 0)
+
+	// no version is deleted from the volume if a rule of a directory keeps it
+	rules := []*proto.SnapshotRetentionRule{vol, dirA, dirAB, dirC}
+	volExpired, prefixExpired = evalSnapshotRetention(rules, vers, now)
+	require.Len(t, volExpired, 48)
+	require.NotContains(t, volExpired, ver(30))
+	// a/b is covered by the rule of a
+	require.Len(t, prefixExpired["a"], 0)
+	require.Len(t, prefixExpired["ab"], 36)
+	require.Contains(t, prefixExpired["ab"], ver(30))
+	require.Len(t, prefixExpired["c"], 42)
+
+	// the versions already deleted under the prefix are skipped
+	dirC.ExpiredVers = []uint64{ver(30)}
+	_, prefixExpired = evalSnapshotRetention(rules, vers, now)
+	require.Len(t, prefixExpired["c"], 41)
+
+	// without the volume rule, the versions are kept for the volume, only deleted under the prefixes
+	volExpired, prefixExpired = evalSnapshotRetention([]*proto.SnapshotRetentionRule{dirA, dirAB}, vers, now)
+	require.Len(t, volExpired, 0)
+	require.Len(t, prefixExpired["a"], 48)
+	require.Len(t, prefixExpired["ab"], 36)
+}
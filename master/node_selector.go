@@ -599,13 +599,99 @@ func NewNodeSelector(name string, nodeType NodeType) NodeSelector {
 	}
 }
 
+func (ns *nodeSet) getNodeRack(nodeType NodeType, addr string) string {
+	value, ok := ns.getNodes(nodeType).Load(addr)
+	if !ok {
+		return ""
+	}
+	switch nodeType {
+	case DataNodeType:
+		return value.(*DataNode).RackName
+	case MetaNodeType:
+		return value.(*MetaNode).RackName
+	default:
+		return ""
+	}
+}
+
+func (ns *nodeSet) hasRackLabel(nodeType NodeType) (ok bool) {
+	ns.getNodes(nodeType).Range(func(key, value interface{}) bool {
+		ok = ns.getNodeRack(nodeType, key.(string)) != ""
+		return !ok
+	})
+	return
+}
+
+// selectRackAwareHosts selects the replicas one by one, the nodes on the racks of the selected hosts
+// and the excluded hosts are excluded, so that no two replicas share a rack within the node set.
+// The rack of replaceHost is kept available as the new replica is going to take its place.
+// The nodes without a rack are not constrained.
+func (ns *nodeSet) selectRackAwareHosts(selector NodeSelector, nodeType NodeType, excludeHosts []string,
+	replaceHost string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
+	if replicaNum == 0 || !ns.hasRackLabel(nodeType) {
+		return selector.Select(ns, excludeHosts, replicaNum)
+	}
+
+	racks := make(map[string]bool)
+	for _, host := range excludeHosts {
+		if host == replaceHost {
+			continue
+		}
+		if rack := ns.getNodeRack(nodeType, host); rack != "" {
+			racks[rack] = true
+		}
+	}
+
+	orderHosts := make([]string, 0, replicaNum)
+	peers = make([]proto.Peer, 0, replicaNum)
+	for i := 0; i < replicaNum; i++ {
+		rackExcludeHosts := make([]string, 0, len(excludeHosts)+len(orderHosts))
+		rackExcludeHosts = append(rackExcludeHosts, excludeHosts...)
+		rackExcludeHosts = append(rackExcludeHosts, orderHosts...)
+		ns.getNodes(nodeType).Range(func(key, value interface{}) bool {
+			if addr := key.(string); racks[ns.getNodeRack(nodeType, addr)] {
+				rackExcludeHosts = append(rackExcludeHosts, addr)
+			}
+			return true
+		})
+
+		var hosts []string
+		var selectedPeers []proto.Peer
+		if hosts, selectedPeers, err = selector.Select(ns, rackExcludeHosts, 1); err != nil {
+			err = fmt.Errorf("action[selectRackAwareHosts] nodeSet[%v] no enough racks, replicaNum:%v selected:%v err:%v",
+				ns.ID, replicaNum, orderHosts, err)
+			return nil, nil, err
+		}
+		orderHosts = append(orderHosts, hosts[0])
+		peers = append(peers, selectedPeers...)
+		if rack := ns.getNodeRack(nodeType, hosts[0]); rack != "" {
+			racks[rack] = true
+		}
+	}
+	log.LogInfof("action[selectRackAwareHosts] nodeSet[%v] peers[%v]", ns.ID, peers)
+	if newHosts, err = reshuffleHosts(orderHosts); err != nil {
+		err = fmt.Errorf("action[selectRackAwareHosts] err:%v  orderHosts is nil", err.Error())
+		return
+	}
+	return
+}
+
 func (ns *nodeSet) getAvailMetaNodeHosts(excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
 	ns.nodeSelectLock.Lock()
 	defer ns.nodeSelectLock.Unlock()
 	// we need a read lock to block the modify of node selector
 	ns.metaNodeSelectorLock.RLock()
 	defer ns.metaNodeSelectorLock.RUnlock()
-	return ns.metaNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.selectRackAwareHosts(ns.metaNodeSelector, MetaNodeType, excludeHosts, "", replicaNum)
+}
+
+// getReplaceMetaNodeHosts selects a meta node to replace srcAddr, the new host may share the rack of srcAddr.
+func (ns *nodeSet) getReplaceMetaNodeHosts(excludeHosts []string, srcAddr string) (newHosts []string, peers []proto.Peer, err error) {
+	ns.nodeSelectLock.Lock()
+	defer ns.nodeSelectLock.Unlock()
+	ns.metaNodeSelectorLock.RLock()
+	defer ns.metaNodeSelectorLock.RUnlock()
+	return ns.selectRackAwareHosts(ns.metaNodeSelector, MetaNodeType, excludeHosts, srcAddr, 1)
 }
 
 func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int) (hosts []string, peers []proto.Peer, err error) {
@@ -614,5 +700,14 @@ func (ns *nodeSet) getAvailDataNodeHosts(excludeHosts []string, replicaNum int)
 	// we need a read lock to block the modify of node selector
 	ns.dataNodeSelectorLock.Lock()
 	defer ns.dataNodeSelectorLock.Unlock()
-	return ns.dataNodeSelector.Select(ns, excludeHosts, replicaNum)
+	return ns.selectRackAwareHosts(ns.dataNodeSelector, DataNodeType, excludeHosts, "", replicaNum)
+}
+
+// getReplaceDataNodeHosts selects a data node to replace srcAddr, the new host may share the rack of srcAddr.
+func (ns *nodeSet) getReplaceDataNodeHosts(excludeHosts []string, srcAddr string) (hosts []string, peers []proto.Peer, err error) {
+	ns.nodeSelectLock.Lock()
+	defer ns.nodeSelectLock.Unlock()
+	ns.dataNodeSelectorLock.Lock()
+	defer ns.dataNodeSelectorLock.Unlock()
+	return ns.selectRackAwareHosts(ns.dataNodeSelector, DataNodeType, excludeHosts, srcAddr, 1)
 }
@@ -536,3 +536,84 @@ func TestBenchStrawNodeSelector(t *testing.T) {
 	selector = NewStrawNodeSelector(MetaNodeType)
 	metaNodeSelectorBench(t, selector)
 }
+
+func TestRackAwareNodeSelect(t *testing.T) {
+	const rackCount = 3
+	dataNs := prepareDataNodesForBench(rackCount*2, 100*util.GB, 0)
+	metaNs := prepareMetaNodesForBench(rackCount*2, 100*util.GB, 0)
+	dataNs.dataNodes.Range(func(key, value interface{}) bool {
+		node := value.(*DataNode)
+		node.RackName = fmt.Sprintf("rack%v", node.ID%rackCount)
+		return true
+	})
+	metaNs.metaNodes.Range(func(key, value interface{}) bool {
+		node := value.(*MetaNode)
+		node.RackName = fmt.Sprintf("rack%v", node.ID%rackCount)
+		return true
+	})
+	checkRacks := func(ns *nodeSet, nodeType NodeType, hosts []string) {
+		racks := make(map[string]bool)
+		for _, host := range hosts {
+			rack := ns.getNodeRack(nodeType, host)
+			if racks[rack] {
+				t.Errorf("hosts %v share the rack %v", hosts, rack)
+			}
+			racks[rack] = true
+		}
+	}
+
+	for i := 0; i < loopNodeSelectorTestCount; i++ {
+		hosts, _, err := dataNs.getAvailDataNodeHosts(nil, rackCount)
+		if err != nil {
+			t.Fatalf("failed to select data nodes %v", err)
+		}
+		checkRacks(dataNs, DataNodeType, hosts)
+		hosts, _, err = metaNs.getAvailMetaNodeHosts(nil, rackCount)
+		if err != nil {
+			t.Fatalf("failed to select meta nodes %v", err)
+		}
+		checkRacks(metaNs, MetaNodeType, hosts)
+	}
+	if _, _, err := dataNs.getAvailDataNodeHosts(nil, rackCount+1); err == nil {
+		t.Errorf("data nodes should not be selected on %v racks", rackCount)
+	}
+	if _, _, err := metaNs.getAvailMetaNodeHosts(nil, rackCount+1); err == nil {
+		t.Errorf("meta nodes should not be selected on %v racks", rackCount)
+	}
+
+	// all the racks are used by the replicas, a new replica could be only added on the rack of the replaced one
+	hosts, _, err := dataNs.getAvailDataNodeHosts(nil, rackCount)
+	if err != nil {
+		t.Fatalf("failed to select data nodes %v", err)
+	}
+	if _, _, err = dataNs.getAvailDataNodeHosts(hosts, 1); err == nil {
+		t.Errorf("data node should not be added on the racks of %v", hosts)
+	}
+	newHosts, _, err := dataNs.getReplaceDataNodeHosts(hosts, hosts[0])
+	if err != nil {
+		t.Fatalf("failed to select data node to replace %v, err %v", hosts[0], err)
+	}
+	if dataNs.getNodeRack(DataNodeType, newHosts[0]) != dataNs.getNodeRack(DataNodeType, hosts[0]) {
+		t.Errorf("data node %v is not on the rack of %v", newHosts[0], hosts[0])
+	}
+	hosts, _, err = metaNs.getAvailMetaNodeHosts(nil, rackCount)
+	if err != nil {
+		t.Fatalf("failed to select meta nodes %v", err)
+	}
+	_, peers, err := metaNs.getReplaceMetaNodeHosts(hosts, hosts[1])
+	if err != nil {
+		t.Fatalf("failed to select meta node to replace %v, err %v", hosts[1], err)
+	}
+	if metaNs.getNodeRack(MetaNodeType, peers[0].Addr) != metaNs.getNodeRack(MetaNodeType, hosts[1]) {
+		t.Errorf("meta node %v is not on the rack of %v", peers[0].Addr, hosts[1])
+	}
+
+	// the nodes without a rack are not constrained
+	dataNs.dataNodes.Range(func(key, value interface{}) bool {
+		value.(*DataNode).RackName = ""
+		return true
+	})
+	if _, _, err = dataNs.getAvailDataNodeHosts(nil, rackCount+1); err != nil {
+		t.Errorf("failed to select data nodes without racks %v", err)
+	}
+}
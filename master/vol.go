@@ -49,6 +49,7 @@ type VolVarargs struct {
 	txConflictRetryNum      int64
 	txConflictRetryInterval int64
 	txOpLimit               int
+	compressCodec           string
 }
 
 // Vol represents a set of meta partitionMap and data partitionMap
@@ -89,6 +90,8 @@ type Vol struct {
 	txConflictRetryNum      int64
 	txConflictRetryInterval int64
 	txOpLimit               int
+	metaStoreMode           proto.MetaStoreMode
+	compressCodec           string
 	zoneName                string
 	MetaPartitions          map[uint64]*MetaPartition `graphql:"-"`
 	dataPartitions          *DataPartitionMap
@@ -108,11 +111,13 @@ type Vol struct {
 	uidSpaceManager         *UidSpaceManager
 	volLock                 sync.RWMutex
 	quotaManager            *MasterQuotaManager
+	ownerQuotaManager       *MasterOwnerQuotaManager
 	enableQuota             bool
 	VersionMgr              *VolVersionManager
 	Forbidden               bool
 	mpsLock                 *mpsLockManager
 	EnableAuditLog          bool
+	EnableReplication       bool
 	preloadCapacity         uint64
 	authKey                 string
 	DeleteExecTime          time.Time
@@ -151,6 +156,8 @@ func newVol(vv volValue) (vol *Vol) {
 	vol.txConflictRetryNum = vv.TxConflictRetryNum
 	vol.txConflictRetryInterval = vv.TxConflictRetryInterval
 	vol.txOpLimit = vv.TxOpLimit
+	vol.metaStoreMode = vv.MetaStoreMode
+	vol.compressCodec = vv.CompressCodec
 
 	vol.VolType = vv.VolType
 	vol.EbsBlkSize = vv.EbsBlkSize
@@ -207,6 +214,7 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	}
 	vol.Forbidden = vv.Forbidden
 	vol.EnableAuditLog = vv.EnableAuditLog
+	vol.EnableReplication = vv.EnableReplication
 	vol.authKey = vv.AuthKey
 	vol.DeleteExecTime = vv.DeleteExecTime
 	vol.user = vv.User
@@ -328,13 +336,21 @@ func (vol *Vol) CheckStrategy(c *Cluster) {
 					return
 				}
 				vol.VersionMgr.RLock()
-				if vol.VersionMgr.strategy.GetPeriodicSecond() == 0 || !vol.VersionMgr.strategy.Enable { // strategy not be set
-					vol.VersionMgr.RUnlock()
+				strategySet := vol.VersionMgr.strategy.GetPeriodicSecond() != 0 && vol.VersionMgr.strategy.Enable
+				retentionSet := len(vol.VersionMgr.retention) > 0
+				vol.VersionMgr.RUnlock()
+				if !strategySet && !retentionSet { // strategy not be set
 					continue
 				}
-				vol.VersionMgr.RUnlock()
-				vol.VersionMgr.checkCreateStrategy(c)
-				vol.VersionMgr.checkDeleteStrategy(c)
+				if strategySet {
+					vol.VersionMgr.checkCreateStrategy(c)
+				}
+				// the retention rules take the place of the count of the strategy
+				if retentionSet {
+					vol.VersionMgr.checkRetentionStrategy(c)
+				} else {
+					vol.VersionMgr.checkDeleteStrategy(c)
+				}
 			}
 		}
 	}()
@@ -1529,6 +1545,7 @@ func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	vol.txConflictRetryInterval = args.txConflictRetryInterval
 	vol.txOpLimit = args.txOpLimit
 	vol.dpReplicaNum = args.dpReplicaNum
+	vol.compressCodec = args.compressCodec
 
 	if proto.IsCold(vol.VolType) {
 		coldArgs := args.coldArgs
@@ -1581,6 +1598,7 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		txOpLimit:               vol.txOpLimit,
 		coldArgs:                args,
 		dpReadOnlyWhenVolFull:   vol.DpReadOnlyWhenVolFull,
+		compressCodec:           vol.compressCodec,
 	}
 }
 
@@ -1623,3 +1641,32 @@ func (vol *Vol) loadQuotaManager(c *Cluster) (err error) {
 
 	return err
 }
+
+func (vol *Vol) initOwnerQuotaManager(c *Cluster) {
+	vol.ownerQuotaManager = newMasterOwnerQuotaManager(c, vol)
+}
+
+func (vol *Vol) loadOwnerQuotaManager(c *Cluster) (err error) {
+	vol.ownerQuotaManager = newMasterOwnerQuotaManager(c, vol)
+
+	result, err := c.fsm.store.SeekForPrefix([]byte(ownerQuotaPrefix + strconv.FormatUint(vol.ID, 10) + keySeparator))
+	if err != nil {
+		err = fmt.Errorf("loadOwnerQuotaManager get quota failed, err [%v]", err)
+		return err
+	}
+
+	for _, value := range result {
+		quotaInfo := &proto.OwnerQuotaInfo{}
+		if err = json.Unmarshal(value, quotaInfo); err != nil {
+			log.LogErrorf("loadOwnerQuotaManager Unmarshal fail err [%v]", err)
+			return err
+		}
+		log.LogDebugf("loadOwnerQuotaManager info [%v]", quotaInfo)
+		if vol.Name != quotaInfo.VolName {
+			panic(fmt.Sprintf("vol name do not match vol name [%v], quotaInfo vol name [%v]", vol.Name, quotaInfo.VolName))
+		}
+		vol.ownerQuotaManager.QuotaInfoMap[quotaInfo.Key()] = quotaInfo
+	}
+
+	return err
+}
@@ -78,6 +78,7 @@ func createMetaPartition(rootDir string, t *testing.T) (mp *metaPartition) {
 		uniqChecker:    newUniqChecker(),
 	}
 	mp.uidManager = NewUidMgr(mpC.VolName, mpC.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(mpC.VolName, mpC.PartitionId)
 	mp.mqMgr = NewQuotaManager(mpC.VolName, mpC.PartitionId)
 
 	ino := NewInode(1, 0)
@@ -28,9 +28,11 @@ type (
 )
 
 // BTree is the wrapper of Google's btree.
+// The items of a BTree made by newKvBtree are kept in rocksdb, see kvTree.
 type BTree struct {
 	sync.RWMutex
 	tree *btree.BTree
+	kv   *kvTree
 }
 
 // NewBtree creates a new btree.
@@ -42,6 +44,12 @@ func NewBtree() *BTree {
 
 // Get returns the object of the given key in the btree.
 func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
+	if b.kv != nil {
+		b.Lock()
+		item = b.kvLookup(key, true)
+		b.Unlock()
+		return
+	}
 	b.RLock()
 	item = b.tree.Get(key)
 	b.RUnlock()
@@ -50,6 +58,10 @@ func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
 
 func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
 	b.Lock()
+	if b.kv != nil && b.kvLookup(key, true) == nil {
+		b.Unlock()
+		return
+	}
 	item = b.tree.CopyGet(key)
 	b.Unlock()
 	return
@@ -57,9 +69,7 @@ func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
 
 // Find searches for the given key in the btree.
 func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
-	b.RLock()
-	item := b.tree.Get(key)
-	b.RUnlock()
+	item := b.Get(key)
 	if item == nil {
 		return
 	}
@@ -68,13 +78,22 @@ func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
 
 func (b *BTree) CopyFind(key BtreeItem, fn func(i BtreeItem)) {
 	b.Lock()
-	item := b.tree.CopyGet(key)
+	var item BtreeItem
+	if b.kv == nil || b.kvLookup(key, true) != nil {
+		item = b.tree.CopyGet(key)
+	}
 	fn(item)
 	b.Unlock()
 }
 
 // Has checks if the key exists in the btree.
 func (b *BTree) Has(key BtreeItem) (ok bool) {
+	if b.kv != nil {
+		b.Lock()
+		ok = b.kvLookup(key, false) != nil
+		b.Unlock()
+		return
+	}
 	b.RLock()
 	ok = b.tree.Has(key)
 	b.RUnlock()
@@ -84,20 +103,23 @@ func (b *BTree) Has(key BtreeItem) (ok bool) {
 // Delete deletes the object by the given key.
 func (b *BTree) Delete(key BtreeItem) (item BtreeItem) {
 	b.Lock()
-	item = b.tree.Delete(key)
+	if b.kv != nil {
+		item = b.kvDelete(key)
+	} else {
+		item = b.tree.Delete(key)
+	}
 	b.Unlock()
 	return
 }
 
-func (b *BTree) Execute(fn func(tree *btree.BTree) interface{}) interface{} {
-	b.Lock()
-	defer b.Unlock()
-	return fn(b.tree)
-}
-
 // ReplaceOrInsert is the wrapper of google's btree ReplaceOrInsert.
 func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok bool) {
 	b.Lock()
+	if b.kv != nil {
+		item, ok = b.kvReplaceOrInsert(key, replace)
+		b.Unlock()
+		return
+	}
 	if replace {
 		item = b.tree.ReplaceOrInsert(key)
 		b.Unlock()
@@ -121,6 +143,10 @@ func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok
 // This function scans the entire btree. When the data is huge, it is not recommended to use this function online.
 // Instead, it is recommended to call GetTree to obtain the snapshot of the current btree, and then do the scan on the snapshot.
 func (b *BTree) Ascend(fn func(i BtreeItem) bool) {
+	if b.kv != nil {
+		b.kvAscend(nil, nil, fn)
+		return
+	}
 	b.RLock()
 	b.tree.Ascend(fn)
 	b.RUnlock()
@@ -128,6 +154,10 @@ func (b *BTree) Ascend(fn func(i BtreeItem) bool) {
 
 // AscendRange is the wrapper of the google's btree AscendRange.
 func (b *BTree) AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i BtreeItem) bool) {
+	if b.kv != nil {
+		b.kvAscend(greaterOrEqual, lessThan, iterator)
+		return
+	}
 	b.RLock()
 	b.tree.AscendRange(greaterOrEqual, lessThan, iterator)
 	b.RUnlock()
@@ -135,6 +165,10 @@ func (b *BTree) AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i
 
 // AscendGreaterOrEqual is the wrapper of the google's btree AscendGreaterOrEqual
 func (b *BTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem) bool) {
+	if b.kv != nil {
+		b.kvAscend(pivot, nil, iterator)
+		return
+	}
 	b.RLock()
 	b.tree.AscendGreaterOrEqual(pivot, iterator)
 	b.RUnlock()
@@ -142,6 +176,9 @@ func (b *BTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem)
 
 // GetTree returns the snapshot of a btree.
 func (b *BTree) GetTree() *BTree {
+	if b.kv != nil {
+		return b.kvGetTree(false)
+	}
 	b.Lock()
 	t := b.tree.Clone()
 	b.Unlock()
@@ -150,8 +187,33 @@ func (b *BTree) GetTree() *BTree {
 	return nb
 }
 
+// PinTree returns the snapshot of a btree like GetTree, and the items of the snapshot kept in rocksdb
+// stay unchanged until Release is called.
+func (b *BTree) PinTree() *BTree {
+	if b.kv != nil {
+		return b.kvGetTree(true)
+	}
+	return b.GetTree()
+}
+
+// Release releases the items pinned by PinTree.
+func (b *BTree) Release() {
+	if b.kv == nil {
+		return
+	}
+	b.Lock()
+	snap := b.kv.snap
+	b.kv.snap = nil
+	b.Unlock()
+	b.kv.store.releaseSnapshot(snap)
+}
+
 // Reset resets the current btree.
 func (b *BTree) Reset() {
+	if b.kv != nil {
+		b.kvReset()
+		return
+	}
 	b.Lock()
 	b.tree.Clear(true)
 	b.Unlock()
@@ -160,13 +222,20 @@ func (b *BTree) Reset() {
 // Len returns the total number of items in the btree.
 func (b *BTree) Len() (size int) {
 	b.RLock()
-	size = b.tree.Len()
+	if b.kv != nil {
+		size = b.kv.count
+	} else {
+		size = b.tree.Len()
+	}
 	b.RUnlock()
 	return
 }
 
 // MaxItem returns the largest item in the btree.
 func (b *BTree) MaxItem() BtreeItem {
+	if b.kv != nil {
+		return b.kvMaxItem()
+	}
 	b.RLock()
 	item := b.tree.Max()
 	b.RUnlock()
@@ -0,0 +1,870 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/raftstore/raftstore_db"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/btree"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/tecbot/gorocksdb"
+)
+
+// In MetaStoreModeRocksDB the items of the inode, dentry, extend and multipart trees are kept in
+// a rocksdb of the partition, under the key prefixes below. The small snapshot files, such as
+// the apply id and the transactions, are kept under kvFilePrefix.
+const (
+	kvInodePrefix     byte = 'i'
+	kvDentryPrefix    byte = 'd'
+	kvExtendPrefix    byte = 'e'
+	kvMultipartPrefix byte = 'm'
+	kvFilePrefix      byte = 's'
+)
+
+const (
+	kvScanBatchSize = 1024
+	kvBulkBatchSize = 4096
+)
+
+var (
+	errKvStoreClosed = errors.New("meta kv store is closed")
+	errKvStoreReset  = errors.New("meta kv store has been reset")
+)
+
+// kvCodec maps the items of a tree to the keys and the values in rocksdb,
+// the keys sort in the same order as the items.
+type kvCodec struct {
+	prefix    byte
+	key       func(item BtreeItem) []byte
+	marshal   func(item BtreeItem) ([]byte, error)
+	unmarshal func(raw []byte) (BtreeItem, error)
+}
+
+func kvUint64Key(prefix byte, id uint64) []byte {
+	key := make([]byte, 9)
+	key[0] = prefix
+	binary.BigEndian.PutUint64(key[1:], id)
+	return key
+}
+
+// kvAppendString appends s to the key with the zero bytes escaped and a terminator,
+// so that a key with more fields after s still sorts by s first.
+func kvAppendString(key []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		key = append(key, s[i])
+		if s[i] == 0 {
+			key = append(key, 0xff)
+		}
+	}
+	return append(key, 0, 1)
+}
+
+var (
+	inodeKvCodec = &kvCodec{
+		prefix: kvInodePrefix,
+		key: func(item BtreeItem) []byte {
+			return kvUint64Key(kvInodePrefix, item.(*Inode).Inode)
+		},
+		marshal: func(item BtreeItem) ([]byte, error) {
+			return item.(*Inode).Marshal()
+		},
+		unmarshal: func(raw []byte) (BtreeItem, error) {
+			ino := NewInode(0, 0)
+			if err := ino.Unmarshal(raw); err != nil {
+				return nil, err
+			}
+			return ino, nil
+		},
+	}
+	dentryKvCodec = &kvCodec{
+		prefix: kvDentryPrefix,
+		key: func(item BtreeItem) []byte {
+			d := item.(*Dentry)
+			return append(kvUint64Key(kvDentryPrefix, d.ParentId), d.Name...)
+		},
+		marshal: func(item BtreeItem) ([]byte, error) {
+			return item.(*Dentry).Marshal()
+		},
+		unmarshal: func(raw []byte) (BtreeItem, error) {
+			d := &Dentry{}
+			if err := d.Unmarshal(raw); err != nil {
+				return nil, err
+			}
+			return d, nil
+		},
+	}
+	extendKvCodec = &kvCodec{
+		prefix: kvExtendPrefix,
+		key: func(item BtreeItem) []byte {
+			return kvUint64Key(kvExtendPrefix, item.(*Extend).inode)
+		},
+		marshal: func(item BtreeItem) ([]byte, error) {
+			return item.(*Extend).Bytes()
+		},
+		unmarshal: func(raw []byte) (BtreeItem, error) {
+			return NewExtendFromBytes(raw)
+		},
+	}
+	multipartKvCodec = &kvCodec{
+		prefix: kvMultipartPrefix,
+		key: func(item BtreeItem) []byte {
+			m := item.(*Multipart)
+			return append(kvAppendString([]byte{kvMultipartPrefix}, m.key), m.id...)
+		},
+		marshal: func(item BtreeItem) ([]byte, error) {
+			return item.(*Multipart).Bytes()
+		},
+		unmarshal: func(raw []byte) (BtreeItem, error) {
+			return MultipartFromBytes(raw), nil
+		},
+	}
+)
+
+// kvKey is an encoded key kept in a btree.
+type kvKey []byte
+
+func (k kvKey) Less(than btree.Item) bool {
+	return bytes.Compare(k, than.(kvKey)) < 0
+}
+
+func (k kvKey) Copy() btree.Item {
+	return k
+}
+
+// metaKvStore is the rocksdb of a meta partition in MetaStoreModeRocksDB.
+type metaKvStore struct {
+	sync.RWMutex
+	db         *raftstore_db.RocksDBStore
+	generation uint64 // increased each time the store is cleared to apply a raft snapshot
+	seq        uint64 // sequence of the checkpoints
+	closed     bool
+}
+
+func openMetaKvStore(dir string) (s *metaKvStore, err error) {
+	s = new(metaKvStore)
+	if s.db, err = raftstore_db.NewRocksDBStore(dir, metaKvLruCacheSize, metaKvWriteBufferSize); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (s *metaKvStore) close() {
+	s.Lock()
+	defer s.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.db.Close()
+	}
+}
+
+func (s *metaKvStore) getGeneration() uint64 {
+	s.RLock()
+	defer s.RUnlock()
+	return s.generation
+}
+
+func (s *metaKvStore) nextSeq() uint64 {
+	return atomic.AddUint64(&s.seq, 1)
+}
+
+// reset clears the store and invalidates the writes of the former generation.
+func (s *metaKvStore) reset() (generation uint64, err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return 0, errKvStoreClosed
+	}
+	s.generation++
+	if err = s.db.Clear(); err != nil {
+		return
+	}
+	return s.generation, nil
+}
+
+func (s *metaKvStore) get(key []byte, snap *gorocksdb.Snapshot) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil, errKvStoreClosed
+	}
+	return s.db.GetWithSnapshot(key, snap)
+}
+
+// write deletes the keys and puts the values in one batch, unless the store has been reset
+// after the given generation.
+func (s *metaKvStore) write(generation uint64, deleteSet map[string]util.Null, cmdMap map[string][]byte, sync bool) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return errKvStoreClosed
+	}
+	if generation != s.generation {
+		return errKvStoreReset
+	}
+	return s.db.BatchDeleteAndPut(deleteSet, cmdMap, sync)
+}
+
+// ingest moves the sst files into the store, unless the store has been reset after the given generation.
+func (s *metaKvStore) ingest(generation uint64, paths []string) error {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return errKvStoreClosed
+	}
+	if generation != s.generation {
+		return errKvStoreReset
+	}
+	return s.db.IngestSstFiles(paths)
+}
+
+func (s *metaKvStore) files() (map[string][]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil, errKvStoreClosed
+	}
+	return s.db.SeekForPrefix([]byte{kvFilePrefix})
+}
+
+func (s *metaKvStore) newSnapshot() *gorocksdb.Snapshot {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil
+	}
+	return s.db.RocksDBSnapshot()
+}
+
+func (s *metaKvStore) releaseSnapshot(snap *gorocksdb.Snapshot) {
+	if snap == nil {
+		return
+	}
+	s.RLock()
+	defer s.RUnlock()
+	if !s.closed {
+		s.db.ReleaseSnapshot(snap)
+	}
+}
+
+// scan calls fn with the keys and the values in [start, end) of the snapshot, in descending
+// order if reverse is set. The keys are read in batches and the store is not locked while fn
+// runs, so fn may use the store as well.
+func (s *metaKvStore) scan(snap *gorocksdb.Snapshot, start, end []byte, reverse bool, fn func(k, v []byte) (bool, error)) (err error) {
+	if snap == nil {
+		if snap = s.newSnapshot(); snap == nil {
+			return errKvStoreClosed
+		}
+		defer s.releaseSnapshot(snap)
+	}
+	var (
+		from         []byte
+		keys, values [][]byte
+		next         bool
+	)
+	for {
+		if keys, values, err = s.scanBatch(snap, start, end, from, reverse); err != nil || len(keys) == 0 {
+			return
+		}
+		for i := range keys {
+			if next, err = fn(keys[i], values[i]); err != nil || !next {
+				return
+			}
+		}
+		from = keys[len(keys)-1]
+	}
+}
+
+// scanBatch reads a batch of the scan after the key from, or from the beginning if from is nil.
+func (s *metaKvStore) scanBatch(snap *gorocksdb.Snapshot, start, end, from []byte, reverse bool) (keys, values [][]byte, err error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return nil, nil, errKvStoreClosed
+	}
+	it := s.db.Iterator(snap)
+	defer it.Close()
+	if reverse {
+		seek := end
+		if from != nil {
+			seek = from
+		}
+		if it.Seek(seek); it.Valid() {
+			it.Prev()
+		} else {
+			it.SeekToLast()
+		}
+	} else if from == nil {
+		it.Seek(start)
+	} else {
+		it.Seek(from)
+		if it.Valid() && bytes.Equal(it.Key().Data(), from) {
+			it.Next()
+		}
+	}
+	for it.Valid() && len(keys) < kvScanBatchSize {
+		k, v := it.Key(), it.Value()
+		key := k.Data()
+		inRange := bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0
+		if inRange {
+			keys = append(keys, append([]byte{}, key...))
+			values = append(values, append([]byte{}, v.Data()...))
+		}
+		k.Free()
+		v.Free()
+		if !inRange {
+			break
+		}
+		if reverse {
+			it.Prev()
+		} else {
+			it.Next()
+		}
+	}
+	err = it.Err()
+	return
+}
+
+// kvDelta is the changes of a tree between two checkpoints.
+type kvDelta struct {
+	seq     uint64
+	items   *btree.BTree
+	deleted *btree.BTree
+}
+
+// kvTree is the state of a BTree whose items are kept in a metaKvStore. The btree of the BTree
+// only holds the items changed or read since the last checkpoint, the keys deleted since then are
+// kept in deleted, and the changes sealed by the checkpoints but not written to rocksdb yet are
+// kept in sealed. A lookup goes through them from the newest to rocksdb.
+//
+// The tree returned by GetTree is a view which merges the changes in memory. Its items in rocksdb
+// are read at the time they are scanned, unless the view is made by PinTree.
+type kvTree struct {
+	store   *metaKvStore
+	codec   *kvCodec
+	deleted *btree.BTree
+	sealed  []*kvDelta // from the oldest
+	count   int
+	view    bool
+	snap    *gorocksdb.Snapshot
+
+	// the items are written to rocksdb directly in bulk mode, to apply a raft snapshot
+	bulk           map[string][]byte
+	bulkGeneration uint64
+	bulkErr        error
+}
+
+func newKvBtree(store *metaKvStore, codec *kvCodec) *BTree {
+	return &BTree{
+		tree: btree.New(defaultBTreeDegree),
+		kv: &kvTree{
+			store:   store,
+			codec:   codec,
+			deleted: btree.New(defaultBTreeDegree),
+		},
+	}
+}
+
+func (kv *kvTree) keyRange(start, end BtreeItem) (startKey, endKey []byte) {
+	startKey, endKey = []byte{kv.codec.prefix}, []byte{kv.codec.prefix + 1}
+	if start != nil {
+		startKey = kv.codec.key(start)
+	}
+	if end != nil {
+		endKey = kv.codec.key(end)
+	}
+	return
+}
+
+// fatal reports the errors of rocksdb which leave the tree unusable.
+func (kv *kvTree) fatal(action string, err error) {
+	if err == errKvStoreClosed {
+		return
+	}
+	log.LogFatalf("action[%v] meta kv store prefix(%c) err(%v)", action, kv.codec.prefix, err)
+}
+
+func (kv *kvTree) load(key []byte) BtreeItem {
+	raw, err := kv.store.get(key, kv.snap)
+	if err == nil && raw != nil {
+		var item BtreeItem
+		if item, err = kv.codec.unmarshal(raw); err == nil {
+			return item
+		}
+	}
+	if err != nil {
+		kv.fatal("kvLoad", err)
+	}
+	return nil
+}
+
+func ascendBtree(t *btree.BTree, start, end BtreeItem, fn btree.ItemIterator) {
+	switch {
+	case start == nil && end == nil:
+		t.Ascend(fn)
+	case end == nil:
+		t.AscendGreaterOrEqual(start, fn)
+	case start == nil:
+		t.AscendLessThan(end, fn)
+	default:
+		t.AscendRange(start, end, fn)
+	}
+}
+
+func ascendKeys(t *btree.BTree, start, end []byte, fn btree.ItemIterator) {
+	t.AscendRange(kvKey(start), kvKey(end), fn)
+}
+
+// kvLookup finds the item in memory or in rocksdb, the caller must hold the lock.
+// With promote, an item found out of the btree is inserted to it, so that the changes
+// made on it go to the next checkpoint.
+func (b *BTree) kvLookup(key BtreeItem, promote bool) BtreeItem {
+	if item := b.tree.Get(key); item != nil {
+		return item
+	}
+	kv := b.kv
+	k := kv.codec.key(key)
+	if kv.deleted.Has(kvKey(k)) {
+		return nil
+	}
+	var item BtreeItem
+	for i := len(kv.sealed) - 1; i >= 0 && item == nil; i-- {
+		d := kv.sealed[i]
+		if item = d.items.Get(key); item != nil {
+			// the sealed items are being written to rocksdb and must stay unchanged
+			item = item.Copy()
+		} else if d.deleted.Has(kvKey(k)) {
+			return nil
+		}
+	}
+	if item == nil {
+		if item = kv.load(k); item == nil {
+			return nil
+		}
+	}
+	if promote && !kv.view {
+		b.tree.ReplaceOrInsert(item)
+	}
+	return item
+}
+
+// kvOverlay returns the changes in memory within [start, end), the caller must hold the lock.
+// The items override the ones in rocksdb and the deleted keys hide them.
+func (b *BTree) kvOverlay(start, end BtreeItem) (items, deleted *btree.BTree) {
+	kv := b.kv
+	if kv.view {
+		return b.tree.Clone(), kv.deleted.Clone()
+	}
+	startKey, endKey := kv.keyRange(start, end)
+	items, deleted = btree.New(defaultBTreeDegree), btree.New(defaultBTreeDegree)
+	ascendBtree(b.tree, start, end, func(i BtreeItem) bool {
+		items.ReplaceOrInsert(i)
+		return true
+	})
+	ascendKeys(kv.deleted, startKey, endKey, func(i BtreeItem) bool {
+		deleted.ReplaceOrInsert(i)
+		return true
+	})
+	for i := len(kv.sealed) - 1; i >= 0; i-- {
+		d := kv.sealed[i]
+		ascendBtree(d.items, start, end, func(i BtreeItem) bool {
+			if !items.Has(i) && !deleted.Has(kvKey(kv.codec.key(i))) {
+				items.ReplaceOrInsert(i)
+			}
+			return true
+		})
+		ascendKeys(d.deleted, startKey, endKey, func(i BtreeItem) bool {
+			deleted.ReplaceOrInsert(i)
+			return true
+		})
+	}
+	return
+}
+
+// kvAscend merges the changes in memory and the items in rocksdb within [start, end).
+func (b *BTree) kvAscend(start, end BtreeItem, fn func(i BtreeItem) bool) {
+	kv := b.kv
+	b.Lock()
+	items, deleted := b.kvOverlay(start, end)
+	snap := kv.snap
+	if snap == nil {
+		snap = kv.store.newSnapshot()
+		defer kv.store.releaseSnapshot(snap)
+	}
+	b.Unlock()
+
+	var (
+		overlay []BtreeItem
+		keys    [][]byte
+		pos     int
+		stopped bool
+	)
+	ascendBtree(items, start, end, func(i BtreeItem) bool {
+		overlay = append(overlay, i)
+		keys = append(keys, kv.codec.key(i))
+		return true
+	})
+	startKey, endKey := kv.keyRange(start, end)
+	err := kv.store.scan(snap, startKey, endKey, false, func(k, v []byte) (bool, error) {
+		for ; pos < len(overlay) && bytes.Compare(keys[pos], k) < 0; pos++ {
+			if !fn(overlay[pos]) {
+				stopped = true
+				return false, nil
+			}
+		}
+		if (pos < len(overlay) && bytes.Equal(keys[pos], k)) || deleted.Has(kvKey(k)) {
+			return true, nil
+		}
+		item, err := kv.codec.unmarshal(v)
+		if err != nil {
+			return false, err
+		}
+		if !fn(item) {
+			stopped = true
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		kv.fatal("kvAscend", err)
+		return
+	}
+	for ; !stopped && pos < len(overlay); pos++ {
+		if !fn(overlay[pos]) {
+			return
+		}
+	}
+}
+
+func (b *BTree) kvMaxItem() BtreeItem {
+	kv := b.kv
+	b.Lock()
+	items, deleted := b.kvOverlay(nil, nil)
+	snap := kv.snap
+	if snap == nil {
+		snap = kv.store.newSnapshot()
+		defer kv.store.releaseSnapshot(snap)
+	}
+	b.Unlock()
+
+	max := items.Max()
+	var last BtreeItem
+	startKey, endKey := kv.keyRange(nil, nil)
+	err := kv.store.scan(snap, startKey, endKey, true, func(k, v []byte) (next bool, err error) {
+		if deleted.Has(kvKey(k)) {
+			return true, nil
+		}
+		last, err = kv.codec.unmarshal(v)
+		return false, err
+	})
+	if err != nil {
+		kv.fatal("kvMaxItem", err)
+		return max
+	}
+	if last != nil && (max == nil || max.Less(last)) {
+		return last
+	}
+	return max
+}
+
+func (b *BTree) kvReplaceOrInsert(item BtreeItem, replace bool) (BtreeItem, bool) {
+	kv := b.kv
+	if kv.bulk != nil {
+		b.kvBulkInsert(item)
+		return nil, true
+	}
+	old := b.kvLookup(item, true)
+	if old != nil && !replace {
+		return old, false
+	}
+	b.tree.ReplaceOrInsert(item)
+	kv.deleted.Delete(kvKey(kv.codec.key(item)))
+	if old == nil {
+		kv.count++
+	}
+	return old, true
+}
+
+func (b *BTree) kvDelete(key BtreeItem) BtreeItem {
+	kv := b.kv
+	old := b.kvLookup(key, false)
+	if old == nil {
+		return nil
+	}
+	b.tree.Delete(key)
+	kv.deleted.ReplaceOrInsert(kvKey(kv.codec.key(key)))
+	kv.count--
+	return old
+}
+
+func (b *BTree) kvGetTree(pin bool) *BTree {
+	kv := b.kv
+	b.Lock()
+	defer b.Unlock()
+	items, deleted := b.tree.Clone(), kv.deleted.Clone()
+	for i := len(kv.sealed) - 1; i >= 0; i-- {
+		d := kv.sealed[i]
+		d.items.Ascend(func(i BtreeItem) bool {
+			if !items.Has(i) && !deleted.Has(kvKey(kv.codec.key(i))) {
+				items.ReplaceOrInsert(i)
+			}
+			return true
+		})
+		d.deleted.Ascend(func(i BtreeItem) bool {
+			deleted.ReplaceOrInsert(i)
+			return true
+		})
+	}
+	view := &kvTree{
+		store:   kv.store,
+		codec:   kv.codec,
+		deleted: deleted,
+		count:   kv.count,
+		view:    true,
+	}
+	if pin {
+		view.snap = kv.store.newSnapshot()
+	}
+	return &BTree{tree: items, kv: view}
+}
+
+func (b *BTree) kvReset() {
+	kv := b.kv
+	b.Lock()
+	b.tree.Clear(true)
+	kv.deleted = btree.New(defaultBTreeDegree)
+	kv.sealed = nil
+	kv.count = 0
+	b.Unlock()
+	if kv.view {
+		return
+	}
+
+	startKey, endKey := kv.keyRange(nil, nil)
+	generation := kv.store.getGeneration()
+	deleteSet := make(map[string]util.Null)
+	flush := func() error {
+		err := kv.store.write(generation, deleteSet, nil, false)
+		deleteSet = make(map[string]util.Null)
+		return err
+	}
+	err := kv.store.scan(nil, startKey, endKey, false, func(k, v []byte) (bool, error) {
+		deleteSet[string(k)] = util.Null{}
+		if len(deleteSet) >= kvBulkBatchSize {
+			return true, flush()
+		}
+		return true, nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil && err != errKvStoreReset {
+		kv.fatal("kvReset", err)
+	}
+}
+
+// kvSeal moves the changes since the last checkpoint to the sealed deltas, to be written to rocksdb
+// by the store of the checkpoint seq.
+func (b *BTree) kvSeal(seq uint64) {
+	kv := b.kv
+	b.Lock()
+	defer b.Unlock()
+	if b.tree.Len() == 0 && kv.deleted.Len() == 0 {
+		return
+	}
+	kv.sealed = append(kv.sealed, &kvDelta{seq: seq, items: b.tree, deleted: kv.deleted})
+	b.tree = btree.New(defaultBTreeDegree)
+	kv.deleted = btree.New(defaultBTreeDegree)
+}
+
+// kvCollect adds the sealed changes up to the checkpoint seq to the write batch.
+func (b *BTree) kvCollect(seq uint64, deleteSet map[string]util.Null, cmdMap map[string][]byte) (err error) {
+	kv := b.kv
+	b.RLock()
+	deltas := make([]*kvDelta, 0, len(kv.sealed))
+	for _, d := range kv.sealed {
+		if d.seq <= seq {
+			deltas = append(deltas, d)
+		}
+	}
+	b.RUnlock()
+
+	for _, d := range deltas {
+		d.deleted.Ascend(func(i BtreeItem) bool {
+			k := string(i.(kvKey))
+			deleteSet[k] = util.Null{}
+			delete(cmdMap, k)
+			return true
+		})
+		d.items.Ascend(func(i BtreeItem) bool {
+			var raw []byte
+			if raw, err = kv.codec.marshal(i); err != nil {
+				return false
+			}
+			k := string(kv.codec.key(i))
+			cmdMap[k] = raw
+			delete(deleteSet, k)
+			return true
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// kvCommitted drops the sealed changes up to the checkpoint seq, which have been written to rocksdb.
+func (b *BTree) kvCommitted(seq uint64) {
+	kv := b.kv
+	b.Lock()
+	defer b.Unlock()
+	sealed := kv.sealed[:0]
+	for _, d := range kv.sealed {
+		if d.seq > seq {
+			sealed = append(sealed, d)
+		}
+	}
+	for i := len(sealed); i < len(kv.sealed); i++ {
+		kv.sealed[i] = nil
+	}
+	kv.sealed = sealed
+}
+
+// kvLoad calls fn on the items in rocksdb when the partition is loaded and counts them.
+func (b *BTree) kvLoad(fn func(i BtreeItem) bool) (err error) {
+	kv := b.kv
+	count := 0
+	startKey, endKey := kv.keyRange(nil, nil)
+	err = kv.store.scan(nil, startKey, endKey, false, func(k, v []byte) (bool, error) {
+		item, err := kv.codec.unmarshal(v)
+		if err != nil {
+			return false, err
+		}
+		count++
+		return fn(item), nil
+	})
+	b.Lock()
+	kv.count = count
+	b.Unlock()
+	return
+}
+
+// kvWriteSst writes the items of a pinned tree to the sst file at path and returns the number of
+// the items, the file is not created if the tree is empty.
+func (b *BTree) kvWriteSst(path string) (count int, err error) {
+	kv := b.kv
+	var w *raftstore_db.SstFileWriter
+	defer func() {
+		if w != nil {
+			w.Destroy()
+		}
+	}()
+	b.kvAscend(nil, nil, func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = kv.codec.marshal(i); err != nil {
+			return false
+		}
+		if w == nil {
+			if w, err = raftstore_db.NewSstFileWriter(path); err != nil {
+				return false
+			}
+		}
+		if err = w.Add(kv.codec.key(i), raw); err != nil {
+			return false
+		}
+		count++
+		return true
+	})
+	if err == nil && w != nil {
+		err = w.Finish()
+	}
+	return
+}
+
+// kvIngestSst moves the sst file written by kvWriteSst into rocksdb of the bulk generation,
+// the file holds count items.
+func (b *BTree) kvIngestSst(path string, count int) (err error) {
+	kv := b.kv
+	b.Lock()
+	defer b.Unlock()
+	if kv.bulk == nil {
+		return errors.New("meta kv tree is not in bulk mode")
+	}
+	if err = kv.store.ingest(kv.bulkGeneration, []string{path}); err != nil {
+		return
+	}
+	kv.count += count
+	return
+}
+
+// kvStartBulk makes the tree write the inserted items to rocksdb of the generation directly.
+func (b *BTree) kvStartBulk(generation uint64) {
+	b.Lock()
+	b.kv.bulk = make(map[string][]byte)
+	b.kv.bulkGeneration = generation
+	b.Unlock()
+}
+
+func (b *BTree) kvBulkInsert(item BtreeItem) {
+	kv := b.kv
+	raw, err := kv.codec.marshal(item)
+	if err != nil {
+		if kv.bulkErr == nil {
+			kv.bulkErr = err
+		}
+		return
+	}
+	k := string(kv.codec.key(item))
+	if _, ok := kv.bulk[k]; !ok {
+		kv.count++
+	}
+	kv.bulk[k] = raw
+	if len(kv.bulk) >= kvBulkBatchSize {
+		b.kvFlushBulk()
+	}
+}
+
+func (b *BTree) kvFlushBulk() {
+	kv := b.kv
+	if len(kv.bulk) == 0 {
+		return
+	}
+	if err := kv.store.write(kv.bulkGeneration, nil, kv.bulk, false); err != nil && kv.bulkErr == nil {
+		kv.bulkErr = err
+	}
+	kv.bulk = make(map[string][]byte)
+}
+
+// FinishBulk writes the rest of the items inserted in bulk mode and switches the tree back to the
+// normal mode. It does nothing for a tree kept in memory.
+func (b *BTree) FinishBulk() (err error) {
+	if b.kv == nil {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	if b.kv.bulk == nil {
+		return
+	}
+	b.kvFlushBulk()
+	b.kv.bulk = nil
+	err, b.kv.bulkErr = b.kv.bulkErr, nil
+	return
+}
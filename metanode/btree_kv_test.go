@@ -0,0 +1,207 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKvStore(t *testing.T) *metaKvStore {
+	store, err := openMetaKvStore(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(store.close)
+	return store
+}
+
+func testDentry(parent uint64, name string) *Dentry {
+	return &Dentry{ParentId: parent, Name: name, Inode: parent*1000 + uint64(len(name)), Type: 1}
+}
+
+func dentryNames(tree *BTree) (names []string) {
+	tree.Ascend(func(i BtreeItem) bool {
+		names = append(names, i.(*Dentry).Name)
+		return true
+	})
+	return
+}
+
+func commitKvTree(t *testing.T, store *metaKvStore, tree *BTree) {
+	seq := store.nextSeq()
+	tree.kvSeal(seq)
+	deleteSet, cmdMap := make(map[string]util.Null), make(map[string][]byte)
+	require.NoError(t, tree.kvCollect(seq, deleteSet, cmdMap))
+	require.NoError(t, store.write(store.getGeneration(), deleteSet, cmdMap, true))
+	tree.kvCommitted(seq)
+}
+
+func TestKvCodecOrder(t *testing.T) {
+	dentries := []*Dentry{
+		testDentry(1, "a"), testDentry(1, "a\x00"), testDentry(1, "ab"), testDentry(2, ""), testDentry(256, "a"),
+	}
+	for i := 1; i < len(dentries); i++ {
+		require.True(t, dentries[i-1].Less(dentries[i]))
+		require.Negative(t, bytes.Compare(dentryKvCodec.key(dentries[i-1]), dentryKvCodec.key(dentries[i])))
+	}
+	multiparts := []*Multipart{
+		{key: "a", id: "z"}, {key: "a\x00", id: "a"}, {key: "a\x00", id: "b"}, {key: "ab", id: ""},
+	}
+	for i := 1; i < len(multiparts); i++ {
+		require.True(t, multiparts[i-1].Less(multiparts[i]))
+		require.Negative(t, bytes.Compare(multipartKvCodec.key(multiparts[i-1]), multipartKvCodec.key(multiparts[i])))
+	}
+}
+
+func TestKvBtree(t *testing.T) {
+	store := newTestKvStore(t)
+	tree := newKvBtree(store, dentryKvCodec)
+	for i := 0; i < 10; i++ {
+		_, ok := tree.ReplaceOrInsert(testDentry(1, fmt.Sprintf("f%v", i)), false)
+		require.True(t, ok)
+	}
+	_, ok := tree.ReplaceOrInsert(testDentry(1, "f0"), false)
+	require.False(t, ok)
+	require.Equal(t, 10, tree.Len())
+
+	// the first checkpoint goes to rocksdb and the tree in memory is empty
+	commitKvTree(t, store, tree)
+	require.Equal(t, 0, tree.tree.Len())
+	require.Equal(t, 10, tree.Len())
+	require.True(t, tree.Has(testDentry(1, "f3")))
+	require.Equal(t, uint64(1002), tree.Get(testDentry(1, "f3")).(*Dentry).Inode)
+
+	// the changes in memory override rocksdb
+	require.NotNil(t, tree.Delete(testDentry(1, "f3")))
+	require.Nil(t, tree.Get(testDentry(1, "f3")))
+	d := tree.CopyGet(testDentry(1, "f4")).(*Dentry)
+	d.Inode = 4
+	tree.ReplaceOrInsert(testDentry(1, "f10"), true)
+	require.Equal(t, 10, tree.Len())
+	require.Equal(t, []string{"f0", "f1", "f10", "f2", "f4", "f5", "f6", "f7", "f8", "f9"}, dentryNames(tree))
+
+	var names []string
+	tree.AscendRange(testDentry(1, "f2"), testDentry(1, "f6"), func(i BtreeItem) bool {
+		names = append(names, i.(*Dentry).Name)
+		return true
+	})
+	require.Equal(t, []string{"f2", "f4", "f5"}, names)
+	require.Equal(t, "f9", tree.MaxItem().(*Dentry).Name)
+
+	// the pinned view stays unchanged by the changes and the commits after it
+	view := tree.PinTree()
+	defer view.Release()
+	seq := store.nextSeq()
+	tree.kvSeal(seq)
+	tree.Delete(testDentry(1, "f5"))
+	require.Equal(t, 9, tree.Len())
+	require.Nil(t, tree.Get(testDentry(1, "f5")))
+	require.Equal(t, uint64(4), tree.Get(testDentry(1, "f4")).(*Dentry).Inode)
+	deleteSet, cmdMap := make(map[string]util.Null), make(map[string][]byte)
+	require.NoError(t, tree.kvCollect(seq, deleteSet, cmdMap))
+	require.NoError(t, store.write(store.getGeneration(), deleteSet, cmdMap, true))
+	tree.kvCommitted(seq)
+	commitKvTree(t, store, tree)
+
+	require.Equal(t, 10, view.Len())
+	require.Contains(t, dentryNames(view), "f5")
+	require.Equal(t, []string{"f0", "f1", "f10", "f2", "f4", "f6", "f7", "f8", "f9"}, dentryNames(tree))
+
+	// a new tree loads the same items from rocksdb
+	loaded := newKvBtree(store, dentryKvCodec)
+	require.NoError(t, loaded.kvLoad(func(i BtreeItem) bool { return true }))
+	require.Equal(t, 9, loaded.Len())
+	require.Equal(t, uint64(4), loaded.Get(testDentry(1, "f4")).(*Dentry).Inode)
+	require.Nil(t, loaded.Get(testDentry(1, "f3")))
+}
+
+func TestKvBtreeBulk(t *testing.T) {
+	store := newTestKvStore(t)
+	old := newKvBtree(store, dentryKvCodec)
+	old.ReplaceOrInsert(testDentry(1, "old"), true)
+	commitKvTree(t, store, old)
+
+	generation, err := store.reset()
+	require.NoError(t, err)
+	tree := newKvBtree(store, dentryKvCodec)
+	tree.kvStartBulk(generation)
+	for i := 0; i < kvBulkBatchSize+10; i++ {
+		tree.ReplaceOrInsert(testDentry(2, fmt.Sprintf("f%05d", i)), true)
+	}
+	require.NoError(t, tree.FinishBulk())
+	require.Equal(t, kvBulkBatchSize+10, tree.Len())
+	require.Equal(t, kvBulkBatchSize+10, len(dentryNames(tree)))
+	require.Nil(t, tree.Get(testDentry(1, "old")))
+
+	// the commits of the trees before the reset are rejected
+	old.ReplaceOrInsert(testDentry(1, "stale"), true)
+	seq := store.nextSeq()
+	old.kvSeal(seq)
+	deleteSet, cmdMap := make(map[string]util.Null), make(map[string][]byte)
+	require.NoError(t, old.kvCollect(seq, deleteSet, cmdMap))
+	require.Equal(t, errKvStoreReset, store.write(generation-1, deleteSet, cmdMap, true))
+}
+
+func TestKvSstSnapshot(t *testing.T) {
+	store := newTestKvStore(t)
+	dentries := newKvBtree(store, dentryKvCodec)
+	multiparts := newKvBtree(store, multipartKvCodec)
+	for i := 0; i < 10; i++ {
+		dentries.ReplaceOrInsert(testDentry(1, fmt.Sprintf("f%d", i)), true)
+	}
+	commitKvTree(t, store, dentries)
+	// the changes in memory go to the sst file as well
+	dentries.ReplaceOrInsert(testDentry(1, "g"), true)
+	dentries.Delete(testDentry(1, "f0"))
+
+	pinned := []*BTree{dentries.PinTree(), multiparts.PinTree()}
+	defer func() {
+		for _, tree := range pinned {
+			tree.Release()
+		}
+	}()
+	var items []interface{}
+	require.NoError(t, sendKvSstFiles(t.TempDir(), pinned, func(item interface{}) bool {
+		items = append(items, item)
+		return true
+	}))
+	// the empty multipart tree is skipped
+	require.Len(t, items, 2)
+	require.Equal(t, &kvSstFile{name: "d.sst", count: 10}, items[0])
+
+	follower := newTestKvStore(t)
+	generation, err := follower.reset()
+	require.NoError(t, err)
+	tree := newKvBtree(follower, dentryKvCodec)
+	tree.kvStartBulk(generation)
+	r, err := newKvSstReceiver(t.TempDir(), []*BTree{tree})
+	require.NoError(t, err)
+	defer r.close()
+	require.Error(t, r.write("d.sst", nil))
+	require.Error(t, r.create("i.sst", 1))
+	require.NoError(t, r.create("d.sst", 10))
+	require.NoError(t, r.write("d.sst", items[1].(*kvSstChunk).data))
+	require.NoError(t, r.ingest())
+	require.NoError(t, tree.FinishBulk())
+
+	require.Equal(t, 10, tree.Len())
+	require.Equal(t, []string{"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "g"}, dentryNames(tree))
+	loaded := newKvBtree(follower, dentryKvCodec)
+	require.NoError(t, loaded.kvLoad(func(i BtreeItem) bool { return true }))
+	require.Equal(t, 10, loaded.Len())
+}
@@ -187,6 +187,21 @@ const (
 	opFSMStoreTickV1  = 72
 
 	opFSMVerListSnapShot = 73
+
+	// file lock
+	opFSMSetLock      = 74
+	opFSMRenewLock    = 75
+	opFSMFileLockSnap = 76
+
+	// extent punch hole and clone
+	opFSMExtentsDel     = 77
+	opFSMCloneExtents   = 78
+	opFSMDropExtentRefs = 79
+	opFSMExtentRefSnap  = 80
+
+	// sst files of the trees kept in rocksdb
+	opFSMKvSstFile  = 81
+	opFSMKvSstChunk = 82
 )
 
 var (
@@ -211,6 +226,7 @@ const (
 	cfgTotalMem                  = "totalMem"
 	cfgMemRatio                  = "memRatio"
 	cfgZoneName                  = "zoneName"
+	cfgRackName                  = "rackName"
 	cfgTickInterval              = "tickInterval"
 	cfgRaftRecvBufSize           = "raftRecvBufSize"
 	cfgSmuxPortShift             = "smuxPortShift"             // int
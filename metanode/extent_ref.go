@@ -0,0 +1,220 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"sort"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const extentRefRecordSize = 12
+
+// extentRefTable counts the inodes sharing the extents cloned within the meta partition.
+// Without a clone an extent belongs to a single inode, so only the shared extents are
+// recorded, and the count is the number of the inodes holding the extent minus one.
+// The shared extents are not deleted from the data node until a single holder is left.
+type extentRefTable struct {
+	sync.RWMutex
+	refs map[uint64]uint32 // dp<<32|extent -> count of the other holders
+}
+
+func newExtentRefTable() *extentRefTable {
+	return &extentRefTable{refs: make(map[uint64]uint32)}
+}
+
+func (t *extentRefTable) clone() *extentRefTable {
+	t.RLock()
+	defer t.RUnlock()
+	table := newExtentRefTable()
+	for id, cnt := range t.refs {
+		table.refs[id] = cnt
+	}
+	return table
+}
+
+func (t *extentRefTable) Marshal() (buf []byte, crc uint32, err error) {
+	t.RLock()
+	ids := make([]uint64, 0, len(t.refs))
+	for id := range t.refs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	buf = make([]byte, extentRefRecordSize*len(ids))
+	for i, id := range ids {
+		binary.BigEndian.PutUint64(buf[i*extentRefRecordSize:], id)
+		binary.BigEndian.PutUint32(buf[i*extentRefRecordSize+8:], t.refs[id])
+	}
+	t.RUnlock()
+	crc = crc32.ChecksumIEEE(buf)
+	return
+}
+
+func (t *extentRefTable) UnMarshal(data []byte) (err error) {
+	if len(data)%extentRefRecordSize != 0 {
+		return ErrSnapshotCrcMismatch
+	}
+	refs := make(map[uint64]uint32, len(data)/extentRefRecordSize)
+	buf := bytes.NewBuffer(data)
+	for buf.Len() > 0 {
+		var (
+			id  uint64
+			cnt uint32
+		)
+		if err = binary.Read(buf, binary.BigEndian, &id); err != nil {
+			return
+		}
+		if err = binary.Read(buf, binary.BigEndian, &cnt); err != nil {
+			return
+		}
+		refs[id] = cnt
+	}
+	t.Lock()
+	t.refs = refs
+	t.Unlock()
+	return
+}
+
+func (t *extentRefTable) count() int {
+	if t == nil {
+		return 0
+	}
+	t.RLock()
+	defer t.RUnlock()
+	return len(t.refs)
+}
+
+func (t *extentRefTable) has(id uint64) bool {
+	if t == nil {
+		return false
+	}
+	t.RLock()
+	defer t.RUnlock()
+	_, ok := t.refs[id]
+	return ok
+}
+
+// share records that one more inode holds the extent.
+func (t *extentRefTable) share(id uint64) {
+	t.Lock()
+	t.refs[id]++
+	t.Unlock()
+}
+
+// drop records that an inode does not hold the extent anymore, it returns false if
+// the extent is not shared, which means the extent could be deleted.
+func (t *extentRefTable) drop(id uint64) bool {
+	t.Lock()
+	defer t.Unlock()
+	cnt, ok := t.refs[id]
+	if !ok {
+		return false
+	}
+	if cnt <= 1 {
+		delete(t.refs, id)
+	} else {
+		t.refs[id] = cnt - 1
+	}
+	return true
+}
+
+// extentIdsOf returns the ids of the extents held by the inode, including the ones
+// kept by its snapshots.
+func extentIdsOf(ino *Inode) map[uint64]struct{} {
+	ids := make(map[uint64]struct{})
+	collect := func(_ int, ek proto.ExtentKey) bool {
+		ids[ek.GenerateId()] = struct{}{}
+		return true
+	}
+	ino.Extents.Range(collect)
+	if ino.multiSnap != nil {
+		for _, layer := range ino.multiSnap.multiVersions {
+			layer.Extents.Range(collect)
+		}
+	}
+	return ids
+}
+
+// releaseExtents filters out the shared extents from the extents removed from the inode.
+// The removed part of a shared extent may still be referred by the other holders, so
+// it is left on the data node, and the inode drops the extent if it holds no part of
+// it anymore. It must be called by the fsm after the extents are removed from the inode.
+func (mp *metaPartition) releaseExtents(ino *Inode, eks []proto.ExtentKey) []proto.ExtentKey {
+	if len(eks) == 0 || mp.extentRefs.count() == 0 {
+		return eks
+	}
+	var held map[uint64]struct{}
+	kept := make([]proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		id := ek.GenerateId()
+		if !mp.extentRefs.has(id) {
+			kept = append(kept, ek)
+			continue
+		}
+		if held == nil {
+			held = extentIdsOf(ino)
+		}
+		if _, ok := held[id]; !ok {
+			mp.extentRefs.drop(id)
+			held[id] = struct{}{} // drop once
+		}
+		log.LogDebugf("releaseExtents: mp(%v) ino(%v) keep shared extent(%v)", mp.config.PartitionId, ino.Inode, ek)
+	}
+	return kept
+}
+
+// sendExtentsToDelete sends the extents removed from the inode to be deleted from the
+// data nodes, except for the shared ones.
+func (mp *metaPartition) sendExtentsToDelete(ino *Inode, eks []proto.ExtentKey) {
+	if eks = mp.releaseExtents(ino, eks); len(eks) > 0 {
+		mp.extDelCh <- eks
+	}
+}
+
+// sharedExtentIdsOf returns the ids of the shared extents held by the inode.
+func (mp *metaPartition) sharedExtentIdsOf(ino *Inode) (ids map[uint64]struct{}) {
+	if mp.extentRefs.count() == 0 {
+		return
+	}
+	for id := range extentIdsOf(ino) {
+		if mp.extentRefs.has(id) {
+			if ids == nil {
+				ids = make(map[uint64]struct{})
+			}
+			ids[id] = struct{}{}
+		}
+	}
+	return
+}
+
+// dropExtentRefs drops the shared extents held by the inodes deleted by the free list,
+// the extents are kept on the data nodes for the other holders.
+func (mp *metaPartition) dropExtentRefs(ids []uint64) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	val, err := json.Marshal(ids)
+	if err != nil {
+		return
+	}
+	_, err = mp.submit(opFSMDropExtentRefs, val)
+	return
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtentRefTable(t *testing.T) {
+	table := newExtentRefTable()
+	require.False(t, table.drop(1))
+
+	table.share(1)
+	table.share(1)
+	table.share(2)
+	require.Equal(t, 2, table.count())
+
+	data, crc, err := table.Marshal()
+	require.NoError(t, err)
+	loaded := newExtentRefTable()
+	require.NoError(t, loaded.UnMarshal(data))
+	_, loadedCrc, err := loaded.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, crc, loadedCrc)
+
+	// the extent is shared until a single holder is left
+	require.True(t, loaded.drop(1))
+	require.True(t, loaded.has(1))
+	require.True(t, loaded.drop(1))
+	require.False(t, loaded.has(1))
+	require.False(t, loaded.drop(1))
+	require.True(t, loaded.drop(2))
+	require.Equal(t, 0, loaded.count())
+}
+
+func TestCloneExtents(t *testing.T) {
+	initMp(t)
+	mp.extentRefs = newExtentRefTable()
+
+	src := testCreateInode(t, FileModeType)
+	ek := buildExtentKey(0, 0, 1025, 0, 2000)
+	src.Extents.eks = append(src.Extents.eks, ek)
+	src.Size = 2000
+	dst := testCreateInode(t, FileModeType)
+
+	resp := mp.fsmCloneExtents(&fsmCloneExtentsRequest{SrcInode: src.Inode, DstInode: dst.Inode, Size: 4096})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.EqualValues(t, 2000, resp.Size)
+	require.EqualValues(t, 2000, dst.Size)
+	require.Equal(t, 1, dst.Extents.Len())
+	require.Equal(t, cloneExtentSeq, dst.Extents.eks[0].GetSeq())
+	require.Equal(t, cloneExtentSeq, src.Extents.eks[0].GetSeq())
+	require.True(t, mp.extentRefs.has(ek.GenerateId()))
+
+	// the extent is kept on the data node while the clone holds it
+	require.Equal(t, proto.OpOk, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: src.Inode, Size: 2000}))
+	require.Equal(t, 0, src.Extents.Len())
+	require.False(t, mp.extentRefs.has(ek.GenerateId()))
+	require.Equal(t, 0, len(mp.extDelCh))
+
+	require.Equal(t, proto.OpOk, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: dst.Inode, Size: 2000}))
+	require.Equal(t, 1, len(mp.extDelCh))
+	eks := <-mp.extDelCh
+	require.Equal(t, uint64(1025), eks[0].ExtentId)
+}
+
+func TestCloneExtentsWithSnapshots(t *testing.T) {
+	initMp(t)
+	initVer()
+	mp.extentRefs = newExtentRefTable()
+
+	snapVer := testCreateVer()
+	src := testCreateInode(t, FileModeType)
+	ek := buildExtentKey(snapVer, 0, 1025, 0, 2000)
+	src.Extents.eks = append(src.Extents.eks, ek)
+	src.Size = 2000
+	dst := testCreateInode(t, FileModeType)
+	dst.Extents.eks = append(dst.Extents.eks, buildExtentKey(snapVer, 0, 1026, 0, 1000))
+	dst.Size = 1000
+	curVer := testCreateVer()
+
+	resp := mp.fsmCloneExtents(&fsmCloneExtentsRequest{SrcInode: src.Inode, DstInode: dst.Inode, Size: 4096})
+	require.Equal(t, proto.OpOk, resp.Status)
+	require.EqualValues(t, 2000, resp.Size)
+	require.True(t, mp.extentRefs.has(ek.GenerateId()))
+
+	// the shared keys keep the versions, and are counted by the ref maps of both files
+	require.Equal(t, 1, dst.Extents.Len())
+	require.Equal(t, curVer, dst.Extents.eks[0].GetSeq())
+	require.True(t, dst.Extents.eks[0].IsSplit())
+	require.Equal(t, snapVer, src.Extents.eks[0].GetSeq())
+	require.True(t, src.Extents.eks[0].IsSplit())
+	require.True(t, src.isEkInRefMap(mp.config.PartitionId, &ek))
+
+	// the data replaced by the clone is kept by the snapshot
+	require.Equal(t, 0, len(mp.extDelCh))
+	rsp := testGetExtList(t, dst, snapVer)
+	require.Equal(t, 1, len(rsp.Extents))
+	require.Equal(t, uint64(1026), rsp.Extents[0].ExtentId)
+	rsp = testGetExtList(t, dst, 0)
+	require.Equal(t, uint64(1025), rsp.Extents[0].ExtentId)
+
+	// the source keeps the extent in its snapshot, so the clone drops it without deletion
+	require.Equal(t, proto.OpOk, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: src.Inode, Size: 2000}))
+	require.Equal(t, 0, src.Extents.Len())
+	require.Equal(t, 0, len(mp.extDelCh))
+	require.Equal(t, proto.OpOk, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: dst.Inode, Size: 2000}))
+	require.Equal(t, 0, dst.Extents.Len())
+	require.False(t, mp.extentRefs.has(ek.GenerateId()))
+	require.Equal(t, 0, len(mp.extDelCh))
+}
+
+func TestExtentsDeleteWithGeneration(t *testing.T) {
+	initMp(t)
+	mp.extentRefs = newExtentRefTable()
+
+	ino := testCreateInode(t, FileModeType)
+	ino.Extents.eks = append(ino.Extents.eks, buildExtentKey(0, 0, 1025, 0, 2000))
+	ino.Size = 2000
+	gen := ino.Generation
+
+	// the file written since the generation is kept
+	ino.Generation++
+	require.Equal(t, proto.OpArgMismatchErr, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: ino.Inode, Size: 2000, Generation: gen}))
+	require.Equal(t, 1, ino.Extents.Len())
+	require.Equal(t, 0, len(mp.extDelCh))
+
+	require.Equal(t, proto.OpOk, mp.fsmExtentsDelete(&fsmExtentsDelRequest{Inode: ino.Inode, Size: 2000, Generation: gen + 1}))
+	require.Equal(t, 0, ino.Extents.Len())
+	require.EqualValues(t, 2000, ino.Size)
+	require.Equal(t, 1, len(mp.extDelCh))
+}
+
+func TestCloneExtentsOwnerQuota(t *testing.T) {
+	initMp(t)
+	mp.extentRefs = newExtentRefTable()
+
+	src := testCreateInode(t, FileModeType)
+	src.Extents.eks = append(src.Extents.eks, buildExtentKey(0, 0, 1025, 0, 2000))
+	src.Size = 2000
+	dst := testCreateInode(t, FileModeType)
+	dst.Uid = 10
+	mp.ownerQuotaMgr.setOwnerQuotaHbInfo([]string{mp.config.VolName}, []*proto.OwnerQuotaHeartBeatInfo{
+		{
+			VolName:     mp.config.VolName,
+			Type:        proto.OwnerQuotaTypeUser,
+			Id:          10,
+			LimitedInfo: proto.QuotaLimitedInfo{LimitedBytes: true},
+		},
+	})
+
+	// the clone grows the usage of the destination owner
+	p := &Packet{}
+	err := mp.CloneExtents(&proto.CloneExtentsRequest{SrcInode: src.Inode, DstInode: dst.Inode, Size: 2000}, p, "")
+	require.Error(t, err)
+	require.Equal(t, proto.OpNoSpaceErr, p.ResultCode)
+	require.Equal(t, 0, dst.Extents.Len())
+}
+
+func TestReadDeleteExtentsDeferShared(t *testing.T) {
+	initMp(t)
+	mp.extentRefs = newExtentRefTable()
+
+	shared := buildExtentKey(0, 0, 1025, 0, 2000)
+	owned := buildExtentKey(0, 0, 1026, 0, 2000)
+	mp.extentRefs.share(shared.GenerateId())
+
+	var data []byte
+	for _, ek := range []proto.ExtentKey{shared, owned} {
+		ekData, err := ek.MarshalBinaryWithCheckSum(true)
+		require.NoError(t, err)
+		data = append(data, ekData...)
+	}
+	// an incomplete extent at the end is left to the next round
+	data = append(data, data[:10]...)
+
+	needDelete := make(map[uint64][]*proto.ExtentKey)
+	deleteCnt, deferExts, unread, err := mp.readDeleteExtents(bytes.NewBuffer(data), "test", true, needDelete)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleteCnt)
+	require.Equal(t, 10, unread)
+	require.Len(t, needDelete[partitionId], 1)
+	require.Equal(t, owned.ExtentId, needDelete[partitionId][0].ExtentId)
+	// the shared extent is kept in the delete list instead of being dropped
+	require.Len(t, deferExts, 1)
+	require.Equal(t, shared.ExtentId, deferExts[0].ExtentId)
+
+	// it is deleted once the other holder drops it
+	mp.extentRefs.drop(shared.GenerateId())
+	needDelete = make(map[uint64][]*proto.ExtentKey)
+	ekData, err := deferExts[0].MarshalBinaryWithCheckSum(true)
+	require.NoError(t, err)
+	deleteCnt, deferExts, _, err = mp.readDeleteExtents(bytes.NewBuffer(ekData), "test", true, needDelete)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleteCnt)
+	require.Empty(t, deferExts)
+	require.Equal(t, shared.ExtentId, needDelete[partitionId][0].ExtentId)
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// fileLockTable keeps the posix and flock locks of the inodes in the meta partition.
+// All the modifications are applied through raft, the time used to expire the locks
+// is carried by the raft log, so every replica gets the same table.
+type fileLockTable struct {
+	sync.RWMutex
+	locks map[uint64][]*proto.FileLock // inode -> locks
+}
+
+type fileLockRecord struct {
+	Inode uint64            `json:"ino"`
+	Locks []*proto.FileLock `json:"locks"`
+}
+
+func newFileLockTable() *fileLockTable {
+	return &fileLockTable{locks: make(map[uint64][]*proto.FileLock)}
+}
+
+func (t *fileLockTable) clone() *fileLockTable {
+	t.RLock()
+	defer t.RUnlock()
+	table := newFileLockTable()
+	for ino, locks := range t.locks {
+		copied := make([]*proto.FileLock, 0, len(locks))
+		for _, lock := range locks {
+			l := *lock
+			copied = append(copied, &l)
+		}
+		table.locks[ino] = copied
+	}
+	return table
+}
+
+func (t *fileLockTable) Marshal() (buf []byte, crc uint32, err error) {
+	t.RLock()
+	records := make([]*fileLockRecord, 0, len(t.locks))
+	for ino, locks := range t.locks {
+		records = append(records, &fileLockRecord{Inode: ino, Locks: locks})
+	}
+	buf, err = json.Marshal(records)
+	t.RUnlock()
+	if err != nil {
+		return
+	}
+	crc = crc32.ChecksumIEEE(buf)
+	return
+}
+
+func (t *fileLockTable) UnMarshal(data []byte) (err error) {
+	records := make([]*fileLockRecord, 0)
+	if err = json.Unmarshal(data, &records); err != nil {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.locks = make(map[uint64][]*proto.FileLock, len(records))
+	for _, record := range records {
+		if len(record.Locks) > 0 {
+			t.locks[record.Inode] = record.Locks
+		}
+	}
+	return
+}
+
+func (t *fileLockTable) count() (n int) {
+	t.RLock()
+	defer t.RUnlock()
+	for _, locks := range t.locks {
+		n += len(locks)
+	}
+	return
+}
+
+func (t *fileLockTable) holdBy(clientID uint64) bool {
+	t.RLock()
+	defer t.RUnlock()
+	for _, locks := range t.locks {
+		for _, l := range locks {
+			if l.ClientID == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// held returns true if the holder of the lock owns any unexpired lock within its range.
+func (t *fileLockTable) held(ino uint64, lock *proto.FileLock, now int64) bool {
+	t.RLock()
+	defer t.RUnlock()
+	for _, l := range t.locks[ino] {
+		if l.Expire >= now && l.Flock == lock.Flock && l.SameHolder(lock) && l.Overlap(lock) {
+			return true
+		}
+	}
+	return false
+}
+
+// getConflict returns a copy of the unexpired lock which conflicts with the given one.
+func (t *fileLockTable) getConflict(ino uint64, lock *proto.FileLock, now int64) *proto.FileLock {
+	t.RLock()
+	defer t.RUnlock()
+	for _, l := range t.locks[ino] {
+		if l.Expire >= now && lock.Conflict(l) {
+			conflict := *l
+			return &conflict
+		}
+	}
+	return nil
+}
+
+// setLock acquires or releases the lock, a copy of the conflicting lock is returned if
+// the lock can not be acquired. Like fcntl(2), the new lock replaces the overlapping
+// parts of the locks held by the same owner, and unlocking may split a lock into two.
+func (t *fileLockTable) setLock(ino uint64, lock *proto.FileLock, now int64) (conflict *proto.FileLock) {
+	t.Lock()
+	defer t.Unlock()
+
+	locks := make([]*proto.FileLock, 0, len(t.locks[ino]))
+	for _, l := range t.locks[ino] {
+		if l.Expire < now {
+			continue
+		}
+		if lock.Type != proto.FileLockUnlock && lock.Conflict(l) {
+			c := *l
+			return &c
+		}
+		locks = append(locks, l)
+	}
+
+	kept := make([]*proto.FileLock, 0, len(locks)+2)
+	for _, l := range locks {
+		if l.Flock != lock.Flock || !l.SameHolder(lock) || !l.Overlap(lock) {
+			kept = append(kept, l)
+			continue
+		}
+		if lock.Flock {
+			continue
+		}
+		if l.Start < lock.Start {
+			left := *l
+			left.End = lock.Start - 1
+			kept = append(kept, &left)
+		}
+		if l.End > lock.End {
+			right := *l
+			right.Start = lock.End + 1
+			kept = append(kept, &right)
+		}
+	}
+	if lock.Type != proto.FileLockUnlock {
+		l := *lock
+		kept = append(kept, &l)
+	}
+
+	if len(kept) == 0 {
+		delete(t.locks, ino)
+	} else {
+		t.locks[ino] = kept
+	}
+	return nil
+}
+
+// renew extends the lease of the locks held by the client, the expired locks are dropped.
+func (t *fileLockTable) renew(clientID uint64, expire, now int64) (renewed int) {
+	t.Lock()
+	defer t.Unlock()
+	for ino, locks := range t.locks {
+		kept := locks[:0]
+		for _, l := range locks {
+			if l.Expire < now {
+				continue
+			}
+			if l.ClientID == clientID {
+				l.Expire = expire
+				renewed++
+			}
+			kept = append(kept, l)
+		}
+		if len(kept) == 0 {
+			delete(t.locks, ino)
+		} else {
+			t.locks[ino] = kept
+		}
+	}
+	return
+}
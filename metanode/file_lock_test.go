@@ -0,0 +1,100 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func newTestFileLock(client, owner, start, end uint64, typ uint32, expire int64) *proto.FileLock {
+	return &proto.FileLock{ClientID: client, Owner: owner, Start: start, End: end, Type: typ, Expire: expire}
+}
+
+func TestFileLockTableConflict(t *testing.T) {
+	table := newFileLockTable()
+	ino := uint64(100)
+
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 0, 99, proto.FileLockRead, 100), 0))
+	// read locks are shared by the clients
+	require.Nil(t, table.setLock(ino, newTestFileLock(2, 1, 50, 149, proto.FileLockRead, 100), 0))
+	// a write lock conflicts with the read locks of others
+	conflict := table.setLock(ino, newTestFileLock(2, 1, 0, 9, proto.FileLockWrite, 100), 0)
+	require.NotNil(t, conflict)
+	require.EqualValues(t, 1, conflict.ClientID)
+	// but not with the locks of the same owner, or out of the range
+	require.Nil(t, table.setLock(ino, newTestFileLock(2, 1, 150, 199, proto.FileLockWrite, 100), 0))
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 10, 19, proto.FileLockWrite, 100), 0))
+	// posix locks do not interact with flock locks
+	flock := newTestFileLock(3, 1, 0, proto.FileLockMaxOffset, proto.FileLockWrite, 100)
+	flock.Flock = true
+	require.Nil(t, table.setLock(ino, flock, 0))
+	require.Nil(t, table.getConflict(ino, newTestFileLock(4, 1, 300, 399, proto.FileLockWrite, 100), 0))
+	flock.ClientID = 4
+	require.NotNil(t, table.getConflict(ino, flock, 0))
+
+	// the conflicting locks are ignored once expired
+	require.Nil(t, table.setLock(ino, newTestFileLock(5, 1, 0, 199, proto.FileLockWrite, 300), 200))
+}
+
+func TestFileLockTableSplit(t *testing.T) {
+	table := newFileLockTable()
+	ino := uint64(100)
+
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 0, 99, proto.FileLockWrite, 100), 0))
+	// unlocking the middle of the range splits the lock
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 40, 59, proto.FileLockUnlock, 100), 0))
+	require.Equal(t, 2, table.count())
+	require.Nil(t, table.getConflict(ino, newTestFileLock(2, 1, 40, 59, proto.FileLockWrite, 100), 0))
+	require.NotNil(t, table.getConflict(ino, newTestFileLock(2, 1, 39, 39, proto.FileLockRead, 100), 0))
+	require.NotNil(t, table.getConflict(ino, newTestFileLock(2, 1, 60, 60, proto.FileLockRead, 100), 0))
+
+	// downgrading a part of the range to a read lock
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 0, 19, proto.FileLockRead, 100), 0))
+	require.Equal(t, 3, table.count())
+	require.Nil(t, table.getConflict(ino, newTestFileLock(2, 1, 0, 19, proto.FileLockRead, 100), 0))
+	require.NotNil(t, table.getConflict(ino, newTestFileLock(2, 1, 0, 20, proto.FileLockRead, 100), 0))
+
+	// releasing all the locks of the owner
+	require.True(t, table.held(ino, newTestFileLock(1, 1, 0, proto.FileLockMaxOffset, proto.FileLockUnlock, 0), 0))
+	require.Nil(t, table.setLock(ino, newTestFileLock(1, 1, 0, proto.FileLockMaxOffset, proto.FileLockUnlock, 0), 0))
+	require.Equal(t, 0, table.count())
+	require.False(t, table.held(ino, newTestFileLock(1, 1, 0, proto.FileLockMaxOffset, proto.FileLockUnlock, 0), 0))
+}
+
+func TestFileLockTableRenew(t *testing.T) {
+	table := newFileLockTable()
+	require.Nil(t, table.setLock(1, newTestFileLock(1, 1, 0, 9, proto.FileLockWrite, 100), 0))
+	require.Nil(t, table.setLock(2, newTestFileLock(1, 2, 0, 9, proto.FileLockWrite, 100), 0))
+	require.Nil(t, table.setLock(3, newTestFileLock(2, 1, 0, 9, proto.FileLockWrite, 50), 0))
+	require.True(t, table.holdBy(2))
+
+	// the locks of client 2 expired, and the ones of client 1 are renewed
+	require.Equal(t, 2, table.renew(1, 200, 60))
+	require.Equal(t, 2, table.count())
+	require.False(t, table.holdBy(2))
+	require.NotNil(t, table.getConflict(1, newTestFileLock(2, 1, 0, 0, proto.FileLockRead, 0), 150))
+
+	data, crc, err := table.Marshal()
+	require.NoError(t, err)
+	require.NotZero(t, crc)
+	loaded := newFileLockTable()
+	require.NoError(t, loaded.UnMarshal(data))
+	require.Equal(t, table.locks, loaded.locks)
+	require.Equal(t, table.locks, table.clone().locks)
+}
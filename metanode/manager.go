@@ -187,6 +187,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaExtentsDel(conn, p, remoteAddr)
 	case proto.OpMetaTruncate:
 		err = m.opMetaExtentsTruncate(conn, p, remoteAddr)
+	case proto.OpMetaCloneExtents:
+		err = m.opMetaCloneExtents(conn, p, remoteAddr)
 	case proto.OpMetaLookup:
 		err = m.opMetaLookup(conn, p, remoteAddr)
 	case proto.OpDeleteMetaPartition:
@@ -281,6 +283,13 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opQuotaCreateDentry(conn, p, remoteAddr)
 	case proto.OpMetaGetUniqID:
 		err = m.opMetaGetUniqID(conn, p, remoteAddr)
+	// operations for file locks
+	case proto.OpMetaSetLock:
+		err = m.opMetaSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opMetaGetLock(conn, p, remoteAddr)
+	case proto.OpMetaRenewLock:
+		err = m.opMetaRenewLock(conn, p, remoteAddr)
 	// multi version
 	case proto.OpVersionOperation:
 		err = m.opMultiVersionOp(conn, p, remoteAddr)
@@ -554,6 +563,7 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		RootDir:     path.Join(m.rootDir, partitionPrefix+partitionId),
 		ConnPool:    m.connPool,
 		VerSeq:      request.VerSeq,
+		StoreMode:   request.StoreMode,
 	}
 	mpc.AfterStop = func() {
 		m.detachPartition(request.PartitionID)
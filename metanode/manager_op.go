@@ -111,21 +111,23 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet, remoteAddr
 			partition.SetUidLimit(req.UidLimitInfo)
 			partition.SetTxInfo(req.TxInfo)
 			partition.setQuotaHbInfo(req.QuotaHbInfos)
+			partition.setOwnerQuotaHbInfo(req.OwnerQuotaEnableVols, req.OwnerQuotaHbInfos)
 			mConf := partition.GetBaseConfig()
 
 			mpr := &proto.MetaPartitionReport{
-				PartitionID:      mConf.PartitionId,
-				Start:            mConf.Start,
-				End:              mConf.End,
-				Status:           proto.ReadWrite,
-				MaxInodeID:       mConf.Cursor,
-				VolName:          mConf.VolName,
-				Size:             partition.DataSize(),
-				InodeCnt:         uint64(partition.GetInodeTreeLen()),
-				DentryCnt:        uint64(partition.GetDentryTreeLen()),
-				FreeListLen:      uint64(partition.GetFreeListLen()),
-				UidInfo:          partition.GetUidInfo(),
-				QuotaReportInfos: partition.getQuotaReportInfos(),
+				PartitionID:           mConf.PartitionId,
+				Start:                 mConf.Start,
+				End:                   mConf.End,
+				Status:                proto.ReadWrite,
+				MaxInodeID:            mConf.Cursor,
+				VolName:               mConf.VolName,
+				Size:                  partition.DataSize(),
+				InodeCnt:              uint64(partition.GetInodeTreeLen()),
+				DentryCnt:             uint64(partition.GetDentryTreeLen()),
+				FreeListLen:           uint64(partition.GetFreeListLen()),
+				UidInfo:               partition.GetUidInfo(),
+				QuotaReportInfos:      partition.getQuotaReportInfos(),
+				OwnerQuotaReportInfos: partition.getOwnerQuotaReportInfos(),
 			}
 			mpr.TxCnt, mpr.TxRbInoCnt, mpr.TxRbDenCnt = partition.TxGetCnt()
 
@@ -1259,29 +1261,59 @@ func (m *metadataManager) opMetaObjExtentsList(conn net.Conn, p *Packet, remoteA
 }
 
 func (m *metadataManager) opMetaExtentsDel(conn net.Conn, p *Packet, remoteAddr string) (err error) {
-	panic("not implemented yet")
-	// req := &proto.DelExtentKeyRequest{}
-	// if err = json.Unmarshal(p.Data, req); err != nil {
-	// 	p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
-	// 	m.respondToClientWithVer(conn, p)
-	// 	err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
-	// 	return
-	// }
-	// mp, err := m.getPartition(req.PartitionID)
-	// if err != nil {
-	// 	p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
-	// 	m.respondToClientWithVer(conn, p)
-	// 	err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
-	// 	return
-	// }
-	// if !m.serveProxy(conn, mp, p) {
-	// 	return
-	// }
-	// mp.ExtentsDelete(req, p)
-	// m.respondToClientWithVer(conn, p)
-	// log.LogDebugf("%s [OpMetaTruncate] req: %d - %v, resp body: %v, "+
-	// 	"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
-	// return
+	req := &proto.DelExtentKeyRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	if err = m.checkMultiVersionStatus(mp, p); err != nil {
+		err = errors.NewErrorf("[%v],req[%v],err[%v]", p.GetOpMsgWithReqAndResult(), req, string(p.Data))
+		m.respondToClientWithVer(conn, p)
+		return
+	}
+	mp.ExtentsDelete(req, p, remoteAddr)
+	m.updatePackRspSeq(mp, p)
+	m.respondToClientWithVer(conn, p)
+	log.LogDebugf("%s [opMetaExtentsDel] req: %d - %v, resp body: %v, "+
+		"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaCloneExtents(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.CloneExtentsRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClientWithVer(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	mp.CloneExtents(req, p, remoteAddr)
+	m.respondToClientWithVer(conn, p)
+	log.LogDebugf("%s [opMetaCloneExtents] req: %d - %v, resp body: %v, "+
+		"resp body: %s", remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
 }
 
 func (m *metadataManager) opMetaExtentsTruncate(conn net.Conn, p *Packet, remoteAddr string) (err error) {
@@ -2334,6 +2366,102 @@ func (m *metadataManager) opMetaGetUniqID(conn net.Conn, p *Packet, remoteAddr s
 	return
 }
 
+func (m *metadataManager) opMetaSetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.SetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+
+	err = mp.SetLock(req, p)
+	m.respondToClient(conn, p)
+	if err != nil {
+		log.LogErrorf("%s [opMetaSetLock] %s, "+
+			"response to client: %s", remoteAddr, err.Error(), p.GetResultMsg())
+	}
+	log.LogDebugf("%s [opMetaSetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+
+	err = mp.GetLock(req, p)
+	m.respondToClient(conn, p)
+	if err != nil {
+		log.LogErrorf("%s [opMetaGetLock] %s, "+
+			"response to client: %s", remoteAddr, err.Error(), p.GetResultMsg())
+	}
+	log.LogDebugf("%s [opMetaGetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaRenewLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.RenewLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+
+	err = mp.RenewLock(req, p)
+	m.respondToClient(conn, p)
+	if err != nil {
+		log.LogErrorf("%s [opMetaRenewLock] %s, "+
+			"response to client: %s", remoteAddr, err.Error(), p.GetResultMsg())
+	}
+	log.LogDebugf("%s [opMetaRenewLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) prepareCreateVersion(req *proto.MultiVersionOpRequest) (err error, opAagin bool) {
 	var ver2Phase *verOp2Phase
 	if value, ok := m.volUpdating.Load(req.VolumeID); ok {
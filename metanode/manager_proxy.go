@@ -56,6 +56,7 @@ func (m *metadataManager) IsForbiddenOp(mp MetaPartition, reqOp uint8) bool {
 		proto.OpMetaBatchObjExtentsAdd,
 		proto.OpMetaBatchExtentsAdd,
 		proto.OpMetaExtentsDel,
+		proto.OpMetaCloneExtents,
 		// inode
 		proto.OpMetaCreateInode,
 		proto.OpQuotaCreateInode,
@@ -0,0 +1,202 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sync"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// MetaOwnerQuotaManager accounts the used files and bytes of every uid and gid
+// in the partition, and keeps the limited info of user and group quotas from master.
+// The usage is always accounted so that a quota set later takes effect at once,
+// it is only reported to master when the quota of the volume is enabled.
+type MetaOwnerQuotaManager struct {
+	statisticTemp        map[proto.OwnerQuotaKey]proto.QuotaUsedInfo
+	statisticBase        map[proto.OwnerQuotaKey]proto.QuotaUsedInfo
+	statisticRebuildTemp map[proto.OwnerQuotaKey]proto.QuotaUsedInfo
+	statisticRebuildBase map[proto.OwnerQuotaKey]proto.QuotaUsedInfo
+	limitedMap           map[proto.OwnerQuotaKey]proto.QuotaLimitedInfo
+	rbuilding            bool
+	volName              string
+	rwlock               sync.RWMutex
+	mpID                 uint64
+	enable               bool
+}
+
+func NewOwnerQuotaManager(volName string, mpId uint64) (oqMgr *MetaOwnerQuotaManager) {
+	oqMgr = &MetaOwnerQuotaManager{
+		statisticTemp:        make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo),
+		statisticBase:        make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo),
+		statisticRebuildTemp: make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo),
+		statisticRebuildBase: make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo),
+		limitedMap:           make(map[proto.OwnerQuotaKey]proto.QuotaLimitedInfo),
+		volName:              volName,
+		mpID:                 mpId,
+	}
+	return
+}
+
+func ownerQuotaKeys(uid, gid uint32) [2]proto.OwnerQuotaKey {
+	return [2]proto.OwnerQuotaKey{
+		{Type: proto.OwnerQuotaTypeUser, Id: uid},
+		{Type: proto.OwnerQuotaTypeGroup, Id: gid},
+	}
+}
+
+func addOwnerUsedInfo(m map[proto.OwnerQuotaKey]proto.QuotaUsedInfo, key proto.OwnerQuotaKey, size int64, files int64) {
+	usedInfo := m[key]
+	usedInfo.UsedBytes += size
+	usedInfo.UsedFiles += files
+	m[key] = usedInfo
+}
+
+func (oqMgr *MetaOwnerQuotaManager) setOwnerQuotaHbInfo(enableVols []string, infos []*proto.OwnerQuotaHeartBeatInfo) {
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+
+	enable := false
+	for _, volName := range enableVols {
+		if oqMgr.volName == volName {
+			enable = true
+			break
+		}
+	}
+	limitedMap := make(map[proto.OwnerQuotaKey]proto.QuotaLimitedInfo)
+	for _, info := range infos {
+		if oqMgr.volName != info.VolName {
+			continue
+		}
+		limitedMap[proto.OwnerQuotaKey{Type: info.Type, Id: info.Id}] = info.LimitedInfo
+		log.LogDebugf("mp[%v] owner quota type [%v] id [%v] limitedInfo [%v]", oqMgr.mpID, info.Type, info.Id, info.LimitedInfo)
+	}
+	oqMgr.enable = enable
+	oqMgr.limitedMap = limitedMap
+}
+
+func (oqMgr *MetaOwnerQuotaManager) getOwnerQuotaReportInfos() (infos []*proto.OwnerQuotaReportInfo) {
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+	for key, usedInfo := range oqMgr.statisticTemp {
+		baseInfo := oqMgr.statisticBase[key]
+		usedInfo.Add(&baseInfo)
+		if usedInfo.UsedFiles < 0 || usedInfo.UsedBytes < 0 {
+			log.LogWarnf("[getOwnerQuotaReportInfos] mp[%v] key [%v] usedInfo [%v]", oqMgr.mpID, key, usedInfo)
+			if usedInfo.UsedFiles < 0 {
+				usedInfo.UsedFiles = 0
+			}
+			if usedInfo.UsedBytes < 0 {
+				usedInfo.UsedBytes = 0
+			}
+		}
+		oqMgr.statisticBase[key] = usedInfo
+	}
+	oqMgr.statisticTemp = make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo)
+	if !oqMgr.enable {
+		return
+	}
+	for key, usedInfo := range oqMgr.statisticBase {
+		if usedInfo.UsedFiles == 0 && usedInfo.UsedBytes == 0 {
+			continue
+		}
+		infos = append(infos, &proto.OwnerQuotaReportInfo{
+			Type:     key.Type,
+			Id:       key.Id,
+			UsedInfo: usedInfo,
+		})
+	}
+	return
+}
+
+func (oqMgr *MetaOwnerQuotaManager) statisticRebuildStart() bool {
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+	if oqMgr.rbuilding {
+		return false
+	}
+	oqMgr.rbuilding = true
+	return true
+}
+
+func (oqMgr *MetaOwnerQuotaManager) statisticRebuildFin(rebuild bool) {
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+	oqMgr.rbuilding = false
+	if rebuild {
+		oqMgr.statisticBase = oqMgr.statisticRebuildBase
+		oqMgr.statisticTemp = oqMgr.statisticRebuildTemp
+	}
+	oqMgr.statisticRebuildBase = make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo)
+	oqMgr.statisticRebuildTemp = make(map[proto.OwnerQuotaKey]proto.QuotaUsedInfo)
+}
+
+// statisticInode accounts an inode into the base statistic when loading,
+// or into the rebuild base when storing snapshot.
+func (oqMgr *MetaOwnerQuotaManager) statisticInode(ino *Inode, rebuild bool) {
+	if ino.NLink == 0 || ino.ShouldDelete() {
+		return
+	}
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+	base := oqMgr.statisticBase
+	if rebuild {
+		base = oqMgr.statisticRebuildBase
+	}
+	for _, key := range ownerQuotaKeys(ino.Uid, ino.Gid) {
+		addOwnerUsedInfo(base, key, int64(ino.Size), 1)
+	}
+}
+
+func (oqMgr *MetaOwnerQuotaManager) IsOverQuota(size bool, files bool, uid, gid uint32) (status uint8) {
+	oqMgr.rwlock.RLock()
+	defer oqMgr.rwlock.RUnlock()
+	if !oqMgr.enable {
+		return
+	}
+	for _, key := range ownerQuotaKeys(uid, gid) {
+		limitedInfo, isFind := oqMgr.limitedMap[key]
+		if !isFind {
+			continue
+		}
+		if (size && limitedInfo.LimitedBytes) || (files && limitedInfo.LimitedFiles) {
+			log.LogInfof("IsOverQuota mp[%v] owner quota [%v] limitedInfo [%v]", oqMgr.mpID, key, limitedInfo)
+			return proto.OpNoSpaceErr
+		}
+	}
+	return
+}
+
+func (oqMgr *MetaOwnerQuotaManager) updateUsedInfo(size int64, files int64, uid, gid uint32) {
+	oqMgr.rwlock.Lock()
+	defer oqMgr.rwlock.Unlock()
+	for _, key := range ownerQuotaKeys(uid, gid) {
+		addOwnerUsedInfo(oqMgr.statisticTemp, key, size, files)
+		if oqMgr.rbuilding {
+			addOwnerUsedInfo(oqMgr.statisticRebuildTemp, key, size, files)
+		}
+	}
+	log.LogDebugf("updateUsedInfo mpId [%v] uid [%v] gid [%v] size [%v] files [%v]", oqMgr.mpID, uid, gid, size, files)
+}
+
+func (oqMgr *MetaOwnerQuotaManager) getUsedInfoForTest(key proto.OwnerQuotaKey) (size int64, files int64) {
+	oqMgr.rwlock.RLock()
+	defer oqMgr.rwlock.RUnlock()
+	usedInfo := oqMgr.statisticTemp[key]
+	baseInfo := oqMgr.statisticBase[key]
+	usedInfo.Add(&baseInfo)
+	return usedInfo.UsedBytes, usedInfo.UsedFiles
+}
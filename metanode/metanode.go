@@ -64,6 +64,7 @@ type MetaNode struct {
 	raftRetainLogs            uint64
 	raftSyncSnapFormatVersion uint32 // format version of snapshot that raft leader sent to follower
 	zoneName                  string
+	rackName                  string
 	httpStopC                 chan uint8
 	smuxStopC                 chan uint8
 	metrics                   *MetaNodeMetrics
@@ -208,6 +209,7 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.raftRecvBufSize = int(cfg.GetInt(cfgRaftRecvBufSize))
 	m.zoneName = cfg.GetString(cfgZoneName)
+	m.rackName = cfg.GetString(cfgRackName)
 
 	deleteBatchCount := cfg.GetInt64(cfgDeleteBatchCount)
 	if deleteBatchCount > 1 {
@@ -271,15 +273,15 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 
 	if cfg.HasKey(cfgRaftSyncSnapFormatVersion) {
 		raftSyncSnapFormatVersion := uint32(cfg.GetInt64(cfgRaftSyncSnapFormatVersion))
-		if raftSyncSnapFormatVersion > SnapFormatVersion_1 {
-			m.raftSyncSnapFormatVersion = SnapFormatVersion_1
+		if raftSyncSnapFormatVersion > SnapFormatVersion_4 {
+			m.raftSyncSnapFormatVersion = SnapFormatVersion_4
 			log.LogInfof("invalid config raftSyncSnapFormatVersion, using default[%v]", m.raftSyncSnapFormatVersion)
 		} else {
 			m.raftSyncSnapFormatVersion = raftSyncSnapFormatVersion
 			log.LogInfof("by config raftSyncSnapFormatVersion:[%v]", m.raftSyncSnapFormatVersion)
 		}
 	} else {
-		m.raftSyncSnapFormatVersion = SnapFormatVersion_1
+		m.raftSyncSnapFormatVersion = SnapFormatVersion_4
 		log.LogInfof("using default raftSyncSnapFormatVersion[%v]", m.raftSyncSnapFormatVersion)
 	}
 	syslog.Println("conf raftSyncSnapFormatVersion=", m.raftSyncSnapFormatVersion)
@@ -303,6 +305,7 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogInfof("[parseConfig] load raftHeartbeatPort[%v].", m.raftHeartbeatPort)
 	log.LogInfof("[parseConfig] load raftReplicatePort[%v].", m.raftReplicatePort)
 	log.LogInfof("[parseConfig] load zoneName[%v].", m.zoneName)
+	log.LogInfof("[parseConfig] load rackName[%v].", m.rackName)
 
 	if err = m.parseSmuxConfig(cfg); err != nil {
 		return fmt.Errorf("parseSmuxConfig fail err %v", err)
@@ -462,7 +465,7 @@ func (m *MetaNode) register() (err error) {
 			step++
 		}
 		var nodeID uint64
-		if nodeID, err = masterClient.NodeAPI().AddMetaNodeWithAuthNode(nodeAddress, m.zoneName, m.serviceIDKey); err != nil {
+		if nodeID, err = masterClient.NodeAPI().AddMetaNodeWithAuthNode(nodeAddress, m.zoneName, m.rackName, m.serviceIDKey); err != nil {
 			log.LogErrorf("register: register to master fail: address(%v) err(%s)", nodeAddress, err)
 			time.Sleep(3 * time.Second)
 			continue
@@ -83,6 +83,7 @@ func newPartition(conf *MetaPartitionConfig, manager *metadataManager) (mp *meta
 	mp.config.Cursor = 0
 	mp.config.End = 100000
 	mp.uidManager = NewUidMgr(conf.VolName, mp.config.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(conf.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(conf.VolName, mp.config.PartitionId)
 	return mp
 }
@@ -1310,6 +1311,7 @@ func newMpWithMock(t *testing.T) {
 	mp.config.Cursor = 0
 	mp.config.End = 100000
 	mp.uidManager = NewUidMgr(metaConf.VolName, metaConf.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(metaConf.VolName, metaConf.PartitionId)
 	mp.mqMgr = NewQuotaManager(metaConf.VolName, metaConf.PartitionId)
 	mp.multiVersionList.VerList = append(mp.multiVersionList.VerList, &proto.VolVersionInfo{
 		Ver: 0,
@@ -39,6 +39,11 @@ func NewPacketToDeleteExtent(dp *DataPartition, ext *proto.ExtentKey) (p *Packet
 	}
 	log.LogDebugf("NewPacketToDeleteExtent. ext %v", ext)
 	if ext.IsSplit() {
+		if ext.IsCompressed() {
+			invalid = true
+			log.LogDebugf("NewPacketToDeleteExtent. ext %v of compressed frame invalid to punch hole", ext)
+			return
+		}
 		var (
 			newOff  = ext.ExtentOffset
 			newSize = ext.Size
@@ -92,6 +92,7 @@ type MetaPartitionConfig struct {
 	NodeId        uint64              `json:"-"`
 	RootDir       string              `json:"-"`
 	VerSeq        uint64              `json:"ver_seq"`
+	StoreMode     proto.MetaStoreMode `json:"store_mode"`
 	BeforeStart   func()              `json:"-"`
 	AfterStart    func()              `json:"-"`
 	BeforeStop    func()              `json:"-"`
@@ -196,7 +197,8 @@ type OpExtent interface {
 	ObjExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error)
 	ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, remoteAddr string) (err error)
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
-	// ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error)
+	ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet, remoteAddr string) (err error)
+	CloneExtents(req *proto.CloneExtentsRequest, p *Packet, remoteAddr string) (err error)
 }
 
 type OpMultipart interface {
@@ -225,6 +227,13 @@ type OpMultiVersion interface {
 	checkByMasterVerlist(mpVerList *proto.VolVersionInfoList, masterVerList *proto.VolVersionInfoList) (err error)
 }
 
+// OpFileLock defines the interface for the posix and flock file lock operations.
+type OpFileLock interface {
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+	RenewLock(req *proto.RenewLockRequest, p *Packet) (err error)
+}
+
 // OpMeta defines the interface for the metadata operations.
 type OpMeta interface {
 	OpInode
@@ -236,6 +245,7 @@ type OpMeta interface {
 	OpTransaction
 	OpQuota
 	OpMultiVersion
+	OpFileLock
 }
 
 // OpPartition defines the interface for the partition operations.
@@ -467,6 +477,8 @@ type OpQuota interface {
 	batchDeleteInodeQuota(req *proto.BatchDeleteMetaserverQuotaReuqest,
 		resp *proto.BatchDeleteMetaserverQuotaResponse) (err error)
 	getInodeQuota(inode uint64, p *Packet) (err error)
+	setOwnerQuotaHbInfo(enableVols []string, infos []*proto.OwnerQuotaHeartBeatInfo)
+	getOwnerQuotaReportInfos() (infos []*proto.OwnerQuotaReportInfo)
 }
 
 // metaPartition manages the range of the inode IDs.
@@ -485,6 +497,7 @@ type metaPartition struct {
 	inodeTree              *BTree                // btree for inodes
 	extendTree             *BTree                // btree for inode extend (XAttr) management
 	multipartTree          *BTree                // collection for multipart management
+	kvStore                *metaKvStore          // keeps the trees above in MetaStoreModeRocksDB
 	txProcessor            *TransactionProcessor // transction processor
 	raftPartition          raftstore.Partition
 	stopC                  chan bool
@@ -504,8 +517,11 @@ type metaPartition struct {
 	xattrLock              sync.Mutex
 	fileRange              []int64
 	mqMgr                  *MetaQuotaManager
+	ownerQuotaMgr          *MetaOwnerQuotaManager
 	nonIdempotent          sync.Mutex
 	uniqChecker            *uniqChecker
+	fileLocks              *fileLockTable
+	extentRefs             *extentRefTable
 	verSeq                 uint64
 	multiVersionList       *proto.VolVersionInfoList
 	verUpdateChan          chan []byte
@@ -529,19 +545,23 @@ func (mp *metaPartition) SetEnableAuditLog(status bool) {
 }
 
 func (mp *metaPartition) acucumRebuildStart() bool {
-	return mp.uidManager.accumRebuildStart()
+	ownerRebuild := mp.ownerQuotaMgr.statisticRebuildStart()
+	return mp.uidManager.accumRebuildStart() && ownerRebuild
 }
 
 func (mp *metaPartition) acucumRebuildFin(rebuild bool) {
 	mp.uidManager.accumRebuildFin(rebuild)
+	mp.ownerQuotaMgr.statisticRebuildFin(rebuild)
 }
 
 func (mp *metaPartition) acucumUidSizeByStore(ino *Inode) {
 	mp.uidManager.accumInoUidSize(ino, mp.uidManager.accumRebuildBase)
+	mp.ownerQuotaMgr.statisticInode(ino, true)
 }
 
 func (mp *metaPartition) acucumUidSizeByLoad(ino *Inode) {
 	mp.uidManager.accumInoUidSize(ino, mp.uidManager.accumBase)
+	mp.ownerQuotaMgr.statisticInode(ino, false)
 }
 
 func (mp *metaPartition) GetVerList() []*proto.VolVersionInfo {
@@ -785,6 +805,7 @@ func (mp *metaPartition) onStop() {
 		mp.delInodeFp.Sync()
 		mp.delInodeFp.Close()
 	}
+	mp.closeKvStore()
 }
 
 func (mp *metaPartition) startRaft() (err error) {
@@ -870,6 +891,9 @@ func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaP
 		vol:           NewVol(),
 		manager:       manager,
 		uniqChecker:   newUniqChecker(),
+		fileLocks:     newFileLockTable(),
+		extentRefs:    newExtentRefTable(),
+		ownerQuotaMgr: NewOwnerQuotaManager(conf.VolName, conf.PartitionId),
 		verSeq:        conf.VerSeq,
 		multiVersionList: &proto.VolVersionInfoList{
 			TemporaryVerMap: make(map[uint64]*proto.VolVersionInfo),
@@ -998,6 +1022,8 @@ const (
 	CRC_COUNT_TX_STUFF   int = 7
 	CRC_COUNT_UINQ_STUFF int = 8
 	CRC_COUNT_MULTI_VER  int = 9
+	CRC_COUNT_FILE_LOCK  int = 10
+	CRC_COUNT_EXTENT_REF int = 11
 )
 
 func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
@@ -1012,9 +1038,15 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		nil, // loading quota info from extend requires mp.loadInode() has been completed, so skip mp.loadExtend() here
 		mp.loadMultipart,
 	}
+	loadExtend := mp.loadExtend
+	if mp.kvStore != nil {
+		loadFuncs[0], loadFuncs[1], loadFuncs[3] = mp.loadInodeFromKv, mp.loadDentryFromKv, mp.loadMultipartFromKv
+		loadExtend = mp.loadExtendFromKv
+	}
 
 	crc_count := len(crcs)
-	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF && crc_count != CRC_COUNT_MULTI_VER {
+	if crc_count != CRC_COUNT_BASIC && crc_count != CRC_COUNT_TX_STUFF && crc_count != CRC_COUNT_UINQ_STUFF &&
+		crc_count != CRC_COUNT_MULTI_VER && crc_count != CRC_COUNT_FILE_LOCK && crc_count != CRC_COUNT_EXTENT_REF {
 		log.LogErrorf("action[LoadSnapshot] crc array length %d not match", len(crcs))
 		return ErrSnapshotCrcMismatch
 	}
@@ -1033,7 +1065,7 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		loadFuncs = append(loadFuncs, mp.loadUniqChecker)
 	}
 
-	if crc_count == CRC_COUNT_MULTI_VER {
+	if crc_count >= CRC_COUNT_MULTI_VER {
 		if err = mp.loadMultiVer(snapshotPath, crcs[CRC_COUNT_MULTI_VER-1]); err != nil {
 			return
 		}
@@ -1041,6 +1073,18 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		mp.storeMultiVersion(snapshotPath, &storeMsg{multiVerList: mp.multiVersionList.VerList})
 	}
 
+	if crc_count >= CRC_COUNT_FILE_LOCK {
+		if err = mp.loadFileLock(snapshotPath, crcs[CRC_COUNT_FILE_LOCK-1]); err != nil {
+			return
+		}
+	}
+
+	if crc_count >= CRC_COUNT_EXTENT_REF {
+		if err = mp.loadExtentRef(snapshotPath, crcs[CRC_COUNT_EXTENT_REF-1]); err != nil {
+			return
+		}
+	}
+
 	errs := make([]error, len(loadFuncs))
 	var wg sync.WaitGroup
 	wg.Add(len(loadFuncs))
@@ -1076,7 +1120,7 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 		}
 	}
 
-	if err = mp.loadExtend(snapshotPath, crcs[2]); err != nil {
+	if err = loadExtend(snapshotPath, crcs[2]); err != nil {
 		return
 	}
 
@@ -1102,10 +1146,18 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 	if err = mp.loadMetadata(); err != nil {
 		return
 	}
+	if err = mp.openKvStore(); err != nil {
+		return
+	}
 	// 1. create new metaPartition, no need to load snapshot
 	// 2. store the snapshot files for new mp, because
 	// mp.load() will check all the snapshot files when mn startup
 	if isCreate {
+		if mp.kvStore != nil {
+			if _, err = mp.kvStore.reset(); err != nil {
+				return
+			}
+		}
 		if err = mp.storeSnapshotFiles(); err != nil {
 			err = errors.NewErrorf("[onStart] storeSnapshotFiles for partition id=%d: %s",
 				mp.config.PartitionId, err.Error())
@@ -1114,6 +1166,18 @@ func (mp *metaPartition) load(isCreate bool) (err error) {
 	}
 
 	snapshotPath := path.Join(mp.config.RootDir, snapshotDir)
+	if mp.kvStore != nil {
+		// rocksdb holds the latest snapshot files, and its trees would not match any other ones
+		var found bool
+		if found, err = mp.restoreKvSnapshotFiles(snapshotPath); err != nil {
+			return
+		}
+		if !found {
+			log.LogErrorf("load snapshot failed, partition(%v) has no snapshot in rocksdb", mp.config.PartitionId)
+			_, err = mp.kvStore.reset()
+			return
+		}
+	}
 	if _, err = os.Stat(snapshotPath); err != nil {
 		log.LogErrorf("load snapshot failed, err: %s", err.Error())
 		return nil
@@ -1151,6 +1215,12 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeTxRbDentry,
 		mp.storeUniqChecker,
 		mp.storeMultiVersion,
+		mp.storeFileLock,
+		mp.storeExtentRef,
+	}
+	if mp.kvStore != nil {
+		storeFuncs[0], storeFuncs[1], storeFuncs[2], storeFuncs[3] = mp.scanInodeOnStore, mp.skipStoreTree,
+			mp.scanExtendOnStore, mp.skipStoreTree
 	}
 	for _, storeFunc := range storeFuncs {
 		var crc uint32
@@ -1177,6 +1247,16 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 	if err = os.WriteFile(path.Join(tmpDir, SnapshotSign), crcBuffer.Bytes(), 0o775); err != nil {
 		return
 	}
+	if mp.kvStore != nil {
+		if err = mp.commitKvStore(tmpDir, sm); err == errKvStoreReset {
+			// the store is reset by a raft snapshot, whose store tick covers this one
+			log.LogWarnf("metaPartition %d skip store apply %v, the kv store has been reset",
+				mp.config.PartitionId, sm.applyIndex)
+			return nil
+		} else if err != nil {
+			return
+		}
+	}
 	snapshotDir := path.Join(mp.config.RootDir, snapshotDir)
 	// check snapshot backup
 	backupDir := path.Join(mp.config.RootDir, snapshotBackup)
@@ -1695,6 +1775,8 @@ func (mp *metaPartition) storeSnapshotFiles() (err error) {
 		uniqId:         mp.GetUniqId(),
 		uniqChecker:    newUniqChecker(),
 		multiVerList:   mp.multiVersionList.VerList,
+		fileLocks:      newFileLockTable(),
+		extentRefs:     newExtentRefTable(),
 	}
 
 	return mp.store(msg)
@@ -137,6 +137,12 @@ LOOP:
 			}
 			log.LogDebugf("[appendDelExtentsToFile] mp(%v) del eks [%v]", mp.config.PartitionId, eks)
 			for _, ek := range eks {
+				if ek.IsSplit() && ek.IsCompressed() {
+					// the frame may be shared by the other keys, so no hole can be punched for
+					// the key, and the extent is deleted with the last key of it.
+					log.LogDebugf("[appendDelExtentsToFile] mp(%v) skip compressed split ek [%v]", mp.config.PartitionId, ek)
+					continue
+				}
 				data, err = ek.MarshalBinaryWithCheckSum(true)
 				if err != nil {
 					log.LogWarnf("[appendDelExtentsToFile] partitionId=%d,"+
@@ -283,6 +289,7 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 		}
 
 		var deleteCnt uint64
+		var deferExts []proto.ExtentKey
 		errExts := make([]proto.ExtentKey, 0)
 		needDeleteExtents := make(map[uint64][]*proto.ExtentKey)
 		buf = make([]byte, util.MB)
@@ -321,53 +328,10 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 				}
 			}
 			cursor += uint64(rLen)
-			buff := bytes.NewBuffer(buf[:rLen])
-			batchCount := DeleteBatchCount() * 5
-			for buff.Len() != 0 && deleteCnt < batchCount {
-				lastUnread := buff.Len()
-				// NOTE: audjust cursor
-				if uint64(buff.Len()) < extentKeyLen {
-					cursor -= uint64(lastUnread)
-					break
-				}
-				if extentV2 && uint64(buff.Len()) < uint64(proto.ExtentV3Length) {
-					if r := bytes.Compare(buff.Bytes()[:4], proto.ExtentKeyHeaderV3); r == 0 {
-						cursor -= uint64(lastUnread)
-						break
-					}
-				}
-				// NOTE: read ek
-				ek := proto.ExtentKey{}
-				if extentV2 {
-					if err = ek.UnmarshalBinaryWithCheckSum(buff); err != nil {
-						if err == proto.InvalidKeyHeader || err == proto.InvalidKeyCheckSum {
-							log.LogErrorf("[deleteExtentsFromList] invalid extent key header %v, %v, %v", fileName, mp.config.PartitionId, err)
-							return
-						}
-						log.LogErrorf("[deleteExtentsFromList] mp: %v Unmarshal extentkey from %v unresolved error: %v", mp.config.PartitionId, fileName, err)
-						return
-					}
-				} else {
-					// ek for del no need to get version
-					if err = ek.UnmarshalBinary(buff, false); err != nil {
-						log.LogErrorf("[deleteExtentsFromList] mp(%v) failed to unmarshal extent", mp.config.PartitionId)
-						return
-					}
-				}
-
-				// NOTE: add to current batch
-				dpId := ek.PartitionId
-				eks := needDeleteExtents[dpId]
-				if eks == nil {
-					eks = make([]*proto.ExtentKey, 0)
-				}
-				eks = append(eks, &ek)
-				needDeleteExtents[dpId] = eks
-
-				// NOTE: limit batch count
-				deleteCnt++
-				log.LogDebugf("[deleteExtentsFromList] mp(%v) append extent(%v) to batch, count limit(%v), cnt(%v)", mp.config.PartitionId, ek, batchCount, deleteCnt)
-			}
+			var unread int
+			deleteCnt, deferExts, unread, err = mp.readDeleteExtents(bytes.NewBuffer(buf[:rLen]), fileName, extentV2, needDeleteExtents)
+			// NOTE: the left is read in the next round
+			cursor -= uint64(unread)
 			log.LogDebugf("[deleteExtentsFromList] mp(%v) reach the end of buffer", mp.config.PartitionId)
 			return
 		}()
@@ -377,7 +341,7 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 			continue
 		}
 
-		if deleteCnt == 0 {
+		if deleteCnt == 0 && len(deferExts) == 0 {
 			log.LogDebugf("[deleteExtentsFromList] mp(%v) delete cnt is 0, sleep", mp.config.PartitionId)
 			continue
 		}
@@ -400,11 +364,20 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 
 		log.LogDebugf("[deleteExtentsFromList] mp(%v) delete success cnt(%v), err cnt(%v)", mp.config.PartitionId, successCnt, len(errExts))
 
-		if successCnt == 0 {
+		if deleteCnt != 0 && successCnt == 0 {
 			log.LogErrorf("[deleteExtentsFromList] no extents delete successfully, sleep")
 			continue
 		}
 
+		if len(deferExts) != 0 {
+			log.LogDebugf("[deleteExtentsFromList] mp(%v) defer shared extents(%v)", mp.config.PartitionId, len(deferExts))
+			if err = mp.sendExtentsToChan(deferExts); err != nil {
+				// NOTE: keep the cursor, they are read again in the next round
+				log.LogErrorf("[deleteExtentsFromList] sendExtentsToChan by raft error, mp[%v], err(%v), ek(%v)", mp.config.PartitionId, err, len(deferExts))
+				continue
+			}
+		}
+
 		if len(errExts) != 0 {
 			log.LogDebugf("[deleteExtentsFromList] mp(%v) sync errExts(%v)", mp.config.PartitionId, errExts)
 			err = mp.sendExtentsToChan(errExts)
@@ -425,6 +398,72 @@ func (mp *metaPartition) deleteExtentsFromList(fileList *synclist.SyncList) {
 	}
 }
 
+// readDeleteExtents reads a batch of the extents from buff and groups them by data partition.
+// The extents shared by the inodes cloned from their holders are returned in deferExts, they are
+// queued into the delete list again and deleted once the other holders drop them. It returns the
+// count of the bytes left in buff, which are read in the next round.
+func (mp *metaPartition) readDeleteExtents(buff *bytes.Buffer, fileName string, extentV2 bool,
+	needDeleteExtents map[uint64][]*proto.ExtentKey) (deleteCnt uint64, deferExts []proto.ExtentKey, unread int, err error) {
+	extentKeyLen := uint64(proto.ExtentLength)
+	if extentV2 {
+		extentKeyLen = uint64(proto.ExtentV2Length)
+	}
+	batchCount := DeleteBatchCount() * 5
+	defer func() {
+		unread = buff.Len()
+	}()
+	for buff.Len() != 0 && deleteCnt < batchCount {
+		// NOTE: the last extent is incomplete
+		if uint64(buff.Len()) < extentKeyLen {
+			break
+		}
+		if extentV2 && uint64(buff.Len()) < uint64(proto.ExtentV3Length) {
+			if r := bytes.Compare(buff.Bytes()[:4], proto.ExtentKeyHeaderV3); r == 0 {
+				break
+			}
+		}
+		// NOTE: read ek
+		ek := proto.ExtentKey{}
+		if extentV2 {
+			if err = ek.UnmarshalBinaryWithCheckSum(buff); err != nil {
+				if err == proto.InvalidKeyHeader || err == proto.InvalidKeyCheckSum {
+					log.LogErrorf("[deleteExtentsFromList] invalid extent key header %v, %v, %v", fileName, mp.config.PartitionId, err)
+					return
+				}
+				log.LogErrorf("[deleteExtentsFromList] mp: %v Unmarshal extentkey from %v unresolved error: %v", mp.config.PartitionId, fileName, err)
+				return
+			}
+		} else {
+			// ek for del no need to get version
+			if err = ek.UnmarshalBinary(buff, false); err != nil {
+				log.LogErrorf("[deleteExtentsFromList] mp(%v) failed to unmarshal extent", mp.config.PartitionId)
+				return
+			}
+		}
+
+		// NOTE: the extent may be shared by the inodes cloned from its holder
+		if mp.extentRefs.has(ek.GenerateId()) {
+			log.LogDebugf("[deleteExtentsFromList] mp(%v) defer shared extent(%v)", mp.config.PartitionId, ek)
+			deferExts = append(deferExts, ek)
+			continue
+		}
+
+		// NOTE: add to current batch
+		dpId := ek.PartitionId
+		eks := needDeleteExtents[dpId]
+		if eks == nil {
+			eks = make([]*proto.ExtentKey, 0)
+		}
+		eks = append(eks, &ek)
+		needDeleteExtents[dpId] = eks
+
+		// NOTE: limit batch count
+		deleteCnt++
+		log.LogDebugf("[deleteExtentsFromList] mp(%v) append extent(%v) to batch, count limit(%v), cnt(%v)", mp.config.PartitionId, ek, batchCount, deleteCnt)
+	}
+	return
+}
+
 // func (mp *metaPartition) checkBatchDeleteExtents(allExtents map[uint64][]*proto.ExtentKey) {
 // 	for partitionID, deleteExtents := range allExtents {
 // 		needDeleteExtents := make([]proto.ExtentKey, len(deleteExtents))
@@ -268,6 +268,7 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 	}
 	log.LogDebugf("[deleteMarkedInodes] . mp[%v] inoSlice [%v]", mp.config.PartitionId, inoSlice)
 	deleteExtentsByPartition := make(map[uint64][]*proto.ExtentKey)
+	sharedExtents := make(map[uint64][]uint64)
 	allInodes := make([]*Inode, 0)
 	for _, ino := range inoSlice {
 		ref := &Inode{Inode: ino}
@@ -291,8 +292,15 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 			return
 		}
 
+		shared := mp.sharedExtentIdsOf(inode)
+		for id := range shared {
+			sharedExtents[inode.Inode] = append(sharedExtents[inode.Inode], id)
+		}
 		extInfo := inode.GetAllExtsOfflineInode(mp.config.PartitionId)
 		for dpID, inodeExts := range extInfo {
+			if len(shared) > 0 {
+				inodeExts = filterSharedExtents(inodeExts, shared)
+			}
 			exts, ok := deleteExtentsByPartition[dpID]
 			if !ok {
 				exts = make([]*proto.ExtentKey, 0)
@@ -328,6 +336,15 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 	if err != nil {
 		log.LogWarnf("[deleteMarkedInodes] raft commit inode list: %v, "+
 			"response %s", shouldCommit, err.Error())
+	} else if len(sharedExtents) > 0 {
+		dropIds := make([]uint64, 0)
+		for _, inode := range shouldCommit {
+			dropIds = append(dropIds, sharedExtents[inode.Inode]...)
+		}
+		if dropErr := mp.dropExtentRefs(dropIds); dropErr != nil {
+			log.LogWarnf("[deleteMarkedInodes] mp(%v) drop shared extents(%v) err(%v)",
+				mp.config.PartitionId, dropIds, dropErr)
+		}
 	}
 
 	for _, inode := range shouldCommit {
@@ -349,6 +366,18 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 	}
 }
 
+// filterSharedExtents removes the extents shared with the other inodes.
+func filterSharedExtents(exts []*proto.ExtentKey, shared map[uint64]struct{}) []*proto.ExtentKey {
+	kept := exts[:0]
+	for _, ek := range exts {
+		if _, ok := shared[ek.GenerateId()]; ok {
+			continue
+		}
+		kept = append(kept, ek)
+	}
+	return kept
+}
+
 func (mp *metaPartition) syncToRaftFollowersFreeInode(hasDeleteInodes []byte) (err error) {
 	if len(hasDeleteInodes) == 0 {
 		return
@@ -58,7 +58,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
 		}
-		resp = mp.fsmCreateInode(ino)
+		if resp = mp.fsmCreateInode(ino); resp == proto.OpOk {
+			mp.ownerQuotaMgr.updateUsedInfo(0, 1, ino.Uid, ino.Gid)
+		}
 	case opFSMCreateInodeQuota:
 		qinode := &MetaQuotaInode{}
 		if err = qinode.Unmarshal(msg.V); err != nil {
@@ -73,6 +75,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		}
 		resp = mp.fsmCreateInode(ino)
 		if resp == proto.OpOk {
+			mp.ownerQuotaMgr.updateUsedInfo(0, 1, ino.Uid, ino.Gid)
 			for _, quotaId := range qinode.quotaIds {
 				mp.mqMgr.updateUsedInfo(0, 1, quotaId)
 			}
@@ -267,6 +270,9 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			uidRebuild:     uidRebuild,
 			uniqChecker:    uniqChecker,
 			multiVerList:   mp.GetAllVerList(),
+			fileLocks:      mp.fileLocks.clone(),
+			extentRefs:     mp.extentRefs.clone(),
+			kvCheckpoint:   mp.checkpointKvTrees(),
 		}
 		log.LogDebugf("opFSMStoreTick: quotaRebuild [%v] uidRebuild [%v]", quotaRebuild, uidRebuild)
 		mp.storeChan <- msg
@@ -442,6 +448,36 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		err = mp.fsmUniqCheckerEvict(req)
 	case opFSMVersionOp:
 		err = mp.fsmVersionOp(msg.V)
+	case opFSMSetLock:
+		req := &fsmSetLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmSetLock(req)
+	case opFSMRenewLock:
+		req := &fsmRenewLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		mp.fsmRenewLock(req)
+	case opFSMExtentsDel:
+		req := &fsmExtentsDelRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmExtentsDelete(req)
+	case opFSMCloneExtents:
+		req := &fsmCloneExtentsRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmCloneExtents(req)
+	case opFSMDropExtentRefs:
+		ids := make([]uint64, 0)
+		if err = json.Unmarshal(msg.V, &ids); err != nil {
+			return
+		}
+		mp.fsmDropExtentRefs(ids)
 	default:
 		// do nothing
 	}
@@ -621,7 +657,10 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		txRbInodeTree  = NewBtree()
 		txRbDentryTree = NewBtree()
 		uniqChecker    = newUniqChecker()
+		fileLocks      = newFileLockTable()
+		extentRefs     = newExtentRefTable()
 		verList        []*proto.VolVersionInfo
+		kvSst          *kvSstReceiver
 	)
 
 	blockUntilStoreSnapshot := func() {
@@ -662,8 +701,26 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		}
 	}
 
+	defer func() {
+		if kvSst != nil {
+			kvSst.close()
+		}
+	}()
+
 	defer func() {
 		if err == io.EOF {
+			if kvSst != nil {
+				if err = kvSst.ingest(); err != nil {
+					log.LogErrorf("ApplySnapshot: ingest sst files failed: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+					return
+				}
+			}
+			for _, tree := range []*BTree{inodeTree, dentryTree, extendTree, multipartTree} {
+				if err = tree.FinishBulk(); err != nil {
+					log.LogErrorf("ApplySnapshot: write kv store failed: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+					return
+				}
+			}
 			mp.applyID = appIndexID
 			mp.config.UniqId = uniqID
 			mp.txProcessor.txManager.txIdAlloc.setTransactionID(txID)
@@ -676,6 +733,8 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			mp.txProcessor.txResource.txRbInodeTree = txRbInodeTree
 			mp.txProcessor.txResource.txRbDentryTree = txRbDentryTree
 			mp.uniqChecker = uniqChecker
+			mp.fileLocks = fileLocks
+			mp.extentRefs = extentRefs
 			mp.multiVersionList.VerList = make([]*proto.VolVersionInfo, len(verList))
 			copy(mp.multiVersionList.VerList, verList)
 			mp.verSeq = mp.multiVersionList.GetLastVer()
@@ -695,6 +754,9 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				txRbDentryTree: mp.txProcessor.txResource.txRbDentryTree.GetTree(),
 				uniqChecker:    uniqChecker.clone(),
 				multiVerList:   mp.GetVerList(),
+				fileLocks:      fileLocks.clone(),
+				extentRefs:     extentRefs.clone(),
+				kvCheckpoint:   mp.checkpointKvTrees(),
 			}
 			select {
 			case mp.extReset <- struct{}{}:
@@ -711,6 +773,16 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		log.LogErrorf("ApplySnapshot: stop with error: partitionID(%v) err(%v)", mp.config.PartitionId, err)
 	}()
 
+	if mp.kvStore != nil {
+		// rocksdb is cleared here, the partition keeps the former trees but can not serve until the
+		// snapshot is applied
+		var trees []*BTree
+		if trees, err = mp.resetKvTrees(); err != nil {
+			return
+		}
+		inodeTree, dentryTree, extendTree, multipartTree = trees[0], trees[1], trees[2], trees[3]
+	}
+
 	var leaderSnapFormatVer uint32
 	leaderSnapFormatVer = math.MaxUint32
 
@@ -836,12 +908,50 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			}
 			log.LogDebugf("ApplySnapshot: write snap extent delete file: partitonID(%v) filename(%v).",
 				mp.config.PartitionId, fileName)
+		case opFSMKvSstFile:
+			if kvSst == nil {
+				if mp.kvStore == nil {
+					err = fmt.Errorf("receive sst file %v in store mode %v", string(snap.K), mp.config.StoreMode)
+					return
+				}
+				if kvSst, err = newKvSstReceiver(mp.config.RootDir, []*BTree{inodeTree, dentryTree, extendTree, multipartTree}); err != nil {
+					return
+				}
+			}
+			if err = kvSst.create(string(snap.K), binary.BigEndian.Uint64(snap.V)); err != nil {
+				log.LogErrorf("ApplySnapshot: create sst file fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+				return
+			}
+			log.LogDebugf("ApplySnapshot: create sst file: partitionID(%v) name(%v)", mp.config.PartitionId, string(snap.K))
+		case opFSMKvSstChunk:
+			if kvSst == nil {
+				err = fmt.Errorf("sst file %v is not created", string(snap.K))
+				return
+			}
+			if err = kvSst.write(string(snap.K), snap.V); err != nil {
+				log.LogErrorf("ApplySnapshot: write sst file fail: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+				return
+			}
 		case opFSMUniqCheckerSnap:
 			if err = uniqChecker.UnMarshal(snap.V); err != nil {
 				log.LogErrorf("ApplyUniqChecker: write snap uniqChecker fail")
 				return
 			}
 			log.LogDebugf("ApplySnapshot: write snap uniqChecker")
+		case opFSMFileLockSnap:
+			if err = fileLocks.UnMarshal(snap.V); err != nil {
+				log.LogErrorf("ApplySnapshot: unmarshal file locks fail: partitionID(%v) err(%v)",
+					mp.config.PartitionId, err)
+				return
+			}
+			log.LogDebugf("ApplySnapshot: write snap file locks: partitionID(%v)", mp.config.PartitionId)
+		case opFSMExtentRefSnap:
+			if err = extentRefs.UnMarshal(snap.V); err != nil {
+				log.LogErrorf("ApplySnapshot: unmarshal extent refs fail: partitionID(%v) err(%v)",
+					mp.config.PartitionId, err)
+				return
+			}
+			log.LogDebugf("ApplySnapshot: write snap extent refs: partitionID(%v)", mp.config.PartitionId)
 
 		default:
 			if leaderSnapFormatVer != math.MaxUint32 && leaderSnapFormatVer > mp.manager.metaNode.raftSyncSnapFormatVersion {
@@ -256,6 +256,9 @@ func (mp *metaPartition) IsEquareCreateMetaPartitionRequst(request *proto.Create
 	if mp.config.VolName != request.VolName {
 		return fmt.Errorf("Exsit unavali Partition(%v) VolName(%v) requestVolName(%v)", mp.config.PartitionId, mp.config.VolName, request.VolName)
 	}
+	if mp.config.StoreMode != request.StoreMode {
+		return fmt.Errorf("Exsit unavali Partition(%v) StoreMode(%v) requestStoreMode(%v)", mp.config.PartitionId, mp.config.StoreMode, request.StoreMode)
+	}
 
 	return
 }
@@ -65,7 +65,10 @@ func (mp *metaPartition) fsmTxCreateInode(txIno *TxInode, quotaIds []uint32) (st
 		}
 	}()
 	// 3.insert inode in inode tree
-	return mp.fsmCreateInode(txIno.Inode)
+	if status = mp.fsmCreateInode(txIno.Inode); status == proto.OpOk {
+		mp.ownerQuotaMgr.updateUsedInfo(0, 1, txIno.Inode.Uid, txIno.Inode.Gid)
+	}
+	return
 }
 
 // Create and inode and attach it to the inode tree.
@@ -332,12 +335,12 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode, uniqID uint64) (resp *InodeR
 		if ino.NLink < 2 { // snapshot deletion
 			log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] really be deleted, empty dir", mp.config.PartitionId, inode)
 			mp.inodeTree.Delete(inode)
-			mp.updateUsedInfo(0, -1, inode.Inode)
+			mp.updateUsedInfo(0, -1, inode)
 		}
 	} else if inode.IsTempFile() {
 		// all snapshot between create to last deletion cleaned
 		if inode.NLink == 0 && inode.getLayerLen() == 0 {
-			mp.updateUsedInfo(-1*int64(inode.Size), -1, inode.Inode)
+			mp.updateUsedInfo(-1*int64(inode.Size), -1, inode)
 			log.LogDebugf("action[fsmUnlinkInode] mp[%v] unlink inode[%v] and push to freeList", mp.config.PartitionId, inode)
 			inode.AccessTime = time.Now().Unix()
 			mp.freeList.Push(inode.Inode)
@@ -349,7 +352,7 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode, uniqID uint64) (resp *InodeR
 	if len(ext2Del) > 0 {
 		log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] DecSplitExts ext2Del %v", mp.config.PartitionId, ino, ext2Del)
 		inode.DecSplitExts(mp.config.PartitionId, ext2Del)
-		mp.extDelCh <- ext2Del
+		mp.sendExtentsToDelete(inode, ext2Del)
 	}
 	log.LogDebugf("action[fsmUnlinkInode] mp[%v] ino[%v] left", mp.config.PartitionId, inode)
 	return
@@ -435,13 +438,13 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 		return
 	}
 	delExtents := ino2.AppendExtents(eks, ino.ModifyTime, mp.volType)
-	mp.updateUsedInfo(int64(ino2.Size)-oldSize, 0, ino2.Inode)
+	mp.updateUsedInfo(int64(ino2.Size)-oldSize, 0, ino2)
 	log.LogInfof("fsmAppendExtents mpId[%v].inode[%v] deleteExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	mp.uidManager.minusUidSpace(ino2.Uid, ino2.Inode, delExtents)
 
 	log.LogInfof("fsmAppendExtents mpId[%v].inode[%v] DecSplitExts deleteExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	ino2.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.sendExtentsToDelete(ino2, delExtents)
 	return
 }
 
@@ -503,7 +506,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		if status == proto.OpOk {
 			log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 			fsmIno.DecSplitExts(appendExtParam.mpId, delExtents)
-			mp.extDelCh <- delExtents
+			mp.sendExtentsToDelete(fsmIno, delExtents)
 		}
 		// conflict need delete eks[0], to clear garbage data
 		if status == proto.OpConflictExtentsErr {
@@ -520,7 +523,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		delExtents, status = fsmIno.SplitExtentWithCheck(appendExtParam)
 		log.LogInfof("action[fsmAppendExtentsWithCheck] mp[%v] DecSplitExts delExtents [%v]", mp.config.PartitionId, delExtents)
 		fsmIno.DecSplitExts(mp.config.PartitionId, delExtents)
-		mp.extDelCh <- delExtents
+		mp.sendExtentsToDelete(fsmIno, delExtents)
 		mp.uidManager.minusUidSpace(fsmIno.Uid, fsmIno.Inode, delExtents)
 	}
 
@@ -531,7 +534,7 @@ func (mp *metaPartition) fsmAppendExtentsWithCheck(ino *Inode, isSplit bool) (st
 		log.LogDebugf("fsmAppendExtentsWithCheck mp[%v] delExtents inode[%v] ek(%v)", mp.config.PartitionId, fsmIno.Inode, delExtents)
 	}
 
-	mp.updateUsedInfo(int64(fsmIno.Size)-oldSize, 0, fsmIno.Inode)
+	mp.updateUsedInfo(int64(fsmIno.Size)-oldSize, 0, fsmIno)
 	log.LogInfof("fsmAppendExtentWithCheck mp[%v] inode[%v] ek(%v) deleteExtents(%v) discardExtents(%v) status(%v)",
 		mp.config.PartitionId, fsmIno.Inode, eks[0], delExtents, discardExtentKey, status)
 
@@ -611,16 +614,199 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 	if delExtents, err = i.RestoreExts2NextLayer(mp.config.PartitionId, delExtents, mp.verSeq, 0); err != nil {
 		panic("RestoreExts2NextLayer should not be error")
 	}
-	mp.updateUsedInfo(int64(i.Size)-oldSize, 0, i.Inode)
+	mp.updateUsedInfo(int64(i.Size)-oldSize, 0, i)
 
 	// now we should delete the extent
 	log.LogInfof("fsmExtentsTruncate.mp (%v) inode[%v] DecSplitExts exts(%v)", mp.config.PartitionId, i.Inode, delExtents)
 	i.DecSplitExts(mp.config.PartitionId, delExtents)
-	mp.extDelCh <- delExtents
+	mp.sendExtentsToDelete(i, delExtents)
 	mp.uidManager.minusUidSpace(i.Uid, i.Inode, delExtents)
 	return
 }
 
+// fsmExtentsDelete punches a hole in the file, the extents within the hole are deleted
+// and the file size is left unchanged. The request with a generation fails with OpArgMismatchErr
+// if the file has been modified since the generation.
+func (mp *metaPartition) fsmExtentsDelete(req *fsmExtentsDelRequest) (status uint8) {
+	status = proto.OpOk
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	i := item.(*Inode)
+	if i.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	if proto.IsDir(i.Type) {
+		return proto.OpArgMismatchErr
+	}
+
+	if i.getVer() != mp.verSeq {
+		i.CreateVer(mp.verSeq)
+	}
+	i.Lock()
+	defer i.Unlock()
+	if req.Generation != 0 && i.Generation != req.Generation {
+		return proto.OpArgMismatchErr
+	}
+
+	if err := i.CreateLowerVersion(i.getVer(), mp.multiVersionList); err != nil {
+		return
+	}
+	delExtents := i.Extents.PunchHole(req.Offset, req.Size, func(ek *proto.ExtentKey) {
+		i.insertEkRefMap(mp.config.PartitionId, ek)
+	})
+	i.ModifyTime = req.ModifyTime
+	i.Generation++
+	if len(delExtents) == 0 {
+		return
+	}
+
+	var err error
+	if delExtents, err = i.RestoreExts2NextLayer(mp.config.PartitionId, delExtents, mp.verSeq, 0); err != nil {
+		panic("RestoreExts2NextLayer should not be error")
+	}
+	log.LogInfof("fsmExtentsDelete.mp (%v) inode[%v] range[%v,%v) DecSplitExts exts(%v)",
+		mp.config.PartitionId, i.Inode, req.Offset, req.Offset+req.Size, delExtents)
+	i.DecSplitExts(mp.config.PartitionId, delExtents)
+	mp.sendExtentsToDelete(i, delExtents)
+	mp.uidManager.minusUidSpace(i.Uid, i.Inode, delExtents)
+	return
+}
+
+// fsmCloneExtents copies a range of the source file to the destination file by sharing
+// the extents. The shared extent keys are counted by the ref maps of both files, and get
+// the cloneExtentSeq in the volume without snapshots, so that the clients write them by
+// appending instead of overwriting in place. The replaced data of the destination is kept
+// by its snapshots.
+func (mp *metaPartition) fsmCloneExtents(req *fsmCloneExtentsRequest) (resp *fsmCloneExtentsResponse) {
+	resp = &fsmCloneExtentsResponse{Status: proto.OpOk}
+	getFile := func(ino uint64) *Inode {
+		item := mp.inodeTree.Get(NewInode(ino, 0))
+		if item == nil {
+			resp.Status = proto.OpNotExistErr
+			return nil
+		}
+		i := item.(*Inode)
+		if i.ShouldDelete() {
+			resp.Status = proto.OpNotExistErr
+			return nil
+		}
+		if !proto.IsRegular(i.Type) {
+			resp.Status = proto.OpArgMismatchErr
+			return nil
+		}
+		return i
+	}
+	src := getFile(req.SrcInode)
+	if src == nil {
+		return
+	}
+	dst := getFile(req.DstInode)
+	if dst == nil {
+		return
+	}
+	if dst.getVer() != mp.verSeq {
+		dst.CreateVer(mp.verSeq)
+	}
+
+	first, second := src, dst
+	if first.Inode > second.Inode {
+		first, second = second, first
+	}
+	first.Lock()
+	defer first.Unlock()
+	if second != first {
+		second.Lock()
+		defer second.Unlock()
+	}
+
+	size := req.Size
+	if req.SrcOffset >= src.Size {
+		return
+	}
+	if req.SrcOffset+size > src.Size {
+		size = src.Size - req.SrcOffset
+	}
+	if src == dst && req.SrcOffset < req.DstOffset+size && req.DstOffset < req.SrcOffset+size {
+		resp.Status = proto.OpArgMismatchErr
+		return
+	}
+
+	eks := src.Extents.CopyRange(req.SrcOffset, size, req.DstOffset)
+	for _, ek := range eks {
+		if storage.IsTinyExtent(ek.ExtentId) {
+			// tiny extents are shared by many files already, they could not be tracked
+			resp.Status = proto.OpArgMismatchErr
+			return
+		}
+	}
+	if err := dst.CreateLowerVersion(dst.getVer(), mp.multiVersionList); err != nil {
+		resp.Status = proto.OpErr
+		return
+	}
+	if resp.Status = mp.uidManager.addUidSpace(dst.Uid, dst.Inode, eks); resp.Status != proto.OpOk {
+		return
+	}
+
+	delExtents := dst.Extents.PunchHole(req.DstOffset, size, func(ek *proto.ExtentKey) {
+		dst.insertEkRefMap(mp.config.PartitionId, ek)
+	})
+	if len(delExtents) > 0 {
+		var err error
+		if delExtents, err = dst.RestoreExts2NextLayer(mp.config.PartitionId, delExtents, mp.verSeq, 0); err != nil {
+			panic("RestoreExts2NextLayer should not be error")
+		}
+		dst.DecSplitExts(mp.config.PartitionId, delExtents)
+		mp.sendExtentsToDelete(dst, delExtents)
+		mp.uidManager.minusUidSpace(dst.Uid, dst.Inode, delExtents)
+	}
+
+	// the keys of the source keep the versions they are written in if the volume has snapshots
+	seq := mp.verSeq
+	if seq == 0 {
+		seq = cloneExtentSeq
+	}
+	src.Extents.UpdateInRange(req.SrcOffset, size, func(ek *proto.ExtentKey) {
+		if mp.verSeq == 0 {
+			ek.SetSeq(seq)
+		}
+		if !ek.IsSplit() {
+			src.insertEkRefMap(mp.config.PartitionId, ek)
+		}
+	})
+	held := extentIdsOf(dst)
+	for idx := range eks {
+		ek := &eks[idx]
+		if _, ok := held[ek.GenerateId()]; !ok {
+			mp.extentRefs.share(ek.GenerateId())
+			held[ek.GenerateId()] = struct{}{}
+		}
+		ek.SetSeq(seq)
+		ek.SetSplit(false)
+		dst.insertEkRefMap(mp.config.PartitionId, ek)
+	}
+	dst.Extents.InsertRange(eks)
+
+	if end := req.DstOffset + size; end > dst.Size {
+		mp.updateUsedInfo(int64(end-dst.Size), 0, dst)
+		dst.Size = end
+	}
+	dst.ModifyTime = req.ModifyTime
+	dst.Generation++
+	resp.Size = size
+	log.LogInfof("fsmCloneExtents: mp(%v) src(%v) range[%v,%v) dst(%v) offset(%v) eks(%v)",
+		mp.config.PartitionId, src.Inode, req.SrcOffset, req.SrcOffset+size, dst.Inode, req.DstOffset, len(eks))
+	return
+}
+
+// fsmDropExtentRefs drops the shared extents held by the inodes deleted by the free list.
+func (mp *metaPartition) fsmDropExtentRefs(ids []uint64) {
+	for _, id := range ids {
+		mp.extentRefs.drop(id)
+	}
+}
+
 func (mp *metaPartition) fsmEvictInode(ino *Inode) (resp *InodeResponse) {
 	resp = NewInodeResponse()
 	log.LogDebugf("action[fsmEvictInode] inode[%v]", ino)
@@ -687,7 +873,13 @@ func (mp *metaPartition) fsmSetAttr(req *SetattrRequest) (err error) {
 	if ino.ShouldDelete() {
 		return
 	}
+	uid, gid := ino.Uid, ino.Gid
 	ino.SetAttr(req)
+	if (uid != ino.Uid || gid != ino.Gid) && !ino.IsTempFile() {
+		// move the usage to the new owner
+		mp.ownerQuotaMgr.updateUsedInfo(-1*int64(ino.Size), -1, uid, gid)
+		mp.ownerQuotaMgr.updateUsedInfo(int64(ino.Size), 1, ino.Uid, ino.Gid)
+	}
 	return
 }
 
@@ -770,7 +962,7 @@ func (mp *metaPartition) fsmClearInodeCache(ino *Inode) (status uint8) {
 	log.LogInfof("fsmClearInodeCache.mp[%v] inode[%v] DecSplitExts delExtents(%v)", mp.config.PartitionId, ino2.Inode, delExtents)
 	if len(delExtents) > 0 {
 		ino2.DecSplitExts(mp.config.PartitionId, delExtents)
-		mp.extDelCh <- delExtents
+		mp.sendExtentsToDelete(ino2, delExtents)
 	}
 	return
 }
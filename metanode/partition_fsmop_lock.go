@@ -0,0 +1,51 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The time of the leader is carried by the requests, so that the locks expire
+// at the same point on all the replicas.
+type fsmSetLockRequest struct {
+	Inode uint64         `json:"ino"`
+	Lock  proto.FileLock `json:"lock"`
+	Now   int64          `json:"now"`
+}
+
+type fsmRenewLockRequest struct {
+	ClientID uint64 `json:"cid"`
+	Expire   int64  `json:"expire"`
+	Now      int64  `json:"now"`
+}
+
+func (mp *metaPartition) fsmSetLock(req *fsmSetLockRequest) (conflict *proto.FileLock) {
+	conflict = mp.fileLocks.setLock(req.Inode, &req.Lock, req.Now)
+	if log.EnableDebug() {
+		log.LogDebugf("fsmSetLock: mp(%v) ino(%v) lock(%v) conflict(%v)",
+			mp.config.PartitionId, req.Inode, &req.Lock, conflict)
+	}
+	return
+}
+
+func (mp *metaPartition) fsmRenewLock(req *fsmRenewLockRequest) {
+	renewed := mp.fileLocks.renew(req.ClientID, req.Expire, req.Now)
+	if log.EnableDebug() {
+		log.LogDebugf("fsmRenewLock: mp(%v) client(%v) renewed(%v) left(%v)",
+			mp.config.PartitionId, req.ClientID, renewed, mp.fileLocks.count())
+	}
+}
@@ -131,6 +131,15 @@ const (
 
 	// version since transaction feature, added formatVersion, txId and cursor in MetaItemIterator struct
 	SnapFormatVersion_1
+
+	// version since file lock feature, added the file lock table
+	SnapFormatVersion_2
+
+	// version since extent clone feature, added the shared extent table
+	SnapFormatVersion_3
+
+	// version since rocksdb store mode, the trees kept in rocksdb are sent as sst files
+	SnapFormatVersion_4
 )
 
 // MetaItemIterator defines the iterator of the MetaItem.
@@ -149,7 +158,10 @@ type MetaItemIterator struct {
 	txRbInodeTree     *BTree
 	txRbDentryTree    *BTree
 	uniqChecker       *uniqChecker
+	fileLocks         *fileLockTable
+	extentRefs        *extentRefTable
 	verList           []*proto.VolVersionInfo
+	kvSst             bool
 
 	filenames []string
 
@@ -187,6 +199,9 @@ func (siw *SnapItemWrapper) UnmarshalKey(k []byte) (err error) {
 }
 
 // newMetaItemIterator returns a new MetaItemIterator.
+// In MetaStoreModeRocksDB the trees are pinned on a rocksdb snapshot instead of being copied in
+// memory, and since SnapFormatVersion_4 they are written to sst files which the follower ingests
+// into its rocksdb, see sendKvSstFiles.
 func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	si = new(MetaItemIterator)
 	si.fileRootDir = mp.config.RootDir
@@ -196,16 +211,19 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	si.txId = mp.txProcessor.txManager.txIdAlloc.getTransactionID()
 	si.cursor = mp.GetCursor()
 	si.uniqID = mp.GetUniqId()
-	si.inodeTree = mp.inodeTree.GetTree()
-	si.dentryTree = mp.dentryTree.GetTree()
-	si.extendTree = mp.extendTree.GetTree()
-	si.multipartTree = mp.multipartTree.GetTree()
+	si.inodeTree = mp.inodeTree.PinTree()
+	si.dentryTree = mp.dentryTree.PinTree()
+	si.extendTree = mp.extendTree.PinTree()
+	si.multipartTree = mp.multipartTree.PinTree()
 	si.txTree = mp.txProcessor.txManager.txTree.GetTree()
 	si.txRbInodeTree = mp.txProcessor.txResource.txRbInodeTree.GetTree()
 	si.txRbDentryTree = mp.txProcessor.txResource.txRbDentryTree.GetTree()
 	si.uniqChecker = mp.uniqChecker.clone()
+	si.fileLocks = mp.fileLocks.clone()
+	si.extentRefs = mp.extentRefs.clone()
 	si.verList = mp.GetAllVerList()
 	mp.nonIdempotent.Unlock()
+	si.kvSst = mp.kvStore != nil && si.SnapFormatVersion >= SnapFormatVersion_4
 
 	si.dataCh = make(chan interface{})
 	si.errorCh = make(chan error, 1)
@@ -215,6 +233,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	filenames := make([]string, 0)
 	var fileInfos []os.DirEntry
 	if fileInfos, err = os.ReadDir(mp.config.RootDir); err != nil {
+		si.releaseTrees()
 		return
 	}
 
@@ -231,6 +250,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	// start data producer
 	go func(iter *MetaItemIterator) {
 		defer func() {
+			iter.releaseTrees()
 			close(iter.dataCh)
 			close(iter.errorCh)
 		}()
@@ -262,7 +282,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			produceItem(si.applyID)
 			log.LogDebugf("newMetaItemIterator: SnapFormatVersion_0, partitionId(%v), applyID(%v)",
 				mp.config.PartitionId, si.applyID)
-		} else if si.SnapFormatVersion == SnapFormatVersion_1 {
+		} else if si.SnapFormatVersion >= SnapFormatVersion_1 && si.SnapFormatVersion <= SnapFormatVersion_4 {
 			// process snapshot format version
 			snapFormatVerWrapper := SnapItemWrapper{SiwKeySnapFormatVer, si.SnapFormatVersion}
 			produceItem(snapFormatVerWrapper)
@@ -282,8 +302,8 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			verListWrapper := SnapItemWrapper{SiwKeyVerList, si.verList}
 			produceItem(verListWrapper)
 
-			log.LogDebugf("newMetaItemIterator: SnapFormatVersion_1, partitionId(%v) applyID(%v) txId(%v) cursor(%v) uniqID(%v) verList(%v)",
-				mp.config.PartitionId, si.applyID, si.txId, si.cursor, si.uniqID, si.verList)
+			log.LogDebugf("newMetaItemIterator: SnapFormatVersion(%v), partitionId(%v) applyID(%v) txId(%v) cursor(%v) uniqID(%v) verList(%v)",
+				si.SnapFormatVersion, mp.config.PartitionId, si.applyID, si.txId, si.cursor, si.uniqID, si.verList)
 
 			if si.uniqID != 0 {
 				// process uniqId
@@ -294,36 +314,48 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			panic(fmt.Sprintf("invalid raftSyncSnapFormatVersione: %v", si.SnapFormatVersion))
 		}
 
-		// process inodes
-		iter.inodeTree.Ascend(func(i BtreeItem) bool {
-			return produceItem(i)
-		})
-		if checkClose() {
-			return
-		}
-		// process dentries
-		iter.dentryTree.Ascend(func(i BtreeItem) bool {
-			return produceItem(i)
-		})
-		if checkClose() {
-			return
-		}
-		// process extends
-		iter.extendTree.Ascend(func(i BtreeItem) bool {
-			return produceItem(i)
-		})
-		if checkClose() {
-			return
-		}
-		// process multiparts
-		iter.multipartTree.Ascend(func(i BtreeItem) bool {
-			return produceItem(i)
-		})
-		if checkClose() {
-			return
+		if iter.kvSst {
+			// process the sst files of inodes, dentries, extends and multiparts
+			trees := []*BTree{iter.inodeTree, iter.dentryTree, iter.extendTree, iter.multipartTree}
+			if err := sendKvSstFiles(iter.fileRootDir, trees, produceItem); err != nil {
+				produceError(err)
+				return
+			}
+			if checkClose() {
+				return
+			}
+		} else {
+			// process inodes
+			iter.inodeTree.Ascend(func(i BtreeItem) bool {
+				return produceItem(i)
+			})
+			if checkClose() {
+				return
+			}
+			// process dentries
+			iter.dentryTree.Ascend(func(i BtreeItem) bool {
+				return produceItem(i)
+			})
+			if checkClose() {
+				return
+			}
+			// process extends
+			iter.extendTree.Ascend(func(i BtreeItem) bool {
+				return produceItem(i)
+			})
+			if checkClose() {
+				return
+			}
+			// process multiparts
+			iter.multipartTree.Ascend(func(i BtreeItem) bool {
+				return produceItem(i)
+			})
+			if checkClose() {
+				return
+			}
 		}
 
-		if si.SnapFormatVersion == SnapFormatVersion_1 {
+		if si.SnapFormatVersion >= SnapFormatVersion_1 {
 			iter.txTree.Ascend(func(i BtreeItem) bool {
 				return produceItem(i)
 			})
@@ -353,6 +385,20 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 			}
 		}
 
+		if si.SnapFormatVersion >= SnapFormatVersion_2 {
+			produceItem(si.fileLocks)
+			if checkClose() {
+				return
+			}
+		}
+
+		if si.SnapFormatVersion >= SnapFormatVersion_3 {
+			produceItem(si.extentRefs)
+			if checkClose() {
+				return
+			}
+		}
+
 		// process extent del files
 		var err error
 		var raw []byte
@@ -375,6 +421,14 @@ func (si *MetaItemIterator) ApplyIndex() uint64 {
 	return si.applyID
 }
 
+// releaseTrees releases the trees pinned by the iterator.
+func (si *MetaItemIterator) releaseTrees() {
+	si.inodeTree.Release()
+	si.dentryTree.Release()
+	si.extendTree.Release()
+	si.multipartTree.Release()
+}
+
 // Close closes the iterator.
 func (si *MetaItemIterator) Close() {
 	si.closeOnce.Do(func() {
@@ -476,6 +530,12 @@ func (si *MetaItemIterator) Next() (data []byte, err error) {
 		snap = NewMetaItem(opFSMTxRbDentrySnapshot, []byte(typedItem.txDentryInfo.GetKey()), val)
 	case *fileData:
 		snap = NewMetaItem(opExtentFileSnapshot, []byte(typedItem.filename), typedItem.data)
+	case *kvSstFile:
+		countBuf := make([]byte, 8)
+		binary.BigEndian.PutUint64(countBuf, typedItem.count)
+		snap = NewMetaItem(opFSMKvSstFile, []byte(typedItem.name), countBuf)
+	case *kvSstChunk:
+		snap = NewMetaItem(opFSMKvSstChunk, []byte(typedItem.name), typedItem.data)
 	case *uniqChecker:
 		var raw []byte
 		if raw, _, err = typedItem.Marshal(); err != nil {
@@ -484,6 +544,22 @@ func (si *MetaItemIterator) Next() (data []byte, err error) {
 			return
 		}
 		snap = NewMetaItem(opFSMUniqCheckerSnap, nil, raw)
+	case *fileLockTable:
+		var raw []byte
+		if raw, _, err = typedItem.Marshal(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMFileLockSnap, nil, raw)
+	case *extentRefTable:
+		var raw []byte
+		if raw, _, err = typedItem.Marshal(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMExtentRefSnap, nil, raw)
 	default:
 		panic(fmt.Sprintf("unknown item type: %v", reflect.TypeOf(item).Name()))
 	}
@@ -46,6 +46,11 @@ func (mp *metaPartition) CheckQuota(inodeId uint64, p *Packet) (iParm *Inode, in
 		return
 	}
 	inode = item.(*Inode)
+	if status = mp.isOverOwnerQuota(inode.Uid, inode.Gid, true, false); status != 0 {
+		err = errors.New("CheckQuota owner quota is over quota")
+		p.PacketErrorWithBody(status, []byte(err.Error()))
+		return
+	}
 	mp.uidManager.acLock.Lock()
 	if mp.uidManager.getUidAcl(inode.Uid) {
 		log.LogWarnf("CheckQuota UidSpace.volname [%v] mp[%v] uid %v be set full", mp.uidManager.mpID, mp.uidManager.volName, inode.Uid)
@@ -485,6 +490,9 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet, rem
 	}
 	i := item.(*Inode)
 	status := mp.isOverQuota(req.Inode, req.Size > i.Size, false)
+	if status == 0 {
+		status = mp.isOverOwnerQuota(i.Uid, i.Gid, req.Size > i.Size, false)
+	}
 	if status != 0 {
 		log.LogErrorf("ExtentsTruncate fail status [%v]", status)
 		err = errors.New("ExtentsTruncate is over quota")
@@ -571,27 +579,125 @@ func (mp *metaPartition) BatchObjExtentAppend(req *proto.AppendObjExtentKeysRequ
 	return
 }
 
-// func (mp *metaPartition) ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error) {
-// 	ino := NewInode(req.Inode, 0)
-// 	inode := mp.inodeTree.Get(ino).(*Inode)
-// 	inode.Extents.Delete(req.Extents)
-// 	curTime := timeutil.GetCurrentTimeUnix()
-// 	if inode.ModifyTime < curTime {
-// 		inode.ModifyTime = curTime
-// 	}
-// 	val, err := inode.Marshal()
-// 	if err != nil {
-// 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
-// 		return
-// 	}
-// 	resp, err := mp.submit(opFSMExtentsDel, val)
-// 	if err != nil {
-// 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
-// 		return
-// 	}
-// 	p.PacketErrorWithBody(resp.(uint8), nil)
-// 	return
-// }
+// cloneExtentSeq is the sequence of the extent keys shared by clones in the volume without
+// snapshots. It differs from the sequence of the volume, so the clients never overwrite the
+// shared extents in place, and it is less than any snapshot version. The keys shared in the
+// volume with snapshots keep the version, and are told by the split flag instead.
+const cloneExtentSeq uint64 = 1
+
+type fsmExtentsDelRequest struct {
+	Inode      uint64 `json:"ino"`
+	Offset     uint64 `json:"off"`
+	Size       uint64 `json:"sz"`
+	ModifyTime int64  `json:"mt"`
+	Generation uint64 `json:"gen,omitempty"`
+}
+
+type fsmCloneExtentsRequest struct {
+	SrcInode   uint64 `json:"src"`
+	DstInode   uint64 `json:"dst"`
+	SrcOffset  uint64 `json:"soff"`
+	DstOffset  uint64 `json:"doff"`
+	Size       uint64 `json:"sz"`
+	ModifyTime int64  `json:"mt"`
+}
+
+type fsmCloneExtentsResponse struct {
+	Status uint8
+	Size   uint64
+}
+
+// ExtentsDelete punches a hole in the file.
+func (mp *metaPartition) ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet, remoteAddr string) (err error) {
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), "", err, time.Since(start).Milliseconds(), req.Inode, req.Size)
+		}()
+	}
+	if mp.inodeTree.Get(NewInode(req.Inode, 0)) == nil {
+		err = fmt.Errorf("inode[%v] is not exist", req.Inode)
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte(err.Error()))
+		return
+	}
+	if req.Size == 0 {
+		p.PacketOkReply()
+		return
+	}
+
+	val, err := json.Marshal(&fsmExtentsDelRequest{
+		Inode:      req.Inode,
+		Offset:     req.Offset,
+		Size:       req.Size,
+		ModifyTime: time.Now().Unix(),
+		Generation: req.Generation,
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMExtentsDel, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// CloneExtents copies a range of the source file to the destination file by sharing the extents.
+func (mp *metaPartition) CloneExtents(req *proto.CloneExtentsRequest, p *Packet, remoteAddr string) (err error) {
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	start := time.Now()
+	if mp.IsEnableAuditLog() {
+		defer func() {
+			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), "", err, time.Since(start).Milliseconds(), req.DstInode, req.Size)
+		}()
+	}
+	if _, _, err = mp.CheckQuota(req.DstInode, p); err != nil {
+		log.LogErrorf("CloneExtents fail status [%v]", err)
+		return
+	}
+
+	val, err := json.Marshal(&fsmCloneExtentsRequest{
+		SrcInode:   req.SrcInode,
+		DstInode:   req.DstInode,
+		SrcOffset:  req.SrcOffset,
+		DstOffset:  req.DstOffset,
+		Size:       req.Size,
+		ModifyTime: time.Now().Unix(),
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	r, err := mp.submit(opFSMCloneExtents, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	resp := r.(*fsmCloneExtentsResponse)
+	if resp.Status != proto.OpOk {
+		p.PacketErrorWithBody(resp.Status, nil)
+		return
+	}
+	reply, err := json.Marshal(&proto.CloneExtentsResponse{Size: resp.Size})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
 
 // ExtentsEmpty only use in datalake situation
 func (mp *metaPartition) ExtentsOp(p *Packet, ino *Inode, op uint32) (err error) {
@@ -115,6 +115,11 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet, remoteAddr st
 			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.GetFullPath(), err, time.Since(start).Milliseconds(), inoID, 0)
 		}()
 	}
+	if st := mp.isOverOwnerQuota(req.Uid, req.Gid, false, true); st != 0 {
+		err = errors.New("create inode is over owner quota")
+		p.PacketErrorWithBody(st, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -169,6 +174,11 @@ func (mp *metaPartition) QuotaCreateInode(req *proto.QuotaCreateInodeRequest, p
 			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.GetFullPath(), err, time.Since(start).Milliseconds(), inoID, 0)
 		}()
 	}
+	if st := mp.isOverOwnerQuota(req.Uid, req.Gid, false, true); st != 0 {
+		err = errors.New("create inode is over owner quota")
+		p.PacketErrorWithBody(st, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -872,6 +882,11 @@ func (mp *metaPartition) TxCreateInode(req *proto.TxCreateInodeRequest, p *Packe
 			auditlog.LogInodeOp(remoteAddr, mp.GetVolName(), p.GetOpMsg(), req.GetFullPath(), err, time.Since(start).Milliseconds(), inoID, 0)
 		}()
 	}
+	if st := mp.isOverOwnerQuota(req.Uid, req.Gid, false, true); st != 0 {
+		err = errors.New("create inode is over owner quota")
+		p.PacketErrorWithBody(st, []byte(err.Error()))
+		return
+	}
 	inoID, err = mp.nextInodeID()
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpInodeFullErr, []byte(err.Error()))
@@ -0,0 +1,113 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func lockLease(lease int64) time.Duration {
+	if lease <= 0 {
+		lease = proto.DefaultFileLockLease
+	}
+	return time.Duration(lease) * time.Second
+}
+
+// SetLock acquires or releases a file lock, the conflicting lock is replied if the lock is held by others.
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	lock := req.Lock
+	if lock.Type > proto.FileLockUnlock || lock.Start > lock.End {
+		err = fmt.Errorf("invalid lock %v", &lock)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	lock.Expire = now.Add(lockLease(req.Lease)).UnixNano()
+	resp := &proto.SetLockResponse{}
+	// avoid the raft round trip if nothing changes, the conflict is checked again while applying
+	if lock.Type != proto.FileLockUnlock {
+		resp.Conflict = mp.fileLocks.getConflict(req.Inode, &lock, now.UnixNano())
+	}
+	if resp.Conflict == nil && (lock.Type != proto.FileLockUnlock || mp.fileLocks.held(req.Inode, &lock, now.UnixNano())) {
+		fsmReq := &fsmSetLockRequest{
+			Inode: req.Inode,
+			Lock:  lock,
+			Now:   now.UnixNano(),
+		}
+		var val []byte
+		if val, err = json.Marshal(fsmReq); err != nil {
+			p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+			return
+		}
+		var r interface{}
+		if r, err = mp.submit(opFSMSetLock, val); err != nil {
+			p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+			return
+		}
+		resp.Conflict = r.(*proto.FileLock)
+	}
+
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// GetLock replies the lock which conflicts with the given one, or nil if there is none.
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	resp := &proto.GetLockResponse{
+		Lock: mp.fileLocks.getConflict(req.Inode, &req.Lock, time.Now().UnixNano()),
+	}
+	var reply []byte
+	if reply, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// RenewLock extends the lease of all the file locks held by the client in the partition.
+func (mp *metaPartition) RenewLock(req *proto.RenewLockRequest, p *Packet) (err error) {
+	if !mp.fileLocks.holdBy(req.ClientID) {
+		p.PacketOkReply()
+		return
+	}
+	now := time.Now()
+	fsmReq := &fsmRenewLockRequest{
+		ClientID: req.ClientID,
+		Expire:   now.Add(lockLease(req.Lease)).UnixNano(),
+		Now:      now.UnixNano(),
+	}
+	var val []byte
+	if val, err = json.Marshal(fsmReq); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	if _, err = mp.submit(opFSMRenewLock, val); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketOkReply()
+	return
+}
@@ -77,6 +77,14 @@ func (mp *metaPartition) getQuotaReportInfos() (infos []*proto.QuotaReportInfo)
 	return mp.mqMgr.getQuotaReportInfos()
 }
 
+func (mp *metaPartition) setOwnerQuotaHbInfo(enableVols []string, infos []*proto.OwnerQuotaHeartBeatInfo) {
+	mp.ownerQuotaMgr.setOwnerQuotaHbInfo(enableVols, infos)
+}
+
+func (mp *metaPartition) getOwnerQuotaReportInfos() (infos []*proto.OwnerQuotaReportInfo) {
+	return mp.ownerQuotaMgr.getOwnerQuotaReportInfos()
+}
+
 func (mp *metaPartition) statisticExtendByLoad(extend *Extend) {
 	mqMgr := mp.mqMgr
 	ino := NewInode(extend.GetInode(), 0)
@@ -151,10 +159,11 @@ func (mp *metaPartition) statisticExtendByStore(extend *Extend, inodeTree *BTree
 	log.LogDebugf("statisticExtendByStore mp[%v] inode[%v] success.", mp.config.PartitionId, extend.GetInode())
 }
 
-func (mp *metaPartition) updateUsedInfo(size int64, files int64, ino uint64) {
-	quotaIds, isFind := mp.isExistQuota(ino)
+func (mp *metaPartition) updateUsedInfo(size int64, files int64, ino *Inode) {
+	mp.ownerQuotaMgr.updateUsedInfo(size, files, ino.Uid, ino.Gid)
+	quotaIds, isFind := mp.isExistQuota(ino.Inode)
 	if isFind {
-		log.LogInfof("updateUsedInfo ino[%v] quotaIds [%v] size [%v] files [%v]", ino, quotaIds, size, files)
+		log.LogInfof("updateUsedInfo ino[%v] quotaIds [%v] size [%v] files [%v]", ino.Inode, quotaIds, size, files)
 		for _, quotaId := range quotaIds {
 			mp.mqMgr.updateUsedInfo(size, files, quotaId)
 		}
@@ -206,6 +215,16 @@ func (mp *metaPartition) isOverQuota(ino uint64, size bool, files bool) (status
 	return
 }
 
+// isOverOwnerQuota checks the user and group quota of the owner of an inode.
+func (mp *metaPartition) isOverOwnerQuota(uid, gid uint32, size bool, files bool) (status uint8) {
+	status = mp.ownerQuotaMgr.IsOverQuota(size, files, uid, gid)
+	if status != 0 {
+		log.LogWarnf("isOverOwnerQuota mp[%v] uid [%v] gid [%v] size [%v] files[%v] status[%v]",
+			mp.config.PartitionId, uid, gid, size, files, status)
+	}
+	return
+}
+
 func (mp *metaPartition) getInodeQuota(inode uint64, p *Packet) (err error) {
 	extend := NewExtend(inode)
 	quotaInfos := &proto.MetaQuotaInfos{
@@ -127,6 +127,95 @@ func TestGetQuotaReportInfos(t *testing.T) {
 	require.Equal(t, info, infos[0])
 }
 
+func TestOwnerQuotaUsedInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mp := mockPartitionRaftForQuotaTest(mockCtrl)
+	mp.uidManager = NewUidMgr(VolNameForTest, PartitionIdForTest)
+	user := func(id uint32) proto.OwnerQuotaKey {
+		return proto.OwnerQuotaKey{Type: proto.OwnerQuotaTypeUser, Id: id}
+	}
+	group := func(id uint32) proto.OwnerQuotaKey {
+		return proto.OwnerQuotaKey{Type: proto.OwnerQuotaTypeGroup, Id: id}
+	}
+
+	ino := NewInode(2, 0)
+	ino.Uid = 10
+	ino.Gid = 20
+	val, err := ino.Marshal()
+	require.NoError(t, err)
+	resp, err := mp.submit(opFSMCreateInode, val)
+	require.NoError(t, err)
+	require.Equal(t, proto.OpOk, resp.(uint8))
+
+	ino = mp.inodeTree.Get(ino).(*Inode)
+	ino.Size = 100
+	mp.updateUsedInfo(100, 0, ino)
+	size, files := mp.ownerQuotaMgr.getUsedInfoForTest(user(10))
+	require.Equal(t, int64(100), size)
+	require.Equal(t, int64(1), files)
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(group(20))
+	require.Equal(t, int64(100), size)
+	require.Equal(t, int64(1), files)
+
+	// chown moves the usage to the new user
+	require.NoError(t, mp.fsmSetAttr(&SetattrRequest{Inode: 2, Valid: proto.AttrUid, Uid: 11}))
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(user(10))
+	require.Equal(t, int64(0), size)
+	require.Equal(t, int64(0), files)
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(user(11))
+	require.Equal(t, int64(100), size)
+	require.Equal(t, int64(1), files)
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(group(20))
+	require.Equal(t, int64(100), size)
+	require.Equal(t, int64(1), files)
+
+	mp.fsmUnlinkInode(NewInode(2, 0), 0)
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(user(11))
+	require.Equal(t, int64(0), size)
+	require.Equal(t, int64(0), files)
+	size, files = mp.ownerQuotaMgr.getUsedInfoForTest(group(20))
+	require.Equal(t, int64(0), size)
+	require.Equal(t, int64(0), files)
+}
+
+func TestOwnerQuotaHbInfo(t *testing.T) {
+	partition := NewMetaPartitionForQuotaTest()
+	oqMgr := partition.ownerQuotaMgr
+	oqMgr.updateUsedInfo(100, 1, 10, 20)
+
+	hbInfos := []*proto.OwnerQuotaHeartBeatInfo{
+		{
+			VolName:     VolNameForTest,
+			Type:        proto.OwnerQuotaTypeUser,
+			Id:          10,
+			LimitedInfo: proto.QuotaLimitedInfo{LimitedBytes: true},
+		},
+		{
+			VolName:     VolNameForTest,
+			Type:        proto.OwnerQuotaTypeGroup,
+			Id:          30,
+			LimitedInfo: proto.QuotaLimitedInfo{LimitedFiles: true},
+		},
+	}
+	// disabled volume is neither limited nor reported
+	oqMgr.setOwnerQuotaHbInfo(nil, hbInfos)
+	require.Equal(t, uint8(0), oqMgr.IsOverQuota(true, true, 10, 20))
+	require.Empty(t, oqMgr.getOwnerQuotaReportInfos())
+
+	oqMgr.setOwnerQuotaHbInfo([]string{VolNameForTest}, hbInfos)
+	require.Equal(t, proto.OpNoSpaceErr, oqMgr.IsOverQuota(true, false, 10, 20))
+	require.Equal(t, uint8(0), oqMgr.IsOverQuota(false, true, 10, 20))
+	require.Equal(t, proto.OpNoSpaceErr, oqMgr.IsOverQuota(false, true, 11, 30))
+	require.Equal(t, uint8(0), oqMgr.IsOverQuota(true, true, 11, 20))
+
+	infos := oqMgr.getOwnerQuotaReportInfos()
+	require.Len(t, infos, 2)
+	for _, info := range infos {
+		require.Equal(t, proto.QuotaUsedInfo{UsedFiles: 1, UsedBytes: 100}, info.UsedInfo)
+	}
+}
+
 func NewMetaPartitionForQuotaTest() *metaPartition {
 	mpC := &MetaPartitionConfig{
 		PartitionId: PartitionIdForTest,
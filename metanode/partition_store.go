@@ -52,6 +52,8 @@ const (
 	uniqIDFile              = "uniqID"
 	uniqCheckerFile         = "uniqChecker"
 	verdataFile             = "multiVer"
+	fileLockFile            = "fileLock"
+	extentRefFile           = "extentRef"
 	StaleMetadataSuffix     = ".old"
 	StaleMetadataTimeFormat = "20060102150405.000000000"
 )
@@ -85,11 +87,13 @@ func (mp *metaPartition) loadMetadata() (err error) {
 	mp.config.Start = mConf.Start
 	mp.config.End = mConf.End
 	mp.config.Peers = mConf.Peers
+	mp.config.StoreMode = mConf.StoreMode
 	mp.config.Cursor = mp.config.Start
 	mp.config.UniqId = 0
 
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
 	mp.mqMgr = NewQuotaManager(mp.config.VolName, mp.config.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(mp.config.VolName, mp.config.PartitionId)
 
 	log.LogInfof("loadMetadata: load complete: partitionID(%v) volume(%v) range(%v,%v) cursor(%v)",
 		mp.config.PartitionId, mp.config.VolName, mp.config.Start, mp.config.End, mp.config.Cursor)
@@ -1300,6 +1304,112 @@ func (mp *metaPartition) storeUniqID(rootDir string, sm *storeMsg) (err error) {
 	return
 }
 
+func (mp *metaPartition) loadFileLock(rootDir string, crc uint32) (err error) {
+	filename := path.Join(rootDir, fileLockFile)
+	if _, err = os.Stat(filename); err != nil {
+		log.LogErrorf("loadFileLock get file %s err(%s)", filename, err)
+		err = nil
+		return
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		err = errors.NewErrorf("[loadFileLock] OpenFile: %v", err.Error())
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadFileLock]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return ErrSnapshotCrcMismatch
+	}
+	if err = mp.fileLocks.UnMarshal(data); err != nil {
+		err = errors.NewErrorf("[loadFileLock] Unmarshal: %v", err.Error())
+		return
+	}
+	log.LogInfof("loadFileLock: load complete: partitionID(%v) volume(%v) locks(%v)",
+		mp.config.PartitionId, mp.config.VolName, mp.fileLocks.count())
+	return
+}
+
+func (mp *metaPartition) storeFileLock(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	filename := path.Join(rootDir, fileLockFile)
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = fp.Sync()
+		fp.Close()
+	}()
+
+	locks := sm.fileLocks
+	if locks == nil {
+		locks = newFileLockTable()
+	}
+	var data []byte
+	if data, crc, err = locks.Marshal(); err != nil {
+		return
+	}
+	if _, err = fp.Write(data); err != nil {
+		return
+	}
+	log.LogInfof("storeFileLock: store complete: partitionID(%v) volume(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, crc)
+	return
+}
+
+func (mp *metaPartition) loadExtentRef(rootDir string, crc uint32) (err error) {
+	filename := path.Join(rootDir, extentRefFile)
+	if _, err = os.Stat(filename); err != nil {
+		log.LogErrorf("loadExtentRef get file %s err(%s)", filename, err)
+		err = nil
+		return
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		err = errors.NewErrorf("[loadExtentRef] OpenFile: %v", err.Error())
+		return
+	}
+	if res := crc32.ChecksumIEEE(data); res != crc {
+		log.LogErrorf("[loadExtentRef]: check crc mismatch, expected[%d], actual[%d]", crc, res)
+		return ErrSnapshotCrcMismatch
+	}
+	if err = mp.extentRefs.UnMarshal(data); err != nil {
+		err = errors.NewErrorf("[loadExtentRef] Unmarshal: %v", err.Error())
+		return
+	}
+	log.LogInfof("loadExtentRef: load complete: partitionID(%v) volume(%v) shared extents(%v)",
+		mp.config.PartitionId, mp.config.VolName, mp.extentRefs.count())
+	return
+}
+
+func (mp *metaPartition) storeExtentRef(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	filename := path.Join(rootDir, extentRefFile)
+	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0o755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		err = fp.Sync()
+		fp.Close()
+	}()
+
+	refs := sm.extentRefs
+	if refs == nil {
+		refs = newExtentRefTable()
+	}
+	var data []byte
+	if data, crc, err = refs.Marshal(); err != nil {
+		return
+	}
+	if _, err = fp.Write(data); err != nil {
+		return
+	}
+	log.LogInfof("storeExtentRef: store complete: partitionID(%v) volume(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, crc)
+	return
+}
+
 func (mp *metaPartition) storeUniqChecker(rootDir string, sm *storeMsg) (crc uint32, err error) {
 	filename := path.Join(rootDir, uniqCheckerFile)
 	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.
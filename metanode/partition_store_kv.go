@@ -0,0 +1,419 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	metaKvStoreDir        = "rocksdb"
+	metaKvLruCacheSize    = 32 * util.MB
+	metaKvWriteBufferSize = 16 * util.MB
+
+	// the sst files of the raft snapshots are kept in the temporary dirs of the partition
+	metaKvSnapshotDirPrefix = "rocksdb_snap_"
+	metaKvSstChunkSize      = 4 * util.MB
+)
+
+// kvCheckpoint is the changes of the trees kept in rocksdb sealed by a store tick,
+// they are written to rocksdb together with the snapshot files of the tick.
+type kvCheckpoint struct {
+	generation uint64
+	seq        uint64
+	trees      []*BTree
+}
+
+// openKvStore opens the rocksdb of a partition in MetaStoreModeRocksDB and keeps the inode,
+// dentry, extend and multipart trees in it.
+func (mp *metaPartition) openKvStore() (err error) {
+	if mp.config.StoreMode != proto.MetaStoreModeRocksDB || mp.kvStore != nil {
+		return
+	}
+	if err = removeKvSnapshotDirs(mp.config.RootDir); err != nil {
+		return
+	}
+	if mp.kvStore, err = openMetaKvStore(path.Join(mp.config.RootDir, metaKvStoreDir)); err != nil {
+		return
+	}
+	mp.inodeTree = newKvBtree(mp.kvStore, inodeKvCodec)
+	mp.dentryTree = newKvBtree(mp.kvStore, dentryKvCodec)
+	mp.extendTree = newKvBtree(mp.kvStore, extendKvCodec)
+	mp.multipartTree = newKvBtree(mp.kvStore, multipartKvCodec)
+	log.LogInfof("openKvStore: partitionID(%v) volume(%v) dir(%v)", mp.config.PartitionId, mp.config.VolName,
+		path.Join(mp.config.RootDir, metaKvStoreDir))
+	return
+}
+
+func (mp *metaPartition) closeKvStore() {
+	if mp.kvStore != nil {
+		mp.kvStore.close()
+	}
+}
+
+// checkpointKvTrees seals the changes of the trees since the last checkpoint, it must be called
+// in the apply goroutine.
+func (mp *metaPartition) checkpointKvTrees() *kvCheckpoint {
+	if mp.kvStore == nil {
+		return nil
+	}
+	cp := &kvCheckpoint{
+		generation: mp.kvStore.getGeneration(),
+		seq:        mp.kvStore.nextSeq(),
+		trees:      []*BTree{mp.inodeTree, mp.dentryTree, mp.extendTree, mp.multipartTree},
+	}
+	for _, tree := range cp.trees {
+		tree.kvSeal(cp.seq)
+	}
+	return cp
+}
+
+// resetKvTrees clears rocksdb to apply a raft snapshot, and returns the trees to ingest the sst files
+// of the snapshot, or to insert its items in bulk mode if the leader sends them one by one.
+func (mp *metaPartition) resetKvTrees() (trees []*BTree, err error) {
+	var generation uint64
+	if generation, err = mp.kvStore.reset(); err != nil {
+		return
+	}
+	trees = []*BTree{
+		newKvBtree(mp.kvStore, inodeKvCodec),
+		newKvBtree(mp.kvStore, dentryKvCodec),
+		newKvBtree(mp.kvStore, extendKvCodec),
+		newKvBtree(mp.kvStore, multipartKvCodec),
+	}
+	for _, tree := range trees {
+		tree.kvStartBulk(generation)
+	}
+	log.LogWarnf("resetKvTrees: partitionID(%v) generation(%v)", mp.config.PartitionId, generation)
+	return
+}
+
+// removeKvSnapshotDirs removes the sst files of the raft snapshots left by a crash.
+func removeKvSnapshotDirs(rootDir string) (err error) {
+	dirs, err := filepath.Glob(path.Join(rootDir, metaKvSnapshotDirPrefix+"*"))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if err = os.RemoveAll(dir); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func kvSstFileName(tree *BTree) string {
+	return string(tree.kv.codec.prefix) + ".sst"
+}
+
+// kvSstFile starts an sst file of a tree in the raft snapshot, followed by the kvSstChunks of the file.
+type kvSstFile struct {
+	name  string
+	count uint64
+}
+
+type kvSstChunk struct {
+	name string
+	data []byte
+}
+
+// sendKvSstFiles writes the pinned trees kept in rocksdb to sst files one by one, and sends each
+// file in chunks by produceItem. It stops without an error once produceItem fails.
+func sendKvSstFiles(rootDir string, trees []*BTree, produceItem func(item interface{}) bool) (err error) {
+	dir, err := os.MkdirTemp(rootDir, metaKvSnapshotDirPrefix)
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+	for _, tree := range trees {
+		name := kvSstFileName(tree)
+		filePath := path.Join(dir, name)
+		var count int
+		if count, err = tree.kvWriteSst(filePath); err != nil {
+			return
+		}
+		if count == 0 {
+			continue
+		}
+		if !produceItem(&kvSstFile{name: name, count: uint64(count)}) {
+			return
+		}
+		var sent bool
+		if sent, err = sendKvSstChunks(filePath, name, produceItem); err != nil || !sent {
+			return
+		}
+		if err = os.Remove(filePath); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func sendKvSstChunks(filePath, name string, produceItem func(item interface{}) bool) (sent bool, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	for {
+		data := make([]byte, metaKvSstChunkSize)
+		var n int
+		if n, err = io.ReadFull(f, data); err == io.EOF {
+			return true, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return
+		}
+		if !produceItem(&kvSstChunk{name: name, data: data[:n]}) {
+			return false, nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			return true, nil
+		}
+	}
+}
+
+// kvSstReceiver keeps the sst files received by a raft snapshot until they are ingested into the trees.
+type kvSstReceiver struct {
+	dir    string
+	trees  map[string]*BTree
+	files  map[string]*os.File
+	counts map[string]int
+}
+
+func newKvSstReceiver(rootDir string, trees []*BTree) (r *kvSstReceiver, err error) {
+	r = &kvSstReceiver{
+		trees:  make(map[string]*BTree, len(trees)),
+		files:  make(map[string]*os.File),
+		counts: make(map[string]int),
+	}
+	for _, tree := range trees {
+		r.trees[kvSstFileName(tree)] = tree
+	}
+	if r.dir, err = os.MkdirTemp(rootDir, metaKvSnapshotDirPrefix); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (r *kvSstReceiver) create(name string, count uint64) (err error) {
+	if _, ok := r.trees[name]; !ok {
+		return fmt.Errorf("unknown sst file %v", name)
+	}
+	if _, ok := r.files[name]; ok {
+		return fmt.Errorf("duplicated sst file %v", name)
+	}
+	var f *os.File
+	if f, err = os.Create(path.Join(r.dir, name)); err != nil {
+		return
+	}
+	r.files[name] = f
+	r.counts[name] = int(count)
+	return
+}
+
+func (r *kvSstReceiver) write(name string, data []byte) (err error) {
+	f, ok := r.files[name]
+	if !ok {
+		return fmt.Errorf("sst file %v is not created", name)
+	}
+	_, err = f.Write(data)
+	return
+}
+
+// ingest moves the received sst files into rocksdb through the trees in bulk mode.
+func (r *kvSstReceiver) ingest() (err error) {
+	for name, f := range r.files {
+		if err = f.Sync(); err != nil {
+			return
+		}
+		if err = r.trees[name].kvIngestSst(f.Name(), r.counts[name]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *kvSstReceiver) close() {
+	for _, f := range r.files {
+		f.Close()
+	}
+	os.RemoveAll(r.dir)
+}
+
+// commitKvStore writes the changes of the checkpoint and the snapshot files in dir to rocksdb in one batch,
+// so that rocksdb always holds the trees and the files of the same apply id.
+func (mp *metaPartition) commitKvStore(dir string, sm *storeMsg) (err error) {
+	deleteSet := make(map[string]util.Null)
+	cmdMap := make(map[string][]byte)
+	generation := mp.kvStore.getGeneration()
+	cp := sm.kvCheckpoint
+	if cp != nil {
+		generation = cp.generation
+		for _, tree := range cp.trees {
+			if err = tree.kvCollect(cp.seq, deleteSet, cmdMap); err != nil {
+				return
+			}
+		}
+	}
+
+	files, err := mp.kvStore.files()
+	if err != nil {
+		return
+	}
+	for key := range files {
+		deleteSet[key] = util.Null{}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		var data []byte
+		if data, err = os.ReadFile(path.Join(dir, entry.Name())); err != nil {
+			return
+		}
+		key := string(kvFilePrefix) + entry.Name()
+		cmdMap[key] = data
+		delete(deleteSet, key)
+	}
+	if err = mp.kvStore.write(generation, deleteSet, cmdMap, true); err != nil {
+		return
+	}
+	if cp != nil {
+		for _, tree := range cp.trees {
+			tree.kvCommitted(cp.seq)
+		}
+	}
+	log.LogInfof("commitKvStore: partitionID(%v) applyID(%v) puts(%v) deletes(%v)",
+		mp.config.PartitionId, sm.applyIndex, len(cmdMap), len(deleteSet))
+	return
+}
+
+// restoreKvSnapshotFiles writes the snapshot files kept in rocksdb to the snapshot dir, the dir on
+// the disk may be older than rocksdb if the metanode crashed during a store.
+func (mp *metaPartition) restoreKvSnapshotFiles(snapshotPath string) (found bool, err error) {
+	files, err := mp.kvStore.files()
+	if err != nil || len(files) == 0 {
+		return
+	}
+	if err = os.RemoveAll(snapshotPath); err != nil {
+		return
+	}
+	if err = os.MkdirAll(snapshotPath, 0o775); err != nil {
+		return
+	}
+	for key, data := range files {
+		if err = os.WriteFile(path.Join(snapshotPath, key[1:]), data, 0o775); err != nil {
+			return
+		}
+	}
+	return true, nil
+}
+
+func (mp *metaPartition) loadInodeFromKv(rootDir string, crc uint32) (err error) {
+	var numInodes uint64
+	err = mp.inodeTree.kvLoad(func(i BtreeItem) bool {
+		ino := i.(*Inode)
+		mp.acucumUidSizeByLoad(ino)
+		mp.size += ino.Size
+		mp.uidManager.addUidSpace(ino.Uid, ino.Inode, nil)
+		mp.checkAndInsertFreeList(ino)
+		if !ino.ShouldDelete() && ino.IsTempFile() {
+			// keep the access time set for the temp file
+			mp.inodeTree.ReplaceOrInsert(ino, true)
+		}
+		if mp.config.Cursor < ino.Inode {
+			mp.config.Cursor = ino.Inode
+		}
+		numInodes += 1
+		return true
+	})
+	if err == nil {
+		log.LogInfof("loadInodeFromKv: load complete: partitonID(%v) volume(%v) numInodes(%v)",
+			mp.config.PartitionId, mp.config.VolName, numInodes)
+	}
+	return
+}
+
+func (mp *metaPartition) loadDentryFromKv(rootDir string, crc uint32) (err error) {
+	if err = mp.dentryTree.kvLoad(func(i BtreeItem) bool { return true }); err == nil {
+		log.LogInfof("loadDentryFromKv: load complete: partitonID(%v) volume(%v) numDentries(%v)",
+			mp.config.PartitionId, mp.config.VolName, mp.dentryTree.Len())
+	}
+	return
+}
+
+func (mp *metaPartition) loadExtendFromKv(rootDir string, crc uint32) (err error) {
+	err = mp.extendTree.kvLoad(func(i BtreeItem) bool {
+		mp.statisticExtendByLoad(i.(*Extend))
+		return true
+	})
+	if err == nil {
+		log.LogInfof("loadExtendFromKv: load complete: partitionID(%v) volume(%v) numExtends(%v)",
+			mp.config.PartitionId, mp.config.VolName, mp.extendTree.Len())
+	}
+	return
+}
+
+func (mp *metaPartition) loadMultipartFromKv(rootDir string, crc uint32) (err error) {
+	if err = mp.multipartTree.kvLoad(func(i BtreeItem) bool { return true }); err == nil {
+		log.LogInfof("loadMultipartFromKv: load complete: partitionID(%v) numMultiparts(%v)",
+			mp.config.PartitionId, mp.multipartTree.Len())
+	}
+	return
+}
+
+// scanInodeOnStore replaces storeInode in MetaStoreModeRocksDB, the inodes are kept in rocksdb
+// and only the statistics are collected.
+func (mp *metaPartition) scanInodeOnStore(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	if sm.uidRebuild || mp.manager.fileStatsEnable {
+		sm.inodeTree.Ascend(func(i BtreeItem) bool {
+			ino := i.(*Inode)
+			if sm.uidRebuild {
+				mp.acucumUidSizeByStore(ino)
+			}
+			mp.fileStats(ino)
+			return true
+		})
+	}
+	mp.acucumRebuildFin(sm.uidRebuild)
+	return
+}
+
+// scanExtendOnStore replaces storeExtend in MetaStoreModeRocksDB.
+func (mp *metaPartition) scanExtendOnStore(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	if sm.quotaRebuild {
+		sm.extendTree.Ascend(func(i BtreeItem) bool {
+			mp.statisticExtendByStore(i.(*Extend), sm.inodeTree)
+			return true
+		})
+	}
+	mp.mqMgr.statisticRebuildFin(sm.quotaRebuild)
+	return
+}
+
+// skipStoreTree replaces the store of a tree which is kept in rocksdb.
+func (mp *metaPartition) skipStoreTree(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	return
+}
@@ -42,6 +42,9 @@ type storeMsg struct {
 	uniqId         uint64
 	uniqChecker    *uniqChecker
 	multiVerList   []*proto.VolVersionInfo
+	fileLocks      *fileLockTable
+	extentRefs     *extentRefTable
+	kvCheckpoint   *kvCheckpoint
 }
 
 func (mp *metaPartition) startSchedule(curIndex uint64) {
@@ -67,6 +67,7 @@ func TestMetaPartition_LoadSnapshot(t *testing.T) {
 		uniqChecker:    mp.uniqChecker,
 	}
 	mp.uidManager = NewUidMgr(mpC.VolName, mpC.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(mpC.VolName, mpC.PartitionId)
 	mp.mqMgr = NewQuotaManager(mpC.VolName, mpC.PartitionId)
 	mp.multiVersionList = &proto.VolVersionInfoList{}
 
@@ -3,6 +3,7 @@ package metanode
 import (
 	"bytes"
 	"encoding/json"
+	"sort"
 	"sync"
 
 	"github.com/cubefs/cubefs/proto"
@@ -164,6 +165,16 @@ func storeEkSplit(mpId uint64, inodeID uint64, ekRef *sync.Map, ek *proto.Extent
 	return
 }
 
+// refCompressedKey counts the key of a compressed frame like a split key. The frames
+// written by the client share the extent, so the extent can only be deleted with the
+// last frame of it, which is told by the ref map.
+func refCompressedKey(ek *proto.ExtentKey, addRefFunc func(*proto.ExtentKey)) {
+	if addRefFunc == nil || !ek.IsCompressed() || ek.IsSplit() {
+		return
+	}
+	addRefFunc(ek)
+}
+
 func (se *SortedExtents) SplitWithCheck(mpId uint64, inodeID uint64, ekSplit proto.ExtentKey, ekRef *sync.Map) (delExtents []proto.ExtentKey, status uint8) {
 	status = proto.OpOk
 	endOffset := ekSplit.FileOffset + uint64(ekSplit.Size)
@@ -233,7 +244,7 @@ func (se *SortedExtents) SplitWithCheck(mpId uint64, inodeID uint64, ekSplit pro
 	}
 
 	delKey := *key
-	delKey.ExtentOffset = key.ExtentOffset + (ekSplit.FileOffset - key.FileOffset)
+	delKey.AddExtentOffset(ekSplit.FileOffset - key.FileOffset)
 	delKey.Size = ekSplit.Size
 	storeEkSplit(mpId, inodeID, ekRef, &delKey)
 
@@ -268,7 +279,7 @@ func (se *SortedExtents) SplitWithCheck(mpId uint64, inodeID uint64, ekSplit pro
 		}
 
 		keyDup.FileOffset = keyDup.FileOffset + uint64(ekSplit.Size)
-		keyDup.ExtentOffset = keyDup.ExtentOffset + uint64(ekSplit.Size)
+		keyDup.AddExtentOffset(uint64(ekSplit.Size))
 		keyDup.Size = keySize - ekSplit.Size
 		if keyDup.Size == 0 {
 			log.LogErrorf("SplitWithCheck. mpId [%v] inode[%v] delKey %v,keyDup %v, eksplit %v", mpId, inodeID, delKey, keyDup, ekSplit)
@@ -310,7 +321,7 @@ func (se *SortedExtents) SplitWithCheck(mpId uint64, inodeID uint64, ekSplit pro
 			FileOffset:   ekSplit.FileOffset + uint64(ekSplit.Size),
 			PartitionId:  key.PartitionId,
 			ExtentId:     key.ExtentId,
-			ExtentOffset: key.ExtentOffset + uint64(key.Size) + uint64(ekSplit.Size),
+			ExtentOffset: key.ExtentOffset,
 			Size:         keySize - key.Size - ekSplit.Size,
 			// crc
 			SnapInfo: &proto.ExtSnapInfo{
@@ -318,7 +329,9 @@ func (se *SortedExtents) SplitWithCheck(mpId uint64, inodeID uint64, ekSplit pro
 				ModGen:  0,
 				IsSplit: true,
 			},
+			CompressInfo: key.CompressInfo,
 		}
+		mKey.AddExtentOffset(uint64(key.Size) + uint64(ekSplit.Size))
 		se.eks = append(se.eks, *mKey)
 		storeEkSplit(mpId, inodeID, ekRef, mKey)
 
@@ -347,11 +360,12 @@ func (se *SortedExtents) CheckAndAddRef(lastKey *proto.ExtentKey, currEk *proto.
 	if lastKey.FileOffset == currEk.FileOffset &&
 		lastKey.PartitionId == currEk.PartitionId &&
 		lastKey.ExtentId == currEk.ExtentId &&
-		lastKey.ExtentOffset == currEk.ExtentOffset && lastKey.Size < currEk.Size && lastKey.GetSeq() < currEk.GetSeq() {
+		lastKey.ExtentOffset == currEk.ExtentOffset && lastKey.Size < currEk.Size && lastKey.GetSeq() < currEk.GetSeq() &&
+		!currEk.IsCompressed() {
 
 		log.LogDebugf("action[AppendWithCheck.CheckAndAddRef] split append key %v", currEk)
 		currEk.FileOffset = lastKey.FileOffset + uint64(lastKey.Size)
-		currEk.ExtentOffset = currEk.ExtentOffset + uint64(lastKey.Size)
+		currEk.AddExtentOffset(uint64(lastKey.Size))
 		currEk.Size = currEk.Size - lastKey.Size
 		log.LogDebugf("action[AppendWithCheck.CheckAndAddRef] after split append key %v", currEk)
 		if !lastKey.IsSplit() {
@@ -371,6 +385,7 @@ func (se *SortedExtents) AppendWithCheck(inodeID uint64, ek proto.ExtentKey, add
 	defer se.Unlock()
 	log.LogDebugf("action[AppendWithCheck] ek [%v], clientDiscardExts [%v] se.eks [%v]", ek, clientDiscardExts, se.eks)
 	if len(se.eks) <= 0 {
+		refCompressedKey(&ek, addRefFunc)
 		se.eks = append(se.eks, ek)
 		return
 	}
@@ -385,6 +400,7 @@ func (se *SortedExtents) AppendWithCheck(inodeID uint64, ek proto.ExtentKey, add
 
 	firstKey := se.eks[0]
 	if firstKey.FileOffset >= endOffset {
+		refCompressedKey(&ek, addRefFunc)
 		se.insert(ek, 0)
 		return
 	}
@@ -435,10 +451,10 @@ func (se *SortedExtents) AppendWithCheck(inodeID uint64, ek proto.ExtentKey, add
 	}
 
 	defer func() {
-		if startIndex == 0 {
+		if startIndex > 0 && se.CheckAndAddRef(&se.eks[startIndex-1], &se.eks[startIndex], addRefFunc) {
 			return
 		}
-		se.CheckAndAddRef(&se.eks[startIndex-1], &se.eks[startIndex], addRefFunc)
+		refCompressedKey(&se.eks[startIndex], addRefFunc)
 	}()
 
 	if len(invalidExtents) == 0 {
@@ -483,13 +499,14 @@ func (se *SortedExtents) Truncate(offset uint64, doOnLastKey func(*proto.ExtentK
 			rsKey := &proto.ExtentKey{}
 			*rsKey = *lastKey
 			lastKey.Size = uint32(offset - lastKey.FileOffset)
-			if insertRefMap != nil {
+			// a split key already holds a reference, which is kept by the rest of it
+			if insertRefMap != nil && !lastKey.IsSplit() {
 				insertRefMap(lastKey)
 			}
 
 			rsKey.Size -= lastKey.Size
 			rsKey.FileOffset += uint64(lastKey.Size)
-			rsKey.ExtentOffset += uint64(lastKey.Size)
+			rsKey.AddExtentOffset(uint64(lastKey.Size))
 			if insertRefMap != nil {
 				insertRefMap(rsKey)
 			}
@@ -501,6 +518,139 @@ func (se *SortedExtents) Truncate(offset uint64, doOnLastKey func(*proto.ExtentK
 	return
 }
 
+// dupExtentKey returns a copy of the extent key which does not share the snapshot info.
+func dupExtentKey(ek proto.ExtentKey) proto.ExtentKey {
+	if ek.SnapInfo != nil {
+		info := *ek.SnapInfo
+		ek.SnapInfo = &info
+	}
+	return ek
+}
+
+// PunchHole removes the extent keys within [offset, offset+size), the keys across the
+// boundaries are split into pieces which are counted by insertRefMap like SplitWithCheck
+// does, and the removed parts are returned.
+func (se *SortedExtents) PunchHole(offset, size uint64, insertRefMap func(ek *proto.ExtentKey)) (deleteExtents []proto.ExtentKey) {
+	end := offset + size
+
+	se.Lock()
+	defer se.Unlock()
+
+	eks := make([]proto.ExtentKey, 0, len(se.eks)+1)
+	for _, key := range se.eks {
+		keyEnd := key.FileOffset + uint64(key.Size)
+		if keyEnd <= offset || key.FileOffset >= end {
+			eks = append(eks, key)
+			continue
+		}
+		if key.FileOffset >= offset && keyEnd <= end {
+			deleteExtents = append(deleteExtents, key)
+			continue
+		}
+
+		// a split key already holds a reference, which is taken over by its first piece
+		taken := !key.IsSplit()
+		addRef := func(ek *proto.ExtentKey) {
+			if !taken {
+				taken = true
+				return
+			}
+			insertRefMap(ek)
+		}
+		hole := dupExtentKey(key)
+		if key.FileOffset < offset {
+			left := dupExtentKey(key)
+			left.Size = uint32(offset - key.FileOffset)
+			addRef(&left)
+			eks = append(eks, left)
+
+			hole.FileOffset = offset
+			hole.AddExtentOffset(uint64(left.Size))
+			hole.Size -= left.Size
+		}
+		if keyEnd > end {
+			right := dupExtentKey(key)
+			right.FileOffset = end
+			right.AddExtentOffset(end - key.FileOffset)
+			right.Size = uint32(keyEnd - end)
+			addRef(&right)
+			eks = append(eks, right)
+
+			hole.Size -= right.Size
+		}
+		addRef(&hole)
+		deleteExtents = append(deleteExtents, hole)
+		log.LogDebugf("SortedExtents.PunchHole key %v hole %v", key, hole)
+	}
+	se.eks = eks
+	return
+}
+
+// CopyRange returns the copies of the extent keys within [offset, offset+size), the keys
+// across the boundaries are trimmed, and the file offsets are moved to start at dstOffset.
+func (se *SortedExtents) CopyRange(offset, size, dstOffset uint64) (eks []proto.ExtentKey) {
+	end := offset + size
+
+	se.RLock()
+	defer se.RUnlock()
+
+	for _, key := range se.eks {
+		keyEnd := key.FileOffset + uint64(key.Size)
+		if keyEnd <= offset || key.FileOffset >= end {
+			continue
+		}
+		ek := dupExtentKey(key)
+		if ek.FileOffset < offset {
+			ek.AddExtentOffset(offset - ek.FileOffset)
+			ek.Size -= uint32(offset - ek.FileOffset)
+			ek.FileOffset = offset
+		}
+		if keyEnd > end {
+			ek.Size -= uint32(keyEnd - end)
+		}
+		ek.FileOffset = ek.FileOffset - offset + dstOffset
+		eks = append(eks, ek)
+	}
+	return
+}
+
+// UpdateInRange updates the extent keys overlapping with [offset, offset+size), the keys
+// get their own snapshot info before the update.
+func (se *SortedExtents) UpdateInRange(offset, size uint64, update func(ek *proto.ExtentKey)) {
+	end := offset + size
+
+	se.Lock()
+	defer se.Unlock()
+
+	for idx := range se.eks {
+		key := &se.eks[idx]
+		if key.FileOffset+uint64(key.Size) <= offset || key.FileOffset >= end {
+			continue
+		}
+		*key = dupExtentKey(*key)
+		update(key)
+	}
+}
+
+// InsertRange inserts the sorted extent keys into a hole of the file.
+func (se *SortedExtents) InsertRange(eks []proto.ExtentKey) {
+	if len(eks) == 0 {
+		return
+	}
+
+	se.Lock()
+	defer se.Unlock()
+
+	idx := sort.Search(len(se.eks), func(i int) bool {
+		return se.eks[i].FileOffset >= eks[0].FileOffset
+	})
+	merged := make([]proto.ExtentKey, 0, len(se.eks)+len(eks))
+	merged = append(merged, se.eks[:idx]...)
+	merged = append(merged, eks...)
+	merged = append(merged, se.eks[idx:]...)
+	se.eks = merged
+}
+
 func (se *SortedExtents) insert(ek proto.ExtentKey, startIdx int) {
 	se.eks = append(se.eks, ek)
 	size := len(se.eks)
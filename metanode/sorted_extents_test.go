@@ -1,6 +1,7 @@
 package metanode
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/cubefs/cubefs/proto"
@@ -137,3 +138,176 @@ func TestSortedMarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestPunchHole(t *testing.T) {
+	se := NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1})
+	se.Append(proto.ExtentKey{FileOffset: 1000, Size: 1000, ExtentId: 2})
+	se.Append(proto.ExtentKey{FileOffset: 2000, Size: 1000, ExtentId: 3})
+
+	refs := 0
+	delExtents := se.PunchHole(500, 2000, func(ek *proto.ExtentKey) {
+		ek.SetSplit(true)
+		refs++
+	})
+	t.Logf("\ndel: %v\neks: %v", delExtents, se.eks)
+	if len(se.eks) != 2 || len(delExtents) != 3 || refs != 4 {
+		t.FailNow()
+	}
+	if se.eks[0].ExtentId != 1 || se.eks[0].Size != 500 || se.eks[1].ExtentId != 3 ||
+		se.eks[1].FileOffset != 2500 || se.eks[1].ExtentOffset != 500 || se.eks[1].Size != 500 {
+		t.Fail()
+	}
+	if delExtents[0].ExtentId != 1 || delExtents[0].ExtentOffset != 500 || delExtents[0].Size != 500 ||
+		delExtents[1].ExtentId != 2 || delExtents[2].ExtentId != 3 || delExtents[2].Size != 500 {
+		t.Fail()
+	}
+	// the file size is kept by the inode, the extents only cover the data
+	if se.Size() != 3000 {
+		t.Fail()
+	}
+}
+
+func TestPunchHoleInKey(t *testing.T) {
+	se := NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 3000, ExtentId: 1})
+
+	delExtents := se.PunchHole(1000, 1000, func(ek *proto.ExtentKey) { ek.SetSplit(true) })
+	t.Logf("\ndel: %v\neks: %v", delExtents, se.eks)
+	if len(se.eks) != 2 || len(delExtents) != 1 {
+		t.FailNow()
+	}
+	if se.eks[0].Size != 1000 || se.eks[1].FileOffset != 2000 || se.eks[1].ExtentOffset != 2000 ||
+		delExtents[0].FileOffset != 1000 || delExtents[0].ExtentOffset != 1000 || !delExtents[0].IsSplit() {
+		t.Fail()
+	}
+}
+
+func TestCopyRange(t *testing.T) {
+	se := NewSortedExtents()
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1})
+	se.Append(proto.ExtentKey{FileOffset: 2000, Size: 1000, ExtentId: 2})
+
+	eks := se.CopyRange(500, 2000, 10000)
+	t.Logf("\neks: %v", eks)
+	if len(eks) != 2 {
+		t.FailNow()
+	}
+	if eks[0].FileOffset != 10000 || eks[0].ExtentOffset != 500 || eks[0].Size != 500 ||
+		eks[1].FileOffset != 11500 || eks[1].ExtentOffset != 0 || eks[1].Size != 500 {
+		t.Fail()
+	}
+
+	dst := NewSortedExtents()
+	dst.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 3})
+	dst.Append(proto.ExtentKey{FileOffset: 20000, Size: 1000, ExtentId: 4})
+	dst.InsertRange(eks)
+	if len(dst.eks) != 4 || dst.eks[1].ExtentId != 1 || dst.eks[2].ExtentId != 2 || dst.eks[3].ExtentId != 4 {
+		t.Fail()
+	}
+}
+
+func TestSplitCompressedKey(t *testing.T) {
+	se := NewSortedExtents()
+	info := &proto.ExtCompressInfo{Codec: 2, FrameSize: 300}
+	se.Append(proto.ExtentKey{FileOffset: 0, Size: 1000, PartitionId: 1, ExtentId: 1, ExtentOffset: 4096, CompressInfo: info})
+
+	ekRef := new(sync.Map)
+	delExtents, status := se.SplitWithCheck(0, 0, proto.ExtentKey{FileOffset: 200, Size: 300, PartitionId: 2, ExtentId: 2}, ekRef)
+	t.Logf("\ndel: %v\neks: %v", delExtents, se.eks)
+	if status != proto.OpOk || len(se.eks) != 3 {
+		t.FailNow()
+	}
+	// the pieces of the frame keep the extent offset, and move in the frame instead
+	right := se.eks[2]
+	if right.FileOffset != 500 || right.Size != 500 || right.ExtentOffset != 4096 ||
+		right.CompressInfo.RawOffset != 500 || right.CompressInfo.FrameSize != 300 || !right.IsSplit() {
+		t.Fail()
+	}
+	if se.eks[0].ExtentOffset != 4096 || se.eks[0].CompressInfo.RawOffset != 0 || se.eks[0].Size != 200 {
+		t.Fail()
+	}
+	// the compress info is not shared by the pieces
+	if info.RawOffset != 0 {
+		t.Fail()
+	}
+
+	delExtents = se.Truncate(700, nil, func(ek *proto.ExtentKey) { ek.SetSplit(true) })
+	t.Logf("\ndel: %v\neks: %v", delExtents, se.eks)
+	if len(delExtents) != 1 || delExtents[0].ExtentOffset != 4096 || delExtents[0].CompressInfo.RawOffset != 700 ||
+		se.eks[2].CompressInfo.RawOffset != 500 || se.eks[2].Size != 200 {
+		t.Fail()
+	}
+}
+
+func TestSortedMarshalCompressed(t *testing.T) {
+	se := NewSortedExtents()
+	se.eks = append(se.eks, proto.ExtentKey{FileOffset: 0, Size: 1000, ExtentId: 1, PartitionId: 1})
+	se.eks = append(se.eks, proto.ExtentKey{
+		FileOffset: 1000, Size: 500, ExtentId: 1, PartitionId: 1, ExtentOffset: 1000,
+		CompressInfo: &proto.ExtCompressInfo{Codec: 3, FrameSize: 100, RawOffset: 24},
+	})
+
+	data, err := se.MarshalBinary(true)
+	if err != nil {
+		t.FailNow()
+	}
+	se2 := NewSortedExtents()
+	if err, _ = se2.UnmarshalBinary(data, true); err != nil || len(se2.eks) != 2 {
+		t.FailNow()
+	}
+	if se2.eks[0].IsCompressed() || !se2.eks[1].Equals(&se.eks[1]) {
+		t.Fail()
+	}
+}
+
+func TestTruncateCompressedFrames(t *testing.T) {
+	ino := NewInode(1, FileModeType)
+	refFunc := func(ek *proto.ExtentKey) { ino.insertEkRefMap(0, ek) }
+	frame := func(fileOffset, extentOffset uint64) proto.ExtentKey {
+		return proto.ExtentKey{
+			FileOffset: fileOffset, Size: 1000, PartitionId: 1, ExtentId: 1, ExtentOffset: extentOffset,
+			CompressInfo: &proto.ExtCompressInfo{Codec: 2, FrameSize: 300},
+		}
+	}
+	truncate := func(offset uint64) []proto.ExtentKey {
+		delExtents := ino.Extents.Truncate(offset, nil, refFunc)
+		ino.DecSplitExts(0, delExtents)
+		return delExtents
+	}
+
+	// the frames of the extent are not next to each other in the file
+	ino.Extents.AppendWithCheck(ino.Inode, frame(0, 0), refFunc, nil)
+	ino.Extents.AppendWithCheck(ino.Inode, proto.ExtentKey{FileOffset: 1000, Size: 1000, PartitionId: 1, ExtentId: 2}, refFunc, nil)
+	ino.Extents.AppendWithCheck(ino.Inode, frame(2000, 300), refFunc, nil)
+	t.Logf("\neks: %v", ino.Extents.eks)
+	if !ino.Extents.eks[0].IsSplit() || !ino.Extents.eks[2].IsSplit() {
+		t.FailNow()
+	}
+
+	// the extent is kept for the first frame
+	delExtents := truncate(2000)
+	t.Logf("\ndel: %v\neks: %v", delExtents, ino.Extents.eks)
+	if len(delExtents) != 1 || delExtents[0].ExtentId != 1 || !delExtents[0].IsSplit() {
+		t.FailNow()
+	}
+
+	// the rest of the frame cut by truncate is not the last one of the extent either
+	delExtents = truncate(1500)
+	t.Logf("\ndel: %v\neks: %v", delExtents, ino.Extents.eks)
+	if len(delExtents) != 1 || delExtents[0].ExtentId != 2 || !delExtents[0].IsSplit() {
+		t.FailNow()
+	}
+	delExtents = truncate(500)
+	t.Logf("\ndel: %v\neks: %v", delExtents, ino.Extents.eks)
+	if len(delExtents) != 2 || delExtents[0].ExtentId != 1 || !delExtents[0].IsSplit() {
+		t.FailNow()
+	}
+
+	// the extent is deleted with the last frame
+	delExtents = truncate(0)
+	t.Logf("\ndel: %v\neks: %v", delExtents, ino.Extents.eks)
+	if len(delExtents) != 1 || delExtents[0].ExtentId != 1 || delExtents[0].IsSplit() {
+		t.Fail()
+	}
+}
@@ -1368,6 +1368,9 @@ func (tr *TransactionResource) rollbackInodeInternal(rbInode *TxRollbackInode) (
 			if mp.uidManager != nil {
 				mp.uidManager.addUidSpace(rbInode.inode.Uid, rbInode.inode.Inode, rbInode.inode.Extents.eks)
 			}
+			if mp.ownerQuotaMgr != nil {
+				mp.ownerQuotaMgr.updateUsedInfo(int64(rbInode.inode.Size), 1, rbInode.inode.Uid, rbInode.inode.Gid)
+			}
 			if mp.mqMgr != nil && len(rbInode.quotaIds) > 0 && item == nil {
 				mp.setInodeQuota(rbInode.quotaIds, rbInode.inode.Inode)
 				for _, quotaId := range rbInode.quotaIds {
@@ -74,6 +74,7 @@ func newMetaPartition(PartitionId uint64, manager *metadataManager) (mp *metaPar
 
 	mp.txProcessor = NewTransactionProcessor(mp)
 	mp.uidManager = NewUidMgr(mp.config.VolName, mp.config.PartitionId)
+	mp.ownerQuotaMgr = NewOwnerQuotaManager(mp.config.VolName, mp.config.PartitionId)
 	return mp
 }
 
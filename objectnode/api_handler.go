@@ -130,6 +130,9 @@ func (o *ObjectNode) errorResponse(w http.ResponseWriter, r *http.Request, err e
 		if err == syscall.EPERM {
 			ec = FileDeleteLock
 		}
+		if err == syscall.EACCES {
+			ec = AccessDenied
+		}
 		if ec1, ok := err.(*ErrorCode); ok && ec == nil {
 			ec = ec1
 		}
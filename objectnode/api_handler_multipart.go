@@ -29,6 +29,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
 )
 
 var (
@@ -62,6 +63,10 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		errorCode = KeyTooLong
 		return
 	}
+	if isReservedPath(param.Object()) {
+		errorCode = InvalidKey
+		return
+	}
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
 		log.LogErrorf("createMultipleUploadHandler: load volume fail: requestID(%v) err(%v)",
@@ -116,6 +121,16 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 			GetRequestID(r), acl, err)
 		return
 	}
+	// Server side encryption
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSEOption(r.Header); errorCode != nil {
+		return
+	}
+	if sseOpt, err = vol.resolveSSEOption(sseOpt); err != nil {
+		log.LogErrorf("createMultipleUploadHandler: resolve sse option fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
 	opt := &PutFileOption{
 		MIMEType:     contentType,
 		Disposition:  contentDisposition,
@@ -124,6 +139,7 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		CacheControl: cacheControl,
 		Expires:      expires,
 		ACL:          acl,
+		SSE:          sseOpt,
 	}
 
 	var uploadID string
@@ -133,6 +149,9 @@ func (o *ObjectNode) createMultipleUploadHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if sseOpt != nil {
+		setSSEResponseHeaders(w, sseOpt.Type, sseOpt.CustomerKeyMD5)
+	}
 	initResult := InitMultipartResult{
 		Bucket:   param.Bucket(),
 		Key:      param.Object(),
@@ -242,6 +261,12 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
+	// the SSE-C key must be the same as the key used to initiate the upload
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSECustomerKey(r.Header, false); errorCode != nil {
+		return
+	}
+
 	// Flow Control
 	var reader io.Reader
 	if length > DefaultFlowLimitSize {
@@ -252,7 +277,7 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Write Part
 	start := time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, reader, sseOpt)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -271,6 +296,9 @@ func (o *ObjectNode) uploadPartHandler(w http.ResponseWriter, r *http.Request) {
 
 	// write header to response
 	w.Header()[ETag] = []string{"\"" + fsFileInfo.ETag + "\""}
+	if sseOpt != nil {
+		setSSEResponseHeaders(w, sseOpt.Type, sseOpt.CustomerKeyMD5)
+	}
 }
 
 // Upload part copy
@@ -346,7 +374,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	start := time.Now()
-	srcFileInfo, _, err := srcVol.ObjectMeta(srcObject)
+	srcFileInfo, srcXAttr, err := srcVol.ObjectMeta(srcObject)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartCopyHandler: get fileMeta fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
@@ -362,6 +390,25 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// the source object is decrypted before it is written as a part of the target object
+	var srcSSEOpt, sseOpt *SSEOption
+	if srcSSEOpt, errorCode = parseSSECustomerKey(r.Header, true); errorCode != nil {
+		return
+	}
+	if sseOpt, errorCode = parseSSECustomerKey(r.Header, false); errorCode != nil {
+		return
+	}
+	var srcEnc *objectEncryption
+	if srcEnc, err = loadObjectEncryption(srcXAttr.XAttrs, srcSSEOpt); err != nil {
+		log.LogErrorf("uploadPartCopyHandler: load source encryption fail: requestId(%v) srcVol(%v) path(%v) err(%v)",
+			GetRequestID(r), srcBucket, srcObject, err)
+		return
+	}
+
+	if err = srcVol.checkPosixAcl(srcFileInfo.Inode, posixacl.PermRead); err != nil {
+		return
+	}
+
 	// step4: extract range params
 	copyRange := r.Header.Get(XAmzCopySourceRange)
 	firstByte, copyLength, errorCode := determineCopyRange(copyRange, srcFileInfo.Size)
@@ -381,8 +428,12 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	reader, writer := io.Pipe()
+	var srcWriter io.Writer = writer
+	if srcEnc != nil {
+		srcWriter = srcEnc.DecryptWriter(writer, fb)
+	}
 	go func() {
-		err = srcVol.readFile(srcFileInfo.Inode, size, srcObject, writer, fb, cl)
+		err = srcVol.readObject(srcFileInfo, size, srcObject, srcWriter, fb, cl)
 		if err != nil {
 			log.LogErrorf("uploadPartCopyHandler: read srcObj err(%v): requestId(%v) srcVol(%v) path(%v)",
 				err, GetRequestID(r), srcBucket, srcObject)
@@ -398,7 +449,7 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 		rd = reader
 	}
 	start = time.Now()
-	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd)
+	fsFileInfo, err := vol.WritePart(param.Object(), uploadId, partNumberInt, rd, sseOpt)
 	span.AppendTrackLog("part.w", start, err)
 	if err != nil {
 		log.LogErrorf("uploadPartCopyHandler: write part fail: requestID(%v) volume(%v) path(%v) uploadId(%v) part(%v) err(%v)",
@@ -419,6 +470,9 @@ func (o *ObjectNode) uploadPartCopyHandler(w http.ResponseWriter, r *http.Reques
 
 	Etag := "\"" + fsFileInfo.ETag + "\""
 	w.Header()[ETag] = []string{Etag}
+	if sseOpt != nil {
+		setSSEResponseHeaders(w, sseOpt.Type, sseOpt.CustomerKeyMD5)
+	}
 	response := NewS3CopyPartResult(Etag, fsFileInfo.CreateTime.UTC().Format(time.RFC3339)).String()
 
 	writeSuccessResponseXML(w, []byte(response))
@@ -667,6 +721,10 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		errorCode = KeyTooLong
 		return
 	}
+	if isReservedPath(param.Object()) {
+		errorCode = InvalidKey
+		return
+	}
 
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
@@ -770,6 +828,9 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if fsFileInfo.VersionId != "" {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
 	completeResult := CompleteMultipartResult{
 		Bucket: param.Bucket(),
 		Key:    param.Object(),
@@ -782,6 +843,7 @@ func (o *ObjectNode) completeMultipartUploadHandler(w http.ResponseWriter, r *ht
 	}
 
 	writeSuccessResponseXML(w, response)
+	o.notifyEvent(r, vol, EventObjectCreatedCompleteMultipart, newEventObject(param.Object(), fsFileInfo))
 }
 
 // Abort multipart
@@ -33,6 +33,7 @@ import (
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
 )
 
 var (
@@ -155,16 +156,42 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, xattr, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, xattr, err := vol.objectMetaWithVersion(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
 		}
 		return
 	}
+	if errorCode = setVersionHeaders(w, fileInfo); errorCode != nil {
+		return
+	}
+	if err = vol.checkPosixAcl(fileInfo.Inode, posixacl.PermRead); err != nil {
+		return
+	}
+
+	// an SSE-C object can only be read with the key it was encrypted with
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSECustomerKey(r.Header, false); errorCode != nil {
+		return
+	}
+	var enc *objectEncryption
+	if enc, err = loadObjectEncryption(xattr.XAttrs, sseOpt); err != nil {
+		log.LogErrorf("getObjectHandler: load object encryption fail: requestId(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	setSSEResponseHeaders(w, fileInfo.SSEType, fileInfo.SSEKeyMD5)
+	if fileInfo.ReplicationStatus != "" {
+		w.Header().Set(XAmzReplicationStatus, fileInfo.ReplicationStatus)
+	}
 
 	// header condition check
 	errorCode = CheckConditionInHeader(r, fileInfo)
@@ -219,9 +246,12 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(Expires, fileInfo.Expires)
 	}
 	if len(fileInfo.RetainUntilDate) > 0 {
-		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
+		w.Header().Set(XAmzObjectLockMode, fileInfo.RetentionMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if fileInfo.LegalHold {
+		w.Header().Set(XAmzObjectLockLegalHold, LegalHoldOn)
+	}
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -287,10 +317,13 @@ func (o *ObjectNode) getObjectHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		writer = w
 	}
+	if enc != nil {
+		writer = enc.DecryptWriter(writer, offset)
+	}
 
 	// read file
 	start = time.Now()
-	err = vol.readFile(fileInfo.Inode, fileSize, param.Object(), writer, offset, size)
+	err = vol.readObject(fileInfo, fileSize, param.Object(), writer, offset, size)
 	span.AppendTrackLog("file.r", start, err)
 	if err != nil {
 		log.LogErrorf("getObjectHandler: read file fail: requestID(%v) volume(%v) path(%v) offset(%v) size(%v) err(%v)",
@@ -398,16 +431,34 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	fileInfo, _, err := vol.objectMetaWithVersion(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("headObjectHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
 		}
 		return
 	}
+	if errorCode = setVersionHeaders(w, fileInfo); errorCode != nil {
+		return
+	}
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSECustomerKey(r.Header, false); errorCode != nil {
+		return
+	}
+	if errorCode = checkSSECustomerKey(fileInfo.SSEType, fileInfo.SSEKeyMD5, sseOpt); errorCode != nil {
+		return
+	}
+	setSSEResponseHeaders(w, fileInfo.SSEType, fileInfo.SSEKeyMD5)
+	if fileInfo.ReplicationStatus != "" {
+		w.Header().Set(XAmzReplicationStatus, fileInfo.ReplicationStatus)
+	}
 
 	// parse request header
 	match := r.Header.Get(IfMatch)
@@ -487,9 +538,12 @@ func (o *ObjectNode) headObjectHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(Expires, fileInfo.Expires)
 	}
 	if len(fileInfo.RetainUntilDate) > 0 {
-		w.Header().Set(XAmzObjectLockMode, ComplianceMode)
+		w.Header().Set(XAmzObjectLockMode, fileInfo.RetentionMode)
 		w.Header().Set(XAmzObjectLockRetainUntilDate, fileInfo.RetainUntilDate)
 	}
+	if fileInfo.LegalHold {
+		w.Header().Set(XAmzObjectLockLegalHold, LegalHoldOn)
+	}
 
 	// check request is whether contain param : partNumber
 	partNumber := r.URL.Query().Get(ParamPartNumber)
@@ -614,6 +668,11 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	var bypassGovernance bool
+	if bypassGovernance, errorCode = parseBypassGovernance(r, param, vol); errorCode != nil {
+		return
+	}
+
 	allowByAcl := false
 	if acl == nil && userInfo.UserID == vol.owner {
 		allowByAcl = true
@@ -651,16 +710,26 @@ func (o *ObjectNode) deleteObjectsHandler(w http.ResponseWriter, r *http.Request
 		if err = rateLimit.AcquireLimitResource(vol.owner, DELETE_OBJECT); err != nil {
 			return
 		}
-		if err1 := vol.DeletePath(object.Key); err1 != nil {
-			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) err(%v)",
-				GetRequestID(r), vol.Name(), object.Key, err1)
-			if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
+		if result, err1 := vol.DeleteObject(object.Key, object.VersionId, bypassGovernance); err1 != nil {
+			log.LogErrorf("deleteObjectsHandler: delete object failed: requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+				GetRequestID(r), vol.Name(), object.Key, object.VersionId, err1)
+			if ec, ok := err1.(*ErrorCode); ok && ec != AccessDenied {
+				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: ec.ErrorCode, Message: ec.ErrorMessage})
+			} else if !strings.Contains(err1.Error(), AccessDenied.ErrorMessage) {
 				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "InternalError", Message: err1.Error()})
 			} else {
 				deletedErrors = append(deletedErrors, Error{Key: object.Key, Code: "AccessDenied", Message: err1.Error()})
 			}
 		} else {
-			deletedObjects = append(deletedObjects, Deleted{Key: object.Key})
+			deleted := Deleted{Key: object.Key, VersionId: object.VersionId}
+			if result.DeleteMarker {
+				deleted.DeleteMarker = "true"
+				if object.VersionId == "" {
+					deleted.DeleteMarkerVersionId = result.VersionId
+				}
+			}
+			deletedObjects = append(deletedObjects, deleted)
+			o.notifyDeleteEvent(r, vol, object.Key, result)
 		}
 		rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 	}
@@ -730,6 +799,10 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = KeyTooLong
 		return
 	}
+	if isReservedPath(param.Object()) {
+		errorCode = InvalidKey
+		return
+	}
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
 		log.LogErrorf("copyObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
@@ -771,7 +844,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// parse x-amz-copy-source header
-	sourceBucket, sourceObject, _, err := extractSrcBucketKey(r)
+	sourceBucket, sourceObject, sourceVersionId, err := extractSrcBucketKey(r)
 	if err != nil {
 		log.LogErrorf("copyObjectHandler: copySource(%v) argument invalid: requestID(%v) volume(%v) err(%v)",
 			r.Header.Get(XAmzCopySource), GetRequestID(r), param.Bucket(), err)
@@ -802,16 +875,40 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// get object meta
 	start := time.Now()
-	fileInfo, _, err := sourceVol.ObjectMeta(sourceObject)
+	fileInfo, _, err := sourceVol.objectMetaWithVersion(sourceObject, sourceVersionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) err(%v)",
-			GetRequestID(r), sourceBucket, sourceObject, err)
+		log.LogErrorf("copyObjectHandler: get object meta fail: requestID(%v) srcVolume(%v) srcObject(%v) srcVersionId(%v) err(%v)",
+			GetRequestID(r), sourceBucket, sourceObject, sourceVersionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			if sourceVersionId != "" {
+				errorCode = NoSuchVersion
+			}
 		}
 		return
 	}
+	if fileInfo.DeleteMarker {
+		errorCode = CopySourceDeleteMarker
+		return
+	}
+	// the key of an SSE-C source object is provided by the copy source headers
+	var sourceSSE *SSEOption
+	if sourceSSE, errorCode = parseSSECustomerKey(r.Header, true); errorCode != nil {
+		return
+	}
+	if errorCode = checkSSECustomerKey(fileInfo.SSEType, fileInfo.SSEKeyMD5, sourceSSE); errorCode != nil {
+		return
+	}
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSEOption(r.Header); errorCode != nil {
+		return
+	}
+	if sseOpt, err = vol.resolveSSEOption(sseOpt); err != nil {
+		log.LogErrorf("copyObjectHandler: resolve sse option fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
 	if fileInfo.Size > SinglePutLimit {
 		errorCode = EntityTooLarge
 		return
@@ -887,9 +984,10 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		SSE:          sseOpt,
 	}
 	start = time.Now()
-	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, param.Object(), metadataDirective, opt)
+	fsFileInfo, err := vol.CopyFile(sourceVol, sourceObject, sourceVersionId, param.Object(), metadataDirective, opt, sourceSSE)
 	span.AppendTrackLog("file.c", start, err)
 	if err != nil && err != syscall.EINVAL && err != syscall.EFBIG {
 		log.LogErrorf("copyObjectHandler: Volume copy file fail: requestID(%v) Volume(%v) source(%v) target(%v) err(%v)",
@@ -909,6 +1007,13 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if fileInfo.VersionId != "" {
+		w.Header().Set(XAmzCopySourceVersionId, fileInfo.VersionId)
+	}
+	if fsFileInfo.VersionId != "" {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	setSSEResponseHeaders(w, fsFileInfo.SSEType, fsFileInfo.SSEKeyMD5)
 	copyResult := CopyResult{
 		ETag:         "\"" + fsFileInfo.ETag + "\"",
 		LastModified: formatTimeISO(fsFileInfo.ModifyTime),
@@ -920,6 +1025,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeSuccessResponseXML(w, response)
+	o.notifyEvent(r, vol, EventObjectCreatedCopy, newEventObject(param.Object(), fsFileInfo))
 }
 
 // List objects v1
@@ -1255,6 +1361,10 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode = KeyTooLong
 		return
 	}
+	if isReservedPath(param.Object()) {
+		errorCode = InvalidKey
+		return
+	}
 	var vol *Volume
 	if vol, err = o.getVol(param.Bucket()); err != nil {
 		log.LogErrorf("putObjectHandler: load volume fail: requestID(%v)  volume(%v) err(%v)",
@@ -1353,6 +1463,16 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	// Checking user-defined metadata
 	metadata := ParseUserDefinedMetadata(r.Header)
+	// Server side encryption
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSEOption(r.Header); errorCode != nil {
+		return
+	}
+	if sseOpt, err = vol.resolveSSEOption(sseOpt); err != nil {
+		log.LogErrorf("putObjectHandler: resolve sse option fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
 	// Audit file write
 	log.LogInfof("Audit: put object: requestID(%v) remote(%v) volume(%v) path(%v) type(%v)",
 		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), contentType)
@@ -1375,6 +1495,7 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          acl,
 		ObjectLock:   objetLock,
+		SSE:          sseOpt,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(param.Object(), reader, opt)
@@ -1396,6 +1517,12 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 
 	// set response header
 	w.Header()[ETag] = []string{wrapUnescapedQuot(fsFileInfo.ETag)}
+	if fsFileInfo.VersionId != "" {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	setSSEResponseHeaders(w, fsFileInfo.SSEType, fsFileInfo.SSEKeyMD5)
+
+	o.notifyEvent(r, vol, EventObjectCreatedPut, newEventObject(param.Object(), fsFileInfo))
 }
 
 // Post object
@@ -1486,6 +1613,10 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		errorCode.ErrorMessage = fmt.Sprintf("%s (%s)", errorCode.ErrorMessage, "Invalid utf8 string or the key is too long")
 		return
 	}
+	if isReservedPath(key) {
+		errorCode = InvalidKey
+		return
+	}
 
 	var aclInfo *AccessControlPolicy
 	if acl := formReq.MultipartFormValue("acl"); acl != "" {
@@ -1585,6 +1716,24 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// server side encryption is specified by form fields
+	sseHeader := make(http.Header)
+	for _, name := range []string{XAmzServerSideEncryption, XAmzServerSideEncryptionCustomerAlgorithm,
+		XAmzServerSideEncryptionCustomerKey, XAmzServerSideEncryptionCustomerKeyMD5} {
+		if value, ok := forms[strings.ToLower(name)]; ok {
+			sseHeader.Set(name, value)
+		}
+	}
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSEOption(sseHeader); errorCode != nil {
+		return
+	}
+	if sseOpt, err = vol.resolveSSEOption(sseOpt); err != nil {
+		log.LogErrorf("postObjectHandler: resolve sse option fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), key, err)
+		return
+	}
+
 	// flow control
 	var reader io.Reader
 	if size > DefaultFlowLimitSize {
@@ -1603,6 +1752,7 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 		Expires:      expires,
 		ACL:          aclInfo,
 		ObjectLock:   objetLock,
+		SSE:          sseOpt,
 	}
 	start := time.Now()
 	fsFileInfo, err := vol.PutObject(key, reader, putOpt)
@@ -1625,6 +1775,11 @@ func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
 	// set response header
 	etag := wrapUnescapedQuot(fsFileInfo.ETag)
 	w.Header()[ETag] = []string{etag}
+	if fsFileInfo.VersionId != "" {
+		w.Header().Set(XAmzVersionId, fsFileInfo.VersionId)
+	}
+	setSSEResponseHeaders(w, fsFileInfo.SSEType, fsFileInfo.SSEKeyMD5)
+	o.notifyEvent(r, vol, EventObjectCreatedPost, newEventObject(key, fsFileInfo))
 
 	// return response depending on success_action_xxx parameter
 	if successRedirectURL != nil {
@@ -1702,23 +1857,37 @@ func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request)
 	}
 	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
 
+	var bypassGovernance bool
+	if bypassGovernance, errorCode = parseBypassGovernance(r, param, vol); errorCode != nil {
+		return
+	}
+
 	// Audit deletion
-	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v)",
-		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	log.LogInfof("Audit: delete object: requestID(%v) remote(%v) volume(%v) path(%v) versionId(%v) bypassGovernance(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), versionId, bypassGovernance)
 
 	// Delete file
 	start := time.Now()
-	err = vol.DeletePath(param.Object())
+	result, err := vol.DeleteObject(param.Object(), versionId, bypassGovernance)
 	span.AppendTrackLog("file.d", start, err)
 	if err != nil {
 		log.LogErrorf("deleteObjectHandler: Volume delete file fail: "+
-			"requestID(%v) volume(%v) path(%v) err(%v)", GetRequestID(r), vol.Name(), param.Object(), err)
+			"requestID(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if strings.Contains(err.Error(), AccessDenied.ErrorMessage) {
 			err = AccessDenied
 		}
 		return
 	}
 
+	if result.VersionId != "" {
+		w.Header().Set(XAmzVersionId, result.VersionId)
+	}
+	if result.DeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+	}
+	o.notifyDeleteEvent(r, vol, param.Object(), result)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -2199,30 +2368,37 @@ func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Re
 
 	// get object meta
 	start := time.Now()
-	_, xattrs, err := vol.ObjectMeta(param.Object())
+	versionId := r.URL.Query().Get(ParamVersionId)
+	info, xattrs, err := vol.objectMetaWithVersion(param.Object(), versionId)
 	span.AppendTrackLog("meta.r", start, err)
 	if err != nil {
-		log.LogErrorf("getObjectRetentionHandler: get file meta fail: requestId(%v) volume(%v) path(%v) err(%v)",
-			GetRequestID(r), vol.Name(), param.Object(), err)
+		log.LogErrorf("getObjectRetentionHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
 		if err == syscall.ENOENT {
 			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
 		}
 		return
 	}
-	retainUntilDate := string(xattrs.Get(XAttrKeyOSSLock))
-	if retainUntilDate == "" {
-		errorCode = NoSuchObjectLockConfiguration
+	if info.DeleteMarker {
+		errorCode = MethodNotAllowed
 		return
 	}
-	retainUntilDateInt64, err := strconv.ParseInt(retainUntilDate, 10, 64)
+	state, err := parseObjectLockState(xattrs)
 	if err != nil {
 		log.LogErrorf("getObjectRetentionHandler: parse retainUntilDate fail: requestId(%v) volume(%v) path(%v) err(%v)",
 			GetRequestID(r), vol.Name(), param.Object(), err)
 		return
 	}
+	if state.retainUntilDate == 0 {
+		errorCode = NoSuchObjectLockConfiguration
+		return
+	}
 	var objectRetention ObjectRetention
-	objectRetention.Mode = ComplianceMode
-	objectRetention.RetainUntilDate = RetentionDate{Time: time.Unix(0, retainUntilDateInt64).UTC()}
+	objectRetention.Mode = state.mode
+	objectRetention.RetainUntilDate = RetentionDate{Time: time.Unix(0, state.retainUntilDate).UTC()}
 	b, err := xml.Marshal(objectRetention)
 	if err != nil {
 		log.LogErrorf("getObjectRetentionHandler: xml marshal fail: requestId(%v) volume(%v) result(%v) err(%v)",
@@ -2233,6 +2409,230 @@ func (o *ObjectNode) getObjectRetentionHandler(w http.ResponseWriter, r *http.Re
 	writeSuccessResponseXML(w, b)
 }
 
+// PutObjectRetention
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
+func (o *ObjectNode) putObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if objectLock == nil || objectLock.IsEmpty() {
+		errorCode = ObjectLockNotEnabled
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxObjectLockSize+1)); err != nil {
+		log.LogErrorf("putObjectRetentionHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxObjectLockSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var retention *ObjectRetention
+	if retention, errorCode = parseObjectRetention(body); errorCode != nil {
+		log.LogErrorf("putObjectRetentionHandler: parse retention fail: requestID(%v) volume(%v) retention(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	var bypassGovernance bool
+	if bypassGovernance, errorCode = parseBypassGovernance(r, param, vol); errorCode != nil {
+		return
+	}
+
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	err = vol.PutObjectRetention(param.Object(), versionId, retention, bypassGovernance)
+	span.AppendTrackLog("xattr.w", start, err)
+	if err != nil {
+		log.LogErrorf("putObjectRetentionHandler: put retention fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			err = nil
+			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (o *ObjectNode) getObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+
+	// get object meta
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	info, xattrs, err := vol.objectMetaWithVersion(param.Object(), versionId)
+	span.AppendTrackLog("meta.r", start, err)
+	if err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: get file meta fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
+		}
+		return
+	}
+	if info.DeleteMarker {
+		errorCode = MethodNotAllowed
+		return
+	}
+	status := string(xattrs.Get(XAttrKeyOSSLegalHold))
+	if status == "" {
+		errorCode = NoSuchObjectLegalHold
+		return
+	}
+	legalHold := ObjectLegalHold{XMLNS: XMLNS, Status: status}
+	b, err := MarshalXMLEntity(legalHold)
+	if err != nil {
+		log.LogErrorf("getObjectLegalHoldHandler: xml marshal fail: requestId(%v) volume(%v) result(%v) err(%v)",
+			GetRequestID(r), vol.Name(), legalHold, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, b)
+}
+
+// PutObjectLegalHold
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+func (o *ObjectNode) putObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	// check args
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load volume fail: requestID(%v) err(%v)",
+			GetRequestID(r), err)
+		return
+	}
+	var objectLock *ObjectLockConfig
+	if objectLock, err = vol.metaLoader.loadObjectLock(); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: load object lock fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if objectLock == nil || objectLock.IsEmpty() {
+		errorCode = ObjectLockNotEnabled
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxObjectLockSize+1)); err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxObjectLockSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var legalHold *ObjectLegalHold
+	if legalHold, errorCode = parseObjectLegalHold(body); errorCode != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: parse legal hold fail: requestID(%v) volume(%v) legalHold(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+
+	start := time.Now()
+	versionId := r.URL.Query().Get(ParamVersionId)
+	err = vol.PutObjectLegalHold(param.Object(), versionId, legalHold.Status)
+	span.AppendTrackLog("xattr.w", start, err)
+	if err != nil {
+		log.LogErrorf("putObjectLegalHoldHandler: put legal hold fail: requestId(%v) volume(%v) path(%v) versionId(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), versionId, err)
+		if err == syscall.ENOENT {
+			err = nil
+			errorCode = NoSuchKey
+			if versionId != "" {
+				errorCode = NoSuchVersion
+			}
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func parsePartInfo(partNumber uint64, fileSize uint64) (uint64, uint64, uint64, uint64) {
 	var partSize uint64
 	var partCount uint64
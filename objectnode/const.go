@@ -85,6 +85,20 @@ const (
 	XAmzSecurityToken               = "X-Amz-Security-Token" // #nosec G101
 	XAmzObjectLockMode              = "X-Amz-Object-Lock-Mode"
 	XAmzObjectLockRetainUntilDate   = "X-Amz-Object-Lock-Retain-Until-Date"
+	XAmzObjectLockLegalHold         = "X-Amz-Object-Lock-Legal-Hold"
+	XAmzBypassGovernanceRetention   = "X-Amz-Bypass-Governance-Retention"
+	XAmzVersionId                   = "x-amz-version-id"
+	XAmzDeleteMarker                = "x-amz-delete-marker"
+	XAmzCopySourceVersionId         = "x-amz-copy-source-version-id"
+	XAmzReplicationStatus           = "x-amz-replication-status"
+
+	XAmzServerSideEncryption                         = "x-amz-server-side-encryption"
+	XAmzServerSideEncryptionCustomerAlgorithm        = "x-amz-server-side-encryption-customer-algorithm"
+	XAmzServerSideEncryptionCustomerKey              = "x-amz-server-side-encryption-customer-key"
+	XAmzServerSideEncryptionCustomerKeyMD5           = "x-amz-server-side-encryption-customer-key-MD5"
+	XAmzCopySourceServerSideEncryptionCustomerAlgo   = "x-amz-copy-source-server-side-encryption-customer-algorithm"
+	XAmzCopySourceServerSideEncryptionCustomerKey    = "x-amz-copy-source-server-side-encryption-customer-key"
+	XAmzCopySourceServerSideEncryptionCustomerKeyMD5 = "x-amz-copy-source-server-side-encryption-customer-key-MD5"
 
 	HeaderNameXAmzDecodedContentLength = "x-amz-decoded-content-length"
 )
@@ -116,6 +130,9 @@ const (
 	ParamStartAfter = "start-after"
 	ParamKey        = "key"
 
+	ParamVersionId       = "versionId"
+	ParamVersionIdMarker = "version-id-marker"
+
 	ParamMaxParts       = "max-parts"
 	ParamUploadIdMarker = "upload-id-marker"
 	ParamPartNoMarker   = "part-number-marker"
@@ -143,17 +160,33 @@ const (
 
 // XAttr keys for ObjectNode compatible feature
 const (
-	XAttrKeyOSSPrefix       = "oss:"
-	XAttrKeyOSSETag         = "oss:etag"
-	XAttrKeyOSSTagging      = "oss:tagging"
-	XAttrKeyOSSPolicy       = "oss:policy"
-	XAttrKeyOSSACL          = "oss:acl"
-	XAttrKeyOSSMIME         = "oss:mime"
-	XAttrKeyOSSDISPOSITION  = "oss:disposition"
-	XAttrKeyOSSCORS         = "oss:cors"
-	XAttrKeyOSSLock         = "oss:lock"
-	XAttrKeyOSSCacheControl = "oss:cache"
-	XAttrKeyOSSExpires      = "oss:expires"
+	XAttrKeyOSSPrefix            = "oss:"
+	XAttrKeyOSSETag              = "oss:etag"
+	XAttrKeyOSSTagging           = "oss:tagging"
+	XAttrKeyOSSPolicy            = "oss:policy"
+	XAttrKeyOSSACL               = "oss:acl"
+	XAttrKeyOSSMIME              = "oss:mime"
+	XAttrKeyOSSDISPOSITION       = "oss:disposition"
+	XAttrKeyOSSCORS              = "oss:cors"
+	XAttrKeyOSSLock              = "oss:lock"
+	XAttrKeyOSSLockMode          = "oss:lock-mode"
+	XAttrKeyOSSLegalHold         = "oss:legal-hold"
+	XAttrKeyOSSCacheControl      = "oss:cache"
+	XAttrKeyOSSExpires           = "oss:expires"
+	XAttrKeyOSSVersioning        = "oss:versioning"
+	XAttrKeyOSSVersionId         = "oss:version-id"
+	XAttrKeyOSSDeleteMarker      = "oss:delete-marker"
+	XAttrKeyOSSEncryption        = "oss:encryption"
+	XAttrKeyOSSSSEType           = "oss:sse-type"
+	XAttrKeyOSSSSEKey            = "oss:sse-key"
+	XAttrKeyOSSSSEKeyMD5         = "oss:sse-key-md5"
+	XAttrKeyOSSSSEIV             = "oss:sse-iv"
+	XAttrKeyOSSSSEParts          = "oss:sse-parts"
+	XAttrKeyOSSSSEPartIV         = "oss:sse-part-iv"
+	XAttrKeyOSSTransition        = "oss:transition"
+	XAttrKeyOSSReplication       = "oss:replication"
+	XAttrKeyOSSReplicationStatus = "oss:replication-status"
+	XAttrKeyOSSNotification      = "oss:notification"
 
 	// Deprecated
 	XAttrKeyOSSETagDeprecated = "oss:tag"
@@ -0,0 +1,159 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
+func (o *ObjectNode) getBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ServerSideEncryptionConfiguration
+	if config, err = vol.metaLoader.loadEncryption(); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: load encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if !config.Enabled() {
+		errorCode = NoSuchEncryptionConfiguration
+		return
+	}
+	result := &ServerSideEncryptionConfiguration{XMLNS: XMLNS, Rules: config.Rules}
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("getBucketEncryptionHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
+func (o *ObjectNode) putBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxBucketEncryptionSize+1)); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxBucketEncryptionSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *ServerSideEncryptionConfiguration
+	if config, errorCode = parseBucketEncryption(body); errorCode != nil {
+		log.LogErrorf("putBucketEncryptionHandler: parse encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	// objects could not be written if the default encryption can not be applied
+	if sseKeyStore == nil {
+		errorCode = SSEMasterKeyNotConfigured
+		return
+	}
+	config.XMLNS = ""
+	if body, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: xml marshal encryption config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketEncryption(body, vol); err != nil {
+		log.LogErrorf("putBucketEncryptionHandler: store encryption config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(config)
+
+	log.LogInfof("Audit: put bucket encryption: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
+func (o *ObjectNode) deleteBucketEncryptionHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = deleteBucketEncryption(vol); err != nil {
+		log.LogErrorf("deleteBucketEncryptionHandler: delete bucket encryption fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeEncryption(nil)
+
+	log.LogInfof("Audit: delete bucket encryption: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -34,6 +34,16 @@ type FSFileInfo struct {
 	Expires         string
 	Metadata        map[string]string `graphql:"-"` // User-defined metadata
 	RetainUntilDate string
+	RetentionMode   string
+	LegalHold       bool
+	VersionId       string
+	DeleteMarker    bool
+	SSEType         string
+	SSEKeyMD5       string
+	// TransitionVolume is the cold volume keeping the data after a lifecycle transition
+	TransitionVolume string
+	// ReplicationStatus is the status of the cross-cluster replication of the object
+	ReplicationStatus string
 }
 
 type Prefixes []string
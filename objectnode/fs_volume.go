@@ -16,6 +16,7 @@ package objectnode
 
 import (
 	"context"
+	"crypto/cipher"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
@@ -24,6 +25,7 @@ import (
 	"hash"
 	"io"
 	"os"
+	"path"
 	"reflect"
 	"sort"
 	"strconv"
@@ -42,6 +44,8 @@ import (
 	"github.com/cubefs/cubefs/util/buf"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
+	"github.com/cubefs/cubefs/util/sse"
 )
 
 const (
@@ -92,6 +96,7 @@ type PutFileOption struct {
 	CacheControl string
 	Expires      string
 	ObjectLock   *ObjectLockConfig
+	SSE          *SSEOption
 }
 
 type ListFilesV1Option struct {
@@ -198,6 +203,30 @@ func (v *Volume) loadOSSMeta() {
 		return
 	}
 	v.metaLoader.storeObjectLock(objectlock)
+
+	var versioning *VersioningConfiguration
+	if versioning, err = v.loadBucketVersioning(); err != nil {
+		return
+	}
+	v.metaLoader.storeVersioning(versioning)
+
+	var encryption *ServerSideEncryptionConfiguration
+	if encryption, err = v.loadBucketEncryption(); err != nil {
+		return
+	}
+	v.metaLoader.storeEncryption(encryption)
+
+	var replication *ReplicationConfiguration
+	if replication, err = v.loadBucketReplication(); err != nil {
+		return
+	}
+	v.metaLoader.storeReplication(replication)
+
+	var notification *NotificationConfiguration
+	if notification, err = v.loadBucketNotification(); err != nil {
+		return
+	}
+	v.metaLoader.storeNotification(notification)
 	v.metaLoader.setSynced()
 }
 
@@ -277,6 +306,66 @@ func (v *Volume) loadObjectLock() (configuration *ObjectLockConfig, err error) {
 	return configuration, nil
 }
 
+func (v *Volume) loadBucketVersioning() (configuration *VersioningConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSVersioning); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &VersioningConfiguration{}
+	if err = xml.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketEncryption() (configuration *ServerSideEncryptionConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSEncryption); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ServerSideEncryptionConfiguration{}
+	if err = xml.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketReplication() (configuration *ReplicationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSReplication); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &ReplicationConfiguration{}
+	if err = xml.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
+func (v *Volume) loadBucketNotification() (configuration *NotificationConfiguration, err error) {
+	var raw []byte
+	if raw, err = v.store.Get(v.name, bucketRootPath, XAttrKeyOSSNotification); err != nil {
+		return
+	}
+	if len(raw) == 0 {
+		return
+	}
+	configuration = &NotificationConfiguration{}
+	if err = xml.Unmarshal(raw, configuration); err != nil {
+		return
+	}
+	return configuration, nil
+}
+
 func (v *Volume) getInodeFromPath(path string) (inode uint64, err error) {
 	if path == "/" {
 		return volumeRootInode, nil
@@ -358,7 +447,14 @@ func (v *Volume) IsEmpty() bool {
 		return false
 	}
 
-	if len(children) > 0 {
+	for _, child := range children {
+		// the version directory and the replication queue do not count if they are empty
+		if child.Name == VersionsDirName || child.Name == ReplicationDirName {
+			versions, err := v.mw.ReadDirLimit_ll(child.Inode, "", 1)
+			if err == nil && len(versions) == 0 {
+				continue
+			}
+		}
 		log.LogDebugf("IsEmpty: parent ino(%v), children: %v", proto.RootIno, children)
 		return false
 	}
@@ -657,6 +753,14 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		return
 	}
 
+	if err = v.checkPosixAcl(parentId, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return
+	}
+	fileMode, aclXattrs, err := v.inheritPosixAcl(parentId, DefaultFileMode)
+	if err != nil {
+		return
+	}
+
 	// check file mode
 	oldInode, lookupMode, err := v.mw.Lookup_ll(parentId, lastPathItem.Name)
 	if err != nil && err != syscall.ENOENT {
@@ -673,17 +777,25 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 
 	// check whether existing object is protected by object lock
 	if oldInode != 0 && opt != nil && opt.ObjectLock != nil {
-		err = isObjectLocked(v, oldInode, lastPathItem.Name, path)
+		err = v.checkOverwriteLock(oldInode, lastPathItem.Name, path)
 		if err != nil {
 			return
 		}
 	}
 
+	var enc *objectEncryption
+	if opt != nil && opt.SSE != nil {
+		if enc, err = newObjectEncryption(opt.SSE); err != nil {
+			log.LogErrorf("PutObject: new object encryption fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+	}
+
 	// Intermediate data during the writing of new versions is managed through invisible files.
 	// This file has only inode but no dentry. In this way, this temporary file can be made invisible
 	// in the true sense. In order to avoid the adverse impact of other user operations on temporary data.
 	var invisibleTempDataInode *proto.InodeInfo
-	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), fixedPath); err != nil {
+	if invisibleTempDataInode, err = v.mw.InodeCreate_ll(parentId, uint32(fileMode), 0, 0, nil, make([]uint64, 0), fixedPath); err != nil {
 		log.LogErrorf("PutObject: inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
 		return
 	}
@@ -698,6 +810,9 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 			_ = v.mw.Evict(invisibleTempDataInode.Inode, fixedPath)
 		}
 	}()
+	if err = v.setInheritedPosixAcl(invisibleTempDataInode.Inode, aclXattrs); err != nil {
+		return
+	}
 
 	md5Hash := md5.New()
 	if err = v.ec.OpenStream(invisibleTempDataInode.Inode); err != nil {
@@ -712,14 +827,19 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}()
 
+	// the ETag of an encrypted object is the MD5 of the plaintext
+	dataReader, dataHash := reader, hash.Hash(md5Hash)
+	if enc != nil {
+		dataReader, dataHash = enc.EncryptReader(io.TeeReader(reader, md5Hash), sse.Part{}), nil
+	}
 	if proto.IsCold(v.volType) {
-		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+		if _, err = v.ebsWrite(invisibleTempDataInode.Inode, dataReader, dataHash); err != nil {
 			log.LogErrorf("PutObject: ebs write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
 		}
 	} else {
-		if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+		if _, err = v.streamWrite(invisibleTempDataInode.Inode, dataReader, dataHash); err != nil {
 			log.LogErrorf("PutObject: stream write fail: volume(%v) path(%v) inode(%v) err(%v)",
 				v.name, path, invisibleTempDataInode.Inode, err)
 			return
@@ -772,7 +892,12 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		attr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
 	if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-		attr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, opt.ObjectLock.ToRetention())
+		opt.ObjectLock.ToRetention().setXAttrs(attr.XAttrs, finalInode.ModifyTime)
+	}
+	if enc != nil {
+		for key, value := range enc.XAttrs() {
+			attr.XAttrs[key] = value
+		}
 	}
 
 	// If user-defined metadata have been specified, use extend attributes for storage.
@@ -785,6 +910,7 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		}
 	}
 
+	v.markReplication(path, attr.XAttrs)
 	if err = v.mw.BatchSetXAttr_ll(invisibleTempDataInode.Inode, attr.XAttrs); err != nil {
 		log.LogErrorf("PutObject: BatchSetXAttr_ll fail: volume(%v) path(%v) inode(%v) attrs(%v) err(%v)",
 			v.name, path, invisibleTempDataInode.Inode, attr.XAttrs, err)
@@ -802,14 +928,21 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
 	}
+	if enc != nil {
+		fsInfo.SSEType, fsInfo.SSEKeyMD5 = enc.Type, enc.KeyMD5
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode, false, fixedPath)
+	fsInfo.VersionId, err = v.applyInodeToDEntry(parentId, lastPathItem.Name, invisibleTempDataInode.Inode, false, fixedPath)
 	if err != nil {
 		log.LogErrorf("PutObject: apply new inode to dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 			parentId, lastPathItem.Name, invisibleTempDataInode.Inode, err)
 		return
 	}
+	if fsInfo.VersionId != "" {
+		attr.XAttrs[XAttrKeyOSSVersionId] = fsInfo.VersionId
+	}
+	v.queueReplication(path, invisibleTempDataInode.Inode, attr.XAttrs)
 
 	// force updating dentry and attrs in cache
 	updateDentryCache(parentId, invisibleTempDataInode.Inode, DefaultFileMode, lastPathItem.Name, v.name)
@@ -818,7 +951,19 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 	return fsInfo, nil
 }
 
-func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool, fullPath string) (err error) {
+func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64, isCompleteMultipart bool, fullPath string,
+) (versionId string, err error) {
+	var versioning *VersioningConfiguration
+	if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("applyInodeToDEntry: load versioning fail: volume(%v) err(%v)", v.name, err)
+		return
+	}
+	if versionId, err = v.assignVersionId(inode, versioning); err != nil {
+		log.LogErrorf("applyInodeToDEntry: assign version id fail: parentID(%v) name(%v) inode(%v) err(%v)",
+			parentId, name, inode, err)
+		return
+	}
+
 	var existMode uint32
 	_, existMode, err = v.mw.Lookup_ll(parentId, name) // exist object inode
 	if err != nil && err != syscall.ENOENT {
@@ -841,15 +986,19 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 			err = syscall.EINVAL
 			return
 		}
-		// current implementation doesn't support object versioning, so uploading a object with a key already existed in bucket
-		// is implemented with replacing the old one instead.
+		// If versioning is not configured, uploading a object with a key already existed in bucket
+		// is implemented with replacing the old one. Otherwise the old one is kept as a noncurrent version.
 		// refer: https://docs.aws.amazon.com/AmazonS3/latest/userguide/upload-objects.html
-		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath); err != nil {
+		if err = v.applyInodeToExistDentry(parentId, name, inode, isCompleteMultipart, fullPath, versioning); err != nil {
 			log.LogErrorf("applyInodeToDEntry: apply inode to exist dentry fail: parentID(%v) name(%v) inode(%v) err(%v)",
 				parentId, name, inode, err)
 			return
 		}
 	}
+	if versioning.Suspended() {
+		// the new object takes over the null version, so the noncurrent null version has to be removed
+		v.removeNullVersion(fullPath)
+	}
 	return
 }
 
@@ -861,6 +1010,12 @@ func (v *Volume) applyInodeToDEntry(parentId uint64, name string, inode uint64,
 // This method will only returns internal system errors.
 // This method will not return syscall.ENOENT error
 func (v *Volume) DeletePath(path string) (err error) {
+	return v.deletePath(path, false)
+}
+
+// deletePath deletes the path, the object protected by a governance retention
+// can be deleted only if bypassGovernance is set.
+func (v *Volume) deletePath(path string, bypassGovernance bool) (err error) {
 	defer func() {
 		// Audit behavior
 		log.LogInfof("Audit: DeletePath: volume(%v) path(%v), err(%v)", v.name, path, err)
@@ -891,6 +1046,9 @@ func (v *Volume) DeletePath(path string) (err error) {
 			return
 		}
 	}
+	if err = v.checkPosixAcl(parent, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return
+	}
 	// check whether object is protected by object lock
 	objetLock, err := v.metaLoader.loadObjectLock()
 	if err != nil {
@@ -900,7 +1058,9 @@ func (v *Volume) DeletePath(path string) (err error) {
 	log.LogInfof("DeletePath: delete: volume(%v) path(%v) inode(%v)", v.name, path, ino)
 
 	// delete dentry with condition when objectlock is open
-	if objetLock != nil {
+	if objetLock != nil && bypassGovernance {
+		_, err = v.mw.DeleteWithCondBypassGovernance_ll(parent, ino, name, mode.IsDir(), path)
+	} else if objetLock != nil {
 		_, err = v.mw.DeleteWithCond_ll(parent, ino, name, mode.IsDir(), path)
 	} else {
 		_, err = v.mw.Delete_ll(parent, name, mode.IsDir(), path)
@@ -963,6 +1123,17 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	if opt != nil && opt.ACL != nil {
 		extend[XAttrKeyOSSACL] = opt.ACL.Encode()
 	}
+	// All parts are encrypted with the data key of the upload.
+	if opt != nil && opt.SSE != nil {
+		var enc *objectEncryption
+		if enc, err = newObjectEncryption(opt.SSE); err != nil {
+			log.LogErrorf("InitMultipart: new object encryption fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+			return
+		}
+		for key, value := range enc.XAttrs() {
+			extend[key] = value
+		}
+	}
 
 	if v.mw.EnableQuota {
 		var parentId uint64
@@ -986,7 +1157,7 @@ func (v *Volume) InitMultipart(path string, opt *PutFileOption) (multipartID str
 	return multipartID, nil
 }
 
-func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader) (*FSFileInfo, error) {
+func (v *Volume) WritePart(path string, multipartId string, partId uint16, reader io.Reader, sseOpt *SSEOption) (*FSFileInfo, error) {
 	var exist bool
 	var err error
 	defer func() {
@@ -998,6 +1169,13 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 	var fInfo *FSFileInfo
 	_, fileName := splitPath(path)
 
+	var enc *objectEncryption
+	if enc, err = v.multipartEncryption(path, multipartId, sseOpt); err != nil {
+		log.LogErrorf("WritePart: load multipart encryption fail: volume(%v) path(%v) multipartID(%v) partID(%v) err(%v)",
+			v.name, path, multipartId, partId, err)
+		return nil, err
+	}
+
 	// create temp file (inode only, invisible for user)
 	var tempInodeInfo *proto.InodeInfo
 	if tempInodeInfo, err = v.mw.InodeCreate_ll(0, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
@@ -1046,15 +1224,25 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 				v.name, path, multipartId, partId, tempInodeInfo.Inode, closeErr)
 		}
 	}()
+	dataReader, dataHash := reader, hash.Hash(md5Hash)
+	if enc != nil {
+		var part sse.Part
+		if part, err = v.newSSEPart(tempInodeInfo.Inode, partId); err != nil {
+			log.LogErrorf("WritePart: store part IV fail: volume(%v) path(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+				v.name, path, multipartId, partId, tempInodeInfo.Inode, err)
+			return nil, err
+		}
+		dataReader, dataHash = enc.EncryptReader(io.TeeReader(reader, md5Hash), part), nil
+	}
 	if proto.IsCold(v.volType) {
-		if size, err = v.ebsWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+		if size, err = v.ebsWrite(tempInodeInfo.Inode, dataReader, dataHash); err != nil {
 			log.LogErrorf("WritePart: ebs write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
 		}
 	} else {
 		// Write data to data node
-		if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+		if size, err = v.streamWrite(tempInodeInfo.Inode, dataReader, dataHash); err != nil {
 			log.LogErrorf("WritePart: stream write fail: volume(%v) inode(%v) multipartID(%v) partID(%v) err(%v)",
 				v.name, tempInodeInfo.Inode, multipartId, partId, err)
 			return nil, err
@@ -1163,17 +1351,24 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		return
 	}
 	if oldInode != 0 && objectLock != nil {
-		err = isObjectLocked(v, oldInode, filename, path)
+		err = v.checkOverwriteLock(oldInode, filename, path)
 		if err != nil {
 			return
 		}
 	}
+	if err = v.checkPosixAcl(parentId, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return
+	}
+	fileMode, aclXattrs, err := v.inheritPosixAcl(parentId, DefaultFileMode)
+	if err != nil {
+		return
+	}
 	parts := multipartInfo.Parts
 	sort.SliceStable(parts, func(i, j int) bool { return parts[i].ID < parts[j].ID })
 
 	// create inode for complete data
 	var completeInodeInfo *proto.InodeInfo
-	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+	if completeInodeInfo, err = v.mw.InodeCreate_ll(parentId, uint32(fileMode), 0, 0, nil, make([]uint64, 0), path); err != nil {
 		log.LogErrorf("CompleteMultipart: meta inode create fail: volume(%v) path(%v) multipartID(%v) err(%v)",
 			v.name, path, multipartID, err)
 		return
@@ -1190,6 +1385,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 			}
 		}
 	}()
+	if err = v.setInheritedPosixAcl(completeInodeInfo.Inode, aclXattrs); err != nil {
+		return
+	}
 
 	// merge complete extent keys
 	var size uint64
@@ -1282,8 +1480,23 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		}
 	}
 	if objectLock != nil && objectLock.ToRetention() != nil {
-		attrs[XAttrKeyOSSLock] = formatRetentionDateStr(finalInode.ModifyTime, objectLock.ToRetention())
+		objectLock.ToRetention().setXAttrs(attrs, finalInode.ModifyTime)
 	}
+	// parts are encrypted independently, the reader needs the layout of parts
+	if len(extend[XAttrKeyOSSSSEType]) > 0 {
+		sseParts := make([]sse.Part, 0, len(parts))
+		for _, part := range parts {
+			var sp sse.Part
+			if sp, err = v.loadSSEPart(part); err != nil {
+				log.LogErrorf("CompleteMultipart: load part IV fail: volume(%v) multipartID(%v) partID(%v) inode(%v) err(%v)",
+					v.name, multipartID, part.ID, part.Inode, err)
+				return nil, err
+			}
+			sseParts = append(sseParts, sp)
+		}
+		attrs[XAttrKeyOSSSSEParts] = sse.EncodeParts(sseParts)
+	}
+	v.markReplication(path, attrs)
 	if err = v.mw.BatchSetXAttr_ll(finalInode.Inode, attrs); err != nil {
 		log.LogErrorf("CompleteMultipart: store multipart extend fail: volume(%v) multipartID(%v) inode(%v) "+
 			"attrs(%v) err(%v)", v.name, multipartID, finalInode.Inode, attrs, err)
@@ -1291,11 +1504,13 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}
 
 	// apply new inode to dentry
-	if err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode, true, path); err != nil {
+	var versionId string
+	if versionId, err = v.applyInodeToDEntry(parentId, filename, completeInodeInfo.Inode, true, path); err != nil {
 		log.LogErrorf("CompleteMultipart: apply inode to dentry fail: volume(%v) multipartID(%v) parentId(%v) "+
 			"fileName(%v) inode(%v) err(%v)", v.name, multipartID, parentId, filename, completeInodeInfo.Inode, err)
 		return
 	}
+	v.queueReplication(path, completeInodeInfo.Inode, attrs)
 
 	// remove multipart
 	var err2 error
@@ -1327,6 +1542,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 	}()
 
 	// force updating dentry and attrs in cache
+	if versionId != "" {
+		attrs[XAttrKeyOSSVersionId] = versionId
+	}
 	updateDentryCache(parentId, completeInodeInfo.Inode, DefaultFileMode, filename, v.name)
 	putAttrCache(attrItem, v.name)
 
@@ -1341,6 +1559,9 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 		ModifyTime: time.Now(),
 		ETag:       etagValue.ETag(),
 		Inode:      finalInode.Inode,
+		VersionId:  versionId,
+		SSEType:    extend[XAttrKeyOSSSSEType],
+		SSEKeyMD5:  extend[XAttrKeyOSSSSEKeyMD5],
 	}
 
 	return fInfo, nil
@@ -1458,7 +1679,9 @@ func (v *Volume) applyInodeToNewDentry(parentID uint64, name string, inode uint6
 	return
 }
 
-func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uint64, isCompleteMultipart bool, fullPath string) (err error) {
+func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uint64, isCompleteMultipart bool, fullPath string,
+	versioning *VersioningConfiguration,
+) (err error) {
 	var oldInode uint64
 	oldInode, err = v.mw.DentryUpdate_ll(parentID, name, inode, fullPath)
 	if err != nil {
@@ -1485,6 +1708,18 @@ func (v *Volume) applyInodeToExistDentry(parentID uint64, name string, inode uin
 		}
 	}
 
+	// keep the old inode as a noncurrent version, the following unlink only drops the link from the dentry
+	if versioning.Configured() {
+		var archived bool
+		if archived, err = v.archiveVersion(fullPath, oldInode, versioning); err != nil {
+			log.LogErrorf("applyInodeToExistDentry: archive old version fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, fullPath, oldInode, err)
+		} else if archived {
+			log.LogDebugf("applyInodeToExistDentry: archive old version: volume(%v) path(%v) inode(%v)",
+				v.name, fullPath, oldInode)
+		}
+	}
+
 	// unlink and evict old inode
 	log.LogWarnf("applyInodeToExistDentry: unlink inode: volume(%v) inode(%v)", v.name, oldInode)
 	if _, err = v.mw.InodeUnlink_ll(oldInode, fullPath); err != nil {
@@ -1548,6 +1783,39 @@ func (v *Volume) readFile(inode, inodeSize uint64, path string, writer io.Writer
 	}
 }
 
+// readObject reads the data of an object, which lives in a cold volume once the
+// object has been transitioned by lifecycle.
+func (v *Volume) readObject(info *FSFileInfo, inodeSize uint64, path string, writer io.Writer, offset, size uint64) error {
+	if info.TransitionVolume == "" {
+		return v.readFile(info.Inode, inodeSize, path, writer, offset, size)
+	}
+	dataVol, dataInode, err := v.transitionedData(info.Inode, info.TransitionVolume)
+	if err != nil {
+		return err
+	}
+	return dataVol.readFile(dataInode, inodeSize, path, writer, offset, size)
+}
+
+// transitionedData returns the cold volume and the inode keeping the data of a transitioned
+// inode, lcnode stores the data at "/<hot volume>/<hot inode>" of the cold volume.
+func (v *Volume) transitionedData(inode uint64, coldVolume string) (dataVol *Volume, dataInode uint64, err error) {
+	if volumeLoader == nil {
+		return nil, 0, syscall.ENOENT
+	}
+	if dataVol, err = volumeLoader(coldVolume); err != nil {
+		log.LogErrorf("transitionedData: load cold volume fail: volume(%v) inode(%v) coldVolume(%v) err(%v)",
+			v.name, inode, coldVolume, err)
+		return
+	}
+	dataPath := path.Join(pathSep, v.name, strconv.FormatUint(inode, 10))
+	if _, dataInode, _, _, err = dataVol.recursiveLookupTarget(dataPath, false); err != nil {
+		log.LogErrorf("transitionedData: lookup transitioned data fail: volume(%v) inode(%v) coldVolume(%v) path(%v) err(%v)",
+			v.name, inode, coldVolume, dataPath, err)
+		return
+	}
+	return
+}
+
 func (v *Volume) readEbs(inode, inodeSize uint64, path string, writer io.Writer, offset, size uint64) error {
 	upper := size + offset
 	if upper > inodeSize {
@@ -1661,6 +1929,10 @@ func (v *Volume) ReadFile(path string, writer io.Writer, offset, size uint64) er
 }
 
 func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	if isReservedPath(path) {
+		err = syscall.ENOENT
+		return
+	}
 	// process path
 	var inode uint64
 	var mode os.FileMode
@@ -1686,7 +1958,13 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		}
 		break
 	}
+	return v.objectMetaByInode(path, inode, mode, inoInfo)
+}
 
+// objectMetaByInode assembles the object information of the given inode, which may
+// be either the current version of the object or one of its noncurrent versions.
+func (v *Volume) objectMetaByInode(path string, inode uint64, mode os.FileMode, inoInfo *proto.InodeInfo,
+) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
 	var (
 		etagValue    ETagValue
 		mimeType     string
@@ -1785,6 +2063,15 @@ func (v *Volume) ObjectMeta(path string) (info *FSFileInfo, xattr *proto.XAttrIn
 		Expires:         expires,
 		Metadata:        metadata,
 		RetainUntilDate: retainUntilDate,
+		RetentionMode:   retentionMode(xattr),
+		LegalHold:       string(xattr.Get(XAttrKeyOSSLegalHold)) == LegalHoldOn,
+		VersionId:       string(xattr.Get(XAttrKeyOSSVersionId)),
+		DeleteMarker:    len(xattr.Get(XAttrKeyOSSDeleteMarker)) > 0,
+		SSEType:         string(xattr.Get(XAttrKeyOSSSSEType)),
+		SSEKeyMD5:       string(xattr.Get(XAttrKeyOSSSSEKeyMD5)),
+
+		TransitionVolume:  string(xattr.Get(XAttrKeyOSSTransition)),
+		ReplicationStatus: string(xattr.Get(XAttrKeyOSSReplicationStatus)),
 	}
 	return
 }
@@ -1997,7 +2284,7 @@ func (v *Volume) recursiveMakeDirectory(path string) (partentIno uint64, err err
 		}
 		if err == syscall.ENOENT {
 			var info *proto.InodeInfo
-			info, err = v.mw.Create_ll(partentIno, pathItem.Name, uint32(DefaultDirMode), 0, 0, nil, path[:pathIterator.cursor])
+			info, err = v.createWithPosixAcl(partentIno, pathItem.Name, DefaultDirMode, path[:pathIterator.cursor])
 			if err != nil && err == syscall.EEXIST {
 				existInode, mode, e := v.mw.Lookup_ll(partentIno, pathItem.Name)
 				if e != nil {
@@ -2301,6 +2588,9 @@ readDir:
 		if child.Name == lastKey {
 			continue
 		}
+		if len(dirs) == 0 && (child.Name == VersionsDirName || child.Name == ReplicationDirName) {
+			continue
+		}
 		path := strings.Join(append(dirs, child.Name), pathSep)
 		if os.FileMode(child.Type).IsDir() {
 			path += pathSep
@@ -2411,7 +2701,7 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 	}
 
 	// Get MD5 information in batches, then update to fileInfos
-	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated}
+	keys := []string{XAttrKeyOSSETag, XAttrKeyOSSETagDeprecated, XAttrKeyOSSVersionId}
 	xattrs, err := v.mw.BatchGetXAttr(inodes, keys)
 	if err != nil {
 		log.LogErrorf("supplyListFileInfo: batch get xattr fail, inodes(%v), err(%v)", inodes, err)
@@ -2438,6 +2728,7 @@ func (v *Volume) supplyListFileInfo(fileInfos []*FSFileInfo) (err error) {
 			if len(rawETag) > 0 {
 				etagValue = ParseETagValue(rawETag)
 			}
+			fileInfo.VersionId = string(xattr.Get(XAttrKeyOSSVersionId))
 		}
 		if !etagValue.Valid() || etagValue.TS.Before(fileInfo.ModifyTime) {
 			// The ETag is invalid or outdated then generate a new ETag and make update.
@@ -2573,7 +2864,9 @@ func (v *Volume) ListParts(path, uploadId string, maxParts, partNumberMarker uin
 	return parts, nextMarker, isTruncated, nil
 }
 
-func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective string, opt *PutFileOption) (info *FSFileInfo, err error) {
+func (v *Volume) CopyFile(sv *Volume, sourcePath, sourceVersionId, targetPath, metaDirective string, opt *PutFileOption,
+	sourceSSE *SSEOption,
+) (info *FSFileInfo, err error) {
 	defer func() {
 		log.LogInfof("Audit: copy file: source path(%v) target path(%v) err(%v)",
 			sourcePath, targetPath, err)
@@ -2587,7 +2880,21 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		sInodeInfo *proto.InodeInfo
 	)
 
-	if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
+	// copying from a noncurrent version always creates a new object even if the source path is the target path
+	sourceIsCurrent := true
+	if sourceVersionId != "" {
+		var entry *objectVersionEntry
+		if entry, err = sv.lookupObjectVersion(sourcePath, sourceVersionId); err != nil {
+			log.LogErrorf("CopyFile: look up source version fail, source path(%v) version(%v) err(%v)",
+				sourcePath, sourceVersionId, err)
+			return
+		}
+		if entry.DeleteMarker {
+			return nil, syscall.ENOENT
+		}
+		_, sName = splitPath(sourcePath)
+		sInode, sMode, sourceIsCurrent = entry.Inode, entry.Mode, entry.IsCurrent
+	} else if _, sInode, sName, sMode, err = sv.recursiveLookupTarget(sourcePath, false); err != nil {
 		log.LogErrorf("CopyFile: look up source path fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
@@ -2595,17 +2902,53 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		log.LogErrorf("CopyFile: get source path inode info fail, source path(%v) err(%v)", sourcePath, err)
 		return
 	}
+	if err = sv.checkPosixAcl(sInode, posixacl.PermRead); err != nil {
+		return
+	}
 	if sInodeInfo.Size > MaxCopyObjectSize {
 		log.LogErrorf("CopyFile: copy source path file size greater than 5GB, source path(%v), target path(%v)", sourcePath, targetPath)
 		return nil, syscall.EFBIG
 	}
-	if err = sv.ec.OpenStream(sInode); err != nil {
+
+	// the source object is decrypted and the target object is encrypted with its own data key
+	var (
+		sXAttr *proto.XAttrInfo
+		srcEnc *objectEncryption
+		tgtEnc *objectEncryption
+	)
+	if sXAttr, err = sv.mw.XAttrGetAll_ll(sInode); err != nil {
+		log.LogErrorf("CopyFile: get source path xattr fail, source path(%v) inode(%v) err(%v)", sourcePath, sInode, err)
+		return
+	}
+	if srcEnc, err = loadObjectEncryption(sXAttr.XAttrs, sourceSSE); err != nil {
+		log.LogErrorf("CopyFile: load source encryption fail, source path(%v) inode(%v) err(%v)", sourcePath, sInode, err)
+		return
+	}
+	if srcEnc == nil && sourceSSE != nil {
+		return nil, InvalidArgument
+	}
+	if opt != nil && opt.SSE != nil {
+		if tgtEnc, err = newObjectEncryption(opt.SSE); err != nil {
+			log.LogErrorf("CopyFile: new target encryption fail, target path(%v) err(%v)", targetPath, err)
+			return
+		}
+	}
+	sseChanged := (srcEnc == nil) != (tgtEnc == nil) ||
+		(srcEnc != nil && (srcEnc.Type != tgtEnc.Type || srcEnc.KeyMD5 != tgtEnc.KeyMD5))
+	// the data of a transitioned source lives in the cold volume
+	dataVol, dataInode := sv, sInode
+	if coldVolume := string(sXAttr.Get(XAttrKeyOSSTransition)); coldVolume != "" {
+		if dataVol, dataInode, err = sv.transitionedData(sInode, coldVolume); err != nil {
+			return
+		}
+	}
+	if err = dataVol.ec.OpenStream(dataInode); err != nil {
 		log.LogErrorf("CopyFile: open source path stream fail, source path(%v) source path inode(%v) err(%v)",
 			sourcePath, sInode, err)
 		return
 	}
 	defer func() {
-		if closeErr := sv.ec.CloseStream(sInode); closeErr != nil {
+		if closeErr := dataVol.ec.CloseStream(dataInode); closeErr != nil {
 			log.LogErrorf("CopyFile: close source path stream fail: source path(%v) source path inode(%v) err(%v)",
 				sourcePath, sInode, closeErr)
 		}
@@ -2614,14 +2957,15 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var xattr *proto.XAttrInfo
 	// if source path is same with target path, just reset file metadata
 	// source path is same with target path, and metadata directive is not 'REPLACE', objectNode does nothing
-	if targetPath == sourcePath && v.name == sv.name {
+	// unless the object is re-encrypted
+	if targetPath == sourcePath && v.name == sv.name && sourceIsCurrent && !sseChanged {
 		if metaDirective != MetadataDirectiveReplace {
 			log.LogInfof("CopyFile: targetPath(%v) is equal with sourcePath(%v),but metaDirective(%v) is not REPLACE",
 				targetPath, sourcePath, metaDirective)
 		} else {
 			// check whether target object is protected by object lock
 			if opt != nil && opt.ObjectLock != nil {
-				err = isObjectLocked(v, sInode, sName, sourcePath)
+				err = v.checkOverwriteLock(sInode, sName, sourcePath)
 				if err != nil {
 					return
 				}
@@ -2650,7 +2994,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 				attr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 			}
 			if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-				attr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(time.Now(), opt.ObjectLock.ToRetention())
+				opt.ObjectLock.ToRetention().setXAttrs(attr.XAttrs, time.Now())
 			}
 			// If user-defined metadata have been specified, use extend attributes for storage.
 			if opt != nil && len(opt.Metadata) > 0 {
@@ -2661,6 +3005,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 						sv.name, sourcePath, sInode, name, value)
 				}
 			}
+			v.markReplication(sourcePath, attr.XAttrs)
 			if err = v.mw.BatchSetXAttr_ll(sInode, attr.XAttrs); err != nil {
 				log.LogErrorf("CopyFile: BatchSetXAttr_ll fail: volume(%v) source path(%v) inode(%v) attrs(%v) err(%v)",
 					sv.name, sourcePath, sInode, attr.XAttrs, err)
@@ -2670,6 +3015,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			if objMetaCache != nil {
 				objMetaCache.MergeAttr(v.name, attr)
 			}
+			v.queueReplication(sourcePath, sInode, attr.XAttrs)
 			log.LogInfof("CopyFile: target path is equal with source path, replace metadata, source path(%v) target path(%v) opt(%v)",
 				sourcePath, targetPath, opt)
 		}
@@ -2740,14 +3086,22 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 
 	// check whether existing object is protected by object lock
 	if oldtInode != 0 && opt != nil && opt.ObjectLock != nil {
-		err = isObjectLocked(v, oldtInode, tLastName, targetPath)
+		err = v.checkOverwriteLock(oldtInode, tLastName, targetPath)
 		if err != nil {
 			return
 		}
 	}
 
+	if err = v.checkPosixAcl(tParentId, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return
+	}
+	tMode, aclXattrs, err := v.inheritPosixAcl(tParentId, sMode)
+	if err != nil {
+		return
+	}
+
 	// create target file inode and set target inode to be source file inode
-	if tInodeInfo, err = v.mw.InodeCreate_ll(tParentId, uint32(sMode), 0, 0, nil, make([]uint64, 0), targetPath); err != nil {
+	if tInodeInfo, err = v.mw.InodeCreate_ll(tParentId, uint32(tMode), 0, 0, nil, make([]uint64, 0), targetPath); err != nil {
 		return
 	}
 	defer func() {
@@ -2761,6 +3115,9 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			_ = v.mw.Evict(tInodeInfo.Inode, targetPath)
 		}
 	}()
+	if err = v.setInheritedPosixAcl(tInodeInfo.Inode, aclXattrs); err != nil {
+		return
+	}
 	if err = v.ec.OpenStream(tInodeInfo.Inode); err != nil {
 		return
 	}
@@ -2790,14 +3147,21 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 	var ebsReader *blobstore.Reader
 	var tctx context.Context
 	var ebsWriter *blobstore.Writer
-	if proto.IsCold(sv.volType) {
+	if proto.IsCold(dataVol.volType) {
 		sctx = context.Background()
-		ebsReader = v.getEbsReader(sInode)
+		ebsReader = dataVol.getEbsReader(dataInode)
 	}
 	if proto.IsCold(v.volType) {
 		tctx = context.Background()
 		ebsWriter = v.getEbsWriter(tInodeInfo.Inode)
 	}
+	var srcStream, tgtStream cipher.Stream
+	if srcEnc != nil {
+		srcStream = srcEnc.Stream(0)
+	}
+	if tgtEnc != nil {
+		tgtStream = tgtEnc.Stream(0)
+	}
 
 	for {
 		if rest = int(fileSize) - readOffset; rest <= 0 {
@@ -2808,15 +3172,24 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			readSize = rest
 		}
 		buf = buf[:readSize]
-		if proto.IsCold(sv.volType) {
+		if proto.IsCold(dataVol.volType) {
 			readN, err = ebsReader.Read(sctx, buf, readOffset, readSize)
 		} else {
-			readN, err = sv.ec.Read(sInode, buf, readOffset, readSize)
+			readN, err = dataVol.ec.Read(dataInode, buf, readOffset, readSize)
 		}
 		if err != nil && err != io.EOF {
 			return
 		}
 		if readN > 0 {
+			if srcStream != nil {
+				srcStream.XORKeyStream(buf[:readN], buf[:readN])
+			}
+			// copy to md5 buffer, and then write to md5
+			copy(hashBuf, buf[:readN])
+			md5Hash.Write(hashBuf[:readN])
+			if tgtStream != nil {
+				tgtStream.XORKeyStream(buf[:readN], buf[:readN])
+			}
 			if proto.IsCold(v.volType) {
 				writeN, err = ebsWriter.WriteWithoutPool(tctx, writeOffset, buf[:readN])
 			} else {
@@ -2829,9 +3202,6 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			}
 			readOffset += readN
 			writeOffset += writeN
-			// copy to md5 buffer, and then write to md5
-			copy(hashBuf, buf[:readN])
-			md5Hash.Write(hashBuf[:readN])
 		}
 		if err == io.EOF {
 			err = nil
@@ -2871,15 +3241,19 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		},
 	}
 	targetAttr.XAttrs[XAttrKeyOSSETag] = etagValue.Encode()
+	if tgtEnc != nil {
+		for key, val := range tgtEnc.XAttrs() {
+			targetAttr.XAttrs[key] = val
+		}
+	}
 
 	// copy source file metadata to write target file metadata
 	if metaDirective != MetadataDirectiveReplace {
-		xattr, err = sv.mw.XAttrGetAll_ll(sInode)
-		if xattr == nil || err != nil {
-			return
-		}
+		xattr = sXAttr
 		for key, val := range xattr.XAttrs {
-			if key == XAttrKeyOSSETag {
+			if key == XAttrKeyOSSETag || key == XAttrKeyOSSVersionId || key == XAttrKeyOSSTransition ||
+				key == XAttrKeyOSSReplicationStatus || strings.HasPrefix(key, xattrKeyOSSSSEPrefix) ||
+				isObjectLockXAttr(key) || key == posixacl.XattrAccess || key == posixacl.XattrDefault {
 				continue
 			}
 			targetAttr.XAttrs[key] = val
@@ -2888,8 +3262,9 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			targetAttr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 		}
 		if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-			targetAttr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(tInodeInfo.ModifyTime, opt.ObjectLock.ToRetention())
+			opt.ObjectLock.ToRetention().setXAttrs(targetAttr.XAttrs, tInodeInfo.ModifyTime)
 		}
+		v.markReplication(targetPath, targetAttr.XAttrs)
 		if err = v.mw.BatchSetXAttr_ll(tInodeInfo.Inode, targetAttr.XAttrs); err != nil {
 			log.LogErrorf("CopyFile: set target xattr fail: volume(%v) target path(%v) inode(%v) xattr (%v)err(%v)",
 				v.name, targetPath, tInodeInfo.Inode, xattr, err)
@@ -2917,7 +3292,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			targetAttr.XAttrs[XAttrKeyOSSACL] = opt.ACL.Encode()
 		}
 		if opt != nil && opt.ObjectLock != nil && opt.ObjectLock.ToRetention() != nil {
-			targetAttr.XAttrs[XAttrKeyOSSLock] = formatRetentionDateStr(tInodeInfo.ModifyTime, opt.ObjectLock.ToRetention())
+			opt.ObjectLock.ToRetention().setXAttrs(targetAttr.XAttrs, tInodeInfo.ModifyTime)
 		}
 
 		// If user-defined metadata have been specified, use extend attributes for storage.
@@ -2930,6 +3305,7 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 			}
 		}
 
+		v.markReplication(targetPath, targetAttr.XAttrs)
 		if err = v.mw.BatchSetXAttr_ll(tInodeInfo.Inode, targetAttr.XAttrs); err != nil {
 			log.LogErrorf("CopyFile: BatchSetXAttr_ll fail: volume(%v) target path(%v) inode(%v) attrs(%v) err(%v)",
 				v.name, targetPath, tInodeInfo.Inode, targetAttr.XAttrs, err)
@@ -2951,13 +3327,22 @@ func (v *Volume) CopyFile(sv *Volume, sourcePath, targetPath, metaDirective stri
 		ETag:       md5Value,
 		Inode:      tInodeInfo.Inode,
 	}
+	if tgtEnc != nil {
+		info.SSEType, info.SSEKeyMD5 = tgtEnc.Type, tgtEnc.KeyMD5
+	}
 
 	// apply new inode to dentry
-	err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode, false, targetPath)
+	info.VersionId, err = v.applyInodeToDEntry(tParentId, tLastName, tInodeInfo.Inode, false, targetPath)
 	if err != nil {
 		log.LogErrorf("CopyFile: apply inode to new dentry fail: path(%v) parentID(%v) name(%v) inode(%v) err(%v)",
 			targetPath, tParentId, tLastName, tInodeInfo.Inode, err)
 	}
+	if info.VersionId != "" {
+		targetAttr.XAttrs[XAttrKeyOSSVersionId] = info.VersionId
+	}
+	if err == nil {
+		v.queueReplication(targetPath, tInodeInfo.Inode, targetAttr.XAttrs)
+	}
 
 	// force updating dentry and attrs in cache
 	updateDentryCache(tParentId, tInodeInfo.Inode, DefaultFileMode, tLastName, v.name)
@@ -3020,6 +3405,7 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		OnSplitExtentKey:  metaWrapper.SplitExtentKey,
 		OnGetExtents:      metaWrapper.GetExtents,
 		OnTruncate:        metaWrapper.Truncate,
+		ReadAheadPool:     readAheadPool,
 	}
 	if proto.IsCold(volumeInfo.VolType) {
 		if blockCache != nil {
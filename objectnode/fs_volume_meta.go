@@ -26,10 +26,18 @@ type ossMetaLoader interface {
 	loadACL() (p *AccessControlPolicy, err error)
 	loadCORS() (cors *CORSConfiguration, err error)
 	loadObjectLock() (config *ObjectLockConfig, err error)
+	loadVersioning() (config *VersioningConfiguration, err error)
+	loadEncryption() (config *ServerSideEncryptionConfiguration, err error)
+	loadReplication() (config *ReplicationConfiguration, err error)
+	loadNotification() (config *NotificationConfiguration, err error)
 	storePolicy(p *Policy)
 	storeACL(p *AccessControlPolicy)
 	storeCORS(cors *CORSConfiguration)
 	storeObjectLock(config *ObjectLockConfig)
+	storeVersioning(config *VersioningConfiguration)
+	storeEncryption(config *ServerSideEncryptionConfiguration)
+	storeReplication(config *ReplicationConfiguration)
+	storeNotification(config *NotificationConfiguration)
 	setSynced()
 }
 
@@ -46,14 +54,22 @@ type cacheMetaLoader struct {
 
 // OSSMeta is bucket policy and ACL metadata.
 type OSSMeta struct {
-	policy     *Policy
-	acl        *AccessControlPolicy
-	corsConfig *CORSConfiguration
-	lockConfig *ObjectLockConfig
-	policyLock sync.RWMutex
-	aclLock    sync.RWMutex
-	corsLock   sync.RWMutex
-	objectLock sync.RWMutex
+	policy       *Policy
+	acl          *AccessControlPolicy
+	corsConfig   *CORSConfiguration
+	lockConfig   *ObjectLockConfig
+	versioning   *VersioningConfiguration
+	encryption   *ServerSideEncryptionConfiguration
+	replication  *ReplicationConfiguration
+	notification *NotificationConfiguration
+	policyLock   sync.RWMutex
+	aclLock      sync.RWMutex
+	corsLock     sync.RWMutex
+	objectLock   sync.RWMutex
+	verLock      sync.RWMutex
+	sseLock      sync.RWMutex
+	replLock     sync.RWMutex
+	notifyLock   sync.RWMutex
 }
 
 func (c *cacheMetaLoader) loadPolicy() (p *Policy, err error) {
@@ -152,6 +168,102 @@ func (c *cacheMetaLoader) storeObjectLock(config *ObjectLockConfig) {
 	c.om.objectLock.Unlock()
 }
 
+func (c *cacheMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	c.om.verLock.RLock()
+	config = c.om.versioning
+	c.om.verLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSVersioning, func() (interface{}, error) {
+			vc, err := c.sml.loadVersioning()
+			return vc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*VersioningConfiguration)
+		c.storeVersioning(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	c.om.verLock.Lock()
+	c.om.versioning = config
+	c.om.verLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	c.om.sseLock.RLock()
+	config = c.om.encryption
+	c.om.sseLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSEncryption, func() (interface{}, error) {
+			ec, err := c.sml.loadEncryption()
+			return ec, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ServerSideEncryptionConfiguration)
+		c.storeEncryption(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	c.om.sseLock.Lock()
+	c.om.encryption = config
+	c.om.sseLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	c.om.replLock.RLock()
+	config = c.om.replication
+	c.om.replLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSReplication, func() (interface{}, error) {
+			rc, err := c.sml.loadReplication()
+			return rc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*ReplicationConfiguration)
+		c.storeReplication(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	c.om.replLock.Lock()
+	c.om.replication = config
+	c.om.replLock.Unlock()
+}
+
+func (c *cacheMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	c.om.notifyLock.RLock()
+	config = c.om.notification
+	c.om.notifyLock.RUnlock()
+	if config == nil && atomic.LoadInt32(c.synced) == 0 {
+		ret, err, _ := c.sf.Do(XAttrKeyOSSNotification, func() (interface{}, error) {
+			nc, err := c.sml.loadNotification()
+			return nc, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		config = ret.(*NotificationConfiguration)
+		c.storeNotification(config)
+	}
+	return
+}
+
+func (c *cacheMetaLoader) storeNotification(config *NotificationConfiguration) {
+	c.om.notifyLock.Lock()
+	c.om.notification = config
+	c.om.notifyLock.Unlock()
+}
+
 func (c *cacheMetaLoader) setSynced() {
 	atomic.StoreInt32(c.synced, 1)
 }
@@ -188,6 +300,38 @@ func (s *strictMetaLoader) storeObjectLock(cors *ObjectLockConfig) {
 	// do nothing
 }
 
+func (s *strictMetaLoader) loadVersioning() (config *VersioningConfiguration, err error) {
+	return s.v.loadBucketVersioning()
+}
+
+func (s *strictMetaLoader) storeVersioning(config *VersioningConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadEncryption() (config *ServerSideEncryptionConfiguration, err error) {
+	return s.v.loadBucketEncryption()
+}
+
+func (s *strictMetaLoader) storeEncryption(config *ServerSideEncryptionConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadReplication() (config *ReplicationConfiguration, err error) {
+	return s.v.loadBucketReplication()
+}
+
+func (s *strictMetaLoader) storeReplication(config *ReplicationConfiguration) {
+	// do nothing
+}
+
+func (s *strictMetaLoader) loadNotification() (config *NotificationConfiguration, err error) {
+	return s.v.loadBucketNotification()
+}
+
+func (s *strictMetaLoader) storeNotification(config *NotificationConfiguration) {
+	// do nothing
+}
+
 func (s *strictMetaLoader) setSynced() {
 	// do nothing
 }
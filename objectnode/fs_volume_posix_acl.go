@@ -0,0 +1,121 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
+)
+
+const defaultPosixAclId = 65534 // nobody
+
+var (
+	// posixAclUid and posixAclGid are the identity which the S3 requests are evaluated
+	// as against the POSIX ACLs of the volumes with posix ACL enabled.
+	posixAclUid uint32 = defaultPosixAclId
+	posixAclGid uint32 = defaultPosixAclId
+)
+
+// getPosixAcl returns the ACL of the inode stored in the xattr key, nil if posix ACL is
+// not enabled on the volume or the inode has no such ACL.
+func (v *Volume) getPosixAcl(ino uint64, key string) (acl posixacl.ACL, err error) {
+	if !v.ec.GetEnablePosixAcl() {
+		return nil, nil
+	}
+	info, err := v.mw.XAttrGet_ll(ino, key)
+	if err != nil {
+		return nil, err
+	}
+	value := info.Get(key)
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if acl, err = posixacl.Parse(value); err != nil {
+		log.LogWarnf("getPosixAcl: volume(%v) inode(%v) key(%v) err(%v)", v.name, ino, key, err)
+		return nil, syscall.EIO
+	}
+	return acl, nil
+}
+
+// checkPosixAcl checks the access ACL of the inode, so that the ACLs set through the fuse
+// client are not bypassed by S3. The inodes without an access ACL are not checked, as the
+// rwx mode has never been enforced on the objects.
+func (v *Volume) checkPosixAcl(ino uint64, want uint16) error {
+	acl, err := v.getPosixAcl(ino, posixacl.XattrAccess)
+	if err != nil || acl == nil {
+		return err
+	}
+	info, err := v.mw.InodeGet_ll(ino)
+	if err != nil {
+		return err
+	}
+	if !acl.Check(info.Uid, info.Gid, posixAclUid, []uint32{posixAclGid}, want) {
+		log.LogWarnf("checkPosixAcl: denied, volume(%v) inode(%v) uid(%v) gid(%v) want(%v)",
+			v.name, ino, posixAclUid, posixAclGid, want)
+		return syscall.EACCES
+	}
+	return nil
+}
+
+// inheritPosixAcl derives the mode and the xattrs of the ACLs of an inode to be created
+// in the directory parentId from the default ACL of the directory.
+func (v *Volume) inheritPosixAcl(parentId uint64, mode os.FileMode) (newMode os.FileMode, xattrs map[string]string, err error) {
+	def, err := v.getPosixAcl(parentId, posixacl.XattrDefault)
+	if err != nil || def == nil {
+		return mode, nil, err
+	}
+	access, perm := def.Inherit(uint32(mode.Perm()))
+	xattrs = make(map[string]string)
+	if access != nil {
+		xattrs[posixacl.XattrAccess] = string(access.Marshal())
+	}
+	if mode.IsDir() {
+		xattrs[posixacl.XattrDefault] = string(def.Marshal())
+	}
+	return mode&^os.ModePerm | os.FileMode(perm), xattrs, nil
+}
+
+func (v *Volume) setInheritedPosixAcl(ino uint64, xattrs map[string]string) (err error) {
+	if len(xattrs) == 0 {
+		return nil
+	}
+	if err = v.mw.BatchSetXAttr_ll(ino, xattrs); err != nil {
+		log.LogErrorf("setInheritedPosixAcl: volume(%v) inode(%v) err(%v)", v.name, ino, err)
+	}
+	return
+}
+
+// createWithPosixAcl creates a file or directory in the directory parentId,
+// which is checked and inherits the ACLs of the directory.
+func (v *Volume) createWithPosixAcl(parentId uint64, name string, mode os.FileMode, fullPath string) (info *proto.InodeInfo, err error) {
+	if err = v.checkPosixAcl(parentId, posixacl.PermWrite|posixacl.PermExec); err != nil {
+		return
+	}
+	mode, xattrs, err := v.inheritPosixAcl(parentId, mode)
+	if err != nil {
+		return
+	}
+	if info, err = v.mw.Create_ll(parentId, name, uint32(mode), 0, 0, nil, fullPath); err != nil {
+		return
+	}
+	if err = v.setInheritedPosixAcl(info.Inode, xattrs); err != nil {
+		return nil, err
+	}
+	return
+}
@@ -0,0 +1,790 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Noncurrent versions and delete markers of an object are kept in the version directory of its key:
+//
+//	/.oss_versions/<hex encoded key>/<version entry>
+//
+// The entry names sort from the newest version to the oldest, so the first entry is always the latest
+// noncurrent version. A noncurrent version shares the inode with the object it was, the inode is linked
+// into the version directory before the dentry of the object is updated or deleted.
+//
+// The hex encoded key doubles the length of the key, so the keys longer than versionKeyChunkLen/2 bytes
+// are split into nested directories to keep every dentry name within 255 bytes:
+//
+//	/.oss_versions/<hex chunk 1>/~<hex chunk 2>/<version entry>
+//
+// The key is not hashed because the version directories have to be listed in key order. The nested
+// directories sort after the version entries, and only the directories of full chunks can have them.
+//
+// The versions are not kept by the multi-version snapshots of metanode, which are taken for the whole
+// volume and only dropped as a whole, while every write of a key makes a version of its own which may be
+// removed permanently in any order.
+
+const versionsReadDirLimit = 1000
+
+// objectVersionEntry locates one version of an object.
+type objectVersionEntry struct {
+	Inode        uint64
+	Mode         os.FileMode
+	VersionId    string
+	IsCurrent    bool
+	DeleteMarker bool
+	KeyDir       uint64 // inode of the version directory, only for noncurrent versions
+	EntryName    string // dentry name in the version directory, only for noncurrent versions
+}
+
+type DeleteObjectResult struct {
+	VersionId    string
+	DeleteMarker bool
+}
+
+type FSVersion struct {
+	Key          string
+	VersionId    string
+	IsLatest     bool
+	DeleteMarker bool
+	Info         *FSFileInfo
+}
+
+type ListObjectVersionsOption struct {
+	Prefix          string
+	Delimiter       string
+	KeyMarker       string
+	VersionIdMarker string
+	MaxKeys         uint64
+}
+
+type ListObjectVersionsResult struct {
+	Versions            []*FSVersion
+	CommonPrefixes      []string
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	Truncated           bool
+}
+
+func (v *Volume) versionStorePath(key, entryName string) string {
+	dirs := append([]string{VersionsDirName}, versionKeyDirNames(key)...)
+	return strings.Join(append(dirs, entryName), pathSep)
+}
+
+func (v *Volume) versionKeyDir(key string, autoCreate bool) (uint64, error) {
+	return v.lookupDirectories(append([]string{VersionsDirName}, versionKeyDirNames(key)...), autoCreate)
+}
+
+func isVersionKeyChunkDir(name string) bool {
+	return strings.HasPrefix(name, versionKeyChunkPrefix)
+}
+
+// objectVersionId returns the version ID of the object stored in the inode.
+// Objects written before versioning was configured have the null version ID.
+func (v *Volume) objectVersionId(inode uint64) (versionId string, err error) {
+	var info *proto.XAttrInfo
+	if info, err = v.mw.XAttrGet_ll(inode, XAttrKeyOSSVersionId); err != nil {
+		return
+	}
+	if versionId = string(info.Get(XAttrKeyOSSVersionId)); versionId == "" {
+		versionId = NullVersionId
+	}
+	return
+}
+
+func (v *Volume) isDeleteMarker(inode uint64) (bool, error) {
+	info, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSDeleteMarker)
+	if err != nil {
+		return false, err
+	}
+	return len(info.Get(XAttrKeyOSSDeleteMarker)) > 0, nil
+}
+
+// assignVersionId stores the version ID of a new object in its inode before the object becomes visible.
+func (v *Volume) assignVersionId(inode uint64, versioning *VersioningConfiguration) (versionId string, err error) {
+	if !versioning.Configured() {
+		return
+	}
+	versionId = NullVersionId
+	if versioning.Enabled() {
+		versionId = newVersionId(time.Now(), inode)
+	}
+	err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSVersionId), []byte(versionId))
+	return
+}
+
+// archiveVersion links the inode of the current object into the version directory of its key.
+// The null version is not kept while versioning is suspended, it will be replaced by the new object.
+func (v *Volume) archiveVersion(path string, inode uint64, versioning *VersioningConfiguration) (archived bool, err error) {
+	var versionId string
+	if versionId, err = v.objectVersionId(inode); err != nil {
+		return
+	}
+	if versionId == NullVersionId {
+		if versioning.Suspended() {
+			return
+		}
+		v.removeNullVersion(path)
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(inode); err != nil {
+		return
+	}
+	var keyDir uint64
+	if keyDir, err = v.versionKeyDir(path, true); err != nil {
+		return
+	}
+	entryName := versionEntryName(versionId, inoInfo.ModifyTime)
+	if _, err = v.mw.Link(keyDir, entryName, inode, v.versionStorePath(path, entryName)); err != nil {
+		return
+	}
+	return true, nil
+}
+
+// removeNullVersion deletes the noncurrent null version of the key if there is one.
+func (v *Volume) removeNullVersion(path string) {
+	keyDir, err := v.versionKeyDir(path, false)
+	if err != nil {
+		return
+	}
+	var dentry proto.Dentry
+	if dentry, err = v.findVersionDentry(keyDir, NullVersionId); err != nil {
+		return
+	}
+	if err = v.deleteVersionEntry(path, keyDir, dentry.Name, dentry.Inode); err != nil {
+		log.LogWarnf("removeNullVersion: delete null version fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, dentry.Inode, err)
+	}
+}
+
+func (v *Volume) findVersionDentry(keyDir uint64, versionId string) (dentry proto.Dentry, err error) {
+	if versionId != NullVersionId {
+		var mode uint32
+		dentry.Name = versionId
+		dentry.Inode, mode, err = v.mw.Lookup_ll(keyDir, versionId)
+		dentry.Type = mode
+		return
+	}
+	var children []proto.Dentry
+	if children, err = v.mw.ReadDir_ll(keyDir); err != nil {
+		return
+	}
+	for _, child := range children {
+		if !isVersionKeyChunkDir(child.Name) && versionIdFromEntryName(child.Name) == NullVersionId {
+			return child, nil
+		}
+	}
+	err = syscall.ENOENT
+	return
+}
+
+func (v *Volume) deleteVersionEntry(path string, keyDir uint64, entryName string, inode uint64) (err error) {
+	storePath := v.versionStorePath(path, entryName)
+	if _, err = v.mw.Delete_ll(keyDir, entryName, false, storePath); err != nil {
+		return
+	}
+	if err = v.ec.EvictStream(inode); err != nil {
+		log.LogWarnf("deleteVersionEntry: evict stream fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, storePath, inode, err)
+	}
+	if err = v.mw.Evict(inode, storePath); err != nil {
+		log.LogWarnf("deleteVersionEntry: evict inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, storePath, inode, err)
+	}
+	deleteAttrCache(inode, v.name)
+	return nil
+}
+
+// cleanVersionKeyDir removes the version directory of the key and its empty parents once it becomes empty.
+func (v *Volume) cleanVersionKeyDir(path string, keyDir uint64) {
+	dirs := append([]string{VersionsDirName}, versionKeyDirNames(path)...)
+	inodes := make([]uint64, 0, len(dirs))
+	parentId := rootIno
+	for _, dir := range dirs[:len(dirs)-1] {
+		ino, _, err := v.mw.Lookup_ll(parentId, dir)
+		if err != nil {
+			return
+		}
+		inodes = append(inodes, ino)
+		parentId = ino
+	}
+	inodes = append(inodes, keyDir)
+	for i := len(inodes) - 1; i > 0; i-- {
+		children, err := v.mw.ReadDirLimit_ll(inodes[i], "", 1)
+		if err != nil || len(children) > 0 {
+			return
+		}
+		dirPath := strings.Join(dirs[:i+1], pathSep)
+		if _, err = v.mw.Delete_ll(inodes[i-1], dirs[i], true, dirPath); err != nil {
+			log.LogDebugf("cleanVersionKeyDir: delete version directory fail: volume(%v) path(%v) dir(%v) err(%v)",
+				v.name, path, dirPath, err)
+			return
+		}
+	}
+}
+
+// lookupObjectVersion finds the specified version of the object, it returns syscall.ENOENT if there is no such version.
+func (v *Volume) lookupObjectVersion(path, versionId string) (entry *objectVersionEntry, err error) {
+	if !isValidVersionId(versionId) {
+		err = syscall.ENOENT
+		return
+	}
+	var ino uint64
+	var mode os.FileMode
+	if _, ino, _, mode, err = v.recursiveLookupTarget(path, true); err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil && !mode.IsDir() {
+		var currentId string
+		if currentId, err = v.objectVersionId(ino); err != nil {
+			return
+		}
+		if currentId == versionId {
+			entry = &objectVersionEntry{Inode: ino, Mode: mode, VersionId: versionId, IsCurrent: true}
+			return
+		}
+	}
+
+	var keyDir uint64
+	if keyDir, err = v.versionKeyDir(path, false); err != nil {
+		return
+	}
+	var dentry proto.Dentry
+	if dentry, err = v.findVersionDentry(keyDir, versionId); err != nil {
+		return
+	}
+	entry = &objectVersionEntry{
+		Inode:     dentry.Inode,
+		Mode:      os.FileMode(dentry.Type),
+		VersionId: versionId,
+		KeyDir:    keyDir,
+		EntryName: dentry.Name,
+	}
+	entry.DeleteMarker, err = v.isDeleteMarker(dentry.Inode)
+	return
+}
+
+// ObjectVersionMeta returns the meta of the specified version of the object.
+// A delete marker is returned with the DeleteMarker flag set.
+func (v *Volume) ObjectVersionMeta(path, versionId string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	if isReservedPath(path) {
+		err = syscall.ENOENT
+		return
+	}
+	var entry *objectVersionEntry
+	if entry, err = v.lookupObjectVersion(path, versionId); err != nil {
+		log.LogDebugf("ObjectVersionMeta: lookup version fail: volume(%v) path(%v) versionId(%v) err(%v)",
+			v.name, path, versionId, err)
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeGet_ll(entry.Inode); err != nil {
+		log.LogErrorf("ObjectVersionMeta: get inode fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, entry.Inode, err)
+		return
+	}
+	if info, xattr, err = v.objectMetaByInode(path, entry.Inode, entry.Mode, inoInfo); err != nil {
+		return
+	}
+	info.VersionId = versionId
+	return
+}
+
+// DeleteObject deletes the object with S3 versioning semantics.
+// Without a version ID, the object is removed permanently if versioning is not configured,
+// otherwise it is kept as a noncurrent version and a delete marker becomes the latest version.
+// With a version ID, the specified version is removed permanently.
+// The object protected by object lock can't be removed permanently, except the one with
+// a governance retention if bypassGovernance is set.
+func (v *Volume) DeleteObject(path, versionId string, bypassGovernance bool) (result *DeleteObjectResult, err error) {
+	defer func() {
+		// Audit behavior
+		log.LogInfof("Audit: DeleteObject: volume(%v) path(%v) versionId(%v) err(%v)", v.name, path, versionId, err)
+	}()
+	result = &DeleteObjectResult{}
+	if isReservedPath(path) {
+		return
+	}
+	if versionId != "" {
+		return v.deleteObjectVersion(path, versionId, bypassGovernance)
+	}
+	var versioning *VersioningConfiguration
+	if versioning, err = v.metaLoader.loadVersioning(); err != nil {
+		return
+	}
+	if !versioning.Configured() {
+		err = v.deletePath(path, bypassGovernance)
+		return
+	}
+	return v.deleteCurrentObject(path, versioning)
+}
+
+func (v *Volume) deleteCurrentObject(path string, versioning *VersioningConfiguration) (result *DeleteObjectResult, err error) {
+	var (
+		parent uint64
+		ino    uint64
+		name   string
+		mode   os.FileMode
+	)
+	parent, ino, name, mode, err = v.recursiveLookupTarget(path, true)
+	if err != nil && err != syscall.ENOENT {
+		return
+	}
+	if err == nil {
+		if mode.IsDir() {
+			// directories are not versioned
+			return &DeleteObjectResult{}, v.DeletePath(path)
+		}
+		var archived bool
+		if archived, err = v.archiveVersion(path, ino, versioning); err != nil {
+			log.LogErrorf("deleteCurrentObject: archive version fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, ino, err)
+			return
+		}
+		if archived {
+			if _, err = v.mw.Delete_ll(parent, name, false, path); err != nil {
+				log.LogErrorf("deleteCurrentObject: delete dentry fail: volume(%v) path(%v) parent(%v) name(%v) err(%v)",
+					v.name, path, parent, name, err)
+				return
+			}
+			deleteDentryCache(parent, name, v.name)
+		} else if err = v.DeletePath(path); err != nil {
+			return
+		}
+	}
+	if versioning.Suspended() {
+		v.removeNullVersion(path)
+	}
+	return v.putDeleteMarker(path, versioning)
+}
+
+func (v *Volume) putDeleteMarker(path string, versioning *VersioningConfiguration) (result *DeleteObjectResult, err error) {
+	var keyDir uint64
+	if keyDir, err = v.versionKeyDir(path, true); err != nil {
+		return
+	}
+	var inoInfo *proto.InodeInfo
+	if inoInfo, err = v.mw.InodeCreate_ll(keyDir, DefaultFileMode, 0, 0, nil, make([]uint64, 0), path); err != nil {
+		log.LogErrorf("putDeleteMarker: inode create fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, _ = v.mw.InodeUnlink_ll(inoInfo.Inode, path)
+			_ = v.mw.Evict(inoInfo.Inode, path)
+		}
+	}()
+	versionId := NullVersionId
+	if versioning.Enabled() {
+		versionId = newVersionId(inoInfo.ModifyTime, inoInfo.Inode)
+	}
+	attrs := map[string]string{
+		XAttrKeyOSSVersionId:    versionId,
+		XAttrKeyOSSDeleteMarker: "true",
+	}
+	if err = v.mw.BatchSetXAttr_ll(inoInfo.Inode, attrs); err != nil {
+		log.LogErrorf("putDeleteMarker: set xattr fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+	entryName := versionEntryName(versionId, inoInfo.ModifyTime)
+	if err = v.mw.DentryCreate_ll(keyDir, entryName, inoInfo.Inode, DefaultFileMode, v.versionStorePath(path, entryName)); err != nil {
+		log.LogErrorf("putDeleteMarker: dentry create fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inoInfo.Inode, err)
+		return
+	}
+	result = &DeleteObjectResult{VersionId: versionId, DeleteMarker: true}
+	return
+}
+
+func (v *Volume) deleteObjectVersion(path, versionId string, bypassGovernance bool) (result *DeleteObjectResult, err error) {
+	if !isValidVersionId(versionId) {
+		err = InvalidVersionId
+		return
+	}
+	result = &DeleteObjectResult{VersionId: versionId}
+	var entry *objectVersionEntry
+	if entry, err = v.lookupObjectVersion(path, versionId); err == syscall.ENOENT {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	if !entry.DeleteMarker {
+		if err = isObjectLocked(v, entry.Inode, versionId, path, bypassGovernance); err != nil {
+			return
+		}
+	}
+	if entry.IsCurrent {
+		err = v.deletePath(path, bypassGovernance)
+	} else {
+		err = v.deleteVersionEntry(path, entry.KeyDir, entry.EntryName, entry.Inode)
+	}
+	if err != nil {
+		log.LogErrorf("deleteObjectVersion: delete version fail: volume(%v) path(%v) versionId(%v) err(%v)",
+			v.name, path, versionId, err)
+		return
+	}
+	result.DeleteMarker = entry.DeleteMarker
+	v.promoteLatestVersion(path)
+	return
+}
+
+// promoteLatestVersion makes the latest noncurrent version the current object if the object
+// has no current version and the latest version is not a delete marker.
+func (v *Volume) promoteLatestVersion(path string) {
+	var err error
+	if _, _, _, _, err = v.recursiveLookupTarget(path, true); err != syscall.ENOENT {
+		return
+	}
+	var keyDir uint64
+	if keyDir, err = v.versionKeyDir(path, false); err != nil {
+		return
+	}
+	defer v.cleanVersionKeyDir(path, keyDir)
+
+	var children []proto.Dentry
+	if children, err = v.mw.ReadDirLimit_ll(keyDir, "", 1); err != nil || len(children) == 0 {
+		return
+	}
+	latest := children[0]
+	if isVersionKeyChunkDir(latest.Name) {
+		return
+	}
+	var isMarker bool
+	if isMarker, err = v.isDeleteMarker(latest.Inode); err != nil || isMarker {
+		return
+	}
+	var parentId uint64
+	if parentId, err = v.recursiveMakeDirectory(path); err != nil {
+		log.LogWarnf("promoteLatestVersion: make parent directory fail: volume(%v) path(%v) err(%v)",
+			v.name, path, err)
+		return
+	}
+	_, name := splitPath(path)
+	if err = v.mw.Rename_ll(keyDir, latest.Name, parentId, name, v.versionStorePath(path, latest.Name), path, false); err != nil {
+		log.LogWarnf("promoteLatestVersion: rename fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, latest.Inode, err)
+		return
+	}
+	updateDentryCache(parentId, latest.Inode, DefaultFileMode, name, v.name)
+}
+
+// ListObjectVersions lists all versions of the objects in key order, and versions of
+// the same key from the newest to the oldest.
+func (v *Volume) ListObjectVersions(opt *ListObjectVersionsOption) (result *ListObjectVersionsResult, err error) {
+	result = &ListObjectVersionsResult{}
+	if opt.MaxKeys == 0 {
+		return
+	}
+	limit := opt.MaxKeys + 1
+
+	// Both the current objects and the keys in the version directory are listed in key order,
+	// the first limit items of the merged results are enough to fill the page.
+	var (
+		infos    []*FSFileInfo
+		prefixes Prefixes
+	)
+	if infos, prefixes, _, err = v.listFilesV1(opt.Prefix, opt.KeyMarker, opt.Delimiter, limit, true); err != nil {
+		log.LogErrorf("ListObjectVersions: list files fail: volume(%v) option(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	current := make(map[string]*FSFileInfo)
+	items := make(map[string]bool) // key or common prefix -> whether it is a common prefix
+	for _, info := range infos {
+		current[info.Path] = info
+		items[info.Path] = false
+	}
+	for _, prefix := range prefixes {
+		items[prefix] = true
+	}
+	// the key marker is excluded from the listing result, but the rest versions of it are still needed
+	if opt.KeyMarker != "" && opt.VersionIdMarker != "" {
+		if info, _, err1 := v.ObjectMeta(opt.KeyMarker); err1 == nil && !info.Mode.IsDir() {
+			current[opt.KeyMarker] = info
+		}
+		items[opt.KeyMarker] = false
+	}
+
+	var keys []string
+	if keys, prefixes, err = v.listVersionedKeys(opt, limit); err != nil {
+		log.LogErrorf("ListObjectVersions: list versioned keys fail: volume(%v) option(%+v) err(%v)", v.name, opt, err)
+		return
+	}
+	for _, key := range keys {
+		if _, ok := items[key]; !ok {
+			items[key] = false
+		}
+	}
+	for _, prefix := range prefixes {
+		items[prefix] = true
+	}
+
+	sorted := make([]string, 0, len(items))
+	for item := range items {
+		sorted = append(sorted, item)
+	}
+	sort.Strings(sorted)
+
+	var count uint64
+	for _, item := range sorted {
+		isPrefix := items[item]
+		if !afterVersionKeyMarker(item, isPrefix, opt) {
+			continue
+		}
+		if isPrefix {
+			if count >= opt.MaxKeys {
+				result.Truncated = true
+				break
+			}
+			result.CommonPrefixes = append(result.CommonPrefixes, item)
+			result.NextKeyMarker, result.NextVersionIdMarker = item, ""
+			count++
+			continue
+		}
+		var versions []*FSVersion
+		if versions, err = v.listKeyVersions(item, current[item]); err != nil {
+			log.LogErrorf("ListObjectVersions: list key versions fail: volume(%v) key(%v) err(%v)", v.name, item, err)
+			return
+		}
+		skipping := item == opt.KeyMarker && opt.VersionIdMarker != ""
+		for _, version := range versions {
+			if skipping {
+				skipping = version.VersionId != opt.VersionIdMarker
+				continue
+			}
+			if count >= opt.MaxKeys {
+				result.Truncated = true
+				break
+			}
+			result.Versions = append(result.Versions, version)
+			result.NextKeyMarker, result.NextVersionIdMarker = item, version.VersionId
+			count++
+		}
+		if result.Truncated {
+			break
+		}
+	}
+	if !result.Truncated {
+		result.NextKeyMarker, result.NextVersionIdMarker = "", ""
+	}
+	return
+}
+
+func afterVersionKeyMarker(item string, isPrefix bool, opt *ListObjectVersionsOption) bool {
+	if opt.KeyMarker == "" {
+		return true
+	}
+	if isPrefix {
+		return item > opt.KeyMarker && !strings.HasPrefix(opt.KeyMarker, item)
+	}
+	if item == opt.KeyMarker {
+		return opt.VersionIdMarker != ""
+	}
+	return item > opt.KeyMarker
+}
+
+// listVersionedKeys lists the keys which have noncurrent versions or delete markers.
+func (v *Volume) listVersionedKeys(opt *ListObjectVersionsOption, limit uint64) (keys []string, prefixes Prefixes, err error) {
+	var versionsDir uint64
+	if versionsDir, _, err = v.mw.Lookup_ll(rootIno, VersionsDirName); err == syscall.ENOENT {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return
+	}
+	lister := &versionedKeyLister{
+		v:         v,
+		opt:       opt,
+		limit:     limit,
+		hexPrefix: strings.Join(versionKeyDirNames(opt.Prefix), ""),
+		prefixMap: PrefixMap(make(map[string]struct{})),
+	}
+	lister.from = lister.hexPrefix
+	if hexMarker := strings.Join(versionKeyDirNames(opt.KeyMarker), ""); hexMarker > lister.from {
+		lister.from = hexMarker
+	}
+	if _, err = lister.walk(versionsDir, nil, ""); err != nil {
+		return
+	}
+	return lister.keys, lister.prefixMap.Prefixes(), nil
+}
+
+// versionedKeyLister walks the version directories in key order, the hex encoded keys
+// are compared with the prefix and the marker without decoding.
+type versionedKeyLister struct {
+	v         *Volume
+	opt       *ListObjectVersionsOption
+	limit     uint64
+	count     uint64
+	hexPrefix string
+	from      string
+	keys      []string
+	prefixMap PrefixMap
+}
+
+// walk lists the keys under the version directory dir, names is the path of dir under VersionsDirName
+// and encoded is the hex encoded key of it. It returns false once the listing is finished.
+func (l *versionedKeyLister) walk(dir uint64, names []string, encoded string) (more bool, err error) {
+	var from string
+	if len(l.from) > len(encoded) && strings.HasPrefix(l.from, encoded) {
+		if from = l.from[len(encoded):]; len(from) > versionKeyChunkLen {
+			from = from[:versionKeyChunkLen]
+		}
+	}
+	if len(names) > 0 {
+		// skip the version entries of dir
+		from = versionKeyChunkPrefix + from
+	}
+	var last string
+	for {
+		var children []proto.Dentry
+		if children, err = l.v.mw.ReadDirLimit_ll(dir, from, versionsReadDirLimit); err != nil {
+			return
+		}
+		for _, child := range children {
+			if child.Name == last {
+				continue
+			}
+			chunk := child.Name
+			if len(names) > 0 {
+				if !isVersionKeyChunkDir(chunk) {
+					continue
+				}
+				chunk = chunk[len(versionKeyChunkPrefix):]
+			}
+			hexKey := encoded + chunk
+			if !strings.HasPrefix(hexKey, l.hexPrefix) && !strings.HasPrefix(l.hexPrefix, hexKey) {
+				if hexKey > l.hexPrefix {
+					return false, nil
+				}
+				continue
+			}
+			childNames := append(names[:len(names):len(names)], child.Name)
+			if len(hexKey) >= len(l.hexPrefix) && hexKey >= l.from {
+				if more, err = l.visit(child, childNames, len(chunk) == versionKeyChunkLen); err != nil || !more {
+					return
+				}
+			}
+			if len(chunk) == versionKeyChunkLen {
+				if more, err = l.walk(child.Inode, childNames, hexKey); err != nil || !more {
+					return
+				}
+			}
+		}
+		if len(children) < versionsReadDirLimit {
+			return true, nil
+		}
+		from = children[len(children)-1].Name
+		last = from
+	}
+}
+
+// visit adds the key of the version directory to the result, the directory of a full chunk
+// may only keep the directories of longer keys.
+func (l *versionedKeyLister) visit(dir proto.Dentry, names []string, fullChunk bool) (more bool, err error) {
+	if fullChunk {
+		var children []proto.Dentry
+		if children, err = l.v.mw.ReadDirLimit_ll(dir.Inode, "", 1); err != nil {
+			return
+		}
+		if len(children) == 0 || isVersionKeyChunkDir(children[0].Name) {
+			return true, nil
+		}
+	}
+	key, err := versionKeyFromDirNames(names...)
+	if err != nil {
+		log.LogWarnf("listVersionedKeys: invalid version directory: volume(%v) names(%v)", l.v.name, names)
+		return true, nil
+	}
+	if l.opt.Delimiter != "" {
+		if idx := strings.Index(key[len(l.opt.Prefix):], l.opt.Delimiter); idx >= 0 {
+			prefix := key[:len(l.opt.Prefix)+idx+len(l.opt.Delimiter)]
+			if !l.prefixMap.contain(prefix) {
+				l.prefixMap.AddPrefix(prefix)
+				l.count++
+			}
+			return true, nil
+		}
+	}
+	l.keys = append(l.keys, key)
+	l.count++
+	return l.count < l.limit, nil
+}
+
+// listKeyVersions lists the versions of the key from the newest to the oldest.
+func (v *Volume) listKeyVersions(key string, current *FSFileInfo) (versions []*FSVersion, err error) {
+	if current != nil {
+		versionId := current.VersionId
+		if versionId == "" {
+			versionId = NullVersionId
+		}
+		versions = append(versions, &FSVersion{Key: key, VersionId: versionId, IsLatest: true, Info: current})
+	}
+	var keyDir uint64
+	if keyDir, err = v.versionKeyDir(key, false); err == syscall.ENOENT {
+		return versions, nil
+	}
+	if err != nil {
+		return
+	}
+	var children []proto.Dentry
+	if children, err = v.mw.ReadDir_ll(keyDir); err != nil {
+		return
+	}
+	for _, child := range children {
+		if isVersionKeyChunkDir(child.Name) {
+			continue
+		}
+		var inoInfo *proto.InodeInfo
+		if inoInfo, err = v.mw.InodeGet_ll(child.Inode); err == syscall.ENOENT {
+			continue
+		}
+		if err != nil {
+			return
+		}
+		var info *FSFileInfo
+		if info, _, err = v.objectMetaByInode(key, child.Inode, os.FileMode(child.Type), inoInfo); err != nil {
+			return
+		}
+		versions = append(versions, &FSVersion{
+			Key:          key,
+			VersionId:    versionIdFromEntryName(child.Name),
+			IsLatest:     len(versions) == 0,
+			DeleteMarker: info.DeleteMarker,
+			Info:         info,
+		})
+	}
+	return
+}
+
+// objectMetaWithVersion returns the meta of the current object if the version ID is empty,
+// otherwise the meta of the specified version.
+func (v *Volume) objectMetaWithVersion(path, versionId string) (info *FSFileInfo, xattr *proto.XAttrInfo, err error) {
+	if versionId == "" {
+		return v.ObjectMeta(path)
+	}
+	return v.ObjectVersionMeta(path, versionId)
+}
@@ -18,6 +18,8 @@ import (
 	"encoding/xml"
 	"net/http"
 	"time"
+
+	"github.com/cubefs/cubefs/proto"
 )
 
 const (
@@ -40,6 +42,15 @@ var (
 	LifeCycleErrDateType         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Date' must be at midnight GMT.", StatusCode: http.StatusBadRequest}
 	LifeCycleErrDaysType         = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' for Expiration action must be a positive integer.", StatusCode: http.StatusBadRequest}
 	LifeCycleErrMalformedXML     = &ErrorCode{ErrorCode: "MalformedXML", ErrorMessage: "The XML you provided was not well-formed or did not validate against our published schema.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrTransitionDays   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' for Transition action must be a nonnegative integer.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrTooManyTransit   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "Only one Transition action is supported in a rule.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrTransitionOrder  = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'Days' in the Expiration action must be greater than 'Days' in the Transition action.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrStorageClass     = &ErrorCode{ErrorCode: "InvalidStorageClass", ErrorMessage: "The storage class you specified is not valid.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrAbortDays        = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'DaysAfterInitiation' for AbortIncompleteMultipartUpload action must be a positive integer.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrNoncurrentDays   = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "'NoncurrentDays' for NoncurrentVersionExpiration action must be a positive integer.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrAbortWithFilter  = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "AbortIncompleteMultipartUpload cannot be specified with Tags or object size filters.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrInvalidFilter    = &ErrorCode{ErrorCode: "InvalidRequest", ErrorMessage: "Filter element can only have one of Prefix, Tag, ObjectSizeGreaterThan, ObjectSizeLessThan or And specified.", StatusCode: http.StatusBadRequest}
+	LifeCycleErrObjectSize       = &ErrorCode{ErrorCode: "InvalidArgument", ErrorMessage: "ObjectSizeLessThan must be greater than ObjectSizeGreaterThan.", StatusCode: http.StatusBadRequest}
 	NoSuchLifecycleConfiguration = &ErrorCode{ErrorCode: "NoSuchLifecycleConfiguration", ErrorMessage: "The lifecycle configuration does not exist.", StatusCode: http.StatusNotFound}
 )
 
@@ -50,11 +61,14 @@ type LifeCycle struct {
 }
 
 type Rule struct {
-	XMLName xml.Name    `xml:"Rule"`
-	Expire  *Expiration `xml:"Expiration"`
-	Filter  *Filter     `xml:"Filter"`
-	ID      string      `xml:"ID"`
-	Status  string      `xml:"Status"`
+	XMLName     xml.Name                     `xml:"Rule"`
+	Expire      *Expiration                  `xml:"Expiration"`
+	Transitions []*Transition                `xml:"Transition,omitempty"`
+	AbortMPU    *AbortMPU                    `xml:"AbortIncompleteMultipartUpload,omitempty"`
+	Noncurrent  *NoncurrentVersionExpiration `xml:"NoncurrentVersionExpiration,omitempty"`
+	Filter      *Filter                      `xml:"Filter"`
+	ID          string                       `xml:"ID"`
+	Status      string                       `xml:"Status"`
 }
 
 type Expiration struct {
@@ -63,9 +77,42 @@ type Expiration struct {
 	Days    *int       `xml:"Days,omitempty"`
 }
 
+// Transition moves the data of objects into a cold volume. CubeFS volumes have no storage
+// classes, so the StorageClass of a transition is the name of the cold volume.
+type Transition struct {
+	XMLName      xml.Name   `xml:"Transition"`
+	Date         *time.Time `xml:"Date,omitempty"`
+	Days         *int       `xml:"Days,omitempty"`
+	StorageClass string     `xml:"StorageClass"`
+}
+
+type AbortMPU struct {
+	XMLName             xml.Name `xml:"AbortIncompleteMultipartUpload"`
+	DaysAfterInitiation int      `xml:"DaysAfterInitiation"`
+}
+
+// NoncurrentVersionExpiration removes the noncurrent versions of objects in the versioned buckets.
+type NoncurrentVersionExpiration struct {
+	XMLName        xml.Name `xml:"NoncurrentVersionExpiration"`
+	NoncurrentDays int      `xml:"NoncurrentDays"`
+}
+
 type Filter struct {
-	XMLName xml.Name `xml:"Filter"`
-	Prefix  string   `xml:"Prefix,omitempty"`
+	XMLName               xml.Name `xml:"Filter"`
+	Prefix                string   `xml:"Prefix,omitempty"`
+	Tag                   *Tag     `xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64   `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64   `xml:"ObjectSizeLessThan,omitempty"`
+	And                   *And     `xml:"And,omitempty"`
+}
+
+// And combines the conditions of a filter.
+type And struct {
+	XMLName               xml.Name `xml:"And"`
+	Prefix                string   `xml:"Prefix,omitempty"`
+	Tags                  []Tag    `xml:"Tag,omitempty"`
+	ObjectSizeGreaterThan *int64   `xml:"ObjectSizeGreaterThan,omitempty"`
+	ObjectSizeLessThan    *int64   `xml:"ObjectSizeLessThan,omitempty"`
 }
 
 func NewLifeCycle() *LifeCycle {
@@ -109,12 +156,44 @@ func (r *Rule) valid() *ErrorCode {
 		return LifeCycleErrMalformedXML
 	}
 
-	if r.Expire == nil {
+	if r.Expire == nil && len(r.Transitions) == 0 && r.AbortMPU == nil && r.Noncurrent == nil {
 		return LifeCycleErrMissingActions
 	}
 
-	if err := r.Expire.validExpiration(); err != nil {
-		return err
+	if r.Expire != nil {
+		if err := r.Expire.validExpiration(); err != nil {
+			return err
+		}
+	}
+	if len(r.Transitions) > 1 {
+		return LifeCycleErrTooManyTransit
+	}
+	for _, t := range r.Transitions {
+		if err := t.validTransition(); err != nil {
+			return err
+		}
+		if r.Expire != nil && r.Expire.Days != nil && t.Days != nil && *r.Expire.Days <= *t.Days {
+			return LifeCycleErrTransitionOrder
+		}
+	}
+	if r.Filter != nil {
+		if err := r.Filter.validFilter(); err != nil {
+			return err
+		}
+	}
+	if r.AbortMPU != nil {
+		if r.AbortMPU.DaysAfterInitiation <= 0 {
+			return LifeCycleErrAbortDays
+		}
+		// incomplete multipart uploads have neither tags nor size
+		if filter := r.Filter.toFilterConfig(); filter != nil &&
+			(len(filter.Tags) > 0 || filter.ObjectSizeGreaterThan > 0 || filter.ObjectSizeLessThan > 0) {
+			return LifeCycleErrAbortWithFilter
+		}
+	}
+
+	if r.Noncurrent != nil && r.Noncurrent.NoncurrentDays <= 0 {
+		return LifeCycleErrNoncurrentDays
 	}
 
 	return nil
@@ -144,3 +223,143 @@ func (e *Expiration) validExpiration() *ErrorCode {
 
 	return nil
 }
+
+func (t *Transition) validTransition() *ErrorCode {
+	if t.Date != nil && t.Days != nil {
+		return LifeCycleErrMalformedXML
+	}
+	if t.Date == nil && t.Days == nil {
+		return LifeCycleErrMalformedXML
+	}
+	if t.Date != nil {
+		date := t.Date.In(time.UTC)
+		if !(date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 && date.Nanosecond() == 0) {
+			return LifeCycleErrDateType
+		}
+	} else if *t.Days < 0 {
+		return LifeCycleErrTransitionDays
+	}
+	if t.StorageClass == "" {
+		return LifeCycleErrStorageClass
+	}
+
+	return nil
+}
+
+func (f *Filter) validFilter() *ErrorCode {
+	conditions := 0
+	if f.Prefix != "" {
+		conditions++
+	}
+	if f.Tag != nil {
+		conditions++
+	}
+	if f.ObjectSizeGreaterThan != nil {
+		conditions++
+	}
+	if f.ObjectSizeLessThan != nil {
+		conditions++
+	}
+	if f.And != nil {
+		conditions++
+	}
+	if conditions > 1 {
+		return LifeCycleErrInvalidFilter
+	}
+	if f.Tag != nil && !f.Tag.isValid() {
+		return InvalidTag
+	}
+
+	config := f.toFilterConfig()
+	if config.ObjectSizeGreaterThan < 0 || config.ObjectSizeLessThan < 0 {
+		return LifeCycleErrObjectSize
+	}
+	if config.ObjectSizeGreaterThan > 0 && config.ObjectSizeLessThan > 0 &&
+		config.ObjectSizeLessThan <= config.ObjectSizeGreaterThan {
+		return LifeCycleErrObjectSize
+	}
+	if f.And != nil {
+		keys := make(map[string]struct{})
+		for _, tag := range f.And.Tags {
+			if !tag.isValid() {
+				return InvalidTag
+			}
+			if _, ok := keys[tag.Key]; ok {
+				return DuplicateTagKey
+			}
+			keys[tag.Key] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// toFilterConfig flattens the conditions of the filter.
+func (f *Filter) toFilterConfig() *proto.FilterConfig {
+	if f == nil {
+		return nil
+	}
+	config := &proto.FilterConfig{Prefix: f.Prefix}
+	greaterThan, lessThan := f.ObjectSizeGreaterThan, f.ObjectSizeLessThan
+	if f.Tag != nil {
+		config.Tags = append(config.Tags, &proto.TagConfig{Key: f.Tag.Key, Value: f.Tag.Value})
+	}
+	if f.And != nil {
+		config.Prefix = f.And.Prefix
+		for _, tag := range f.And.Tags {
+			config.Tags = append(config.Tags, &proto.TagConfig{Key: tag.Key, Value: tag.Value})
+		}
+		greaterThan, lessThan = f.And.ObjectSizeGreaterThan, f.And.ObjectSizeLessThan
+	}
+	if greaterThan != nil {
+		config.ObjectSizeGreaterThan = *greaterThan
+	}
+	if lessThan != nil {
+		config.ObjectSizeLessThan = *lessThan
+	}
+	return config
+}
+
+// newFilter is the reverse of toFilterConfig, multiple conditions are combined with And.
+func newFilter(config *proto.FilterConfig) *Filter {
+	if config == nil {
+		return nil
+	}
+	var greaterThan, lessThan *int64
+	if config.ObjectSizeGreaterThan > 0 {
+		greaterThan = &config.ObjectSizeGreaterThan
+	}
+	if config.ObjectSizeLessThan > 0 {
+		lessThan = &config.ObjectSizeLessThan
+	}
+	conditions := len(config.Tags)
+	if config.Prefix != "" {
+		conditions++
+	}
+	if greaterThan != nil {
+		conditions++
+	}
+	if lessThan != nil {
+		conditions++
+	}
+	if conditions > 1 {
+		and := &And{
+			Prefix:                config.Prefix,
+			ObjectSizeGreaterThan: greaterThan,
+			ObjectSizeLessThan:    lessThan,
+		}
+		for _, tag := range config.Tags {
+			and.Tags = append(and.Tags, Tag{Key: tag.Key, Value: tag.Value})
+		}
+		return &Filter{And: and}
+	}
+	filter := &Filter{
+		Prefix:                config.Prefix,
+		ObjectSizeGreaterThan: greaterThan,
+		ObjectSizeLessThan:    lessThan,
+	}
+	if len(config.Tags) > 0 {
+		filter.Tag = &Tag{Key: config.Tags[0].Key, Value: config.Tags[0].Value}
+	}
+	return filter
+}
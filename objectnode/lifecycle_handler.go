@@ -65,11 +65,21 @@ func (o *ObjectNode) getBucketLifecycleConfigurationHandler(w http.ResponseWrite
 				rule.Expire.Days = &lc.Expire.Days
 			}
 		}
-		if lc.Filter != nil {
-			rule.Filter = &Filter{
-				Prefix: lc.Filter.Prefix,
+		for _, t := range lc.Transitions {
+			transition := &Transition{Date: t.Date, StorageClass: t.StorageClass}
+			if t.Date == nil {
+				days := t.Days
+				transition.Days = &days
 			}
+			rule.Transitions = append(rule.Transitions, transition)
 		}
+		if lc.AbortIncompleteMultipartUpload != nil {
+			rule.AbortMPU = &AbortMPU{DaysAfterInitiation: lc.AbortIncompleteMultipartUpload.DaysAfterInitiation}
+		}
+		if lc.NoncurrentVersionExpiration != nil {
+			rule.Noncurrent = &NoncurrentVersionExpiration{NoncurrentDays: lc.NoncurrentVersionExpiration.NoncurrentDays}
+		}
+		rule.Filter = newFilter(lc.Filter)
 		lifeCycle.Rules = append(lifeCycle.Rules, rule)
 	}
 
@@ -150,11 +160,29 @@ func (o *ObjectNode) putBucketLifecycleConfigurationHandler(w http.ResponseWrite
 				rule.Expire.Days = *lr.Expire.Days
 			}
 		}
-		if lr.Filter != nil {
-			rule.Filter = &proto.FilterConfig{
-				Prefix: lr.Filter.Prefix,
+		for _, t := range lr.Transitions {
+			transition := &proto.TransitionConfig{Date: t.Date, StorageClass: t.StorageClass}
+			if t.Days != nil {
+				transition.Days = *t.Days
+			}
+			if errorCode = o.checkTransitionVolume(param.Bucket(), t.StorageClass); errorCode != nil {
+				log.LogErrorf("putBucketLifecycle failed: invalid transition: requestID(%v) volume(%v) storageClass(%v)",
+					GetRequestID(r), param.Bucket(), t.StorageClass)
+				return
+			}
+			rule.Transitions = append(rule.Transitions, transition)
+		}
+		if lr.AbortMPU != nil {
+			rule.AbortIncompleteMultipartUpload = &proto.AbortIncompleteMultipartUploadConfig{
+				DaysAfterInitiation: lr.AbortMPU.DaysAfterInitiation,
+			}
+		}
+		if lr.Noncurrent != nil {
+			rule.NoncurrentVersionExpiration = &proto.NoncurrentVersionExpirationConfig{
+				NoncurrentDays: lr.Noncurrent.NoncurrentDays,
 			}
 		}
+		rule.Filter = lr.Filter.toFilterConfig()
 		req.Rules = append(req.Rules, rule)
 	}
 
@@ -192,3 +220,19 @@ func (o *ObjectNode) deleteBucketLifecycleConfigurationHandler(w http.ResponseWr
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// checkTransitionVolume makes sure the transition target is a cold volume of the same owner.
+func (o *ObjectNode) checkTransitionVolume(bucket, storageClass string) *ErrorCode {
+	if storageClass == bucket {
+		return LifeCycleErrStorageClass
+	}
+	src, err := o.vm.Volume(bucket)
+	if err != nil {
+		return NoSuchBucket
+	}
+	dst, err := o.vm.Volume(storageClass)
+	if err != nil || !proto.IsCold(dst.volType) || dst.owner != src.owner {
+		return LifeCycleErrStorageClass
+	}
+	return nil
+}
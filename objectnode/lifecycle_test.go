@@ -19,6 +19,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -124,3 +125,110 @@ func TestLifecycleConfiguration(t *testing.T) {
 	_, err = l1.Validate()
 	require.Equal(t, err, LifeCycleErrMissingRules)
 }
+
+func TestLifecycleTransitionAndFilter(t *testing.T) {
+	LifecycleXml := `
+<LifecycleConfiguration>
+    <Rule>
+        <Filter>
+           <And>
+              <Prefix>logs/</Prefix>
+              <Tag><Key>type</Key><Value>log</Value></Tag>
+              <ObjectSizeGreaterThan>1024</ObjectSizeGreaterThan>
+           </And>
+        </Filter>
+        <ID>id1</ID>
+        <Status>Enabled</Status>
+        <Transition>
+           <Days>30</Days>
+           <StorageClass>cold-vol</StorageClass>
+        </Transition>
+        <Expiration>
+           <Days>365</Days>
+        </Expiration>
+    </Rule>
+    <Rule>
+        <Filter>
+           <Prefix>tmp/</Prefix>
+        </Filter>
+        <ID>id2</ID>
+        <Status>Enabled</Status>
+        <AbortIncompleteMultipartUpload>
+           <DaysAfterInitiation>7</DaysAfterInitiation>
+        </AbortIncompleteMultipartUpload>
+        <NoncurrentVersionExpiration>
+           <NoncurrentDays>30</NoncurrentDays>
+        </NoncurrentVersionExpiration>
+    </Rule>
+</LifecycleConfiguration>
+`
+
+	l1 := NewLifeCycle()
+	err := xml.Unmarshal([]byte(LifecycleXml), l1)
+	require.NoError(t, err)
+	ok, errCode := l1.Validate()
+	require.True(t, ok)
+	require.Nil(t, errCode)
+
+	filter := l1.Rules[0].Filter.toFilterConfig()
+	require.Equal(t, "logs/", filter.Prefix)
+	require.Equal(t, int64(1024), filter.ObjectSizeGreaterThan)
+	require.Len(t, filter.Tags, 1)
+	require.Equal(t, "type", filter.Tags[0].Key)
+	require.Equal(t, filter, newFilter(filter).toFilterConfig())
+	require.Nil(t, newFilter(&proto.FilterConfig{Prefix: "tmp/"}).And)
+
+	// expiration must be later than transition
+	day := 30
+	l1.Rules[0].Expire.Days = &day
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrTransitionOrder, errCode)
+	day = 365
+
+	// only one transition
+	l1.Rules[0].Transitions = append(l1.Rules[0].Transitions, l1.Rules[0].Transitions[0])
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrTooManyTransit, errCode)
+	l1.Rules[0].Transitions = l1.Rules[0].Transitions[:1]
+
+	// storage class is required
+	l1.Rules[0].Transitions[0].StorageClass = ""
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrStorageClass, errCode)
+	l1.Rules[0].Transitions[0].StorageClass = "cold-vol"
+
+	// invalid object size
+	lessThan := int64(512)
+	l1.Rules[0].Filter.And.ObjectSizeLessThan = &lessThan
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrObjectSize, errCode)
+	l1.Rules[0].Filter.And.ObjectSizeLessThan = nil
+
+	// duplicate tag keys
+	l1.Rules[0].Filter.And.Tags = append(l1.Rules[0].Filter.And.Tags, Tag{Key: "type", Value: "txt"})
+	_, errCode = l1.Validate()
+	require.Equal(t, DuplicateTagKey, errCode)
+	l1.Rules[0].Filter.And.Tags = l1.Rules[0].Filter.And.Tags[:1]
+
+	// multiple conditions without And
+	l1.Rules[1].Filter.Tag = &Tag{Key: "type", Value: "tmp"}
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrInvalidFilter, errCode)
+	l1.Rules[1].Filter.Tag = nil
+
+	// abort multipart upload can not filter by tags
+	l1.Rules[1].Filter = &Filter{Tag: &Tag{Key: "type", Value: "tmp"}}
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrAbortWithFilter, errCode)
+	l1.Rules[1].Filter = nil
+
+	require.Equal(t, 30, l1.Rules[1].Noncurrent.NoncurrentDays)
+	l1.Rules[1].Noncurrent.NoncurrentDays = 0
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrNoncurrentDays, errCode)
+	l1.Rules[1].Noncurrent.NoncurrentDays = 30
+
+	l1.Rules[1].AbortMPU.DaysAfterInitiation = 0
+	_, errCode = l1.Validate()
+	require.Equal(t, LifeCycleErrAbortDays, errCode)
+}
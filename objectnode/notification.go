@@ -0,0 +1,397 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/EventNotifications.html
+//
+// The destinations of notifications are the kafka and webhook targets configured to objectnode,
+// they are referred as "arn:cubefs:sqs::<id>:kafka" and "arn:cubefs:sqs::<id>:webhook"
+// by the notification configurations of buckets.
+
+const (
+	MaxNotificationSize = 1 << 16 // 64KB
+
+	NotificationArnPrefix     = "arn:cubefs:sqs::"
+	NotificationTargetKafka   = "kafka"
+	NotificationTargetWebhook = "webhook"
+
+	EventObjectCreatedAll               = "s3:ObjectCreated:*"
+	EventObjectCreatedPut               = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost              = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy              = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipart = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectRemovedAll               = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete            = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarker      = "s3:ObjectRemoved:DeleteMarkerCreated"
+
+	notificationFilterPrefix = "prefix"
+	notificationFilterSuffix = "suffix"
+	notificationEventVersion = "2.1"
+	notificationEventSource  = "aws:s3"
+	notificationS3Schema     = "1.0"
+)
+
+var supportedNotificationEvents = map[string]struct{}{
+	EventObjectCreatedAll:               {},
+	EventObjectCreatedPut:               {},
+	EventObjectCreatedPost:              {},
+	EventObjectCreatedCopy:              {},
+	EventObjectCreatedCompleteMultipart: {},
+	EventObjectRemovedAll:               {},
+	EventObjectRemovedDelete:            {},
+	EventObjectRemovedDeleteMarker:      {},
+}
+
+type NotificationConfiguration struct {
+	XMLNS   string          `xml:"xmlns,attr,omitempty"`
+	XMLName xml.Name        `xml:"NotificationConfiguration"`
+	Queues  []*EventTarget  `xml:"QueueConfiguration,omitempty"`
+	Topics  []*EventTarget  `xml:"TopicConfiguration,omitempty"`
+	Lambdas []*LambdaTarget `xml:"CloudFunctionConfiguration,omitempty"`
+	Bridge  *struct{}       `xml:"EventBridgeConfiguration,omitempty"`
+}
+
+// EventTarget is a queue or topic configuration, which both are published to the configured targets.
+type EventTarget struct {
+	ID     string              `xml:"Id,omitempty"`
+	Queue  string              `xml:"Queue,omitempty"`
+	Topic  string              `xml:"Topic,omitempty"`
+	Events []string            `xml:"Event"`
+	Filter *NotificationFilter `xml:"Filter,omitempty"`
+}
+
+type LambdaTarget struct {
+	CloudFunction string `xml:"CloudFunction"`
+}
+
+type NotificationFilter struct {
+	S3Key struct {
+		FilterRules []NotificationFilterRule `xml:"FilterRule"`
+	} `xml:"S3Key"`
+}
+
+type NotificationFilterRule struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+func parseBucketNotification(bytes []byte) (config *NotificationConfiguration, errCode *ErrorCode) {
+	config = &NotificationConfiguration{}
+	if err := xml.Unmarshal(bytes, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Lambdas) > 0 || config.Bridge != nil {
+		return nil, UnsupportedOperation
+	}
+	ids := make(map[string]struct{})
+	for _, target := range config.targets() {
+		if errCode = target.validate(); errCode != nil {
+			return nil, errCode
+		}
+		if target.ID == "" {
+			target.ID = uuid.New().String()
+		}
+		if _, ok := ids[target.ID]; ok {
+			return nil, InvalidArgument
+		}
+		ids[target.ID] = struct{}{}
+	}
+	return config, nil
+}
+
+func (c *NotificationConfiguration) targets() []*EventTarget {
+	if c == nil {
+		return nil
+	}
+	targets := make([]*EventTarget, 0, len(c.Queues)+len(c.Topics))
+	targets = append(targets, c.Queues...)
+	return append(targets, c.Topics...)
+}
+
+func (c *NotificationConfiguration) IsEmpty() bool {
+	return len(c.targets()) == 0
+}
+
+func (t *EventTarget) arn() string {
+	if t.Queue != "" {
+		return t.Queue
+	}
+	return t.Topic
+}
+
+func (t *EventTarget) validate() *ErrorCode {
+	if (t.Queue == "") == (t.Topic == "") || !strings.HasPrefix(t.arn(), NotificationArnPrefix) {
+		return InvalidArgument
+	}
+	if len(t.Events) == 0 {
+		return InvalidArgument
+	}
+	for _, event := range t.Events {
+		if _, ok := supportedNotificationEvents[event]; !ok {
+			return InvalidArgument
+		}
+	}
+	if t.Filter == nil {
+		return nil
+	}
+	names := make(map[string]struct{})
+	for i, rule := range t.Filter.S3Key.FilterRules {
+		name := strings.ToLower(rule.Name)
+		if name != notificationFilterPrefix && name != notificationFilterSuffix {
+			return InvalidArgument
+		}
+		if _, ok := names[name]; ok {
+			return InvalidArgument
+		}
+		names[name] = struct{}{}
+		t.Filter.S3Key.FilterRules[i].Name = name
+	}
+	return nil
+}
+
+func (t *EventTarget) match(event, key string) bool {
+	matched := false
+	for _, e := range t.Events {
+		if e == event || (strings.HasSuffix(e, "*") && strings.HasPrefix(event, strings.TrimSuffix(e, "*"))) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if t.Filter == nil {
+		return true
+	}
+	for _, rule := range t.Filter.S3Key.FilterRules {
+		switch rule.Name {
+		case notificationFilterPrefix:
+			if !strings.HasPrefix(key, rule.Value) {
+				return false
+			}
+		case notificationFilterSuffix:
+			if !strings.HasSuffix(key, rule.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func storeBucketNotification(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSNotification, bytes)
+}
+
+func deleteBucketNotification(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSNotification)
+}
+
+// NotificationConfig is the targets which the events of buckets can be published to,
+// the key of map is the id of target.
+type NotificationConfig struct {
+	Kafka   map[string]KafkaAuditConfig   `json:"kafka,omitempty"`
+	Webhook map[string]WebhookAuditConfig `json:"webhook,omitempty"`
+}
+
+type Notifier struct {
+	targets map[string]AuditLogger
+}
+
+func NewNotifier(conf NotificationConfig) (*Notifier, error) {
+	n := &Notifier{targets: make(map[string]AuditLogger)}
+	if err := n.addTargets(conf); err != nil {
+		n.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *Notifier) addTargets(conf NotificationConfig) (err error) {
+	for id, cfg := range conf.Kafka {
+		if cfg.Enable {
+			var target AuditLogger
+			if target, err = NewKafkaAudit(id, cfg); err != nil {
+				return
+			}
+			n.targets[notificationArn(id, NotificationTargetKafka)] = target
+		}
+	}
+	for id, cfg := range conf.Webhook {
+		if cfg.Enable {
+			var target AuditLogger
+			if target, err = NewWebhookAudit(id, cfg); err != nil {
+				return
+			}
+			n.targets[notificationArn(id, NotificationTargetWebhook)] = target
+		}
+	}
+	return
+}
+
+func notificationArn(id, targetType string) string {
+	return NotificationArnPrefix + id + ":" + targetType
+}
+
+func (n *Notifier) hasTarget(arn string) bool {
+	_, ok := n.targets[arn]
+	return ok
+}
+
+// checkTargets returns the first destination of the configuration which is not configured.
+func (n *Notifier) checkTargets(config *NotificationConfiguration) string {
+	for _, target := range config.targets() {
+		if n == nil || !n.hasTarget(target.arn()) {
+			return target.arn()
+		}
+	}
+	return ""
+}
+
+func (n *Notifier) Close() {
+	for _, target := range n.targets {
+		target.Close()
+	}
+}
+
+type NotificationEvent struct {
+	Records []*NotificationRecord `json:"Records"`
+}
+
+type NotificationRecord struct {
+	EventVersion      string            `json:"eventVersion"`
+	EventSource       string            `json:"eventSource"`
+	AwsRegion         string            `json:"awsRegion"`
+	EventTime         string            `json:"eventTime"`
+	EventName         string            `json:"eventName"`
+	UserIdentity      Identity          `json:"userIdentity"`
+	RequestParameters map[string]string `json:"requestParameters"`
+	ResponseElements  map[string]string `json:"responseElements"`
+	S3                EventS3           `json:"s3"`
+}
+
+type Identity struct {
+	PrincipalID string `json:"principalId"`
+}
+
+type EventS3 struct {
+	SchemaVersion   string      `json:"s3SchemaVersion"`
+	ConfigurationID string      `json:"configurationId"`
+	Bucket          EventBucket `json:"bucket"`
+	Object          EventObject `json:"object"`
+}
+
+type EventBucket struct {
+	Name          string   `json:"name"`
+	OwnerIdentity Identity `json:"ownerIdentity"`
+	Arn           string   `json:"arn"`
+}
+
+type EventObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size,omitempty"`
+	ETag      string `json:"eTag,omitempty"`
+	VersionID string `json:"versionId,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
+
+func newEventObject(key string, info *FSFileInfo) EventObject {
+	object := EventObject{Key: key}
+	if info != nil {
+		object.Size = info.Size
+		object.ETag = info.ETag
+		object.VersionID = info.VersionId
+	}
+	return object
+}
+
+// notifyDeleteEvent publishes the removed event of an object according to the result of deletion.
+func (o *ObjectNode) notifyDeleteEvent(r *http.Request, vol *Volume, key string, result *DeleteObjectResult) {
+	event := EventObjectRemovedDelete
+	if result.DeleteMarker {
+		event = EventObjectRemovedDeleteMarker
+	}
+	o.notifyEvent(r, vol, event, EventObject{Key: key, VersionID: result.VersionId})
+}
+
+// notifyEvent publishes the event of an object to the destinations configured by the bucket.
+func (o *ObjectNode) notifyEvent(r *http.Request, vol *Volume, event string, object EventObject) {
+	if o.notifier == nil {
+		return
+	}
+	config, err := vol.metaLoader.loadNotification()
+	if err != nil || config == nil {
+		return
+	}
+	now := time.Now().UTC()
+	object.Sequencer = fmt.Sprintf("%016X", now.UnixNano())
+	key := object.Key
+	object.Key = url.QueryEscape(key)
+	requester := mux.Vars(r)[ContextKeyRequester]
+	for _, target := range config.targets() {
+		if !target.match(event, key) {
+			continue
+		}
+		logger, ok := o.notifier.targets[target.arn()]
+		if !ok {
+			continue
+		}
+		record := &NotificationRecord{
+			EventVersion:      notificationEventVersion,
+			EventSource:       notificationEventSource,
+			AwsRegion:         o.region,
+			EventTime:         now.Format(ISO8601Layout),
+			EventName:         strings.TrimPrefix(event, "s3:"),
+			UserIdentity:      Identity{PrincipalID: requester},
+			RequestParameters: map[string]string{"sourceIPAddress": getRequestIP(r)},
+			ResponseElements:  map[string]string{XAmzRequestId: GetRequestID(r)},
+			S3: EventS3{
+				SchemaVersion:   notificationS3Schema,
+				ConfigurationID: target.ID,
+				Bucket: EventBucket{
+					Name:          vol.Name(),
+					OwnerIdentity: Identity{PrincipalID: vol.owner},
+					Arn:           s3BucketArnPrefix + vol.Name(),
+				},
+				Object: object,
+			},
+		}
+		data, err := json.Marshal(&NotificationEvent{Records: []*NotificationRecord{record}})
+		if err != nil {
+			log.LogErrorf("notifyEvent: json marshal fail: requestID(%v) volume(%v) event(%v) err(%v)",
+				GetRequestID(r), vol.Name(), event, err)
+			continue
+		}
+		go func(logger AuditLogger, arn string) {
+			if err := logger.Send(data); err != nil {
+				log.LogErrorf("notifyEvent: send to '%v' fail: volume(%v) event(%v) key(%v) err(%v)",
+					arn, vol.Name(), event, key, err)
+			}
+		}(logger, target.arn())
+	}
+}
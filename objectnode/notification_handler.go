@@ -0,0 +1,143 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+func (o *ObjectNode) getBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *NotificationConfiguration
+	if config, err = vol.metaLoader.loadNotification(); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: load notification fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// an empty configuration is responded if the notification is not configured
+	result := &NotificationConfiguration{XMLNS: XMLNS}
+	if config != nil {
+		result.Queues = config.Queues
+		result.Topics = config.Topics
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("getBucketNotificationHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+func (o *ObjectNode) putBucketNotificationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxNotificationSize+1)); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxNotificationSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *NotificationConfiguration
+	if config, errorCode = parseBucketNotification(body); errorCode != nil {
+		log.LogErrorf("putBucketNotificationHandler: parse notification config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+
+	// an empty configuration disables the notification of the bucket
+	if config.IsEmpty() {
+		if err = deleteBucketNotification(vol); err != nil {
+			log.LogErrorf("putBucketNotificationHandler: delete notification config fail: requestID(%v) volume(%v) err(%v)",
+				GetRequestID(r), vol.Name(), err)
+			return
+		}
+		vol.metaLoader.storeNotification(nil)
+		log.LogInfof("Audit: delete bucket notification: requestID(%v) remote(%v) volume(%v)",
+			GetRequestID(r), getRequestIP(r), vol.Name())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if arn := o.notifier.checkTargets(config); arn != "" {
+		log.LogErrorf("putBucketNotificationHandler: destination not configured: requestID(%v) volume(%v) destination(%v)",
+			GetRequestID(r), vol.Name(), arn)
+		errorCode = InvalidNotificationDestination
+		return
+	}
+	config.XMLNS = ""
+	if body, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: xml marshal notification config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketNotification(body, vol); err != nil {
+		log.LogErrorf("putBucketNotificationHandler: store notification config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeNotification(config)
+
+	log.LogInfof("Audit: put bucket notification: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+	w.WriteHeader(http.StatusOK)
+}
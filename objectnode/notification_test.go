@@ -0,0 +1,190 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketNotification(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<NotificationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<QueueConfiguration><Id>q1</Id><Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:*</Event>
+							<Filter><S3Key><FilterRule><Name>Prefix</Name><Value>logs/</Value></FilterRule>
+								<FilterRule><Name>suffix</Name><Value>.gz</Value></FilterRule></S3Key></Filter>
+						</QueueConfiguration>
+						<TopicConfiguration><Topic>arn:cubefs:sqs::pipeline:webhook</Topic>
+							<Event>s3:ObjectRemoved:Delete</Event></TopicConfiguration>
+					</NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration></NotificationConfiguration>`,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration><Queue>arn:aws:sqs:us-east-1:123456789012:queue</Queue>
+							<Event>s3:ObjectCreated:*</Event></QueueConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration><Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectRestore:*</Event></QueueConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration><Queue>arn:cubefs:sqs::ingest:kafka</Queue><Event>s3:ObjectCreated:*</Event>
+							<Filter><S3Key><FilterRule><Name>prefix</Name><Value>a</Value></FilterRule>
+								<FilterRule><Name>prefix</Name><Value>b</Value></FilterRule></S3Key></Filter>
+						</QueueConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<QueueConfiguration><Id>q</Id><Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+							<Event>s3:ObjectCreated:*</Event></QueueConfiguration>
+						<TopicConfiguration><Id>q</Id><Topic>arn:cubefs:sqs::pipeline:webhook</Topic>
+							<Event>s3:ObjectRemoved:*</Event></TopicConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<NotificationConfiguration>
+						<CloudFunctionConfiguration><CloudFunction>arn:aws:lambda:us-east-1:1:function:f</CloudFunction>
+							<Event>s3:ObjectCreated:*</Event></CloudFunctionConfiguration>
+					</NotificationConfiguration>`,
+			expectedErr: UnsupportedOperation,
+		},
+		{
+			value:       `<NotificationConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for i, tt := range tests {
+		config, errCode := parseBucketNotification([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode, "case %d", i)
+		if errCode == nil {
+			for _, target := range config.targets() {
+				require.NotEmpty(t, target.ID)
+			}
+		}
+	}
+}
+
+func TestNotificationMatch(t *testing.T) {
+	config, errCode := parseBucketNotification([]byte(`<NotificationConfiguration>
+			<QueueConfiguration><Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+				<Event>s3:ObjectCreated:*</Event>
+				<Filter><S3Key><FilterRule><Name>Prefix</Name><Value>logs/</Value></FilterRule>
+					<FilterRule><Name>Suffix</Name><Value>.gz</Value></FilterRule></S3Key></Filter>
+			</QueueConfiguration>
+			<QueueConfiguration><Queue>arn:cubefs:sqs::ingest:kafka</Queue>
+				<Event>s3:ObjectRemoved:DeleteMarkerCreated</Event></QueueConfiguration>
+		</NotificationConfiguration>`))
+	require.Nil(t, errCode)
+	created, removed := config.Queues[0], config.Queues[1]
+
+	require.True(t, created.match(EventObjectCreatedPut, "logs/a.gz"))
+	require.True(t, created.match(EventObjectCreatedCompleteMultipart, "logs/b/c.gz"))
+	require.False(t, created.match(EventObjectCreatedPut, "logs/a.txt"))
+	require.False(t, created.match(EventObjectCreatedPut, "data/a.gz"))
+	require.False(t, created.match(EventObjectRemovedDelete, "logs/a.gz"))
+	require.True(t, removed.match(EventObjectRemovedDeleteMarker, "any"))
+	require.False(t, removed.match(EventObjectRemovedDelete, "any"))
+}
+
+type memoryNotifyTarget struct {
+	sent chan []byte
+}
+
+func (m *memoryNotifyTarget) Name() string { return "memory" }
+
+func (m *memoryNotifyTarget) Send(data []byte) error {
+	m.sent <- data
+	return nil
+}
+
+func (m *memoryNotifyTarget) Close() error { return nil }
+
+func TestNotifyEvent(t *testing.T) {
+	target := &memoryNotifyTarget{sent: make(chan []byte, 1)}
+	arn := notificationArn("ingest", NotificationTargetKafka)
+	o := &ObjectNode{
+		region:   "cfs_dev",
+		notifier: &Notifier{targets: map[string]AuditLogger{arn: target}},
+	}
+
+	config, errCode := parseBucketNotification([]byte(`<NotificationConfiguration>
+			<QueueConfiguration><Id>new-logs</Id><Queue>` + arn + `</Queue><Event>s3:ObjectCreated:*</Event>
+				<Filter><S3Key><FilterRule><Name>prefix</Name><Value>logs/</Value></FilterRule></S3Key></Filter>
+			</QueueConfiguration>
+		</NotificationConfiguration>`))
+	require.Nil(t, errCode)
+	require.Equal(t, "", o.notifier.checkTargets(config))
+	require.Equal(t, "arn:cubefs:sqs::other:webhook",
+		o.notifier.checkTargets(&NotificationConfiguration{Topics: []*EventTarget{{Topic: "arn:cubefs:sqs::other:webhook"}}}))
+
+	synced := int32(1)
+	vol := &Volume{name: "bucket", owner: "owner", metaLoader: &cacheMetaLoader{
+		om:     &OSSMeta{notification: config},
+		synced: &synced,
+	}}
+	r := httptest.NewRequest("PUT", "/bucket/logs/a%20b.gz", nil)
+
+	o.notifyEvent(r, vol, EventObjectRemovedDelete, EventObject{Key: "logs/a b.gz"})
+	o.notifyEvent(r, vol, EventObjectCreatedPut, EventObject{Key: "data/a.gz", Size: 1})
+	o.notifyEvent(r, vol, EventObjectCreatedPut, EventObject{Key: "logs/a b.gz", Size: 10, ETag: "etag"})
+
+	var data []byte
+	select {
+	case data = <-target.sent:
+	case <-time.After(time.Second):
+		t.Fatal("event not sent")
+	}
+	event := &NotificationEvent{}
+	require.NoError(t, json.Unmarshal(data, event))
+	require.Len(t, event.Records, 1)
+	record := event.Records[0]
+	require.Equal(t, "ObjectCreated:Put", record.EventName)
+	require.Equal(t, "cfs_dev", record.AwsRegion)
+	require.Equal(t, "aws:s3", record.EventSource)
+	require.Equal(t, "new-logs", record.S3.ConfigurationID)
+	require.Equal(t, "bucket", record.S3.Bucket.Name)
+	require.Equal(t, "arn:aws:s3:::bucket", record.S3.Bucket.Arn)
+	require.Equal(t, "logs%2Fa+b.gz", record.S3.Object.Key)
+	require.Equal(t, int64(10), record.S3.Object.Size)
+	require.Equal(t, "etag", record.S3.Object.ETag)
+	require.NotEmpty(t, record.S3.Object.Sequencer)
+
+	select {
+	case data = <-target.sent:
+		t.Fatalf("unexpected event: %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -17,9 +17,14 @@ package objectnode
 import (
 	"encoding/xml"
 	"errors"
+	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -36,7 +41,10 @@ var (
 
 const (
 	ComplianceMode = "COMPLIANCE"
+	GovernanceMode = "GOVERNANCE"
 	Enabled        = "Enabled"
+	LegalHoldOn    = "ON"
+	LegalHoldOff   = "OFF"
 
 	MaxObjectLockSize     = 1 << 12 // 16KB
 	maximumRetentionDays  = 70 * 365
@@ -125,7 +133,7 @@ func ParseObjectLockConfigFromXML(data []byte) (*ObjectLockConfig, error) {
 // check valid of DefaultRetention
 func (d DefaultRetention) isValid() error {
 	switch d.Mode {
-	case ComplianceMode:
+	case ComplianceMode, GovernanceMode:
 	default:
 		return InvalidModeErr
 	}
@@ -175,33 +183,271 @@ func (r RetentionDate) MarshalXML(e *xml.Encoder, startElement xml.StartElement)
 	return e.EncodeElement(r.Format(ISO8601Layout), startElement)
 }
 
+func (r *RetentionDate) UnmarshalXML(d *xml.Decoder, startElement xml.StartElement) error {
+	var value string
+	if err := d.DecodeElement(&value, &startElement); err != nil {
+		return err
+	}
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return err
+	}
+	r.Time = t
+	return nil
+}
+
+type ObjectLegalHold struct {
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+// parseObjectRetention parses the retention of PutObjectRetention, an empty retention
+// removes the existing one.
+func parseObjectRetention(data []byte) (*ObjectRetention, *ErrorCode) {
+	retention := &ObjectRetention{}
+	if err := xml.Unmarshal(data, retention); err != nil {
+		return nil, MalformedXML
+	}
+	if retention.Mode == "" && retention.RetainUntilDate.IsZero() {
+		return retention, nil
+	}
+	if retention.Mode != ComplianceMode && retention.Mode != GovernanceMode {
+		return nil, MalformedXML
+	}
+	if retention.RetainUntilDate.IsZero() {
+		return nil, MalformedXML
+	}
+	if !retention.RetainUntilDate.After(time.Now()) {
+		return nil, InvalidRetainUntilDate
+	}
+	return retention, nil
+}
+
+func parseObjectLegalHold(data []byte) (*ObjectLegalHold, *ErrorCode) {
+	legalHold := &ObjectLegalHold{}
+	if err := xml.Unmarshal(data, legalHold); err != nil {
+		return nil, MalformedXML
+	}
+	if legalHold.Status != LegalHoldOn && legalHold.Status != LegalHoldOff {
+		return nil, MalformedXML
+	}
+	return legalHold, nil
+}
+
 func storeObjectLock(bytes []byte, vol *Volume) (err error) {
 	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSLock, bytes)
 }
 
-func isObjectLocked(v *Volume, inode uint64, name, path string) error {
-	xattrInfo, err := v.mw.XAttrGet_ll(inode, XAttrKeyOSSLock)
+// objectLockState is the object lock of an object kept in xattrs, the retention without
+// mode is written by the previous versions which only support compliance mode.
+type objectLockState struct {
+	mode            string
+	retainUntilDate int64 // unix nanoseconds
+	legalHold       bool
+}
+
+func parseObjectLockState(xattr *proto.XAttrInfo) (state objectLockState, err error) {
+	if xattr == nil {
+		return
+	}
+	if value := xattr.Get(XAttrKeyOSSLock); len(value) > 0 {
+		if state.retainUntilDate, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return
+		}
+		state.mode = retentionMode(xattr)
+	}
+	state.legalHold = string(xattr.Get(XAttrKeyOSSLegalHold)) == LegalHoldOn
+	return
+}
+
+func (s objectLockState) retained() bool {
+	return s.retainUntilDate > time.Now().UnixNano()
+}
+
+// check returns AccessDenied if the object can not be deleted or overwritten.
+func (s objectLockState) check(bypassGovernance bool) error {
+	if s.legalHold {
+		return AccessDenied
+	}
+	if s.retained() && !(bypassGovernance && s.mode == GovernanceMode) {
+		return AccessDenied
+	}
+	return nil
+}
+
+// checkRetention checks whether the retention can be replaced by the specified one.
+// A compliance retention can only be extended, a governance retention can be
+// shortened or removed only if governance is bypassed.
+func (s objectLockState) checkRetention(retention *ObjectRetention, bypassGovernance bool) error {
+	if !s.retained() {
+		return nil
+	}
+	extended := retention.Mode != "" && retention.RetainUntilDate.UnixNano() >= s.retainUntilDate
+	switch {
+	case s.mode == ComplianceMode && extended && retention.Mode == ComplianceMode:
+		return nil
+	case s.mode == GovernanceMode && (extended || bypassGovernance):
+		return nil
+	default:
+		return AccessDenied
+	}
+}
+
+func retentionMode(xattr *proto.XAttrInfo) string {
+	if len(xattr.Get(XAttrKeyOSSLock)) == 0 {
+		return ""
+	}
+	if mode := string(xattr.Get(XAttrKeyOSSLockMode)); mode != "" {
+		return mode
+	}
+	return ComplianceMode
+}
+
+func isObjectLockXAttr(key string) bool {
+	return key == XAttrKeyOSSLock || key == XAttrKeyOSSLockMode || key == XAttrKeyOSSLegalHold
+}
+
+func isObjectLocked(v *Volume, inode uint64, name, path string, bypassGovernance bool) error {
+	xattrInfo, err := v.mw.XAttrGetAll_ll(inode)
 	if err != nil {
 		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) volume(%v) path(%v) name(%v)",
 			err, v.name, path, name)
 		return err
 	}
-	retainUntilDate := xattrInfo.Get(XAttrKeyOSSLock)
-	if len(retainUntilDate) > 0 {
-		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
-		if err != nil {
-			return err
-		}
-		if retainUntilDateInt64 > time.Now().UnixNano() {
-			log.LogWarnf("isObjectLocked: object is locked, retainUntilDate(%v) volume(%v) path(%v) name(%v)",
-				retainUntilDateInt64, v.name, path, name)
-			return AccessDenied
-		}
+	state, err := parseObjectLockState(xattrInfo)
+	if err != nil {
+		return err
+	}
+	if err = state.check(bypassGovernance); err != nil {
+		log.LogWarnf("isObjectLocked: object is locked, state(%+v) volume(%v) path(%v) name(%v)",
+			state, v.name, path, name)
+		return err
 	}
 	return nil
 }
 
+// checkOverwriteLock checks whether the existing object can be overwritten, it is kept as
+// a noncurrent version if versioning is enabled, so the object lock is not violated.
+func (v *Volume) checkOverwriteLock(inode uint64, name, path string) error {
+	versioning, err := v.metaLoader.loadVersioning()
+	if err != nil {
+		return err
+	}
+	if versioning.Enabled() {
+		return nil
+	}
+	return isObjectLocked(v, inode, name, path, false)
+}
+
 func formatRetentionDateStr(modifyTime time.Time, retention *Retention) string {
 	retentionDateUnixNano := modifyTime.Add(retention.Duration).UnixNano()
 	return strconv.FormatInt(retentionDateUnixNano, 10)
 }
+
+// setXAttrs keeps the default retention of the object modified at modifyTime into xattrs.
+func (r *Retention) setXAttrs(attrs map[string]string, modifyTime time.Time) {
+	attrs[XAttrKeyOSSLock] = formatRetentionDateStr(modifyTime, r)
+	attrs[XAttrKeyOSSLockMode] = r.Mode
+}
+
+// lookupLockTarget returns the inode of the object or the specified version of it.
+func (v *Volume) lookupLockTarget(path, versionId string) (inode uint64, err error) {
+	if isReservedPath(path) {
+		err = syscall.ENOENT
+		return
+	}
+	if versionId == "" {
+		var mode os.FileMode
+		if _, inode, _, mode, err = v.recursiveLookupTarget(path, true); err == nil && mode.IsDir() {
+			err = syscall.ENOENT
+		}
+		return
+	}
+	var entry *objectVersionEntry
+	if entry, err = v.lookupObjectVersion(path, versionId); err != nil {
+		return
+	}
+	if entry.DeleteMarker {
+		err = syscall.ENOENT
+		return
+	}
+	return entry.Inode, nil
+}
+
+// PutObjectRetention sets or removes the retention of the object or the specified version of it.
+func (v *Volume) PutObjectRetention(path, versionId string, retention *ObjectRetention, bypassGovernance bool) (err error) {
+	defer func() {
+		log.LogInfof("Audit: PutObjectRetention: volume(%v) path(%v) versionId(%v) retention(%+v) bypass(%v) err(%v)",
+			v.name, path, versionId, retention, bypassGovernance, err)
+	}()
+	var inode uint64
+	if inode, err = v.lookupLockTarget(path, versionId); err != nil {
+		return
+	}
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGetAll_ll(inode); err != nil {
+		return
+	}
+	var state objectLockState
+	if state, err = parseObjectLockState(xattr); err != nil {
+		return
+	}
+	if err = state.checkRetention(retention, bypassGovernance); err != nil {
+		return
+	}
+	if retention.Mode == "" {
+		for _, key := range []string{XAttrKeyOSSLock, XAttrKeyOSSLockMode} {
+			if err = v.mw.XAttrDel_ll(inode, key); err != nil {
+				return
+			}
+			if objMetaCache != nil {
+				objMetaCache.DeleteAttrWithKey(v.name, inode, key)
+			}
+		}
+		return
+	}
+	attrs := map[string]string{
+		XAttrKeyOSSLock:     strconv.FormatInt(retention.RetainUntilDate.UnixNano(), 10),
+		XAttrKeyOSSLockMode: retention.Mode,
+	}
+	if err = v.mw.BatchSetXAttr_ll(inode, attrs); err != nil {
+		return
+	}
+	for key, value := range attrs {
+		updateAttrCache(inode, key, value, v.name)
+	}
+	return
+}
+
+// PutObjectLegalHold turns on or off the legal hold of the object or the specified version of it.
+func (v *Volume) PutObjectLegalHold(path, versionId, status string) (err error) {
+	defer func() {
+		log.LogInfof("Audit: PutObjectLegalHold: volume(%v) path(%v) versionId(%v) status(%v) err(%v)",
+			v.name, path, versionId, status, err)
+	}()
+	var inode uint64
+	if inode, err = v.lookupLockTarget(path, versionId); err != nil {
+		return
+	}
+	if err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSLegalHold), []byte(status)); err != nil {
+		return
+	}
+	updateAttrCache(inode, XAttrKeyOSSLegalHold, status, v.name)
+	return
+}
+
+// parseBypassGovernance checks whether the request bypasses the governance retention,
+// only the owner of the bucket is allowed to do so.
+func parseBypassGovernance(r *http.Request, param *RequestParam, vol *Volume) (bool, *ErrorCode) {
+	if !strings.EqualFold(r.Header.Get(XAmzBypassGovernanceRetention), "true") {
+		return false, nil
+	}
+	if param.Requester() != vol.owner {
+		return false, AccessDenied
+	}
+	return true, nil
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cubefs/cubefs/proto"
 	"github.com/stretchr/testify/require"
 )
 
@@ -217,3 +218,87 @@ func TestMarshalXML(t *testing.T) {
 	_, err := xml.Marshal(objectRetention)
 	require.NoError(t, err)
 }
+
+func TestParseObjectRetention(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{value: `<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>` + future + `</RetainUntilDate></Retention>`},
+		{value: `<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>` + future + `</RetainUntilDate></Retention>`},
+		{value: `<Retention></Retention>`},
+		{
+			value:       `<Retention><Mode>LOCKED</Mode><RetainUntilDate>` + future + `</RetainUntilDate></Retention>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<Retention><Mode>GOVERNANCE</Mode></Retention>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>tomorrow</RetainUntilDate></Retention>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>` + past + `</RetainUntilDate></Retention>`,
+			expectedErr: InvalidRetainUntilDate,
+		},
+	}
+	for i, tt := range tests {
+		_, errCode := parseObjectRetention([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode, "case %d", i)
+	}
+
+	_, errCode := parseObjectLegalHold([]byte(`<LegalHold><Status>ON</Status></LegalHold>`))
+	require.Nil(t, errCode)
+	_, errCode = parseObjectLegalHold([]byte(`<LegalHold><Status>on</Status></LegalHold>`))
+	require.Equal(t, MalformedXML, errCode)
+}
+
+func TestObjectLockState(t *testing.T) {
+	now := time.Now()
+	retainUntil := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(d).UnixNano(), 10)
+	}
+	retention := func(mode string, d time.Duration) *ObjectRetention {
+		return &ObjectRetention{Mode: mode, RetainUntilDate: RetentionDate{Time: now.Add(d)}}
+	}
+
+	// retention written without mode is compliance
+	state, err := parseObjectLockState(&proto.XAttrInfo{XAttrs: map[string]string{XAttrKeyOSSLock: retainUntil(time.Hour)}})
+	require.NoError(t, err)
+	require.Equal(t, ComplianceMode, state.mode)
+	require.Equal(t, AccessDenied, state.check(true))
+	require.NoError(t, state.checkRetention(retention(ComplianceMode, 2*time.Hour), false))
+	require.Equal(t, AccessDenied, state.checkRetention(retention(ComplianceMode, time.Minute), true))
+	require.Equal(t, AccessDenied, state.checkRetention(retention(GovernanceMode, 2*time.Hour), true))
+	require.Equal(t, AccessDenied, state.checkRetention(&ObjectRetention{}, true))
+
+	state, err = parseObjectLockState(&proto.XAttrInfo{XAttrs: map[string]string{
+		XAttrKeyOSSLock:     retainUntil(time.Hour),
+		XAttrKeyOSSLockMode: GovernanceMode,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, AccessDenied, state.check(false))
+	require.NoError(t, state.check(true))
+	require.NoError(t, state.checkRetention(retention(ComplianceMode, 2*time.Hour), false))
+	require.Equal(t, AccessDenied, state.checkRetention(retention(GovernanceMode, time.Minute), false))
+	require.NoError(t, state.checkRetention(retention(GovernanceMode, time.Minute), true))
+	require.NoError(t, state.checkRetention(&ObjectRetention{}, true))
+
+	// expired retention doesn't protect the object
+	state, err = parseObjectLockState(&proto.XAttrInfo{XAttrs: map[string]string{XAttrKeyOSSLock: retainUntil(-time.Hour)}})
+	require.NoError(t, err)
+	require.NoError(t, state.check(false))
+	require.NoError(t, state.checkRetention(&ObjectRetention{}, false))
+
+	// legal hold can't be bypassed
+	state, err = parseObjectLockState(&proto.XAttrInfo{XAttrs: map[string]string{XAttrKeyOSSLegalHold: LegalHoldOn}})
+	require.NoError(t, err)
+	require.Equal(t, AccessDenied, state.check(true))
+
+	_, err = parseObjectLockState(&proto.XAttrInfo{XAttrs: map[string]string{XAttrKeyOSSLock: "never"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,301 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/xml"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/replication.html
+//
+// Objects written into a bucket with replication configured are marked PENDING and queued
+// under ReplicationDirName, the replicator of lcnode copies them to the destination bucket
+// and updates the replication status of the source objects.
+
+const (
+	MaxReplicationSize     = 1 << 16 // 64KB
+	MaxReplicationRules    = 1000
+	ReplicationRuleEnabled = "Enabled"
+	ReplicationRuleDisable = "Disabled"
+
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+
+	// ReplicationDirName is the hidden directory under the volume root which keeps
+	// the objects waiting for replication, each entry is named by the inode of the object.
+	ReplicationDirName = ".oss_replication"
+
+	s3BucketArnPrefix = "arn:aws:s3:::"
+
+	XAttrKeyOSSReplicationPath   = "oss:replication-path"
+	XAttrKeyOSSReplicationBucket = "oss:replication-bucket"
+	XAttrKeyOSSReplicationClass  = "oss:replication-class"
+)
+
+type ReplicationConfiguration struct {
+	XMLNS   string             `xml:"xmlns,attr,omitempty"`
+	XMLName xml.Name           `xml:"ReplicationConfiguration"`
+	Role    string             `xml:"Role"`
+	Rules   []*ReplicationRule `xml:"Rule"`
+}
+
+type ReplicationRule struct {
+	ID                      string                   `xml:"ID,omitempty"`
+	Priority                int                      `xml:"Priority,omitempty"`
+	Status                  string                   `xml:"Status"`
+	Prefix                  string                   `xml:"Prefix,omitempty"`
+	Filter                  *ReplicationFilter       `xml:"Filter,omitempty"`
+	Destination             *ReplicationDestination  `xml:"Destination"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication,omitempty"`
+}
+
+type ReplicationFilter struct {
+	Prefix string          `xml:"Prefix,omitempty"`
+	Tag    *Tag            `xml:"Tag,omitempty"`
+	And    *ReplicationAnd `xml:"And,omitempty"`
+}
+
+type ReplicationAnd struct {
+	Prefix string `xml:"Prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag,omitempty"`
+}
+
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket"`
+	StorageClass string `xml:"StorageClass,omitempty"`
+}
+
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status"`
+}
+
+func parseBucketReplication(bytes []byte) (config *ReplicationConfiguration, errCode *ErrorCode) {
+	config = &ReplicationConfiguration{}
+	if err := xml.Unmarshal(bytes, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Rules) == 0 || len(config.Rules) > MaxReplicationRules {
+		return nil, MalformedXML
+	}
+	ids := make(map[string]struct{})
+	for _, rule := range config.Rules {
+		if errCode = rule.validate(); errCode != nil {
+			return nil, errCode
+		}
+		if rule.ID == "" {
+			continue
+		}
+		if _, ok := ids[rule.ID]; ok {
+			return nil, InvalidArgument
+		}
+		ids[rule.ID] = struct{}{}
+	}
+	return config, nil
+}
+
+func (r *ReplicationRule) validate() *ErrorCode {
+	if len(r.ID) > MaxIdLength {
+		return InvalidArgument
+	}
+	if r.Status != ReplicationRuleEnabled && r.Status != ReplicationRuleDisable {
+		return MalformedXML
+	}
+	if r.Destination == nil || r.Destination.bucketName() == "" {
+		return InvalidArgument
+	}
+	// deletions are not replicated
+	if r.DeleteMarkerReplication != nil && r.DeleteMarkerReplication.Status == ReplicationRuleEnabled {
+		return UnsupportedOperation
+	}
+	if r.Filter == nil {
+		return nil
+	}
+	if r.Prefix != "" {
+		return MalformedXML
+	}
+	conditions := 0
+	if r.Filter.Prefix != "" {
+		conditions++
+	}
+	if r.Filter.Tag != nil {
+		conditions++
+		if !r.Filter.Tag.isValid() {
+			return InvalidTag
+		}
+	}
+	if r.Filter.And != nil {
+		conditions++
+		keys := make(map[string]struct{})
+		for _, tag := range r.Filter.And.Tags {
+			if !tag.isValid() {
+				return InvalidTag
+			}
+			if _, ok := keys[tag.Key]; ok {
+				return DuplicateTagKey
+			}
+			keys[tag.Key] = struct{}{}
+		}
+	}
+	if conditions > 1 {
+		return MalformedXML
+	}
+	return nil
+}
+
+// bucketName returns the destination bucket, the ARN form is accepted for compatibility.
+func (d *ReplicationDestination) bucketName() string {
+	return strings.TrimPrefix(d.Bucket, s3BucketArnPrefix)
+}
+
+func (r *ReplicationRule) prefix() string {
+	switch {
+	case r.Filter == nil:
+		return r.Prefix
+	case r.Filter.And != nil:
+		return r.Filter.And.Prefix
+	default:
+		return r.Filter.Prefix
+	}
+}
+
+func (r *ReplicationRule) tags() []Tag {
+	switch {
+	case r.Filter == nil:
+		return nil
+	case r.Filter.And != nil:
+		return r.Filter.And.Tags
+	case r.Filter.Tag != nil:
+		return []Tag{*r.Filter.Tag}
+	default:
+		return nil
+	}
+}
+
+func (r *ReplicationRule) match(key string, tags map[string]string) bool {
+	if r.Status != ReplicationRuleEnabled || !strings.HasPrefix(key, r.prefix()) {
+		return false
+	}
+	for _, tag := range r.tags() {
+		if value, ok := tags[tag.Key]; !ok || value != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns the enabled rule with the highest priority which applies to the object.
+func (c *ReplicationConfiguration) Match(key string, tags map[string]string) *ReplicationRule {
+	if c == nil {
+		return nil
+	}
+	rules := make([]*ReplicationRule, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		if rule.match(key, tags) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+	return rules[0]
+}
+
+func storeBucketReplication(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSReplication, bytes)
+}
+
+func deleteBucketReplication(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSReplication)
+}
+
+// markReplication marks the object to be written as pending for replication
+// if a replication rule applies to it.
+func (v *Volume) markReplication(path string, attrs map[string]string) {
+	if v.matchReplication(path, attrs) != nil {
+		attrs[XAttrKeyOSSReplicationStatus] = ReplicationStatusPending
+	}
+}
+
+func (v *Volume) matchReplication(path string, attrs map[string]string) *ReplicationRule {
+	config, err := v.metaLoader.loadReplication()
+	if err != nil || config == nil {
+		return nil
+	}
+	return config.Match(strings.TrimPrefix(path, pathSep), parseTagsMap(attrs[XAttrKeyOSSTagging]))
+}
+
+// queueReplication records the object into the replication queue, which is consumed by lcnode.
+func (v *Volume) queueReplication(path string, inode uint64, attrs map[string]string) {
+	if attrs[XAttrKeyOSSReplicationStatus] != ReplicationStatusPending {
+		return
+	}
+	rule := v.matchReplication(path, attrs)
+	if rule == nil {
+		return
+	}
+
+	var err error
+	var dirIno uint64
+	if dirIno, err = v.lookupDirectories([]string{ReplicationDirName}, true); err != nil {
+		log.LogErrorf("queueReplication: lookup queue directory fail: volume(%v) path(%v) err(%v)", v.name, path, err)
+		return
+	}
+	name := strconv.FormatUint(inode, 10)
+	fullPath := ReplicationDirName + pathSep + name
+	var info *proto.InodeInfo
+	if info, err = v.mw.Create_ll(dirIno, name, uint32(DefaultFileMode), 0, 0, nil, fullPath); err != nil {
+		if err != syscall.EEXIST {
+			log.LogErrorf("queueReplication: create queue entry fail: volume(%v) path(%v) inode(%v) err(%v)",
+				v.name, path, inode, err)
+		}
+		return
+	}
+	entry := map[string]string{
+		XAttrKeyOSSReplicationPath:   strings.TrimPrefix(path, pathSep),
+		XAttrKeyOSSReplicationBucket: rule.Destination.bucketName(),
+	}
+	if rule.Destination.StorageClass != "" {
+		entry[XAttrKeyOSSReplicationClass] = rule.Destination.StorageClass
+	}
+	if err = v.mw.BatchSetXAttr_ll(info.Inode, entry); err != nil {
+		log.LogErrorf("queueReplication: set queue entry fail: volume(%v) path(%v) inode(%v) err(%v)",
+			v.name, path, inode, err)
+	}
+}
+
+func parseTagsMap(tagging string) map[string]string {
+	tags := make(map[string]string)
+	if tagging == "" {
+		return tags
+	}
+	if values, err := url.ParseQuery(tagging); err == nil {
+		for key := range values {
+			tags[key] = values.Get(key)
+		}
+	}
+	return tags
+}
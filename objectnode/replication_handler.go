@@ -0,0 +1,165 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
+func (o *ObjectNode) getBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *ReplicationConfiguration
+	if config, err = vol.metaLoader.loadReplication(); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: load replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config == nil {
+		errorCode = NoSuchReplicationConfiguration
+		return
+	}
+	result := &ReplicationConfiguration{XMLNS: XMLNS, Role: config.Role, Rules: config.Rules}
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("getBucketReplicationHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
+func (o *ObjectNode) putBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxReplicationSize+1)); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxReplicationSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *ReplicationConfiguration
+	if config, errorCode = parseBucketReplication(body); errorCode != nil {
+		log.LogErrorf("putBucketReplicationHandler: parse replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	config.XMLNS = ""
+	if body, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: xml marshal replication config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	// lcnode only replicates the volumes with replication enabled on master
+	if err = o.mc.AdminAPI().SetVolumeReplication(vol.Name(), true); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: enable volume replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if err = storeBucketReplication(body, vol); err != nil {
+		log.LogErrorf("putBucketReplicationHandler: store replication config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeReplication(config)
+
+	log.LogInfof("Audit: put bucket replication: requestID(%v) remote(%v) volume(%v) config(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), string(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
+func (o *ObjectNode) deleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+	if err = deleteBucketReplication(vol); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: delete bucket replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	vol.metaLoader.storeReplication(nil)
+	if err = o.mc.AdminAPI().SetVolumeReplication(vol.Name(), false); err != nil {
+		log.LogErrorf("deleteBucketReplicationHandler: disable volume replication fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	log.LogInfof("Audit: delete bucket replication: requestID(%v) remote(%v) volume(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name())
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketReplication(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<ReplicationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Role>arn:aws:iam::123456789012:role/replication</Role>
+						<Rule><ID>r1</ID><Status>Enabled</Status><Prefix>logs/</Prefix>
+							<Destination><Bucket>arn:aws:s3:::backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Priority>1</Priority>
+							<Filter><And><Prefix>a/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter>
+							<DeleteMarkerReplication><Status>Disabled</Status></DeleteMarkerReplication>
+							<Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+		},
+		{
+			value:       `<ReplicationConfiguration></ReplicationConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>On</Status><Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><ID>r1</ID><Status>Enabled</Status><Destination><Bucket>a</Bucket></Destination></Rule>
+						<Rule><ID>r1</ID><Status>Enabled</Status><Destination><Bucket>b</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: InvalidArgument,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><Filter><Prefix>a/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></Filter>
+							<Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status>
+							<Filter><And><Tag><Key>k</Key><Value>v</Value></Tag><Tag><Key>k</Key><Value>w</Value></Tag></And></Filter>
+							<Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: DuplicateTagKey,
+		},
+		{
+			value: `<ReplicationConfiguration>
+						<Rule><Status>Enabled</Status><DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication>
+							<Destination><Bucket>backup</Bucket></Destination></Rule>
+					</ReplicationConfiguration>`,
+			expectedErr: UnsupportedOperation,
+		},
+	}
+	for _, tt := range tests {
+		_, errCode := parseBucketReplication([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode, tt.value)
+	}
+}
+
+func TestReplicationMatch(t *testing.T) {
+	config, errCode := parseBucketReplication([]byte(`<ReplicationConfiguration>
+		<Rule><ID>all</ID><Status>Enabled</Status><Priority>1</Priority>
+			<Filter></Filter><Destination><Bucket>all</Bucket></Destination></Rule>
+		<Rule><ID>tagged</ID><Status>Enabled</Status><Priority>3</Priority>
+			<Filter><And><Prefix>docs/</Prefix><Tag><Key>dr</Key><Value>yes</Value></Tag></And></Filter>
+			<Destination><Bucket>arn:aws:s3:::tagged</Bucket><StorageClass>STANDARD</StorageClass></Destination></Rule>
+		<Rule><ID>disabled</ID><Status>Disabled</Status><Priority>5</Priority>
+			<Filter><Prefix>docs/</Prefix></Filter><Destination><Bucket>disabled</Bucket></Destination></Rule>
+	</ReplicationConfiguration>`))
+	require.Nil(t, errCode)
+
+	rule := config.Match("docs/a.txt", map[string]string{"dr": "yes"})
+	require.NotNil(t, rule)
+	require.Equal(t, "tagged", rule.ID)
+	require.Equal(t, "tagged", rule.Destination.bucketName())
+
+	rule = config.Match("docs/a.txt", map[string]string{"dr": "no"})
+	require.NotNil(t, rule)
+	require.Equal(t, "all", rule.ID)
+
+	var empty *ReplicationConfiguration
+	require.Nil(t, empty.Match("docs/a.txt", nil))
+
+	require.True(t, isReservedPath(ReplicationDirName+"/123"))
+	require.Equal(t, map[string]string{"dr": "yes", "k": ""}, parseTagsMap("dr=yes&k="))
+}
@@ -89,7 +89,33 @@ var (
 	NoContentMd5HeaderErr               = &ErrorCode{"NoContentMd5Header", "Content-MD5 HTTP header is required for Upload Object/Part requests with Object Lock parameters", http.StatusBadRequest}
 	ObjectLockConfigurationNotFound     = &ErrorCode{"ObjectLockConfigurationNotFoundError", "Object Lock configuration does not exist for this bucket", http.StatusNotFound}
 	TooManyRequests                     = &ErrorCode{"TooManyRequests", "too many requests, please retry later", http.StatusTooManyRequests}
+	IllegalVersioningConfiguration      = &ErrorCode{"IllegalVersioningConfigurationException", "The versioning configuration specified in the request is invalid.", http.StatusBadRequest}
+	NoSuchVersion                       = &ErrorCode{"NoSuchVersion", "The specified version does not exist.", http.StatusNotFound}
+	InvalidVersionId                    = &ErrorCode{"InvalidArgument", "Invalid version id specified.", http.StatusBadRequest}
+	MethodNotAllowed                    = &ErrorCode{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	CopySourceDeleteMarker              = &ErrorCode{"InvalidRequest", "The source of a copy request may not specifically refer to a delete marker by version id.", http.StatusBadRequest}
+	InvalidEncryptionAlgorithm          = &ErrorCode{"InvalidEncryptionAlgorithmError", "The encryption request you specified is not valid. The valid value is AES256.", http.StatusBadRequest}
+	InvalidSSECustomerKey               = &ErrorCode{"InvalidArgument", "The secret key was invalid for the specified algorithm.", http.StatusBadRequest}
+	SSECustomerKeyMD5Mismatch           = &ErrorCode{"InvalidArgument", "The calculated MD5 hash of the key did not match the hash that was provided.", http.StatusBadRequest}
+	SSECustomerKeyRequired              = &ErrorCode{"InvalidRequest", "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.", http.StatusBadRequest}
+	SSECustomerKeyMismatch              = &ErrorCode{"AccessDenied", "The provided encryption key does not match the key used to encrypt the object.", http.StatusForbidden}
+	SSEMasterKeyNotConfigured           = &ErrorCode{"InvalidRequest", "Server side encryption with managed keys is not configured.", http.StatusBadRequest}
+	NoSuchEncryptionConfiguration       = &ErrorCode{"ServerSideEncryptionConfigurationNotFoundError", "The server side encryption configuration was not found.", http.StatusNotFound}
+	NoSuchReplicationConfiguration      = &ErrorCode{"ReplicationConfigurationNotFoundError", "The replication configuration was not found.", http.StatusNotFound}
+	InvalidNotificationDestination      = &ErrorCode{"InvalidArgument", "Unable to validate the following destination configurations.", http.StatusBadRequest}
+	ObjectLockNotEnabled                = &ErrorCode{"InvalidRequest", "Bucket is missing Object Lock Configuration.", http.StatusBadRequest}
+	InvalidRetainUntilDate              = &ErrorCode{"InvalidArgument", "The retain until date must be in the future.", http.StatusBadRequest}
+	NoSuchObjectLegalHold               = &ErrorCode{"NoSuchObjectLockConfiguration", "The specified object does not have a legal hold configuration.", http.StatusNotFound}
 	MalformedPOSTRequest                = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
+	InvalidExpressionType               = &ErrorCode{"InvalidExpressionType", "The ExpressionType is invalid. Only SQL expressions are supported.", http.StatusBadRequest}
+	InvalidCompressionFormat            = &ErrorCode{"InvalidCompressionFormat", "The file is not in a supported compression format. Only GZIP and BZIP2 are supported.", http.StatusBadRequest}
+	ObjectSerializationConflict         = &ErrorCode{"ObjectSerializationConflict", "The InputSerialization and OutputSerialization must specify exactly one format.", http.StatusBadRequest}
+	InvalidFileHeaderInfo               = &ErrorCode{"InvalidFileHeaderInfo", "The FileHeaderInfo is invalid. Only NONE, USE, and IGNORE are supported.", http.StatusBadRequest}
+	InvalidJsonType                     = &ErrorCode{"InvalidJsonType", "The JsonType is invalid. Only DOCUMENT and LINES are supported.", http.StatusBadRequest}
+	InvalidQuoteFields                  = &ErrorCode{"InvalidQuoteFields", "The QuoteFields is invalid. Only ALWAYS and ASNEEDED are supported.", http.StatusBadRequest}
+	InvalidRequestParameter             = &ErrorCode{"InvalidRequestParameter", "The value of a parameter in SelectRequest element is invalid.", http.StatusBadRequest}
+	CSVParsingError                     = &ErrorCode{"CSVParsingError", "Encountered an error parsing the CSV file.", http.StatusBadRequest}
+	JSONParsingError                    = &ErrorCode{"JSONParsingError", "Encountered an error parsing the JSON file.", http.StatusBadRequest}
 )
 
 type ErrorCode struct {
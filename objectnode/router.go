@@ -96,12 +96,11 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetObjectLegalHoldAction)).
 			Methods(http.MethodGet).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getObjectLegalHoldHandler)
 
 		// Get object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectRetention.html
@@ -179,11 +178,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketEncryptionAction)).
 			Methods(http.MethodGet).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketEncryptionHandler)
 
 		// Get bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketCors.html
@@ -219,11 +217,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketReplicationAction)).
 			Methods(http.MethodGet).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketReplicationHandler)
+
+		// Get bucket notification
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketNotificationAction)).
+			Methods(http.MethodGet).
+			Queries("notification", "").
+			HandlerFunc(o.getBucketNotificationHandler)
 
 		// Get bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycle.html
@@ -242,19 +246,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Get bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetBucketVersioningAction)).
 			Methods(http.MethodGet).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.getBucketVersioningHandler)
 
 		// List object versions
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListObjectVersionsAction)).
 			Methods(http.MethodGet).
 			Queries("versions", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.listObjectVersionsHandler)
 
 		// List objects version 1
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjects.html
@@ -280,6 +282,14 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Queries("uploadId", "{uploadId:.*}").
 			HandlerFunc(o.completeMultipartUploadHandler)
 
+		// Select object content
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSSelectObjectContentAction)).
+			Methods(http.MethodPost).
+			Path("/{object:.+}").
+			Queries("select", "", "select-type", "2").
+			HandlerFunc(o.selectObjectContentHandler)
+
 		// Restore object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_RestoreObject.html
 		// Notes: unsupported operation
@@ -362,21 +372,19 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put object legal hold
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectLegalHoldAction)).
 			Methods(http.MethodPut).
 			Path("/{object:.+}").
 			Queries("legal-hold", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putObjectLegalHoldHandler)
 
 		// Put object retention
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectRetention.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectRetentionAction)).
 			Methods(http.MethodPut).
 			Path("/{object:.+}").
 			Queries("retention", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putObjectRetentionHandler)
 
 		// Put object
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html
@@ -408,11 +416,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketEncryptionAction)).
 			Methods(http.MethodPut).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketEncryptionHandler)
 
 		// Put bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
@@ -448,11 +455,17 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketReplicationAction)).
 			Methods(http.MethodPut).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketReplicationHandler)
+
+		// Put bucket notification
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketNotificationConfiguration.html
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketNotificationAction)).
+			Methods(http.MethodPut).
+			Queries("notification", "").
+			HandlerFunc(o.putBucketNotificationHandler)
 
 		// Put bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycle.html
@@ -471,11 +484,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Put bucket versioning
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutBucketVersioningAction)).
 			Methods(http.MethodPut).
 			Queries("versioning", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.putBucketVersioningHandler)
 
 		// Create bucket
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_CreateBucket.html
@@ -532,11 +544,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket encryption
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketEncryption.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketEncryptionAction)).
 			Methods(http.MethodDelete).
 			Queries("encryption", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketEncryptionHandler)
 
 		// Delete bucket cors
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketCors.html
@@ -564,11 +575,10 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 
 		// Delete bucket replication
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketReplication.html
-		// Notes: unsupported operation
 		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSDeleteBucketReplicationAction)).
 			Methods(http.MethodDelete).
 			Queries("replication", "").
-			HandlerFunc(o.unsupportedOperationHandler)
+			HandlerFunc(o.deleteBucketReplicationHandler)
 
 		// Delete bucket lifecycle
 		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
@@ -0,0 +1,856 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+)
+
+const (
+	MaxSelectRequestSize = 1 << 18 // 256KB
+
+	maxSelectRecordSize   = 1 << 20 // 1MB
+	selectRecordsBatch    = 1 << 18 // send the records once 256KB are buffered
+	selectCheckInterval   = 1 << 10 // check whether to send a message every 1024 input records
+	selectKeepAlivePeriod = 2 * time.Second
+
+	SelectExpressionTypeSQL = "SQL"
+
+	SelectCompressionNone  = "NONE"
+	SelectCompressionGzip  = "GZIP"
+	SelectCompressionBzip2 = "BZIP2"
+
+	SelectFileHeaderUse    = "USE"
+	SelectFileHeaderIgnore = "IGNORE"
+	SelectFileHeaderNone   = "NONE"
+
+	SelectJsonTypeDocument = "DOCUMENT"
+	SelectJsonTypeLines    = "LINES"
+
+	SelectQuoteFieldsAlways   = "ALWAYS"
+	SelectQuoteFieldsAsNeeded = "ASNEEDED"
+)
+
+type SelectObjectContentRequest struct {
+	XMLName             xml.Name                  `xml:"SelectObjectContentRequest"`
+	Expression          string                    `xml:"Expression"`
+	ExpressionType      string                    `xml:"ExpressionType"`
+	RequestProgress     *SelectRequestProgress    `xml:"RequestProgress,omitempty"`
+	InputSerialization  SelectInputSerialization  `xml:"InputSerialization"`
+	OutputSerialization SelectOutputSerialization `xml:"OutputSerialization"`
+	ScanRange           *SelectScanRange          `xml:"ScanRange,omitempty"`
+}
+
+type SelectRequestProgress struct {
+	Enabled bool `xml:"Enabled"`
+}
+
+type SelectScanRange struct {
+	Start *int64 `xml:"Start,omitempty"`
+	End   *int64 `xml:"End,omitempty"`
+}
+
+type SelectInputSerialization struct {
+	CompressionType string           `xml:"CompressionType,omitempty"`
+	CSV             *SelectCSVInput  `xml:"CSV,omitempty"`
+	JSON            *SelectJSONInput `xml:"JSON,omitempty"`
+	Parquet         *struct{}        `xml:"Parquet,omitempty"`
+}
+
+type SelectCSVInput struct {
+	AllowQuotedRecordDelimiter bool   `xml:"AllowQuotedRecordDelimiter,omitempty"`
+	Comments                   string `xml:"Comments,omitempty"`
+	FieldDelimiter             string `xml:"FieldDelimiter,omitempty"`
+	FileHeaderInfo             string `xml:"FileHeaderInfo,omitempty"`
+	QuoteCharacter             string `xml:"QuoteCharacter,omitempty"`
+	QuoteEscapeCharacter       string `xml:"QuoteEscapeCharacter,omitempty"`
+	RecordDelimiter            string `xml:"RecordDelimiter,omitempty"`
+}
+
+type SelectJSONInput struct {
+	Type string `xml:"Type,omitempty"`
+}
+
+type SelectOutputSerialization struct {
+	CSV  *SelectCSVOutput  `xml:"CSV,omitempty"`
+	JSON *SelectJSONOutput `xml:"JSON,omitempty"`
+}
+
+type SelectCSVOutput struct {
+	FieldDelimiter       string `xml:"FieldDelimiter,omitempty"`
+	QuoteCharacter       string `xml:"QuoteCharacter,omitempty"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter,omitempty"`
+	QuoteFields          string `xml:"QuoteFields,omitempty"`
+	RecordDelimiter      string `xml:"RecordDelimiter,omitempty"`
+}
+
+type SelectJSONOutput struct {
+	RecordDelimiter string `xml:"RecordDelimiter,omitempty"`
+}
+
+type SelectStats struct {
+	BytesScanned   int64 `xml:"BytesScanned"`
+	BytesProcessed int64 `xml:"BytesProcessed"`
+	BytesReturned  int64 `xml:"BytesReturned"`
+}
+
+func ParseSelectObjectContentRequest(data []byte) (*SelectObjectContentRequest, *ErrorCode) {
+	req := &SelectObjectContentRequest{}
+	if err := xml.Unmarshal(data, req); err != nil {
+		return nil, MalformedXML
+	}
+	if errCode := req.validate(); errCode != nil {
+		return nil, errCode
+	}
+	return req, nil
+}
+
+func (req *SelectObjectContentRequest) validate() *ErrorCode {
+	if !strings.EqualFold(req.ExpressionType, SelectExpressionTypeSQL) {
+		return InvalidExpressionType
+	}
+	if strings.TrimSpace(req.Expression) == "" {
+		return NewError("MissingRequiredParameter", "The SelectRequest entity is missing a required parameter Expression.", http.StatusBadRequest)
+	}
+	if req.ScanRange != nil {
+		return UnsupportedOperation
+	}
+
+	in := &req.InputSerialization
+	switch strings.ToUpper(in.CompressionType) {
+	case "", SelectCompressionNone, SelectCompressionGzip, SelectCompressionBzip2:
+	default:
+		return InvalidCompressionFormat
+	}
+	if in.Parquet != nil {
+		return UnsupportedOperation
+	}
+	if (in.CSV == nil) == (in.JSON == nil) {
+		return ObjectSerializationConflict
+	}
+	if in.CSV != nil {
+		switch strings.ToUpper(in.CSV.FileHeaderInfo) {
+		case "", SelectFileHeaderUse, SelectFileHeaderIgnore, SelectFileHeaderNone:
+		default:
+			return InvalidFileHeaderInfo
+		}
+		if utf8.RuneCountInString(in.CSV.FieldDelimiter) > 1 || utf8.RuneCountInString(in.CSV.Comments) > 1 {
+			return InvalidRequestParameter
+		}
+		if !isValidSelectQuote(in.CSV.QuoteCharacter) || !isValidSelectQuote(in.CSV.QuoteEscapeCharacter) ||
+			!isValidSelectRecordDelimiter(in.CSV.RecordDelimiter) {
+			return InvalidRequestParameter
+		}
+	}
+	if in.JSON != nil {
+		switch strings.ToUpper(in.JSON.Type) {
+		case "", SelectJsonTypeDocument, SelectJsonTypeLines:
+		default:
+			return InvalidJsonType
+		}
+	}
+
+	out := &req.OutputSerialization
+	if (out.CSV == nil) == (out.JSON == nil) {
+		return ObjectSerializationConflict
+	}
+	if out.CSV != nil {
+		switch strings.ToUpper(out.CSV.QuoteFields) {
+		case "", SelectQuoteFieldsAlways, SelectQuoteFieldsAsNeeded:
+		default:
+			return InvalidQuoteFields
+		}
+		if utf8.RuneCountInString(out.CSV.FieldDelimiter) > 1 || utf8.RuneCountInString(out.CSV.QuoteCharacter) > 1 ||
+			utf8.RuneCountInString(out.CSV.QuoteEscapeCharacter) > 1 || len(out.CSV.RecordDelimiter) > 2 {
+			return InvalidRequestParameter
+		}
+	}
+	if out.JSON != nil && len(out.JSON.RecordDelimiter) > 2 {
+		return InvalidRequestParameter
+	}
+	return nil
+}
+
+// only the double quote is supported to quote the fields of the input csv
+func isValidSelectQuote(quote string) bool {
+	return quote == "" || quote == `"`
+}
+
+func isValidSelectRecordDelimiter(delimiter string) bool {
+	return delimiter == "" || delimiter == "\n" || delimiter == "\r\n"
+}
+
+// selectObject is a record or a nested json object, which keeps the order of the fields.
+type selectObject struct {
+	keys []string
+	vals []interface{}
+}
+
+func newSelectObject(keys []string, vals []interface{}) *selectObject {
+	return &selectObject{keys: keys, vals: vals}
+}
+
+// lookup returns the value of the field by its name, the name is matched case-insensitively
+// if there is no exact match, and _N refers to the Nth field.
+func (o *selectObject) lookup(name string) interface{} {
+	for i, key := range o.keys {
+		if key == name {
+			return o.vals[i]
+		}
+	}
+	for i, key := range o.keys {
+		if strings.EqualFold(key, name) {
+			return o.vals[i]
+		}
+	}
+	if strings.HasPrefix(name, "_") {
+		if n, err := strconv.Atoi(name[1:]); err == nil && n >= 1 && n <= len(o.vals) {
+			return o.vals[n-1]
+		}
+	}
+	return nil
+}
+
+type selectJSONWriter interface {
+	WriteByte(c byte) error
+	WriteString(s string) (int, error)
+}
+
+func writeSelectJSONValue(w selectJSONWriter, v interface{}) {
+	switch x := v.(type) {
+	case nil:
+		w.WriteString("null")
+	case bool:
+		w.WriteString(strconv.FormatBool(x))
+	case int64:
+		w.WriteString(strconv.FormatInt(x, 10))
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			w.WriteString("null")
+		} else {
+			w.WriteString(strconv.FormatFloat(x, 'g', -1, 64))
+		}
+	case string:
+		data, _ := json.Marshal(x)
+		w.WriteString(string(data))
+	case []interface{}:
+		w.WriteByte('[')
+		for i, item := range x {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeSelectJSONValue(w, item)
+		}
+		w.WriteByte(']')
+	case *selectObject:
+		writeSelectJSONObject(w, x.keys, x.vals)
+	}
+}
+
+func writeSelectJSONObject(w selectJSONWriter, keys []string, vals []interface{}) {
+	w.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		writeSelectJSONValue(w, key)
+		w.WriteByte(':')
+		writeSelectJSONValue(w, vals[i])
+	}
+	w.WriteByte('}')
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return
+}
+
+type selectRecordReader interface {
+	// Read returns the next record, or io.EOF if there is no more record.
+	Read() (*selectObject, error)
+}
+
+type selectCSVReader struct {
+	r      *csv.Reader
+	header string
+	names  []string
+}
+
+func newSelectCSVReader(r io.Reader, opt *SelectCSVInput) *selectCSVReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	if opt.FieldDelimiter != "" {
+		cr.Comma, _ = utf8.DecodeRuneInString(opt.FieldDelimiter)
+	}
+	if opt.Comments != "" {
+		cr.Comment, _ = utf8.DecodeRuneInString(opt.Comments)
+	}
+	header := strings.ToUpper(opt.FileHeaderInfo)
+	if header == "" {
+		header = SelectFileHeaderNone
+	}
+	return &selectCSVReader{r: cr, header: header}
+}
+
+func (c *selectCSVReader) readLine() ([]string, error) {
+	fields, err := c.r.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, NewError(CSVParsingError.ErrorCode, fmt.Sprintf("%v: %v", CSVParsingError.ErrorMessage, err), http.StatusBadRequest)
+	}
+	var size int
+	for _, field := range fields {
+		size += len(field)
+	}
+	if size > maxSelectRecordSize {
+		return nil, OverMaxRecordSize
+	}
+	return fields, nil
+}
+
+func (c *selectCSVReader) Read() (*selectObject, error) {
+	if c.names == nil && c.header != SelectFileHeaderNone {
+		fields, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		c.names = make([]string, len(fields))
+		for i, field := range fields {
+			if c.header == SelectFileHeaderUse {
+				c.names[i] = field
+			} else {
+				c.names[i] = "_" + strconv.Itoa(i+1)
+			}
+		}
+	}
+	fields, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(fields))
+	vals := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if i < len(c.names) {
+			keys[i] = c.names[i]
+		} else {
+			keys[i] = "_" + strconv.Itoa(i+1)
+		}
+		vals[i] = field
+	}
+	return newSelectObject(keys, vals), nil
+}
+
+type selectJSONReader struct {
+	d         *json.Decoder
+	arrayIter bool
+	inArray   bool
+}
+
+func newSelectJSONReader(r io.Reader, arrayIter bool) *selectJSONReader {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &selectJSONReader{d: d, arrayIter: arrayIter}
+}
+
+func (j *selectJSONReader) parsingError(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return NewError(JSONParsingError.ErrorCode, fmt.Sprintf("%v: %v", JSONParsingError.ErrorMessage, err), http.StatusBadRequest)
+}
+
+func (j *selectJSONReader) Read() (*selectObject, error) {
+	for {
+		if j.inArray {
+			if j.d.More() {
+				break
+			}
+			// consume the end of the array
+			if _, err := j.d.Token(); err != nil {
+				return nil, j.parsingError(err)
+			}
+			j.inArray = false
+		}
+		if !j.d.More() {
+			// drain the trailing whitespace and detect the garbage after the last value
+			if _, err := j.d.Token(); err != io.EOF {
+				return nil, j.parsingError(fmt.Errorf("invalid character after top-level value"))
+			}
+			return nil, io.EOF
+		}
+		if !j.arrayIter {
+			break
+		}
+		tok, err := j.d.Token()
+		if err != nil {
+			return nil, j.parsingError(err)
+		}
+		if delim, ok := tok.(json.Delim); ok && delim == '[' {
+			j.inArray = true
+			continue
+		}
+		v, err := j.decodeToken(tok)
+		if err != nil {
+			return nil, j.parsingError(err)
+		}
+		return j.record(v), nil
+	}
+
+	tok, err := j.d.Token()
+	if err != nil {
+		return nil, j.parsingError(err)
+	}
+	v, err := j.decodeToken(tok)
+	if err != nil {
+		return nil, j.parsingError(err)
+	}
+	return j.record(v), nil
+}
+
+// record wraps the value which is not an object as the record with a single field.
+func (j *selectJSONReader) record(v interface{}) *selectObject {
+	if o, ok := v.(*selectObject); ok {
+		return o
+	}
+	return newSelectObject([]string{"_1"}, []interface{}{v})
+}
+
+func (j *selectJSONReader) decodeToken(tok json.Token) (interface{}, error) {
+	switch x := tok.(type) {
+	case json.Delim:
+		switch x {
+		case '{':
+			o := newSelectObject(nil, nil)
+			for j.d.More() {
+				keyTok, err := j.d.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid object key %v", keyTok)
+				}
+				val, err := j.decodeValue()
+				if err != nil {
+					return nil, err
+				}
+				o.keys = append(o.keys, key)
+				o.vals = append(o.vals, val)
+			}
+			if _, err := j.d.Token(); err != nil {
+				return nil, err
+			}
+			return o, nil
+		case '[':
+			arr := make([]interface{}, 0)
+			for j.d.More() {
+				val, err := j.decodeValue()
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			}
+			if _, err := j.d.Token(); err != nil {
+				return nil, err
+			}
+			return arr, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", x)
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i, nil
+		}
+		return x.Float64()
+	case string, bool, nil:
+		return x, nil
+	}
+	return nil, fmt.Errorf("unexpected token %v", tok)
+}
+
+func (j *selectJSONReader) decodeValue() (interface{}, error) {
+	tok, err := j.d.Token()
+	if err != nil {
+		return nil, err
+	}
+	return j.decodeToken(tok)
+}
+
+type selectRecordWriter interface {
+	// Write appends the record with the names and the values of the fields to the buffer.
+	Write(buf *bytes.Buffer, names []string, vals []interface{})
+}
+
+type selectCSVWriter struct {
+	delimiter       string
+	quote           string
+	escape          string
+	recordDelimiter string
+	quoteAlways     bool
+}
+
+func newSelectCSVWriter(opt *SelectCSVOutput) *selectCSVWriter {
+	w := &selectCSVWriter{
+		delimiter:       opt.FieldDelimiter,
+		quote:           opt.QuoteCharacter,
+		escape:          opt.QuoteEscapeCharacter,
+		recordDelimiter: opt.RecordDelimiter,
+		quoteAlways:     strings.ToUpper(opt.QuoteFields) == SelectQuoteFieldsAlways,
+	}
+	if w.delimiter == "" {
+		w.delimiter = ","
+	}
+	if w.quote == "" {
+		w.quote = `"`
+	}
+	if w.escape == "" {
+		w.escape = w.quote
+	}
+	if w.recordDelimiter == "" {
+		w.recordDelimiter = "\n"
+	}
+	return w
+}
+
+func (w *selectCSVWriter) Write(buf *bytes.Buffer, names []string, vals []interface{}) {
+	for i, v := range vals {
+		if i > 0 {
+			buf.WriteString(w.delimiter)
+		}
+		field := sqlToString(v)
+		if !w.quoteAlways && !strings.Contains(field, w.delimiter) && !strings.Contains(field, w.quote) &&
+			!strings.ContainsAny(field, "\r\n") && !strings.Contains(field, w.recordDelimiter) {
+			buf.WriteString(field)
+			continue
+		}
+		buf.WriteString(w.quote)
+		buf.WriteString(strings.ReplaceAll(field, w.quote, w.escape+w.quote))
+		buf.WriteString(w.quote)
+	}
+	buf.WriteString(w.recordDelimiter)
+}
+
+type selectJSONRecordWriter struct {
+	recordDelimiter string
+}
+
+func newSelectJSONRecordWriter(opt *SelectJSONOutput) *selectJSONRecordWriter {
+	w := &selectJSONRecordWriter{recordDelimiter: opt.RecordDelimiter}
+	if w.recordDelimiter == "" {
+		w.recordDelimiter = "\n"
+	}
+	return w
+}
+
+func (w *selectJSONRecordWriter) Write(buf *bytes.Buffer, names []string, vals []interface{}) {
+	writeSelectJSONObject(buf, names, vals)
+	buf.WriteString(w.recordDelimiter)
+}
+
+// selectEventWriter writes the messages of the response in the event stream encoding.
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTSelectObjectAppendix.html
+type selectEventWriter struct {
+	w   io.Writer
+	enc *eventstream.Encoder
+}
+
+func newSelectEventWriter(w io.Writer) *selectEventWriter {
+	return &selectEventWriter{w: w, enc: eventstream.NewEncoder(w)}
+}
+
+func (e *selectEventWriter) send(eventType, contentType string, payload []byte) error {
+	msg := eventstream.Message{Payload: payload}
+	msg.Headers.Set(":message-type", eventstream.StringValue("event"))
+	msg.Headers.Set(":event-type", eventstream.StringValue(eventType))
+	if contentType != "" {
+		msg.Headers.Set(":content-type", eventstream.StringValue(contentType))
+	}
+	if err := e.enc.Encode(msg); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (e *selectEventWriter) sendRecords(payload []byte) error {
+	return e.send("Records", ValueContentTypeStream, payload)
+}
+
+func (e *selectEventWriter) sendStats(eventType string, stats *SelectStats) error {
+	payload, err := xml.Marshal(struct {
+		XMLName xml.Name
+		*SelectStats
+	}{XMLName: xml.Name{Local: eventType}, SelectStats: stats})
+	if err != nil {
+		return err
+	}
+	return e.send(eventType, ValueContentTypeXML, payload)
+}
+
+func (e *selectEventWriter) sendError(errCode *ErrorCode) error {
+	msg := eventstream.Message{}
+	msg.Headers.Set(":message-type", eventstream.StringValue("error"))
+	msg.Headers.Set(":error-code", eventstream.StringValue(errCode.ErrorCode))
+	msg.Headers.Set(":error-message", eventstream.StringValue(errCode.ErrorMessage))
+	if err := e.enc.Encode(msg); err != nil {
+		return err
+	}
+	if f, ok := e.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// selectJob evaluates the query over the records of an object, and streams the result.
+type selectJob struct {
+	req       *SelectObjectContentRequest
+	query     *selectQuery
+	scanned   *countingReader
+	processed *countingReader
+	reader    selectRecordReader
+	writer    selectRecordWriter
+	closer    io.Closer
+}
+
+func newSelectJob(req *SelectObjectContentRequest) (*selectJob, *ErrorCode) {
+	query, errCode := parseSelectSQL(req.Expression)
+	if errCode != nil {
+		return nil, errCode
+	}
+	if query.arrayIter && req.InputSerialization.CSV != nil {
+		return nil, newSelectError("ParseUnsupportedSyntax", "S3Object[*] is only supported for JSON objects")
+	}
+	job := &selectJob{req: req, query: query}
+	if out := req.OutputSerialization.CSV; out != nil {
+		job.writer = newSelectCSVWriter(out)
+	} else {
+		job.writer = newSelectJSONRecordWriter(req.OutputSerialization.JSON)
+	}
+	return job, nil
+}
+
+// open prepares to read the records from the object data, the compressed data is checked
+// here, so the error could be responded before the result is streamed.
+func (j *selectJob) open(data io.Reader) *ErrorCode {
+	j.scanned = &countingReader{r: data}
+	var r io.Reader = j.scanned
+	switch strings.ToUpper(j.req.InputSerialization.CompressionType) {
+	case SelectCompressionGzip:
+		gr, err := gzip.NewReader(r)
+		if err == gzip.ErrHeader || err == io.EOF || err == io.ErrUnexpectedEOF {
+			return InvalidCompressionFormat
+		}
+		if err != nil {
+			return InternalErrorCode(err)
+		}
+		j.closer = gr
+		r = gr
+	case SelectCompressionBzip2:
+		r = bzip2.NewReader(r)
+	}
+	j.processed = &countingReader{r: r}
+	if in := j.req.InputSerialization.CSV; in != nil {
+		j.reader = newSelectCSVReader(j.processed, in)
+	} else {
+		j.reader = newSelectJSONReader(j.processed, j.query.arrayIter)
+	}
+	return nil
+}
+
+func (j *selectJob) close() {
+	if j.closer != nil {
+		j.closer.Close()
+	}
+}
+
+func (j *selectJob) stats(returned int64) *SelectStats {
+	return &SelectStats{
+		BytesScanned:   j.scanned.n,
+		BytesProcessed: j.processed.n,
+		BytesReturned:  returned,
+	}
+}
+
+// project appends the selected fields of the record to the buffer.
+func (j *selectJob) project(buf *bytes.Buffer, rec *selectObject) error {
+	if j.query.star {
+		j.writer.Write(buf, rec.keys, rec.vals)
+		return nil
+	}
+	names := make([]string, len(j.query.items))
+	vals := make([]interface{}, len(j.query.items))
+	for i, item := range j.query.items {
+		v, err := item.expr.eval(rec)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+		names[i] = item.name
+		if names[i] == "" {
+			if ref, ok := item.expr.(*sqlRef); ok {
+				names[i] = ref.name()
+			}
+		}
+		if names[i] == "" {
+			names[i] = "_" + strconv.Itoa(i+1)
+		}
+	}
+	j.writer.Write(buf, names, vals)
+	return nil
+}
+
+// run streams the result to the writer, the error happened after the result is started is
+// sent as an error message, and returned for the caller to log it.
+func (j *selectJob) run(w io.Writer) (err error) {
+	events := newSelectEventWriter(w)
+	defer func() {
+		if err == nil {
+			return
+		}
+		errCode, ok := err.(*ErrorCode)
+		if !ok {
+			errCode = InternalErrorCode(err)
+		}
+		events.sendError(errCode)
+	}()
+
+	var (
+		buf       bytes.Buffer
+		returned  int64
+		outputs   int64
+		inputs    int
+		lastEvent = time.Now()
+		progress  = j.req.RequestProgress != nil && j.req.RequestProgress.Enabled
+	)
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		returned += int64(buf.Len())
+		if err := events.sendRecords(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+		lastEvent = time.Now()
+		return nil
+	}
+
+	for j.query.limit < 0 || outputs < j.query.limit || j.query.isAggregate() {
+		var rec *selectObject
+		if rec, err = j.reader.Read(); err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		inputs++
+		if inputs%selectCheckInterval == 0 && time.Since(lastEvent) > selectKeepAlivePeriod {
+			// keep the connection alive while the records are filtered out
+			if buf.Len() > 0 {
+				err = flush()
+			} else if progress {
+				err = events.sendStats("Progress", j.stats(returned))
+			} else {
+				err = events.send("Cont", "", nil)
+			}
+			if err != nil {
+				return
+			}
+			lastEvent = time.Now()
+		}
+
+		if j.query.where != nil {
+			var matched interface{}
+			if matched, err = j.query.where.eval(rec); err != nil {
+				return
+			}
+			if matched == nil {
+				continue
+			}
+			var ok bool
+			if ok, err = sqlToBool(matched); err != nil {
+				return
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if j.query.isAggregate() {
+			for _, agg := range j.query.aggs {
+				if err = agg.accumulate(rec); err != nil {
+					return
+				}
+			}
+			continue
+		}
+
+		size := buf.Len()
+		if err = j.project(&buf, rec); err != nil {
+			return
+		}
+		if buf.Len()-size > maxSelectRecordSize {
+			return OverMaxRecordSize
+		}
+		outputs++
+		if buf.Len() >= selectRecordsBatch {
+			if err = flush(); err != nil {
+				return
+			}
+		}
+	}
+
+	if j.query.isAggregate() && j.query.limit != 0 {
+		if err = j.project(&buf, nil); err != nil {
+			return
+		}
+	}
+	if err = flush(); err != nil {
+		return
+	}
+	if err = events.sendStats("Stats", j.stats(returned)); err != nil {
+		return
+	}
+	return events.send("End", "", nil)
+}
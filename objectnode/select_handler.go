@@ -0,0 +1,142 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/posixacl"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+func (o *ObjectNode) selectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("selectObjectContentHandler: load volume fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), param.Object(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxSelectRequestSize+1)); err != nil {
+		log.LogErrorf("selectObjectContentHandler: read request body fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if len(body) > MaxSelectRequestSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var req *SelectObjectContentRequest
+	if req, errorCode = ParseSelectObjectContentRequest(body); errorCode != nil {
+		log.LogErrorf("selectObjectContentHandler: parse request fail: requestID(%v) volume(%v) path(%v) body(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), string(body), errorCode)
+		return
+	}
+	var job *selectJob
+	if job, errorCode = newSelectJob(req); errorCode != nil {
+		log.LogErrorf("selectObjectContentHandler: parse expression fail: requestID(%v) volume(%v) path(%v) expression(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), req.Expression, errorCode)
+		return
+	}
+
+	fileInfo, xattr, err := vol.objectMetaWithVersion(param.Object(), "")
+	if err != nil {
+		log.LogErrorf("selectObjectContentHandler: get file meta fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		if err == syscall.ENOENT {
+			errorCode = NoSuchKey
+		}
+		return
+	}
+	var sseOpt *SSEOption
+	if sseOpt, errorCode = parseSSECustomerKey(r.Header, false); errorCode != nil {
+		return
+	}
+	var enc *objectEncryption
+	if enc, err = loadObjectEncryption(xattr.XAttrs, sseOpt); err != nil {
+		log.LogErrorf("selectObjectContentHandler: load object encryption fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), err)
+		return
+	}
+	if err = vol.checkPosixAcl(fileInfo.Inode, posixacl.PermRead); err != nil {
+		return
+	}
+	var size uint64
+	if !fileInfo.Mode.IsDir() {
+		if size, err = safeConvertInt64ToUint64(fileInfo.Size); err != nil {
+			return
+		}
+	}
+
+	// the object data is streamed into the job, reading is stopped once the pipe is closed
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		if size == 0 {
+			pw.Close()
+			return
+		}
+		var writer io.Writer = pw
+		if enc != nil {
+			writer = enc.DecryptWriter(writer, 0)
+		}
+		pw.CloseWithError(vol.readObject(fileInfo, size, param.Object(), writer, 0, size))
+	}()
+
+	if errorCode = job.open(pr); errorCode != nil {
+		log.LogErrorf("selectObjectContentHandler: open object fail: requestID(%v) volume(%v) path(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), errorCode)
+		return
+	}
+	defer job.close()
+
+	// the errors happened after the response is started are sent within the event stream
+	w.Header().Set(ContentType, ValueContentTypeStream)
+	w.WriteHeader(http.StatusOK)
+	start := time.Now()
+	if err := job.run(w); err != nil {
+		log.LogErrorf("selectObjectContentHandler: select fail: requestID(%v) volume(%v) path(%v) expression(%v) cost(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), req.Expression, time.Since(start), err)
+	}
+}
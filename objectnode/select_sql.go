@@ -0,0 +1,1423 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The SQL subset supported by S3 Select:
+//
+//	SELECT <* | expr [[AS] name], ...> FROM S3Object[[*]] [[AS] alias] [WHERE expr] [LIMIT n]
+//
+// The expressions support the comparison, logical, arithmetic, ||, LIKE, BETWEEN, IN and
+// IS NULL operators, CAST, some string functions, and the COUNT, SUM, AVG, MIN and MAX
+// aggregates. A field is referred by its name, its position like _1, or a path like s.a.b[0].
+// The values of the CSV records are strings, they are converted to numbers when compared
+// with or calculated with numbers.
+
+func newSelectError(code string, format string, args ...interface{}) *ErrorCode {
+	return NewError(code, fmt.Sprintf(format, args...), http.StatusBadRequest)
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenQuotedIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenOp
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+func (t sqlToken) String() string {
+	if t.kind == sqlTokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q at %d", t.text, t.pos)
+}
+
+func tokenizeSQL(sql string) (tokens []sqlToken, errCode *ErrorCode) {
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == c {
+					// the quote is escaped by doubling it
+					if j+1 < len(sql) && sql[j+1] == c {
+						sb.WriteByte(c)
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(sql[j])
+			}
+			if j >= len(sql) {
+				return nil, newSelectError("ParseUnexpectedToken", "unterminated quote at %d", i)
+			}
+			kind := sqlTokenString
+			if c == '"' {
+				kind = sqlTokenQuotedIdent
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sb.String(), pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i
+			for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.') {
+				j++
+			}
+			if j < len(sql) && (sql[j] == 'e' || sql[j] == 'E') {
+				k := j + 1
+				if k < len(sql) && (sql[k] == '+' || sql[k] == '-') {
+					k++
+				}
+				if k < len(sql) && sql[k] >= '0' && sql[k] <= '9' {
+					for j = k; j < len(sql) && sql[j] >= '0' && sql[j] <= '9'; j++ {
+					}
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenNumber, text: sql[i:j], pos: i})
+			i = j
+		case c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(sql) && (sql[j] == '_' || sql[j] < utf8.RuneSelf && (unicode.IsLetter(rune(sql[j])) || unicode.IsDigit(rune(sql[j])))) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenIdent, text: sql[i:j], pos: i})
+			i = j
+		default:
+			if i+1 < len(sql) {
+				switch op := sql[i : i+2]; op {
+				case "<=", ">=", "<>", "!=", "||":
+					tokens = append(tokens, sqlToken{kind: sqlTokenOp, text: op, pos: i})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>+-*/%(),.[];", rune(c)) {
+				return nil, newSelectError("ParseUnexpectedToken", "unexpected character %q at %d", c, i)
+			}
+			tokens = append(tokens, sqlToken{kind: sqlTokenOp, text: string(c), pos: i})
+			i++
+		}
+	}
+	return append(tokens, sqlToken{kind: sqlTokenEOF, pos: len(sql)}), nil
+}
+
+// the keywords which could not be used as names without quoting
+var sqlReservedWords = map[string]struct{}{
+	"SELECT": {}, "FROM": {}, "WHERE": {}, "LIMIT": {}, "AS": {}, "AND": {}, "OR": {}, "NOT": {},
+	"LIKE": {}, "ESCAPE": {}, "IS": {}, "NULL": {}, "MISSING": {}, "BETWEEN": {}, "IN": {},
+	"TRUE": {}, "FALSE": {}, "CAST": {}, "FOR": {},
+}
+
+var sqlAggregateFuncs = map[string]struct{}{
+	"COUNT": {}, "SUM": {}, "AVG": {}, "MIN": {}, "MAX": {},
+}
+
+var sqlScalarFuncs = map[string]struct{}{
+	"LOWER": {}, "UPPER": {}, "CHAR_LENGTH": {}, "CHARACTER_LENGTH": {}, "TRIM": {},
+	"SUBSTRING": {}, "COALESCE": {}, "NULLIF": {},
+}
+
+type selectItem struct {
+	expr sqlExpr
+	name string
+}
+
+// selectQuery is a parsed SQL statement of S3 Select.
+type selectQuery struct {
+	star      bool
+	items     []*selectItem
+	alias     string
+	arrayIter bool // FROM S3Object[*], the elements of the top level json arrays are the records
+	where     sqlExpr
+	limit     int64 // -1 if unlimited
+	aggs      []*sqlAggregate
+}
+
+func (q *selectQuery) isAggregate() bool {
+	return len(q.aggs) > 0
+}
+
+type sqlParser struct {
+	tokens    []sqlToken
+	pos       int
+	refs      []*sqlRef
+	aggs      []*sqlAggregate
+	inAgg     bool
+	bareRefer bool // a field is referred outside of the aggregates
+}
+
+func parseSelectSQL(sql string) (q *selectQuery, errCode *ErrorCode) {
+	tokens, errCode := tokenizeSQL(sql)
+	if errCode != nil {
+		return nil, errCode
+	}
+	p := &sqlParser{tokens: tokens}
+	return p.parseQuery()
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != sqlTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sqlParser) isKeyword(t sqlToken, keyword string) bool {
+	return t.kind == sqlTokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *sqlParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(p.peek(), keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(keyword string) *ErrorCode {
+	if !p.acceptKeyword(keyword) {
+		return newSelectError("ParseExpectedKeyword", "expect %v but found %v", keyword, p.peek())
+	}
+	return nil
+}
+
+func (p *sqlParser) acceptOp(op string) bool {
+	if t := p.peek(); t.kind == sqlTokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectOp(op string) *ErrorCode {
+	if !p.acceptOp(op) {
+		return newSelectError("ParseExpectedToken", "expect %q but found %v", op, p.peek())
+	}
+	return nil
+}
+
+func (p *sqlParser) unexpected() *ErrorCode {
+	return newSelectError("ParseUnexpectedToken", "unexpected %v", p.peek())
+}
+
+// acceptName accepts an unreserved identifier or a quoted identifier.
+func (p *sqlParser) acceptName() (name string, ok bool) {
+	t := p.peek()
+	switch t.kind {
+	case sqlTokenQuotedIdent:
+	case sqlTokenIdent:
+		if _, reserved := sqlReservedWords[strings.ToUpper(t.text)]; reserved {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	p.pos++
+	return t.text, true
+}
+
+func (p *sqlParser) parseQuery() (q *selectQuery, errCode *ErrorCode) {
+	q = &selectQuery{limit: -1}
+	if errCode = p.expectKeyword("SELECT"); errCode != nil {
+		return
+	}
+
+	if p.acceptOp("*") {
+		q.star = true
+	} else {
+		var aggItems, bareItems int
+		for {
+			p.bareRefer = false
+			aggs := len(p.aggs)
+			item := &selectItem{}
+			if item.expr, errCode = p.parseExpr(); errCode != nil {
+				return
+			}
+			if p.acceptKeyword("AS") {
+				var ok bool
+				if item.name, ok = p.acceptName(); !ok {
+					return nil, p.unexpected()
+				}
+			} else if name, ok := p.acceptName(); ok {
+				item.name = name
+			}
+			if len(p.aggs) > aggs {
+				aggItems++
+			}
+			if p.bareRefer {
+				bareItems++
+			}
+			q.items = append(q.items, item)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		if aggItems > 0 && bareItems > 0 {
+			return nil, newSelectError("ParseUnsupportedSyntax", "the fields must be referred within the aggregate functions")
+		}
+	}
+	q.aggs = p.aggs
+
+	if !p.acceptKeyword("FROM") {
+		return nil, newSelectError("ParseSelectMissingFrom", "expect FROM but found %v", p.peek())
+	}
+	if t := p.next(); !p.isKeyword(t, "S3Object") {
+		return nil, newSelectError("ParseUnsupportedSyntax", "only S3Object could be selected from, but found %v", t)
+	}
+	if p.acceptOp("[") {
+		if errCode = p.expectOp("*"); errCode != nil {
+			return
+		}
+		if errCode = p.expectOp("]"); errCode != nil {
+			return
+		}
+		q.arrayIter = true
+	}
+	if p.acceptKeyword("AS") {
+		var ok bool
+		if q.alias, ok = p.acceptName(); !ok {
+			return nil, p.unexpected()
+		}
+	} else if name, ok := p.acceptName(); ok {
+		q.alias = name
+	}
+
+	if p.acceptKeyword("WHERE") {
+		aggs := len(p.aggs)
+		if q.where, errCode = p.parseExpr(); errCode != nil {
+			return
+		}
+		if len(p.aggs) > aggs {
+			return nil, newSelectError("ParseUnsupportedSyntax", "aggregate functions are not allowed in WHERE clause")
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		if t.kind != sqlTokenNumber {
+			return nil, newSelectError("ParseUnexpectedToken", "expect the limit but found %v", t)
+		}
+		var err error
+		if q.limit, err = strconv.ParseInt(t.text, 10, 64); err != nil || q.limit < 0 {
+			return nil, newSelectError("ParseInvalidLimit", "invalid limit %v", t)
+		}
+	}
+	p.acceptOp(";")
+	if p.peek().kind != sqlTokenEOF {
+		return nil, p.unexpected()
+	}
+
+	// the leading alias of the paths refers to the record itself
+	for _, ref := range p.refs {
+		if len(ref.path) == 0 || ref.path[0].isIndex || ref.path[0].quoted {
+			continue
+		}
+		if first := ref.path[0].name; strings.EqualFold(first, "S3Object") || q.alias != "" && strings.EqualFold(first, q.alias) {
+			ref.path = ref.path[1:]
+		}
+	}
+	return q, nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, *ErrorCode) {
+	return p.parseOr()
+}
+
+func (p *sqlParser) parseOr() (sqlExpr, *ErrorCode) {
+	l, errCode := p.parseAnd()
+	if errCode != nil {
+		return nil, errCode
+	}
+	for p.acceptKeyword("OR") {
+		r, errCode := p.parseAnd()
+		if errCode != nil {
+			return nil, errCode
+		}
+		l = &sqlBinary{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, *ErrorCode) {
+	l, errCode := p.parseNot()
+	if errCode != nil {
+		return nil, errCode
+	}
+	for p.acceptKeyword("AND") {
+		r, errCode := p.parseNot()
+		if errCode != nil {
+			return nil, errCode
+		}
+		l = &sqlBinary{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, *ErrorCode) {
+	if p.acceptKeyword("NOT") {
+		x, errCode := p.parseNot()
+		if errCode != nil {
+			return nil, errCode
+		}
+		return &sqlUnary{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, *ErrorCode) {
+	l, errCode := p.parseAdditive()
+	if errCode != nil {
+		return nil, errCode
+	}
+
+	if t := p.peek(); t.kind == sqlTokenOp {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.pos++
+			r, errCode := p.parseAdditive()
+			if errCode != nil {
+				return nil, errCode
+			}
+			op := t.text
+			if op == "<>" {
+				op = "!="
+			}
+			return &sqlBinary{op: op, l: l, r: r}, nil
+		}
+		return l, nil
+	}
+
+	if p.acceptKeyword("IS") {
+		not := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") && !p.acceptKeyword("MISSING") {
+			return nil, p.unexpected()
+		}
+		return &sqlIsNull{x: l, not: not}, nil
+	}
+
+	not := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("LIKE"):
+		like := &sqlLike{x: l, not: not}
+		if like.pattern, errCode = p.parseAdditive(); errCode != nil {
+			return nil, errCode
+		}
+		if p.acceptKeyword("ESCAPE") {
+			if like.escape, errCode = p.parseAdditive(); errCode != nil {
+				return nil, errCode
+			}
+		}
+		return like, nil
+	case p.acceptKeyword("BETWEEN"):
+		between := &sqlBetween{x: l, not: not}
+		if between.lo, errCode = p.parseAdditive(); errCode != nil {
+			return nil, errCode
+		}
+		if errCode = p.expectKeyword("AND"); errCode != nil {
+			return nil, errCode
+		}
+		if between.hi, errCode = p.parseAdditive(); errCode != nil {
+			return nil, errCode
+		}
+		return between, nil
+	case p.acceptKeyword("IN"):
+		in := &sqlIn{x: l, not: not}
+		if errCode = p.expectOp("("); errCode != nil {
+			return nil, errCode
+		}
+		for {
+			x, errCode := p.parseExpr()
+			if errCode != nil {
+				return nil, errCode
+			}
+			in.list = append(in.list, x)
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		if errCode = p.expectOp(")"); errCode != nil {
+			return nil, errCode
+		}
+		return in, nil
+	}
+	if not {
+		return nil, p.unexpected()
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, *ErrorCode) {
+	l, errCode := p.parseMultiplicative()
+	if errCode != nil {
+		return nil, errCode
+	}
+	for {
+		t := p.peek()
+		if t.kind != sqlTokenOp || t.text != "+" && t.text != "-" && t.text != "||" {
+			return l, nil
+		}
+		p.pos++
+		r, errCode := p.parseMultiplicative()
+		if errCode != nil {
+			return nil, errCode
+		}
+		l = &sqlBinary{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, *ErrorCode) {
+	l, errCode := p.parseUnary()
+	if errCode != nil {
+		return nil, errCode
+	}
+	for {
+		t := p.peek()
+		if t.kind != sqlTokenOp || t.text != "*" && t.text != "/" && t.text != "%" {
+			return l, nil
+		}
+		p.pos++
+		r, errCode := p.parseUnary()
+		if errCode != nil {
+			return nil, errCode
+		}
+		l = &sqlBinary{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, *ErrorCode) {
+	if p.acceptOp("-") {
+		x, errCode := p.parseUnary()
+		if errCode != nil {
+			return nil, errCode
+		}
+		return &sqlUnary{op: "-", x: x}, nil
+	}
+	if p.acceptOp("+") {
+		return p.parseUnary()
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, *ErrorCode) {
+	t := p.peek()
+	switch t.kind {
+	case sqlTokenNumber:
+		p.pos++
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &sqlLiteral{v: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, newSelectError("ParseInvalidNumber", "invalid number %v", t)
+		}
+		return &sqlLiteral{v: f}, nil
+	case sqlTokenString:
+		p.pos++
+		return &sqlLiteral{v: t.text}, nil
+	case sqlTokenOp:
+		if t.text == "(" {
+			p.pos++
+			x, errCode := p.parseExpr()
+			if errCode != nil {
+				return nil, errCode
+			}
+			if errCode = p.expectOp(")"); errCode != nil {
+				return nil, errCode
+			}
+			return x, nil
+		}
+		return nil, newSelectError("ParseExpectedExpression", "expect an expression but found %v", t)
+	case sqlTokenQuotedIdent:
+		return p.parseRef()
+	case sqlTokenIdent:
+		keyword := strings.ToUpper(t.text)
+		switch keyword {
+		case "NULL", "MISSING":
+			p.pos++
+			return &sqlLiteral{v: nil}, nil
+		case "TRUE", "FALSE":
+			p.pos++
+			return &sqlLiteral{v: keyword == "TRUE"}, nil
+		case "CAST":
+			p.pos++
+			return p.parseCast()
+		}
+		if next := p.tokens[p.pos+1]; next.kind == sqlTokenOp && next.text == "(" {
+			p.pos += 2
+			return p.parseFunc(keyword)
+		}
+		if _, reserved := sqlReservedWords[keyword]; reserved {
+			return nil, newSelectError("ParseExpectedExpression", "expect an expression but found %v", t)
+		}
+		return p.parseRef()
+	}
+	return nil, newSelectError("ParseExpectedExpression", "expect an expression but found %v", t)
+}
+
+func (p *sqlParser) parseRef() (sqlExpr, *ErrorCode) {
+	ref := &sqlRef{}
+	t := p.next()
+	ref.path = append(ref.path, sqlPathElem{name: t.text, quoted: t.kind == sqlTokenQuotedIdent})
+	for {
+		if p.acceptOp(".") {
+			t = p.next()
+			if t.kind != sqlTokenIdent && t.kind != sqlTokenQuotedIdent {
+				return nil, newSelectError("ParseUnexpectedToken", "expect a field name but found %v", t)
+			}
+			ref.path = append(ref.path, sqlPathElem{name: t.text, quoted: t.kind == sqlTokenQuotedIdent})
+			continue
+		}
+		if p.acceptOp("[") {
+			t = p.next()
+			var elem sqlPathElem
+			switch t.kind {
+			case sqlTokenNumber:
+				idx, err := strconv.Atoi(t.text)
+				if err != nil || idx < 0 {
+					return nil, newSelectError("ParseInvalidPathComponent", "invalid index %v", t)
+				}
+				elem = sqlPathElem{index: idx, isIndex: true}
+			case sqlTokenString:
+				elem = sqlPathElem{name: t.text, quoted: true}
+			default:
+				return nil, newSelectError("ParseInvalidPathComponent", "invalid path component %v", t)
+			}
+			if errCode := p.expectOp("]"); errCode != nil {
+				return nil, errCode
+			}
+			ref.path = append(ref.path, elem)
+			continue
+		}
+		break
+	}
+	if !p.inAgg {
+		p.bareRefer = true
+	}
+	p.refs = append(p.refs, ref)
+	return ref, nil
+}
+
+func (p *sqlParser) parseCast() (sqlExpr, *ErrorCode) {
+	if errCode := p.expectOp("("); errCode != nil {
+		return nil, errCode
+	}
+	x, errCode := p.parseExpr()
+	if errCode != nil {
+		return nil, errCode
+	}
+	if errCode = p.expectKeyword("AS"); errCode != nil {
+		return nil, errCode
+	}
+	t := p.next()
+	if t.kind != sqlTokenIdent {
+		return nil, newSelectError("ParseInvalidTypeParam", "expect a type but found %v", t)
+	}
+	cast := &sqlCast{x: x}
+	switch strings.ToUpper(t.text) {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT":
+		cast.typ = sqlTypeInt
+	case "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
+		cast.typ = sqlTypeFloat
+		// DECIMAL(p, s)
+		if p.acceptOp("(") {
+			for !p.acceptOp(")") {
+				if p.next().kind == sqlTokenEOF {
+					return nil, p.unexpected()
+				}
+			}
+		}
+	case "STRING", "VARCHAR", "CHAR", "TEXT":
+		cast.typ = sqlTypeString
+	case "BOOL", "BOOLEAN":
+		cast.typ = sqlTypeBool
+	default:
+		return nil, newSelectError("ParseInvalidTypeParam", "unsupported type %v", t)
+	}
+	if errCode = p.expectOp(")"); errCode != nil {
+		return nil, errCode
+	}
+	return cast, nil
+}
+
+// parseFunc parses the arguments of the function, the left parenthesis is consumed already.
+func (p *sqlParser) parseFunc(name string) (sqlExpr, *ErrorCode) {
+	if _, ok := sqlAggregateFuncs[name]; ok {
+		if p.inAgg {
+			return nil, newSelectError("ParseUnsupportedSyntax", "aggregate functions could not be nested")
+		}
+		agg := &sqlAggregate{fn: name}
+		if name == "COUNT" && p.acceptOp("*") {
+			agg.star = true
+		} else {
+			p.inAgg = true
+			x, errCode := p.parseExpr()
+			p.inAgg = false
+			if errCode != nil {
+				return nil, errCode
+			}
+			agg.arg = x
+		}
+		if errCode := p.expectOp(")"); errCode != nil {
+			return nil, errCode
+		}
+		p.aggs = append(p.aggs, agg)
+		return agg, nil
+	}
+
+	if _, ok := sqlScalarFuncs[name]; !ok {
+		return nil, newSelectError("UnsupportedFunction", "unsupported function %v", name)
+	}
+	fn := &sqlFunc{name: name}
+	if !p.acceptOp(")") {
+		for {
+			x, errCode := p.parseExpr()
+			if errCode != nil {
+				return nil, errCode
+			}
+			fn.args = append(fn.args, x)
+			// SUBSTRING(s FROM start [FOR length])
+			if name == "SUBSTRING" && len(fn.args) == 1 && p.acceptKeyword("FROM") {
+				if x, errCode = p.parseExpr(); errCode != nil {
+					return nil, errCode
+				}
+				fn.args = append(fn.args, x)
+				if p.acceptKeyword("FOR") {
+					if x, errCode = p.parseExpr(); errCode != nil {
+						return nil, errCode
+					}
+					fn.args = append(fn.args, x)
+				}
+				break
+			}
+			if !p.acceptOp(",") {
+				break
+			}
+		}
+		if errCode := p.expectOp(")"); errCode != nil {
+			return nil, errCode
+		}
+	}
+
+	var min, max int
+	switch name {
+	case "LOWER", "UPPER", "CHAR_LENGTH", "CHARACTER_LENGTH", "TRIM":
+		min, max = 1, 1
+	case "SUBSTRING":
+		min, max = 2, 3
+	case "NULLIF":
+		min, max = 2, 2
+	case "COALESCE":
+		min, max = 1, math.MaxInt32
+	}
+	if len(fn.args) < min || len(fn.args) > max {
+		return nil, newSelectError("EvaluatorInvalidArguments", "invalid number of arguments of %v", name)
+	}
+	return fn, nil
+}
+
+// The expressions are evaluated on a record, the values are nil, bool, int64, float64, string,
+// *selectObject and []interface{}.
+type sqlExpr interface {
+	eval(rec *selectObject) (interface{}, error)
+}
+
+type sqlLiteral struct {
+	v interface{}
+}
+
+func (e *sqlLiteral) eval(rec *selectObject) (interface{}, error) {
+	return e.v, nil
+}
+
+type sqlPathElem struct {
+	name    string
+	index   int
+	isIndex bool
+	quoted  bool
+}
+
+type sqlRef struct {
+	path []sqlPathElem
+}
+
+func (e *sqlRef) eval(rec *selectObject) (interface{}, error) {
+	var v interface{} = rec
+	for _, elem := range e.path {
+		switch x := v.(type) {
+		case *selectObject:
+			if elem.isIndex {
+				return nil, nil
+			}
+			v = x.lookup(elem.name)
+		case []interface{}:
+			if !elem.isIndex || elem.index >= len(x) {
+				return nil, nil
+			}
+			v = x[elem.index]
+		default:
+			return nil, nil
+		}
+	}
+	if o, ok := v.(*selectObject); ok && o == nil {
+		return nil, nil
+	}
+	return v, nil
+}
+
+func (e *sqlRef) name() string {
+	if len(e.path) == 0 || e.path[len(e.path)-1].isIndex {
+		return ""
+	}
+	return e.path[len(e.path)-1].name
+}
+
+type sqlUnary struct {
+	op string
+	x  sqlExpr
+}
+
+func (e *sqlUnary) eval(rec *selectObject) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if e.op == "NOT" {
+		b, err := sqlToBool(v)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	}
+	n, err := sqlToNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := n.(int64); ok {
+		return -i, nil
+	}
+	return -n.(float64), nil
+}
+
+type sqlBinary struct {
+	op   string
+	l, r sqlExpr
+}
+
+func (e *sqlBinary) eval(rec *selectObject) (interface{}, error) {
+	l, err := e.l.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND", "OR":
+		return e.evalLogical(rec, l)
+	}
+	r, err := e.r.eval(rec)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		c, err := sqlCompare(l, r)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "||":
+		return sqlToString(l) + sqlToString(r), nil
+	}
+	return sqlArithmetic(e.op, l, r)
+}
+
+// evalLogical evaluates AND and OR with the three-valued logic of SQL.
+func (e *sqlBinary) evalLogical(rec *selectObject, l interface{}) (interface{}, error) {
+	short := e.op == "OR" // the result if any side is short
+	if l != nil {
+		b, err := sqlToBool(l)
+		if err != nil {
+			return nil, err
+		}
+		if b == short {
+			return short, nil
+		}
+	}
+	r, err := e.r.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		b, err := sqlToBool(r)
+		if err != nil {
+			return nil, err
+		}
+		if b == short {
+			return short, nil
+		}
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	return !short, nil
+}
+
+type sqlIsNull struct {
+	x   sqlExpr
+	not bool
+}
+
+func (e *sqlIsNull) eval(rec *selectObject) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != e.not, nil
+}
+
+type sqlLike struct {
+	x, pattern, escape sqlExpr
+	not                bool
+}
+
+func (e *sqlLike) eval(rec *selectObject) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	pattern, err := e.pattern.eval(rec)
+	if err != nil || pattern == nil {
+		return nil, err
+	}
+	var escape rune
+	if e.escape != nil {
+		esc, err := e.escape.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if s := sqlToString(esc); utf8.RuneCountInString(s) != 1 {
+			return nil, newSelectError("EvaluatorInvalidArguments", "invalid escape character %q", s)
+		} else {
+			escape, _ = utf8.DecodeRuneInString(s)
+		}
+	}
+	return sqlLikeMatch([]rune(sqlToString(v)), []rune(sqlToString(pattern)), escape) != e.not, nil
+}
+
+// sqlLikeMatch matches the string with the pattern, in which % matches any sequence of
+// characters and _ matches any single character.
+func sqlLikeMatch(s, pattern []rune, escape rune) bool {
+	var si, pi int
+	starP, starS := -1, 0
+	for si < len(s) {
+		if pi < len(pattern) {
+			c := pattern[pi]
+			switch {
+			case escape != 0 && c == escape && pi+1 < len(pattern):
+				if pattern[pi+1] == s[si] {
+					si++
+					pi += 2
+					continue
+				}
+			case c == '%':
+				starP, starS = pi, si
+				pi++
+				continue
+			case c == '_' || c == s[si]:
+				si++
+				pi++
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		// backtrack, let the last % match one more character
+		starS++
+		si, pi = starS, starP+1
+	}
+	for pi < len(pattern) && pattern[pi] == '%' {
+		pi++
+	}
+	return pi == len(pattern)
+}
+
+type sqlBetween struct {
+	x, lo, hi sqlExpr
+	not       bool
+}
+
+func (e *sqlBetween) eval(rec *selectObject) (interface{}, error) {
+	ge, err := (&sqlBinary{op: ">=", l: e.x, r: e.lo}).eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	le, err := (&sqlBinary{op: "<=", l: e.x, r: e.hi}).eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	if ge == nil || le == nil {
+		return nil, nil
+	}
+	return (ge.(bool) && le.(bool)) != e.not, nil
+}
+
+type sqlIn struct {
+	x    sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+func (e *sqlIn) eval(rec *selectObject) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	var hasNull bool
+	for _, x := range e.list {
+		item, err := x.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			hasNull = true
+			continue
+		}
+		c, err := sqlCompare(v, item)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			return !e.not, nil
+		}
+	}
+	if hasNull {
+		return nil, nil
+	}
+	return e.not, nil
+}
+
+const (
+	sqlTypeInt = iota
+	sqlTypeFloat
+	sqlTypeString
+	sqlTypeBool
+)
+
+type sqlCast struct {
+	x   sqlExpr
+	typ int
+}
+
+func (e *sqlCast) eval(rec *selectObject) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.typ {
+	case sqlTypeInt:
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		n, err := sqlToNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := n.(float64); ok {
+			return int64(f), nil
+		}
+		return n, nil
+	case sqlTypeFloat:
+		n, err := sqlToNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := n.(int64); ok {
+			return float64(i), nil
+		}
+		return n, nil
+	case sqlTypeString:
+		return sqlToString(v), nil
+	default:
+		return sqlToBool(v)
+	}
+}
+
+type sqlFunc struct {
+	name string
+	args []sqlExpr
+}
+
+func (e *sqlFunc) eval(rec *selectObject) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, x := range e.args {
+		v, err := x.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch e.name {
+	case "COALESCE":
+		for _, v := range args {
+			if v != nil {
+				return v, nil
+			}
+		}
+		return nil, nil
+	case "NULLIF":
+		if args[0] == nil || args[1] == nil {
+			return args[0], nil
+		}
+		if c, err := sqlCompare(args[0], args[1]); err == nil && c == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	}
+
+	if args[0] == nil {
+		return nil, nil
+	}
+	s := sqlToString(args[0])
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(s), nil
+	case "UPPER":
+		return strings.ToUpper(s), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(utf8.RuneCountInString(s)), nil
+	case "TRIM":
+		return strings.TrimSpace(s), nil
+	}
+
+	// SUBSTRING, the position starts from 1
+	runes := []rune(s)
+	start, err := sqlToInt(args[1])
+	if err != nil || args[1] == nil {
+		return nil, err
+	}
+	end := int64(len(runes)) + 1
+	if len(args) > 2 {
+		if args[2] == nil {
+			return nil, nil
+		}
+		length, err := sqlToInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, newSelectError("EvaluatorInvalidArguments", "negative substring length %v", length)
+		}
+		if start+length < end {
+			end = start + length
+		}
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start >= end {
+		return "", nil
+	}
+	return string(runes[start-1 : end-1]), nil
+}
+
+// sqlAggregate accumulates the values of the records, and returns the result after all
+// the records are accumulated.
+type sqlAggregate struct {
+	fn    string
+	arg   sqlExpr
+	star  bool
+	count int64
+	sumI  int64
+	sumF  float64
+	float bool
+	value interface{}
+}
+
+func (e *sqlAggregate) accumulate(rec *selectObject) error {
+	if e.star {
+		e.count++
+		return nil
+	}
+	v, err := e.arg.eval(rec)
+	if err != nil || v == nil {
+		return err
+	}
+	switch e.fn {
+	case "SUM", "AVG":
+		n, err := sqlToNumber(v)
+		if err != nil {
+			return err
+		}
+		switch x := n.(type) {
+		case int64:
+			e.sumI += x
+		case float64:
+			e.sumF += x
+			e.float = true
+		}
+	case "MIN", "MAX":
+		if e.value != nil {
+			c, err := sqlCompare(v, e.value)
+			if err != nil {
+				return err
+			}
+			if e.fn == "MIN" && c >= 0 || e.fn == "MAX" && c <= 0 {
+				break
+			}
+		}
+		if o, ok := v.(*selectObject); ok {
+			v = sqlToString(o)
+		}
+		e.value = v
+	}
+	e.count++
+	return nil
+}
+
+func (e *sqlAggregate) eval(rec *selectObject) (interface{}, error) {
+	switch e.fn {
+	case "COUNT":
+		return e.count, nil
+	case "SUM":
+		if e.count == 0 {
+			return nil, nil
+		}
+		if e.float {
+			return e.sumF + float64(e.sumI), nil
+		}
+		return e.sumI, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		return (e.sumF + float64(e.sumI)) / float64(e.count), nil
+	default:
+		return e.value, nil
+	}
+}
+
+func sqlToBool(v interface{}) (bool, error) {
+	switch x := v.(type) {
+	case bool:
+		return x, nil
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(x)); err == nil {
+			return b, nil
+		}
+	}
+	return false, newSelectError("CastFailed", "could not convert %v to bool", sqlToString(v))
+}
+
+// sqlToNumber converts the value to int64 or float64.
+func sqlToNumber(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case int64, float64:
+		return x, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, newSelectError("CastFailed", "could not convert %v to number", sqlToString(v))
+}
+
+func sqlToInt(v interface{}) (int64, error) {
+	n, err := sqlToNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if f, ok := n.(float64); ok {
+		return int64(f), nil
+	}
+	return n.(int64), nil
+}
+
+func sqlToString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	var sb strings.Builder
+	writeSelectJSONValue(&sb, v)
+	return sb.String()
+}
+
+func sqlIsNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// sqlCompare compares two non-null values. A string is converted to the type of the other
+// side if it is a number or bool, and the values of other types are compared as strings.
+func sqlCompare(a, b interface{}) (int, error) {
+	if sqlIsNumber(a) || sqlIsNumber(b) {
+		na, errA := sqlToNumber(a)
+		nb, errB := sqlToNumber(b)
+		if errA == nil && errB == nil {
+			ia, okA := na.(int64)
+			ib, okB := nb.(int64)
+			if okA && okB {
+				return compareInt64(ia, ib), nil
+			}
+			return compareFloat64(sqlToFloat(na), sqlToFloat(nb)), nil
+		}
+	}
+	_, boolA := a.(bool)
+	_, boolB := b.(bool)
+	if boolA || boolB {
+		ba, errA := sqlToBool(a)
+		bb, errB := sqlToBool(b)
+		if errA == nil && errB == nil {
+			switch {
+			case ba == bb:
+				return 0, nil
+			case !ba:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	}
+	return strings.Compare(sqlToString(a), sqlToString(b)), nil
+}
+
+func sqlToFloat(n interface{}) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sqlArithmetic(op string, l, r interface{}) (interface{}, error) {
+	nl, err := sqlToNumber(l)
+	if err != nil {
+		return nil, err
+	}
+	nr, err := sqlToNumber(r)
+	if err != nil {
+		return nil, err
+	}
+	il, okL := nl.(int64)
+	ir, okR := nr.(int64)
+	if okL && okR {
+		switch op {
+		case "+":
+			return il + ir, nil
+		case "-":
+			return il - ir, nil
+		case "*":
+			return il * ir, nil
+		}
+		if ir == 0 {
+			return nil, newSelectError("DivisionByZero", "division by zero")
+		}
+		if op == "/" {
+			return il / ir, nil
+		}
+		return il % ir, nil
+	}
+	fl, fr := sqlToFloat(nl), sqlToFloat(nr)
+	switch op {
+	case "+":
+		return fl + fr, nil
+	case "-":
+		return fl - fr, nil
+	case "*":
+		return fl * fr, nil
+	}
+	if fr == 0 {
+		return nil, newSelectError("DivisionByZero", "division by zero")
+	}
+	if op == "/" {
+		return fl / fr, nil
+	}
+	return math.Mod(fl, fr), nil
+}
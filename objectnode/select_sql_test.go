@@ -0,0 +1,143 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectSQL(t *testing.T) {
+	tests := []struct {
+		sql     string
+		errCode string
+	}{
+		{sql: "select * from S3Object"},
+		{sql: "SELECT s.name, s.age AS years FROM S3Object s WHERE s.age > 18 LIMIT 10;"},
+		{sql: "SELECT COUNT(*), AVG(CAST(age AS FLOAT)) FROM s3object WHERE city IN ('a', 'b')"},
+		{sql: `SELECT "first name", _2 FROM S3Object[*] AS r WHERE r.tags[0] LIKE 'x%'`},
+		{sql: "SELECT SUBSTRING(name FROM 2 FOR 3) FROM S3Object"},
+		{sql: "SELECT name", errCode: "ParseSelectMissingFrom"},
+		{sql: "SELECT name FROM table1", errCode: "ParseUnsupportedSyntax"},
+		{sql: "SELECT name, COUNT(*) FROM S3Object", errCode: "ParseUnsupportedSyntax"},
+		{sql: "SELECT SUM(COUNT(*)) FROM S3Object", errCode: "ParseUnsupportedSyntax"},
+		{sql: "SELECT * FROM S3Object WHERE COUNT(*) > 1", errCode: "ParseUnsupportedSyntax"},
+		{sql: "SELECT MD5(name) FROM S3Object", errCode: "UnsupportedFunction"},
+		{sql: "SELECT * FROM S3Object LIMIT x", errCode: "ParseUnexpectedToken"},
+		{sql: "SELECT * FROM S3Object WHERE name = 'abc", errCode: "ParseUnexpectedToken"},
+		{sql: "SELECT * FROM S3Object extra tokens", errCode: "ParseUnexpectedToken"},
+		{sql: "SELECT CAST(a AS DATE) FROM S3Object", errCode: "ParseInvalidTypeParam"},
+	}
+	for _, test := range tests {
+		_, errCode := parseSelectSQL(test.sql)
+		if test.errCode == "" {
+			require.Nil(t, errCode, test.sql)
+		} else {
+			require.NotNil(t, errCode, test.sql)
+			require.Equal(t, test.errCode, errCode.ErrorCode, test.sql)
+		}
+	}
+}
+
+func TestSelectSQLEval(t *testing.T) {
+	rec := newSelectObject(
+		[]string{"name", "age", "score", "city", "note", "info"},
+		[]interface{}{"Alice", "30", "88.5", "Beijing", nil, newSelectObject(
+			[]string{"tags", "level"},
+			[]interface{}{[]interface{}{"a", "b"}, int64(3)})},
+	)
+	tests := []struct {
+		where    string
+		expected interface{}
+	}{
+		{where: "age = 30", expected: true},
+		{where: "age > 100", expected: false},
+		{where: "Age >= '30'", expected: true},
+		{where: "_2 = 30", expected: true},
+		{where: "score * 2 = 177", expected: true},
+		{where: "age + 1", expected: int64(31)},
+		{where: "age / 4", expected: int64(7)},
+		{where: "age % 7", expected: int64(2)},
+		{where: "-age", expected: int64(-30)},
+		{where: "name || '-' || city", expected: "Alice-Beijing"},
+		{where: "name LIKE 'A%e'", expected: true},
+		{where: "name LIKE '_lic_'", expected: true},
+		{where: "name NOT LIKE '%x%'", expected: true},
+		{where: "'10%' LIKE '10!%' ESCAPE '!'", expected: true},
+		{where: "'100' LIKE '10!%' ESCAPE '!'", expected: false},
+		{where: "age BETWEEN 20 AND 30", expected: true},
+		{where: "age NOT BETWEEN 20 AND 30", expected: false},
+		{where: "city IN ('Shanghai', 'Beijing')", expected: true},
+		{where: "city NOT IN ('Shanghai')", expected: true},
+		{where: "note IS NULL", expected: true},
+		{where: "missing_field IS MISSING", expected: true},
+		{where: "name IS NOT NULL", expected: true},
+		{where: "note = 1", expected: nil},
+		{where: "note = 1 OR age = 30", expected: true},
+		{where: "note = 1 AND age = 30", expected: nil},
+		{where: "note = 1 AND age = 1", expected: false},
+		{where: "NOT (age = 30)", expected: false},
+		{where: "info.level = 3", expected: true},
+		{where: "s.info.tags[1]", expected: "b"},
+		{where: "info.tags[5]", expected: nil},
+		{where: "CAST(score AS INT)", expected: int64(88)},
+		{where: "CAST(age AS FLOAT)", expected: float64(30)},
+		{where: "CAST(1 = 1 AS STRING)", expected: "true"},
+		{where: "LOWER(name)", expected: "alice"},
+		{where: "UPPER(name)", expected: "ALICE"},
+		{where: "CHAR_LENGTH(city)", expected: int64(7)},
+		{where: "TRIM('  x ')", expected: "x"},
+		{where: "SUBSTRING(name, 2, 3)", expected: "lic"},
+		{where: "SUBSTRING(name FROM 3)", expected: "ice"},
+		{where: "COALESCE(note, city)", expected: "Beijing"},
+		{where: "NULLIF(age, 30)", expected: nil},
+	}
+	for _, test := range tests {
+		q, errCode := parseSelectSQL("SELECT * FROM S3Object s WHERE " + test.where)
+		require.Nil(t, errCode, test.where)
+		v, err := q.where.eval(rec)
+		require.NoError(t, err, test.where)
+		require.Equal(t, test.expected, v, test.where)
+	}
+
+	q, errCode := parseSelectSQL("SELECT * FROM S3Object WHERE CAST(name AS INT) = 1")
+	require.Nil(t, errCode)
+	_, err := q.where.eval(rec)
+	require.Equal(t, "CastFailed", err.(*ErrorCode).ErrorCode)
+
+	q, errCode = parseSelectSQL("SELECT * FROM S3Object WHERE age / 0 = 1")
+	require.Nil(t, errCode)
+	_, err = q.where.eval(rec)
+	require.Equal(t, "DivisionByZero", err.(*ErrorCode).ErrorCode)
+}
+
+func TestSelectSQLAggregate(t *testing.T) {
+	q, errCode := parseSelectSQL("SELECT COUNT(*), COUNT(v), SUM(v), AVG(v), MIN(v), MAX(v) FROM S3Object")
+	require.Nil(t, errCode)
+	require.True(t, q.isAggregate())
+	for _, v := range []interface{}{"3", "1", nil, "5"} {
+		rec := newSelectObject([]string{"v"}, []interface{}{v})
+		for _, agg := range q.aggs {
+			require.NoError(t, agg.accumulate(rec))
+		}
+	}
+	expected := []interface{}{int64(4), int64(3), int64(9), float64(3), "1", "5"}
+	for i, item := range q.items {
+		v, err := item.expr.eval(nil)
+		require.NoError(t, err)
+		require.Equal(t, expected[i], v)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/private/protocol/eventstream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectObjectContentRequest(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><CompressionType>GZIP</CompressionType><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>
+						<OutputSerialization><JSON/></OutputSerialization></SelectObjectContentRequest>`,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><JSON><Type>LINES</Type></JSON></InputSerialization>
+						<OutputSerialization><CSV><QuoteFields>ASNEEDED</QuoteFields></CSV></OutputSerialization></SelectObjectContentRequest>`,
+		},
+		{
+			value:       `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>XPATH</ExpressionType>
+						<InputSerialization><CSV/></InputSerialization><OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: InvalidExpressionType,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><CompressionType>ZSTD</CompressionType><CSV/></InputSerialization>
+						<OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: InvalidCompressionFormat,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><CSV/><JSON/></InputSerialization><OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: ObjectSerializationConflict,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><CSV/></InputSerialization><OutputSerialization></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: ObjectSerializationConflict,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><CSV><FileHeaderInfo>FIRST</FileHeaderInfo></CSV></InputSerialization>
+						<OutputSerialization><CSV/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: InvalidFileHeaderInfo,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><JSON><Type>ARRAY</Type></JSON></InputSerialization>
+						<OutputSerialization><JSON/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: InvalidJsonType,
+		},
+		{
+			value: `<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression><ExpressionType>SQL</ExpressionType>
+						<InputSerialization><Parquet/></InputSerialization>
+						<OutputSerialization><JSON/></OutputSerialization></SelectObjectContentRequest>`,
+			expectedErr: UnsupportedOperation,
+		},
+	}
+	for i, test := range tests {
+		_, errCode := ParseSelectObjectContentRequest([]byte(test.value))
+		require.Equal(t, test.expectedErr, errCode, "case %d", i)
+	}
+}
+
+type selectTestResult struct {
+	records string
+	stats   *SelectStats
+	errCode string
+	end     bool
+}
+
+func runSelectTest(t *testing.T, req *SelectObjectContentRequest, data []byte) *selectTestResult {
+	job, errCode := newSelectJob(req)
+	require.Nil(t, errCode)
+	require.Nil(t, job.open(bytes.NewReader(data)))
+	defer job.close()
+
+	out := new(bytes.Buffer)
+	job.run(out)
+
+	result := &selectTestResult{}
+	decoder := eventstream.NewDecoder(out)
+	for {
+		msg, err := decoder.Decode(nil)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.False(t, result.end)
+		if msg.Headers.Get(":message-type").String() == "error" {
+			result.errCode = msg.Headers.Get(":error-code").String()
+			break
+		}
+		switch msg.Headers.Get(":event-type").String() {
+		case "Records":
+			result.records += string(msg.Payload)
+		case "Stats":
+			result.stats = &SelectStats{}
+			require.NoError(t, xml.Unmarshal(msg.Payload, result.stats))
+		case "End":
+			result.end = true
+		}
+	}
+	return result
+}
+
+func newSelectTestRequest(sql string, in SelectInputSerialization, out SelectOutputSerialization) *SelectObjectContentRequest {
+	return &SelectObjectContentRequest{
+		Expression:          sql,
+		ExpressionType:      SelectExpressionTypeSQL,
+		InputSerialization:  in,
+		OutputSerialization: out,
+	}
+}
+
+func TestSelectObjectContentCSV(t *testing.T) {
+	data := "name,age,city\n" +
+		"Alice,30,Beijing\n" +
+		"Bob,17,\"Shang,hai\"\n" +
+		"# comment line\n" +
+		"Carol,45,Shenzhen\n"
+	in := SelectInputSerialization{CSV: &SelectCSVInput{FileHeaderInfo: SelectFileHeaderUse, Comments: "#"}}
+	csvOut := SelectOutputSerialization{CSV: &SelectCSVOutput{}}
+	jsonOut := SelectOutputSerialization{JSON: &SelectJSONOutput{}}
+
+	tests := []struct {
+		sql      string
+		out      SelectOutputSerialization
+		expected string
+	}{
+		{sql: "SELECT * FROM S3Object", out: csvOut, expected: "Alice,30,Beijing\nBob,17,\"Shang,hai\"\nCarol,45,Shenzhen\n"},
+		{sql: "SELECT name FROM S3Object s WHERE CAST(s.age AS INT) >= 18", out: csvOut, expected: "Alice\nCarol\n"},
+		{sql: "SELECT s.name, age FROM S3Object s WHERE city LIKE 'Sh%'", out: jsonOut, expected: "{\"name\":\"Bob\",\"age\":\"17\"}\n{\"name\":\"Carol\",\"age\":\"45\"}\n"},
+		{sql: "SELECT UPPER(name) AS n FROM S3Object LIMIT 1", out: jsonOut, expected: "{\"n\":\"ALICE\"}\n"},
+		{sql: "SELECT COUNT(*), MAX(CAST(age AS INT)) AS oldest FROM S3Object WHERE age > 20", out: jsonOut, expected: "{\"_1\":2,\"oldest\":45}\n"},
+		{sql: "SELECT * FROM S3Object WHERE name = 'nobody'", out: csvOut, expected: ""},
+	}
+	for _, test := range tests {
+		result := runSelectTest(t, newSelectTestRequest(test.sql, in, test.out), []byte(data))
+		require.Empty(t, result.errCode, test.sql)
+		require.True(t, result.end, test.sql)
+		require.Equal(t, test.expected, result.records, test.sql)
+		require.Equal(t, int64(len(data)), result.stats.BytesScanned, test.sql)
+		require.Equal(t, int64(len(test.expected)), result.stats.BytesReturned, test.sql)
+	}
+
+	// positional fields without header, quoted always
+	in = SelectInputSerialization{CSV: &SelectCSVInput{FieldDelimiter: "|"}}
+	out := SelectOutputSerialization{CSV: &SelectCSVOutput{QuoteFields: SelectQuoteFieldsAlways, FieldDelimiter: ";"}}
+	result := runSelectTest(t, newSelectTestRequest("SELECT _2, _1 FROM S3Object WHERE _3 IS NULL", in, out), []byte("a|b\nc|d|e\n"))
+	require.True(t, result.end)
+	require.Equal(t, "\"b\";\"a\"\n", result.records)
+
+	// the evaluation error is sent within the event stream
+	in = SelectInputSerialization{CSV: &SelectCSVInput{FileHeaderInfo: SelectFileHeaderUse}}
+	result = runSelectTest(t, newSelectTestRequest("SELECT * FROM S3Object WHERE CAST(name AS INT) = 1", in, csvOut), []byte(data))
+	require.Equal(t, "CastFailed", result.errCode)
+	require.False(t, result.end)
+}
+
+func TestSelectObjectContentJSON(t *testing.T) {
+	lines := `{"id":1,"user":{"name":"a","tags":["x","y"]},"size":10}
+{"id":2,"user":{"name":"b","tags":[]},"size":2.5}
+{"id":3,"user":null,"size":7}
+`
+	in := SelectInputSerialization{JSON: &SelectJSONInput{Type: SelectJsonTypeLines}}
+	jsonOut := SelectOutputSerialization{JSON: &SelectJSONOutput{}}
+	csvOut := SelectOutputSerialization{CSV: &SelectCSVOutput{}}
+
+	tests := []struct {
+		sql      string
+		out      SelectOutputSerialization
+		expected string
+	}{
+		{sql: "SELECT * FROM S3Object s WHERE s.id = 1", out: jsonOut, expected: `{"id":1,"user":{"name":"a","tags":["x","y"]},"size":10}` + "\n"},
+		{sql: "SELECT s.user.name, s.user.tags[1] AS tag FROM S3Object s WHERE s.size > 5", out: jsonOut, expected: "{\"name\":\"a\",\"tag\":\"y\"}\n{\"name\":null,\"tag\":null}\n"},
+		{sql: "SELECT id, user FROM S3Object WHERE id = 1", out: csvOut, expected: "1,\"{\"\"name\"\":\"\"a\"\",\"\"tags\"\":[\"\"x\"\",\"\"y\"\"]}\"\n"},
+		{sql: "SELECT SUM(size), AVG(id), COUNT(user) FROM S3Object", out: csvOut, expected: "19.5,2,2\n"},
+	}
+	for _, test := range tests {
+		result := runSelectTest(t, newSelectTestRequest(test.sql, in, test.out), []byte(lines))
+		require.Empty(t, result.errCode, test.sql)
+		require.True(t, result.end, test.sql)
+		require.Equal(t, test.expected, result.records, test.sql)
+	}
+
+	// the elements of the top level array are the records
+	in = SelectInputSerialization{JSON: &SelectJSONInput{Type: SelectJsonTypeDocument}}
+	doc := `[{"k":"a","v":1},{"k":"b","v":2}, {"k":"c","v":3}]`
+	result := runSelectTest(t, newSelectTestRequest("SELECT k FROM S3Object[*] WHERE v >= 2", in, csvOut), []byte(doc))
+	require.True(t, result.end)
+	require.Equal(t, "b\nc\n", result.records)
+
+	result = runSelectTest(t, newSelectTestRequest("SELECT * FROM S3Object", in, csvOut), []byte(`{"a":1} {"a":`))
+	require.Equal(t, JSONParsingError.ErrorCode, result.errCode)
+}
+
+func TestSelectObjectContentGzip(t *testing.T) {
+	var data bytes.Buffer
+	for i := 0; i < 5000; i++ {
+		data.WriteString("row,")
+		data.WriteString(strings.Repeat("x", i%7))
+		data.WriteString("\n")
+	}
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, err := gw.Write(data.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	in := SelectInputSerialization{CompressionType: SelectCompressionGzip, CSV: &SelectCSVInput{}}
+	out := SelectOutputSerialization{CSV: &SelectCSVOutput{}}
+	req := newSelectTestRequest("SELECT COUNT(*) FROM S3Object WHERE CHAR_LENGTH(_2) = 6", in, out)
+	result := runSelectTest(t, req, compressed.Bytes())
+	require.True(t, result.end)
+	require.Equal(t, "714\n", result.records)
+	require.Equal(t, int64(compressed.Len()), result.stats.BytesScanned)
+	require.Equal(t, int64(data.Len()), result.stats.BytesProcessed)
+
+	job, errCode := newSelectJob(req)
+	require.Nil(t, errCode)
+	require.Equal(t, InvalidCompressionFormat, job.open(bytes.NewReader(data.Bytes())))
+}
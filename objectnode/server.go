@@ -33,11 +33,13 @@ import (
 	"github.com/cubefs/cubefs/cmd/common"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/reloadconf"
+	"github.com/cubefs/cubefs/util/sse"
 
 	"github.com/gorilla/mux"
 )
@@ -128,6 +130,30 @@ const (
 	// 		}
 	configAuditLog = "auditLog"
 
+	// Map type configuration item, used to configure the targets which the event notifications of
+	// buckets are published to. For detailed parameters, see the NotificationConfig structure.
+	// The targets are referred as "arn:cubefs:sqs::<id>:kafka" or "arn:cubefs:sqs::<id>:webhook"
+	// in the notification configurations of buckets.
+	// Example:
+	// 		{
+	// 			"notification": {
+	// 				"kafka": {
+	// 					"ingest": {
+	//						"enable": true,
+	// 						"topic": "bucket_event_topic",
+	// 						"brokers": "192.168.80.130:9095,192.168.80.131:9095,192.168.80.132:9095"
+	// 					}
+	// 				},
+	// 				"webhook": {
+	// 					"pipeline": {
+	//						"enable": true,
+	// 						"endpoint": "http://192.168.80.140:8080/events"
+	// 					}
+	// 				}
+	// 			}
+	// 		}
+	configNotification = "notification"
+
 	// ObjMetaCache takes each path hierarchy of the path-like S3 object key as the cache key,
 	// and map it to the corresponding posix-compatible inode
 	// when enabled, the maxDentryCacheNum must at least be the minimum of defaultMaxDentryCacheNum
@@ -154,6 +180,35 @@ const (
 
 	// s3 QoS config refresh interval
 	s3QoSRefreshIntervalSec = "s3QoSRefreshIntervalSec"
+
+	// Server side encryption with the master keys stored in a local key file, see sse.LocalKeyStoreConfig.
+	// If sseEnforce is enabled, every object is encrypted with SSE-S3 unless SSE-C is requested.
+	// Example:
+	//		{
+	//			"sseMasterKeyFile": "/cfs/conf/sse_master_keys.json",
+	//			"sseEnforce": true
+	//		}
+	configSSEMasterKeyFile = "sseMasterKeyFile"
+	configSSEEnforce       = "sseEnforce"
+
+	// Readahead of the sequential GETs of hot volumes, the memory is shared by all the volumes.
+	// Example:
+	//		{
+	//			"readAheadMemMB": 1024,
+	//			"readAheadWindowMB": 16
+	//		}
+	configReadAheadMemMB    = "readAheadMemMB"
+	configReadAheadWindowMB = "readAheadWindowMB"
+
+	// The uid and gid which the S3 requests are evaluated as against the POSIX ACLs of the
+	// volumes with posix ACL enabled, nobody(65534) by default.
+	// Example:
+	//		{
+	//			"posixAclUid": 1000,
+	//			"posixAclGid": 1000
+	//		}
+	configPosixAclUid = "posixAclUid"
+	configPosixAclGid = "posixAclGid"
 )
 
 // Default of configuration value
@@ -178,6 +233,9 @@ var (
 	writeThreads     = 4
 	readThreads      = 4
 	enableBlockcache bool
+	readAheadPool    *stream.ReadAheadPool
+	// volumeLoader resolves the cold volumes which keep the data of transitioned objects
+	volumeLoader func(name string) (*Volume, error)
 )
 
 type ObjectNode struct {
@@ -194,6 +252,7 @@ type ObjectNode struct {
 
 	localAuditHandler rpc.ProgressHandler
 	externalAudit     *ExternalAudit
+	notifier          *Notifier
 
 	closes []func() // close other resources after http server closed
 
@@ -286,6 +345,15 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 		log.LogInfof("loadConfig: setup config: %v(%v)", configAuditLog, rawAuditLog)
 	}
 
+	// parse notification config
+	if rawNotification := cfg.GetValue(configNotification); rawNotification != nil {
+		if err = o.setNotification(rawNotification); err != nil {
+			err = fmt.Errorf("invalid %v configuration: %v", configNotification, err)
+			return
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configNotification, rawNotification)
+	}
+
 	// parse strict config
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
@@ -293,6 +361,7 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 
 	o.mc = master.NewMasterClient(masters, false)
 	o.vm = NewVolumeManager(masters, strict)
+	volumeLoader = o.vm.Volume
 	o.userStore = NewUserInfoStore(masters, strict)
 
 	// parse inode cache
@@ -322,6 +391,26 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 		blockCache = bcache.NewBcacheClient()
 	}
 
+	readAheadPool = stream.NewReadAheadPool(cfg.GetInt64(configReadAheadMemMB), cfg.GetInt64(configReadAheadWindowMB))
+	log.LogInfof("loadConfig: readahead enabled(%v)", readAheadPool != nil)
+
+	// parse server side encryption config
+	if keyFile := cfg.GetString(configSSEMasterKeyFile); keyFile != "" {
+		if sseKeyStore, err = sse.LoadLocalKeyStore(keyFile); err != nil {
+			return fmt.Errorf("load sse master key file(%v) fail: %v", keyFile, err)
+		}
+		log.LogInfof("loadConfig: setup config: %v(%v)", configSSEMasterKeyFile, keyFile)
+	}
+	sseEnforced = cfg.GetBool(configSSEEnforce)
+	if sseEnforced && sseKeyStore == nil {
+		return fmt.Errorf("%v requires %v", configSSEEnforce, configSSEMasterKeyFile)
+	}
+	log.LogInfof("loadConfig: sseEnforce: %v", sseEnforced)
+
+	posixAclUid = uint32(cfg.GetInt64WithDefault(configPosixAclUid, defaultPosixAclId))
+	posixAclGid = uint32(cfg.GetInt64WithDefault(configPosixAclGid, defaultPosixAclId))
+	log.LogInfof("loadConfig: posix acl uid(%v) gid(%v)", posixAclUid, posixAclGid)
+
 	return
 }
 
@@ -370,6 +459,21 @@ func (o *ObjectNode) setAuditLog(raw interface{}) error {
 	return nil
 }
 
+func (o *ObjectNode) setNotification(raw interface{}) error {
+	var conf NotificationConfig
+	if err := ParseJSONEntity(raw, &conf); err != nil {
+		return err
+	}
+	notifier, err := NewNotifier(conf)
+	if err != nil {
+		return err
+	}
+	o.notifier = notifier
+	o.closes = append(o.closes, notifier.Close)
+
+	return nil
+}
+
 func handleStart(s common.Server, cfg *config.Config) (err error) {
 	o, ok := s.(*ObjectNode)
 	if !ok {
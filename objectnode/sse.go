@@ -0,0 +1,360 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/sse"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/serv-side-encryption.html
+//
+// Each encrypted object has its own random data key and IV, the object data is encrypted with
+// AES-256-CTR so that the stored size equals the object size and any range can be decrypted
+// independently. The data key is sealed with AES-GCM by the master key (SSE-S3) or by the
+// customer provided key (SSE-C), only the sealed key is stored in the object attributes.
+// Parts of a multipart upload share the data key of the upload, every upload of a part has
+// its own random IV which is kept in the part inode and collected into the object attributes
+// once the upload completes.
+
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+
+	SSETypeS3 = "SSE-S3"
+	SSETypeC  = "SSE-C"
+
+	MaxBucketEncryptionSize = 1 << 12 // 4KB
+
+	xattrKeyOSSSSEPrefix = "oss:sse-"
+)
+
+var (
+	// sseKeyStore keeps the master keys of SSE-S3, it is nil if no master key is configured.
+	sseKeyStore sse.MasterKeyStore
+	// sseEnforced encrypts every object with SSE-S3 unless the client asks for SSE-C.
+	sseEnforced bool
+)
+
+type ServerSideEncryptionConfiguration struct {
+	XMLNS   string     `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName xml.Name   `xml:"ServerSideEncryptionConfiguration" json:"-"`
+	Rules   []*SSERule `xml:"Rule" json:"rules"`
+}
+
+type SSERule struct {
+	ApplyDefault     *SSEByDefault `xml:"ApplyServerSideEncryptionByDefault" json:"apply_default"`
+	BucketKeyEnabled bool          `xml:"BucketKeyEnabled,omitempty" json:"bucket_key_enabled,omitempty"`
+}
+
+type SSEByDefault struct {
+	SSEAlgorithm   string `xml:"SSEAlgorithm" json:"sse_algorithm"`
+	KMSMasterKeyID string `xml:"KMSMasterKeyID,omitempty" json:"kms_master_key_id,omitempty"`
+}
+
+// Enabled returns true if new objects of the bucket should be encrypted with SSE-S3 by default.
+func (c *ServerSideEncryptionConfiguration) Enabled() bool {
+	return c != nil && len(c.Rules) > 0
+}
+
+func parseBucketEncryption(bytes []byte) (config *ServerSideEncryptionConfiguration, errCode *ErrorCode) {
+	config = &ServerSideEncryptionConfiguration{}
+	if err := xml.Unmarshal(bytes, config); err != nil {
+		return nil, MalformedXML
+	}
+	if len(config.Rules) != 1 || config.Rules[0].ApplyDefault == nil {
+		return nil, MalformedXML
+	}
+	def := config.Rules[0].ApplyDefault
+	switch def.SSEAlgorithm {
+	case SSEAlgorithmAES256:
+		if def.KMSMasterKeyID != "" {
+			return nil, InvalidArgument
+		}
+	case SSEAlgorithmKMS:
+		return nil, UnsupportedOperation
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+	return config, nil
+}
+
+func storeBucketEncryption(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSEncryption, bytes)
+}
+
+func deleteBucketEncryption(vol *Volume) (err error) {
+	return vol.store.Delete(vol.name, bucketRootPath, XAttrKeyOSSEncryption)
+}
+
+// SSEOption is the server side encryption requested by the client.
+type SSEOption struct {
+	Type           string
+	CustomerKey    []byte
+	CustomerKeyMD5 string // base64 encoded MD5 of the customer key
+}
+
+// parseSSEOption parses the encryption headers of PutObject, CreateMultipartUpload and CopyObject.
+func parseSSEOption(header http.Header) (*SSEOption, *ErrorCode) {
+	opt, errCode := parseSSECustomerKey(header, false)
+	if errCode != nil {
+		return nil, errCode
+	}
+	algorithm := header.Get(XAmzServerSideEncryption)
+	if algorithm == "" {
+		return opt, nil
+	}
+	if opt != nil {
+		// SSE-S3 and SSE-C are exclusive
+		return nil, InvalidArgument
+	}
+	switch algorithm {
+	case SSEAlgorithmAES256:
+		return &SSEOption{Type: SSETypeS3}, nil
+	case SSEAlgorithmKMS:
+		return nil, UnsupportedOperation
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+}
+
+// resolveSSEOption decides the encryption of a new object, the default encryption of the
+// bucket or the enforced encryption of the node applies if the client does not request one.
+func (v *Volume) resolveSSEOption(opt *SSEOption) (*SSEOption, error) {
+	if opt == nil {
+		config, err := v.metaLoader.loadEncryption()
+		if err != nil {
+			return nil, err
+		}
+		if !sseEnforced && !config.Enabled() {
+			return nil, nil
+		}
+		opt = &SSEOption{Type: SSETypeS3}
+	}
+	if opt.Type == SSETypeS3 && sseKeyStore == nil {
+		return nil, SSEMasterKeyNotConfigured
+	}
+	return opt, nil
+}
+
+// multipartEncryption loads the encryption of a multipart upload, the SSE-C key must match
+// the key used to initiate the upload.
+func (v *Volume) multipartEncryption(path, multipartId string, opt *SSEOption) (*objectEncryption, error) {
+	info, err := v.mw.GetMultipart_ll(path, multipartId)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := loadObjectEncryption(info.Extend, opt)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil && opt != nil {
+		return nil, InvalidArgument
+	}
+	return enc, nil
+}
+
+// parseSSECustomerKey parses the SSE-C headers, or the SSE-C headers of the copy source if copySource is true.
+func parseSSECustomerKey(header http.Header, copySource bool) (*SSEOption, *ErrorCode) {
+	algorithmKey := XAmzServerSideEncryptionCustomerAlgorithm
+	keyKey := XAmzServerSideEncryptionCustomerKey
+	md5Key := XAmzServerSideEncryptionCustomerKeyMD5
+	if copySource {
+		algorithmKey = XAmzCopySourceServerSideEncryptionCustomerAlgo
+		keyKey = XAmzCopySourceServerSideEncryptionCustomerKey
+		md5Key = XAmzCopySourceServerSideEncryptionCustomerKeyMD5
+	}
+	algorithm, rawKey, keyMD5 := header.Get(algorithmKey), header.Get(keyKey), header.Get(md5Key)
+	if algorithm == "" && rawKey == "" && keyMD5 == "" {
+		return nil, nil
+	}
+	if algorithm != SSEAlgorithmAES256 {
+		return nil, InvalidEncryptionAlgorithm
+	}
+	key, err := base64.StdEncoding.DecodeString(rawKey)
+	if err != nil || len(key) != sse.KeySize {
+		return nil, InvalidSSECustomerKey
+	}
+	if keyMD5 == "" || sseKeyMD5(key) != keyMD5 {
+		return nil, SSECustomerKeyMD5Mismatch
+	}
+	return &SSEOption{Type: SSETypeC, CustomerKey: key, CustomerKeyMD5: keyMD5}, nil
+}
+
+func sseKeyMD5(key []byte) string {
+	sum := md5.Sum(key)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// setSSEResponseHeaders sets the encryption headers of an encrypted object.
+func setSSEResponseHeaders(w http.ResponseWriter, sseType, keyMD5 string) {
+	switch sseType {
+	case SSETypeS3:
+		w.Header().Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	case SSETypeC:
+		w.Header().Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+		w.Header().Set(XAmzServerSideEncryptionCustomerKeyMD5, keyMD5)
+	}
+}
+
+// checkSSECustomerKey checks whether the client provided the key of an SSE-C object.
+func checkSSECustomerKey(sseType, keyMD5 string, opt *SSEOption) *ErrorCode {
+	if sseType != SSETypeC {
+		return nil
+	}
+	if opt == nil {
+		return SSECustomerKeyRequired
+	}
+	if opt.CustomerKeyMD5 != keyMD5 {
+		return SSECustomerKeyMismatch
+	}
+	return nil
+}
+
+// newSSEPart generates the IV of a part upload and stores it in the part inode, so that
+// the completion of the upload finds the IV of the part which wins.
+func (v *Volume) newSSEPart(inode uint64, partNumber uint16) (part sse.Part, err error) {
+	part.Number = partNumber
+	if part.IV, err = sse.NewPartIV(); err != nil {
+		return
+	}
+	err = v.mw.XAttrSet_ll(inode, []byte(XAttrKeyOSSSSEPartIV), []byte(base64.StdEncoding.EncodeToString(part.IV)))
+	return
+}
+
+// loadSSEPart returns the layout of an uploaded part, the parts uploaded without their own IV have none.
+func (v *Volume) loadSSEPart(info *proto.MultipartPartInfo) (part sse.Part, err error) {
+	part.Number, part.Size = info.ID, info.Size
+	var xattr *proto.XAttrInfo
+	if xattr, err = v.mw.XAttrGet_ll(info.Inode, XAttrKeyOSSSSEPartIV); err != nil {
+		return
+	}
+	if raw := xattr.Get(XAttrKeyOSSSSEPartIV); len(raw) > 0 {
+		if part.IV, err = base64.StdEncoding.DecodeString(string(raw)); err != nil || len(part.IV) != aes.BlockSize {
+			return part, sse.ErrInvalidSealedKey
+		}
+	}
+	return
+}
+
+// objectEncryption is the encryption state of an object or a multipart upload.
+type objectEncryption struct {
+	sse.Cipher
+	Type      string
+	SealedKey string
+	KeyMD5    string
+}
+
+func newObjectEncryption(opt *SSEOption) (enc *objectEncryption, err error) {
+	enc = &objectEncryption{
+		Cipher: sse.Cipher{Key: make([]byte, sse.KeySize), IV: make([]byte, aes.BlockSize)},
+		Type:   opt.Type,
+	}
+	if _, err = io.ReadFull(rand.Reader, enc.Key); err != nil {
+		return
+	}
+	if _, err = io.ReadFull(rand.Reader, enc.IV); err != nil {
+		return
+	}
+	switch opt.Type {
+	case SSETypeS3:
+		if sseKeyStore == nil {
+			return nil, SSEMasterKeyNotConfigured
+		}
+		enc.SealedKey, err = sseKeyStore.Seal(enc.Key)
+	case SSETypeC:
+		var aead cipher.AEAD
+		if aead, err = sse.NewKeyWrapper(opt.CustomerKey); err != nil {
+			return
+		}
+		enc.KeyMD5 = opt.CustomerKeyMD5
+		enc.SealedKey, err = sse.SealKey(aead, enc.Key)
+	default:
+		err = InvalidEncryptionAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// loadObjectEncryption restores the encryption state from the object attributes,
+// it returns nil if the object is not encrypted.
+func loadObjectEncryption(attrs map[string]string, opt *SSEOption) (enc *objectEncryption, err error) {
+	sseType := attrs[XAttrKeyOSSSSEType]
+	if sseType == "" {
+		return nil, nil
+	}
+	enc = &objectEncryption{
+		Type:      sseType,
+		SealedKey: attrs[XAttrKeyOSSSSEKey],
+		KeyMD5:    attrs[XAttrKeyOSSSSEKeyMD5],
+	}
+	if enc.IV, err = base64.StdEncoding.DecodeString(attrs[XAttrKeyOSSSSEIV]); err != nil || len(enc.IV) != aes.BlockSize {
+		return nil, sse.ErrInvalidSealedKey
+	}
+	if enc.Parts, err = sse.ParseParts(attrs[XAttrKeyOSSSSEParts]); err != nil {
+		return nil, err
+	}
+	switch sseType {
+	case SSETypeS3:
+		if sseKeyStore == nil {
+			return nil, SSEMasterKeyNotConfigured
+		}
+		enc.Key, err = sseKeyStore.Unseal(enc.SealedKey)
+	case SSETypeC:
+		if errCode := checkSSECustomerKey(sseType, enc.KeyMD5, opt); errCode != nil {
+			return nil, errCode
+		}
+		var aead cipher.AEAD
+		if aead, err = sse.NewKeyWrapper(opt.CustomerKey); err != nil {
+			return
+		}
+		if enc.Key, err = sse.UnsealKey(aead, enc.SealedKey); err != nil {
+			return nil, SSECustomerKeyMismatch
+		}
+	default:
+		return nil, InvalidEncryptionAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// XAttrs returns the attributes to store with the object.
+func (e *objectEncryption) XAttrs() map[string]string {
+	attrs := map[string]string{
+		XAttrKeyOSSSSEType: e.Type,
+		XAttrKeyOSSSSEKey:  e.SealedKey,
+		XAttrKeyOSSSSEIV:   base64.StdEncoding.EncodeToString(e.IV),
+	}
+	if e.KeyMD5 != "" {
+		attrs[XAttrKeyOSSSSEKeyMD5] = e.KeyMD5
+	}
+	if len(e.Parts) > 0 {
+		attrs[XAttrKeyOSSSSEParts] = sse.EncodeParts(e.Parts)
+	}
+	return attrs
+}
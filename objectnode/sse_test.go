@@ -0,0 +1,248 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"testing"
+
+	"github.com/cubefs/cubefs/util/sse"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, sse.KeySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	require.NoError(t, err)
+	return key
+}
+
+func setTestKeyStore(t *testing.T, config *sse.LocalKeyStoreConfig) {
+	ks, err := sse.NewLocalKeyStore(config)
+	require.NoError(t, err)
+	old := sseKeyStore
+	sseKeyStore = ks
+	t.Cleanup(func() { sseKeyStore = old })
+}
+
+func TestParseBucketEncryption(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<ServerSideEncryptionConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+					</ServerSideEncryptionConfiguration>`,
+		},
+		{
+			value: `<ServerSideEncryptionConfiguration>
+						<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>aws:kms</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+					</ServerSideEncryptionConfiguration>`,
+			expectedErr: UnsupportedOperation,
+		},
+		{
+			value: `<ServerSideEncryptionConfiguration>
+						<Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>DES</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule>
+					</ServerSideEncryptionConfiguration>`,
+			expectedErr: InvalidEncryptionAlgorithm,
+		},
+		{
+			value:       `<ServerSideEncryptionConfiguration></ServerSideEncryptionConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+		{
+			value:       `<ServerSideEncryptionConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, errCode := parseBucketEncryption([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode)
+		if tt.expectedErr == nil {
+			require.True(t, config.Enabled())
+		}
+	}
+	var config *ServerSideEncryptionConfiguration
+	require.False(t, config.Enabled())
+}
+
+func TestParseSSEOption(t *testing.T) {
+	key := newTestKey(t)
+	keyMD5 := sseKeyMD5(key)
+
+	header := make(http.Header)
+	opt, errCode := parseSSEOption(header)
+	require.Nil(t, errCode)
+	require.Nil(t, opt)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	opt, errCode = parseSSEOption(header)
+	require.Nil(t, errCode)
+	require.Equal(t, SSETypeS3, opt.Type)
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmKMS)
+	_, errCode = parseSSEOption(header)
+	require.Equal(t, UnsupportedOperation, errCode)
+
+	header = make(http.Header)
+	header.Set(XAmzServerSideEncryptionCustomerAlgorithm, SSEAlgorithmAES256)
+	header.Set(XAmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+	header.Set(XAmzServerSideEncryptionCustomerKeyMD5, keyMD5)
+	opt, errCode = parseSSEOption(header)
+	require.Nil(t, errCode)
+	require.Equal(t, SSETypeC, opt.Type)
+	require.Equal(t, key, opt.CustomerKey)
+	require.Nil(t, checkSSECustomerKey(SSETypeC, keyMD5, opt))
+	require.Equal(t, SSECustomerKeyRequired, checkSSECustomerKey(SSETypeC, keyMD5, nil))
+	require.Equal(t, SSECustomerKeyMismatch, checkSSECustomerKey(SSETypeC, "other", opt))
+
+	header.Set(XAmzServerSideEncryption, SSEAlgorithmAES256)
+	_, errCode = parseSSEOption(header)
+	require.Equal(t, InvalidArgument, errCode)
+	header.Del(XAmzServerSideEncryption)
+
+	header.Set(XAmzServerSideEncryptionCustomerKeyMD5, sseKeyMD5([]byte("other")))
+	_, errCode = parseSSEOption(header)
+	require.Equal(t, SSECustomerKeyMD5Mismatch, errCode)
+
+	header.Set(XAmzServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key[:16]))
+	_, errCode = parseSSEOption(header)
+	require.Equal(t, InvalidSSECustomerKey, errCode)
+
+	// the copy source key is parsed from its own headers
+	header = make(http.Header)
+	header.Set(XAmzCopySourceServerSideEncryptionCustomerAlgo, SSEAlgorithmAES256)
+	header.Set(XAmzCopySourceServerSideEncryptionCustomerKey, base64.StdEncoding.EncodeToString(key))
+	header.Set(XAmzCopySourceServerSideEncryptionCustomerKeyMD5, keyMD5)
+	opt, errCode = parseSSECustomerKey(header, false)
+	require.Nil(t, errCode)
+	require.Nil(t, opt)
+	opt, errCode = parseSSECustomerKey(header, true)
+	require.Nil(t, errCode)
+	require.Equal(t, keyMD5, opt.CustomerKeyMD5)
+}
+
+func TestObjectEncryptionRange(t *testing.T) {
+	setTestKeyStore(t, &sse.LocalKeyStoreConfig{
+		Current: "k1",
+		Keys:    map[string]string{"k1": base64.StdEncoding.EncodeToString(newTestKey(t))},
+	})
+	customerKey := newTestKey(t)
+	customerOpt := &SSEOption{Type: SSETypeC, CustomerKey: customerKey, CustomerKeyMD5: sseKeyMD5(customerKey)}
+
+	plaintext := make([]byte, 100*1024+7)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+
+	for _, opt := range []*SSEOption{{Type: SSETypeS3}, customerOpt} {
+		enc, err := newObjectEncryption(opt)
+		require.NoError(t, err)
+		ciphertext, err := io.ReadAll(enc.EncryptReader(bytes.NewReader(plaintext), sse.Part{}))
+		require.NoError(t, err)
+		require.Equal(t, len(plaintext), len(ciphertext))
+		require.NotEqual(t, plaintext, ciphertext)
+
+		loaded, err := loadObjectEncryption(enc.XAttrs(), opt)
+		require.NoError(t, err)
+		for i := 0; i < 20; i++ {
+			start := mrand.Intn(len(plaintext))
+			end := start + mrand.Intn(len(plaintext)-start) + 1
+			buf := new(bytes.Buffer)
+			_, err = loaded.DecryptWriter(buf, uint64(start)).Write(ciphertext[start:end])
+			require.NoError(t, err)
+			require.Equal(t, plaintext[start:end], buf.Bytes())
+		}
+	}
+
+	// an SSE-C object can not be loaded without the customer key
+	enc, err := newObjectEncryption(customerOpt)
+	require.NoError(t, err)
+	_, err = loadObjectEncryption(enc.XAttrs(), nil)
+	require.Equal(t, SSECustomerKeyRequired, err)
+	otherKey := newTestKey(t)
+	_, err = loadObjectEncryption(enc.XAttrs(), &SSEOption{Type: SSETypeC, CustomerKey: otherKey, CustomerKeyMD5: enc.KeyMD5})
+	require.Equal(t, SSECustomerKeyMismatch, err)
+
+	enc, err = loadObjectEncryption(map[string]string{}, nil)
+	require.NoError(t, err)
+	require.Nil(t, enc)
+}
+
+func TestObjectEncryptionMultipart(t *testing.T) {
+	setTestKeyStore(t, &sse.LocalKeyStoreConfig{
+		Current: "k1",
+		Keys:    map[string]string{"k1": base64.StdEncoding.EncodeToString(newTestKey(t))},
+	})
+	enc, err := newObjectEncryption(&SSEOption{Type: SSETypeS3})
+	require.NoError(t, err)
+
+	// parts are encrypted independently and concatenated in the order of part numbers,
+	// the part without its own IV is uploaded before parts had one
+	parts := []sse.Part{{Number: 1, Size: 5000}, {Number: 3, Size: 33}, {Number: 4, Size: 4096}}
+	for _, idx := range []int{0, 2} {
+		parts[idx].IV, err = sse.NewPartIV()
+		require.NoError(t, err)
+	}
+	var plaintext, ciphertext []byte
+	for _, part := range parts {
+		data := make([]byte, part.Size)
+		_, err = io.ReadFull(rand.Reader, data)
+		require.NoError(t, err)
+		encrypted, err := io.ReadAll(enc.EncryptReader(bytes.NewReader(data), part))
+		require.NoError(t, err)
+		plaintext = append(plaintext, data...)
+		ciphertext = append(ciphertext, encrypted...)
+	}
+
+	attrs := enc.XAttrs()
+	attrs[XAttrKeyOSSSSEParts] = sse.EncodeParts(parts)
+	loaded, err := loadObjectEncryption(attrs, nil)
+	require.NoError(t, err)
+	require.Equal(t, parts, loaded.Parts)
+
+	for i := 0; i < 20; i++ {
+		start := mrand.Intn(len(plaintext))
+		end := start + mrand.Intn(len(plaintext)-start) + 1
+		buf := new(bytes.Buffer)
+		writer := loaded.DecryptWriter(buf, uint64(start))
+		// write in small pieces to cross the part boundaries within a write and between writes
+		for offset := start; offset < end; offset += 1000 {
+			limit := offset + 1000
+			if limit > end {
+				limit = end
+			}
+			_, err = writer.Write(ciphertext[offset:limit])
+			require.NoError(t, err)
+		}
+		require.Equal(t, plaintext[start:end], buf.Bytes())
+	}
+
+	// uploading the same part number again never reuses the key stream
+	data := make([]byte, 64)
+	again := sse.Part{Number: parts[0].Number}
+	again.IV, err = sse.NewPartIV()
+	require.NoError(t, err)
+	first, err := io.ReadAll(enc.EncryptReader(bytes.NewReader(data), parts[0]))
+	require.NoError(t, err)
+	second, err := io.ReadAll(enc.EncryptReader(bytes.NewReader(data), again))
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/Versioning.html
+
+const (
+	VersioningEnabled   = "Enabled"
+	VersioningSuspended = "Suspended"
+
+	MaxVersioningSize = 1 << 10 // 1KB
+
+	// NullVersionId is the version ID of objects written while versioning is not enabled.
+	NullVersionId = "null"
+
+	// VersionsDirName is the hidden directory under the volume root which keeps
+	// noncurrent versions and delete markers of objects.
+	VersionsDirName = ".oss_versions"
+
+	versionIdLength     = 32
+	versionSortKeyWidth = 16
+
+	// versionKeyChunkLen is the max length of the hex encoded key kept in a single dentry name,
+	// the hex encoded keys longer than it are split into nested version directories.
+	versionKeyChunkLen = 254
+	// versionKeyChunkPrefix prefixes the names of the nested version directories, it sorts
+	// after the version entries which start with hex digits.
+	versionKeyChunkPrefix = "~"
+)
+
+type VersioningConfiguration struct {
+	XMLNS     string   `xml:"xmlns,attr,omitempty" json:"-"`
+	XMLName   xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Status    string   `xml:"Status,omitempty" json:"status,omitempty"`
+	MfaDelete string   `xml:"MfaDelete,omitempty" json:"mfa_delete,omitempty"`
+}
+
+// Enabled returns true if new object versions should be kept.
+func (c *VersioningConfiguration) Enabled() bool {
+	return c != nil && c.Status == VersioningEnabled
+}
+
+// Suspended returns true if versioning has been enabled before but is suspended now.
+func (c *VersioningConfiguration) Suspended() bool {
+	return c != nil && c.Status == VersioningSuspended
+}
+
+// Configured returns true if versioning has ever been enabled on the bucket.
+func (c *VersioningConfiguration) Configured() bool {
+	return c.Enabled() || c.Suspended()
+}
+
+func parseVersioningConfig(bytes []byte) (config *VersioningConfiguration, errCode *ErrorCode) {
+	config = &VersioningConfiguration{}
+	if err := xml.Unmarshal(bytes, config); err != nil {
+		return nil, MalformedXML
+	}
+	if config.Status != VersioningEnabled && config.Status != VersioningSuspended {
+		return nil, IllegalVersioningConfiguration
+	}
+	if config.MfaDelete == VersioningEnabled {
+		return nil, UnsupportedOperation
+	}
+	return config, nil
+}
+
+func storeBucketVersioning(bytes []byte, vol *Volume) (err error) {
+	return vol.store.Put(vol.name, bucketRootPath, XAttrKeyOSSVersioning, bytes)
+}
+
+// newVersionId generates a version ID for the object stored in the given inode.
+// The first half is the inverted creation time so that version IDs of the same
+// key sort from the newest to the oldest, the second half makes it unique.
+func newVersionId(ts time.Time, inode uint64) string {
+	return versionSortKey(ts) + fmt.Sprintf("%016x", inode)
+}
+
+func versionSortKey(ts time.Time) string {
+	return fmt.Sprintf("%016x", uint64(math.MaxInt64-ts.UnixNano()))
+}
+
+// versionEntryName returns the dentry name of a noncurrent version in the version directory of its key.
+// The null version has no time information in its ID, so the modify time is prefixed to it.
+func versionEntryName(versionId string, modifyTime time.Time) string {
+	if versionId == "" || versionId == NullVersionId {
+		return versionSortKey(modifyTime) + NullVersionId
+	}
+	return versionId
+}
+
+// versionIdFromEntryName is the reverse of versionEntryName.
+func versionIdFromEntryName(name string) string {
+	if strings.HasSuffix(name, NullVersionId) {
+		return NullVersionId
+	}
+	return name
+}
+
+func isValidVersionId(versionId string) bool {
+	if versionId == NullVersionId {
+		return true
+	}
+	if len(versionId) != versionIdLength {
+		return false
+	}
+	_, err := strconv.ParseUint(versionId[:versionSortKeyWidth], 16, 64)
+	return err == nil
+}
+
+// versionKeyDirNames returns the path of the version directory of the object key under VersionsDirName.
+// The key is hex encoded, which keeps the byte order of keys, so the version directories are listed in
+// the same order as the objects. The hex encoded key is split into chunks of versionKeyChunkLen to keep
+// the dentry names within 255 bytes, every chunk but the first one is prefixed with versionKeyChunkPrefix:
+//
+//	<hex chunk 1>/~<hex chunk 2>/.../~<hex chunk n>
+//
+// The keys of at most versionKeyChunkLen/2 bytes are kept in a single directory.
+func versionKeyDirNames(key string) []string {
+	encoded := hex.EncodeToString([]byte(key))
+	names := make([]string, 0, len(encoded)/versionKeyChunkLen+1)
+	for start := 0; start < len(encoded); start += versionKeyChunkLen {
+		end := start + versionKeyChunkLen
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		name := encoded[start:end]
+		if start > 0 {
+			name = versionKeyChunkPrefix + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// versionKeyFromDirNames is the reverse of versionKeyDirNames.
+func versionKeyFromDirNames(names ...string) (string, error) {
+	var encoded strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			if !strings.HasPrefix(name, versionKeyChunkPrefix) {
+				return "", fmt.Errorf("invalid version directory: %v", name)
+			}
+			name = name[len(versionKeyChunkPrefix):]
+		}
+		encoded.WriteString(name)
+	}
+	key, err := hex.DecodeString(encoded.String())
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// isReservedPath reports whether the path belongs to the internal version storage
+// or the replication queue.
+func isReservedPath(path string) bool {
+	path = strings.TrimPrefix(path, pathSep)
+	for _, dir := range []string{VersionsDirName, ReplicationDirName} {
+		if path == dir || strings.HasPrefix(path, dir+pathSep) {
+			return true
+		}
+	}
+	return false
+}
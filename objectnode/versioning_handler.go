@@ -0,0 +1,299 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// Put bucket versioning
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+func (o *ObjectNode) putBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var body []byte
+	if body, err = io.ReadAll(io.LimitReader(r.Body, MaxVersioningSize+1)); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: read request body fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if len(body) > MaxVersioningSize {
+		errorCode = EntityTooLarge
+		return
+	}
+	var config *VersioningConfiguration
+	if config, errorCode = parseVersioningConfig(body); errorCode != nil {
+		log.LogErrorf("putBucketVersioningHandler: parse versioning config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), errorCode)
+		return
+	}
+	// versioning can only be suspended once it has been enabled
+	var current *VersioningConfiguration
+	if current, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	if config.Suspended() && !current.Configured() {
+		log.LogInfof("putBucketVersioningHandler: suspend versioning on unversioned bucket: requestID(%v) volume(%v)",
+			GetRequestID(r), vol.Name())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	config.XMLNS = ""
+	if body, err = MarshalXMLEntity(config); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: xml marshal versioning config fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), config, err)
+		return
+	}
+	if err = storeBucketVersioning(body, vol); err != nil {
+		log.LogErrorf("putBucketVersioningHandler: store versioning config fail: requestID(%v) volume(%v) config(%v) err(%v)",
+			GetRequestID(r), vol.Name(), string(body), err)
+		return
+	}
+	vol.metaLoader.storeVersioning(config)
+
+	log.LogInfof("Audit: put bucket versioning: requestID(%v) remote(%v) volume(%v) status(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), config.Status)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Get bucket versioning
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+func (o *ObjectNode) getBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	var config *VersioningConfiguration
+	if config, err = vol.metaLoader.loadVersioning(); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: load versioning fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+	// a bucket which has never been versioned returns an empty configuration
+	result := &VersioningConfiguration{XMLNS: XMLNS}
+	if config != nil {
+		result.Status = config.Status
+		result.MfaDelete = config.MfaDelete
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(result); err != nil {
+		log.LogErrorf("getBucketVersioningHandler: xml marshal fail: requestID(%v) volume(%v) config(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), result, err)
+		return
+	}
+
+	writeSuccessResponseXML(w, data)
+}
+
+// List object versions
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+func (o *ObjectNode) listObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err       error
+		errorCode *ErrorCode
+	)
+
+	span := trace.SpanFromContextSafe(r.Context())
+	defer func() {
+		o.errorResponse(w, r, err, errorCode)
+	}()
+
+	param := ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("listObjectVersionsHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		return
+	}
+
+	// QPS and Concurrency Limit
+	rateLimit := o.AcquireRateLimiter()
+	if err = rateLimit.AcquireLimitResource(vol.owner, param.apiName); err != nil {
+		return
+	}
+	defer rateLimit.ReleaseLimitResource(vol.owner, param.apiName)
+
+	// get options
+	keyMarker := r.URL.Query().Get(ParamKeyMarker)
+	versionIdMarker := r.URL.Query().Get(ParamVersionIdMarker)
+	prefix := r.URL.Query().Get(ParamPrefix)
+	maxKeys := r.URL.Query().Get(ParamMaxKeys)
+	delimiter := r.URL.Query().Get(ParamPartDelimiter)
+	encodingType := r.URL.Query().Get(ParamEncodingType)
+
+	var maxKeysInt uint64
+	if maxKeys != "" {
+		maxKeysInt, err = strconv.ParseUint(maxKeys, 10, 16)
+		if err != nil {
+			log.LogErrorf("listObjectVersionsHandler: parse max key fail: requestID(%v) volume(%v) maxKeys(%v) err(%v)",
+				GetRequestID(r), vol.Name(), maxKeys, err)
+			errorCode = InvalidArgument
+			return
+		}
+		if maxKeysInt > MaxKeys {
+			maxKeysInt = MaxKeys
+		}
+	} else {
+		maxKeysInt = uint64(MaxKeys)
+	}
+	if encodingType != "" && encodingType != "url" {
+		errorCode = InvalidArgument
+		return
+	}
+	if versionIdMarker != "" {
+		if keyMarker == "" {
+			errorCode = InvalidArgument
+			return
+		}
+		if !isValidVersionId(versionIdMarker) {
+			errorCode = InvalidVersionId
+			return
+		}
+	}
+	if keyMarker != "" && prefix != "" && !strings.HasPrefix(keyMarker, prefix) {
+		errorCode = InvalidArgument
+		return
+	}
+
+	option := &ListObjectVersionsOption{
+		Prefix:          prefix,
+		Delimiter:       delimiter,
+		KeyMarker:       keyMarker,
+		VersionIdMarker: versionIdMarker,
+		MaxKeys:         maxKeysInt,
+	}
+	start := time.Now()
+	result, err := vol.ListObjectVersions(option)
+	span.AppendTrackLog("file.l", start, err)
+	if err != nil {
+		log.LogErrorf("listObjectVersionsHandler: list object versions fail: requestID(%v) volume(%v) option(%+v) err(%v)",
+			GetRequestID(r), vol.Name(), option, err)
+		return
+	}
+
+	bucketOwner := NewBucketOwner(vol)
+	versions := make([]*ObjectVersion, 0)
+	deleteMarkers := make([]*DeleteMarkerEntry, 0)
+	for _, version := range result.Versions {
+		if version.DeleteMarker {
+			deleteMarkers = append(deleteMarkers, &DeleteMarkerEntry{
+				Key:          encodeKey(version.Key, encodingType),
+				VersionId:    version.VersionId,
+				IsLatest:     version.IsLatest,
+				LastModified: formatTimeISO(version.Info.ModifyTime),
+				Owner:        bucketOwner,
+			})
+			continue
+		}
+		versions = append(versions, &ObjectVersion{
+			Key:          encodeKey(version.Key, encodingType),
+			VersionId:    version.VersionId,
+			IsLatest:     version.IsLatest,
+			LastModified: formatTimeISO(version.Info.ModifyTime),
+			ETag:         wrapUnescapedQuot(version.Info.ETag),
+			Size:         int(version.Info.Size),
+			StorageClass: StorageClassStandard,
+			Owner:        bucketOwner,
+		})
+	}
+	commonPrefixes := make([]*CommonPrefix, 0, len(result.CommonPrefixes))
+	for _, prefix := range result.CommonPrefixes {
+		commonPrefixes = append(commonPrefixes, &CommonPrefix{Prefix: encodeKey(prefix, encodingType)})
+	}
+
+	listVersionsResult := &ListVersionsResult{
+		Bucket:              param.Bucket(),
+		Prefix:              encodeKey(prefix, encodingType),
+		KeyMarker:           encodeKey(keyMarker, encodingType),
+		VersionIdMarker:     versionIdMarker,
+		NextKeyMarker:       encodeKey(result.NextKeyMarker, encodingType),
+		NextVersionIdMarker: result.NextVersionIdMarker,
+		MaxKeys:             int(maxKeysInt),
+		Delimiter:           encodeKey(delimiter, encodingType),
+		EncodingType:        encodingType,
+		IsTruncated:         result.Truncated,
+		Versions:            versions,
+		DeleteMarkers:       deleteMarkers,
+		CommonPrefixes:      commonPrefixes,
+	}
+	response, err := MarshalXMLEntity(listVersionsResult)
+	if err != nil {
+		log.LogErrorf("listObjectVersionsHandler: xml marshal result fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), vol.Name(), err)
+		return
+	}
+
+	writeSuccessResponseXML(w, response)
+}
+
+// setVersionHeaders sets the version headers of GetObject and HeadObject.
+// A delete marker has no content, so MethodNotAllowed is returned for it.
+func setVersionHeaders(w http.ResponseWriter, info *FSFileInfo) *ErrorCode {
+	if info.VersionId != "" {
+		w.Header().Set(XAmzVersionId, info.VersionId)
+	}
+	if info.DeleteMarker {
+		w.Header().Set(XAmzDeleteMarker, "true")
+		return MethodNotAllowed
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersioningConfig(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr *ErrorCode
+	}{
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+					</VersioningConfiguration>`,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Suspended</Status>
+						<MfaDelete>Disabled</MfaDelete>
+					</VersioningConfiguration>`,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>enabled</Status>
+					</VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value:       `<VersioningConfiguration></VersioningConfiguration>`,
+			expectedErr: IllegalVersioningConfiguration,
+		},
+		{
+			value: `<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+						<Status>Enabled</Status>
+						<MfaDelete>Enabled</MfaDelete>
+					</VersioningConfiguration>`,
+			expectedErr: UnsupportedOperation,
+		},
+		{
+			value:       `<VersioningConfiguration>`,
+			expectedErr: MalformedXML,
+		},
+	}
+	for _, tt := range tests {
+		config, errCode := parseVersioningConfig([]byte(tt.value))
+		require.Equal(t, tt.expectedErr, errCode)
+		if tt.expectedErr == nil {
+			require.True(t, config.Configured())
+		}
+	}
+}
+
+func TestVersioningConfigurationStatus(t *testing.T) {
+	var config *VersioningConfiguration
+	require.False(t, config.Configured())
+	config = &VersioningConfiguration{Status: VersioningEnabled}
+	require.True(t, config.Enabled())
+	require.False(t, config.Suspended())
+	config = &VersioningConfiguration{Status: VersioningSuspended}
+	require.False(t, config.Enabled())
+	require.True(t, config.Suspended())
+	require.True(t, config.Configured())
+}
+
+func TestVersionIdOrder(t *testing.T) {
+	now := time.Now()
+	older := newVersionId(now.Add(-time.Second), 100)
+	newer := newVersionId(now, 1)
+	require.Len(t, older, versionIdLength)
+	require.True(t, isValidVersionId(older))
+	require.True(t, isValidVersionId(NullVersionId))
+	require.False(t, isValidVersionId("abc"))
+	require.False(t, isValidVersionId("zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz"))
+
+	nullEntry := versionEntryName(NullVersionId, now.Add(-time.Millisecond))
+	names := []string{older, nullEntry, newer}
+	sort.Strings(names)
+	require.Equal(t, []string{newer, nullEntry, older}, names)
+
+	require.Equal(t, NullVersionId, versionIdFromEntryName(nullEntry))
+	require.Equal(t, newer, versionIdFromEntryName(versionEntryName(newer, now)))
+}
+
+func TestVersionKeyDirNames(t *testing.T) {
+	long := strings.Repeat("k", versionKeyChunkLen/2)
+	keys := []string{
+		"a/b", "a", "a/", "b", "a0", "中文",
+		long, long + "a", long + "/", long + strings.Repeat("x", 300), strings.Repeat("中", 200),
+	}
+	paths := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		names := versionKeyDirNames(key)
+		for i, name := range names {
+			require.LessOrEqual(t, len(name), 255)
+			require.Equal(t, i > 0, isVersionKeyChunkDir(name))
+		}
+		decoded, err := versionKeyFromDirNames(names...)
+		require.NoError(t, err)
+		require.Equal(t, key, decoded)
+		paths = append(paths, names)
+	}
+	require.Len(t, versionKeyDirNames(long), 1)
+	require.Len(t, versionKeyDirNames(long+"a"), 2)
+
+	// the version directories are walked in the order of their names level by level
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	sort.Strings(keys)
+	for i, names := range paths {
+		require.Equal(t, versionKeyDirNames(keys[i]), names)
+	}
+
+	_, err := versionKeyFromDirNames("xyz")
+	require.Error(t, err)
+	_, err = versionKeyFromDirNames("61", "62")
+	require.Error(t, err)
+}
+
+func TestIsReservedPath(t *testing.T) {
+	require.True(t, isReservedPath(VersionsDirName))
+	require.True(t, isReservedPath("/"+VersionsDirName+"/6162"))
+	require.False(t, isReservedPath(VersionsDirName+"x"))
+	require.False(t, isReservedPath("a/"+VersionsDirName))
+}
+
+func TestAfterVersionKeyMarker(t *testing.T) {
+	opt := &ListObjectVersionsOption{KeyMarker: "a/b"}
+	require.False(t, afterVersionKeyMarker("a/b", false, opt))
+	require.True(t, afterVersionKeyMarker("a/c", false, opt))
+	require.False(t, afterVersionKeyMarker("a/", true, opt))
+	opt.VersionIdMarker = NullVersionId
+	require.True(t, afterVersionKeyMarker("a/b", false, opt))
+}
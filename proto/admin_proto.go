@@ -51,6 +51,7 @@ const (
 	AdminVolExpand                            = "/vol/expand"
 	AdminVolForbidden                         = "/vol/forbidden"
 	AdminVolEnableAuditLog                    = "/vol/auditlog"
+	AdminVolEnableReplication                 = "/vol/replication"
 	AdminCreateVol                            = "/admin/createVol"
 	AdminGetVol                               = "/admin/getVol"
 	AdminClusterFreeze                        = "/cluster/freeze"
@@ -101,6 +102,9 @@ const (
 	AdminQueryDecommissionDiskLimit   = "/admin/queryDecommissionDiskLimit"
 	AdminEnableAutoDecommissionDisk   = "/admin/enableAutoDecommissionDisk"
 	AdminQueryAutoDecommissionDisk    = "/admin/queryAutoDecommissionDisk"
+
+	AdminSetDpBalance     = "/admin/dpBalance/set"
+	AdminGetDpBalancePlan = "/admin/dpBalance/plan"
 	// graphql master api
 	AdminClusterAPI = "/api/cluster"
 	AdminUserAPI    = "/api/user"
@@ -188,6 +192,9 @@ const (
 	AdminGetVolVer         = "/vol/getVer"
 	AdminSetVerStrategy    = "/vol/SetVerStrategy"
 
+	AdminSetSnapshotRetention = "/vol/setSnapshotRetention"
+	AdminGetSnapshotRetention = "/vol/getSnapshotRetention"
+
 	// S3 lifecycle configuration APIS
 	SetBucketLifecycle    = "/s3/setLifecycle"
 	GetBucketLifecycle    = "/s3/getLifecycle"
@@ -237,6 +244,11 @@ const (
 	QuotaGet    = "/quota/get"
 	// QuotaBatchModifyPath = "/quota/batchModifyPath"
 	QuotaListAll = "/quota/listAll"
+	// user and group quota of the whole volume
+	QuotaOwnerSet    = "/quota/owner/set"
+	QuotaOwnerDelete = "/quota/owner/delete"
+	QuotaOwnerGet    = "/quota/owner/get"
+	QuotaOwnerReport = "/quota/owner/report"
 
 	// s3 qos api
 	S3QoSSet    = "/s3/qos/set"
@@ -668,6 +680,12 @@ type QuotaHeartBeatInfos struct {
 	QuotaHbInfos []*QuotaHeartBeatInfo
 }
 
+type OwnerQuotaHeartBeatInfos struct {
+	OwnerQuotaHbInfos []*OwnerQuotaHeartBeatInfo
+	// the usage of uid and gid is reported only for volumes with quota enabled
+	OwnerQuotaEnableVols []string
+}
+
 type TxInfo struct {
 	Volume     string
 	Mask       TxOpMask
@@ -687,6 +705,7 @@ type HeartBeatRequest struct {
 	FileStatsEnable bool
 	UidLimitToMetaNode
 	QuotaHeartBeatInfos
+	OwnerQuotaHeartBeatInfos
 	TxInfos
 	ForbiddenVols     []string
 	DisableAuditVols  []string
@@ -730,6 +749,33 @@ type DiskStat struct {
 	DiskErrPartitionList []uint64
 }
 
+// DiskScrubStat is the progress of the data scrubber on a disk.
+type DiskScrubStat struct {
+	DiskPath          string
+	Round             uint64 // rounds finished
+	Running           bool
+	RoundStartTime    int64
+	LastFinishTime    int64
+	TotalPartitions   int
+	ScannedPartitions int
+	ScannedExtents    uint64
+	ScannedBytes      uint64
+	CorruptBlocks     uint64 // blocks whose data does not match the crc
+	RepairedBlocks    uint64 // corrupt blocks repaired from other replicas
+	MismatchBlocks    uint64 // blocks which are intact on the disk but differ from other replicas
+}
+
+// ScrubCorruptExtent is an extent with corrupt blocks found by the data scrubber.
+type ScrubCorruptExtent struct {
+	PartitionID   uint64
+	ExtentID      uint64
+	DiskPath      string
+	CorruptBlocks []int
+	Repaired      bool
+	FoundTime     int64
+	Err           string
+}
+
 // DataNodeHeartbeatResponse defines the response to the data node heartbeat.
 type DataNodeHeartbeatResponse struct {
 	Total               uint64
@@ -748,26 +794,29 @@ type DataNodeHeartbeatResponse struct {
 	DiskStats           []DiskStat         // key: disk path
 	CpuUtil             float64            `json:"cpuUtil"`
 	IoUtils             map[string]float64 `json:"ioUtil"`
+	ScrubStats          []DiskScrubStat
+	CorruptExtents      []*ScrubCorruptExtent
 }
 
 // MetaPartitionReport defines the meta partition report.
 type MetaPartitionReport struct {
-	PartitionID      uint64
-	Start            uint64
-	End              uint64
-	Status           int
-	Size             uint64
-	MaxInodeID       uint64
-	IsLeader         bool
-	VolName          string
-	InodeCnt         uint64
-	DentryCnt        uint64
-	TxCnt            uint64
-	TxRbInoCnt       uint64
-	TxRbDenCnt       uint64
-	FreeListLen      uint64
-	UidInfo          []*UidReportSpaceInfo
-	QuotaReportInfos []*QuotaReportInfo
+	PartitionID           uint64
+	Start                 uint64
+	End                   uint64
+	Status                int
+	Size                  uint64
+	MaxInodeID            uint64
+	IsLeader              bool
+	VolName               string
+	InodeCnt              uint64
+	DentryCnt             uint64
+	TxCnt                 uint64
+	TxRbInoCnt            uint64
+	TxRbDenCnt            uint64
+	FreeListLen           uint64
+	UidInfo               []*UidReportSpaceInfo
+	QuotaReportInfos      []*QuotaReportInfo
+	OwnerQuotaReportInfos []*OwnerQuotaReportInfo
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
@@ -1083,6 +1132,8 @@ type SimpleVolView struct {
 	TxConflictRetryNum      int64
 	TxConflictRetryInterval int64
 	TxOpLimit               int
+	MetaStoreMode           string
+	CompressCodec           string
 	Description             string
 	DpSelectorName          string
 	DpSelectorParm          string
@@ -1106,6 +1157,8 @@ type SimpleVolView struct {
 	Forbidden      bool
 	EnableAuditLog bool
 	DeleteExecTime time.Time
+	// the bucket has a replication configuration, set by objectnode
+	EnableReplication bool
 }
 
 type NodeSetInfo struct {
@@ -1157,6 +1210,7 @@ type VolInfo struct {
 	TotalSize             uint64
 	UsedSize              uint64
 	DpReadOnlyWhenVolFull bool
+	EnableReplication     bool
 }
 
 func NewVolInfo(name, owner string, createTime int64, status uint8, totalSize, usedSize uint64, dpReadOnlyWhenVolFull bool) *VolInfo {
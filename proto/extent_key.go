@@ -47,6 +47,22 @@ type ExtSnapInfo struct {
 	ModGen  uint64
 }
 
+// the flags of the extent key in the v3 binary format, which takes the place of isSplit.
+const (
+	extentKeyFlagSplit uint8 = 1 << iota
+	extentKeyFlagCompressed
+)
+
+// ExtCompressInfo describes the extent key of the data compressed by the client. The data of
+// the extent [ExtentOffset, ExtentOffset+FrameSize) is a frame compressed by Codec, and the
+// data of the key is [RawOffset, RawOffset+Size) of the decompressed frame. Codec 0 means the
+// frame could not be compressed and is kept as it is.
+type ExtCompressInfo struct {
+	Codec     uint8
+	FrameSize uint32
+	RawOffset uint32
+}
+
 // ExtentKey defines the extent key struct.
 type ExtentKey struct {
 	FileOffset   uint64 // offset in file
@@ -57,6 +73,34 @@ type ExtentKey struct {
 	CRC          uint32
 	// snapshot
 	SnapInfo *ExtSnapInfo
+	// compression, nil if the data is not compressed
+	CompressInfo *ExtCompressInfo `json:",omitempty"`
+}
+
+// IsCompressed returns if the data of the key is a compressed frame.
+func (k *ExtentKey) IsCompressed() bool {
+	return k.CompressInfo != nil
+}
+
+// PhysicalSize returns the size of the data of the key kept in the extent.
+func (k *ExtentKey) PhysicalSize() uint32 {
+	if k.CompressInfo != nil {
+		return k.CompressInfo.FrameSize
+	}
+	return k.Size
+}
+
+// AddExtentOffset moves the start of the data of the key forward by delta bytes of the file,
+// the key of a compressed frame moves the offset in the frame instead of the extent offset.
+func (k *ExtentKey) AddExtentOffset(delta uint64) {
+	if k.CompressInfo == nil {
+		k.ExtentOffset += delta
+		return
+	}
+	// the compress info may be shared by the copies of the key
+	info := *k.CompressInfo
+	info.RawOffset += uint32(delta)
+	k.CompressInfo = &info
 }
 
 func (k *ExtentKey) GetModGen() uint64 {
@@ -90,6 +134,10 @@ func (k *ExtentKey) Equals(ek *ExtentKey) bool {
 		k.CRC != ek.CRC {
 		return false
 	}
+	if (k.CompressInfo == nil) != (ek.CompressInfo == nil) ||
+		k.CompressInfo != nil && *k.CompressInfo != *ek.CompressInfo {
+		return false
+	}
 	if k.SnapInfo == nil && ek.SnapInfo == nil {
 		return true
 	} else if k.SnapInfo == nil || ek.SnapInfo == nil {
@@ -181,15 +229,19 @@ func (k *ExtentKey) IsEqual(rightKey *ExtentKey) bool {
 }
 
 func (k *ExtentKey) IsCoveredWithDiffSeq(rightKey *ExtentKey) bool {
-	return k.PartitionId == rightKey.PartitionId &&
+	return k.CompressInfo == nil && rightKey.CompressInfo == nil &&
+		k.PartitionId == rightKey.PartitionId &&
 		k.ExtentId == rightKey.ExtentId &&
 		k.GetSeq() < rightKey.GetSeq() &&
 		k.ExtentOffset+uint64(k.Size) == rightKey.ExtentOffset &&
 		k.FileOffset+uint64(k.Size) == rightKey.FileOffset
 }
 
+// IsSequenceWithSameSeq returns if the right key follows the key in both the file and the extent,
+// and the two keys can be merged. The keys of the compressed frames are never merged.
 func (k *ExtentKey) IsSequenceWithSameSeq(rightKey *ExtentKey) bool {
-	return k.PartitionId == rightKey.PartitionId &&
+	return k.CompressInfo == nil && rightKey.CompressInfo == nil &&
+		k.PartitionId == rightKey.PartitionId &&
 		k.ExtentId == rightKey.ExtentId &&
 		k.GetSeq() == rightKey.GetSeq() &&
 		k.ExtentOffset+uint64(k.Size) == rightKey.ExtentOffset &&
@@ -202,7 +254,8 @@ func (k *ExtentKey) IsSameExtent(rightKey *ExtentKey) bool {
 }
 
 func (k *ExtentKey) IsSequenceWithDiffSeq(rightKey *ExtentKey) bool {
-	return k.PartitionId == rightKey.PartitionId &&
+	return k.CompressInfo == nil && rightKey.CompressInfo == nil &&
+		k.PartitionId == rightKey.PartitionId &&
 		k.ExtentId == rightKey.ExtentId &&
 		!(k.GetSeq() == rightKey.GetSeq()) &&
 		k.ExtentOffset+uint64(k.Size) == rightKey.ExtentOffset &&
@@ -212,11 +265,16 @@ func (k *ExtentKey) IsSequenceWithDiffSeq(rightKey *ExtentKey) bool {
 func (k *ExtentKey) IsFileInSequence(rightKey *ExtentKey) bool {
 	return k.PartitionId == rightKey.PartitionId &&
 		k.ExtentId == rightKey.ExtentId &&
-		k.ExtentOffset+uint64(k.Size) == rightKey.ExtentOffset
+		k.ExtentOffset+uint64(k.PhysicalSize()) == rightKey.ExtentOffset
 }
 
 // String returns the string format of the extentKey.
 func (k ExtentKey) String() string {
+	if k.CompressInfo != nil {
+		return fmt.Sprintf("ExtentKey{FileOffset(%v),VerSeq(%v) Partition(%v),ExtentID(%v),ExtentOffset(%v),isSplit(%v),Size(%v),CRC(%v),Compress(%v,%v,%v)}",
+			k.FileOffset, k.GetSeq(), k.PartitionId, k.ExtentId, k.ExtentOffset, k.IsSplit(), k.Size, k.CRC,
+			k.CompressInfo.Codec, k.CompressInfo.FrameSize, k.CompressInfo.RawOffset)
+	}
 	return fmt.Sprintf("ExtentKey{FileOffset(%v),VerSeq(%v) Partition(%v),ExtentID(%v),ExtentOffset(%v),isSplit(%v),Size(%v),CRC(%v)}",
 		k.FileOffset, k.GetSeq(), k.PartitionId, k.ExtentId, k.ExtentOffset, k.IsSplit(), k.Size, k.CRC)
 }
@@ -247,6 +305,12 @@ func (k *ExtentKey) MarshalBinaryExt(data []byte) {
 
 // MarshalBinary marshals the binary format of the extent key.
 func (k *ExtentKey) MarshalBinary(v3 bool) ([]byte, error) {
+	return k.marshalBinary(v3, true)
+}
+
+// marshalBinary marshals the extent key, the compress info is only kept in the v3 format of the
+// inode with the compressed flag, but not in the fixed length format with checksum.
+func (k *ExtentKey) marshalBinary(v3 bool, withCompress bool) ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0, ExtentLength))
 	if err := binary.Write(buf, binary.BigEndian, k.FileOffset); err != nil {
 		return nil, err
@@ -270,9 +334,21 @@ func (k *ExtentKey) MarshalBinary(v3 bool) ([]byte, error) {
 		if err := binary.Write(buf, binary.BigEndian, k.GetSeq()); err != nil {
 			return nil, err
 		}
-		if err := binary.Write(buf, binary.BigEndian, k.IsSplit()); err != nil {
+		var flags uint8
+		if k.IsSplit() {
+			flags |= extentKeyFlagSplit
+		}
+		if withCompress && k.CompressInfo != nil {
+			flags |= extentKeyFlagCompressed
+		}
+		if err := binary.Write(buf, binary.BigEndian, flags); err != nil {
 			return nil, err
 		}
+		if flags&extentKeyFlagCompressed != 0 {
+			if err := binary.Write(buf, binary.BigEndian, k.CompressInfo); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return buf.Bytes(), nil
 }
@@ -303,11 +379,17 @@ func (k *ExtentKey) UnmarshalBinary(buf *bytes.Buffer, v3 bool) (err error) {
 			return
 		}
 		k.SetSeq(seq)
-		var isSplit bool
-		if err = binary.Read(buf, binary.BigEndian, &isSplit); err != nil {
+		var flags uint8
+		if err = binary.Read(buf, binary.BigEndian, &flags); err != nil {
 			return
 		}
-		k.SetSplit(isSplit)
+		k.SetSplit(flags&extentKeyFlagSplit != 0)
+		if flags&extentKeyFlagCompressed != 0 {
+			k.CompressInfo = &ExtCompressInfo{}
+			if err = binary.Read(buf, binary.BigEndian, k.CompressInfo); err != nil {
+				return
+			}
+		}
 	}
 
 	return
@@ -315,7 +397,7 @@ func (k *ExtentKey) UnmarshalBinary(buf *bytes.Buffer, v3 bool) (err error) {
 
 func (k *ExtentKey) CheckSum(v3 bool) uint32 {
 	sign := crc32.NewIEEE()
-	buf, err := k.MarshalBinary(v3)
+	buf, err := k.marshalBinary(v3, false)
 	if err != nil {
 		log.LogErrorf("[ExtentKey] extentKey %v CRC32 error: %v", k, err)
 		return 0
@@ -0,0 +1,106 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"math"
+)
+
+// Types of the file lock, the values are the same as F_RDLCK, F_WRLCK and F_UNLCK of linux.
+const (
+	FileLockRead   uint32 = 0
+	FileLockWrite  uint32 = 1
+	FileLockUnlock uint32 = 2
+)
+
+const (
+	// FileLockMaxOffset is the end offset of a lock which reaches the end of the file.
+	FileLockMaxOffset uint64 = math.MaxInt64
+
+	// DefaultFileLockLease is the lease in seconds of the file locks held by a client,
+	// the locks are dropped by the meta partition if the client does not renew them in time.
+	DefaultFileLockLease int64 = 30
+)
+
+// FileLock is an advisory byte-range (posix) or whole file (flock) lock on an inode.
+// The lock holder is identified by the client and the lock owner within the client.
+type FileLock struct {
+	ClientID uint64 `json:"cid"`
+	Owner    uint64 `json:"owner"`
+	Pid      uint32 `json:"pid"`
+	Start    uint64 `json:"start"`
+	End      uint64 `json:"end"` // inclusive
+	Type     uint32 `json:"type"`
+	Flock    bool   `json:"flock"`
+	Expire   int64  `json:"expire"` // unix nano, set by the meta partition
+}
+
+func (l *FileLock) String() string {
+	if l == nil {
+		return ""
+	}
+	return fmt.Sprintf("FileLock{client(%v) owner(%v) pid(%v) range[%v,%v] type(%v) flock(%v) expire(%v)}",
+		l.ClientID, l.Owner, l.Pid, l.Start, l.End, l.Type, l.Flock, l.Expire)
+}
+
+// SameHolder returns true if both locks belong to the same owner of the same client.
+func (l *FileLock) SameHolder(o *FileLock) bool {
+	return l.ClientID == o.ClientID && l.Owner == o.Owner
+}
+
+// Overlap returns true if the ranges of both locks overlap.
+func (l *FileLock) Overlap(o *FileLock) bool {
+	return l.Start <= o.End && o.Start <= l.End
+}
+
+// Conflict returns true if the lock can not be held together with the other one.
+// Posix locks and flock locks do not interact with each other.
+func (l *FileLock) Conflict(o *FileLock) bool {
+	if l.Flock != o.Flock || l.SameHolder(o) || !l.Overlap(o) {
+		return false
+	}
+	return l.Type == FileLockWrite || o.Type == FileLockWrite
+}
+
+type SetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+	Lease       int64    `json:"lease"` // seconds
+}
+
+type SetLockResponse struct {
+	Conflict *FileLock `json:"conflict"`
+}
+
+type GetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionId uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+}
+
+type GetLockResponse struct {
+	Lock *FileLock `json:"lock"`
+}
+
+type RenewLockRequest struct {
+	VolName     string `json:"vol"`
+	PartitionId uint64 `json:"pid"`
+	ClientID    uint64 `json:"cid"`
+	Lease       int64  `json:"lease"` // seconds
+}
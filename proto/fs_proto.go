@@ -727,11 +727,32 @@ type DelVerRequest struct {
 	VerSeq      uint64 `json:"ver"`
 }
 
+// DelExtentKeyRequest defines the request to remove the extent keys within a range
+// of the file, the range becomes a hole and the file size is left unchanged.
 type DelExtentKeyRequest struct {
-	VolName     string      `json:"vol"`
-	PartitionID uint64      `json:"pid"`
-	Inode       uint64      `json:"ino"`
-	Extents     []ExtentKey `json:"ek"`
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Offset      uint64 `json:"off"`
+	Size        uint64 `json:"sz"`
+	Generation  uint64 `json:"gen,omitempty"` // the hole is punched only if the inode is of the generation if not 0
+}
+
+// CloneExtentsRequest defines the request to copy a range of the source file to the
+// destination file by sharing the extents, both inodes must be in the same partition.
+type CloneExtentsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	SrcInode    uint64 `json:"src"`
+	DstInode    uint64 `json:"dst"`
+	SrcOffset   uint64 `json:"soff"`
+	DstOffset   uint64 `json:"doff"`
+	Size        uint64 `json:"sz"`
+}
+
+// CloneExtentsResponse defines the response to the request of cloning extents.
+type CloneExtentsResponse struct {
+	Size uint64 `json:"sz"` // bytes cloned, less than requested if it reaches the end of the source
 }
 
 // SetAttrRequest defines the request to set attribute.
@@ -981,6 +1002,21 @@ type ListMasterQuotaResponse struct {
 	Quotas []*QuotaInfo
 }
 
+type SetOwnerQuotaRequest struct {
+	VolName     string `json:"vol"`
+	Type        uint8  `json:"type"`
+	Id          uint32 `json:"id"`
+	HardFiles   uint64 `json:"hf"`
+	HardBytes   uint64 `json:"hbyte"`
+	SoftFiles   uint64 `json:"sf"`
+	SoftBytes   uint64 `json:"sbyte"`
+	GracePeriod int64  `json:"grace"`
+}
+
+type OwnerQuotaReportResponse struct {
+	Quotas []*OwnerQuotaInfo
+}
+
 type BatchSetMetaserverQuotaReuqest struct {
 	PartitionId uint64   `json:"pid"`
 	Inodes      []uint64 `json:"ino"`
@@ -28,10 +28,13 @@ type LcConfiguration struct {
 }
 
 type Rule struct {
-	Expire *ExpirationConfig
-	Filter *FilterConfig
-	ID     string
-	Status string
+	Expire                         *ExpirationConfig
+	Transitions                    []*TransitionConfig
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUploadConfig
+	NoncurrentVersionExpiration    *NoncurrentVersionExpirationConfig
+	Filter                         *FilterConfig
+	ID                             string
+	Status                         string
 }
 
 type ExpirationConfig struct {
@@ -39,8 +42,75 @@ type ExpirationConfig struct {
 	Days int
 }
 
+// TransitionConfig moves the data of objects into the cold volume named by StorageClass.
+type TransitionConfig struct {
+	Date         *time.Time
+	Days         int
+	StorageClass string
+}
+
+// The xattrs set on a transitioned inode, the hot data of the inode is gone once
+// XAttrKeyTransitionState is TransitionStateReleasing or TransitionStateReleased.
+const (
+	XAttrKeyTransition       = "oss:transition"
+	XAttrKeyTransitionState  = "oss:transition-state"
+	TransitionStateReleasing = "releasing"
+	TransitionStateReleased  = "released"
+)
+
+type AbortIncompleteMultipartUploadConfig struct {
+	DaysAfterInitiation int
+}
+
+// NoncurrentVersionExpirationConfig removes the versions of objects which have been noncurrent
+// for NoncurrentDays, the current versions are only expired by ExpirationConfig.
+type NoncurrentVersionExpirationConfig struct {
+	NoncurrentDays int
+}
+
+// FilterConfig selects the objects which the rule applies to, all of the conditions must be met.
 type FilterConfig struct {
-	Prefix string
+	Prefix                string
+	Tags                  []*TagConfig
+	ObjectSizeGreaterThan int64
+	ObjectSizeLessThan    int64
+}
+
+type TagConfig struct {
+	Key   string
+	Value string
+}
+
+// HasDentryAction returns true if the rule needs to scan the objects of the volume.
+func (r *Rule) HasDentryAction() bool {
+	return r.Expire != nil || len(r.Transitions) > 0
+}
+
+// MatchSize checks the object size conditions of the filter.
+func (f *FilterConfig) MatchSize(size uint64) bool {
+	if f == nil {
+		return true
+	}
+	if f.ObjectSizeGreaterThan > 0 && size <= uint64(f.ObjectSizeGreaterThan) {
+		return false
+	}
+	if f.ObjectSizeLessThan > 0 && size >= uint64(f.ObjectSizeLessThan) {
+		return false
+	}
+	return true
+}
+
+// MatchTags checks whether the object tags contain all tags of the filter.
+func (f *FilterConfig) MatchTags(tags map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if value, ok := tags[tag.Key]; !ok || value != tag.Value {
+			return false
+		}
+	}
+	return true
 }
 
 const (
@@ -101,6 +171,10 @@ type LcNodeRuleTaskStatistics struct {
 	FileScannedNum       int64
 	DirScannedNum        int64
 	ExpiredNum           int64
+	TransitionedNum      int64
+	AbortedMultipartNum  int64
+	NoncurrentExpiredNum int64
+	LockedSkippedNum     int64
 	ErrorSkippedNum      int64
 }
 
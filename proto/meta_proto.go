@@ -14,7 +14,10 @@
 
 package proto
 
-import "sync"
+import (
+	"fmt"
+	"sync"
+)
 
 // CreateNameSpaceRequest defines the request to create a name space.
 type CreateNameSpaceRequest struct {
@@ -42,6 +45,40 @@ type CreateMetaPartitionRequest struct {
 	PartitionID uint64
 	Members     []Peer
 	VerSeq      uint64
+	StoreMode   MetaStoreMode
+}
+
+// MetaStoreMode defines where a meta partition keeps its inodes, dentries, extends and multiparts.
+type MetaStoreMode uint8
+
+const (
+	// MetaStoreModeMem keeps the items in memory and dumps them as snapshot files.
+	MetaStoreModeMem MetaStoreMode = iota
+	// MetaStoreModeRocksDB keeps the items in a rocksdb of the meta partition.
+	MetaStoreModeRocksDB
+)
+
+func (m MetaStoreMode) String() string {
+	switch m {
+	case MetaStoreModeMem:
+		return "mem"
+	case MetaStoreModeRocksDB:
+		return "rocksdb"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(m))
+	}
+}
+
+// ParseMetaStoreMode parses the meta store mode, an empty string means MetaStoreModeMem.
+func ParseMetaStoreMode(s string) (mode MetaStoreMode, err error) {
+	switch s {
+	case "", "mem":
+		return MetaStoreModeMem, nil
+	case "rocksdb":
+		return MetaStoreModeRocksDB, nil
+	default:
+		return MetaStoreModeMem, fmt.Errorf("invalid meta store mode %v, should be mem or rocksdb", s)
+	}
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
@@ -141,3 +178,117 @@ func (quotaInfo *QuotaInfo) IsOverQuotaBytes() (isOver bool) {
 	}
 	return
 }
+
+const (
+	OwnerQuotaTypeUser  uint8 = 1
+	OwnerQuotaTypeGroup uint8 = 2
+
+	DefaultOwnerQuotaGracePeriod int64 = 7 * 24 * 3600
+)
+
+func OwnerQuotaTypeString(typ uint8) string {
+	switch typ {
+	case OwnerQuotaTypeUser:
+		return "user"
+	case OwnerQuotaTypeGroup:
+		return "group"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseOwnerQuotaType(s string) (uint8, error) {
+	switch s {
+	case "user", "uid", "u":
+		return OwnerQuotaTypeUser, nil
+	case "group", "gid", "g":
+		return OwnerQuotaTypeGroup, nil
+	default:
+		return 0, fmt.Errorf("invalid owner quota type %v, should be user or group", s)
+	}
+}
+
+// OwnerQuotaKey identifies the quota of one uid or gid in a volume.
+type OwnerQuotaKey struct {
+	Type uint8
+	Id   uint32
+}
+
+// OwnerQuotaInfo is the quota of one uid or gid over the whole volume.
+// Hard limits are enforced at once, soft limits are enforced after being exceeded
+// for longer than the grace period. Zero means no limit.
+type OwnerQuotaInfo struct {
+	VolName     string
+	Type        uint8
+	Id          uint32
+	CTime       int64
+	HardFiles   uint64
+	HardBytes   uint64
+	SoftFiles   uint64
+	SoftBytes   uint64
+	GracePeriod int64 // seconds
+	// unix time when the soft limit is exceeded, zero if under the soft limit
+	FilesSoftTime int64
+	BytesSoftTime int64
+	UsedInfo      QuotaUsedInfo
+	LimitedInfo   QuotaLimitedInfo
+}
+
+type OwnerQuotaReportInfo struct {
+	Type     uint8
+	Id       uint32
+	UsedInfo QuotaUsedInfo
+}
+
+type OwnerQuotaHeartBeatInfo struct {
+	VolName     string
+	Type        uint8
+	Id          uint32
+	LimitedInfo QuotaLimitedInfo
+}
+
+func (info *OwnerQuotaInfo) Key() OwnerQuotaKey {
+	return OwnerQuotaKey{Type: info.Type, Id: info.Id}
+}
+
+// UpdateSoftTime records or clears the time the soft limits are exceeded,
+// returns true if any of them is changed.
+func (info *OwnerQuotaInfo) UpdateSoftTime(now int64) (changed bool) {
+	update := func(soft uint64, used int64, softTime *int64) {
+		over := soft > 0 && used > 0 && uint64(used) > soft
+		if over && *softTime == 0 {
+			*softTime = now
+			changed = true
+		} else if !over && *softTime != 0 {
+			*softTime = 0
+			changed = true
+		}
+	}
+	update(info.SoftFiles, info.UsedInfo.UsedFiles, &info.FilesSoftTime)
+	update(info.SoftBytes, info.UsedInfo.UsedBytes, &info.BytesSoftTime)
+	return
+}
+
+// UpdateLimited computes the limited info by the hard limits and expired soft limits.
+func (info *OwnerQuotaInfo) UpdateLimited(now int64) {
+	limited := func(hard, soft uint64, used, softTime int64) bool {
+		if used < 0 {
+			used = 0
+		}
+		if hard > 0 && uint64(used) >= hard {
+			return true
+		}
+		return soft > 0 && softTime > 0 && now >= softTime+info.GracePeriod
+	}
+	info.LimitedInfo.LimitedFiles = limited(info.HardFiles, info.SoftFiles, info.UsedInfo.UsedFiles, info.FilesSoftTime)
+	info.LimitedInfo.LimitedBytes = limited(info.HardBytes, info.SoftBytes, info.UsedInfo.UsedBytes, info.BytesSoftTime)
+}
+
+// GraceLeft returns the seconds left before the soft limit is enforced,
+// negative if it has been enforced, and false if the soft limit is not exceeded.
+func (info *OwnerQuotaInfo) GraceLeft(softTime, now int64) (int64, bool) {
+	if softTime == 0 {
+		return 0, false
+	}
+	return softTime + info.GracePeriod - now, true
+}
@@ -0,0 +1,57 @@
+package proto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerQuotaLimited(t *testing.T) {
+	info := &OwnerQuotaInfo{
+		Type:        OwnerQuotaTypeUser,
+		Id:          1,
+		HardFiles:   100,
+		SoftFiles:   10,
+		SoftBytes:   1000,
+		GracePeriod: 60,
+	}
+	now := int64(10000)
+
+	info.UsedInfo = QuotaUsedInfo{UsedFiles: 10, UsedBytes: 1000}
+	require.False(t, info.UpdateSoftTime(now))
+	info.UpdateLimited(now)
+	require.Equal(t, QuotaLimitedInfo{}, info.LimitedInfo)
+
+	// over soft limits, limited after the grace period
+	info.UsedInfo = QuotaUsedInfo{UsedFiles: 11, UsedBytes: 1001}
+	require.True(t, info.UpdateSoftTime(now))
+	require.Equal(t, now, info.FilesSoftTime)
+	require.Equal(t, now, info.BytesSoftTime)
+	require.False(t, info.UpdateSoftTime(now+30))
+	info.UpdateLimited(now + 59)
+	require.Equal(t, QuotaLimitedInfo{}, info.LimitedInfo)
+	left, exceeded := info.GraceLeft(info.FilesSoftTime, now+59)
+	require.True(t, exceeded)
+	require.Equal(t, int64(1), left)
+	info.UpdateLimited(now + 60)
+	require.Equal(t, QuotaLimitedInfo{LimitedFiles: true, LimitedBytes: true}, info.LimitedInfo)
+
+	// back under the soft limit of bytes
+	info.UsedInfo.UsedBytes = 500
+	require.True(t, info.UpdateSoftTime(now+70))
+	require.Equal(t, int64(0), info.BytesSoftTime)
+	info.UpdateLimited(now + 70)
+	require.Equal(t, QuotaLimitedInfo{LimitedFiles: true}, info.LimitedInfo)
+	_, exceeded = info.GraceLeft(info.BytesSoftTime, now+70)
+	require.False(t, exceeded)
+
+	// hard limit is enforced at once
+	info.SoftFiles = 0
+	info.UsedInfo.UsedFiles = 100
+	info.UpdateSoftTime(now + 80)
+	info.UpdateLimited(now + 80)
+	require.Equal(t, QuotaLimitedInfo{LimitedFiles: true}, info.LimitedInfo)
+	info.UsedInfo.UsedFiles = 99
+	info.UpdateLimited(now + 80)
+	require.Equal(t, QuotaLimitedInfo{}, info.LimitedInfo)
+}
@@ -34,6 +34,7 @@ type MetaNodeInfo struct {
 	IsActive                  bool
 	IsWriteAble               bool
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	MaxMemAvailWeight         uint64 `json:"MaxMemAvailWeight"`
 	Total                     uint64 `json:"TotalWeight"`
 	Used                      uint64 `json:"UsedWeight"`
@@ -55,6 +56,7 @@ type DataNodeInfo struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	Addr                      string
 	DomainAddr                string
 	ReportTime                time.Time
@@ -71,6 +73,8 @@ type DataNodeInfo struct {
 	MaxDpCntLimit             uint32             `json:"maxDpCntLimit"`
 	CpuUtil                   float64            `json:"cpuUtil"`
 	IoUtils                   map[string]float64 `json:"ioUtil"`
+	ScrubStats                []DiskScrubStat
+	CorruptExtents            []*ScrubCorruptExtent
 }
 
 // MetaPartition defines the structure of a meta partition
@@ -467,3 +471,40 @@ type DecommissionDataPartitionInfo struct {
 	ErrorMessage      string
 	NeedRollbackTimes uint32
 }
+
+// DpBalanceConfig defines the config of the data partition balancer in master.
+type DpBalanceConfig struct {
+	Enable        bool
+	Threshold     float64 // the data nodes whose usage ratio exceeds the average of the node set by the threshold are balanced
+	MaxMigrations int     // the max count of the data partitions migrated by the balancer at the same time
+}
+
+type DpBalanceMigration struct {
+	PartitionID uint64
+	VolName     string
+	Size        uint64
+	ZoneName    string
+	NodeSetID   uint64
+	SrcAddr     string
+	SrcDisk     string
+	DstAddr     string
+}
+
+type DpBalanceNodeStat struct {
+	Addr              string
+	ZoneName          string
+	NodeSetID         uint64
+	Total             uint64
+	Used              uint64
+	UsageRatio        float64
+	PlannedUsageRatio float64
+}
+
+// DpBalancePlan defines the migrations planned by the data partition balancer,
+// Running is the migrations started by the balancer and still in recovering.
+type DpBalancePlan struct {
+	Config     DpBalanceConfig
+	Running    []*DpBalanceMigration
+	Migrations []*DpBalanceMigration
+	Nodes      []*DpBalanceNodeStat
+}
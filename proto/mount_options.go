@@ -49,6 +49,7 @@ const (
 	EnableSummary
 	EnableUnixPermission
 	RequestTimeout
+	EnableFileLock
 
 	// adls
 	VolType
@@ -76,6 +77,11 @@ const (
 	SnapshotReadVerSeq
 
 	DisableMountSubtype
+
+	// readahead
+	ReadAheadMemMB
+	ReadAheadWindowMB
+
 	MaxMountOption
 )
 
@@ -143,6 +149,7 @@ func InitMountOptions(opts []MountOption) {
 	opts[EnablePosixACL] = MountOption{"enablePosixACL", "Enable posix ACL support", "", false}
 	opts[EnableSummary] = MountOption{"enableSummary", "Enable content summary", "", false}
 	opts[EnableUnixPermission] = MountOption{"enableUnixPermission", "Enable unix permission check(e.g: 777/755)", "", false}
+	opts[EnableFileLock] = MountOption{"enableFileLock", "Enable posix and flock locks shared by all the clients", "", false}
 
 	opts[VolType] = MountOption{"volType", "volume type", "", int64(0)}
 	opts[EbsEndpoint] = MountOption{"ebsEndpoint", "Ebs service address", "", ""}
@@ -170,6 +177,8 @@ func InitMountOptions(opts []MountOption) {
 	opts[FileSystemName] = MountOption{"fileSystemName", "The explicit name of the filesystem", "", ""}
 	opts[SnapshotReadVerSeq] = MountOption{"snapshotReadSeq", "Snapshot read seq", "", int64(0)} // default false
 	opts[DisableMountSubtype] = MountOption{"disableMountSubtype", "Disable Mount Subtype", "", false}
+	opts[ReadAheadMemMB] = MountOption{"readAheadMemMB", "Memory of the client readahead in MB, 0 disables it", "", int64(0)}
+	opts[ReadAheadWindowMB] = MountOption{"readAheadWindowMB", "Max readahead window of a sequential reader in MB", "", int64(16)}
 
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
@@ -300,6 +309,7 @@ type MountOptions struct {
 	EnableXattr                  bool
 	NearRead                     bool
 	EnablePosixACL               bool
+	EnableFileLock               bool
 	EnableQuota                  bool
 	EnableTransaction            string
 	TxTimeout                    int64
@@ -331,4 +341,6 @@ type MountOptions struct {
 	VerReadSeq                   uint64
 	// disable mount subtype
 	DisableMountSubtype bool
+	ReadAheadMemMB      int64
+	ReadAheadWindowMB   int64
 }
@@ -71,6 +71,7 @@ const (
 	OpGetMaxExtentIDAndPartitionSize uint8 = 0x16
 	OpSnapshotExtentRepairRead       uint8 = 0x17
 	OpSnapshotExtentRepairRsp        uint8 = 0x18
+	OpGetExtentBlockCrc              uint8 = 0x19
 
 	// Operations: Client -> MetaNode.
 	OpMetaCreateInode   uint8 = 0x20
@@ -104,6 +105,8 @@ const (
 	OpMetaBatchGetXAttr      uint8 = 0x39
 	OpMetaExtentAddWithCheck uint8 = 0x3A // Append extent key with discard extents check
 	OpMetaReadDirLimit       uint8 = 0x3D
+	OpMetaSetLock            uint8 = 0x3E // acquire or release a posix/flock file lock
+	OpMetaGetLock            uint8 = 0x3F // query the lock which conflicts with the given one
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -223,6 +226,8 @@ const (
 
 	OpMetaBatchSetXAttr uint8 = 0xD2
 	OpMetaGetAllXAttr   uint8 = 0xD3
+	OpMetaRenewLock     uint8 = 0xD4 // renew the lease of all file locks held by a client
+	OpMetaCloneExtents  uint8 = 0xD8 // share the extents of a range with another inode
 
 	// transaction error
 
@@ -408,6 +413,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpNotifyReplicasToRepair"
 	case OpExtentRepairRead:
 		m = "OpExtentRepairRead"
+	case OpGetExtentBlockCrc:
+		m = "OpGetExtentBlockCrc"
 	case OpConflictExtentsErr:
 		m = "ConflictExtentsErr"
 	case OpIntraGroupNetErr:
@@ -550,6 +557,14 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaBatchGetXAttr"
 	case OpMetaUpdateXAttr:
 		m = "OpMetaUpdateXAttr"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaRenewLock:
+		m = "OpMetaRenewLock"
+	case OpMetaCloneExtents:
+		m = "OpMetaCloneExtents"
 	case OpCreateMultipart:
 		m = "OpCreateMultipart"
 	case OpGetMultipart:
@@ -129,12 +129,12 @@ const (
 	OSSListObjectVersionsAction  Action = OSSActionPrefix + "ListObjectVersions"  // unsupported
 
 	// Object legal hold actions
-	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold" // unsupported
-	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold" // unsupported
+	OSSGetObjectLegalHoldAction Action = OSSActionPrefix + "GetObjectLegalHold"
+	OSSPutObjectLegalHoldAction Action = OSSActionPrefix + "PutObjectLegalHold"
 
 	// Object retention actions
-	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention" // unsupported
-	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention" // unsupported
+	OSSGetObjectRetentionAction Action = OSSActionPrefix + "GetObjectRetention"
+	OSSPutObjectRetentionAction Action = OSSActionPrefix + "PutObjectRetention"
 
 	// Bucket encryption actions
 	OSSGetBucketEncryptionAction    Action = OSSActionPrefix + "GetBucketEncryption"    // unsupported
@@ -149,6 +149,9 @@ const (
 	// Object restore actions
 	OSSRestoreObjectAction Action = OSSActionPrefix + "RestoreObject" // unsupported
 
+	// Object select actions
+	OSSSelectObjectContentAction Action = OSSActionPrefix + "SelectObjectContent"
+
 	// Public access block actions
 	OSSGetPublicAccessBlockAction    Action = OSSActionPrefix + "GetPublicAccessBlock"   // unsupported
 	OSSPutPublicAccessBlockAction    Action = OSSActionPrefix + "PutPublicAccessBlock"   // unsupported
@@ -163,6 +166,10 @@ const (
 	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
 	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
 
+	// Bucket notification actions
+	OSSGetBucketNotificationAction Action = OSSActionPrefix + "GetBucketNotification"
+	OSSPutBucketNotificationAction Action = OSSActionPrefix + "PutBucketNotification"
+
 	// STS actions
 	OSSGetFederationTokenAction Action = OSSActionPrefix + "GetFederationToken"
 
@@ -241,6 +248,7 @@ var AllActions = []Action{
 	OSSPutBucketWebsiteAction,
 	OSSDeleteBucketWebsiteAction,
 	OSSRestoreObjectAction,
+	OSSSelectObjectContentAction,
 	OSSGetPublicAccessBlockAction,
 	OSSPutPublicAccessBlockAction,
 	OSSDeletePublicAccessBlockAction,
@@ -249,6 +257,8 @@ var AllActions = []Action{
 	OSSGetBucketReplicationAction,
 	OSSPutBucketReplicationAction,
 	OSSDeleteBucketReplicationAction,
+	OSSGetBucketNotificationAction,
+	OSSPutBucketNotificationAction,
 	OSSOptionsObjectAction,
 	OSSGetFederationTokenAction,
 
@@ -403,6 +413,7 @@ var builtinPermissionActionsMap = map[Permission]Actions{
 	BuiltinPermissionReadOnly: {
 		// Object storage interface actions
 		OSSGetObjectAction,
+		OSSSelectObjectContentAction,
 		OSSListObjectsAction,
 		OSSHeadObjectAction,
 		OSSHeadBucketAction,
@@ -422,6 +433,7 @@ var builtinPermissionActionsMap = map[Permission]Actions{
 	BuiltinPermissionWritable: {
 		// Object storage interface actions
 		OSSGetObjectAction,
+		OSSSelectObjectContentAction,
 		OSSPutObjectAction,
 		OSSCopyObjectAction,
 		OSSListObjectsAction,
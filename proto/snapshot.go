@@ -1,6 +1,9 @@
 package proto
 
 import (
+	"fmt"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -14,6 +17,9 @@ type SnapshotVerDelTask struct {
 	Id             string
 	VolName        string
 	VolVersionInfo *VolVersionInfo
+	// only delete the version under the directory for the retention rule, the whole volume if empty
+	Prefix string `json:",omitempty"`
+	RuleID string `json:",omitempty"`
 }
 
 type SnapshotVerDelTaskResponse struct {
@@ -37,3 +43,110 @@ type SnapshotStatistics struct {
 	DirNum          int64
 	ErrorSkippedNum int64
 }
+
+const (
+	MaxSnapshotRetentionRules = 100
+	// the max number of versions a retention rule may keep
+	MaxSnapshotRetentionVers = 512
+)
+
+// SnapshotRetentionTier keeps the oldest version of every Interval seconds among the versions
+// created in the last Duration seconds, e.g. hourly versions for two days.
+type SnapshotRetentionTier struct {
+	Interval int64
+	Duration int64
+}
+
+// SnapshotRetentionRule is the retention policy of the snapshot versions of the whole volume, or of a directory of it.
+// A version is kept if any tier of the rule keeps it.
+type SnapshotRetentionRule struct {
+	ID     string
+	Prefix string // the directory path the rule applies to, empty for the whole volume
+	Tiers  []SnapshotRetentionTier
+	// versions whose data under the prefix are already deleted, maintained by the master
+	ExpiredVers []uint64 `json:",omitempty"`
+}
+
+type SnapshotRetentionConfig struct {
+	VolName string
+	Rules   []*SnapshotRetentionRule
+}
+
+// VerTime returns the creation time of the version, since the version sequence is the creation time in microseconds.
+func VerTime(ver uint64) time.Time {
+	return time.UnixMicro(int64(ver))
+}
+
+// Retains returns the versions kept by the rule at the time now, vers should be sorted in ascending order.
+func (r *SnapshotRetentionRule) Retains(vers []uint64, now time.Time) map[uint64]bool {
+	retained := make(map[uint64]bool)
+	for _, tier := range r.Tiers {
+		lastBucket := int64(-1)
+		for _, ver := range vers {
+			verTime := VerTime(ver)
+			if now.Sub(verTime) > time.Duration(tier.Duration)*time.Second {
+				continue
+			}
+			if bucket := verTime.Unix() / tier.Interval; bucket != lastBucket {
+				retained[ver] = true
+				lastBucket = bucket
+			}
+		}
+	}
+	return retained
+}
+
+// Covers returns whether the directory of the rule contains the directory of other.
+func (r *SnapshotRetentionRule) Covers(other *SnapshotRetentionRule) bool {
+	return r.Prefix == "" || other.Prefix == r.Prefix || strings.HasPrefix(other.Prefix, r.Prefix+"/")
+}
+
+func (r *SnapshotRetentionRule) IsExpired(ver uint64) bool {
+	for _, v := range r.ExpiredVers {
+		if v == ver {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the rules and normalizes the prefixes to the form of "a/b".
+func (c *SnapshotRetentionConfig) Validate() error {
+	if len(c.Rules) > MaxSnapshotRetentionRules {
+		return fmt.Errorf("too many rules, limit %v", MaxSnapshotRetentionRules)
+	}
+	ids := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	for _, r := range c.Rules {
+		if r == nil {
+			return fmt.Errorf("empty rule")
+		}
+		if r.ID == "" || ids[r.ID] {
+			return fmt.Errorf("rule id %q is empty or duplicated", r.ID)
+		}
+		ids[r.ID] = true
+
+		prefix := strings.Trim(path.Clean("/"+r.Prefix), "/")
+		if prefixes[prefix] {
+			return fmt.Errorf("rule %v: prefix %q is duplicated", r.ID, r.Prefix)
+		}
+		prefixes[prefix] = true
+		r.Prefix = prefix
+
+		if len(r.Tiers) == 0 {
+			return fmt.Errorf("rule %v: no tiers", r.ID)
+		}
+		var total int64
+		for _, tier := range r.Tiers {
+			if tier.Interval <= 0 || tier.Duration < tier.Interval {
+				return fmt.Errorf("rule %v: tier interval %v duration %v invalid", r.ID, tier.Interval, tier.Duration)
+			}
+			total += tier.Duration/tier.Interval + 1
+		}
+		if total > MaxSnapshotRetentionVers {
+			return fmt.Errorf("rule %v: keeps %v versions at most, limit %v", r.ID, total, MaxSnapshotRetentionVers)
+		}
+		r.ExpiredVers = nil
+	}
+	return nil
+}
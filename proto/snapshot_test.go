@@ -0,0 +1,78 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRetentionRetains(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	ver := func(d time.Duration) uint64 {
+		return uint64(now.Add(-d).UnixMicro())
+	}
+	// versions every 30 minutes for 3 days
+	var vers []uint64
+	for d := 72 * time.Hour; d > 0; d -= 30 * time.Minute {
+		vers = append(vers, ver(d))
+	}
+
+	rule := &SnapshotRetentionRule{
+		ID: "hourly",
+		Tiers: []SnapshotRetentionTier{
+			{Interval: 3600, Duration: 24 * 3600},
+		},
+	}
+	retained := rule.Retains(vers, now)
+	require.Len(t, retained, 24)
+	require.True(t, retained[ver(24*time.Hour)])
+	require.False(t, retained[ver(24*time.Hour-30*time.Minute)])
+	require.False(t, retained[ver(25*time.Hour)])
+
+	rule.Tiers = append(rule.Tiers, SnapshotRetentionTier{Interval: 24 * 3600, Duration: 30 * 24 * 3600})
+	retained = rule.Retains(vers, now)
+	// the oldest version of each of the 4 days is kept by the daily tier
+	require.True(t, retained[vers[0]])
+	require.True(t, retained[ver(60*time.Hour)])
+	require.False(t, retained[ver(61*time.Hour)])
+}
+
+func TestSnapshotRetentionValidate(t *testing.T) {
+	tiers := []SnapshotRetentionTier{{Interval: 3600, Duration: 7200}}
+	conf := &SnapshotRetentionConfig{Rules: []*SnapshotRetentionRule{
+		{ID: "a", Prefix: "/a/b/", Tiers: tiers, ExpiredVers: []uint64{1}},
+		{ID: "root", Prefix: "/", Tiers: tiers},
+	}}
+	require.NoError(t, conf.Validate())
+	require.Equal(t, "a/b", conf.Rules[0].Prefix)
+	require.Equal(t, "", conf.Rules[1].Prefix)
+	require.Nil(t, conf.Rules[0].ExpiredVers)
+	require.True(t, conf.Rules[1].Covers(conf.Rules[0]))
+	require.False(t, conf.Rules[0].Covers(&SnapshotRetentionRule{Prefix: "a/bc"}))
+	require.True(t, conf.Rules[0].Covers(&SnapshotRetentionRule{Prefix: "a/b/c"}))
+
+	for _, rules := range [][]*SnapshotRetentionRule{
+		{{ID: "a", Tiers: tiers}, {ID: "a", Prefix: "b", Tiers: tiers}},
+		{{ID: "a", Prefix: "b", Tiers: tiers}, {ID: "c", Prefix: "/b", Tiers: tiers}},
+		{{ID: "a"}},
+		{{ID: "a", Tiers: []SnapshotRetentionTier{{Interval: 3600, Duration: 60}}}},
+		{{ID: "a", Tiers: []SnapshotRetentionTier{{Interval: 1, Duration: 3600}}}},
+	} {
+		require.Error(t, (&SnapshotRetentionConfig{Rules: rules}).Validate())
+	}
+}
@@ -166,6 +166,16 @@ func (rs *RocksDBStore) Get(key interface{}) (result interface{}, err error) {
 	return rs.db.GetBytes(ro, []byte(key.(string)))
 }
 
+// GetWithSnapshot returns the value of the given key in the snapshot, the latest value is returned if the snapshot is nil.
+func (rs *RocksDBStore) GetWithSnapshot(key []byte, snapshot *gorocksdb.Snapshot) ([]byte, error) {
+	ro := gorocksdb.NewDefaultReadOptions()
+	if snapshot != nil {
+		ro.SetSnapshot(snapshot)
+	}
+	defer ro.Destroy()
+	return rs.db.GetBytes(ro, key)
+}
+
 // DeleteKeyAndPutIndex deletes the key-value pair based on the given key and put other keys in the cmdMap to RocksDB.
 // TODO explain
 func (rs *RocksDBStore) DeleteKeyAndPutIndex(key string, cmdMap map[string][]byte, isSync bool) error {
@@ -300,3 +310,49 @@ func (rs *RocksDBStore) Clear() (err error) {
 	err = rs.db.Write(wo, wb)
 	return
 }
+
+// IngestSstFiles moves the sst files into the db, the keys of the files must not overlap.
+func (rs *RocksDBStore) IngestSstFiles(paths []string) error {
+	opts := gorocksdb.NewDefaultIngestExternalFileOptions()
+	defer opts.Destroy()
+	opts.SetMoveFiles(true)
+	return rs.db.IngestExternalFile(paths, opts)
+}
+
+// SstFileWriter writes the key-value pairs in ascending order of the keys to an sst file,
+// which can be ingested by RocksDBStore.IngestSstFiles.
+type SstFileWriter struct {
+	envOpts *gorocksdb.EnvOptions
+	opts    *gorocksdb.Options
+	writer  *gorocksdb.SSTFileWriter
+}
+
+// NewSstFileWriter creates the sst file at path.
+func NewSstFileWriter(path string) (w *SstFileWriter, err error) {
+	w = &SstFileWriter{
+		envOpts: gorocksdb.NewDefaultEnvOptions(),
+		opts:    gorocksdb.NewDefaultOptions(),
+	}
+	w.opts.SetCompression(gorocksdb.NoCompression)
+	w.writer = gorocksdb.NewSSTFileWriter(w.envOpts, w.opts)
+	if err = w.writer.Open(path); err != nil {
+		w.Destroy()
+		return nil, err
+	}
+	return
+}
+
+func (w *SstFileWriter) Add(key, value []byte) error {
+	return w.writer.Add(key, value)
+}
+
+// Finish completes the sst file, it fails if no key-value pair has been added.
+func (w *SstFileWriter) Finish() error {
+	return w.writer.Finish()
+}
+
+func (w *SstFileWriter) Destroy() {
+	w.writer.Destroy()
+	w.opts.Destroy()
+	w.envOpts.Destroy()
+}
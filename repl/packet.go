@@ -233,6 +233,18 @@ func NewPacketToGetAllWatermarks(partitionID uint64, extentType uint8) (p *Packe
 	return
 }
 
+func NewPacketToGetExtentBlockCrc(partitionID uint64, extentID uint64) (p *Packet) {
+	p = new(Packet)
+	p.Opcode = proto.OpGetExtentBlockCrc
+	p.PartitionID = partitionID
+	p.ExtentID = extentID
+	p.Magic = proto.ProtoMagic
+	p.ReqID = proto.GenerateRequestID()
+	p.ExtentType = proto.NormalExtentType
+
+	return
+}
+
 func NewPacketToReadTinyDeleteRecord(partitionID uint64, offset int64) (p *Packet) {
 	p = new(Packet)
 	p.Opcode = proto.OpReadTinyDeleteRecord
@@ -169,7 +169,7 @@ func (cache *ExtentCache) SplitExtentKey(inodeID uint64, ekPivot *proto.ExtentKe
 	if ek.FileOffset == ekPivot.FileOffset {
 		ek.Size = ek.Size - ekPivot.Size
 		ek.FileOffset = ek.FileOffset + uint64(ekPivot.Size)
-		ek.ExtentOffset = ek.ExtentOffset + uint64(ekPivot.Size)
+		ek.AddExtentOffset(uint64(ekPivot.Size))
 		if ekLeft != nil && ekLeft.IsSequenceWithSameSeq(ekPivot) {
 			log.LogDebugf("SplitExtentKey.merge.begin. ekLeft %v and %v", ekLeft, ekPivot)
 			ekLeft.Size += ekPivot.Size
@@ -200,13 +200,15 @@ func (cache *ExtentCache) SplitExtentKey(inodeID uint64, ekPivot *proto.ExtentKe
 			FileOffset:   ekPivot.FileOffset + uint64(ekPivot.Size),
 			PartitionId:  ek.PartitionId,
 			ExtentId:     ek.ExtentId,
-			ExtentOffset: ek.ExtentOffset + uint64(newSize+ekPivot.Size),
+			ExtentOffset: ek.ExtentOffset,
 			Size:         ek.Size - newSize - ekPivot.Size,
 			SnapInfo: &proto.ExtSnapInfo{
 				VerSeq: ek.GetSeq(),
 				ModGen: ek.GetModGen(),
 			},
+			CompressInfo: ek.CompressInfo,
 		}
+		ekEnd.AddExtentOffset(uint64(newSize + ekPivot.Size))
 		log.LogDebugf("action[SplitExtentKey] inode %v add ekEnd [%v] after split size(%v,%v,%v)", inodeID, ekEnd, newSize, ekPivot.Size, ekEnd.Size)
 		cache.root.ReplaceOrInsert(ekEnd)
 		log.LogDebugf("ExtentCache ReplaceOrInsert: ino(%v) ek(%v) ", cache.inode, ekEnd)
@@ -254,6 +256,14 @@ func (cache *ExtentCache) Append(ek *proto.ExtentKey, sync bool) (discardExtents
 	for _, key := range discard {
 		cache.root.Delete(key)
 		log.LogDebugf("ExtentCache del: ino(%v) ek(%v) ", cache.inode, key)
+		if key.PartitionId == 0 && key.ExtentId == 0 && key.FileOffset+uint64(key.Size) > ekEnd {
+			// keep the rest of the temp key, whose data is still on the way, e.g. the
+			// key of a compressed frame only covers the front of the temp key.
+			rest := &proto.ExtentKey{FileOffset: ekEnd, Size: uint32(key.FileOffset + uint64(key.Size) - ekEnd)}
+			rest.SetSeq(key.GetSeq())
+			cache.root.ReplaceOrInsert(rest)
+			continue
+		}
 		if key.PartitionId != 0 && key.ExtentId != 0 && (key.PartitionId != ek.PartitionId || key.ExtentId != ek.ExtentId || ek.ExtentOffset != key.ExtentOffset) {
 			if sync || (ek.PartitionId == 0 && ek.ExtentId == 0) {
 				cache.discard.ReplaceOrInsert(key)
@@ -336,6 +346,13 @@ func (cache *ExtentCache) SetSize(size uint64, sync bool) {
 	}
 }
 
+// Len returns the number of the extents in the cache.
+func (cache *ExtentCache) Len() int {
+	cache.RLock()
+	defer cache.RUnlock()
+	return cache.root.Len()
+}
+
 // List returns a list of the extents in the cache.
 func (cache *ExtentCache) List() []*proto.ExtentKey {
 	cache.RLock()
@@ -386,7 +403,7 @@ func (cache *ExtentCache) GetEndForAppendWrite(offset uint64, verSeq uint64, nee
 
 		if offset == ek.FileOffset+uint64(ek.Size) {
 			if !needCheck || ek.GetSeq() == verSeq {
-				if int(ek.ExtentOffset)+int(ek.Size) >= util.ExtentSize {
+				if int(ek.ExtentOffset)+int(ek.PhysicalSize()) >= util.ExtentSize {
 					log.LogDebugf("action[ExtentCache.GetEndForAppendWrite] inode %v req offset %v verseq %v not found, exist ek [%v]",
 						cache.inode, offset, verSeq, ek.String())
 					ret = nil
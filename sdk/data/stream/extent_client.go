@@ -46,6 +46,7 @@ type (
 	LoadBcacheFunc      func(key string, buf []byte, offset uint64, size uint32) (int, error)
 	CacheBcacheFunc     func(key string, buf []byte) error
 	EvictBacheFunc      func(key string) error
+	CheckReleasedFunc   func(inode uint64) (bool, error)
 )
 
 const (
@@ -118,9 +119,18 @@ type ExtentConfig struct {
 	OnLoadBcache      LoadBcacheFunc
 	OnCacheBcache     CacheBcacheFunc
 	OnEvictBcache     EvictBacheFunc
+	// reports whether the data of an inode is released by lifecycle transition, may be nil
+	OnCheckReleased CheckReleasedFunc
 
 	DisableMetaCache             bool
 	MinWriteAbleDataPartitionCnt int
+
+	// memory of the prefetched blocks of the sequential readers, readahead is disabled if not set
+	ReadAheadMemMB int64
+	// max bytes to prefetch ahead of a sequential reader
+	ReadAheadWindowMB int64
+	// shared by several extent clients, ReadAheadMemMB and ReadAheadWindowMB are ignored if set
+	ReadAheadPool *ReadAheadPool
 }
 
 type MultiVerMgr struct {
@@ -155,9 +165,11 @@ type ExtentClient struct {
 	loadBcache         LoadBcacheFunc
 	cacheBcache        CacheBcacheFunc
 	evictBcache        EvictBacheFunc
+	checkReleased      CheckReleasedFunc // May be null, must check before using
 	inflightL1cache    sync.Map
 	inflightL1BigBlock int32
 	multiVerMgr        *MultiVerMgr
+	readAheadPool      *ReadAheadPool
 }
 
 func (client *ExtentClient) UidIsLimited(uid uint32) bool {
@@ -261,6 +273,7 @@ retry:
 	client.getExtents = config.OnGetExtents
 	client.truncate = config.OnTruncate
 	client.evictIcache = config.OnEvictIcache
+	client.checkReleased = config.OnCheckReleased
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
 	client.loadBcache = config.OnLoadBcache
@@ -274,6 +287,10 @@ retry:
 	client.BcacheHealth = true
 	client.preload = config.Preload
 	client.disableMetaCache = config.DisableMetaCache
+	client.readAheadPool = config.ReadAheadPool
+	if client.readAheadPool == nil {
+		client.readAheadPool = NewReadAheadPool(config.ReadAheadMemMB, config.ReadAheadWindowMB)
+	}
 
 	var readLimit, writeLimit rate.Limit
 	if config.ReadRate <= 0 {
@@ -585,6 +602,18 @@ func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int
 		return
 	}
 
+	if err = s.checkReleased(); err != nil {
+		log.LogWarnf("Read: ino(%v) offset(%v) size(%v) err(%v)", inode, offset, size, err)
+		return
+	}
+
+	if s.readAhead != nil {
+		var hit bool
+		if read, hit = s.readAhead.read(data, offset, size); hit {
+			return
+		}
+	}
+
 	read, err = s.read(data, offset, size)
 	// log.LogErrorf("======> ExtentClient Read Exit, inode(%v), time[%v us].", inode, time.Since(t1).Microseconds())
 	return
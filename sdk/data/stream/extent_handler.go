@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/sdk/data/wrapper"
 	"github.com/cubefs/cubefs/sdk/meta"
 	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
@@ -54,6 +55,11 @@ type ExtentHandler struct {
 	fileOffset int
 	storeMode  int
 
+	// The codec to compress the data with, empty if the volume is not compressed.
+	// Each packet is compressed into a frame in flushPacket, and the frames are
+	// kept by extent keys of their own.
+	codec string
+
 	// Either open/closed/recovery/error.
 	// Can transit from one state to the next adjacent state ONLY.
 	status int32
@@ -73,6 +79,9 @@ type ExtentHandler struct {
 	// Will not be changed once assigned.
 	extID int
 
+	// Updated in the sender ONLY, the extent offset to write the next frame to.
+	frameOffset int
+
 	// Allocated in the sender, and released in the receiver.
 	// Will not be changed.
 	conn *net.TCPConn
@@ -88,6 +97,10 @@ type ExtentHandler struct {
 	key   *proto.ExtentKey
 	dirty bool // indicate if open handler is dirty.
 
+	// Created in receiver ONLY, the keys of the frames done before *key*,
+	// which are not appended yet.
+	frames []*proto.ExtentKey
+
 	// Created in receiver ONLY in recovery status.
 	// Will not be changed once assigned.
 	recoverHandler *ExtentHandler
@@ -129,6 +142,9 @@ func NewExtentHandler(stream *Streamer, offset int, storeMode int, size int) *Ex
 		doneSender:   make(chan struct{}),
 		doneReceiver: make(chan struct{}),
 	}
+	if storeMode == proto.NormalExtentType && proto.IsHot(stream.client.volumeType) {
+		eh.codec = stream.client.dataWrapper.CompressCodec()
+	}
 
 	go eh.receiver()
 	go eh.sender()
@@ -199,6 +215,11 @@ func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool) (ek *
 		FileOffset: uint64(eh.fileOffset),
 		Size:       uint32(eh.size),
 	}
+	if eh.codec != "" {
+		// the frames appended already must not be covered by the temp key
+		ek.FileOffset = uint64(offset)
+		ek.Size = uint32(size)
+	}
 	return ek, nil
 }
 
@@ -236,9 +257,16 @@ func (eh *ExtentHandler) sender() {
 			// For ExtentStore, calculate the extent offset.
 			// For TinyStore, the extent offset is always 0 in the request packet,
 			// and the reply packet tells the real extent offset.
-			extOffset := int(packet.KernelOffset) - eh.fileOffset
-			if eh.key != nil {
-				extOffset += int(eh.key.ExtentOffset)
+			var extOffset int
+			if packet.rawSize != 0 {
+				// the frames are written one after another
+				extOffset = eh.frameOffset
+				eh.frameOffset += int(packet.Size)
+			} else {
+				extOffset = int(packet.KernelOffset) - eh.fileOffset
+				if eh.key != nil {
+					extOffset += int(eh.key.ExtentOffset)
+				}
 			}
 
 			// fill the packet according to the extent
@@ -362,7 +390,27 @@ func (eh *ExtentHandler) processReply(packet *Packet) {
 	if verUpdate {
 		fileOffset = reply.KernelOffset
 	}
-	if eh.key == nil || verUpdate {
+	if packet.rawSize != 0 {
+		if eh.key != nil && eh.dirty {
+			eh.frames = append(eh.frames, eh.key)
+		}
+		eh.key = &proto.ExtentKey{
+			FileOffset:   packet.KernelOffset,
+			PartitionId:  packet.PartitionID,
+			ExtentId:     extID,
+			ExtentOffset: uint64(packet.ExtentOffset),
+			Size:         packet.rawSize,
+			SnapInfo: &proto.ExtSnapInfo{
+				VerSeq: reply.VerSeq,
+			},
+			// the frame kept as it is has the compress info too, so that all the
+			// frames sharing the extent are counted by the meta node
+			CompressInfo: &proto.ExtCompressInfo{
+				Codec:     packet.codec,
+				FrameSize: packet.Size,
+			},
+		}
+	} else if eh.key == nil || verUpdate {
 		eh.key = &proto.ExtentKey{
 			FileOffset:   fileOffset,
 			PartitionId:  packet.PartitionID,
@@ -431,6 +479,12 @@ func (eh *ExtentHandler) appendExtentKey() (err error) {
 	eh.appendLK.Lock()
 	defer eh.appendLK.Unlock()
 
+	if err = eh.appendFrames(); err != nil {
+		log.LogErrorf("action[appendExtentKey] %v append frames err %v", eh, err)
+		eh.lastKey.PartitionId = 0
+		return
+	}
+
 	if eh.key != nil {
 		if eh.dirty {
 			if proto.IsCold(eh.stream.client.volumeType) && eh.status == ExtentStatusError {
@@ -477,7 +531,7 @@ func (eh *ExtentHandler) appendExtentKey() (err error) {
 					eh.lastKey.ExtentOffset == ekey.ExtentOffset &&
 					eh.lastKey.Size < ekey.Size {
 					ekey.FileOffset += uint64(eh.lastKey.Size)
-					ekey.ExtentOffset += uint64(eh.lastKey.Size)
+					ekey.AddExtentOffset(uint64(eh.lastKey.Size))
 					ekey.Size -= eh.lastKey.Size
 					ekey.SetSeq(eh.stream.verSeq)
 					eh.lastKey = ekey
@@ -509,6 +563,23 @@ func (eh *ExtentHandler) appendExtentKey() (err error) {
 	return
 }
 
+// appendFrames appends the keys of the frames done before the current key in order.
+// The caller holds appendLK.
+func (eh *ExtentHandler) appendFrames() (err error) {
+	for len(eh.frames) > 0 {
+		frame := eh.frames[0]
+		discard := eh.stream.extents.Append(frame, true)
+		if _, err = eh.stream.client.appendExtentKey(eh.stream.parentInode, eh.inode, *frame, discard); err != nil {
+			return
+		}
+		if len(discard) > 0 {
+			eh.stream.extents.RemoveDiscard(discard)
+		}
+		eh.frames = eh.frames[1:]
+	}
+	return
+}
+
 // This function is meaningful to be called from stream writer flush method,
 // because there is no new write request.
 func (eh *ExtentHandler) waitForFlush() {
@@ -534,6 +605,8 @@ func (eh *ExtentHandler) recoverPacket(packet *Packet) error {
 		// Because tiny extent files are limited, tiny store
 		// failures might due to lack of tiny extent file.
 		handler = NewExtentHandler(eh.stream, int(packet.KernelOffset), proto.NormalExtentType, 0)
+		// the packets are compressed already
+		handler.codec = eh.codec
 		handler.setClosed()
 	}
 	handler.pushToRequest(packet)
@@ -605,6 +678,9 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 		eh.dp = dp
 		eh.conn = conn
 		eh.extID = extID
+		if eh.key != nil {
+			eh.frameOffset = int(eh.key.ExtentOffset) + int(eh.key.PhysicalSize())
+		}
 
 		// log.LogDebugf("ExtentHandler allocateExtent exit: eh(%v) dp(%v) extID(%v)", eh, dp, extID)
 		return nil
@@ -665,10 +741,37 @@ func (eh *ExtentHandler) flushPacket() {
 		return
 	}
 
+	if eh.codec != "" {
+		eh.packet.rawSize = eh.packet.Size
+		frame, codec := compressFrame(eh.codec, eh.packet.Data[:eh.packet.Size])
+		if codec != 0 {
+			copy(eh.packet.Data, frame)
+			eh.packet.Size = uint32(len(frame))
+			eh.packet.codec = codec
+		}
+	}
 	eh.pushToRequest(eh.packet)
 	eh.packet = nil
 }
 
+// compressFrame compresses the data into a frame with the encoding. The data itself and
+// codec 0 are returned if the data can not be compressed to be smaller.
+func compressFrame(encoding string, data []byte) (frame []byte, codec uint8) {
+	codec, ok := compressor.Codec(encoding)
+	if !ok || codec == 0 {
+		return data, 0
+	}
+	frame, err := compressor.New(encoding).Compress(data)
+	if err != nil {
+		log.LogWarnf("compressFrame: encoding(%v) size(%v) err(%v)", encoding, len(data), err)
+		return data, 0
+	}
+	if len(frame) >= len(data) {
+		return data, 0
+	}
+	return frame, codec
+}
+
 func (eh *ExtentHandler) pushToRequest(packet *Packet) {
 	// Increase before sending the packet, because inflight is used
 	// to determine if the handler has finished.
@@ -23,6 +23,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/data/wrapper"
 	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/compressor"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
 )
@@ -55,20 +56,59 @@ func (reader *ExtentReader) String() (m string) {
 
 // Read reads the extent request.
 func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error) {
+	if reader.key.IsCompressed() {
+		return reader.readFrame(req)
+	}
 	offset := req.FileOffset - int(reader.key.FileOffset) + int(reader.key.ExtentOffset)
-	size := req.Size
+	return reader.read(req.Data[:req.Size], offset, req.FileOffset)
+}
+
+// readFrame reads the whole compressed frame of the key, and copies the requested data out of the decompressed frame.
+func (reader *ExtentReader) readFrame(req *ExtentRequest) (readBytes int, err error) {
+	info := reader.key.CompressInfo
+	frame := make([]byte, info.FrameSize)
+	if _, err = reader.read(frame, int(reader.key.ExtentOffset), int(reader.key.FileOffset)); err != nil {
+		return
+	}
+
+	encoding, ok := compressor.Encoding(info.Codec)
+	if !ok {
+		err = errors.New(fmt.Sprintf("readFrame: unknown codec, ino(%v) key(%v)", reader.inode, reader.key))
+		log.LogErrorf("Extent Reader readFrame: err(%v) req(%v)", err, req)
+		return
+	}
+	raw, err := compressor.New(encoding).Decompress(frame)
+	if err != nil {
+		err = errors.New(fmt.Sprintf("readFrame: decompress failed, ino(%v) key(%v) err(%v)", reader.inode, reader.key, err))
+		log.LogErrorf("Extent Reader readFrame: err(%v) req(%v)", err, req)
+		return
+	}
+
+	start := int(info.RawOffset) + req.FileOffset - int(reader.key.FileOffset)
+	if start < 0 || start+req.Size > len(raw) {
+		err = errors.New(fmt.Sprintf("readFrame: frame too short, ino(%v) key(%v) rawSize(%v)", reader.inode, reader.key, len(raw)))
+		log.LogErrorf("Extent Reader readFrame: err(%v) req(%v)", err, req)
+		return
+	}
+	readBytes = copy(req.Data[:req.Size], raw[start:start+req.Size])
+	return
+}
+
+// read reads the data of the extent from the extent offset, the file offset is used to identify the request.
+func (reader *ExtentReader) read(data []byte, extentOffset, fileOffset int) (readBytes int, err error) {
+	size := len(data)
 
-	reqPacket := NewReadPacket(reader.key, offset, size, reader.inode, req.FileOffset, reader.followerRead)
+	reqPacket := NewReadPacket(reader.key, extentOffset, size, reader.inode, fileOffset, reader.followerRead)
 	sc := NewStreamConn(reader.dp, reader.followerRead)
 
-	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
+	log.LogDebugf("ExtentReader Read enter: size(%v) reqPacket(%v)", size, reqPacket)
 
 	err = sc.Send(&reader.retryRead, reqPacket, func(conn *net.TCPConn) (error, bool) {
 		readBytes = 0
 		for readBytes < size {
 			replyPacket := NewReply(reqPacket.ReqID, reader.dp.PartitionID, reqPacket.ExtentID)
 			bufSize := util.Min(util.ReadBlockSize, size-readBytes)
-			replyPacket.Data = req.Data[readBytes : readBytes+bufSize]
+			replyPacket.Data = data[readBytes : readBytes+bufSize]
 			e := replyPacket.readFromConn(conn, proto.ReadDeadlineTime)
 
 			if e != nil {
@@ -97,13 +137,13 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 	if err != nil {
 		// if cold vol and cach is invaild
 		if !reader.retryRead && (err == TryOtherAddrError || strings.Contains(err.Error(), "ExistErr")) {
-			log.LogWarnf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
+			log.LogWarnf("Extent Reader Read: err(%v) reqPacket(%v)", err, reqPacket)
 		} else {
-			log.LogErrorf("Extent Reader Read: err(%v) req(%v) reqPacket(%v)", err, req, reqPacket)
+			log.LogErrorf("Extent Reader Read: err(%v) reqPacket(%v)", err, reqPacket)
 		}
 	}
 
-	log.LogDebugf("ExtentReader Read exit: req(%v) reqPacket(%v) readBytes(%v) err(%v)", req, reqPacket, readBytes, err)
+	log.LogDebugf("ExtentReader Read exit: reqPacket(%v) readBytes(%v) err(%v)", reqPacket, readBytes, err)
 	return
 }
 
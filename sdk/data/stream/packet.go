@@ -32,6 +32,10 @@ type Packet struct {
 	proto.Packet
 	inode    uint64
 	errCount int
+	// set for the packet of a compressing handler, the size of the data before compression,
+	// and the codec of the data, 0 if the data can not be compressed and is kept as it is.
+	rawSize uint32
+	codec   uint8
 }
 
 // String returns the string format of the packet.
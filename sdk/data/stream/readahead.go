@@ -0,0 +1,278 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	readAheadBlockSize        = util.MB
+	readAheadMinWindow        = 2 * readAheadBlockSize
+	defaultReadAheadWindowMB  = 16
+	defaultReadAheadMaxWindow = defaultReadAheadWindowMB * util.MB
+)
+
+// ReadAheadPool bounds the memory held by the prefetched blocks of all the streamers of an extent client.
+type ReadAheadPool struct {
+	limit     int64
+	used      int64
+	maxWindow int
+	buffers   sync.Pool
+}
+
+// NewReadAheadPool returns a pool of memMB megabytes, or nil if readahead is disabled.
+func NewReadAheadPool(memMB, windowMB int64) *ReadAheadPool {
+	if memMB <= 0 {
+		return nil
+	}
+	p := &ReadAheadPool{
+		limit:     memMB * util.MB,
+		maxWindow: defaultReadAheadMaxWindow,
+	}
+	if windowMB > 0 {
+		p.maxWindow = int(windowMB) * util.MB
+	}
+	if p.maxWindow < readAheadMinWindow {
+		p.maxWindow = readAheadMinWindow
+	}
+	if int64(p.maxWindow) > p.limit {
+		p.maxWindow = int(p.limit)
+	}
+	p.buffers.New = func() interface{} {
+		return make([]byte, readAheadBlockSize)
+	}
+	return p
+}
+
+// get reserves a block from the pool, it returns nil if the pool is exhausted.
+func (p *ReadAheadPool) get() []byte {
+	if atomic.AddInt64(&p.used, readAheadBlockSize) > p.limit {
+		atomic.AddInt64(&p.used, -readAheadBlockSize)
+		return nil
+	}
+	return p.buffers.Get().([]byte)
+}
+
+func (p *ReadAheadPool) put(data []byte) {
+	p.buffers.Put(data[:readAheadBlockSize])
+	atomic.AddInt64(&p.used, -readAheadBlockSize)
+}
+
+// Used returns the bytes taken by the prefetched blocks.
+func (p *ReadAheadPool) Used() int64 {
+	return atomic.LoadInt64(&p.used)
+}
+
+type readAheadBlock struct {
+	offset  int
+	size    int // valid bytes once fetched
+	data    []byte
+	err     error
+	done    chan struct{}
+	fetched bool
+	dropped bool
+	refs    int // readers copying from data
+}
+
+// readAhead detects the sequential reads of a streamer and prefetches the following blocks
+// in parallel. The window doubles on every sequential read up to the max window of the pool,
+// it is halved when the pool runs out of memory and reset by random reads, writes and truncates.
+type readAhead struct {
+	sync.Mutex
+	s          *Streamer
+	pool       *ReadAheadPool
+	nextOffset int // where the next sequential read is expected
+	window     int
+	blocks     map[int]*readAheadBlock
+}
+
+func newReadAhead(s *Streamer, pool *ReadAheadPool) *readAhead {
+	return &readAhead{
+		s:      s,
+		pool:   pool,
+		blocks: make(map[int]*readAheadBlock),
+	}
+}
+
+func alignReadAhead(offset int) int {
+	return offset / readAheadBlockSize * readAheadBlockSize
+}
+
+// read serves the request from the prefetched blocks and triggers the next prefetch,
+// hit is false if the caller has to read the data from the datanodes itself.
+func (ra *readAhead) read(data []byte, offset, size int) (total int, hit bool) {
+	end := offset + size
+
+	ra.Lock()
+	sequential := ra.isSequential(offset)
+	if !sequential {
+		ra.reset()
+	}
+	if end > ra.nextOffset || !sequential {
+		ra.nextOffset = end
+	}
+
+	var blocks []*readAheadBlock
+	hit = sequential
+	for off := alignReadAhead(offset); hit && off < end; off += readAheadBlockSize {
+		b, ok := ra.blocks[off]
+		if !ok {
+			hit = false
+			break
+		}
+		blocks = append(blocks, b)
+	}
+	if hit {
+		for _, b := range blocks {
+			b.refs++
+		}
+	} else {
+		blocks = nil
+	}
+
+	if sequential {
+		if ra.window < readAheadMinWindow {
+			ra.window = readAheadMinWindow
+		} else if ra.window < ra.pool.maxWindow {
+			ra.window = util.Min(ra.window*2, ra.pool.maxWindow)
+		}
+		ra.prefetch(end)
+	}
+	log.LogDebugf("readAhead: ino(%v) offset(%v) size(%v) sequential(%v) hit(%v) window(%v)", ra.s.inode, offset, size, sequential, hit, ra.window)
+	ra.Unlock()
+
+	if !hit {
+		return 0, false
+	}
+
+	for _, b := range blocks {
+		<-b.done
+		start := util.Max(offset, b.offset)
+		stop := util.Min(end, b.offset+readAheadBlockSize)
+		if !hit || b.err != nil || stop > b.offset+b.size {
+			hit = false
+			continue
+		}
+		total += copy(data[start-offset:stop-offset], b.data[start-b.offset:stop-b.offset])
+	}
+
+	ra.Lock()
+	for _, b := range blocks {
+		b.refs--
+		// the blocks behind the reader are not needed anymore
+		if b.offset+readAheadBlockSize <= end {
+			ra.drop(b)
+		}
+		ra.tryFree(b)
+	}
+	ra.Unlock()
+
+	if !hit {
+		return 0, false
+	}
+	return total, true
+}
+
+func (ra *readAhead) isSequential(offset int) bool {
+	if ra.window == 0 {
+		return offset == ra.nextOffset
+	}
+	// concurrent readers of a sequential stream may arrive slightly out of order
+	return offset >= ra.nextOffset-ra.window && offset <= ra.nextOffset+ra.window
+}
+
+// prefetch starts fetching the missing blocks of the window after the offset, it must be called with the lock held.
+func (ra *readAhead) prefetch(offset int) {
+	fileSize, _ := ra.s.extents.Size()
+	end := util.Min(offset+ra.window, fileSize)
+	for off := alignReadAhead(offset); off < end; off += readAheadBlockSize {
+		if _, ok := ra.blocks[off]; ok {
+			continue
+		}
+		data := ra.pool.get()
+		if data == nil {
+			ra.window = util.Max(ra.window/2, readAheadMinWindow)
+			log.LogDebugf("readAhead: ino(%v) pool exhausted, shrink window to %v", ra.s.inode, ra.window)
+			return
+		}
+		b := &readAheadBlock{
+			offset: off,
+			data:   data,
+			done:   make(chan struct{}),
+		}
+		ra.blocks[off] = b
+		go ra.fetch(b, util.Min(readAheadBlockSize, fileSize-off))
+	}
+}
+
+func (ra *readAhead) fetch(b *readAheadBlock, size int) {
+	n, err := ra.s.read(b.data[:size], b.offset, size)
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		log.LogWarnf("readAhead: ino(%v) fetch offset(%v) size(%v) err(%v)", ra.s.inode, b.offset, size, err)
+	}
+
+	ra.Lock()
+	b.size, b.err = n, err
+	b.fetched = true
+	close(b.done)
+	if err != nil {
+		ra.drop(b)
+	}
+	ra.tryFree(b)
+	ra.Unlock()
+}
+
+// drop removes the block from the streamer, the memory is given back once the block is not used.
+func (ra *readAhead) drop(b *readAheadBlock) {
+	if b.dropped {
+		return
+	}
+	b.dropped = true
+	if ra.blocks[b.offset] == b {
+		delete(ra.blocks, b.offset)
+	}
+}
+
+func (ra *readAhead) tryFree(b *readAheadBlock) {
+	if b.dropped && b.fetched && b.refs == 0 && b.data != nil {
+		ra.pool.put(b.data)
+		b.data = nil
+	}
+}
+
+// reset drops all the prefetched blocks, it must be called with the lock held.
+func (ra *readAhead) reset() {
+	for _, b := range ra.blocks {
+		ra.drop(b)
+		ra.tryFree(b)
+	}
+	ra.window = 0
+}
+
+// invalidate drops the prefetched data after the file is changed or released.
+func (ra *readAhead) invalidate() {
+	ra.Lock()
+	ra.reset()
+	ra.Unlock()
+}
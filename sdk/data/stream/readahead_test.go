@@ -0,0 +1,86 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAheadPool(t *testing.T) {
+	require.Nil(t, NewReadAheadPool(0, 16))
+
+	p := NewReadAheadPool(2, 16)
+	require.Equal(t, 2*util.MB, p.maxWindow)
+
+	b1, b2 := p.get(), p.get()
+	require.NotNil(t, b1)
+	require.NotNil(t, b2)
+	require.Nil(t, p.get())
+	require.Equal(t, int64(2*util.MB), p.Used())
+
+	p.put(b1)
+	p.put(b2)
+	require.Equal(t, int64(0), p.Used())
+}
+
+func TestReadAheadWindow(t *testing.T) {
+	// the file is empty, so nothing is prefetched
+	s := &Streamer{inode: 1, extents: NewExtentCache(1)}
+	ra := newReadAhead(s, NewReadAheadPool(64, 8))
+	data := make([]byte, 4096)
+
+	_, hit := ra.read(data, 0, 4096)
+	require.False(t, hit)
+	require.Equal(t, readAheadMinWindow, ra.window)
+
+	ra.read(data, 4096, 4096)
+	ra.read(data, 8192, 4096)
+	require.Equal(t, 8*util.MB, ra.window)
+
+	// a random read resets the window
+	ra.read(data, 100*util.MB, 4096)
+	require.Equal(t, 0, ra.window)
+	require.Equal(t, 100*util.MB+4096, ra.nextOffset)
+}
+
+func TestReadAheadHit(t *testing.T) {
+	s := &Streamer{inode: 1, extents: NewExtentCache(1)}
+	pool := NewReadAheadPool(64, 8)
+	ra := newReadAhead(s, pool)
+
+	b := &readAheadBlock{offset: 0, data: pool.get(), done: make(chan struct{})}
+	copy(b.data, bytes.Repeat([]byte{'a'}, 8192))
+	b.size, b.fetched = 8192, true
+	close(b.done)
+	ra.blocks[0] = b
+
+	data := make([]byte, 4096)
+	n, hit := ra.read(data, 0, 4096)
+	require.True(t, hit)
+	require.Equal(t, 4096, n)
+	require.Equal(t, bytes.Repeat([]byte{'a'}, 4096), data)
+
+	// beyond the fetched data of the block
+	_, hit = ra.read(data, 8192, 4096)
+	require.False(t, hit)
+
+	ra.invalidate()
+	require.Equal(t, 0, len(ra.blocks))
+	require.Equal(t, int64(0), pool.Used())
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/cubefs/cubefs/blockcache/bcache"
@@ -54,6 +55,11 @@ type Streamer struct {
 	pendingCache         chan bcacheKey
 	verSeq               uint64
 	needUpdateVer        int32
+	readAhead            *readAhead // nil if readahead is disabled
+	releasedLock         sync.Mutex
+	releasedChecked      bool
+	releasedGen          uint64 // generation of the extents when released is checked
+	released             bool
 }
 
 type bcacheKey struct {
@@ -75,6 +81,9 @@ func NewStreamer(client *ExtentClient, inode uint64) *Streamer {
 	s.pendingCache = make(chan bcacheKey, 1)
 	s.verSeq = client.multiVerMgr.latestVerSeq
 	s.extents.verSeq = client.multiVerMgr.latestVerSeq
+	if client.readAheadPool != nil {
+		s.readAhead = newReadAhead(s, client.readAheadPool)
+	}
 	go s.server()
 	go s.asyncBlockCache()
 	return s
@@ -89,6 +98,34 @@ func (s *Streamer) String() string {
 	return fmt.Sprintf("Streamer{ino(%v)}", s.inode)
 }
 
+// checkReleased fails the read if the data of the inode has been moved to a cold volume by
+// lifecycle transition, such an inode keeps its size but has no extents in the hot volume.
+func (s *Streamer) checkReleased() error {
+	if s.client.checkReleased == nil {
+		return nil
+	}
+	size, gen := s.extents.Size()
+	if size == 0 || s.extents.Len() > 0 {
+		return nil
+	}
+
+	s.releasedLock.Lock()
+	defer s.releasedLock.Unlock()
+	if !s.releasedChecked || s.releasedGen != gen {
+		released, err := s.client.checkReleased(s.inode)
+		if err != nil {
+			return err
+		}
+		s.releasedChecked = true
+		s.releasedGen = gen
+		s.released = released
+	}
+	if s.released {
+		return syscall.ENODATA
+	}
+	return nil
+}
+
 // TODO should we call it RefreshExtents instead?
 func (s *Streamer) GetExtents() error {
 	if s.client.disableMetaCache || !s.needBCache {
@@ -0,0 +1,61 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamerCheckReleased(t *testing.T) {
+	var checked int
+	released := true
+	client := &ExtentClient{checkReleased: func(inode uint64) (bool, error) {
+		checked++
+		return released, nil
+	}}
+	s := &Streamer{client: client, inode: 1, extents: NewExtentCache(1)}
+
+	// an empty file is never released
+	require.NoError(t, s.checkReleased())
+	require.Equal(t, 0, checked)
+
+	// a file with extents is never released
+	s.extents.update(1, 4096, false, []proto.ExtentKey{{FileOffset: 0, Size: 4096}})
+	require.NoError(t, s.checkReleased())
+	require.Equal(t, 0, checked)
+
+	// the size is kept without any extent once released, the result is cached by generation
+	s.extents.update(2, 4096, false, nil)
+	require.Equal(t, syscall.ENODATA, s.checkReleased())
+	require.Equal(t, syscall.ENODATA, s.checkReleased())
+	require.Equal(t, 1, checked)
+
+	// a sparse file is checked again once the generation changes
+	released = false
+	s.extents.update(3, 8192, false, nil)
+	require.NoError(t, s.checkReleased())
+	require.NoError(t, s.checkReleased())
+	require.Equal(t, 2, checked)
+
+	// nothing is checked without the callback
+	client.checkReleased = nil
+	s.extents.update(4, 8192, false, nil)
+	require.NoError(t, s.checkReleased())
+	require.Equal(t, 2, checked)
+}
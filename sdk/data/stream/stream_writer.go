@@ -310,6 +310,11 @@ func (s *Streamer) write(data []byte, offset, size, flags int, checkFunc func()
 	if flags&proto.FlagsSyncWrite != 0 {
 		direct = true
 	}
+	// drop the blocks prefetched by the concurrent readers during the write as well
+	if s.readAhead != nil {
+		s.readAhead.invalidate()
+		defer s.readAhead.invalidate()
+	}
 begin:
 	if flags&proto.FlagsAppend != 0 {
 		filesize, _ := s.extents.Size()
@@ -357,7 +362,7 @@ begin:
 			}
 			log.LogDebugf("action[streamer.write] inode [%v] latest seq [%v] extentkey seq [%v]  info [%v] before compare seq",
 				s.inode, s.verSeq, req.ExtentKey.GetSeq(), req.ExtentKey)
-			if req.ExtentKey.GetSeq() == s.verSeq {
+			if req.ExtentKey.GetSeq() == s.verSeq && !req.ExtentKey.IsCompressed() && !req.ExtentKey.IsSplit() {
 				writeSize, err = s.doOverwrite(req, direct)
 				if err == proto.ErrCodeVersionOp {
 					log.LogDebugf("action[streamer.write] write need version update")
@@ -377,7 +382,9 @@ begin:
 				}
 				log.LogDebugf("action[streamer.write] err %v retryTimes %v", err, retryTimes)
 			} else {
-				log.LogDebugf("action[streamer.write] ino %v do OverWriteByAppend extent key (%v) because seq not equal", s.inode, req.ExtentKey)
+				// the compressed frame can not be overwritten in place either, nor can the split key,
+				// whose extent may be shared by the clones
+				log.LogDebugf("action[streamer.write] ino %v do OverWriteByAppend extent key (%v) because seq not equal, compressed or split", s.inode, req.ExtentKey)
 				writeSize, _, err, _ = s.doOverWriteByAppend(req, direct)
 			}
 			if s.client.bcacheEnable {
@@ -449,13 +456,25 @@ func (s *Streamer) doDirectWriteByAppend(req *ExtentRequest, direct bool, op uin
 	}
 	log.LogDebugf("action[doDirectWriteByAppend] inode %v  data process", s.inode)
 
+	var (
+		data   = req.Data[:req.Size]
+		codec  uint8
+		framed bool
+	)
 	addr := dp.LeaderAddr
 	if storage.IsTinyExtent(req.ExtentKey.ExtentId) {
 		addr = dp.Hosts[0]
 		reqPacket = NewWriteTinyDirectly(s.inode, req.ExtentKey.PartitionId, req.FileOffset, dp)
 	} else {
-		reqPacket = NewOverwriteByAppendPacket(dp, req.ExtentKey.ExtentId, int(req.ExtentKey.ExtentOffset)+int(req.ExtentKey.Size),
+		reqPacket = NewOverwriteByAppendPacket(dp, req.ExtentKey.ExtentId, int(req.ExtentKey.ExtentOffset)+int(req.ExtentKey.PhysicalSize()),
 			s.inode, req.FileOffset, direct, op)
+		// the data appended to the extent of the open handler must follow the way it writes
+		encoding := s.client.dataWrapper.CompressCodec()
+		if op == proto.OpTryWriteAppend && s.handler != nil {
+			encoding = s.handler.codec
+		}
+		data, codec = compressFrame(encoding, data)
+		framed = encoding != ""
 	}
 
 	sc := &StreamConn{
@@ -468,7 +487,7 @@ func (s *Streamer) doDirectWriteByAppend(req *ExtentRequest, direct bool, op uin
 		log.LogErrorf("action[doDirectWriteByAppend] inode %v size too large %v", s.inode, req.Size)
 		panic(nil)
 	}
-	for total < req.Size { // normally should only run once due to key exist in the system must be less than BlockSize
+	for total < len(data) { // normally should only run once due to key exist in the system must be less than BlockSize
 		// right position in extent:offset-ek4FileOffset+total+ekExtOffset .
 		// ekExtOffset will be set by replay packet at addExtentInfo(datanode)
 
@@ -479,8 +498,8 @@ func (s *Streamer) doDirectWriteByAppend(req *ExtentRequest, direct bool, op uin
 			reqPacket.ExtentType = proto.TinyExtentType
 		}
 
-		packSize := util.Min(req.Size-total, util.BlockSize)
-		copy(reqPacket.Data[:packSize], req.Data[total:total+packSize])
+		packSize := util.Min(len(data)-total, util.BlockSize)
+		copy(reqPacket.Data[:packSize], data[total:total+packSize])
 		reqPacket.Size = uint32(packSize)
 		reqPacket.CRC = crc32.ChecksumIEEE(reqPacket.Data[:packSize])
 
@@ -547,11 +566,18 @@ func (s *Streamer) doDirectWriteByAppend(req *ExtentRequest, direct bool, op uin
 		PartitionId:  req.ExtentKey.PartitionId,
 		ExtentId:     replyPacket.ExtentID,
 		ExtentOffset: uint64(replyPacket.ExtentOffset),
-		Size:         uint32(total),
+		Size:         uint32(req.Size),
 		SnapInfo: &proto.ExtSnapInfo{
 			VerSeq: s.verSeq,
 		},
 	}
+	if framed {
+		extKey.CompressInfo = &proto.ExtCompressInfo{
+			Codec:     codec,
+			FrameSize: uint32(total),
+		}
+	}
+	total = req.Size
 	if op == proto.OpRandomWriteAppend || op == proto.OpSyncRandomWriteAppend {
 		log.LogDebugf("action[doDirectWriteByAppend] inode %v local cache process start extKey %v", s.inode, extKey)
 		if err = s.extents.SplitExtentKey(s.inode, extKey); err != nil {
@@ -703,9 +729,10 @@ func (s *Streamer) tryInitExtentHandlerByLastEk(offset, size int) (isLastEkVerNo
 	}
 
 	checkVerFunc := func(currentEK *proto.ExtentKey) {
-		if currentEK.GetSeq() != s.verSeq {
-			log.LogDebugf("tryInitExtentHandlerByLastEk. exist ek seq %v vs request seq %v", currentEK.GetSeq(), s.verSeq)
-			if int(currentEK.ExtentOffset)+int(currentEK.Size)+size > util.ExtentSize {
+		// the extent of the split key may be shared by the clones, so it is only appended at the end
+		if currentEK.GetSeq() != s.verSeq || currentEK.IsSplit() {
+			log.LogDebugf("tryInitExtentHandlerByLastEk. exist ek seq %v vs request seq %v split %v", currentEK.GetSeq(), s.verSeq, currentEK.IsSplit())
+			if int(currentEK.ExtentOffset)+int(currentEK.PhysicalSize())+size > util.ExtentSize {
 				s.closeOpenHandler()
 				return
 			}
@@ -725,6 +752,12 @@ func (s *Streamer) tryInitExtentHandlerByLastEk(offset, size int) (isLastEkVerNo
 			}
 			log.LogDebugf("tryInitExtentHandlerByLastEk NewExtentHandler")
 			handler := NewExtentHandler(s, int(currentEK.FileOffset), storeMode, int(currentEK.Size))
+			if currentEK.IsCompressed() && handler.codec == "" {
+				// the handler without compression can not append to a compressed frame
+				log.LogDebugf("tryInitExtentHandlerByLastEk: compression disabled, skip currentEK [%v]", currentEK)
+				handler.cleanup()
+				return
+			}
 			handler.key = &proto.ExtentKey{
 				FileOffset:   currentEK.FileOffset,
 				PartitionId:  currentEK.PartitionId,
@@ -734,6 +767,7 @@ func (s *Streamer) tryInitExtentHandlerByLastEk(offset, size int) (isLastEkVerNo
 				SnapInfo: &proto.ExtSnapInfo{
 					VerSeq: seq,
 				},
+				CompressInfo: currentEK.CompressInfo,
 			}
 			handler.lastKey = *currentEK
 
@@ -975,6 +1009,9 @@ func (s *Streamer) open() {
 
 func (s *Streamer) release() error {
 	s.refcnt--
+	if s.refcnt <= 0 && s.readAhead != nil {
+		s.readAhead.invalidate()
+	}
 	s.closeOpenHandler()
 	err := s.flush()
 	if err != nil {
@@ -1011,6 +1048,9 @@ func (s *Streamer) abort() {
 }
 
 func (s *Streamer) truncate(size int, fullPath string) error {
+	if s.readAhead != nil {
+		s.readAhead.invalidate()
+	}
 	s.closeOpenHandler()
 	err := s.flush()
 	if err != nil {
@@ -66,6 +66,7 @@ type Wrapper struct {
 	dpSelectorChanged     bool
 	dpSelectorName        string
 	dpSelectorParm        string
+	compressCodec         string
 	mc                    *masterSDK.MasterClient
 	stopOnce              sync.Once
 	stopC                 chan struct{}
@@ -166,6 +167,13 @@ func (w *Wrapper) FollowerRead() bool {
 	return w.followerRead
 }
 
+// CompressCodec returns the codec the volume compresses file data with, empty if disabled.
+func (w *Wrapper) CompressCodec() string {
+	w.Lock.RLock()
+	defer w.Lock.RUnlock()
+	return w.compressCodec
+}
+
 func (w *Wrapper) tryGetPartition(index uint64) (partition *DataPartition, ok bool) {
 	w.Lock.RLock()
 	defer w.Lock.RUnlock()
@@ -217,6 +225,7 @@ func (w *Wrapper) GetSimpleVolView() (err error) {
 	w.dpSelectorParm = view.DpSelectorParm
 	w.volType = view.VolType
 	w.EnablePosixAcl = view.EnablePosixAcl
+	w.compressCodec = view.CompressCodec
 	w.UpdateUidsView(view)
 
 	log.LogDebugf("GetSimpleVolView: get volume simple info: ID(%v) name(%v) owner(%v) status(%v) capacity(%v) "+
@@ -349,6 +358,14 @@ func (w *Wrapper) updateSimpleVolView() (err error) {
 		w.Lock.Unlock()
 	}
 
+	if w.CompressCodec() != view.CompressCodec {
+		log.LogInfof("UpdateSimpleVolView: update compressCodec from old(%v) to new(%v)",
+			w.CompressCodec(), view.CompressCodec)
+		w.Lock.Lock()
+		w.compressCodec = view.CompressCodec
+		w.Lock.Unlock()
+	}
+
 	return nil
 }
 
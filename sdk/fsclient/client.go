@@ -0,0 +1,639 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fsclient is a pure Go client of a CubeFS volume, it works on paths without FUSE or cgo.
+//
+// The names follow the io/fs conventions: they are slash-separated, unrooted and relative to
+// the root of the volume (or the SubDir of the config), "." is the root itself.
+package fsclient
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	gopath "path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	masterSDK "github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/buf"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	maxSymlinks        = 40
+	defaultBlockThread = 10
+	maxSizePutOnce     = int64(1) << 23
+)
+
+var (
+	_ fs.FS        = (*Client)(nil)
+	_ fs.ReadDirFS = (*Client)(nil)
+	_ fs.StatFS    = (*Client)(nil)
+)
+
+// Config is the config of a client.
+type Config struct {
+	Volume  string
+	Masters []string
+	// the permission of the user is checked if the keys are set
+	AccessKey string
+	SecretKey string
+	// the root of the client in the volume, the root of the volume if not set
+	SubDir        string
+	FollowerRead  bool
+	EnableSummary bool
+
+	// readahead of the sequential readers, see stream.ExtentConfig
+	ReadAheadMemMB    int64
+	ReadAheadWindowMB int64
+
+	// concurrency of the blob reads and writes of cold volumes
+	ReadBlockThread  int
+	WriteBlockThread int
+}
+
+// metaWrapper is the part of meta.MetaWrapper used by the client.
+type metaWrapper interface {
+	LookupPath(subdir string) (uint64, error)
+	Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error)
+	Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error)
+	InodeGet_ll(inode uint64) (*proto.InodeInfo, error)
+	Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error)
+	Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string, srcFullPath string, dstFullPath string, overwritten bool) error
+	ReadDir_ll(parentID uint64) ([]proto.Dentry, error)
+	Evict(inode uint64, fullPath string) error
+	Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error
+	XAttrSet_ll(inode uint64, name, value []byte) error
+	XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error)
+	XAttrDel_ll(inode uint64, name string) error
+	XAttrsList_ll(inode uint64) ([]string, error)
+	IsQuotaLimitedById(inodeId uint64, size bool, files bool) bool
+	QuotaEnabled() bool
+	Close() error
+}
+
+// extentClient is the part of stream.ExtentClient used by the client.
+type extentClient interface {
+	OpenStream(inode uint64) error
+	CloseStream(inode uint64) error
+	EvictStream(inode uint64) error
+	FileSize(inode uint64) (size int, gen uint64, valid bool)
+	Flush(inode uint64) error
+	GetStreamer(inode uint64) *stream.Streamer
+	Read(inode uint64, data []byte, offset int, size int) (read int, err error)
+	Write(inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error)
+	Truncate(mw *meta.MetaWrapper, parentIno uint64, inode uint64, size int, fullPath string) error
+	UidIsLimited(uid uint32) bool
+	Close() error
+}
+
+// Client accesses the files of a volume by path, it is safe for concurrent use.
+type Client struct {
+	config  Config
+	rootIno uint64
+	mw      metaWrapper
+	ec      extentClient
+
+	// cold volume
+	volType        int
+	ebsc           *blobstore.BlobStoreClient
+	ebsBlockSize   int
+	cacheAction    int
+	cacheThreshold int
+}
+
+// NewClient connects to the volume of the config.
+func NewClient(config *Config) (c *Client, err error) {
+	if config.Volume == "" || len(config.Masters) == 0 {
+		return nil, errors.New("fsclient: volume and masters are required")
+	}
+	c = &Client{config: *config}
+	if c.config.ReadBlockThread <= 0 {
+		c.config.ReadBlockThread = defaultBlockThread
+	}
+	if c.config.WriteBlockThread <= 0 {
+		c.config.WriteBlockThread = defaultBlockThread
+	}
+
+	mc := masterSDK.NewMasterClient(config.Masters, false)
+	if config.AccessKey != "" || config.SecretKey != "" {
+		if err = c.checkPermission(mc); err != nil {
+			return nil, err
+		}
+	}
+	if err = c.loadVolume(mc); err != nil {
+		return nil, err
+	}
+
+	mw, err := meta.NewMetaWrapper(&meta.MetaConfig{
+		Volume:        config.Volume,
+		Masters:       config.Masters,
+		ValidateOwner: false,
+		EnableSummary: config.EnableSummary,
+	})
+	if err != nil {
+		log.LogErrorf("NewClient: new meta wrapper failed: volume(%v) err(%v)", config.Volume, err)
+		return nil, err
+	}
+	ec, err := stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            config.Volume,
+		VolumeType:        c.volType,
+		Masters:           config.Masters,
+		FollowerRead:      config.FollowerRead,
+		OnAppendExtentKey: mw.AppendExtentKey,
+		OnSplitExtentKey:  mw.SplitExtentKey,
+		OnGetExtents:      mw.GetExtents,
+		OnTruncate:        mw.Truncate,
+		OnCheckReleased:   mw.IsTransitionReleased,
+		DisableMetaCache:  true,
+		ReadAheadMemMB:    config.ReadAheadMemMB,
+		ReadAheadWindowMB: config.ReadAheadWindowMB,
+	})
+	if err != nil {
+		log.LogErrorf("NewClient: new extent client failed: volume(%v) err(%v)", config.Volume, err)
+		mw.Close()
+		return nil, err
+	}
+	c.mw, c.ec = mw, ec
+
+	c.rootIno = proto.RootIno
+	if subDir := strings.Trim(config.SubDir, "/"); subDir != "" {
+		if c.rootIno, err = c.mw.LookupPath("/" + subDir); err != nil {
+			c.Close()
+			return nil, &fs.PathError{Op: "lookup", Path: config.SubDir, Err: err}
+		}
+	}
+	return c, nil
+}
+
+func (c *Client) checkPermission(mc *masterSDK.MasterClient) (err error) {
+	userInfo, err := mc.UserAPI().GetAKInfo(c.config.AccessKey)
+	if err != nil {
+		return
+	}
+	if userInfo.SecretKey != c.config.SecretKey {
+		return proto.ErrNoPermission
+	}
+	policy := userInfo.Policy
+	if policy.IsOwn(c.config.Volume) || policy.IsAuthorized(c.config.Volume, c.config.SubDir, proto.POSIXReadAction) {
+		return nil
+	}
+	return proto.ErrNoPermission
+}
+
+func (c *Client) loadVolume(mc *masterSDK.MasterClient) (err error) {
+	view, err := mc.AdminAPI().GetVolumeSimpleInfo(c.config.Volume)
+	if err != nil {
+		return
+	}
+	c.volType = view.VolType
+	if proto.IsHot(c.volType) {
+		return
+	}
+
+	c.ebsBlockSize = view.ObjBlockSize
+	c.cacheAction = view.CacheAction
+	c.cacheThreshold = view.CacheThreshold
+	clusterInfo, err := mc.AdminAPI().GetClusterInfo()
+	if err != nil {
+		return
+	}
+	buf.InitCachePool(c.ebsBlockSize)
+	c.ebsc, err = blobstore.NewEbsClient(access.Config{
+		ConnMode:       access.NoLimitConnMode,
+		Consul:         access.ConsulConfig{Address: clusterInfo.EbsAddr},
+		MaxSizePutOnce: maxSizePutOnce,
+	})
+	return
+}
+
+// Close releases the connections of the client, the opened files must be closed before.
+func (c *Client) Close() error {
+	if c.ec != nil {
+		c.ec.Close()
+	}
+	if c.mw != nil {
+		c.mw.Close()
+	}
+	return nil
+}
+
+// split returns the components of a valid name.
+func split(name string) []string {
+	if name == "." || name == "" {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+// lookup resolves the name to its inode, the symlinks in the middle of the path are followed,
+// so is the last component if follow is set.
+func (c *Client) lookup(name string, follow bool) (info *proto.InodeInfo, err error) {
+	ino := c.rootIno
+	var ancestors []uint64
+	comps := split(name)
+	links := 0
+	for len(comps) > 0 {
+		comp := comps[0]
+		comps = comps[1:]
+		switch comp {
+		case ".":
+			continue
+		case "..":
+			if n := len(ancestors); n > 0 {
+				ino, ancestors = ancestors[n-1], ancestors[:n-1]
+			}
+			continue
+		}
+
+		child, mode, err := c.mw.Lookup_ll(ino, comp)
+		if err != nil {
+			return nil, err
+		}
+		if !proto.IsSymlink(mode) || (len(comps) == 0 && !follow) {
+			ancestors = append(ancestors, ino)
+			ino = child
+			continue
+		}
+
+		if links++; links > maxSymlinks {
+			return nil, syscall.ELOOP
+		}
+		link, err := c.mw.InodeGet_ll(child)
+		if err != nil {
+			return nil, err
+		}
+		target := string(link.Target)
+		if gopath.IsAbs(target) {
+			ino, ancestors = c.rootIno, nil
+		}
+		comps = append(split(strings.Trim(gopath.Clean(target), "/")), comps...)
+	}
+	return c.mw.InodeGet_ll(ino)
+}
+
+// lookupParent resolves the parent directory of the name, it returns the base name as well.
+func (c *Client) lookupParent(op, name string) (parent *proto.InodeInfo, base string, err error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	dir, base := gopath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	if parent, err = c.lookup(strings.TrimSuffix(dir, "/"), true); err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if !proto.IsDir(parent.Mode) {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+	}
+	return parent, base, nil
+}
+
+// metaWrapper returns the meta wrapper required by the data clients, nil if it is a fake.
+func (c *Client) metaWrapper() *meta.MetaWrapper {
+	mw, _ := c.mw.(*meta.MetaWrapper)
+	return mw
+}
+
+// extentClient returns the extent client required by the data clients, nil if it is a fake.
+func (c *Client) extentClient() *stream.ExtentClient {
+	ec, _ := c.ec.(*stream.ExtentClient)
+	return ec
+}
+
+// fullPath returns the path of the name in the volume, used by the audit logs of the meta nodes.
+func (c *Client) fullPath(name string) string {
+	return gopath.Join("/", c.config.SubDir, name)
+}
+
+// Open opens the named file for reading.
+func (c *Client) Open(name string) (fs.File, error) {
+	f, err := c.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Create creates or truncates the named file, like os.Create.
+func (c *Client) Create(name string) (*File, error) {
+	return c.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
+}
+
+// OpenFile opens the named file with the flags of os.OpenFile, perm is used if the file is created.
+func (c *Client) OpenFile(name string, flag int, perm fs.FileMode) (f *File, err error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	var info *proto.InodeInfo
+	var parentIno uint64
+	if flag&os.O_CREATE != 0 {
+		var parent *proto.InodeInfo
+		var base string
+		if parent, base, err = c.lookupParent("open", name); err != nil {
+			return nil, err
+		}
+		parentIno = parent.Inode
+		info, err = c.mw.Create_ll(parent.Inode, base, proto.Mode(perm.Perm()), 0, 0, nil, c.fullPath(name))
+		if err == syscall.EEXIST {
+			if flag&os.O_EXCL != 0 {
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			info, err = c.lookup(name, true)
+		}
+	} else {
+		info, err = c.lookup(name, true)
+		if err == nil && name != "." {
+			var parent *proto.InodeInfo
+			if parent, _, err = c.lookupParent("open", name); err != nil {
+				return nil, err
+			}
+			parentIno = parent.Inode
+		}
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if proto.IsDir(info.Mode) && writable {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	}
+
+	f = newFile(c, name, flag, info, parentIno)
+	if proto.IsRegular(info.Mode) {
+		if err = f.open(); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if flag&os.O_TRUNC != 0 && writable && info.Size != 0 {
+			if err = c.ec.Truncate(c.metaWrapper(), parentIno, info.Inode, 0, c.fullPath(name)); err != nil {
+				f.release()
+				return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+			}
+			info.Size = 0
+		}
+	}
+	return f, nil
+}
+
+// Stat returns the FileInfo of the named file, the symlinks are followed.
+func (c *Client) Stat(name string) (fs.FileInfo, error) {
+	return c.stat("stat", name, true)
+}
+
+// Lstat returns the FileInfo of the named file, the symlink itself is described if the file is one.
+func (c *Client) Lstat(name string) (fs.FileInfo, error) {
+	return c.stat("lstat", name, false)
+}
+
+func (c *Client) stat(op, name string, follow bool) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := c.lookup(name, follow)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return newFileInfo(gopath.Base(name), info), nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (c *Client) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := c.lookup(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !proto.IsDir(info.Mode) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	entries, err := c.readDir(info.Inode)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (c *Client) readDir(ino uint64) ([]fs.DirEntry, error) {
+	dentries, err := c.mw.ReadDir_ll(ino)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]fs.DirEntry, 0, len(dentries))
+	for _, d := range dentries {
+		entries = append(entries, &dirEntry{c: c, dentry: d})
+	}
+	return entries, nil
+}
+
+// Mkdir creates the named directory.
+func (c *Client) Mkdir(name string, perm fs.FileMode) error {
+	parent, base, err := c.lookupParent("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, err = c.mw.Create_ll(parent.Inode, base, proto.Mode(os.ModeDir|perm.Perm()), 0, 0, nil, c.fullPath(name)); err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return nil
+}
+
+// MkdirAll creates the named directory along with the missing parents.
+func (c *Client) MkdirAll(name string, perm fs.FileMode) error {
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	comps := split(name)
+	for i := range comps {
+		dir := strings.Join(comps[:i+1], "/")
+		err := c.Mkdir(dir, perm)
+		if err == nil || errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return err
+	}
+	if info, err := c.Stat(name); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (c *Client) Remove(name string) error {
+	parent, base, err := c.lookupParent("remove", name)
+	if err != nil {
+		return err
+	}
+	_, mode, err := c.mw.Lookup_ll(parent.Inode, base)
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	info, err := c.mw.Delete_ll(parent.Inode, base, proto.IsDir(mode), c.fullPath(name))
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	if info != nil && !proto.IsDir(mode) {
+		_ = c.mw.Evict(info.Inode, c.fullPath(name))
+	}
+	return nil
+}
+
+// Rename renames the file, a regular file at the new path is replaced.
+func (c *Client) Rename(oldpath, newpath string) error {
+	src, srcName, err := c.lookupParent("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	dst, dstName, err := c.lookupParent("rename", newpath)
+	if err != nil {
+		return err
+	}
+	if err = c.mw.Rename_ll(src.Inode, srcName, dst.Inode, dstName, c.fullPath(oldpath), c.fullPath(newpath), true); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (c *Client) Symlink(oldname, newname string) error {
+	parent, base, err := c.lookupParent("symlink", newname)
+	if err != nil {
+		return err
+	}
+	if _, err = c.mw.Create_ll(parent.Inode, base, proto.Mode(os.ModeSymlink|os.ModePerm), 0, 0, []byte(oldname), c.fullPath(newname)); err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+	return nil
+}
+
+// ReadLink returns the target of the named symbolic link.
+func (c *Client) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := c.lookup(name, false)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	if !proto.IsSymlink(info.Mode) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+	}
+	return string(info.Target), nil
+}
+
+// Truncate changes the size of the named file.
+func (c *Client) Truncate(name string, size int64) error {
+	f, err := c.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if err = f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Chmod changes the permission bits of the named file.
+func (c *Client) Chmod(name string, mode fs.FileMode) error {
+	info, err := c.lookup(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	newMode := info.Mode&^uint32(os.ModePerm) | uint32(mode.Perm())
+	if err = c.mw.Setattr(info.Inode, proto.AttrMode, newMode, 0, 0, 0, 0); err != nil {
+		return &fs.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Chtimes changes the access and modification times of the named file.
+func (c *Client) Chtimes(name string, atime, mtime time.Time) error {
+	info, err := c.lookup(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	if err = c.mw.Setattr(info.Inode, proto.AttrAccessTime|proto.AttrModifyTime, 0, 0, 0, atime.Unix(), mtime.Unix()); err != nil {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return nil
+}
+
+// SetXattr sets the extended attribute of the named file.
+func (c *Client) SetXattr(name, key string, value []byte) error {
+	info, err := c.lookup(name, true)
+	if err == nil {
+		err = c.mw.XAttrSet_ll(info.Inode, []byte(key), value)
+	}
+	if err != nil {
+		return &fs.PathError{Op: "setxattr", Path: name, Err: err}
+	}
+	return nil
+}
+
+// GetXattr returns the extended attribute of the named file, syscall.ENODATA if it is not set.
+func (c *Client) GetXattr(name, key string) ([]byte, error) {
+	info, err := c.lookup(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	xattr, err := c.mw.XAttrGet_ll(info.Inode, key)
+	if err != nil {
+		return nil, &fs.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	value, ok := xattr.XAttrs[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "getxattr", Path: name, Err: syscall.ENODATA}
+	}
+	return []byte(value), nil
+}
+
+// ListXattr returns the names of the extended attributes of the named file.
+func (c *Client) ListXattr(name string) ([]string, error) {
+	info, err := c.lookup(name, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+	keys, err := c.mw.XAttrsList_ll(info.Inode)
+	if err != nil {
+		return nil, &fs.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+	return keys, nil
+}
+
+// RemoveXattr removes the extended attribute of the named file.
+func (c *Client) RemoveXattr(name, key string) error {
+	info, err := c.lookup(name, true)
+	if err == nil {
+		err = c.mw.XAttrDel_ll(info.Inode, key)
+	}
+	if err != nil {
+		return &fs.PathError{Op: "removexattr", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fsclient
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/mocktest/fsmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	require.Nil(t, split("."))
+	require.Equal(t, []string{"a"}, split("a"))
+	require.Equal(t, []string{"a", "b", "c"}, split("a/b/c"))
+}
+
+func TestInvalidPath(t *testing.T) {
+	c := &Client{}
+	for _, name := range []string{"/a", "a/", "a//b", "../a", "a/../b"} {
+		_, err := c.Open(name)
+		require.True(t, errors.Is(err, fs.ErrInvalid), name)
+		_, err = c.Stat(name)
+		require.True(t, errors.Is(err, fs.ErrInvalid), name)
+		require.True(t, errors.Is(c.Remove(name), fs.ErrInvalid), name)
+	}
+	// the root can not be removed or renamed
+	require.True(t, errors.Is(c.Remove("."), fs.ErrInvalid))
+	require.True(t, errors.Is(c.Rename(".", "a"), fs.ErrInvalid))
+}
+
+func TestFileInfo(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	info := &proto.InodeInfo{Inode: 10, Mode: proto.Mode(os.ModeDir | 0o755), Size: 4096, ModifyTime: mtime}
+	fi := newFileInfo("dir", info)
+	require.Equal(t, "dir", fi.Name())
+	require.True(t, fi.IsDir())
+	require.Equal(t, fs.ModeDir|0o755, fi.Mode())
+	require.Equal(t, mtime, fi.ModTime())
+	require.Equal(t, info, fi.Sys())
+
+	de := &dirEntry{dentry: proto.Dentry{Name: "link", Inode: 11, Type: proto.Mode(os.ModeSymlink | 0o777)}}
+	require.Equal(t, "link", de.Name())
+	require.False(t, de.IsDir())
+	require.Equal(t, fs.ModeSymlink, de.Type())
+}
+
+func TestFileReadDir(t *testing.T) {
+	info := &proto.InodeInfo{Inode: 10, Mode: proto.Mode(os.ModeDir | 0o755)}
+	f := newFile(&Client{}, "dir", os.O_RDONLY, info, 1)
+	for _, name := range []string{"a", "b", "c"} {
+		f.entries = append(f.entries, &dirEntry{dentry: proto.Dentry{Name: name}})
+	}
+
+	entries, err := f.ReadDir(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	entries, err = f.ReadDir(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "c", entries[0].Name())
+	_, err = f.ReadDir(2)
+	require.Equal(t, io.EOF, err)
+	entries, err = f.ReadDir(-1)
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+
+	_, err = f.Read(make([]byte, 1))
+	require.True(t, errors.Is(err, syscall.EISDIR))
+	require.NoError(t, f.Close())
+	_, err = f.ReadDir(-1)
+	require.True(t, errors.Is(err, fs.ErrClosed))
+}
+
+func newTestClient(t *testing.T) (*Client, *fsmock.ExtentClient) {
+	mw := fsmock.NewMetaWrapper()
+	ec := fsmock.NewExtentClient(mw)
+	c := &Client{rootIno: proto.RootIno, mw: mw, ec: ec}
+	t.Cleanup(func() { require.Zero(t, ec.OpenedStreams()) })
+	return c, ec
+}
+
+func TestClientReadWrite(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	f, err := c.Create("file")
+	require.NoError(t, err)
+	n, err := f.Write([]byte("hello "))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	_, err = f.WriteAt([]byte("world"), 6)
+	require.NoError(t, err)
+
+	off, err := f.Seek(0, io.SeekEnd)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, off)
+	_, err = f.Seek(-1, io.SeekStart)
+	require.True(t, errors.Is(err, syscall.EINVAL))
+	off, err = f.Seek(-5, io.SeekCurrent)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, off)
+	buf := make([]byte, 8)
+	n, err = f.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "world", string(buf[:n]))
+	_, err = f.Read(buf)
+	require.Equal(t, io.EOF, err)
+
+	// ReadAt returns io.EOF with the bytes read at the end of the file
+	n, err = f.ReadAt(make([]byte, 16), 3)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 8, n)
+	n, err = f.ReadAt(buf, 3)
+	require.NoError(t, err)
+	require.Equal(t, "lo world", string(buf[:n]))
+	n, err = f.ReadAt(buf[:4], 0)
+	require.NoError(t, err)
+	require.Equal(t, "hell", string(buf[:n]))
+	require.NoError(t, f.Truncate(5))
+	fi, err := f.Stat()
+	require.NoError(t, err)
+	require.EqualValues(t, 5, fi.Size())
+	require.NoError(t, f.Close())
+	require.True(t, errors.Is(f.Close(), fs.ErrClosed))
+	_, err = f.Write(buf)
+	require.True(t, errors.Is(err, fs.ErrClosed))
+
+	// a read only file can not be written, and Create truncates the file
+	rf, err := c.Open("file")
+	require.NoError(t, err)
+	_, err = rf.(*File).Write(buf)
+	require.True(t, errors.Is(err, syscall.EBADF))
+	require.NoError(t, rf.Close())
+	f, err = c.Create("file")
+	require.NoError(t, err)
+	fi, err = f.Stat()
+	require.NoError(t, err)
+	require.EqualValues(t, 0, fi.Size())
+	require.NoError(t, f.Close())
+
+	_, err = c.OpenFile("file", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	require.True(t, errors.Is(err, fs.ErrExist))
+	_, err = c.Open("none")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+	_, err = c.OpenFile(".", os.O_RDWR, 0)
+	require.True(t, errors.Is(err, syscall.EISDIR))
+}
+
+func TestClientRenameRemove(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	require.NoError(t, c.MkdirAll("dir/sub", 0o755))
+	for _, name := range []string{"a", "b", "dir/c"} {
+		f, err := c.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(name))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	require.NoError(t, c.Rename("a", "dir/a"))
+	_, err := c.Stat("a")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+	// a regular file is replaced, but not a directory
+	require.NoError(t, c.Rename("b", "dir/a"))
+	data, err := fs.ReadFile(c, "dir/a")
+	require.NoError(t, err)
+	require.Equal(t, "b", string(data))
+	err = c.Rename("dir/a", "dir/sub")
+	require.True(t, errors.Is(err, fs.ErrExist))
+	err = c.Rename("none", "x")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+
+	// only the empty directories are removed
+	require.True(t, errors.Is(c.Remove("dir"), syscall.ENOTEMPTY))
+	require.NoError(t, c.Remove("dir/sub"))
+	require.NoError(t, c.Remove("dir/a"))
+	require.NoError(t, c.Remove("dir/c"))
+	require.NoError(t, c.Remove("dir"))
+	require.True(t, errors.Is(c.Remove("dir"), fs.ErrNotExist))
+	entries, err := c.ReadDir(".")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestClientFS(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	files := map[string]string{
+		"a.txt":         "a",
+		"dir/b.txt":     "bb",
+		"dir/sub/c.txt": "",
+	}
+	for name, data := range files {
+		require.NoError(t, c.MkdirAll(path.Dir(name), 0o755))
+		f, err := c.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+	require.NoError(t, c.Symlink("dir/b.txt", "link"))
+	require.NoError(t, fstest.TestFS(c, "a.txt", "dir/b.txt", "dir/sub/c.txt", "link"))
+
+	sub, err := fs.Sub(c, "dir")
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(sub, "b.txt", "sub/c.txt"))
+}
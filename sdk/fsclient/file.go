@@ -0,0 +1,402 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	gopath "path"
+	"sync"
+	"syscall"
+
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/blobstore"
+)
+
+var (
+	_ fs.ReadDirFile = (*File)(nil)
+	_ io.ReaderAt    = (*File)(nil)
+	_ io.WriterAt    = (*File)(nil)
+	_ io.Seeker      = (*File)(nil)
+)
+
+// File is an opened file of a client, the methods are safe for concurrent use.
+type File struct {
+	c      *Client
+	name   string
+	flag   int
+	ino    uint64
+	pino   uint64
+	info   *proto.InodeInfo
+	opened bool // whether the stream of a regular file is opened
+
+	// cold volume
+	reader *blobstore.Reader
+	writer *blobstore.Writer
+
+	sync.Mutex
+	offset  int64
+	entries []fs.DirEntry // entries of the directory not returned by ReadDir yet, nil before the first call
+	closed  bool
+}
+
+func newFile(c *Client, name string, flag int, info *proto.InodeInfo, pino uint64) *File {
+	return &File{
+		c:    c,
+		name: name,
+		flag: flag,
+		ino:  info.Inode,
+		pino: pino,
+		info: info,
+	}
+}
+
+func (f *File) open() (err error) {
+	if err = f.c.ec.OpenStream(f.ino); err != nil {
+		return
+	}
+	f.opened = true
+	if proto.IsHot(f.c.volType) {
+		return
+	}
+
+	config := blobstore.ClientConfig{
+		VolName:         f.c.config.Volume,
+		VolType:         f.c.volType,
+		BlockSize:       f.c.ebsBlockSize,
+		Ino:             f.ino,
+		Mw:              f.c.metaWrapper(),
+		Ec:              f.c.extentClient(),
+		Ebsc:            f.c.ebsc,
+		WConcurrency:    f.c.config.WriteBlockThread,
+		ReadConcurrency: f.c.config.ReadBlockThread,
+		CacheAction:     f.c.cacheAction,
+		FileSize:        f.info.Size,
+		CacheThreshold:  f.c.cacheThreshold,
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		f.writer = blobstore.NewWriter(config)
+	}
+	if f.flag&os.O_WRONLY == 0 {
+		f.reader = blobstore.NewReader(config)
+	}
+	return
+}
+
+func (f *File) release() {
+	if !f.opened {
+		return
+	}
+	_ = f.c.ec.CloseStream(f.ino)
+	_ = f.c.ec.EvictStream(f.ino)
+	if f.writer != nil {
+		f.writer.FreeCache()
+		f.writer = nil
+	}
+	f.reader = nil
+	f.opened = false
+}
+
+func (f *File) ctx() context.Context {
+	_, ctx := trace.StartSpanFromContextWithTraceID(context.Background(), "", fmt.Sprintf("ino=%v", f.ino))
+	return ctx
+}
+
+// Name returns the name of the file as passed to Open.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Stat returns the FileInfo of the file.
+func (f *File) Stat() (fs.FileInfo, error) {
+	info, err := f.c.mw.InodeGet_ll(f.ino)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	if f.opened {
+		// the size in the meta node does not cover the data not flushed yet
+		if size, _, valid := f.c.ec.FileSize(f.ino); valid && uint64(size) > info.Size {
+			info.Size = uint64(size)
+		}
+	}
+	return newFileInfo(gopath.Base(f.name), info), nil
+}
+
+func (f *File) size() int64 {
+	if size, _, valid := f.c.ec.FileSize(f.ino); valid {
+		return int64(size)
+	}
+	if info, err := f.c.mw.InodeGet_ll(f.ino); err == nil {
+		return int64(info.Size)
+	}
+	return int64(f.info.Size)
+}
+
+func (f *File) checkRegular(op string, write bool) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+	if proto.IsDir(f.info.Mode) {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	}
+	if !f.opened {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EINVAL}
+	}
+	writable := f.flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if write && !writable || !write && f.flag&os.O_WRONLY != 0 {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	}
+	return nil
+}
+
+// Read reads from the current offset of the file.
+func (f *File) Read(p []byte) (n int, err error) {
+	f.Lock()
+	defer f.Unlock()
+	if err = f.checkRegular("read", false); err != nil {
+		return
+	}
+	n, err = f.readAt(p, f.offset)
+	f.offset += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return
+}
+
+// ReadAt reads len(p) bytes from the offset of the file, it returns io.EOF if less are read.
+func (f *File) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, &fs.PathError{Op: "readat", Path: f.name, Err: errors.New("negative offset")}
+	}
+	f.Lock()
+	err = f.checkRegular("read", false)
+	f.Unlock()
+	if err != nil {
+		return
+	}
+	return f.readAt(p, off)
+}
+
+func (f *File) readAt(p []byte, off int64) (n int, err error) {
+	size := f.size()
+	if off >= size {
+		return 0, io.EOF
+	}
+	want := len(p)
+	if int64(want) > size-off {
+		want = int(size - off)
+	}
+	if want == 0 {
+		return 0, nil
+	}
+
+	if proto.IsHot(f.c.volType) {
+		n, err = f.c.ec.Read(f.ino, p[:want], int(off), want)
+	} else {
+		n, err = f.reader.Read(f.ctx(), p[:want], int(off), want)
+	}
+	if err != nil && err != io.EOF {
+		return n, &fs.PathError{Op: "read", Path: f.name, Err: err}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes to the current offset of the file, or to the end in the append mode.
+func (f *File) Write(p []byte) (n int, err error) {
+	f.Lock()
+	defer f.Unlock()
+	if err = f.checkRegular("write", true); err != nil {
+		return
+	}
+	n, err = f.writeAt(p, f.offset)
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = f.size()
+	} else {
+		f.offset += int64(n)
+	}
+	return
+}
+
+// WriteAt writes len(p) bytes to the offset of the file, it is not allowed in the append mode.
+func (f *File) WriteAt(p []byte, off int64) (n int, err error) {
+	if f.flag&os.O_APPEND != 0 {
+		return 0, errors.New("fsclient: invalid use of WriteAt on file opened with O_APPEND")
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "writeat", Path: f.name, Err: errors.New("negative offset")}
+	}
+	f.Lock()
+	err = f.checkRegular("write", true)
+	f.Unlock()
+	if err != nil {
+		return
+	}
+	return f.writeAt(p, off)
+}
+
+func (f *File) writeAt(p []byte, off int64) (n int, err error) {
+	var flags int
+	if f.flag&os.O_APPEND != 0 || proto.IsCold(f.c.volType) {
+		flags |= proto.FlagsAppend | proto.FlagsSyncWrite
+	}
+
+	if proto.IsHot(f.c.volType) {
+		if s := f.c.ec.GetStreamer(f.ino); s != nil {
+			s.SetParentInode(f.pino)
+		}
+		checkFunc := func() error {
+			if !f.c.mw.QuotaEnabled() {
+				return nil
+			}
+			if f.c.ec.UidIsLimited(0) || f.c.mw.IsQuotaLimitedById(f.ino, true, false) {
+				return syscall.ENOSPC
+			}
+			return nil
+		}
+		n, err = f.c.ec.Write(f.ino, int(off), p, flags, checkFunc)
+	} else {
+		n, err = f.writer.Write(f.ctx(), int(off), p, flags)
+	}
+	if err != nil {
+		return n, &fs.PathError{Op: "write", Path: f.name, Err: err}
+	}
+	if f.flag&os.O_SYNC != 0 {
+		if err = f.sync(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Seek sets the offset of the next Read or Write, like os.File.Seek.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size()
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	// seeking a directory to the start rewinds its entries
+	if offset == 0 {
+		f.entries = nil
+	}
+	return offset, nil
+}
+
+// ReadDir reads the entries of the directory, like os.File.ReadDir.
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: fs.ErrClosed}
+	}
+	if !proto.IsDir(f.info.Mode) {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	if f.entries == nil {
+		entries, err := f.c.readDir(f.ino)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: err}
+		}
+		f.entries = entries
+	}
+
+	if n <= 0 || n > len(f.entries) {
+		if n > 0 && len(f.entries) == 0 {
+			return nil, io.EOF
+		}
+		n = len(f.entries)
+	}
+	entries := f.entries[:n:n]
+	f.entries = f.entries[n:]
+	return entries, nil
+}
+
+// Truncate changes the size of the file.
+func (f *File) Truncate(size int64) error {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.checkRegular("truncate", true); err != nil {
+		return err
+	}
+	if size < 0 {
+		return &fs.PathError{Op: "truncate", Path: f.name, Err: syscall.EINVAL}
+	}
+	if err := f.c.ec.Truncate(f.c.metaWrapper(), f.pino, f.ino, int(size), f.c.fullPath(f.name)); err != nil {
+		return &fs.PathError{Op: "truncate", Path: f.name, Err: err}
+	}
+	return nil
+}
+
+// Sync flushes the data written to the file.
+func (f *File) Sync() error {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return &fs.PathError{Op: "sync", Path: f.name, Err: fs.ErrClosed}
+	}
+	return f.sync()
+}
+
+func (f *File) sync() (err error) {
+	if !f.opened {
+		return nil
+	}
+	if proto.IsHot(f.c.volType) {
+		err = f.c.ec.Flush(f.ino)
+	} else if f.writer != nil {
+		err = f.writer.Flush(f.ino, f.ctx())
+	}
+	if err != nil {
+		return &fs.PathError{Op: "sync", Path: f.name, Err: err}
+	}
+	return nil
+}
+
+// Close flushes the data and releases the file.
+func (f *File) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	err := f.sync()
+	f.release()
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fsclient
+
+import (
+	"io/fs"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+// fileInfo implements fs.FileInfo, Sys returns the *proto.InodeInfo of the file.
+type fileInfo struct {
+	name string
+	info *proto.InodeInfo
+}
+
+func newFileInfo(name string, info *proto.InodeInfo) *fileInfo {
+	if name == "." || name == "/" {
+		name = "."
+	}
+	return &fileInfo{name: name, info: info}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return int64(fi.info.Size) }
+func (fi *fileInfo) Mode() fs.FileMode  { return proto.OsMode(fi.info.Mode) }
+func (fi *fileInfo) ModTime() time.Time { return fi.info.ModifyTime }
+func (fi *fileInfo) IsDir() bool        { return proto.IsDir(fi.info.Mode) }
+func (fi *fileInfo) Sys() interface{}   { return fi.info }
+
+// dirEntry implements fs.DirEntry, the inode is only fetched by Info.
+type dirEntry struct {
+	c      *Client
+	dentry proto.Dentry
+}
+
+func (de *dirEntry) Name() string      { return de.dentry.Name }
+func (de *dirEntry) IsDir() bool       { return proto.IsDir(de.dentry.Type) }
+func (de *dirEntry) Type() fs.FileMode { return proto.OsModeType(de.dentry.Type) }
+
+func (de *dirEntry) Info() (fs.FileInfo, error) {
+	info, err := de.c.mw.InodeGet_ll(de.dentry.Inode)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: de.dentry.Name, Err: err}
+	}
+	return newFileInfo(de.dentry.Name, info), nil
+}
@@ -274,6 +274,13 @@ func (api *AdminAPI) UpdateVolume(
 	request.addParam("replicaNum", strconv.FormatUint(uint64(vv.DpReplicaNum), 10))
 	request.addParam("enableQuota", strconv.FormatBool(vv.EnableQuota))
 	request.addParam("deleteLockTime", strconv.FormatInt(vv.DeleteLockTime, 10))
+	if proto.IsHot(vv.VolType) {
+		compressCodec := vv.CompressCodec
+		if compressCodec == "" {
+			compressCodec = "none"
+		}
+		request.addParam("compressCodec", compressCodec)
+	}
 	request.addParam("clientIDKey", clientIDKey)
 	if txMask != "" {
 		request.addParam("enableTxMask", txMask)
@@ -324,7 +331,7 @@ func (api *AdminAPI) CreateVolName(volName, owner string, capacity uint64, delet
 	mpCount, dpCount, replicaNum, dpSize, volType int, followerRead bool, zoneName, cacheRuleKey string, ebsBlkSize,
 	cacheCapacity, cacheAction, cacheThreshold, cacheTTL, cacheHighWater, cacheLowWater, cacheLRUInterval int,
 	dpReadOnlyWhenVolFull bool, txMask string, txTimeout uint32, txConflictRetryNum int64, txConflictRetryInterval int64, optEnableQuota string,
-	clientIDKey string,
+	metaStoreMode, compressCodec string, clientIDKey string,
 ) (err error) {
 	request := newRequest(get, proto.AdminCreateVol).Header(api.h)
 	request.addParam("name", volName)
@@ -352,6 +359,10 @@ func (api *AdminAPI) CreateVolName(volName, owner string, capacity uint64, delet
 	request.addParam("cacheLRUInterval", strconv.Itoa(cacheLRUInterval))
 	request.addParam("dpReadOnlyWhenVolFull", strconv.FormatBool(dpReadOnlyWhenVolFull))
 	request.addParam("enableQuota", optEnableQuota)
+	request.addParam("metaStoreMode", metaStoreMode)
+	if compressCodec != "" {
+		request.addParam("compressCodec", compressCodec)
+	}
 	request.addParam("clientIDKey", clientIDKey)
 	if txMask != "" {
 		request.addParam("enableTxMask", txMask)
@@ -400,6 +411,14 @@ func (api *AdminAPI) SetVolumeAuditLog(volName string, enable bool) (err error)
 	return
 }
 
+func (api *AdminAPI) SetVolumeReplication(volName string, enable bool) (err error) {
+	request := newRequest(post, proto.AdminVolEnableReplication).Header(api.h)
+	request.addParam("name", volName)
+	request.addParam("enable", strconv.FormatBool(enable))
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *AdminAPI) GetMonitorPushAddr() (addr string, err error) {
 	err = api.mc.requestWith(&addr, newRequest(get, proto.AdminGetMonitorPushAddr).Header(api.h))
 	return
@@ -474,6 +493,34 @@ func (api *AdminAPI) SetForbidMpDecommission(disable bool) (err error) {
 	return
 }
 
+// SetDpBalance updates the config of the data partition balancer, the empty params are left unchanged.
+func (api *AdminAPI) SetDpBalance(enable, threshold, maxMigrations string) (err error) {
+	params := make([]anyParam, 0)
+	if enable != "" {
+		params = append(params, anyParam{"enable", enable})
+	}
+	if threshold != "" {
+		params = append(params, anyParam{"threshold", threshold})
+	}
+	if maxMigrations != "" {
+		params = append(params, anyParam{"maxMigrations", maxMigrations})
+	}
+	_, err = api.mc.serveRequest(newRequest(get, proto.AdminSetDpBalance).Header(api.h).Param(params...))
+	return
+}
+
+// GetDpBalancePlan returns at most count migrations planned by the data partition balancer without starting them,
+// count is the max migrations of the balancer if it's zero.
+func (api *AdminAPI) GetDpBalancePlan(count int) (plan *proto.DpBalancePlan, err error) {
+	request := newRequest(get, proto.AdminGetDpBalancePlan).Header(api.h)
+	if count > 0 {
+		request.addParam("count", strconv.Itoa(count))
+	}
+	plan = &proto.DpBalancePlan{}
+	err = api.mc.requestWith(plan, request)
+	return
+}
+
 func (api *AdminAPI) SetMetaNodeThreshold(threshold float64, clientIDKey string) (err error) {
 	request := newRequest(get, proto.AdminSetMetaNodeThreshold).Header(api.h)
 	request.addParam("threshold", strconv.FormatFloat(threshold, 'f', 6, 64))
@@ -594,6 +641,63 @@ func (api *AdminAPI) GetQuota(volName string, quotaId string) (quotaInfo *proto.
 	return quotaInfo, err
 }
 
+func (api *AdminAPI) SetOwnerQuota(req *proto.SetOwnerQuotaRequest) (err error) {
+	request := newRequest(get, proto.QuotaOwnerSet).Header(api.h).Param(
+		anyParam{"name", req.VolName},
+		anyParam{"ownerType", proto.OwnerQuotaTypeString(req.Type)},
+		anyParam{"ownerId", req.Id},
+		anyParam{"hardFiles", req.HardFiles},
+		anyParam{"hardBytes", req.HardBytes},
+		anyParam{"softFiles", req.SoftFiles},
+		anyParam{"softBytes", req.SoftBytes},
+		anyParam{"gracePeriod", req.GracePeriod})
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[SetOwnerQuota] fail. %v", err)
+		return
+	}
+	log.LogInfof("action[SetOwnerQuota] success.")
+	return nil
+}
+
+func (api *AdminAPI) DeleteOwnerQuota(volName string, typ uint8, id uint32) (err error) {
+	request := newRequest(get, proto.QuotaOwnerDelete).Header(api.h).Param(
+		anyParam{"name", volName},
+		anyParam{"ownerType", proto.OwnerQuotaTypeString(typ)},
+		anyParam{"ownerId", id})
+	if _, err = api.mc.serveRequest(request); err != nil {
+		log.LogErrorf("action[DeleteOwnerQuota] fail. %v", err)
+		return
+	}
+	log.LogInfo("action[DeleteOwnerQuota] success.")
+	return nil
+}
+
+func (api *AdminAPI) GetOwnerQuota(volName string, typ uint8, id uint32) (quotaInfo *proto.OwnerQuotaInfo, err error) {
+	info := &proto.OwnerQuotaInfo{}
+	if err = api.mc.requestWith(info, newRequest(get, proto.QuotaOwnerGet).Header(api.h).Param(
+		anyParam{"name", volName},
+		anyParam{"ownerType", proto.OwnerQuotaTypeString(typ)},
+		anyParam{"ownerId", id})); err != nil {
+		log.LogErrorf("action[GetOwnerQuota] fail. %v", err)
+		return
+	}
+	return info, nil
+}
+
+// ReportOwnerQuota returns the quota and usage of users and groups in the volume, typ zero means both.
+func (api *AdminAPI) ReportOwnerQuota(volName string, typ uint8) (quotaInfos []*proto.OwnerQuotaInfo, err error) {
+	resp := &proto.OwnerQuotaReportResponse{}
+	request := newRequest(get, proto.QuotaOwnerReport).Header(api.h).addParam("name", volName)
+	if typ != 0 {
+		request.addParam("ownerType", proto.OwnerQuotaTypeString(typ))
+	}
+	if err = api.mc.requestWith(resp, request); err != nil {
+		log.LogErrorf("action[ReportOwnerQuota] fail. %v", err)
+		return
+	}
+	return resp.Quotas, nil
+}
+
 func (api *AdminAPI) QueryBadDisks() (badDisks *proto.DiskInfos, err error) {
 	badDisks = &proto.DiskInfos{}
 	err = api.mc.requestWith(badDisks, newRequest(get, proto.QueryBadDisks).Header(api.h))
@@ -698,6 +802,17 @@ func (api *AdminAPI) GetVerList(volName string) (verList *proto.VolVersionInfoLi
 	return
 }
 
+func (api *AdminAPI) SetSnapshotRetention(req *proto.SnapshotRetentionConfig) (err error) {
+	return api.mc.request(newRequest(post, proto.AdminSetSnapshotRetention).Header(api.h).Body(req))
+}
+
+func (api *AdminAPI) GetSnapshotRetention(volName string) (conf *proto.SnapshotRetentionConfig, err error) {
+	conf = &proto.SnapshotRetentionConfig{}
+	err = api.mc.requestWith(conf, newRequest(get, proto.AdminGetSnapshotRetention).
+		Header(api.h).addParam("name", volName))
+	return
+}
+
 func (api *AdminAPI) SetBucketLifecycle(req *proto.LcConfiguration) (err error) {
 	return api.mc.request(newRequest(post, proto.SetBucketLifecycle).Header(api.h).Body(req))
 }
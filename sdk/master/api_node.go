@@ -49,10 +49,11 @@ func (api *NodeAPI) AddDataNode(serverAddr, zoneName string) (id uint64, err err
 	return
 }
 
-func (api *NodeAPI) AddDataNodeWithAuthNode(serverAddr, zoneName, clientIDKey string) (id uint64, err error) {
+func (api *NodeAPI) AddDataNodeWithAuthNode(serverAddr, zoneName, rackName, clientIDKey string) (id uint64, err error) {
 	request := newRequest(get, proto.AddDataNode).Header(api.h)
 	request.addParam("addr", serverAddr)
 	request.addParam("zoneName", zoneName)
+	request.addParam("rackName", rackName)
 	request.addParam("clientIDKey", clientIDKey)
 	var data []byte
 	if data, err = api.mc.serveRequest(request); err != nil {
@@ -74,10 +75,11 @@ func (api *NodeAPI) AddMetaNode(serverAddr, zoneName string) (id uint64, err err
 	return
 }
 
-func (api *NodeAPI) AddMetaNodeWithAuthNode(serverAddr, zoneName, clientIDKey string) (id uint64, err error) {
+func (api *NodeAPI) AddMetaNodeWithAuthNode(serverAddr, zoneName, rackName, clientIDKey string) (id uint64, err error) {
 	request := newRequest(get, proto.AddMetaNode).Header(api.h)
 	request.addParam("addr", serverAddr)
 	request.addParam("zoneName", zoneName)
+	request.addParam("rackName", rackName)
 	request.addParam("clientIDKey", clientIDKey)
 	var data []byte
 	if data, err = api.mc.serveRequest(request); err != nil {
@@ -87,6 +89,15 @@ func (api *NodeAPI) AddMetaNodeWithAuthNode(serverAddr, zoneName, clientIDKey st
 	return
 }
 
+// SetNodeRack sets the rack of a data node or meta node, an empty rackName clears the rack of the node.
+func (api *NodeAPI) SetNodeRack(serverAddr, rackName string) (err error) {
+	request := newRequest(get, proto.AdminSetNodeInfo).Header(api.h)
+	request.addParam("addr", serverAddr)
+	request.addParam("rackName", rackName)
+	_, err = api.mc.serveRequest(request)
+	return
+}
+
 func (api *NodeAPI) GetDataNode(serverHost string) (node *proto.DataNodeInfo, err error) {
 	node = &proto.DataNodeInfo{}
 	err = api.mc.requestWith(node, newRequest(get, proto.GetDataNode).Header(api.h).addParam("addr", serverHost))
@@ -53,6 +53,15 @@ const (
 	ForceUpdateRWMP        = "ForceUpdateRWMP"
 )
 
+// the object lock kept in xattrs by objectnode
+const (
+	objectLockKey            = "oss:lock"
+	objectLockModeKey        = "oss:lock-mode"
+	objectLegalHoldKey       = "oss:legal-hold"
+	objectLockGovernanceMode = "GOVERNANCE"
+	objectLegalHoldOn        = "ON"
+)
+
 func (mw *MetaWrapper) GetRootIno(subdir string) (uint64, error) {
 	rootIno, err := mw.LookupPath(subdir)
 	if err != nil {
@@ -552,7 +561,13 @@ func (mw *MetaWrapper) Delete_Ver_ll(parentID uint64, name string, isDir bool, v
 }
 
 func (mw *MetaWrapper) DeleteWithCond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
-	return mw.deletewithcond_ll(parentID, cond, name, isDir, fullPath)
+	return mw.deletewithcond_ll(parentID, cond, name, isDir, false, fullPath)
+}
+
+// DeleteWithCondBypassGovernance_ll is the same as DeleteWithCond_ll, except that the object
+// protected by a retention in governance mode can be deleted.
+func (mw *MetaWrapper) DeleteWithCondBypassGovernance_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
+	return mw.deletewithcond_ll(parentID, cond, name, isDir, true, fullPath)
 }
 
 func (mw *MetaWrapper) txDelete_ll(parentID uint64, name string, isDir bool, fullPath string) (info *proto.InodeInfo, err error) {
@@ -796,19 +811,30 @@ func (mw *MetaWrapper) Delete_ll_EX(parentID uint64, name string, isDir bool, ve
 	return info, nil
 }
 
-func isObjectLocked(mw *MetaWrapper, inode uint64, name string) error {
-	xattrInfo, err := mw.XAttrGet_ll(inode, "oss:lock")
+// isObjectLocked checks the object lock of the object written by objectnode, the object is
+// locked if it is under legal hold or its retain until date is not reached.
+func isObjectLocked(mw *MetaWrapper, inode uint64, name string, bypassGovernance bool) error {
+	xattrInfo, err := mw.XAttrGetAll_ll(inode)
 	if err != nil {
 		log.LogErrorf("isObjectLocked: check ObjectLock err(%v) name(%v)", err, name)
 		return err
 	}
-	retainUntilDate := xattrInfo.Get("oss:lock")
+	if string(xattrInfo.Get(objectLegalHoldKey)) == objectLegalHoldOn {
+		log.LogWarnf("isObjectLocked: object is under legal hold, name(%v)", name)
+		return errors.New("Access Denied")
+	}
+	retainUntilDate := xattrInfo.Get(objectLockKey)
 	if len(retainUntilDate) > 0 {
 		retainUntilDateInt64, err := strconv.ParseInt(string(retainUntilDate), 10, 64)
 		if err != nil {
 			return err
 		}
 		if retainUntilDateInt64 > time.Now().UnixNano() {
+			if bypassGovernance && string(xattrInfo.Get(objectLockModeKey)) == objectLockGovernanceMode {
+				log.LogWarnf("isObjectLocked: bypass governance retention, retainUntilDate(%v) name(%v)",
+					retainUntilDateInt64, name)
+				return nil
+			}
 			log.LogWarnf("isObjectLocked: object is locked, retainUntilDate(%v) name(%v)", retainUntilDateInt64, name)
 			return errors.New("Access Denied")
 		}
@@ -816,8 +842,8 @@ func isObjectLocked(mw *MetaWrapper, inode uint64, name string) error {
 	return nil
 }
 
-func (mw *MetaWrapper) deletewithcond_ll(parentID, cond uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
-	err := isObjectLocked(mw, cond, name)
+func (mw *MetaWrapper) deletewithcond_ll(parentID, cond uint64, name string, isDir, bypassGovernance bool, fullPath string) (*proto.InodeInfo, error) {
+	err := isObjectLocked(mw, cond, name, bypassGovernance)
 	if err != nil {
 		return nil, err
 	}
@@ -1498,6 +1524,137 @@ func (mw *MetaWrapper) Truncate(inode, size uint64, fullPath string) error {
 	return nil
 }
 
+// PunchHole removes the extents within [offset, offset+size) of the file, the file size is left unchanged.
+func (mw *MetaWrapper) PunchHole(inode, offset, size uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("PunchHole: No inode partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+
+	status, err := mw.delExtentKey(mp, inode, offset, size, 0)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
+// PunchHoleWithCond punches the hole only if the file is of the generation,
+// it returns EINVAL if the file has been modified since.
+func (mw *MetaWrapper) PunchHoleWithCond(inode, offset, size, generation uint64) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("PunchHoleWithCond: No inode partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+
+	status, err := mw.delExtentKey(mp, inode, offset, size, generation)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	return nil
+}
+
+// CloneExtents copies [srcOff, srcOff+size) of the source file to dstOff of the destination file by
+// sharing the extents, and returns the bytes cloned. It returns EXDEV if the files are in different
+// meta partitions, or EINVAL if the range could not be cloned, the caller should copy the data instead.
+func (mw *MetaWrapper) CloneExtents(srcIno, dstIno, srcOff, dstOff, size uint64) (uint64, error) {
+	mp := mw.getPartitionByInode(srcIno)
+	if mp == nil {
+		log.LogErrorf("CloneExtents: No inode partition, ino(%v)", srcIno)
+		return 0, syscall.ENOENT
+	}
+	if dstMp := mw.getPartitionByInode(dstIno); dstMp == nil || dstMp.PartitionID != mp.PartitionID {
+		return 0, syscall.EXDEV
+	}
+
+	cloned, status, err := mw.cloneExtents(mp, srcIno, dstIno, srcOff, dstOff, size)
+	if err != nil || status != statusOK {
+		return 0, statusErrToErrno(status, err)
+	}
+	return cloned, nil
+}
+
+// CloneFile_ll creates a file under the parent which shares the extents of the source file, so that
+// the data is copied without being read. The new inode is created in the meta partition of the
+// source file and becomes visible once all the extents are cloned. The shared extents are copied on
+// write. It returns EINVAL if the source file could not be cloned, the caller should copy the data instead.
+func (mw *MetaWrapper) CloneFile_ll(srcIno, parentID uint64, name string, mode, uid, gid uint32, fullPath string) (*proto.InodeInfo, error) {
+	mp := mw.getPartitionByInode(srcIno)
+	if mp == nil {
+		log.LogErrorf("CloneFile_ll: No inode partition, ino(%v)", srcIno)
+		return nil, syscall.ENOENT
+	}
+	parentMP := mw.getPartitionByInode(parentID)
+	if parentMP == nil {
+		log.LogErrorf("CloneFile_ll: No parent partition, parentID(%v)", parentID)
+		return nil, syscall.ENOENT
+	}
+
+	status, srcInfo, err := mw.iget(mp, srcIno, mw.VerReadSeq)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	if !proto.IsRegular(srcInfo.Mode) {
+		return nil, syscall.EINVAL
+	}
+
+	var (
+		info     *proto.InodeInfo
+		quotaIds []uint32
+	)
+	if mw.EnableQuota {
+		quotaInfos, err := mw.getInodeQuota(parentMP, parentID)
+		if err != nil {
+			log.LogErrorf("CloneFile_ll: get parent quota fail, parentID(%v) err(%v)", parentID, err)
+			return nil, syscall.ENOENT
+		}
+		for quotaId := range quotaInfos {
+			quotaIds = append(quotaIds, quotaId)
+		}
+		status, info, err = mw.quotaIcreate(mp, mode, uid, gid, nil, quotaIds, fullPath)
+	} else {
+		status, info, err = mw.icreate(mp, mode, uid, gid, nil, fullPath)
+	}
+	if err != nil || status != statusOK {
+		log.LogErrorf("CloneFile_ll: create inode in mp(%v) status(%v) err(%v)", mp.PartitionID, status, err)
+		return nil, statusToErrno(status)
+	}
+
+	undo := func() {
+		mw.iunlink(mp, info.Inode, mw.Client.GetLatestVer(), 0, fullPath)
+		mw.ievict(mp, info.Inode, fullPath)
+	}
+
+	if srcInfo.Size > 0 {
+		var cloned uint64
+		cloned, status, err = mw.cloneExtents(mp, srcIno, info.Inode, 0, 0, srcInfo.Size)
+		if err != nil || status != statusOK {
+			log.LogErrorf("CloneFile_ll: clone ino(%v) to ino(%v) status(%v) err(%v)", srcIno, info.Inode, status, err)
+			undo()
+			return nil, statusErrToErrno(status, err)
+		}
+		info.Size = cloned
+	}
+
+	if mw.EnableQuota {
+		status, err = mw.quotaDcreate(parentMP, parentID, name, info.Inode, mode, quotaIds, fullPath)
+	} else {
+		status, err = mw.dcreate(parentMP, parentID, name, info.Inode, mode, fullPath)
+	}
+	if err != nil || status != statusOK {
+		if status != statusExist {
+			undo()
+		}
+		return nil, statusToErrno(status)
+	}
+	if mw.EnableSummary {
+		go mw.UpdateSummary_ll(parentID, 1, 0, int64(info.Size))
+	}
+	log.LogDebugf("CloneFile_ll: volume(%v) src(%v) parent(%v) name(%v) ino(%v) size(%v)", mw.volname, srcIno, parentID, name, info.Inode, info.Size)
+	return info, nil
+}
+
 func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
 	// if mw.EnableTransaction {
 	if mw.EnableTransaction&proto.TxOpMaskLink > 0 {
@@ -2072,6 +2229,20 @@ func (mw *MetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo,
 	return xAttr, nil
 }
 
+// IsTransitionReleased returns true if the data of the inode has been moved to a cold volume
+// by lifecycle transition and the hot extents are released.
+func (mw *MetaWrapper) IsTransitionReleased(inode uint64) (bool, error) {
+	xattr, err := mw.XAttrGet_ll(inode, proto.XAttrKeyTransitionState)
+	if err != nil {
+		return false, err
+	}
+	switch xattr.XAttrs[proto.XAttrKeyTransitionState] {
+	case proto.TransitionStateReleasing, proto.TransitionStateReleased:
+		return true, nil
+	}
+	return false, nil
+}
+
 // XAttrDel_ll is a low-level meta api that deletes specified xattr.
 func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
 	var err error
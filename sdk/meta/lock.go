@@ -0,0 +1,114 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The leases are renewed three times within a lease period, so that a lock
+// survives a couple of failed renewals.
+const lockRenewInterval = time.Duration(proto.DefaultFileLockLease) * time.Second / 3
+
+func newLockClientID() uint64 {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return uint64(time.Now().UnixNano())
+	}
+	return binary.BigEndian.Uint64(buf)
+}
+
+// LockClientID returns the id which identifies the file locks held by this client.
+func (mw *MetaWrapper) LockClientID() uint64 {
+	return mw.lockClientID
+}
+
+// SetLock_ll acquires or releases a file lock on the inode. If the lock is held by
+// others, the conflicting lock is returned and nothing is changed.
+func (mw *MetaWrapper) SetLock_ll(inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, err error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("SetLock_ll: no such partition, inode(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+
+	lock.ClientID = mw.lockClientID
+	if lock.Type != proto.FileLockUnlock {
+		mw.startLockRenewal(mp)
+	}
+	conflict, status, err := mw.setLock(mp, inode, lock)
+	if err != nil || status != statusOK {
+		return nil, statusErrToErrno(status, err)
+	}
+	log.LogDebugf("SetLock_ll: volume(%v) inode(%v) lock(%v) conflict(%v)", mw.volname, inode, lock, conflict)
+	return conflict, nil
+}
+
+// GetLock_ll returns the lock which conflicts with the given one, or nil if the lock could be acquired.
+func (mw *MetaWrapper) GetLock_ll(inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, err error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetLock_ll: no such partition, inode(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+
+	lock.ClientID = mw.lockClientID
+	conflict, status, err := mw.getLock(mp, inode, lock)
+	if err != nil || status != statusOK {
+		return nil, statusErrToErrno(status, err)
+	}
+	return conflict, nil
+}
+
+// startLockRenewal records the partition in which the client holds locks, and starts
+// renewing the leases of the locks if it is the first lock of the client.
+func (mw *MetaWrapper) startLockRenewal(mp *MetaPartition) {
+	mw.lockMutex.Lock()
+	mw.lockPartitions[mp.PartitionID] = mp
+	mw.lockMutex.Unlock()
+	mw.lockRenewOnce.Do(func() {
+		go mw.renewLockTick()
+	})
+}
+
+func (mw *MetaWrapper) renewLockTick() {
+	t := time.NewTicker(lockRenewInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mw.closeCh:
+			return
+		case <-t.C:
+			mw.lockMutex.Lock()
+			mps := make([]*MetaPartition, 0, len(mw.lockPartitions))
+			for _, mp := range mw.lockPartitions {
+				mps = append(mps, mp)
+			}
+			mw.lockMutex.Unlock()
+			for _, mp := range mps {
+				if _, err := mw.renewLock(mp); err != nil {
+					log.LogWarnf("renewLockTick: volume(%v) mp(%v) client(%v) err(%v)",
+						mw.volname, mp.PartitionID, mw.lockClientID, err)
+				}
+			}
+		}
+	}
+}
@@ -168,6 +168,13 @@ type MetaWrapper struct {
 	VerReadSeq uint64
 	LastVerSeq uint64
 	Client     wrapper.SimpleClientInfo
+
+	// identifies the holder of file locks across clients, and the partitions
+	// in which the leases of the file locks have to be renewed
+	lockClientID   uint64
+	lockPartitions map[uint64]*MetaPartition
+	lockMutex      sync.Mutex
+	lockRenewOnce  sync.Once
 }
 
 type uniqidRange struct {
@@ -221,6 +228,8 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.uniqidRangeMap = make(map[uint64]*uniqidRange)
 	mw.qc = NewQuotaCache(DefaultQuotaExpiration, MaxQuotaCache)
 	mw.VerReadSeq = config.VerReadSeq
+	mw.lockClientID = newLockClientID()
+	mw.lockPartitions = make(map[uint64]*MetaPartition)
 
 	limit := 0
 	for limit < MaxMountRetryLimit {
@@ -282,6 +291,10 @@ func (mw *MetaWrapper) VolCreateTime() int64 {
 	return mw.volCreateTime
 }
 
+func (mw *MetaWrapper) QuotaEnabled() bool {
+	return mw.EnableQuota
+}
+
 func (mw *MetaWrapper) Close() error {
 	mw.closeOnce.Do(func() {
 		close(mw.closeCh)
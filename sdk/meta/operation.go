@@ -1363,40 +1363,100 @@ func (mw *MetaWrapper) getObjExtents(mp *MetaPartition, inode uint64) (status in
 	return statusOK, resp.Generation, resp.Size, resp.Extents, resp.ObjExtents, nil
 }
 
-// func (mw *MetaWrapper) delExtentKey(mp *MetaPartition, inode uint64, extents []proto.ExtentKey) (status int, err error) {
-// 	req := &proto.DelExtentKeyRequest{
-// 		VolName:     mw.volname,
-// 		PartitionID: mp.PartitionID,
-// 		Inode:       inode,
-// 		Extents:     extents,
-// 	}
-
-// 	packet := proto.NewPacketReqID()
-// 	packet.Opcode = proto.OpMetaExtentsDel
-// 	packet.PartitionID = mp.PartitionID
-// 	err = packet.MarshalData(req)
-// 	if err != nil {
-// 		log.LogErrorf("delExtentKey: req(%v) err(%v)", *req, err)
-// 		return
-// 	}
-
-// 	metric := exporter.NewTPCnt(packet.GetOpMsg())
-// 	defer func() {
-// 		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
-// 	}()
-
-// 	packet, err = mw.sendToMetaPartition(mp, packet)
-// 	if err != nil {
-// 		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
-// 		return
-// 	}
-
-// 	status = parseStatus(packet.ResultCode)
-// 	if status != statusOK {
-// 		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
-// 	}
-// 	return status, nil
-// }
+func (mw *MetaWrapper) delExtentKey(mp *MetaPartition, inode, offset, size, generation uint64) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("delExtentKey", err, bgTime, 1)
+	}()
+
+	req := &proto.DelExtentKeyRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Size:        size,
+		Generation:  generation,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaExtentsDel
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("delExtentKey: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("delExtentKey: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	return statusOK, nil
+}
+
+func (mw *MetaWrapper) cloneExtents(mp *MetaPartition, srcIno, dstIno, srcOff, dstOff, size uint64) (cloned uint64, status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("cloneExtents", err, bgTime, 1)
+	}()
+
+	req := &proto.CloneExtentsRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		SrcInode:    srcIno,
+		DstInode:    dstIno,
+		SrcOffset:   srcOff,
+		DstOffset:   dstOff,
+		Size:        size,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaCloneExtents
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("cloneExtents: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.CloneExtentsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("cloneExtents: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	return resp.Size, statusOK, nil
+}
 
 func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64, fullPath string) (status int, err error) {
 	bgTime := stat.BeginStat()
@@ -2843,3 +2903,137 @@ func (mw *MetaWrapper) checkVerFromMeta(packet *proto.Packet) {
 	log.LogDebugf("checkVerFromMeta.UpdateLatestVer.try update meta wrapper verSeq from %v to %v verlist[%v]", mw.Client.GetLatestVer(), packet.VerSeq, packet.VerList)
 	mw.Client.UpdateLatestVer(&proto.VolVersionInfoList{VerList: packet.VerList})
 }
+
+func (mw *MetaWrapper) setLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("setLock", err, bgTime, 1)
+	}()
+
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+		Lease:       proto.DefaultFileLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	packet.PartitionID = mp.PartitionID
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("setLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.SetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("setLock: packet(%v) mp(%v) req(%v) conflict(%v)", packet, mp, *req, resp.Conflict)
+	return resp.Conflict, statusOK, nil
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("getLock", err, bgTime, 1)
+	}()
+
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	packet.PartitionID = mp.PartitionID
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("getLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("getLock: packet(%v) mp(%v) req(%v) lock(%v)", packet, mp, *req, resp.Lock)
+	return resp.Lock, statusOK, nil
+}
+
+func (mw *MetaWrapper) renewLock(mp *MetaPartition) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("renewLock", err, bgTime, 1)
+	}()
+
+	req := &proto.RenewLockRequest{
+		VolName:     mw.volname,
+		PartitionId: mp.PartitionID,
+		ClientID:    mw.lockClientID,
+		Lease:       proto.DefaultFileLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaRenewLock
+	packet.PartitionID = mp.PartitionID
+	if err = packet.MarshalData(req); err != nil {
+		log.LogErrorf("renewLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("renewLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("renewLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("renewLock: packet(%v) mp(%v) req(%v)", packet, mp, *req)
+	return
+}
@@ -39,6 +39,7 @@ var (
 	VerNotConsistentError            = errors.New("ver not consistent")
 	SnapshotNeedNewExtentError       = errors.New("snapshot need new extent error")
 	NoDiskReadRepairExtentTokenError = errors.New("no disk read repair extent token")
+	BlockChangedError                = errors.New("block has been changed")
 )
 
 func newParameterError(format string, a ...interface{}) error {
@@ -0,0 +1,121 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"hash/crc32"
+	"io"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+func (e *Extent) blockSize(blockNo int) int {
+	extSize := e.Size()
+	if e.snapshotDataOff > util.ExtentSize {
+		extSize = int64(e.snapshotDataOff)
+	}
+	offset := int64(blockNo) * util.BlockSize
+	if offset >= extSize {
+		return 0
+	}
+	return int(util.Min(int(extSize-offset), util.BlockSize))
+}
+
+func (s *ExtentStore) normalExtentWithHeader(extentID uint64) (ei *ExtentInfo, e *Extent, err error) {
+	if !proto.IsNormalDp(s.partitionType) || IsTinyExtent(extentID) {
+		err = ParameterMismatchError
+		return
+	}
+	s.eiMutex.RLock()
+	ei = s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	e, err = s.extentWithHeader(ei)
+	return
+}
+
+// ScrubBlock reads a block of a normal extent into data, which must hold util.BlockSize bytes.
+// It returns the size of the block, the crc of the data on the disk and the crc kept in the header,
+// which is 0 if unknown. The size is 0 if the block is beyond the end of the extent.
+func (s *ExtentStore) ScrubBlock(extentID uint64, blockNo int, data []byte) (size int, dataCrc, crc uint32, err error) {
+	var e *Extent
+	if _, e, err = s.normalExtentWithHeader(extentID); err != nil {
+		return
+	}
+	// hold the lock of the extent so that the data and the header of the block are not changed by a write in between
+	e.Lock()
+	defer e.Unlock()
+	if size = e.blockSize(blockNo); size == 0 {
+		return
+	}
+	var readN int
+	if readN, err = e.file.ReadAt(data[:size], int64(blockNo)*util.BlockSize); err != nil && !(err == io.EOF && readN == size) {
+		return
+	}
+	err = nil
+	dataCrc = crc32.ChecksumIEEE(data[:size])
+	crc = e.GetCrc(int64(blockNo))
+	return
+}
+
+// RepairBlock overwrites a block of a normal extent with the data of another replica and updates its crc in the header.
+// The block is only overwritten if the crc of its data on the disk is still corruptCrc, so that the data of a write
+// which lands during the repair is never replaced.
+func (s *ExtentStore) RepairBlock(extentID uint64, blockNo int, data []byte, corruptCrc uint32) (err error) {
+	var (
+		ei *ExtentInfo
+		e  *Extent
+	)
+	if ei, e, err = s.normalExtentWithHeader(extentID); err != nil {
+		return
+	}
+
+	e.Lock()
+	size := e.blockSize(blockNo)
+	if size != len(data) {
+		e.Unlock()
+		return BlockChangedError
+	}
+	offset := int64(blockNo) * util.BlockSize
+	local := make([]byte, size)
+	if _, err = e.file.ReadAt(local, offset); err != nil && err != io.EOF {
+		e.Unlock()
+		return
+	}
+	if crc32.ChecksumIEEE(local) != corruptCrc {
+		e.Unlock()
+		return BlockChangedError
+	}
+	if _, err = e.file.WriteAt(data, offset); err != nil {
+		e.Unlock()
+		return
+	}
+	if err = e.file.Sync(); err != nil {
+		e.Unlock()
+		return
+	}
+	crc := crc32.ChecksumIEEE(data)
+	err = s.PersistenceBlockCrc(e, blockNo, crc)
+	e.Unlock()
+	if err != nil {
+		return
+	}
+	// the crc of the extent is computed again from the headers of the blocks
+	ei.UpdateExtentInfo(e, 0)
+	log.LogWarnf("action[RepairBlock] dp %v extent %v block %v size %v crc %v corruptCrc %v repaired",
+		s.partitionID, extentID, blockNo, size, crc, corruptCrc)
+	return
+}
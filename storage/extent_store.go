@@ -1049,7 +1049,9 @@ func (s *ExtentStore) ScanBlocks(extentID uint64) (bcs []*BlockCrc, err error) {
 
 	var blockCnt int
 	bcs = make([]*BlockCrc, 0)
+	s.eiMutex.RLock()
 	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
 	e, err := s.extentWithHeader(ei)
 	if err != nil {
 		return bcs, err
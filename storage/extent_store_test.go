@@ -256,3 +256,59 @@ func TestExtentStores(t *testing.T) {
 		ExtentStoreTest(t, ty)
 	}
 }
+
+func TestExtentStoreScrubBlock(t *testing.T) {
+	path, clean, err := getTestPathExtentStore()
+	require.NoError(t, err)
+	defer clean()
+	s, err := storage.NewExtentStore(path, 0, 1*util.GB, proto.PartitionTypeNormal, true)
+	require.NoError(t, err)
+	defer s.Close()
+	id, err := s.NextExtentID()
+	require.NoError(t, err)
+	require.NoError(t, s.Create(id))
+
+	// a full block with its crc in the header and a partial block without crc
+	block := []byte(strings.Repeat("a", util.BlockSize))
+	crc := crc32.ChecksumIEEE(block)
+	_, err = s.Write(id, 0, util.BlockSize, block, crc, storage.AppendWriteType, true, false)
+	require.NoError(t, err)
+	tail := []byte(dataStr)
+	_, err = s.Write(id, util.BlockSize, int64(len(tail)), tail, crc32.ChecksumIEEE(tail), storage.AppendWriteType, true, false)
+	require.NoError(t, err)
+
+	data := make([]byte, util.BlockSize)
+	size, dataCrc, headerCrc, err := s.ScrubBlock(id, 0, data)
+	require.NoError(t, err)
+	require.Equal(t, util.BlockSize, size)
+	require.Equal(t, crc, dataCrc)
+	require.Equal(t, crc, headerCrc)
+	size, dataCrc, headerCrc, err = s.ScrubBlock(id, 1, data)
+	require.NoError(t, err)
+	require.Equal(t, len(tail), size)
+	require.Equal(t, crc32.ChecksumIEEE(tail), dataCrc)
+	require.EqualValues(t, 0, headerCrc)
+	size, _, _, err = s.ScrubBlock(id, 2, data)
+	require.NoError(t, err)
+	require.Equal(t, 0, size)
+
+	// flip a byte of the first block on the disk
+	f, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%v", id)), os.O_RDWR, 0o666)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("b"), 100)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	_, dataCrc, headerCrc, err = s.ScrubBlock(id, 0, data)
+	require.NoError(t, err)
+	require.Equal(t, crc, headerCrc)
+	require.NotEqual(t, crc, dataCrc)
+
+	// the repair is rejected if the block has been changed since the scrub
+	require.Equal(t, storage.BlockChangedError, s.RepairBlock(id, 0, block, crc))
+	require.Equal(t, storage.BlockChangedError, s.RepairBlock(id, 0, block[:10], dataCrc))
+	require.NoError(t, s.RepairBlock(id, 0, block, dataCrc))
+	_, dataCrc, headerCrc, err = s.ScrubBlock(id, 0, data)
+	require.NoError(t, err)
+	require.Equal(t, crc, dataCrc)
+	require.Equal(t, crc, headerCrc)
+}
@@ -14,7 +14,15 @@
 
 package compressor
 
-const EncodingGzip = "gzip"
+const (
+	EncodingGzip = "gzip"
+	EncodingLz4  = "lz4"
+	EncodingZstd = "zstd"
+)
+
+// codecs are the encodings indexed by their codec ids, the id is kept in the
+// metadata of the compressed data, so the order must never be changed.
+var codecs = []string{"", EncodingGzip, EncodingLz4, EncodingZstd}
 
 // Compressor bytes compressor.
 // TODO: add stream Compressor.
@@ -33,6 +41,8 @@ var compressors = make(map[string]func() Compressor)
 func init() {
 	compressors[""] = func() Compressor { return none{} }
 	compressors[EncodingGzip] = func() Compressor { return gzipCompressor{} }
+	compressors[EncodingLz4] = func() Compressor { return lz4Compressor{} }
+	compressors[EncodingZstd] = func() Compressor { return zstdCompressor{} }
 }
 
 func New(encoding string) Compressor {
@@ -41,3 +51,21 @@ func New(encoding string) Compressor {
 	}
 	return compressors[""]()
 }
+
+// Codec returns the codec id of the encoding, 0 means no compression.
+func Codec(encoding string) (uint8, bool) {
+	for codec, e := range codecs {
+		if e == encoding {
+			return uint8(codec), true
+		}
+	}
+	return 0, false
+}
+
+// Encoding returns the encoding of the codec id.
+func Encoding(codec uint8) (string, bool) {
+	if int(codec) >= len(codecs) {
+		return "", false
+	}
+	return codecs[codec], true
+}
@@ -15,6 +15,7 @@
 package compressor_test
 
 import (
+	"bytes"
 	"crypto/rand"
 	"testing"
 
@@ -37,3 +38,35 @@ func TestCompressor_New(t *testing.T) {
 		require.Equal(t, buf, pbuf)
 	}
 }
+
+func TestCompressor_Encodings(t *testing.T) {
+	buf := bytes.Repeat([]byte("cubefs compressor "), 1024)
+	for _, encoding := range []string{compressor.EncodingGzip, compressor.EncodingLz4, compressor.EncodingZstd} {
+		c := compressor.New(encoding)
+		cbuf, err := c.Compress(buf)
+		require.NoError(t, err)
+		require.Less(t, len(cbuf), len(buf)/10, encoding)
+		pbuf, err := c.Decompress(cbuf)
+		require.NoError(t, err)
+		require.Equal(t, buf, pbuf)
+
+		_, err = c.Decompress(buf[:100])
+		require.Error(t, err, encoding)
+	}
+}
+
+func TestCompressor_Codec(t *testing.T) {
+	for _, encoding := range []string{"", compressor.EncodingGzip, compressor.EncodingLz4, compressor.EncodingZstd} {
+		codec, ok := compressor.Codec(encoding)
+		require.True(t, ok)
+		e, ok := compressor.Encoding(codec)
+		require.True(t, ok)
+		require.Equal(t, encoding, e)
+	}
+	codec, _ := compressor.Codec("")
+	require.Equal(t, uint8(0), codec)
+	_, ok := compressor.Codec("balaa")
+	require.False(t, ok)
+	_, ok = compressor.Encoding(100)
+	require.False(t, ok)
+}
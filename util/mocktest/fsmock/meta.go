@@ -0,0 +1,450 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package fsmock provides in-memory fakes of the meta wrapper and the extent client,
+// so that the clients built on them can be tested without a cluster.
+package fsmock
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/meta"
+)
+
+type inode struct {
+	info   proto.InodeInfo
+	xattrs map[string]string
+	dentry map[string]uint64 // dir only
+	data   []byte            // regular file only
+}
+
+// MetaWrapper is a fake of meta.MetaWrapper which keeps the inodes and dentries in memory.
+type MetaWrapper struct {
+	sync.Mutex
+	inodes  map[uint64]*inode
+	nextIno uint64
+
+	EnableQuota bool
+	// TotalSpace and UsedSpace are returned by Statfs.
+	TotalSpace uint64
+	UsedSpace  uint64
+}
+
+// NewMetaWrapper returns a fake with the root directory only.
+func NewMetaWrapper() *MetaWrapper {
+	mw := &MetaWrapper{
+		inodes:     make(map[uint64]*inode),
+		nextIno:    proto.RootIno + 1,
+		TotalSpace: 1 << 30,
+	}
+	mw.inodes[proto.RootIno] = mw.newInode(proto.RootIno, proto.Mode(os.ModeDir|0o755), 0, 0, nil)
+	return mw
+}
+
+func (mw *MetaWrapper) newInode(ino uint64, mode, uid, gid uint32, target []byte) *inode {
+	now := time.Now()
+	nlink := uint32(1)
+	if proto.IsDir(mode) {
+		nlink = 2
+	}
+	i := &inode{
+		info: proto.InodeInfo{
+			Inode:      ino,
+			Mode:       mode,
+			Nlink:      nlink,
+			Uid:        uid,
+			Gid:        gid,
+			Generation: 1,
+			ModifyTime: now,
+			CreateTime: now,
+			AccessTime: now,
+			Target:     target,
+		},
+		xattrs: make(map[string]string),
+	}
+	if proto.IsDir(mode) {
+		i.dentry = make(map[string]uint64)
+	}
+	return i
+}
+
+func (mw *MetaWrapper) getInode(ino uint64) (*inode, error) {
+	i, ok := mw.inodes[ino]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	return i, nil
+}
+
+func (mw *MetaWrapper) getDir(ino uint64) (*inode, error) {
+	i, err := mw.getInode(ino)
+	if err != nil {
+		return nil, err
+	}
+	if i.dentry == nil {
+		return nil, syscall.ENOTDIR
+	}
+	return i, nil
+}
+
+func (i *inode) copyInfo() *proto.InodeInfo {
+	info := i.info
+	return &info
+}
+
+func (mw *MetaWrapper) LookupPath(subdir string) (uint64, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	ino := proto.RootIno
+	for _, name := range strings.Split(subdir, "/") {
+		if name == "" {
+			continue
+		}
+		dir, err := mw.getDir(ino)
+		if err != nil {
+			return 0, err
+		}
+		child, ok := dir.dentry[name]
+		if !ok {
+			return 0, syscall.ENOENT
+		}
+		ino = child
+	}
+	return ino, nil
+}
+
+func (mw *MetaWrapper) Statfs() (total, used, inodeCount uint64) {
+	mw.Lock()
+	defer mw.Unlock()
+	return mw.TotalSpace, mw.UsedSpace, uint64(len(mw.inodes))
+}
+
+func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte, fullPath string) (*proto.InodeInfo, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := dir.dentry[name]; ok {
+		return nil, syscall.EEXIST
+	}
+	i := mw.newInode(mw.nextIno, mode, uid, gid, target)
+	if proto.IsSymlink(mode) {
+		i.info.Size = uint64(len(target))
+	}
+	mw.nextIno++
+	mw.inodes[i.info.Inode] = i
+	dir.dentry[name] = i.info.Inode
+	if proto.IsDir(mode) {
+		dir.info.Nlink++
+	}
+	return i.copyInfo(), nil
+}
+
+func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	ino, ok := dir.dentry[name]
+	if !ok {
+		return 0, 0, syscall.ENOENT
+	}
+	return ino, mw.inodes[ino].info.Mode, nil
+}
+
+func (mw *MetaWrapper) InodeGet_ll(ino uint64) (*proto.InodeInfo, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(ino)
+	if err != nil {
+		return nil, err
+	}
+	return i.copyInfo(), nil
+}
+
+func (mw *MetaWrapper) BatchInodeGet(inodes []uint64) []*proto.InodeInfo {
+	mw.Lock()
+	defer mw.Unlock()
+	infos := make([]*proto.InodeInfo, 0, len(inodes))
+	for _, ino := range inodes {
+		if i, ok := mw.inodes[ino]; ok {
+			infos = append(infos, i.copyInfo())
+		}
+	}
+	return infos
+}
+
+func (mw *MetaWrapper) unlink(i *inode) {
+	if i.info.Nlink--; i.info.Nlink == 0 || i.dentry != nil {
+		delete(mw.inodes, i.info.Inode)
+	}
+}
+
+func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool, fullPath string) (*proto.InodeInfo, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentID)
+	if err != nil {
+		return nil, err
+	}
+	ino, ok := dir.dentry[name]
+	if !ok {
+		return nil, syscall.ENOENT
+	}
+	i := mw.inodes[ino]
+	if isDir != (i.dentry != nil) {
+		if isDir {
+			return nil, syscall.ENOTDIR
+		}
+		return nil, syscall.EISDIR
+	}
+	if isDir {
+		if len(i.dentry) > 0 {
+			return nil, syscall.ENOTEMPTY
+		}
+		dir.info.Nlink--
+	}
+	delete(dir.dentry, name)
+	mw.unlink(i)
+	return i.copyInfo(), nil
+}
+
+func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string, srcFullPath string, dstFullPath string, overwritten bool) error {
+	mw.Lock()
+	defer mw.Unlock()
+	srcDir, err := mw.getDir(srcParentID)
+	if err != nil {
+		return err
+	}
+	dstDir, err := mw.getDir(dstParentID)
+	if err != nil {
+		return err
+	}
+	ino, ok := srcDir.dentry[srcName]
+	if !ok {
+		return syscall.ENOENT
+	}
+	src := mw.inodes[ino]
+	if old, ok := dstDir.dentry[dstName]; ok {
+		if old == ino {
+			return nil
+		}
+		// the same as the meta nodes, only regular files are allowed to be overwritten
+		dst := mw.inodes[old]
+		if !overwritten || !proto.IsRegular(dst.info.Mode) || !proto.IsRegular(src.info.Mode) {
+			return syscall.EEXIST
+		}
+		mw.unlink(dst)
+	}
+	delete(srcDir.dentry, srcName)
+	dstDir.dentry[dstName] = ino
+	if src.dentry != nil {
+		srcDir.info.Nlink--
+		dstDir.info.Nlink++
+	}
+	return nil
+}
+
+func (mw *MetaWrapper) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentID)
+	if err != nil {
+		return nil, err
+	}
+	dentries := make([]proto.Dentry, 0, len(dir.dentry))
+	for name, ino := range dir.dentry {
+		dentries = append(dentries, proto.Dentry{Name: name, Inode: ino, Type: mw.inodes[ino].info.Mode})
+	}
+	sort.Slice(dentries, func(i, j int) bool { return dentries[i].Name < dentries[j].Name })
+	return dentries, nil
+}
+
+func (mw *MetaWrapper) CloneFile_ll(srcIno, parentID uint64, name string, mode, uid, gid uint32, fullPath string) (*proto.InodeInfo, error) {
+	mw.Lock()
+	src, err := mw.getInode(srcIno)
+	if err != nil {
+		mw.Unlock()
+		return nil, err
+	}
+	data := append([]byte(nil), src.data...)
+	mw.Unlock()
+
+	info, err := mw.Create_ll(parentID, name, mode, uid, gid, nil, fullPath)
+	if err != nil {
+		return nil, err
+	}
+	mw.Lock()
+	defer mw.Unlock()
+	i := mw.inodes[info.Inode]
+	i.data = data
+	i.info.Size = uint64(len(data))
+	return i.copyInfo(), nil
+}
+
+func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64, fullPath string) (*proto.InodeInfo, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentID)
+	if err != nil {
+		return nil, err
+	}
+	i, err := mw.getInode(ino)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := dir.dentry[name]; ok {
+		return nil, syscall.EEXIST
+	}
+	dir.dentry[name] = ino
+	i.info.Nlink++
+	return i.copyInfo(), nil
+}
+
+func (mw *MetaWrapper) Evict(inode uint64, fullPath string) error {
+	return nil
+}
+
+func (mw *MetaWrapper) Setattr(inode uint64, valid, mode, uid, gid uint32, atime, mtime int64) error {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(inode)
+	if err != nil {
+		return err
+	}
+	if valid&proto.AttrMode != 0 {
+		i.info.Mode = i.info.Mode&^uint32(0o7777) | mode&0o7777
+	}
+	if valid&proto.AttrUid != 0 {
+		i.info.Uid = uid
+	}
+	if valid&proto.AttrGid != 0 {
+		i.info.Gid = gid
+	}
+	if valid&proto.AttrAccessTime != 0 {
+		i.info.AccessTime = time.Unix(atime, 0)
+	}
+	if valid&proto.AttrModifyTime != 0 {
+		i.info.ModifyTime = time.Unix(mtime, 0)
+	}
+	return nil
+}
+
+func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
+	return mw.BatchSetXAttr_ll(inode, map[string]string{string(name): string(value)})
+}
+
+func (mw *MetaWrapper) BatchSetXAttr_ll(inode uint64, attrs map[string]string) error {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(inode)
+	if err != nil {
+		return err
+	}
+	for k, v := range attrs {
+		i.xattrs[k] = v
+	}
+	return nil
+}
+
+func (mw *MetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(inode)
+	if err != nil {
+		return nil, err
+	}
+	xattrs := make(map[string]string)
+	if v, ok := i.xattrs[name]; ok {
+		xattrs[name] = v
+	}
+	return &proto.XAttrInfo{Inode: inode, XAttrs: xattrs}, nil
+}
+
+func (mw *MetaWrapper) XAttrDel_ll(inode uint64, name string) error {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(inode)
+	if err != nil {
+		return err
+	}
+	delete(i.xattrs, name)
+	return nil
+}
+
+func (mw *MetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
+	mw.Lock()
+	defer mw.Unlock()
+	i, err := mw.getInode(inode)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(i.xattrs))
+	for k := range i.xattrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// GetSummary_ll counts the files, sub directories and bytes under the directory recursively.
+func (mw *MetaWrapper) GetSummary_ll(parentIno uint64, goroutineNum int32) (summary meta.SummaryInfo, err error) {
+	mw.Lock()
+	defer mw.Unlock()
+	dir, err := mw.getDir(parentIno)
+	if err != nil {
+		return
+	}
+	var walk func(dir *inode)
+	walk = func(dir *inode) {
+		for _, ino := range dir.dentry {
+			i := mw.inodes[ino]
+			if i.dentry != nil {
+				summary.Subdirs++
+				walk(i)
+			} else {
+				summary.Files++
+				summary.Fbytes += int64(i.info.Size)
+			}
+		}
+	}
+	walk(dir)
+	return
+}
+
+func (mw *MetaWrapper) RefreshSummary_ll(parentIno uint64, goroutineNum int32) error {
+	return nil
+}
+
+func (mw *MetaWrapper) IsQuotaLimitedById(inodeId uint64, size bool, files bool) bool {
+	return false
+}
+
+func (mw *MetaWrapper) QuotaEnabled() bool {
+	return mw.EnableQuota
+}
+
+func (mw *MetaWrapper) Close() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fsmock
+
+import (
+	"io"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/cubefs/cubefs/sdk/data/stream"
+	"github.com/cubefs/cubefs/sdk/meta"
+)
+
+// ExtentClient is a fake of stream.ExtentClient which keeps the file data in the
+// inodes of the MetaWrapper, so the sizes of both stay consistent.
+type ExtentClient struct {
+	mw *MetaWrapper
+
+	EnablePosixAcl bool
+	// FlushErr is returned by Flush if set.
+	FlushErr error
+	// ReadHook is called before each read if set, e.g. to block the read.
+	ReadHook func(inode uint64, offset int)
+	// opened counts the streams opened and not closed yet
+	opened int64
+}
+
+func NewExtentClient(mw *MetaWrapper) *ExtentClient {
+	return &ExtentClient{mw: mw}
+}
+
+// OpenedStreams returns the number of the streams opened and not closed.
+func (ec *ExtentClient) OpenedStreams() int64 {
+	return atomic.LoadInt64(&ec.opened)
+}
+
+func (ec *ExtentClient) OpenStream(inode uint64) error {
+	atomic.AddInt64(&ec.opened, 1)
+	return nil
+}
+
+func (ec *ExtentClient) CloseStream(inode uint64) error {
+	atomic.AddInt64(&ec.opened, -1)
+	return nil
+}
+
+func (ec *ExtentClient) EvictStream(inode uint64) error {
+	return nil
+}
+
+func (ec *ExtentClient) FileSize(inode uint64) (size int, gen uint64, valid bool) {
+	ec.mw.Lock()
+	defer ec.mw.Unlock()
+	i, ok := ec.mw.inodes[inode]
+	if !ok {
+		return 0, 0, false
+	}
+	return int(i.info.Size), i.info.Generation, true
+}
+
+func (ec *ExtentClient) Flush(inode uint64) error {
+	return ec.FlushErr
+}
+
+func (ec *ExtentClient) ForceRefreshExtentsCache(inode uint64) error {
+	return nil
+}
+
+func (ec *ExtentClient) GetEnablePosixAcl() bool {
+	return ec.EnablePosixAcl
+}
+
+// GetStreamer returns nil, there is no streamer behind the fake.
+func (ec *ExtentClient) GetStreamer(inode uint64) *stream.Streamer {
+	return nil
+}
+
+// Read reads the data like the streamer, which returns io.EOF with the data read
+// if the range exceeds the file size.
+func (ec *ExtentClient) Read(inode uint64, data []byte, offset int, size int) (read int, err error) {
+	if size == 0 {
+		return
+	}
+	if ec.ReadHook != nil {
+		ec.ReadHook(inode, offset)
+	}
+	ec.mw.Lock()
+	defer ec.mw.Unlock()
+	i, err := ec.mw.getInode(inode)
+	if err != nil {
+		return 0, err
+	}
+	if offset >= len(i.data) {
+		return 0, io.EOF
+	}
+	read = copy(data[:size], i.data[offset:])
+	if read < size {
+		err = io.EOF
+	}
+	return
+}
+
+func (ec *ExtentClient) Write(inode uint64, offset int, data []byte, flags int, checkFunc func() error) (write int, err error) {
+	if checkFunc != nil {
+		if err = checkFunc(); err != nil {
+			return 0, err
+		}
+	}
+	ec.mw.Lock()
+	defer ec.mw.Unlock()
+	i, err := ec.mw.getInode(inode)
+	if err != nil {
+		return 0, err
+	}
+	if i.dentry != nil {
+		return 0, syscall.EISDIR
+	}
+	if end := offset + len(data); end > len(i.data) {
+		i.data = append(i.data, make([]byte, end-len(i.data))...)
+	}
+	copy(i.data[offset:], data)
+	i.info.Size = uint64(len(i.data))
+	i.info.Generation++
+	return len(data), nil
+}
+
+// Truncate ignores the meta wrapper, which is only for the summary of the real client.
+func (ec *ExtentClient) Truncate(mw *meta.MetaWrapper, parentIno uint64, inode uint64, size int, fullPath string) error {
+	ec.mw.Lock()
+	defer ec.mw.Unlock()
+	i, err := ec.mw.getInode(inode)
+	if err != nil {
+		return err
+	}
+	if size <= len(i.data) {
+		i.data = i.data[:size]
+	} else {
+		i.data = append(i.data, make([]byte, size-len(i.data))...)
+	}
+	i.info.Size = uint64(size)
+	i.info.Generation++
+	return nil
+}
+
+func (ec *ExtentClient) UidIsLimited(uid uint32) bool {
+	return false
+}
+
+func (ec *ExtentClient) Close() error {
+	return nil
+}
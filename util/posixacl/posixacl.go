@@ -0,0 +1,255 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package posixacl parses and evaluates the POSIX ACLs stored in the
+// system.posix_acl_access and system.posix_acl_default xattrs, which are
+// written by setfacl through the fuse client in the format of the linux kernel.
+package posixacl
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	XattrAccess  = "system.posix_acl_access"
+	XattrDefault = "system.posix_acl_default"
+
+	Version = 2
+)
+
+// tags of the ACL entries
+const (
+	TagUserObj  uint16 = 0x01
+	TagUser     uint16 = 0x02
+	TagGroupObj uint16 = 0x04
+	TagGroup    uint16 = 0x08
+	TagMask     uint16 = 0x10
+	TagOther    uint16 = 0x20
+)
+
+// permissions of the ACL entries
+const (
+	PermExec  uint16 = 0x1
+	PermWrite uint16 = 0x2
+	PermRead  uint16 = 0x4
+	PermRWX          = PermRead | PermWrite | PermExec
+)
+
+// UndefinedId is the id of the entries which are not of named users or groups.
+const UndefinedId = ^uint32(0)
+
+const (
+	headerSize = 4
+	entrySize  = 8
+)
+
+var ErrInvalid = errors.New("invalid posix acl")
+
+type Entry struct {
+	Tag  uint16
+	Perm uint16
+	Id   uint32
+}
+
+// ACL is the entries sorted by tag and id as the kernel keeps them.
+type ACL []Entry
+
+// Parse decodes and validates an ACL xattr value.
+func Parse(data []byte) (acl ACL, err error) {
+	if len(data) < headerSize || (len(data)-headerSize)%entrySize != 0 {
+		return nil, ErrInvalid
+	}
+	if binary.LittleEndian.Uint32(data) != Version {
+		return nil, ErrInvalid
+	}
+	count := (len(data) - headerSize) / entrySize
+	acl = make(ACL, 0, count)
+	for off := headerSize; off < len(data); off += entrySize {
+		e := Entry{
+			Tag:  binary.LittleEndian.Uint16(data[off:]),
+			Perm: binary.LittleEndian.Uint16(data[off+2:]),
+			Id:   binary.LittleEndian.Uint32(data[off+4:]),
+		}
+		if e.Tag != TagUser && e.Tag != TagGroup {
+			e.Id = UndefinedId
+		}
+		acl = append(acl, e)
+	}
+	if err = acl.Validate(); err != nil {
+		return nil, err
+	}
+	return acl, nil
+}
+
+// Marshal encodes the ACL into the xattr value.
+func (acl ACL) Marshal() []byte {
+	data := make([]byte, headerSize+len(acl)*entrySize)
+	binary.LittleEndian.PutUint32(data, Version)
+	off := headerSize
+	for _, e := range acl {
+		binary.LittleEndian.PutUint16(data[off:], e.Tag)
+		binary.LittleEndian.PutUint16(data[off+2:], e.Perm)
+		binary.LittleEndian.PutUint32(data[off+4:], e.Id)
+		off += entrySize
+	}
+	return data
+}
+
+// Validate checks the ACL like posix_acl_valid of the kernel, the owner, owning group
+// and other entries are required, and the mask is required if there are named entries.
+func (acl ACL) Validate() error {
+	var userObj, groupObj, mask, other, named int
+	for i, e := range acl {
+		if e.Perm&^PermRWX != 0 {
+			return ErrInvalid
+		}
+		if i > 0 && (e.Tag < acl[i-1].Tag || (e.Tag == acl[i-1].Tag && e.Id <= acl[i-1].Id)) {
+			return ErrInvalid
+		}
+		switch e.Tag {
+		case TagUserObj:
+			userObj++
+		case TagGroupObj:
+			groupObj++
+		case TagMask:
+			mask++
+		case TagOther:
+			other++
+		case TagUser, TagGroup:
+			named++
+		default:
+			return ErrInvalid
+		}
+	}
+	if userObj != 1 || groupObj != 1 || other != 1 || mask > 1 || (named > 0 && mask == 0) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// IsExtended reports whether the ACL can not be represented by the mode bits alone.
+func (acl ACL) IsExtended() bool {
+	return len(acl) > 3
+}
+
+// Check evaluates the ACL of an inode owned by owner and group for the user uid
+// within gids, following the access check algorithm of POSIX.1e.
+// Root is always allowed, except executing when no one may execute.
+func (acl ACL) Check(owner, group uint32, uid uint32, gids []uint32, want uint16) bool {
+	want &= PermRWX
+	mask := PermRWX
+	var hasMask bool
+	var ownerPerm, groupPerm, otherPerm uint16
+	for _, e := range acl {
+		switch e.Tag {
+		case TagUserObj:
+			ownerPerm = e.Perm
+		case TagGroupObj:
+			groupPerm = e.Perm
+		case TagMask:
+			mask, hasMask = e.Perm, true
+		case TagOther:
+			otherPerm = e.Perm
+		}
+	}
+	if uid == 0 {
+		// the group bits of the mode are the mask if there is one
+		if hasMask {
+			groupPerm = mask
+		}
+		return want&PermExec == 0 || (ownerPerm|groupPerm|otherPerm)&PermExec != 0
+	}
+
+	inGroup := func(gid uint32) bool {
+		for _, g := range gids {
+			if g == gid {
+				return true
+			}
+		}
+		return false
+	}
+
+	var groupFound bool
+	for _, e := range acl {
+		switch e.Tag {
+		case TagUserObj:
+			if uid == owner {
+				return e.Perm&want == want
+			}
+		case TagUser:
+			if uid == e.Id {
+				return e.Perm&mask&want == want
+			}
+		case TagGroupObj, TagGroup:
+			id := e.Id
+			if e.Tag == TagGroupObj {
+				id = group
+			}
+			if inGroup(id) {
+				groupFound = true
+				if e.Perm&mask&want == want {
+					return true
+				}
+			}
+		case TagOther:
+			if groupFound {
+				return false
+			}
+			return e.Perm&want == want
+		}
+	}
+	return false
+}
+
+// Inherit derives the access ACL and the mode of an inode created in a directory
+// with the default ACL, like posix_acl_create_masq of the kernel. The entries of
+// the owner, the owning group or mask and other are masked by the requested mode,
+// and the permission bits of the mode are masked by them in turn.
+// The access ACL is nil if the mode alone is equivalent to it.
+func (acl ACL) Inherit(mode uint32) (access ACL, newMode uint32) {
+	access = make(ACL, len(acl))
+	copy(access, acl)
+
+	perm := uint16(mode & 0o777)
+	var groupObj, maskObj *Entry
+	for i := range access {
+		e := &access[i]
+		switch e.Tag {
+		case TagUserObj:
+			e.Perm &= perm >> 6 & PermRWX
+			perm &= e.Perm<<6 | 0o077
+		case TagGroupObj:
+			groupObj = e
+		case TagMask:
+			maskObj = e
+		case TagOther:
+			e.Perm &= perm & PermRWX
+			perm &= e.Perm | 0o770
+		}
+	}
+	if maskObj != nil {
+		maskObj.Perm &= perm >> 3 & PermRWX
+		perm &= maskObj.Perm<<3 | 0o707
+	} else if groupObj != nil {
+		groupObj.Perm &= perm >> 3 & PermRWX
+		perm &= groupObj.Perm<<3 | 0o707
+	}
+
+	newMode = mode&^0o777 | uint32(perm)
+	if !access.IsExtended() {
+		access = nil
+	}
+	return
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package posixacl_test
+
+import (
+	"testing"
+
+	"github.com/cubefs/cubefs/util/posixacl"
+	"github.com/stretchr/testify/require"
+)
+
+// user::rw- user:1001:r-- group::r-x group:2001:rw- mask::r-- other::---
+var testACL = posixacl.ACL{
+	{Tag: posixacl.TagUserObj, Perm: 6, Id: posixacl.UndefinedId},
+	{Tag: posixacl.TagUser, Perm: 4, Id: 1001},
+	{Tag: posixacl.TagGroupObj, Perm: 5, Id: posixacl.UndefinedId},
+	{Tag: posixacl.TagGroup, Perm: 6, Id: 2001},
+	{Tag: posixacl.TagMask, Perm: 4, Id: posixacl.UndefinedId},
+	{Tag: posixacl.TagOther, Perm: 0, Id: posixacl.UndefinedId},
+}
+
+func TestPosixACL_Parse(t *testing.T) {
+	data := testACL.Marshal()
+	require.Equal(t, 4+8*len(testACL), len(data))
+	acl, err := posixacl.Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, testACL, acl)
+	require.True(t, acl.IsExtended())
+
+	for _, bad := range [][]byte{nil, data[:3], data[:len(data)-1]} {
+		_, err = posixacl.Parse(bad)
+		require.ErrorIs(t, err, posixacl.ErrInvalid)
+	}
+	badVersion := append([]byte{}, data...)
+	badVersion[0] = 1
+	_, err = posixacl.Parse(badVersion)
+	require.ErrorIs(t, err, posixacl.ErrInvalid)
+
+	// named entries without mask
+	_, err = posixacl.Parse(posixacl.ACL{testACL[0], testACL[1], testACL[2], testACL[5]}.Marshal())
+	require.ErrorIs(t, err, posixacl.ErrInvalid)
+	// missing other
+	_, err = posixacl.Parse(testACL[:5].Marshal())
+	require.ErrorIs(t, err, posixacl.ErrInvalid)
+	// unsorted
+	_, err = posixacl.Parse(posixacl.ACL{testACL[0], testACL[2], testACL[1], testACL[4], testACL[5]}.Marshal())
+	require.ErrorIs(t, err, posixacl.ErrInvalid)
+}
+
+func TestPosixACL_Check(t *testing.T) {
+	const owner, group = 1000, 2000
+	r, w, x := posixacl.PermRead, posixacl.PermWrite, posixacl.PermExec
+	for _, cs := range []struct {
+		uid     uint32
+		gids    []uint32
+		want    uint16
+		allowed bool
+	}{
+		{owner, nil, r | w, true},
+		{owner, nil, x, false},
+		{1001, nil, r, true},
+		{1001, nil, w, false},
+		// named group has rw but mask allows r only
+		{1002, []uint32{2001}, r, true},
+		{1002, []uint32{2001}, w, false},
+		// in the owning group, mask limits r-x to r
+		{1002, []uint32{group}, r, true},
+		{1002, []uint32{group}, x, false},
+		// a matched group denies falling back to other
+		{1002, []uint32{3000, 2001}, w, false},
+		{1002, []uint32{3000}, r, false},
+		{0, nil, r | w, true},
+		{0, nil, x, false},
+	} {
+		require.Equal(t, cs.allowed, testACL.Check(owner, group, cs.uid, cs.gids, cs.want), "%+v", cs)
+	}
+}
+
+func TestPosixACL_Inherit(t *testing.T) {
+	// default:user::rwx default:user:1001:rwx default:group::r-x default:mask::rwx default:other::r-x
+	def := posixacl.ACL{
+		{Tag: posixacl.TagUserObj, Perm: 7, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagUser, Perm: 7, Id: 1001},
+		{Tag: posixacl.TagGroupObj, Perm: 5, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagMask, Perm: 7, Id: posixacl.UndefinedId},
+		{Tag: posixacl.TagOther, Perm: 5, Id: posixacl.UndefinedId},
+	}
+	access, mode := def.Inherit(0o644)
+	require.Equal(t, uint32(0o644), mode)
+	require.Equal(t, uint16(6), access[0].Perm)
+	require.Equal(t, uint16(7), access[1].Perm)
+	require.Equal(t, uint16(5), access[2].Perm)
+	require.Equal(t, uint16(4), access[3].Perm)
+	require.Equal(t, uint16(4), access[4].Perm)
+	require.NoError(t, access.Validate())
+	// the default ACL is not changed
+	require.Equal(t, uint16(7), def[3].Perm)
+
+	access, mode = def.Inherit(0o40777)
+	require.Equal(t, uint32(0o40775), mode)
+	require.Equal(t, uint16(7), access[3].Perm)
+
+	// the mode alone is equivalent to a minimal ACL
+	access, mode = posixacl.ACL{def[0], def[2], def[4]}.Inherit(0o666)
+	require.Nil(t, access)
+	require.Equal(t, uint32(0o644), mode)
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sse
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Part is a part of a multipart object, the parts are encrypted independently.
+type Part struct {
+	Number uint16
+	Size   uint64
+	IV     []byte // nil for parts uploaded before parts had their own IV
+}
+
+// Cipher encrypts the data of an object with AES-256-CTR, so that the encrypted size equals
+// the object size and any range can be decrypted independently.
+type Cipher struct {
+	Key   []byte
+	IV    []byte
+	Parts []Part // parts of a completed multipart object, empty for other objects
+}
+
+// NewPartIV generates the IV of a part upload, uploads of the same part number must never reuse an IV.
+func NewPartIV() (iv []byte, err error) {
+	iv = make([]byte, aes.BlockSize)
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// partIV returns the IV of a part, the IV of a part without its own IV is derived from the part number,
+// the part number 0 is used by objects which are not uploaded in parts.
+func (c *Cipher) partIV(part Part) []byte {
+	iv := make([]byte, aes.BlockSize)
+	if len(part.IV) == aes.BlockSize {
+		copy(iv, part.IV)
+		return iv
+	}
+	copy(iv, c.IV)
+	iv[0] ^= byte(part.Number >> 8)
+	iv[1] ^= byte(part.Number)
+	return iv
+}
+
+// partStream returns the key stream of the part starting at the offset within the part.
+func (c *Cipher) partStream(part Part, offset uint64) cipher.Stream {
+	block, _ := aes.NewCipher(c.Key)
+	iv := c.partIV(part)
+	// move the counter to the block of the offset
+	carry := offset / aes.BlockSize
+	for i := aes.BlockSize - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(iv[i]) + carry&0xff
+		iv[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(block, iv)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream
+}
+
+// EncryptReader encrypts the data read from the reader as the part, the zero part is the whole object.
+func (c *Cipher) EncryptReader(reader io.Reader, part Part) io.Reader {
+	return &cipher.StreamReader{S: c.partStream(part, 0), R: reader}
+}
+
+// Stream returns the key stream of the whole object starting at the offset.
+func (c *Cipher) Stream(offset uint64) cipher.Stream {
+	if len(c.Parts) == 0 {
+		return c.partStream(Part{}, offset)
+	}
+	return &multipartStream{c: c, offset: offset, index: -1}
+}
+
+// DecryptWriter decrypts the object data starting at the offset before writing it to the writer.
+func (c *Cipher) DecryptWriter(writer io.Writer, offset uint64) io.Writer {
+	return &cipher.StreamWriter{S: c.Stream(offset), W: writer}
+}
+
+// DecryptReaderAt decrypts the object data read from the reader at any offset.
+func (c *Cipher) DecryptReaderAt(reader io.ReaderAt) io.ReaderAt {
+	return &decryptReaderAt{c: c, r: reader}
+}
+
+type decryptReaderAt struct {
+	c *Cipher
+	r io.ReaderAt
+}
+
+func (d *decryptReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	n, err = d.r.ReadAt(p, off)
+	if n > 0 {
+		d.c.Stream(uint64(off)).XORKeyStream(p[:n], p[:n])
+	}
+	return
+}
+
+// multipartStream switches the key stream at the boundaries of parts.
+type multipartStream struct {
+	c       *Cipher
+	offset  uint64 // offset in the object
+	index   int    // index of the current part
+	partEnd uint64 // end offset of the current part in the object
+	stream  cipher.Stream
+}
+
+func (s *multipartStream) seek() {
+	var start uint64
+	for i, part := range s.c.Parts {
+		if s.offset < start+part.Size {
+			s.index, s.partEnd = i, start+part.Size
+			s.stream = s.c.partStream(part, s.offset-start)
+			return
+		}
+		start += part.Size
+	}
+	// beyond the last part, the data is not encrypted by any part
+	s.index, s.partEnd, s.stream = len(s.c.Parts), ^uint64(0), nil
+}
+
+func (s *multipartStream) XORKeyStream(dst, src []byte) {
+	for len(src) > 0 {
+		if s.index < 0 || (s.offset >= s.partEnd && s.index < len(s.c.Parts)) {
+			s.seek()
+		}
+		n := uint64(len(src))
+		if rest := s.partEnd - s.offset; n > rest {
+			n = rest
+		}
+		if s.stream != nil {
+			s.stream.XORKeyStream(dst[:n], src[:n])
+		} else {
+			copy(dst[:n], src[:n])
+		}
+		dst, src = dst[n:], src[n:]
+		s.offset += n
+	}
+}
+
+// EncodeParts encodes the layout of the parts of a multipart object.
+func EncodeParts(parts []Part) string {
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		item := strconv.FormatUint(uint64(part.Number), 10) + ":" + strconv.FormatUint(part.Size, 10)
+		if len(part.IV) > 0 {
+			item += ":" + base64.StdEncoding.EncodeToString(part.IV)
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseParts is the reverse of EncodeParts.
+func ParseParts(raw string) (parts []Part, err error) {
+	if raw == "" {
+		return nil, nil
+	}
+	for _, item := range strings.Split(raw, ",") {
+		kv := strings.SplitN(item, ":", 3)
+		if len(kv) < 2 {
+			return nil, ErrInvalidSealedKey
+		}
+		var number, size uint64
+		if number, err = strconv.ParseUint(kv[0], 10, 16); err != nil {
+			return nil, err
+		}
+		if size, err = strconv.ParseUint(kv[1], 10, 64); err != nil {
+			return nil, err
+		}
+		part := Part{Number: uint16(number), Size: size}
+		if len(kv) == 3 {
+			if part.IV, err = base64.StdEncoding.DecodeString(kv[2]); err != nil || len(part.IV) != aes.BlockSize {
+				return nil, ErrInvalidSealedKey
+			}
+		}
+		parts = append(parts, part)
+	}
+	return
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package sse keeps the master keys and the data encryption of the server side encrypted objects,
+// it is shared by objectnode which encrypts the objects and lcnode which replicates them.
+package sse
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeySize is the size of the data keys and the master keys, AES-256 is used for both.
+const KeySize = 32
+
+var (
+	ErrMasterKeyNotFound = errors.New("master key not found")
+	ErrInvalidSealedKey  = errors.New("invalid sealed key")
+)
+
+// MasterKeyStore wraps the per-object data keys of SSE-S3 objects.
+type MasterKeyStore interface {
+	// Seal encrypts the data key with the current master key, the result carries the master key ID.
+	Seal(dataKey []byte) (sealed string, err error)
+	// Unseal decrypts the data key sealed by any known master key.
+	Unseal(sealed string) (dataKey []byte, err error)
+}
+
+// LocalKeyStoreConfig is the content of the master key file, it keeps the retired
+// master keys so that objects encrypted before a key rotation are still readable.
+// Example:
+//
+//	{
+//		"current": "key-2023",
+//		"keys": {
+//			"key-2023": "base64 encoded 32 bytes key",
+//			"key-2022": "base64 encoded 32 bytes key"
+//		}
+//	}
+type LocalKeyStoreConfig struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// localKeyStore is a file based stand-in of a key management service.
+type localKeyStore struct {
+	current string
+	aeads   map[string]cipher.AEAD
+}
+
+func LoadLocalKeyStore(filename string) (MasterKeyStore, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	config := &LocalKeyStoreConfig{}
+	if err = json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return NewLocalKeyStore(config)
+}
+
+func NewLocalKeyStore(config *LocalKeyStoreConfig) (MasterKeyStore, error) {
+	if _, ok := config.Keys[config.Current]; !ok {
+		return nil, fmt.Errorf("current master key(%v) not found", config.Current)
+	}
+	ks := &localKeyStore{current: config.Current, aeads: make(map[string]cipher.AEAD)}
+	for id, raw := range config.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid master key id(%v)", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode master key(%v) fail: %v", id, err)
+		}
+		aead, err := NewKeyWrapper(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key(%v): %v", id, err)
+		}
+		ks.aeads[id] = aead
+	}
+	return ks, nil
+}
+
+func (ks *localKeyStore) Seal(dataKey []byte) (string, error) {
+	sealed, err := SealKey(ks.aeads[ks.current], dataKey)
+	if err != nil {
+		return "", err
+	}
+	return ks.current + ":" + sealed, nil
+}
+
+func (ks *localKeyStore) Unseal(sealed string) ([]byte, error) {
+	items := strings.SplitN(sealed, ":", 2)
+	if len(items) != 2 {
+		return nil, ErrInvalidSealedKey
+	}
+	aead, ok := ks.aeads[items[0]]
+	if !ok {
+		return nil, ErrMasterKeyNotFound
+	}
+	return UnsealKey(aead, items[1])
+}
+
+// NewKeyWrapper returns the AEAD sealing data keys with the key.
+func NewKeyWrapper(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key size must be %v bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealKey encrypts the data key with a random nonce.
+func SealKey(aead cipher.AEAD, dataKey []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, dataKey, nil)), nil
+}
+
+// UnsealKey is the reverse of SealKey.
+func UnsealKey(aead cipher.AEAD, sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return nil, ErrInvalidSealedKey
+	}
+	nonce, ciphertext := raw[:aead.NonceSize()], raw[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sse
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) []byte {
+	key := make([]byte, KeySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestLocalKeyStore(t *testing.T) {
+	oldKey := base64.StdEncoding.EncodeToString(newTestKey(t))
+	newKey := base64.StdEncoding.EncodeToString(newTestKey(t))
+
+	_, err := NewLocalKeyStore(&LocalKeyStoreConfig{Current: "k2", Keys: map[string]string{"k1": oldKey}})
+	require.Error(t, err)
+	_, err = NewLocalKeyStore(&LocalKeyStoreConfig{Current: "k:1", Keys: map[string]string{"k:1": oldKey}})
+	require.Error(t, err)
+	_, err = NewLocalKeyStore(&LocalKeyStoreConfig{Current: "k1", Keys: map[string]string{"k1": "short"}})
+	require.Error(t, err)
+
+	ks, err := NewLocalKeyStore(&LocalKeyStoreConfig{Current: "k1", Keys: map[string]string{"k1": oldKey}})
+	require.NoError(t, err)
+	dataKey := newTestKey(t)
+	sealed, err := ks.Seal(dataKey)
+	require.NoError(t, err)
+	unsealed, err := ks.Unseal(sealed)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unsealed)
+
+	// keys sealed by a retired master key are still readable after rotation
+	rotated, err := NewLocalKeyStore(&LocalKeyStoreConfig{Current: "k2", Keys: map[string]string{"k1": oldKey, "k2": newKey}})
+	require.NoError(t, err)
+	unsealed, err = rotated.Unseal(sealed)
+	require.NoError(t, err)
+	require.Equal(t, dataKey, unsealed)
+	resealed, err := rotated.Seal(dataKey)
+	require.NoError(t, err)
+	_, err = ks.Unseal(resealed)
+	require.Equal(t, ErrMasterKeyNotFound, err)
+	_, err = ks.Unseal("invalid")
+	require.Equal(t, ErrInvalidSealedKey, err)
+}
+
+func TestCipherDecryptReaderAt(t *testing.T) {
+	c := &Cipher{Key: newTestKey(t), IV: newTestKey(t)[:16]}
+	plaintext := make([]byte, 10000)
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	require.NoError(t, err)
+	ciphertext, err := io.ReadAll(c.EncryptReader(bytes.NewReader(plaintext), Part{}))
+	require.NoError(t, err)
+
+	reader := c.DecryptReaderAt(bytes.NewReader(ciphertext))
+	for _, offset := range []int64{0, 1, 16, 4097} {
+		decrypted, err := io.ReadAll(io.NewSectionReader(reader, offset, int64(len(plaintext))-offset))
+		require.NoError(t, err)
+		require.Equal(t, plaintext[offset:], decrypted)
+	}
+}
+
+func TestPartsEncoding(t *testing.T) {
+	iv, err := NewPartIV()
+	require.NoError(t, err)
+	parts := []Part{{Number: 1, Size: 5 << 20, IV: iv}, {Number: 10000, Size: 1}}
+	decoded, err := ParseParts(EncodeParts(parts))
+	require.NoError(t, err)
+	require.Equal(t, parts, decoded)
+
+	decoded, err = ParseParts("")
+	require.NoError(t, err)
+	require.Nil(t, decoded)
+
+	_, err = ParseParts("1:2,3")
+	require.Error(t, err)
+	_, err = ParseParts("70000:1")
+	require.Error(t, err)
+	_, err = ParseParts("1:2:YWJj")
+	require.Error(t, err)
+}